@@ -16,6 +16,7 @@ diff -u $opDeploy/cluster_role.yaml           $chartTmpl/clusterrole.yaml | diff
 diff -u $opDeploy/cluster_role_binding.yaml   $chartTmpl/clusterrolebinding.yaml | diff-so-fancy || true
 diff -u $opDeploy/service_account.yaml        $chartTmpl/serviceaccount.yaml | diff-so-fancy || true
 diff -u $opDeploy/webhook_configuration.yaml  $chartTmpl/validatingwebhookconfiguration.yaml | diff-so-fancy || true
+diff -u $opDeploy/mutating_webhook_configuration.yaml $chartTmpl/mutatingwebhookconfiguration.yaml | diff-so-fancy || true
 diff -u $opDeploy/operator.yaml               $chartTmpl/deployment.yaml | diff-so-fancy || true
 diff -u $opDeploy/webhook_service.yaml        $chartTmpl/service.yaml | diff-so-fancy || true
 diff -u $opDeploy/webhook_secret.yaml         $chartTmpl/secret.yaml | diff-so-fancy || true
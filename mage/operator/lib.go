@@ -36,6 +36,12 @@ const (
 	mermaidJsImage             = "operator-mermaid-js"
 	generatedDseDataCentersCrd = "operator/deploy/crds/cassandra.datastax.com_cassandradatacenters_crd.yaml"
 	helmChartCrd               = "charts/cass-operator-chart/templates/customresourcedefinition.yaml"
+	generatedCassandraTasksCrd = "operator/deploy/crds/cassandra.datastax.com_cassandratasks_crd.yaml"
+	helmChartCassandraTaskCrd  = "charts/cass-operator-chart/templates/cassandratask-customresourcedefinition.yaml"
+	generatedBackupsCrd        = "operator/deploy/crds/cassandra.datastax.com_cassandrabackups_crd.yaml"
+	helmChartBackupCrd         = "charts/cass-operator-chart/templates/cassandrabackup-customresourcedefinition.yaml"
+	generatedRestoresCrd       = "operator/deploy/crds/cassandra.datastax.com_cassandrarestores_crd.yaml"
+	helmChartRestoreCrd        = "charts/cass-operator-chart/templates/cassandrarestore-customresourcedefinition.yaml"
 	packagePath                = "github.com/k8ssandra/cass-operator/operator"
 	envGitBranch               = "MO_BRANCH"
 	envVersionString           = "MO_VERSION"
@@ -239,6 +245,7 @@ func doSdkGenerate() {
 	generateK8sAndOpenApi()
 	postProcessCrd()
 	patchCrdToTemplate()
+	cpTaskCrdsToChart()
 }
 
 func cpCrdToChart() {
@@ -249,6 +256,22 @@ func cpCrdToChart() {
 	mageutil.PanicOnError(err)
 }
 
+// The CRDs other than CassandraDatacenter are copied to the chart as is
+func cpTaskCrdsToChart() {
+	crds := map[string]string{
+		generatedCassandraTasksCrd: helmChartCassandraTaskCrd,
+		generatedBackupsCrd:        helmChartBackupCrd,
+		generatedRestoresCrd:       helmChartRestoreCrd,
+	}
+	for generated, chart := range crds {
+		crd, err := ioutil.ReadFile(generated)
+		mageutil.PanicOnError(err)
+
+		err = ioutil.WriteFile(chart, crd, os.ModePerm)
+		mageutil.PanicOnError(err)
+	}
+}
+
 func patchCrdToTemplate() {
 	shutil.RunVPanic("patch", generatedDseDataCentersCrd, "mage/operator/crd.patch", "-o", helmChartCrd)
 }
@@ -13,14 +13,20 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 
+	"github.com/k8ssandra/cass-operator/operator/pkg/adminapi"
 	webhook "github.com/k8ssandra/cass-operator/operator/pkg/admissionwebhook"
 	"github.com/k8ssandra/cass-operator/operator/pkg/apis"
 	"github.com/k8ssandra/cass-operator/operator/pkg/controller"
+	"github.com/k8ssandra/cass-operator/operator/pkg/orphans"
+	"github.com/k8ssandra/cass-operator/operator/pkg/probes"
+	"github.com/k8ssandra/cass-operator/operator/pkg/sharding"
+	"github.com/k8ssandra/cass-operator/operator/pkg/watchnamespace"
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	kubemetrics "github.com/operator-framework/operator-sdk/pkg/kube-metrics"
 	"github.com/operator-framework/operator-sdk/pkg/leader"
@@ -31,7 +37,6 @@ import (
 	"github.com/spf13/pflag"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	controllerRuntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -51,6 +56,17 @@ var (
 )
 var log = logf.Log.WithName("cmd")
 
+// How the replicas of the operator elect their leader
+const (
+	// The leader leads until its pod is deleted, and the other replicas wait
+	// without serving anything
+	leaderForLife = "leader-for-life"
+	// The leader renews a lease, which another replica takes over when it is
+	// not renewed in time. Every replica serves the webhooks, with the
+	// certificate the first of them generated.
+	leaderLease = "lease"
+)
+
 func printVersion() {
 	log.Info("Go Version",
 		"goVersion", runtime.Version())
@@ -86,6 +102,19 @@ func main() {
 	// controller-runtime)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 
+	leaderElectionMode := pflag.String("leader-election-mode", leaderForLife,
+		"How the replicas of the operator elect their leader, "+leaderForLife+" or "+leaderLease)
+	leaderElectionNamespace := pflag.String("leader-election-namespace", "",
+		"The namespace of the lock of the "+leaderLease+" leader election, the namespace of the operator by default")
+	leaseDuration := pflag.Duration("leader-election-lease-duration", 15*time.Second,
+		"How long the replicas wait for the leader to renew its lease before taking it over")
+	renewDeadline := pflag.Duration("leader-election-renew-deadline", 10*time.Second,
+		"How long the leader retries renewing its lease before giving up leading")
+	retryPeriod := pflag.Duration("leader-election-retry-period", 2*time.Second,
+		"How often the replicas try to acquire or renew the lease")
+	healthProbeBindAddress := pflag.String("health-probe-bind-address", "",
+		"The address the /healthz, /readyz and /leader probes are served on, such as :8081, none when empty")
+
 	pflag.Parse()
 
 	// Use a zap logr.Logger implementation. If none of the zap
@@ -106,6 +135,43 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Namespaces are selected by their labels among all the namespaces, as the
+	// cache of a single namespace or a list of namespaces cannot hold Namespaces
+	if selector, err := watchnamespace.GetSelector(); err != nil {
+		log.Error(err, "Failed to get watch namespace selector")
+		os.Exit(1)
+	} else if selector != nil && namespace != "" {
+		log.Error(nil, "WATCH_NAMESPACE must be all namespaces when WATCH_NAMESPACE_SELECTOR is set")
+		os.Exit(1)
+	}
+
+	if *leaderElectionMode != leaderForLife && *leaderElectionMode != leaderLease {
+		log.Error(nil, "--leader-election-mode must be "+leaderForLife+" or "+leaderLease, "mode", *leaderElectionMode)
+		os.Exit(1)
+	}
+
+	// Each shard of the datacenters elects a leader of its own
+	shard, err := sharding.FromEnv()
+	if err != nil {
+		log.Error(err, "Failed to get the shard of the operator")
+		os.Exit(1)
+	}
+	lockName := shard.LockName("cass-operator-lock")
+	log.Info("Managing the datacenters of shard " + shard.String())
+
+	stop := signals.SetupSignalHandler()
+
+	// The probes are served by every replica, leading or not
+	probeServer := probes.NewServer(*healthProbeBindAddress, *leaderElectionMode == leaderLease)
+	if *healthProbeBindAddress != "" {
+		go func() {
+			if err := probeServer.Start(stop); err != nil {
+				log.Error(err, "probe server exited")
+				os.Exit(1)
+			}
+		}()
+	}
+
 	// Get a config to talk to the apiserver
 	cfg, err := config.GetConfig()
 	if err != nil {
@@ -124,12 +190,14 @@ func main() {
 	defer readyFile.Unset()
 
 	ctx := context.Background()
-	// Become the leader before proceeding
-	err = leader.Become(ctx, "cass-operator-lock")
+	// Become the leader before proceeding, unless the manager elects it
+	if *leaderElectionMode == leaderForLife {
+		err = leader.Become(ctx, lockName)
 
-	if err != nil {
-		log.Error(err, "could not become leader")
-		os.Exit(1)
+		if err != nil {
+			log.Error(err, "could not become leader")
+			os.Exit(1)
+		}
 	}
 
 	if err = webhook.EnsureWebhookConfigVolume(cfg); err != nil {
@@ -144,12 +212,21 @@ func main() {
 		log.Error(err, "Failed to read base OS into env")
 	}
 
-	// Set default manager options
+	// Set default manager options. The webhooks are served by a server of
+	// their own, below.
 	options := manager.Options{
 		Namespace:          namespace,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
-		CertDir:            certDir,
-		Port:               8443,
+	}
+
+	// The manager only starts the controllers on the leader
+	if *leaderElectionMode == leaderLease {
+		options.LeaderElection = true
+		options.LeaderElectionID = lockName
+		options.LeaderElectionNamespace = *leaderElectionNamespace
+		options.LeaseDuration = leaseDuration
+		options.RenewDeadline = renewDeadline
+		options.RetryPeriod = retryPeriod
 	}
 
 	// Add support for MultiNamespace set in WATCH_NAMESPACE (e.g ns1,ns2)
@@ -182,6 +259,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := mgr.Add(probeServer.LeaderRunnable()); err != nil {
+		log.Error(err, "unable to add leader probe")
+		os.Exit(1)
+	}
+
 	skipWebhookEnvVal := os.Getenv("SKIP_VALIDATING_WEBHOOK")
 	if skipWebhookEnvVal == "" {
 		skipWebhookEnvVal = "FALSE"
@@ -193,9 +275,44 @@ func main() {
 	}
 
 	if !skipWebhook {
-		err = controllerRuntime.NewWebhookManagedBy(mgr).For(&api.CassandraDatacenter{}).Complete()
+		// Also serves the defaulting of new CassandraDatacenters and their
+		// conversion between v1beta1 and v1, on every replica
+		webhookServer := webhook.NewServer(8443, certDir)
+		webhookServer.RegisterDatacenterWebhooks(&api.CassandraDatacenter{})
+		if err := mgr.Add(webhookServer); err != nil {
+			log.Error(err, "unable to create validating, defaulting and conversion webhooks for CassandraDatacenter")
+			os.Exit(1)
+		}
+	}
+
+	// The admin API is only served when an address to bind it to is set
+	if adminApiAddress := os.Getenv("ADMIN_API_BIND_ADDRESS"); adminApiAddress != "" {
+		if err := mgr.Add(adminapi.NewServer(mgr, adminApiAddress, certDir)); err != nil {
+			log.Error(err, "unable to add admin API server")
+			os.Exit(1)
+		}
+	}
+
+	// The sweep of orphaned resources only runs when a mode is set
+	if orphanGc := os.Getenv("ORPHAN_GC"); orphanGc != "" {
+		mode, err := orphans.ParseMode(orphanGc)
 		if err != nil {
-			log.Error(err, "unable to create validating webhook for CassandraDatacenter")
+			log.Error(err, "bad value for ORPHAN_GC env")
+			os.Exit(1)
+		}
+		interval := orphans.DefaultInterval
+		if intervalEnvVal := os.Getenv("ORPHAN_GC_INTERVAL"); intervalEnvVal != "" {
+			if interval, err = time.ParseDuration(intervalEnvVal); err != nil || interval <= 0 {
+				log.Error(err, "bad value for ORPHAN_GC_INTERVAL env")
+				os.Exit(1)
+			}
+		}
+		var namespaces []string
+		if namespace != "" {
+			namespaces = strings.Split(namespace, ",")
+		}
+		if err := mgr.Add(orphans.NewSweeper(mgr, mode, interval, namespaces, shard)); err != nil {
+			log.Error(err, "unable to add orphaned resource sweeper")
 			os.Exit(1)
 		}
 	}
@@ -206,7 +323,7 @@ func main() {
 	log.Info("Starting the Cmd.")
 
 	// Start the Cmd
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(stop); err != nil {
 		log.Error(err, "Manager exited non-zero")
 		os.Exit(1)
 	}
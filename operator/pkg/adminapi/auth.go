@@ -0,0 +1,80 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package adminapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+var (
+	errUnauthenticated = errors.New("invalid or missing bearer token")
+	errForbidden       = errors.New("not allowed")
+)
+
+// Authorizer checks that the holder of a bearer token may perform verb on the
+// CassandraDatacenters of a namespace, or of every namespace if namespace is
+// empty. It returns the name of the user on success.
+type Authorizer interface {
+	Authorize(ctx context.Context, token, verb, namespace string) (string, error)
+}
+
+// kubeAuthorizer authenticates tokens with a TokenReview and then checks the
+// RBAC permissions of their user with a SubjectAccessReview, so access to the
+// admin API follows access to the CassandraDatacenter resources.
+type kubeAuthorizer struct {
+	client client.Client
+}
+
+func (a *kubeAuthorizer) Authorize(ctx context.Context, token, verb, namespace string) (string, error) {
+	if token == "" {
+		return "", errUnauthenticated
+	}
+
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}
+	if err := a.client.Create(ctx, review); err != nil {
+		return "", fmt.Errorf("failed to review token: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return "", errUnauthenticated
+	}
+
+	user := review.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+
+	access := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Group:     api.SchemeGroupVersion.Group,
+				Resource:  "cassandradatacenters",
+			},
+		},
+	}
+	if err := a.client.Create(ctx, access); err != nil {
+		return "", fmt.Errorf("failed to review access: %w", err)
+	}
+	if !access.Status.Allowed {
+		return user.Username, errForbidden
+	}
+
+	return user.Username, nil
+}
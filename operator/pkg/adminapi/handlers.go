@@ -0,0 +1,225 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package adminapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+// DatacenterSummary is the admin API view of a CassandraDatacenter
+type DatacenterSummary struct {
+	Namespace     string                    `json:"namespace"`
+	Name          string                    `json:"name"`
+	ClusterName   string                    `json:"clusterName"`
+	ServerType    string                    `json:"serverType"`
+	ServerVersion string                    `json:"serverVersion"`
+	Size          int32                     `json:"size"`
+	Stopped       bool                      `json:"stopped"`
+	Paused        bool                      `json:"paused"`
+	Progress      api.ProgressState         `json:"progress,omitempty"`
+	Conditions    []api.DatacenterCondition `json:"conditions,omitempty"`
+}
+
+// PodStatus is the admin API view of a pod of a CassandraDatacenter. The
+// ring fields are empty when no node of the datacenter could be asked.
+type PodStatus struct {
+	Name      string `json:"name"`
+	Rack      string `json:"rack"`
+	IP        string `json:"ip,omitempty"`
+	NodeState string `json:"nodeState,omitempty"`
+	Ready     bool   `json:"ready"`
+	HostID    string `json:"hostId,omitempty"`
+
+	// Gossip status of the node, such as NORMAL or LEAVING
+	Status  string `json:"status,omitempty"`
+	IsAlive string `json:"isAlive,omitempty"`
+	Load    string `json:"load,omitempty"`
+}
+
+func summarize(dc *api.CassandraDatacenter) DatacenterSummary {
+	return DatacenterSummary{
+		Namespace:     dc.Namespace,
+		Name:          dc.Name,
+		ClusterName:   dc.Spec.ClusterName,
+		ServerType:    dc.Spec.ServerType,
+		ServerVersion: dc.Spec.ServerVersion,
+		Size:          dc.Spec.Size,
+		Stopped:       dc.Spec.Stopped,
+		Paused:        dc.IsReconciliationPaused(),
+		Progress:      dc.Status.CassandraOperatorProgress,
+		Conditions:    dc.Status.Conditions,
+	}
+}
+
+func (s *Server) listDatacenters(w http.ResponseWriter, r *http.Request, namespace string) {
+	dcList := &api.CassandraDatacenterList{}
+	if err := s.client.List(r.Context(), dcList, client.InNamespace(namespace)); err != nil {
+		log.Error(err, "error listing CassandraDatacenters")
+		writeError(w, http.StatusInternalServerError, "failed to list datacenters")
+		return
+	}
+
+	summaries := make([]DatacenterSummary, 0, len(dcList.Items))
+	for i := range dcList.Items {
+		summaries = append(summaries, summarize(&dcList.Items[i]))
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Namespace != summaries[j].Namespace {
+			return summaries[i].Namespace < summaries[j].Namespace
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// getDatacenter fetches a CassandraDatacenter, and writes the error response
+// if that fails
+func (s *Server) getDatacenter(w http.ResponseWriter, r *http.Request, namespace, name string) *api.CassandraDatacenter {
+	dc := &api.CassandraDatacenter{}
+	err := s.client.Get(r.Context(), types.NamespacedName{Namespace: namespace, Name: name}, dc)
+	if errors.IsNotFound(err) {
+		writeError(w, http.StatusNotFound, "datacenter not found")
+		return nil
+	} else if err != nil {
+		log.Error(err, "error getting CassandraDatacenter", "namespace", namespace, "name", name)
+		writeError(w, http.StatusInternalServerError, "failed to get datacenter")
+		return nil
+	}
+	return dc
+}
+
+func (s *Server) getPods(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	dc := s.getDatacenter(w, r, namespace, name)
+	if dc == nil {
+		return
+	}
+
+	podList := &corev1.PodList{}
+	listOptions := []client.ListOption{
+		client.InNamespace(dc.Namespace),
+		client.MatchingLabels(dc.GetDatacenterLabels()),
+	}
+	if err := s.client.List(r.Context(), podList, listOptions...); err != nil {
+		log.Error(err, "error listing pods", "namespace", namespace, "name", name)
+		writeError(w, http.StatusInternalServerError, "failed to list pods")
+		return
+	}
+
+	endpoints := s.ringEndpoints(r, dc, podList.Items)
+
+	statuses := make([]PodStatus, 0, len(podList.Items))
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		status := PodStatus{
+			Name:      pod.Name,
+			Rack:      pod.Labels[api.RackLabel],
+			IP:        pod.Status.PodIP,
+			NodeState: pod.Labels[api.CassNodeState],
+			Ready:     utils.IsCassandraContainerReady(pod),
+			HostID:    dc.Status.NodeStatuses[pod.Name].HostID,
+		}
+		if endpoint, ok := endpoints[pod.Status.PodIP]; ok && pod.Status.PodIP != "" {
+			status.Status = endpoint.Status
+			status.IsAlive = endpoint.IsAlive
+			status.Load = endpoint.Load
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// ringEndpoints asks the first ready pod for the state of the ring, and
+// returns it by the address of each node
+func (s *Server) ringEndpoints(r *http.Request, dc *api.CassandraDatacenter, pods []corev1.Pod) map[string]httphelper.EndpointState {
+	endpoints := make(map[string]httphelper.EndpointState)
+
+	mgmtClient, err := s.newMgmtClient(r.Context(), s.client, dc, log)
+	if err != nil {
+		log.Error(err, "error creating management API client", "namespace", dc.Namespace, "name", dc.Name)
+		return endpoints
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		if !utils.IsCassandraContainerReady(pod) {
+			continue
+		}
+
+		metadata, err := mgmtClient.CallMetadataEndpointsEndpoint(pod)
+		if err != nil || len(metadata.Entity) == 0 {
+			continue
+		}
+
+		for _, endpoint := range metadata.Entity {
+			endpoints[endpoint.GetRpcAddress()] = endpoint
+		}
+		break
+	}
+
+	return endpoints
+}
+
+func (s *Server) updateDatacenter(w http.ResponseWriter, r *http.Request, namespace, name, action string) {
+	dc := s.getDatacenter(w, r, namespace, name)
+	if dc == nil {
+		return
+	}
+
+	patch := client.MergeFrom(dc.DeepCopy())
+
+	switch action {
+	case "restart":
+		// An optional body limits the restart like spec.rollingRestart does.
+		// Without one, or with an empty one, the whole datacenter restarts
+		// and the scope of an earlier restart is cleared.
+		var config *api.RollingRestartConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil && err != io.EOF {
+			writeError(w, http.StatusBadRequest, "invalid rolling restart: "+err.Error())
+			return
+		}
+		if config != nil && reflect.DeepEqual(*config, api.RollingRestartConfig{}) {
+			config = nil
+		}
+		dc.Spec.RollingRestartRequested = true
+		dc.Spec.RollingRestart = config
+	case "pause":
+		if dc.Annotations == nil {
+			dc.Annotations = make(map[string]string)
+		}
+		dc.Annotations[api.PausedAnnotation] = "true"
+	case "resume":
+		delete(dc.Annotations, api.PausedAnnotation)
+	}
+
+	if err := s.client.Patch(r.Context(), dc, patch); err != nil {
+		if errors.IsInvalid(err) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error(err, "error patching CassandraDatacenter", "namespace", namespace, "name", name, "action", action)
+		writeError(w, http.StatusInternalServerError, "failed to "+action+" datacenter")
+		return
+	}
+
+	log.Info("Updated CassandraDatacenter from admin API", "namespace", namespace, "name", name, "action", action)
+	writeJSON(w, http.StatusAccepted, summarize(dc))
+}
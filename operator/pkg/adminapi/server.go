@@ -0,0 +1,180 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package adminapi serves a REST API from the operator to list the
+// CassandraDatacenters it manages, look at the state and the stats of their
+// pods, and request rolling restarts or pause their reconciliation, so
+// dashboards can be built without kubectl access.
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+)
+
+var log = logf.Log.WithName("adminapi")
+
+// Server is the admin API server. It is added to the manager, which starts it
+// and stops it with the rest of the operator.
+type Server struct {
+	client      client.Client
+	authorizer  Authorizer
+	bindAddress string
+	certDir     string
+
+	// Overridden in tests, where there is no management API to talk to
+	newMgmtClient func(ctx context.Context, client client.Client, dc *api.CassandraDatacenter, logger logr.Logger) (httphelper.NodeMgmtClient, error)
+}
+
+// blank assignment to verify that Server implements manager.Runnable
+var _ manager.Runnable = &Server{}
+
+// NewServer creates an admin API server listening on bindAddress. It serves
+// TLS with the tls.crt and tls.key files of certDir, and refuses to start
+// without them since the requests carry bearer tokens.
+func NewServer(mgr manager.Manager, bindAddress string, certDir string) *Server {
+	return &Server{
+		client:        mgr.GetClient(),
+		authorizer:    &kubeAuthorizer{client: mgr.GetClient()},
+		bindAddress:   bindAddress,
+		certDir:       certDir,
+		newMgmtClient: httphelper.NewMgmtClient,
+	}
+}
+
+// Start serves the admin API until stop is closed
+func (s *Server) Start(stop <-chan struct{}) error {
+	if s.certDir == "" {
+		return fmt.Errorf("the admin API is only served over TLS, and no certificate directory is set")
+	}
+
+	server := &http.Server{
+		Addr:    s.bindAddress,
+		Handler: s,
+	}
+
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Error(err, "error shutting down admin API server")
+		}
+	}()
+
+	log.Info("Starting admin API server", "bindAddress", s.bindAddress, "certDir", s.certDir)
+
+	err := server.ListenAndServeTLS(filepath.Join(s.certDir, "tls.crt"), filepath.Join(s.certDir, "tls.key"))
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// ServeHTTP routes the requests of the admin API:
+//
+//	GET  /api/v1/datacenters[?namespace=<namespace>]
+//	GET  /api/v1/namespaces/<namespace>/datacenters/<name>/pods
+//	GET  /api/v1/namespaces/<namespace>/datacenters/<name>/stats
+//	POST /api/v1/namespaces/<namespace>/datacenters/<name>/restart
+//	POST /api/v1/namespaces/<namespace>/datacenters/<name>/pause
+//	POST /api/v1/namespaces/<namespace>/datacenters/<name>/resume
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	if len(parts) == 3 && parts[0] == "api" && parts[1] == "v1" && parts[2] == "datacenters" {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		namespace := r.URL.Query().Get("namespace")
+		if s.authorize(w, r, "list", namespace) {
+			s.listDatacenters(w, r, namespace)
+		}
+		return
+	}
+
+	if len(parts) != 7 || parts[0] != "api" || parts[1] != "v1" || parts[2] != "namespaces" || parts[4] != "datacenters" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	namespace, name, action := parts[3], parts[5], parts[6]
+	switch action {
+	case "pods":
+		if allowMethod(w, r, http.MethodGet) && s.authorize(w, r, "get", namespace) {
+			s.getPods(w, r, namespace, name)
+		}
+	case "stats":
+		if allowMethod(w, r, http.MethodGet) && s.authorize(w, r, "get", namespace) {
+			s.getStats(w, r, namespace, name)
+		}
+	case "restart", "pause", "resume":
+		if allowMethod(w, r, http.MethodPost) && s.authorize(w, r, "patch", namespace) {
+			s.updateDatacenter(w, r, namespace, name, action)
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// authorize checks the bearer token of the request, and writes the error
+// response if it does not grant verb on the CassandraDatacenters of namespace
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, verb, namespace string) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == r.Header.Get("Authorization") {
+		token = ""
+	}
+
+	user, err := s.authorizer.Authorize(r.Context(), token, verb, namespace)
+	switch err {
+	case nil:
+		log.Info("admin API request", "user", user, "method", r.Method, "path", r.URL.Path)
+		return true
+	case errUnauthenticated:
+		writeError(w, http.StatusUnauthorized, err.Error())
+	case errForbidden:
+		writeError(w, http.StatusForbidden, "user "+user+" may not "+verb+" cassandradatacenters")
+	default:
+		log.Error(err, "error authorizing admin API request")
+		writeError(w, http.StatusInternalServerError, "failed to authorize request")
+	}
+	return false
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return false
+	}
+	return true
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Error(err, "error writing admin API response")
+	}
+}
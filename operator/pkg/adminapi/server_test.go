@@ -0,0 +1,298 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+// fakeAuthorizer lets the token "admin" do anything, and the token "viewer"
+// only get and list
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) Authorize(ctx context.Context, token, verb, namespace string) (string, error) {
+	switch token {
+	case "admin":
+		return "admin", nil
+	case "viewer":
+		if verb == "get" || verb == "list" {
+			return "viewer", nil
+		}
+		return "viewer", errForbidden
+	}
+	return "", errUnauthenticated
+}
+
+func setupServer(objects ...runtime.Object) *Server {
+	s := scheme.Scheme
+	s.AddKnownTypes(api.SchemeGroupVersion, &api.CassandraDatacenter{}, &api.CassandraDatacenterList{})
+
+	return &Server{
+		client:     fake.NewFakeClientWithScheme(s, objects...),
+		authorizer: fakeAuthorizer{},
+		newMgmtClient: func(ctx context.Context, client client.Client, dc *api.CassandraDatacenter, logger logr.Logger) (httphelper.NodeMgmtClient, error) {
+			mockHttpClient := &mocks.HttpClient{}
+			mockHttpClient.On("Do",
+				mock.MatchedBy(
+					func(req *http.Request) bool {
+						return req.URL.Path == "/api/v0/metadata/endpoints"
+					})).
+				Return(&http.Response{
+					StatusCode: http.StatusOK,
+					Body: ioutil.NopCloser(strings.NewReader(
+						`{"entity": [{"RPC_ADDRESS": "10.0.0.1", "IS_ALIVE": "true", "STATUS": "NORMAL,1", "LOAD": "1024"}]}`)),
+				}, nil)
+			return httphelper.NodeMgmtClient{Client: mockHttpClient, Log: logger, Protocol: "http"}, nil
+		},
+	}
+}
+
+func newDatacenter(namespace, name string) *api.CassandraDatacenter {
+	return &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "cluster1",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			Size:          1,
+		},
+	}
+}
+
+func serve(server *Server, method, path, token, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestServer_Unauthenticated(t *testing.T) {
+	server := setupServer()
+
+	response := serve(server, http.MethodGet, "/api/v1/datacenters", "", "")
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+
+	response = serve(server, http.MethodGet, "/api/v1/datacenters", "bogus", "")
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestServer_Forbidden(t *testing.T) {
+	server := setupServer(newDatacenter("ns1", "dc1"))
+
+	response := serve(server, http.MethodPost, "/api/v1/namespaces/ns1/datacenters/dc1/pause", "viewer", "")
+	assert.Equal(t, http.StatusForbidden, response.Code)
+}
+
+func TestServer_NotFound(t *testing.T) {
+	server := setupServer()
+
+	response := serve(server, http.MethodGet, "/api/v1/unknown", "admin", "")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = serve(server, http.MethodPost, "/api/v1/namespaces/ns1/datacenters/missing/restart", "admin", "")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestServer_MethodNotAllowed(t *testing.T) {
+	server := setupServer(newDatacenter("ns1", "dc1"))
+
+	response := serve(server, http.MethodGet, "/api/v1/namespaces/ns1/datacenters/dc1/restart", "admin", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+}
+
+func TestServer_ListDatacenters(t *testing.T) {
+	server := setupServer(newDatacenter("ns2", "dc2"), newDatacenter("ns1", "dc1"))
+
+	response := serve(server, http.MethodGet, "/api/v1/datacenters", "viewer", "")
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var summaries []DatacenterSummary
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &summaries))
+	assert.Len(t, summaries, 2)
+	assert.Equal(t, "dc1", summaries[0].Name)
+	assert.Equal(t, "cluster1", summaries[0].ClusterName)
+	assert.Equal(t, "dc2", summaries[1].Name)
+
+	response = serve(server, http.MethodGet, "/api/v1/datacenters?namespace=ns2", "viewer", "")
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &summaries))
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, "dc2", summaries[0].Name)
+}
+
+func TestServer_PauseAndResume(t *testing.T) {
+	server := setupServer(newDatacenter("ns1", "dc1"))
+	key := types.NamespacedName{Namespace: "ns1", Name: "dc1"}
+	dc := &api.CassandraDatacenter{}
+
+	response := serve(server, http.MethodPost, "/api/v1/namespaces/ns1/datacenters/dc1/pause", "admin", "")
+	assert.Equal(t, http.StatusAccepted, response.Code)
+	assert.NoError(t, server.client.Get(context.Background(), key, dc))
+	assert.True(t, dc.IsReconciliationPaused())
+
+	response = serve(server, http.MethodPost, "/api/v1/namespaces/ns1/datacenters/dc1/resume", "admin", "")
+	assert.Equal(t, http.StatusAccepted, response.Code)
+	assert.NoError(t, server.client.Get(context.Background(), key, dc))
+	assert.False(t, dc.IsReconciliationPaused())
+}
+
+func TestServer_Restart(t *testing.T) {
+	server := setupServer(newDatacenter("ns1", "dc1"))
+	key := types.NamespacedName{Namespace: "ns1", Name: "dc1"}
+	dc := &api.CassandraDatacenter{}
+
+	response := serve(server, http.MethodPost, "/api/v1/namespaces/ns1/datacenters/dc1/restart", "admin",
+		`{"racks": ["r1"], "maxUnavailablePerRack": 2}`)
+	assert.Equal(t, http.StatusAccepted, response.Code)
+	assert.NoError(t, server.client.Get(context.Background(), key, dc))
+	assert.True(t, dc.Spec.RollingRestartRequested)
+	assert.Equal(t, []string{"r1"}, dc.Spec.RollingRestart.Racks)
+	assert.Equal(t, 2, dc.Spec.RollingRestart.GetMaxUnavailablePerRack())
+
+	for _, body := range []string{"", "{}"} {
+		dc.Spec.RollingRestartRequested = false
+		dc.Spec.RollingRestart = &api.RollingRestartConfig{Racks: []string{"r1"}}
+		assert.NoError(t, server.client.Update(context.Background(), dc))
+
+		response = serve(server, http.MethodPost, "/api/v1/namespaces/ns1/datacenters/dc1/restart", "admin", body)
+		assert.Equal(t, http.StatusAccepted, response.Code)
+		assert.NoError(t, server.client.Get(context.Background(), key, dc))
+		assert.True(t, dc.Spec.RollingRestartRequested)
+		assert.Nil(t, dc.Spec.RollingRestart, "the body %q restarts the whole datacenter", body)
+	}
+
+	response = serve(server, http.MethodPost, "/api/v1/namespaces/ns1/datacenters/dc1/restart", "admin", "{")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestServer_StartWithoutTLS(t *testing.T) {
+	server := setupServer()
+	server.bindAddress = "127.0.0.1:0"
+
+	stop := make(chan struct{})
+	defer close(stop)
+	assert.Error(t, server.Start(stop), "the admin API is not served without a certificate")
+}
+
+func TestServer_GetPods(t *testing.T) {
+	dc := newDatacenter("ns1", "dc1")
+	dc.Status.NodeStatuses = api.CassandraStatusMap{"pod-1": {HostID: "host-1"}}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "pod-1",
+			Labels: map[string]string{
+				api.ClusterLabel:    "cluster1",
+				api.DatacenterLabel: "dc1",
+				api.RackLabel:       "r1",
+				api.CassNodeState:   "Started",
+			},
+		},
+		Status: corev1.PodStatus{
+			PodIP:             "10.0.0.1",
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+		},
+	}
+	server := setupServer(dc, pod)
+
+	response := serve(server, http.MethodGet, "/api/v1/namespaces/ns1/datacenters/dc1/pods", "viewer", "")
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var statuses []PodStatus
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &statuses))
+	assert.Equal(t, []PodStatus{{
+		Name:      "pod-1",
+		Rack:      "r1",
+		IP:        "10.0.0.1",
+		NodeState: "Started",
+		Ready:     true,
+		HostID:    "host-1",
+		Status:    "NORMAL,1",
+		IsAlive:   "true",
+		Load:      "1024",
+	}}, statuses)
+}
+
+func TestServer_GetStats(t *testing.T) {
+	dc := newDatacenter("ns1", "dc1")
+	labels := map[string]string{
+		api.ClusterLabel:    "cluster1",
+		api.DatacenterLabel: "dc1",
+		api.RackLabel:       "r1",
+	}
+	newPod := func(name string, ready bool) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name, Labels: labels},
+			Status: corev1.PodStatus{
+				PodIP:             "10.0.0.1",
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: ready}},
+			},
+		}
+	}
+	server := setupServer(dc, newPod("pod-2", true), newPod("pod-1", true), newPod("pod-3", false))
+	server.newMgmtClient = func(ctx context.Context, client client.Client, dc *api.CassandraDatacenter, logger logr.Logger) (httphelper.NodeMgmtClient, error) {
+		mockHttpClient := &mocks.HttpClient{}
+		mockHttpClient.On("Do", mock.Anything).
+			Return(func(req *http.Request) *http.Response {
+				if req.Context().Value(requestKey{}) == nil {
+					return &http.Response{StatusCode: http.StatusInternalServerError, Body: ioutil.NopCloser(strings.NewReader(""))}
+				}
+				bodies := map[string]string{
+					"/api/v0/ops/tables/compactions": `[]`,
+					"/api/v0/ops/node/threadpools":   `[{"name": "MutationStage", "pending_tasks": 0}]`,
+				}
+				body, ok := bodies[req.URL.Path]
+				if !ok {
+					return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(strings.NewReader(""))}
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(body))}
+			}, nil)
+		return httphelper.NodeMgmtClient{Client: mockHttpClient, Log: logger, Protocol: "http", Ctx: ctx}, nil
+	}
+
+	// The nodes are called with the context of the request
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/ns1/datacenters/dc1/stats", nil)
+	request = request.WithContext(context.WithValue(request.Context(), requestKey{}, true))
+	request.Header.Set("Authorization", "Bearer viewer")
+	response := httptest.NewRecorder()
+	server.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var stats []NodeStats
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &stats))
+	assert.Len(t, stats, 2, "pods that are not ready are not asked")
+	assert.Equal(t, "pod-1", stats[0].Name)
+	assert.Equal(t, "r1", stats[0].Rack)
+	assert.JSONEq(t, `[]`, string(stats[0].Compactions))
+	assert.JSONEq(t, `[{"name": "MutationStage", "pending_tasks": 0}]`, string(stats[1].ThreadPools))
+	assert.Empty(t, stats[1].PendingHints)
+	assert.Len(t, stats[1].Errors, 1)
+	assert.Equal(t, "failed to read pending hints: not served by the management API of the node", stats[1].Errors[0])
+}
+
+type requestKey struct{}
@@ -0,0 +1,130 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package adminapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+// NodeStats is the admin API view of the virtual tables of a node of a
+// CassandraDatacenter, as the management API of the node answers with them.
+// The stats that could not be read are left out and their errors listed.
+type NodeStats struct {
+	Name string `json:"name"`
+	Rack string `json:"rack"`
+
+	Compactions  json.RawMessage `json:"compactions,omitempty"`
+	PendingHints json.RawMessage `json:"pendingHints,omitempty"`
+	ThreadPools  json.RawMessage `json:"threadPools,omitempty"`
+
+	Errors []string `json:"errors,omitempty"`
+}
+
+type statsEndpoint struct {
+	name string
+	call func(client *httphelper.NodeMgmtClient, pod *corev1.Pod) (string, error)
+	set  func(stats *NodeStats, value json.RawMessage)
+}
+
+var statsEndpoints = []statsEndpoint{
+	{
+		name: "compactions",
+		call: (*httphelper.NodeMgmtClient).CallCompactionsEndpoint,
+		set:  func(stats *NodeStats, value json.RawMessage) { stats.Compactions = value },
+	},
+	{
+		name: "pending hints",
+		call: (*httphelper.NodeMgmtClient).CallPendingHintsEndpoint,
+		set:  func(stats *NodeStats, value json.RawMessage) { stats.PendingHints = value },
+	},
+	{
+		name: "thread pools",
+		call: (*httphelper.NodeMgmtClient).CallThreadPoolsEndpoint,
+		set:  func(stats *NodeStats, value json.RawMessage) { stats.ThreadPools = value },
+	},
+}
+
+// getStats asks every ready pod of the datacenter for its compactions in
+// progress, pending hints and thread pools, in parallel, so dashboards get
+// the stats of the whole datacenter with one request. The calls are canceled
+// with the request.
+func (s *Server) getStats(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	dc := s.getDatacenter(w, r, namespace, name)
+	if dc == nil {
+		return
+	}
+
+	podList := &corev1.PodList{}
+	listOptions := []client.ListOption{
+		client.InNamespace(dc.Namespace),
+		client.MatchingLabels(dc.GetDatacenterLabels()),
+	}
+	if err := s.client.List(r.Context(), podList, listOptions...); err != nil {
+		log.Error(err, "error listing pods", "namespace", namespace, "name", name)
+		writeError(w, http.StatusInternalServerError, "failed to list pods")
+		return
+	}
+
+	mgmtClient, err := s.newMgmtClient(r.Context(), s.client, dc, log)
+	if err != nil {
+		log.Error(err, "error creating management API client", "namespace", namespace, "name", name)
+		writeError(w, http.StatusInternalServerError, "failed to create management API client")
+		return
+	}
+
+	var pods []*corev1.Pod
+	for i := range podList.Items {
+		if utils.IsCassandraContainerReady(&podList.Items[i]) {
+			pods = append(pods, &podList.Items[i])
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+
+	stats := make([]NodeStats, len(pods))
+	var wg sync.WaitGroup
+	for i, pod := range pods {
+		stats[i] = NodeStats{Name: pod.Name, Rack: pod.Labels[api.RackLabel]}
+		wg.Add(1)
+		go func(pod *corev1.Pod, nodeStats *NodeStats) {
+			defer wg.Done()
+			readNodeStats(&mgmtClient, pod, nodeStats)
+		}(pod, &stats[i])
+	}
+	wg.Wait()
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func readNodeStats(mgmtClient *httphelper.NodeMgmtClient, pod *corev1.Pod, stats *NodeStats) {
+	for _, endpoint := range statsEndpoints {
+		body, err := endpoint.call(mgmtClient, pod)
+		if mgmtapi.IsNotSupported(err) {
+			// Older management APIs do not serve every stat
+			stats.Errors = append(stats.Errors, "failed to read "+endpoint.name+": not served by the management API of the node")
+			continue
+		}
+		if err != nil {
+			stats.Errors = append(stats.Errors, "failed to read "+endpoint.name+": "+err.Error())
+			continue
+		}
+		if !json.Valid([]byte(body)) {
+			stats.Errors = append(stats.Errors, "failed to read "+endpoint.name+": invalid JSON")
+			continue
+		}
+		endpoint.set(stats, json.RawMessage(body))
+	}
+}
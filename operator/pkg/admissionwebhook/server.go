@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+// The paths of the webhooks of CassandraDatacenters, which the webhook
+// configurations and the CRD call
+const (
+	DefaultingPath = "/mutate-cassandra-datastax-com-v1beta1-cassandradatacenter"
+	ValidatingPath = "/validate-cassandra-datastax-com-v1beta1-cassandradatacenter"
+	ConversionPath = "/convert"
+)
+
+// Server serves the webhooks on every replica of the operator. The webhook
+// server of the manager of controller-runtime v0.5 only runs on the leader,
+// which leaves the webhook service sending requests to standby replicas that
+// do not answer them.
+type Server struct {
+	*webhook.Server
+}
+
+// blank assignment to verify that Server runs without leading
+var _ manager.LeaderElectionRunnable = &Server{}
+
+// NewServer creates a webhook server listening on port with the certificate
+// of certDir
+func NewServer(port int, certDir string) *Server {
+	return &Server{Server: &webhook.Server{
+		Port:    port,
+		CertDir: certDir,
+	}}
+}
+
+// NeedLeaderElection tells the manager to start the server on every replica
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// RegisterDatacenterWebhooks registers the defaulting, the validation and the
+// conversion of CassandraDatacenters, of which datacenter is the hub version
+func (s *Server) RegisterDatacenterWebhooks(datacenter interface {
+	admission.Defaulter
+	admission.Validator
+}) {
+	s.Register(DefaultingPath, admission.DefaultingWebhookFor(datacenter))
+	s.Register(ValidatingPath, admission.ValidatingWebhookFor(datacenter))
+	s.Register(ConversionPath, &conversion.Webhook{})
+}
@@ -14,11 +14,13 @@ import (
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
 	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -66,7 +68,7 @@ func EnsureWebhookCertificate(cfg *rest.Config) (certDir string, err error) {
 								}
 								if _, err = cert.Verify(verify_opts); err == nil {
 									log.Info("Found valid certificate for webhook")
-									return certDir, nil
+									return certDir, updateWebhooks(client, string(contents), namespace)
 								}
 							}
 						}
@@ -78,31 +80,51 @@ func EnsureWebhookCertificate(cfg *rest.Config) (certDir string, err error) {
 	return updateSecretAndWebhook(cfg, namespace)
 }
 
+// updateSecretAndWebhook serves the certificate of the webhook secret, which
+// it generates when the secret has no valid one. The replicas of an operator
+// electing a lease all start at once: the secret is only updated at the
+// version they read, so the first replica to generate a certificate updates
+// it, and the others read it again and serve that certificate.
 func updateSecretAndWebhook(cfg *rest.Config, namespace string) (certDir string, err error) {
 	var key, cert string
 	var client crclient.Client
-	if key, cert, err = utils.GetNewCAandKey("cass-operator-webhook-config", namespace); err == nil {
-		if client, err = crclient.New(cfg, crclient.Options{}); err == nil {
+	if client, err = crclient.New(cfg, crclient.Options{}); err == nil {
+		for {
 			secret := &v1.Secret{}
 			err = client.Get(context.Background(), crclient.ObjectKey{
 				Namespace: namespace,
 				Name:      "cass-operator-webhook-config",
 			}, secret)
+			if err != nil {
+				break
+			}
+			if len(secret.Data["tls.key"]) > 0 && validCertificate(secret.Data["tls.crt"], namespace) {
+				key, cert = string(secret.Data["tls.key"]), string(secret.Data["tls.crt"])
+				log.Info("TLS secret for webhook already holds a valid certificate")
+				break
+			}
+			if key, cert, err = utils.GetNewCAandKey("cass-operator-webhook-config", namespace); err != nil {
+				break
+			}
+			secret.StringData = make(map[string]string)
+			secret.StringData["tls.key"] = key
+			secret.StringData["tls.crt"] = cert
+			if err = client.Update(context.Background(), secret); apierrors.IsConflict(err) {
+				log.Info("TLS secret for webhook updated by another replica, reading it again")
+				continue
+			}
 			if err == nil {
-				secret.StringData = make(map[string]string)
-				secret.StringData["tls.key"] = key
-				secret.StringData["tls.crt"] = cert
-				if err = client.Update(context.Background(), secret); err == nil {
-					log.Info("TLS secret for webhook updated")
-					if err = ioutil.WriteFile(altServerCertFile, []byte(cert), 0600); err == nil {
-						if err = ioutil.WriteFile(altServerKeyFile, []byte(key), 0600); err == nil {
-							certDir = altCertDir
-							log.Info("TLS secret updated in pod mount")
-							return certDir, updateWebhook(client, cert, namespace)
-						}
-					}
+				log.Info("TLS secret for webhook updated")
+			}
+			break
+		}
+		if err == nil {
+			if err = ioutil.WriteFile(altServerCertFile, []byte(cert), 0600); err == nil {
+				if err = ioutil.WriteFile(altServerKeyFile, []byte(key), 0600); err == nil {
+					certDir = altCertDir
+					log.Info("TLS secret updated in pod mount")
+					return certDir, updateWebhooks(client, cert, namespace)
 				}
-
 			}
 		}
 	}
@@ -110,6 +132,41 @@ func updateSecretAndWebhook(cfg *rest.Config, namespace string) (certDir string,
 	return certDir, err
 }
 
+// validCertificate tells whether the PEM contents hold a certificate of the
+// webhook service of the namespace that has not expired
+func validCertificate(contents []byte, namespace string) bool {
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	certpool := x509.NewCertPool()
+	certpool.AddCert(cert)
+	_, err = cert.Verify(x509.VerifyOptions{
+		DNSName: fmt.Sprintf("cassandradatacenter-webhook-service.%s.svc", namespace),
+		Roots:   certpool,
+	})
+	return err == nil
+}
+
+// updateWebhooks points the validating, defaulting and conversion webhooks at
+// the namespace and the certificate, again when another replica updates them
+// at the same time
+func updateWebhooks(client crclient.Client, cert, namespace string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := updateWebhook(client, cert, namespace); err != nil {
+			return err
+		}
+		if err := updateMutatingWebhook(client, cert, namespace); err != nil {
+			return err
+		}
+		return updateConversionWebhook(client, cert, namespace)
+	})
+}
+
 func fetchWebhookForNamespace(client crclient.Client, namespace string) (err error, webhook_config *unstructured.Unstructured, webhook map[string]interface{}, unstructured_index int) {
 
 	webhook_config = &unstructured.Unstructured{}
@@ -165,6 +222,94 @@ func updateWebhook(client crclient.Client, cert, namespace string) (err error) {
 	return err
 }
 
+// updateMutatingWebhook points the defaulting webhooks of the operator at its
+// namespace and certificate. Installations without the
+// MutatingWebhookConfiguration, such as those of older releases, are left
+// without defaulting.
+func updateMutatingWebhook(client crclient.Client, cert, namespace string) error {
+	webhook_config := &unstructured.Unstructured{}
+	webhook_config.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "admissionregistration.k8s.io",
+		Kind:    "MutatingWebhookConfiguration",
+		Version: "v1beta1",
+	})
+	err := client.Get(context.Background(), crclient.ObjectKey{
+		Name: "cassandradatacenter-mutating-webhook-registration",
+	}, webhook_config)
+	if apierrors.IsNotFound(err) {
+		log.Info("MutatingWebhookConfiguration not found, CassandraDatacenters will not be defaulted")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	webhook_list, _, err := unstructured.NestedSlice(webhook_config.Object, "webhooks")
+	if err != nil {
+		return err
+	}
+	bundle := base64.StdEncoding.EncodeToString([]byte(cert))
+	for webhook_index, webhook_untypped := range webhook_list {
+		webhook, ok := webhook_untypped.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if err = unstructured.SetNestedField(webhook, namespace, "clientConfig", "service", "namespace"); err != nil {
+			return err
+		}
+		if err = unstructured.SetNestedField(webhook, bundle, "clientConfig", "caBundle"); err != nil {
+			return err
+		}
+		webhook_list[webhook_index] = webhook
+	}
+	if err = unstructured.SetNestedSlice(webhook_config.Object, webhook_list, "webhooks"); err != nil {
+		return err
+	}
+	return client.Update(context.Background(), webhook_config)
+}
+
+// updateConversionWebhook points the conversion webhook of the
+// CassandraDatacenter CRD at the operator's namespace and certificate. CRDs
+// that do not convert with a webhook, such as the CRDs of older releases, are
+// left alone.
+func updateConversionWebhook(client crclient.Client, cert, namespace string) error {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "apiextensions.k8s.io",
+		Kind:    "CustomResourceDefinition",
+		Version: "v1beta1",
+	})
+	err := client.Get(context.Background(), crclient.ObjectKey{
+		Name: "cassandradatacenters.cassandra.datastax.com",
+	}, crd)
+	if err != nil {
+		return err
+	}
+
+	if strategy, _, _ := unstructured.NestedString(crd.Object, "spec", "conversion", "strategy"); strategy != "Webhook" {
+		return nil
+	}
+
+	bundle := base64.StdEncoding.EncodeToString([]byte(cert))
+	bundled, _, _ := unstructured.NestedString(crd.Object, "spec", "conversion", "webhookClientConfig", "caBundle")
+	found_namespace, _, _ := unstructured.NestedString(crd.Object, "spec", "conversion", "webhookClientConfig", "service", "namespace")
+	if bundled == bundle && found_namespace == namespace {
+		return nil
+	}
+
+	if err = unstructured.SetNestedField(crd.Object, namespace, "spec", "conversion", "webhookClientConfig", "service", "namespace"); err != nil {
+		return err
+	}
+	if err = unstructured.SetNestedField(crd.Object, bundle, "spec", "conversion", "webhookClientConfig", "caBundle"); err != nil {
+		return err
+	}
+	if err = client.Update(context.Background(), crd); err != nil {
+		return err
+	}
+	log.Info("Conversion webhook of the CassandraDatacenter CRD updated")
+	return nil
+}
+
 func EnsureWebhookConfigVolume(cfg *rest.Config) (err error) {
 	var pod *v1.Pod
 	namespace, err := k8sutil.GetOperatorNamespace()
@@ -0,0 +1,13 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package apis
+
+import (
+	v1 "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1"
+)
+
+func init() {
+	// Register the types with the Scheme so the components can map objects to GroupVersionKinds and back
+	AddToSchemes = append(AddToSchemes, v1.SchemeBuilder.AddToScheme)
+}
@@ -0,0 +1,536 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package v1
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+// The v1 API is the v1beta1 API with the field renames v1beta1 could not make
+// without breaking existing resources. Resources are still stored as v1beta1,
+// see conversion.go for how the two versions map onto each other. Fields that
+// are the same in both versions use the v1beta1 types.
+
+// CassandraDatacenterSpec defines the desired state of a CassandraDatacenter
+// +k8s:openapi-gen=true
+type CassandraDatacenterSpec struct {
+	// Important: Run "mage operator:sdkGenerate" to regenerate code after modifying this file
+	// Add custom validation using kubebuilder tags:
+	// https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
+
+	// Desired number of Cassandra server nodes in each rack. Replaces the size of
+	// v1beta1, which is the number of nodes of the whole datacenter.
+	// +kubebuilder:validation:Minimum=1
+	NodesPerRack int32 `json:"nodesPerRack"`
+
+	// Version string for config builder,
+	// used to generate Cassandra server configuration
+	// +kubebuilder:validation:Pattern=(6\.8\.\d+)|(3\.11\.\d+)|(4\.0\.\d+)|(4\.1\.\d+)|(5\.0\.\d+)
+	ServerVersion string `json:"serverVersion"`
+
+	// Cassandra server image name.
+	// More info: https://kubernetes.io/docs/concepts/containers/images
+	ServerImage string `json:"serverImage,omitempty"`
+
+	// Secrets of the private registries the images of the pods are pulled
+	// from, added to the pods along with the pull secrets of the operator
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// Server type: "cassandra" or "dse"
+	// +kubebuilder:validation:Enum=cassandra;dse
+	ServerType string `json:"serverType"`
+
+	// Does the Server Docker image run as the Cassandra user?
+	DockerImageRunsAsCassandra *bool `json:"dockerImageRunsAsCassandra,omitempty"`
+
+	// Config for the server, in YAML format
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config json.RawMessage `json:"config,omitempty"`
+
+	// ConfigSecret is the name of a secret that contains configuration for Cassandra. The
+	// secret is expected to have a property named config whose value should be a JSON
+	// formatted string that should look like this:
+	//
+	//    config: |-
+	//      {
+	//        "cassandra-yaml": {
+	//          "read_request_timeout_in_ms": 10000
+	//        },
+	//        "jmv-options": {
+	//          "max_heap_size": 1024M
+	//        }
+	//      }
+	//
+	// ConfigSecret is mutually exclusive with Config. ConfigSecret takes precedence and
+	// will be used exclusively if both properties are set. The operator sets a watch such
+	// that an update to the secret will trigger an update of the StatefulSets.
+	ConfigSecret string `json:"configSecret,omitempty"`
+
+	// Names of more secrets of the config like configSecret, merged in order over the
+	// config of configSecret: the options of each secret take precedence over the ones of
+	// the previous secrets, and the sections and nested objects are merged rather than
+	// replaced, so that defaults and overrides can live in separate secrets. The operator
+	// watches them like configSecret.
+	// +optional
+	ConfigSecrets []string `json:"configSecrets,omitempty"`
+
+	// Name of a ConfigMap that contains configuration for Cassandra like configSecret, in
+	// its config property, for the options that are not sensitive. The configs of
+	// configSecret and configSecrets are merged over it. The operator watches it like
+	// configSecret.
+	// +optional
+	ConfigConfigMap string `json:"configConfigMap,omitempty"`
+
+	// Computes the heap, the size of the young generation and the garbage collector of
+	// Cassandra from the memory and the CPUs of the resources: G1 with Cassandra 4.0, CMS
+	// with Cassandra 3.11. The JVM options set in the config take precedence. Changing the
+	// resources then restarts the pods with the new settings.
+	// +optional
+	AutoTuneJvm bool `json:"autoTuneJvm,omitempty"`
+
+	// Config for the Management API certificates
+	ManagementApiAuth v1beta1.ManagementApiAuthConfig `json:"managementApiAuth,omitempty"`
+
+	//NodeAffinityLabels to pin the Datacenter, using node affinity
+	NodeAffinityLabels map[string]string `json:"nodeAffinityLabels,omitempty"`
+
+	// Kubernetes resource requests and limits, per pod
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Kubernetes resource requests and limits per system logger container.
+	SystemLoggerResources corev1.ResourceRequirements `json:"systemLoggerResources,omitempty"`
+
+	// Kubernetes resource requests and limits per server config initialization container.
+	ConfigBuilderResources corev1.ResourceRequirements `json:"configBuilderResources,omitempty"`
+
+	// A list of the named racks in the datacenter, representing independent failure domains. The
+	// number of racks should match the replication factor in the keyspaces you plan to create, and
+	// the number of racks cannot easily be changed once a datacenter is deployed.
+	Racks []v1beta1.Rack `json:"racks,omitempty"`
+
+	// Defines a rack for every zone of the k8s workers when no racks are listed
+	// +optional
+	ZoneRacks *v1beta1.ZoneRacksConfig `json:"zoneRacks,omitempty"`
+
+	// Describes the persistent storage request of each server node
+	StorageConfig v1beta1.StorageConfig `json:"storageConfig"`
+
+	// A list of pod names that need to be replaced.
+	ReplaceNodes []string `json:"replaceNodes,omitempty"`
+
+	// The name by which CQL clients and instances will know the cluster. If the same
+	// cluster name is shared by multiple Datacenters in the same Kubernetes namespace,
+	// they will join together in a multi-datacenter cluster.
+	// +kubebuilder:validation:MinLength=2
+	ClusterName string `json:"clusterName"`
+
+	// A stopped CassandraDatacenter will have no running server pods, like using "stop" with
+	// traditional System V init scripts. Other Kubernetes resources will be left intact, and volumes
+	// will re-attach when the CassandraDatacenter workload is resumed.
+	Stopped bool `json:"stopped,omitempty"`
+
+	// How many nodes may start at once when a stopped CassandraDatacenter is resumed.
+	// Once a node is ready, the nodes that already joined the cluster before the
+	// datacenter was stopped are started without waiting for each other to be ready,
+	// since they already have their data. The start calls are still made one at a time.
+	// Defaults to 1, starting one node at a time.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ResumeParallelism int32 `json:"resumeParallelism,omitempty"`
+
+	// A hibernated CassandraDatacenter keeps a single node per rack running, or a single
+	// node with singleNode, so that the schema and the system tables stay available while
+	// the other nodes are stopped. Disabling it resumes the stopped nodes. Stopped takes
+	// precedence over it.
+	// +optional
+	Hibernate *v1beta1.HibernateConfig `json:"hibernate,omitempty"`
+
+	// Decommission every node before the CassandraDatacenter is deleted, so they are removed
+	// from the cluster rather than left behind in the ring of the other datacenters. The keyspaces
+	// must not be replicated to this datacenter anymore.
+	DecommissionOnDelete bool `json:"decommissionOnDelete,omitempty"`
+
+	// Hold the deletion of the CassandraDatacenter and of the persistent volume
+	// claims of its nodes until it is confirmed with the
+	// cassandra.datastax.com/confirm-deletion annotation, so that deleting it
+	// or its namespace by mistake does not destroy the data
+	// +optional
+	DeletionProtection bool `json:"deletionProtection,omitempty"`
+
+	// Take ownership of the StatefulSets and services that already exist under
+	// the names the operator gives them, such as the ones of a cluster that was
+	// deployed without the operator. Their pods are rolled forward to the pod
+	// template of the operator. The StatefulSets must have a server-data volume
+	// claim template, for the pods to keep their data.
+	// +optional
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+
+	// Migrate the data and the clients of another datacenter of the cluster to
+	// this one, and then decommission and delete it, to replace a datacenter
+	// with a new one alongside it.
+	// +optional
+	MigrateFrom *v1beta1.MigrationConfig `json:"migrateFrom,omitempty"`
+
+	// Whether the persistent volume claims of the nodes are deleted when the
+	// datacenter is scaled down and when it is deleted. They are deleted in
+	// both cases by default.
+	// +optional
+	PersistentVolumeClaimRetentionPolicy *v1beta1.PersistentVolumeClaimRetentionPolicy `json:"persistentVolumeClaimRetentionPolicy,omitempty"`
+
+	// Replace the nodes whose persistent volumes failed without waiting for
+	// them to be listed in replaceNodes
+	// +optional
+	AutomaticNodeReplacement *v1beta1.AutomaticNodeReplacementConfig `json:"automaticNodeReplacement,omitempty"`
+
+	// Restarts the pods that are stuck in CrashLoopBackOff, or whose node is
+	// drained or stuck joining the ring, and replaces their nodes if they are
+	// still stuck after the restart, within a budget of remediations per hour
+	// +optional
+	Remediation *v1beta1.RemediationConfig `json:"remediation,omitempty"`
+
+	// Moves the pods and data off the k8s workers that are tainted for
+	// maintenance. Without it, the taints of the VMware PSP are only handled
+	// when the operator runs with ENABLE_VMWARE_PSP.
+	// +optional
+	NodeMaintenancePolicy *v1beta1.NodeMaintenancePolicy `json:"nodeMaintenancePolicy,omitempty"`
+
+	// How the nodes are drained before their server containers are stopped. By
+	// default the preStop hook drains the node within the grace period of the
+	// pod, and the operator drains the nodes before a stopped datacenter is
+	// scaled down.
+	// +optional
+	Drain *v1beta1.DrainConfig `json:"drain,omitempty"`
+
+	// Tunes the probes of the server container, and adds a startup probe that
+	// holds off the other probes while a node with large data volumes starts.
+	// The probes of the podTemplateSpec take precedence.
+	// +optional
+	Probes *v1beta1.ProbesConfig `json:"probes,omitempty"`
+
+	// Container image for the config builder init container.
+	ConfigBuilderImage string `json:"configBuilderImage,omitempty"`
+
+	// Where the configuration files of Cassandra are rendered: config-builder, the
+	// default, renders them in the server-config-init container with the config builder
+	// image, and operator renders them in the operator into a ConfigMap of each rack that
+	// the server-config-init container copies. Only Cassandra supports operator, with the
+	// cassandra-yaml of the config and the heap and additional-jvm-opts of its JVM options.
+	// +kubebuilder:validation:Enum=config-builder;operator
+	// +optional
+	ConfigRenderer v1beta1.ConfigRenderer `json:"configRenderer,omitempty"`
+
+	// Indicates that configuration and container image changes should only be pushed to
+	// the first rack of the datacenter. Once the canary pods are upgraded, the upgrade
+	// pauses with the CanaryUpgradePaused condition until canaryUpgradeApproved is set.
+	CanaryUpgrade bool `json:"canaryUpgrade,omitempty"`
+
+	// The number of nodes that will be updated when CanaryUpgrade is true, 1 when unset. If the
+	// value is greater than the rack size, then all nodes in the rack will get updated.
+	CanaryUpgradeCount int32 `json:"canaryUpgradeCount,omitempty"`
+
+	// Push canary upgrades to canaryUpgradeCount nodes of every rack, rather than of only
+	// the first rack
+	CanaryUpgradeAllRacks bool `json:"canaryUpgradeAllRacks,omitempty"`
+
+	// Approves a canary upgrade that is waiting for approval, so the remaining nodes get
+	// upgraded. The operator will set this back to false once the upgrade proceeds.
+	CanaryUpgradeApproved bool `json:"canaryUpgradeApproved,omitempty"`
+
+	// Runs upgradesstables on the nodes, rack by rack, once they were all
+	// upgraded to a new major version of the server, so that their sstables are
+	// rewritten in its format
+	// +optional
+	UpgradeSSTables *v1beta1.UpgradeSSTablesConfig `json:"upgradeSSTables,omitempty"`
+
+	// Rolls out the changes of the config first to the canary pods of every rack,
+	// and to the rest of the pods once the canary pods are back to Up/Normal and pass
+	// a health check. canaryUpgrade takes precedence over it.
+	// +optional
+	ConfigRolloutStrategy *v1beta1.ConfigRolloutStrategy `json:"configRolloutStrategy,omitempty"`
+
+	// Turning this option on allows multiple server pods to be created on a k8s worker node.
+	// By default the operator creates just one server pod per k8s worker node using k8s
+	// podAntiAffinity and requiredDuringSchedulingIgnoredDuringExecution.
+	AllowMultipleNodesPerWorker bool `json:"allowMultipleNodesPerWorker,omitempty"`
+
+	// Isolates the server pods from each other when allowMultipleNodesPerWorker
+	// places several of them on a k8s worker node. Cannot be combined with
+	// hostNetwork, as the nodes all listen on the same ports.
+	// +optional
+	MultipleNodesPerWorker *v1beta1.MultipleNodesPerWorkerConfig `json:"multipleNodesPerWorker,omitempty"`
+
+	// Relaxes the anti-affinity that keeps the server pods on different k8s worker nodes,
+	// for instance to spread them across zones instead, or to only prefer placing them
+	// apart. Ignored when allowMultipleNodesPerWorker is set. Racks can override it.
+	// +optional
+	PodAntiAffinity *v1beta1.PodAntiAffinityConfig `json:"podAntiAffinity,omitempty"`
+
+	// Spreads the server pods evenly over the domains of the topology keys,
+	// such as the workers, in addition to the podAntiAffinity. The constraints
+	// without a labelSelector select the server pods of the datacenter.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// This secret defines the username and password for the Cassandra server superuser.
+	// If it is omitted, we will generate a secret instead.
+	SuperuserSecretName string `json:"superuserSecretName,omitempty"`
+
+	// How a change of the password of the superuser secret is applied. With
+	// Online, the operator changes the password of the superuser role through
+	// the management API while the nodes keep running. With Manual, the
+	// default, the password must be changed by hand.
+	// +kubebuilder:validation:Enum=Manual;Online
+	// +optional
+	SuperuserSecretRotationPolicy v1beta1.SuperuserSecretRotationPolicy `json:"superuserSecretRotationPolicy,omitempty"`
+
+	// The k8s service account to use for the server pods. Replaces serviceAccount of v1beta1.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// The priority class of the server pods, so that they are not the first to
+	// be preempted or evicted when their workers are under pressure
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// The runtime class of the server pods, such as one that runs them in
+	// gVisor or Kata Containers
+	// +optional
+	RuntimeClassName string `json:"runtimeClassName,omitempty"`
+
+	// The scheduler of the server pods, when it is not the default scheduler
+	// +optional
+	SchedulerName string `json:"schedulerName,omitempty"`
+
+	// Whether to do a rolling restart at the next opportunity. The operator will set this back
+	// to false once the restart is in progress.
+	RollingRestartRequested bool `json:"rollingRestartRequested,omitempty"`
+
+	// Limits the rolling restart requested with rollingRestartRequested to some of
+	// the pods, and sets how many pods of a rack are restarted at the same time.
+	// The settings are read when the restart is requested; changing them while a
+	// restart is in progress has no effect on it.
+	RollingRestart *v1beta1.RollingRestartConfig `json:"rollingRestart,omitempty"`
+
+	// Restricts rolling restarts, upgrades and configuration rollouts to a
+	// recurring window. Outside the window they wait with the
+	// MaintenancePending condition.
+	// +optional
+	MaintenanceWindow *v1beta1.MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// A map of label keys and values to restrict Cassandra node scheduling to k8s workers
+	// with matchiing labels.
+	// More info: https://kubernetes.io/docs/concepts/configuration/assign-pod-node/#nodeselector
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Rack names in this list are set to the latest StatefulSet configuration
+	// even if Cassandra nodes are down. Use this to recover from an upgrade that couldn't
+	// roll out. When the configuration changes fields of the StatefulSet that cannot be
+	// updated, such as its volumeClaimTemplates, the StatefulSet is deleted without its
+	// pods and recreated.
+	ForceUpgradeRacks []string `json:"forceUpgradeRacks,omitempty"`
+
+	// Reverts the edits of the StatefulSets of the racks that were not made by the
+	// operator, such as a kubectl edit of their resources, and emits a
+	// RevertedStatefulSetDrift event for each. Without it, such edits stay until
+	// the next change of the datacenter.
+	// +optional
+	RevertStatefulSetDrift bool `json:"revertStatefulSetDrift,omitempty"`
+
+	DseWorkloads *v1beta1.DseWorkloads `json:"dseWorkloads,omitempty"`
+
+	// PodTemplate provides customisation options (labels, annotations, affinity rules, resource requests, and so on) for the cassandra pods
+	PodTemplateSpec *corev1.PodTemplateSpec `json:"podTemplateSpec,omitempty"`
+
+	// Containers added to the Cassandra pods, after the containers of the operator.
+	// A container with the name of a container of the operator, such as cassandra or
+	// server-system-logger, is merged into it instead: the fields it sets override
+	// the fields of the operator, and its env, ports and volumeMounts are merged by
+	// name. Sidecars take precedence over the containers of podTemplateSpec.
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// Init containers added to the Cassandra pods, after the init containers of the
+	// operator. They are merged with the init containers of the operator, such as
+	// server-config-init, the same way as sidecars.
+	// +optional
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+
+	// Cassandra users to bootstrap
+	Users []v1beta1.CassandraUser `json:"users,omitempty"`
+
+	Networking *v1beta1.NetworkingConfig `json:"networking,omitempty"`
+
+	// IP addresses or hostnames of seed nodes to add to the seeds of the cluster, such as the
+	// nodes of datacenters that are not managed by the operator. Hostnames are resolved
+	// again periodically.
+	AdditionalSeeds []string `json:"additionalSeeds,omitempty"`
+
+	// CassandraDatacenters, possibly in other namespaces, whose seed nodes are added to the
+	// seeds of this datacenter. The namespace defaults to the namespace of this datacenter.
+	AdditionalSeedDatacenters []corev1.ObjectReference `json:"additionalSeedDatacenters,omitempty"`
+
+	// Seeds of the datacenters of the cluster in other Kubernetes clusters, as addresses
+	// or as the seeds ConfigMaps their operators publish with spec.federation, copied to
+	// the namespace of this datacenter.
+	// +optional
+	ClusterSeeds []v1beta1.ClusterSeedsSource `json:"clusterSeeds,omitempty"`
+
+	// Joins the datacenter with the datacenters of the cluster in other Kubernetes clusters.
+	// +optional
+	Federation *v1beta1.FederationConfig `json:"federation,omitempty"`
+
+	// Configuration for disabling the simple log tailing sidecar container. Our default is to have it enabled.
+	DisableSystemLoggerSidecar bool `json:"disableSystemLoggerSidecar,omitempty"`
+
+	// Container image for the log tailing sidecar container.
+	SystemLoggerImage string `json:"systemLoggerImage,omitempty"`
+
+	// Format of the logs of Cassandra, which the server-system-logger sidecar prints:
+	// Text, the pattern of Cassandra, or JSON, one object per line for log agents. With
+	// JSON, the operator generates the logback.xml of the nodes, and the standard output
+	// of the cassandra container is in JSON as well. Changing it restarts the pods.
+	// +kubebuilder:validation:Enum=Text;JSON
+	// +optional
+	LogFormat v1beta1.LogFormat `json:"logFormat,omitempty"`
+
+	// Levels of the loggers of Cassandra. The operator generates the logback.xml of
+	// the nodes, with the levels in a ConfigMap logback rescans, and sets them through
+	// the management API of the running nodes, so changing them does not restart the pods.
+	// Adding it restarts the pods once, to switch them to the generated logback.xml.
+	// +optional
+	LoggingConfig *v1beta1.LoggingConfig `json:"loggingConfig,omitempty"`
+
+	// Labels added to every resource the operator creates for the datacenter: the
+	// statefulsets and their pods, the services, the pod disruption budgets, the secrets
+	// and the ConfigMaps. The labels of the operator take precedence over these. Changing
+	// them restarts the pods, like any other change of the pod template.
+	// +optional
+	AdditionalLabels map[string]string `json:"additionalLabels,omitempty"`
+
+	// Annotations added to every resource the operator creates for the datacenter, for
+	// instance for cost allocation or service mesh injection. The annotations of the
+	// operator take precedence over these. Changing them restarts the pods.
+	// +optional
+	AdditionalAnnotations map[string]string `json:"additionalAnnotations,omitempty"`
+
+	// AdditionalServiceConfig allows to define additional parameters that are included in the created Services. Note, user can override values set by cass-operator and doing so could break cass-operator functionality.
+	// Avoid label "cass-operator" and anything that starts with "cassandra.datastax.com/"
+	AdditionalServiceConfig v1beta1.ServiceConfig `json:"additionalServiceConfig,omitempty"`
+
+	// Tolerations applied to the Cassandra pod. Note that these cannot be overridden with PodTemplateSpec.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Adds a sidecar container to the Cassandra pods that uploads snapshots to, and
+	// downloads them from, object storage for CassandraBackup and CassandraRestore.
+	BackupSidecar *v1beta1.BackupSidecarConfig `json:"backupSidecar,omitempty"`
+
+	// Injects the Medusa backup agent in the Cassandra pods, which a backup
+	// operator drives through its gRPC API without having to mutate the pods.
+	// +optional
+	BackupAgent *v1beta1.BackupAgentConfig `json:"backupAgent,omitempty"`
+
+	// Repairs the operator runs on a schedule. The nodes of the datacenter are
+	// repaired one at a time, so no two repairs of a schedule work on the same
+	// token ranges at once.
+	Repairs []v1beta1.RepairSchedule `json:"repairs,omitempty"`
+
+	// Keyspaces whose NetworkTopologyStrategy replication factor in the
+	// datacenter the operator keeps as declared, altering them whenever it
+	// changes. The factors of the other datacenters are left to them. When the
+	// replication factor of the datacenter grows, its nodes are then repaired
+	// one at a time.
+	Keyspaces []v1beta1.KeyspaceReplication `json:"keyspaces,omitempty"`
+
+	// Keep the replication of system_auth, system_distributed and system_traces in
+	// step with the datacenters of the cluster, with as many replicas in each of them
+	// as it has nodes, up to 3. A datacenter is added to it once its nodes have joined
+	// the cluster, and removed from it before its nodes are decommissioned with
+	// decommissionOnDelete. Its replicas are lowered before it is scaled down.
+	ManageSystemKeyspaces bool `json:"manageSystemKeyspaces,omitempty"`
+
+	// Full query logging of Cassandra 4.0 nodes, which logs every query to
+	// binary files that can be replayed or inspected with fqltool.
+	FullQueryLogging *v1beta1.QueryLoggingConfig `json:"fullQueryLogging,omitempty"`
+
+	// Audit logging of Cassandra 4.0 nodes, which logs the queries and logins
+	// of clients, and whether they succeeded.
+	AuditLogging *v1beta1.QueryLoggingConfig `json:"auditLogging,omitempty"`
+
+	// Change data capture of the nodes, which keeps the commit log segments of
+	// the tables with cdc enabled in the cdc_raw_directory, for instance for the
+	// forwarder sidecar to send on the changes.
+	// +optional
+	CDC *v1beta1.CDCConfig `json:"cdc,omitempty"`
+
+	// Settings of the PodDisruptionBudget of the datacenter. Without them the
+	// budget keeps all but one node of the datacenter available.
+	PodDisruptionBudget *v1beta1.PodDisruptionBudgetConfig `json:"podDisruptionBudget,omitempty"`
+
+	// Has the Prometheus operator scrape the metrics of the nodes, by creating
+	// a ServiceMonitor or a PodMonitor for the datacenter.
+	Monitoring *v1beta1.MonitoringConfig `json:"monitoring,omitempty"`
+
+	// Settings of the Metric Collector for Apache Cassandra (MCAC) agent that
+	// runs in the server containers and serves the metrics of the nodes.
+	MetricsCollector *v1beta1.MetricsCollectorConfig `json:"metricsCollector,omitempty"`
+
+	// Encryption of the traffic of the nodes, set up by the operator
+	Encryption *v1beta1.EncryptionConfig `json:"encryption,omitempty"`
+
+	// Reads the superuser secret and the config secret from HashiCorp Vault
+	// instead of kubernetes secrets.
+	// +optional
+	Vault *v1beta1.VaultConfig `json:"vault,omitempty"`
+
+	// Accepts authenticated JMX connections from outside the pods, such as
+	// the ones of Reaper.
+	// +optional
+	Jmx *v1beta1.JmxConfig `json:"jmx,omitempty"`
+
+	// Deploys Cassandra Reaper next to the datacenter and registers the
+	// cluster with it, which requires spec.jmx.
+	// +optional
+	Reaper *v1beta1.ReaperConfig `json:"reaper,omitempty"`
+}
+
+// CassandraDatacenterStatus defines the observed state of CassandraDatacenter,
+// which is the same in v1 and v1beta1
+type CassandraDatacenterStatus = v1beta1.CassandraDatacenterStatus
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraDatacenter is the Schema for the cassandradatacenters API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=cassandradatacenters,scope=Namespaced,shortName=cassdc;cassdcs
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.spec.size`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyNodes`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.serverVersion`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type CassandraDatacenter struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraDatacenterSpec   `json:"spec,omitempty"`
+	Status CassandraDatacenterStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraDatacenterList contains a list of CassandraDatacenter
+type CassandraDatacenterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraDatacenter `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraDatacenter{}, &CassandraDatacenterList{})
+}
@@ -0,0 +1,114 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+const (
+	// SizeAnnotation keeps the size of a v1beta1 datacenter whose nodes are not
+	// spread evenly over its racks, which nodesPerRack cannot represent. The
+	// nodesPerRack of such a datacenter is rounded up.
+	SizeAnnotation = "cassandra.datastax.com/v1beta1-size"
+
+	// ReaperAnnotation kept the reaper settings of a v1beta1 datacenter when
+	// v1 did not have spec.reaper. It is still read from datacenters that
+	// have it.
+	ReaperAnnotation = "cassandra.datastax.com/v1beta1-reaper"
+)
+
+// blank assignment to verify that CassandraDatacenter implements conversion.Convertible
+var _ conversion.Convertible = &CassandraDatacenter{}
+
+// ConvertTo converts this CassandraDatacenter to the v1beta1 hub version
+func (dc *CassandraDatacenter) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1beta1.CassandraDatacenter)
+	if !ok {
+		return fmt.Errorf("cannot convert a v1 CassandraDatacenter to %T", dstRaw)
+	}
+
+	dc.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+	dc.Status.DeepCopyInto(&dst.Status)
+
+	// The fields that did not change have the same JSON names in both versions
+	if err := convertFields(&dc.Spec, &dst.Spec); err != nil {
+		return err
+	}
+
+	racks := int32(len(dst.GetRacks()))
+	dst.Spec.Size = dc.Spec.NodesPerRack * racks
+	if size, err := strconv.ParseInt(dc.Annotations[SizeAnnotation], 10, 32); err == nil &&
+		size > 0 && ceilDiv(int32(size), racks) == dc.Spec.NodesPerRack {
+		dst.Spec.Size = int32(size)
+	}
+
+	dst.Spec.ServiceAccount = dc.Spec.ServiceAccountName
+
+	if reaper, ok := dc.Annotations[ReaperAnnotation]; ok && dc.Spec.Reaper == nil {
+		dst.Spec.Reaper = &v1beta1.ReaperConfig{}
+		if err := json.Unmarshal([]byte(reaper), dst.Spec.Reaper); err != nil {
+			return fmt.Errorf("invalid %s annotation: %w", ReaperAnnotation, err)
+		}
+	}
+
+	delete(dst.Annotations, SizeAnnotation)
+	delete(dst.Annotations, ReaperAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	return nil
+}
+
+// ConvertFrom converts the v1beta1 hub version to this CassandraDatacenter
+func (dc *CassandraDatacenter) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1beta1.CassandraDatacenter)
+	if !ok {
+		return fmt.Errorf("cannot convert %T to a v1 CassandraDatacenter", srcRaw)
+	}
+
+	src.ObjectMeta.DeepCopyInto(&dc.ObjectMeta)
+	src.Status.DeepCopyInto(&dc.Status)
+
+	if err := convertFields(&src.Spec, &dc.Spec); err != nil {
+		return err
+	}
+
+	racks := int32(len(src.GetRacks()))
+	dc.Spec.NodesPerRack = ceilDiv(src.Spec.Size, racks)
+	if dc.Spec.NodesPerRack*racks != src.Spec.Size {
+		setAnnotation(dc, SizeAnnotation, strconv.Itoa(int(src.Spec.Size)))
+	}
+
+	dc.Spec.ServiceAccountName = src.Spec.ServiceAccount
+
+	return nil
+}
+
+// convertFields copies the spec fields that are the same in both versions
+func convertFields(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func ceilDiv(size, racks int32) int32 {
+	return (size + racks - 1) / racks
+}
+
+func setAnnotation(dc *CassandraDatacenter, key, value string) {
+	if dc.Annotations == nil {
+		dc.Annotations = make(map[string]string)
+	}
+	dc.Annotations[key] = value
+}
@@ -0,0 +1,185 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func newV1beta1Datacenter(size int32, racks ...string) *v1beta1.CassandraDatacenter {
+	dc := &v1beta1.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dc1",
+			Namespace:   "ns1",
+			Annotations: map[string]string{"example.com/owner": "team"},
+		},
+		Spec: v1beta1.CassandraDatacenterSpec{
+			Size:           size,
+			ClusterName:    "cluster1",
+			ServerType:     "cassandra",
+			ServerVersion:  "3.11.7",
+			ServiceAccount: "cassandra",
+			Config:         []byte(`{"cassandra-yaml":{"num_tokens":16}}`),
+			Repairs: []v1beta1.RepairSchedule{
+				{Name: "weekly", Schedule: "0 2 * * 0", Keyspaces: []string{"ks1"}},
+			},
+		},
+		Status: v1beta1.CassandraDatacenterStatus{
+			CassandraOperatorProgress: v1beta1.ProgressReady,
+		},
+	}
+	for _, rack := range racks {
+		dc.Spec.Racks = append(dc.Spec.Racks, v1beta1.Rack{Name: rack})
+	}
+	return dc
+}
+
+func TestConvertFrom(t *testing.T) {
+	src := newV1beta1Datacenter(6, "r1", "r2", "r3")
+
+	dc := &CassandraDatacenter{}
+	assert.NoError(t, dc.ConvertFrom(src))
+	assert.Equal(t, int32(2), dc.Spec.NodesPerRack)
+	assert.Equal(t, "cassandra", dc.Spec.ServiceAccountName)
+	assert.Equal(t, "cluster1", dc.Spec.ClusterName)
+	assert.Equal(t, src.Spec.Config, dc.Spec.Config)
+	assert.Equal(t, src.Spec.Repairs, dc.Spec.Repairs)
+	assert.Equal(t, src.Spec.Racks, dc.Spec.Racks)
+	assert.Equal(t, src.Status, dc.Status)
+	assert.Equal(t, map[string]string{"example.com/owner": "team"}, dc.Annotations)
+}
+
+func TestConvertTo(t *testing.T) {
+	dc := &CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns1"},
+		Spec: CassandraDatacenterSpec{
+			NodesPerRack:       3,
+			ClusterName:        "cluster1",
+			ServiceAccountName: "cassandra",
+			Racks:              []v1beta1.Rack{{Name: "r1"}, {Name: "r2"}},
+		},
+	}
+
+	dst := &v1beta1.CassandraDatacenter{}
+	assert.NoError(t, dc.ConvertTo(dst))
+	assert.Equal(t, int32(6), dst.Spec.Size)
+	assert.Equal(t, "cassandra", dst.Spec.ServiceAccount)
+	assert.Equal(t, dc.Spec.Racks, dst.Spec.Racks)
+
+	// Without racks the datacenter has a single default rack
+	dc.Spec.Racks = nil
+	assert.NoError(t, dc.ConvertTo(dst))
+	assert.Equal(t, int32(3), dst.Spec.Size)
+}
+
+func TestConversionRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		src  *v1beta1.CassandraDatacenter
+	}{
+		{
+			name: "nodes spread evenly",
+			src:  newV1beta1Datacenter(6, "r1", "r2", "r3"),
+		},
+		{
+			name: "nodes not spread evenly",
+			src:  newV1beta1Datacenter(5, "r1", "r2", "r3"),
+		},
+		{
+			name: "reaper",
+			src: func() *v1beta1.CassandraDatacenter {
+				dc := newV1beta1Datacenter(3)
+				dc.Spec.Reaper = &v1beta1.ReaperConfig{Enabled: true, Image: "reaper:latest"}
+				return dc
+			}(),
+		},
+		{
+			name: "deletion protection",
+			src: func() *v1beta1.CassandraDatacenter {
+				dc := newV1beta1Datacenter(3)
+				dc.Spec.DeletionProtection = true
+				return dc
+			}(),
+		},
+		{
+			name: "adoption",
+			src: func() *v1beta1.CassandraDatacenter {
+				dc := newV1beta1Datacenter(3)
+				dc.Spec.AdoptExisting = true
+				return dc
+			}(),
+		},
+		{
+			name: "multiple nodes per worker",
+			src: func() *v1beta1.CassandraDatacenter {
+				dc := newV1beta1Datacenter(3)
+				dc.Spec.AllowMultipleNodesPerWorker = true
+				dc.Spec.MultipleNodesPerWorker = &v1beta1.MultipleNodesPerWorkerConfig{DedicatedCpus: true, IsolateRacks: true}
+				return dc
+			}(),
+		},
+		{
+			name: "migration",
+			src: func() *v1beta1.CassandraDatacenter {
+				dc := newV1beta1Datacenter(3)
+				dc.Spec.MigrateFrom = &v1beta1.MigrationConfig{Datacenter: "dc-old", HoldDecommission: true}
+				return dc
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &CassandraDatacenter{}
+			assert.NoError(t, dc.ConvertFrom(tt.src))
+
+			dst := &v1beta1.CassandraDatacenter{}
+			assert.NoError(t, dc.ConvertTo(dst))
+			assert.Equal(t, tt.src, dst)
+		})
+	}
+}
+
+func TestConvertTo_StaleSizeAnnotation(t *testing.T) {
+	dc := &CassandraDatacenter{}
+	assert.NoError(t, dc.ConvertFrom(newV1beta1Datacenter(5, "r1", "r2", "r3")))
+	assert.Equal(t, int32(2), dc.Spec.NodesPerRack)
+	assert.Equal(t, "5", dc.Annotations[SizeAnnotation])
+
+	// Scaling up in v1 makes the annotation obsolete
+	dc.Spec.NodesPerRack = 3
+
+	dst := &v1beta1.CassandraDatacenter{}
+	assert.NoError(t, dc.ConvertTo(dst))
+	assert.Equal(t, int32(9), dst.Spec.Size)
+	assert.NotContains(t, dst.Annotations, SizeAnnotation)
+}
+
+func TestConvertTo_ReaperAnnotation(t *testing.T) {
+	// Written when v1 did not have spec.reaper
+	dc := &CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dc1",
+			Namespace:   "ns1",
+			Annotations: map[string]string{ReaperAnnotation: `{"enabled":true,"image":"reaper:latest"}`},
+		},
+		Spec: CassandraDatacenterSpec{NodesPerRack: 3, ClusterName: "cluster1"},
+	}
+
+	dst := &v1beta1.CassandraDatacenter{}
+	assert.NoError(t, dc.ConvertTo(dst))
+	assert.Equal(t, &v1beta1.ReaperConfig{Enabled: true, Image: "reaper:latest"}, dst.Spec.Reaper)
+	assert.NotContains(t, dst.Annotations, ReaperAnnotation)
+
+	// spec.reaper takes precedence over the annotation
+	dc.Spec.Reaper = &v1beta1.ReaperConfig{Enabled: false}
+	dst = &v1beta1.CassandraDatacenter{}
+	assert.NoError(t, dc.ConvertTo(dst))
+	assert.Equal(t, &v1beta1.ReaperConfig{Enabled: false}, dst.Spec.Reaper)
+}
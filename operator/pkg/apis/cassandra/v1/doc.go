@@ -0,0 +1,7 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package v1 contains API Schema definitions for the cassandra v1 API group
+// +k8s:deepcopy-gen=package,register
+// +groupName=cassandra.datastax.com
+package v1
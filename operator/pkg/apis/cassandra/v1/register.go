@@ -0,0 +1,25 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// NOTE: Boilerplate only.  Ignore this file.
+
+// Package v1 contains API Schema definitions for the cassandra v1 API group
+// +k8s:deepcopy-gen=package,register
+// +groupName=cassandra.datastax.com
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// SchemeGroupVersion is group version used to register these objects
+	SchemeGroupVersion = schema.GroupVersion{Group: "cassandra.datastax.com", Version: "v1"}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: SchemeGroupVersion}
+)
+
+// AddToScheme is a global function that registers this API group & version to a scheme
+var AddToScheme = SchemeBuilder.AddToScheme
@@ -0,0 +1,234 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// BackupSidecarPort is the port the backup sidecar serves its API on
+const BackupSidecarPort = 8091
+
+// BackupSidecarConfig configures the container that moves snapshot files
+// between the Cassandra data volume and object storage.
+type BackupSidecarConfig struct {
+	// Container image for the backup sidecar
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// A secret holding the object storage credentials. Every key of the
+	// secret is exposed to the sidecar as an environment variable.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// Kubernetes resource requests and limits for the backup sidecar.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// BackupStorageProvider is the object store a backup is uploaded to
+type BackupStorageProvider string
+
+const (
+	BackupStorageS3    BackupStorageProvider = "s3"
+	BackupStorageGCS   BackupStorageProvider = "gcs"
+	BackupStorageAzure BackupStorageProvider = "azure"
+)
+
+// BackupStorageLocation is where the files of a backup are stored. The
+// credentials for the object store are given to the backup sidecar with
+// backupSidecar.secretName on the CassandraDatacenter.
+type BackupStorageLocation struct {
+	// +kubebuilder:validation:Enum=s3;gcs;azure
+	Provider BackupStorageProvider `json:"provider"`
+
+	// The bucket, or container for Azure, the backup is uploaded to
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+
+	// An optional path prefix inside the bucket
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// CassandraBackupSpec defines the desired state of CassandraBackup
+// +k8s:openapi-gen=true
+type CassandraBackupSpec struct {
+	// The CassandraDatacenter to back up. If the namespace is left empty,
+	// the namespace of the CassandraBackup is used.
+	Datacenter corev1.ObjectReference `json:"datacenter"`
+
+	Storage BackupStorageLocation `json:"storage"`
+
+	// Keyspaces to include in the backup. Defaults to all keyspaces.
+	// +optional
+	Keyspaces []string `json:"keyspaces,omitempty"`
+}
+
+// BackupPodStatus is the progress of a backup or a restore on a single pod
+type BackupPodStatus struct {
+	State TaskState `json:"state"`
+
+	// For a restore, the pod of the backed up datacenter whose files are
+	// restored on this pod
+	// +optional
+	Source string `json:"source,omitempty"`
+
+	// The transfer of the backup sidecar that is running on the pod
+	// +optional
+	TransferID string `json:"transferId,omitempty"`
+
+	// How many times the transfer was started on the pod
+	// +optional
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// +optional
+	BytesTransferred int64 `json:"bytesTransferred,omitempty"`
+
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// BackupTransferAttempts is how many times the transfer of a pod is started
+// before its backup or restore fails
+const BackupTransferAttempts = 3
+
+// CassandraBackupStatus defines the observed state of CassandraBackup
+// +k8s:openapi-gen=true
+type CassandraBackupStatus struct {
+	// +optional
+	State TaskState `json:"state,omitempty"`
+
+	// The name of the snapshot taken on every node
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+
+	// The UID of the backed up CassandraDatacenter. A backup only restores
+	// into that datacenter, whose nodes still own the tokens of the files.
+	// +optional
+	DatacenterUID types.UID `json:"datacenterUID,omitempty"`
+
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Progress of the backup keyed by pod name
+	// +optional
+	Pods map[string]BackupPodStatus `json:"pods,omitempty"`
+
+	// +optional
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraBackup is the Schema for the cassandrabackups API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=cassandrabackups,scope=Namespaced,shortName=cassbackup
+type CassandraBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraBackupSpec   `json:"spec,omitempty"`
+	Status CassandraBackupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraBackupList contains a list of CassandraBackup
+type CassandraBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraBackup `json:"items"`
+}
+
+// CassandraRestoreSpec defines the desired state of CassandraRestore
+// +k8s:openapi-gen=true
+type CassandraRestoreSpec struct {
+	// The name of a completed CassandraBackup in the same namespace
+	// +kubebuilder:validation:MinLength=1
+	Backup string `json:"backup"`
+
+	// The CassandraDatacenter to restore into. It must be the datacenter
+	// that was backed up: the files of a node only hold the token ranges it
+	// owns. If the datacenter does not exist yet, the restore waits for it
+	// to be created. If the namespace is left empty, the namespace of the
+	// CassandraRestore is used.
+	Datacenter corev1.ObjectReference `json:"datacenter"`
+}
+
+// CassandraRestoreStatus defines the observed state of CassandraRestore
+// +k8s:openapi-gen=true
+type CassandraRestoreStatus struct {
+	// +optional
+	State TaskState `json:"state,omitempty"`
+
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Progress of the restore keyed by the name of the restored pod
+	// +optional
+	Pods map[string]BackupPodStatus `json:"pods,omitempty"`
+
+	// +optional
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraRestore is the Schema for the cassandrarestores API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=cassandrarestores,scope=Namespaced,shortName=cassrestore
+type CassandraRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraRestoreSpec   `json:"spec,omitempty"`
+	Status CassandraRestoreStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraRestoreList contains a list of CassandraRestore
+type CassandraRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraBackup{}, &CassandraBackupList{})
+	SchemeBuilder.Register(&CassandraRestore{}, &CassandraRestoreList{})
+}
+
+// GetDatacenterKey returns the namespaced name of the CassandraDatacenter
+// that is backed up
+func (backup *CassandraBackup) GetDatacenterKey() types.NamespacedName {
+	return objectReferenceKey(backup.Spec.Datacenter, backup.Namespace)
+}
+
+// GetDatacenterKey returns the namespaced name of the CassandraDatacenter
+// that is restored into
+func (restore *CassandraRestore) GetDatacenterKey() types.NamespacedName {
+	return objectReferenceKey(restore.Spec.Datacenter, restore.Namespace)
+}
+
+// IsFinished reports whether the backup has reached a terminal state
+func (status *CassandraBackupStatus) IsFinished() bool {
+	return status.State == TaskStateSucceeded || status.State == TaskStateFailed
+}
+
+// IsFinished reports whether the restore has reached a terminal state
+func (status *CassandraRestoreStatus) IsFinished() bool {
+	return status.State == TaskStateSucceeded || status.State == TaskStateFailed
+}
@@ -6,13 +6,19 @@ package v1beta1
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Jeffail/gabs"
 	"github.com/k8ssandra/cass-operator/operator/pkg/serverconfig"
 	"github.com/pkg/errors"
+	"github.com/robfig/cron"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
@@ -31,6 +37,15 @@ const (
 	// RackLabel is the operator's label for the rack name
 	CassOperatorProgressLabel = "cassandra.datastax.com/operator-progress"
 
+	// ConfigCanaryAnnotation is the operator's annotation of the statefulsets
+	// whose canary pods a change of the config is rolled out to first, with
+	// spec.configRolloutStrategy
+	ConfigCanaryAnnotation = "cassandra.datastax.com/config-canary"
+
+	// ConfigValidationLabel is the operator's label for the jobs that render
+	// the config of the datacenter before it is rolled out
+	ConfigValidationLabel = "cassandra.datastax.com/config-validation"
+
 	// PromMetricsLabel is a service label that can be selected for prometheus metrics scraping
 	PromMetricsLabel = "cassandra.datastax.com/prom-metrics"
 
@@ -40,9 +55,96 @@ const (
 	// ConfigHashAnnotation is the operator's annotation for the hash of the ConfigSecret
 	ConfigHashAnnotation = "cassandra.datastax.com/config-hash"
 
+	// ClientCertificateHashAnnotation is the operator's annotation for the
+	// hash of the certificate of the client encryption, which restarts the
+	// nodes when the certificate is renewed
+	ClientCertificateHashAnnotation = "cassandra.datastax.com/client-certificate-hash"
+
+	// InternodeKeystoresHashAnnotation is the operator's annotation for the
+	// hash of the keystores of the secretName of the internode encryption
+	InternodeKeystoresHashAnnotation = "cassandra.datastax.com/internode-keystores-hash"
+
+	// JmxCredentialsVersionAnnotation is the operator's annotation for the
+	// UID and resource version of the credentials secret of remote JMX, which
+	// restarts the nodes when the secret changes
+	JmxCredentialsVersionAnnotation = "cassandra.datastax.com/jmx-credentials-version"
+
+	// AdditionalLabelsAnnotation and AdditionalAnnotationsAnnotation list the
+	// keys of spec.additionalLabels and spec.additionalAnnotations the operator
+	// set on a resource, so that it removes the ones taken out of the spec
+	AdditionalLabelsAnnotation      = "cassandra.datastax.com/additional-labels"
+	AdditionalAnnotationsAnnotation = "cassandra.datastax.com/additional-annotations"
+
+	// BackupAgentConfigHashAnnotation is the hash of the configuration of the
+	// backup agent, which restarts the pods when it changes
+	BackupAgentConfigHashAnnotation = "cassandra.datastax.com/backup-agent-config-hash"
+
+	// MetricsCollectorConfigHashAnnotation is the annotation of the server
+	// pods for the hash of the configuration of the MCAC agent
+	MetricsCollectorConfigHashAnnotation = "cassandra.datastax.com/metrics-collector-config-hash"
+
+	// LoggingLevelsAnnotation is the annotation of the server pods for the
+	// logging levels the operator set through the management API, as JSON
+	LoggingLevelsAnnotation = "cassandra.datastax.com/logging-levels"
+
+	// LiveSettingsAnnotation is the annotation of the server pods for the
+	// settings of cassandra.yaml the operator changed through the management
+	// API without a restart, as JSON
+	LiveSettingsAnnotation = "cassandra.datastax.com/live-settings"
+
+	// SetLogLevelAnnotation is the annotation of a datacenter that sets the
+	// levels of loggers of all its nodes at runtime, as comma separated
+	// logger=LEVEL entries such as org.apache.cassandra.db=DEBUG
+	SetLogLevelAnnotation = "cassandra.datastax.com/set-log-level"
+
 	// CassNodeState
 	CassNodeState = "cassandra.datastax.com/node-state"
 
+	// PausedAnnotation stops the operator from reconciling a CassandraDatacenter while it is "true"
+	PausedAnnotation = "cassandra.datastax.com/paused"
+
+	// Finalizer holds the deletion of a CassandraDatacenter until the operator has cleaned up after it
+	Finalizer = "finalizer.cassandra.datastax.com"
+
+	// DeletionProtectionFinalizer holds the deletion of the PVCs of a
+	// datacenter with deletionProtection until its deletion is confirmed, so
+	// that deleting its namespace does not delete their volumes
+	DeletionProtectionFinalizer = "deletion-protection.cassandra.datastax.com"
+
+	// ConfirmDeletionAnnotation confirms the deletion of a CassandraDatacenter
+	// with deletionProtection while it is "true"
+	ConfirmDeletionAnnotation = "cassandra.datastax.com/confirm-deletion"
+
+	// MigratingToAnnotation records on a datacenter the name of the datacenter
+	// that migrates its data and clients with spec.migrateFrom, once it has
+	// taken over the client service of the datacenter
+	MigratingToAnnotation = "cassandra.datastax.com/migrating-to"
+
+	// VolumeFailureAnnotation records on a PVC when the operator first saw its volume fail
+	VolumeFailureAnnotation = "cassandra.datastax.com/volume-failure-time"
+
+	// DrainedForNodeAnnotation records on a pod the name of the cordoned k8s worker its node was drained for
+	DrainedForNodeAnnotation = "cassandra.datastax.com/drained-for-node"
+
+	// StatefulSetSpecHashAnnotation records on the StatefulSet of a rack the hash
+	// of its spec as the operator last left it, to detect the edits of others
+	StatefulSetSpecHashAnnotation = "cassandra.datastax.com/statefulset-spec-hash"
+
+	// BroadcastRpcAddressAnnotation records on a pod the address of its load
+	// balancer, which the node broadcasts to the clients
+	BroadcastRpcAddressAnnotation = "cassandra.datastax.com/broadcast-rpc-address"
+
+	// LoadBalancerPodLabel is the label of the load balancer services of the
+	// pods, whose value is the name of their pod
+	LoadBalancerPodLabel = "cassandra.datastax.com/load-balancer-pod"
+
+	// SniPodLabel is the label of the services the SNI Ingress routes the
+	// host names of the pods to, whose value is the name of their pod
+	SniPodLabel = "cassandra.datastax.com/sni-pod"
+
+	// MgmtApiJobAnnotationPrefix prefixes the annotations that record on a pod the ID of a running job of the management API
+	MgmtApiJobAnnotationPrefix = "jobs.cassandra.datastax.com/"
+
 	// Progress states for status
 	ProgressUpdating ProgressState = "Updating"
 	ProgressReady    ProgressState = "Ready"
@@ -55,11 +157,47 @@ const (
 // This type exists so there's no chance of pushing random strings to our progress status
 type ProgressState string
 
+// DatacenterPhase summarizes the state of the datacenter in one word, for
+// kubectl get
+type DatacenterPhase string
+
+const (
+	PhaseInitializing DatacenterPhase = "Initializing"
+	PhaseReady        DatacenterPhase = "Ready"
+	PhaseUpdating     DatacenterPhase = "Updating"
+	PhaseStopped      DatacenterPhase = "Stopped"
+	PhaseError        DatacenterPhase = "Error"
+	PhaseResuming     DatacenterPhase = "Resuming"
+	PhaseHibernated   DatacenterPhase = "Hibernated"
+)
+
+type SuperuserSecretRotationPolicy string
+
+const (
+	SuperuserSecretRotationManual SuperuserSecretRotationPolicy = "Manual"
+	SuperuserSecretRotationOnline SuperuserSecretRotationPolicy = "Online"
+)
+
+// CassandraUser is a CQL role managed by the operator. The role is named
+// after the username key of the secret, and logs in with its password key.
+// The password of the role is changed when the secret changes, and the role
+// is dropped when the user is removed from the spec.
 type CassandraUser struct {
 	SecretName string `json:"secretName"`
 	Superuser  bool   `json:"superuser"`
 }
 
+// CassandraUserStatus is a CQL role the operator created from the users of
+// the spec
+type CassandraUserStatus struct {
+	Username   string `json:"username"`
+	SecretName string `json:"secretName"`
+	Superuser  bool   `json:"superuser,omitempty"`
+	// The timestamp at which the role was last upserted
+	// +optional
+	Upserted metav1.Time `json:"upserted,omitempty"`
+}
+
 // CassandraDatacenterSpec defines the desired state of a CassandraDatacenter
 // +k8s:openapi-gen=true
 type CassandraDatacenterSpec struct {
@@ -73,13 +211,18 @@ type CassandraDatacenterSpec struct {
 
 	// Version string for config builder,
 	// used to generate Cassandra server configuration
-	// +kubebuilder:validation:Pattern=(6\.8\.\d+)|(3\.11\.\d+)|(4\.0\.\d+)
+	// +kubebuilder:validation:Pattern=(6\.8\.\d+)|(3\.11\.\d+)|(4\.0\.\d+)|(4\.1\.\d+)|(5\.0\.\d+)
 	ServerVersion string `json:"serverVersion"`
 
 	// Cassandra server image name.
 	// More info: https://kubernetes.io/docs/concepts/containers/images
 	ServerImage string `json:"serverImage,omitempty"`
 
+	// Secrets of the private registries the images of the pods are pulled
+	// from, added to the pods along with the pull secrets of the operator
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
 	// Server type: "cassandra" or "dse"
 	// +kubebuilder:validation:Enum=cassandra;dse
 	ServerType string `json:"serverType"`
@@ -110,6 +253,28 @@ type CassandraDatacenterSpec struct {
 	// that an update to the secret will trigger an update of the StatefulSets.
 	ConfigSecret string `json:"configSecret,omitempty"`
 
+	// Names of more secrets of the config like configSecret, merged in order over the
+	// config of configSecret: the options of each secret take precedence over the ones of
+	// the previous secrets, and the sections and nested objects are merged rather than
+	// replaced, so that defaults and overrides can live in separate secrets. The operator
+	// watches them like configSecret.
+	// +optional
+	ConfigSecrets []string `json:"configSecrets,omitempty"`
+
+	// Name of a ConfigMap that contains configuration for Cassandra like configSecret, in
+	// its config property, for the options that are not sensitive. The configs of
+	// configSecret and configSecrets are merged over it. The operator watches it like
+	// configSecret.
+	// +optional
+	ConfigConfigMap string `json:"configConfigMap,omitempty"`
+
+	// Computes the heap, the size of the young generation and the garbage collector of
+	// Cassandra from the memory and the CPUs of the resources: G1 with Cassandra 4.0, CMS
+	// with Cassandra 3.11. The JVM options set in the config take precedence. Changing the
+	// resources then restarts the pods with the new settings.
+	// +optional
+	AutoTuneJvm bool `json:"autoTuneJvm,omitempty"`
+
 	// Config for the Management API certificates
 	ManagementApiAuth ManagementApiAuthConfig `json:"managementApiAuth,omitempty"`
 
@@ -130,6 +295,10 @@ type CassandraDatacenterSpec struct {
 	// the number of racks cannot easily be changed once a datacenter is deployed.
 	Racks []Rack `json:"racks,omitempty"`
 
+	// Defines a rack for every zone of the k8s workers when no racks are listed
+	// +optional
+	ZoneRacks *ZoneRacksConfig `json:"zoneRacks,omitempty"`
+
 	// Describes the persistent storage request of each server node
 	StorageConfig StorageConfig `json:"storageConfig"`
 
@@ -147,33 +316,193 @@ type CassandraDatacenterSpec struct {
 	// will re-attach when the CassandraDatacenter workload is resumed.
 	Stopped bool `json:"stopped,omitempty"`
 
+	// How many nodes may start at once when a stopped CassandraDatacenter is resumed.
+	// Once a node is ready, the nodes that already joined the cluster before the
+	// datacenter was stopped are started without waiting for each other to be ready,
+	// since they already have their data. The start calls are still made one at a time.
+	// Defaults to 1, starting one node at a time.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ResumeParallelism int32 `json:"resumeParallelism,omitempty"`
+
+	// A hibernated CassandraDatacenter keeps a single node per rack running, or a single
+	// node with singleNode, so that the schema and the system tables stay available while
+	// the other nodes are stopped. Disabling it resumes the stopped nodes. Stopped takes
+	// precedence over it.
+	// +optional
+	Hibernate *HibernateConfig `json:"hibernate,omitempty"`
+
+	// Decommission every node before the CassandraDatacenter is deleted, so they are removed
+	// from the cluster rather than left behind in the ring of the other datacenters. The keyspaces
+	// must not be replicated to this datacenter anymore.
+	DecommissionOnDelete bool `json:"decommissionOnDelete,omitempty"`
+
+	// Hold the deletion of the CassandraDatacenter and of the persistent volume
+	// claims of its nodes until it is confirmed with the
+	// cassandra.datastax.com/confirm-deletion annotation, so that deleting it
+	// or its namespace by mistake does not destroy the data
+	// +optional
+	DeletionProtection bool `json:"deletionProtection,omitempty"`
+
+	// Take ownership of the StatefulSets and services that already exist under
+	// the names the operator gives them, such as the ones of a cluster that was
+	// deployed without the operator. Their pods are rolled forward to the pod
+	// template of the operator. The StatefulSets must have a server-data volume
+	// claim template, for the pods to keep their data.
+	// +optional
+	AdoptExisting bool `json:"adoptExisting,omitempty"`
+
+	// Migrate the data and the clients of another datacenter of the cluster to
+	// this one, and then decommission and delete it, to replace a datacenter
+	// with a new one alongside it.
+	// +optional
+	MigrateFrom *MigrationConfig `json:"migrateFrom,omitempty"`
+
+	// Whether the persistent volume claims of the nodes are deleted when the
+	// datacenter is scaled down and when it is deleted. They are deleted in
+	// both cases by default.
+	// +optional
+	PersistentVolumeClaimRetentionPolicy *PersistentVolumeClaimRetentionPolicy `json:"persistentVolumeClaimRetentionPolicy,omitempty"`
+
+	// Replace the nodes whose persistent volumes failed without waiting for
+	// them to be listed in replaceNodes
+	// +optional
+	AutomaticNodeReplacement *AutomaticNodeReplacementConfig `json:"automaticNodeReplacement,omitempty"`
+
+	// Restarts the pods that are stuck in CrashLoopBackOff, or whose node is
+	// drained or stuck joining the ring, and replaces their nodes if they are
+	// still stuck after the restart, within a budget of remediations per hour
+	// +optional
+	Remediation *RemediationConfig `json:"remediation,omitempty"`
+
+	// Moves the pods and data off the k8s workers that are tainted for
+	// maintenance. Without it, the taints of the VMware PSP are only handled
+	// when the operator runs with ENABLE_VMWARE_PSP.
+	// +optional
+	NodeMaintenancePolicy *NodeMaintenancePolicy `json:"nodeMaintenancePolicy,omitempty"`
+
+	// How the nodes are drained before their server containers are stopped. By
+	// default the preStop hook drains the node within the grace period of the
+	// pod, and the operator drains the nodes before a stopped datacenter is
+	// scaled down.
+	// +optional
+	Drain *DrainConfig `json:"drain,omitempty"`
+
+	// Tunes the probes of the server container, and adds a startup probe that
+	// holds off the other probes while a node with large data volumes starts.
+	// The probes of the podTemplateSpec take precedence.
+	// +optional
+	Probes *ProbesConfig `json:"probes,omitempty"`
+
 	// Container image for the config builder init container.
 	ConfigBuilderImage string `json:"configBuilderImage,omitempty"`
 
+	// Where the configuration files of Cassandra are rendered: config-builder, the
+	// default, renders them in the server-config-init container with the config builder
+	// image, and operator renders them in the operator into a ConfigMap of each rack that
+	// the server-config-init container copies. Only Cassandra supports operator, with the
+	// cassandra-yaml of the config and the heap and additional-jvm-opts of its JVM options.
+	// +kubebuilder:validation:Enum=config-builder;operator
+	// +optional
+	ConfigRenderer ConfigRenderer `json:"configRenderer,omitempty"`
+
 	// Indicates that configuration and container image changes should only be pushed to
-	// the first rack of the datacenter
+	// the first rack of the datacenter. Once the canary pods are upgraded, the upgrade
+	// pauses with the CanaryUpgradePaused condition until canaryUpgradeApproved is set.
 	CanaryUpgrade bool `json:"canaryUpgrade,omitempty"`
 
-	// The number of nodes that will be updated when CanaryUpgrade is true. Note that the value is
-	// either 0 or greater than the rack size, then all nodes in the rack will get updated.
+	// The number of nodes that will be updated when CanaryUpgrade is true, 1 when unset. If the
+	// value is greater than the rack size, then all nodes in the rack will get updated.
 	CanaryUpgradeCount int32 `json:"canaryUpgradeCount,omitempty"`
 
+	// Push canary upgrades to canaryUpgradeCount nodes of every rack, rather than of only
+	// the first rack
+	CanaryUpgradeAllRacks bool `json:"canaryUpgradeAllRacks,omitempty"`
+
+	// Approves a canary upgrade that is waiting for approval, so the remaining nodes get
+	// upgraded. The operator will set this back to false once the upgrade proceeds.
+	CanaryUpgradeApproved bool `json:"canaryUpgradeApproved,omitempty"`
+
+	// Runs upgradesstables on the nodes, rack by rack, once they were all
+	// upgraded to a new major version of the server, so that their sstables are
+	// rewritten in its format
+	// +optional
+	UpgradeSSTables *UpgradeSSTablesConfig `json:"upgradeSSTables,omitempty"`
+
+	// Rolls out the changes of the config first to the canary pods of every rack,
+	// and to the rest of the pods once the canary pods are back to Up/Normal and pass
+	// a health check. canaryUpgrade takes precedence over it.
+	// +optional
+	ConfigRolloutStrategy *ConfigRolloutStrategy `json:"configRolloutStrategy,omitempty"`
+
 	// Turning this option on allows multiple server pods to be created on a k8s worker node.
 	// By default the operator creates just one server pod per k8s worker node using k8s
 	// podAntiAffinity and requiredDuringSchedulingIgnoredDuringExecution.
 	AllowMultipleNodesPerWorker bool `json:"allowMultipleNodesPerWorker,omitempty"`
 
+	// Isolates the server pods from each other when allowMultipleNodesPerWorker
+	// places several of them on a k8s worker node. Cannot be combined with
+	// hostNetwork, as the nodes all listen on the same ports.
+	// +optional
+	MultipleNodesPerWorker *MultipleNodesPerWorkerConfig `json:"multipleNodesPerWorker,omitempty"`
+
+	// Relaxes the anti-affinity that keeps the server pods on different k8s worker nodes,
+	// for instance to spread them across zones instead, or to only prefer placing them
+	// apart. Ignored when allowMultipleNodesPerWorker is set. Racks can override it.
+	// +optional
+	PodAntiAffinity *PodAntiAffinityConfig `json:"podAntiAffinity,omitempty"`
+
+	// Spreads the server pods evenly over the domains of the topology keys,
+	// such as the workers, in addition to the podAntiAffinity. The constraints
+	// without a labelSelector select the server pods of the datacenter.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
 	// This secret defines the username and password for the Cassandra server superuser.
 	// If it is omitted, we will generate a secret instead.
 	SuperuserSecretName string `json:"superuserSecretName,omitempty"`
 
+	// How a change of the password of the superuser secret is applied. With
+	// Online, the operator changes the password of the superuser role through
+	// the management API while the nodes keep running. With Manual, the
+	// default, the password must be changed by hand.
+	// +kubebuilder:validation:Enum=Manual;Online
+	// +optional
+	SuperuserSecretRotationPolicy SuperuserSecretRotationPolicy `json:"superuserSecretRotationPolicy,omitempty"`
+
 	// The k8s service account to use for the server pods
 	ServiceAccount string `json:"serviceAccount,omitempty"`
 
+	// The priority class of the server pods, so that they are not the first to
+	// be preempted or evicted when their workers are under pressure
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// The runtime class of the server pods, such as one that runs them in
+	// gVisor or Kata Containers
+	// +optional
+	RuntimeClassName string `json:"runtimeClassName,omitempty"`
+
+	// The scheduler of the server pods, when it is not the default scheduler
+	// +optional
+	SchedulerName string `json:"schedulerName,omitempty"`
+
 	// Whether to do a rolling restart at the next opportunity. The operator will set this back
 	// to false once the restart is in progress.
 	RollingRestartRequested bool `json:"rollingRestartRequested,omitempty"`
 
+	// Limits the rolling restart requested with rollingRestartRequested to some of
+	// the pods, and sets how many pods of a rack are restarted at the same time.
+	// The settings are read when the restart is requested; changing them while a
+	// restart is in progress has no effect on it.
+	RollingRestart *RollingRestartConfig `json:"rollingRestart,omitempty"`
+
+	// Restricts rolling restarts, upgrades and configuration rollouts to a
+	// recurring window. Outside the window they wait with the
+	// MaintenancePending condition.
+	// +optional
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
 	// A map of label keys and values to restrict Cassandra node scheduling to k8s workers
 	// with matchiing labels.
 	// More info: https://kubernetes.io/docs/concepts/configuration/assign-pod-node/#nodeselector
@@ -181,26 +510,64 @@ type CassandraDatacenterSpec struct {
 
 	// Rack names in this list are set to the latest StatefulSet configuration
 	// even if Cassandra nodes are down. Use this to recover from an upgrade that couldn't
-	// roll out.
+	// roll out. When the configuration changes fields of the StatefulSet that cannot be
+	// updated, such as its volumeClaimTemplates, the StatefulSet is deleted without its
+	// pods and recreated.
 	ForceUpgradeRacks []string `json:"forceUpgradeRacks,omitempty"`
 
+	// Reverts the edits of the StatefulSets of the racks that were not made by the
+	// operator, such as a kubectl edit of their resources, and emits a
+	// RevertedStatefulSetDrift event for each. Without it, such edits stay until
+	// the next change of the datacenter.
+	// +optional
+	RevertStatefulSetDrift bool `json:"revertStatefulSetDrift,omitempty"`
+
 	DseWorkloads *DseWorkloads `json:"dseWorkloads,omitempty"`
 
 	// PodTemplate provides customisation options (labels, annotations, affinity rules, resource requests, and so on) for the cassandra pods
 	PodTemplateSpec *corev1.PodTemplateSpec `json:"podTemplateSpec,omitempty"`
 
+	// Containers added to the Cassandra pods, after the containers of the operator.
+	// A container with the name of a container of the operator, such as cassandra or
+	// server-system-logger, is merged into it instead: the fields it sets override
+	// the fields of the operator, and its env, ports and volumeMounts are merged by
+	// name. Sidecars take precedence over the containers of podTemplateSpec.
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// Init containers added to the Cassandra pods, after the init containers of the
+	// operator. They are merged with the init containers of the operator, such as
+	// server-config-init, the same way as sidecars.
+	// +optional
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+
 	// Cassandra users to bootstrap
 	Users []CassandraUser `json:"users,omitempty"`
 
 	Networking *NetworkingConfig `json:"networking,omitempty"`
 
+	// IP addresses or hostnames of seed nodes to add to the seeds of the cluster, such as the
+	// nodes of datacenters that are not managed by the operator. Hostnames are resolved
+	// again periodically.
 	AdditionalSeeds []string `json:"additionalSeeds,omitempty"`
 
-	// Deprecated: Reaper's sidecar mode has too many problems in Kubernetes for it to
-	// usable. In order for it to work reliably, changes in Reaper would be needed. See
-	// https://github.com/thelastpickle/cassandra-reaper/issues/956 for details. Because
-	// those changes were not implemented in Reaper and because Reaper support was instead
-	// added through k8ssandra, this field will be removed in the 1.8.0 release.
+	// CassandraDatacenters, possibly in other namespaces, whose seed nodes are added to the
+	// seeds of this datacenter. The namespace defaults to the namespace of this datacenter.
+	AdditionalSeedDatacenters []corev1.ObjectReference `json:"additionalSeedDatacenters,omitempty"`
+
+	// Seeds of the datacenters of the cluster in other Kubernetes clusters, as addresses
+	// or as the seeds ConfigMaps their operators publish with spec.federation, copied to
+	// the namespace of this datacenter.
+	// +optional
+	ClusterSeeds []ClusterSeedsSource `json:"clusterSeeds,omitempty"`
+
+	// Joins the datacenter with the datacenters of the cluster in other Kubernetes clusters.
+	// +optional
+	Federation *FederationConfig `json:"federation,omitempty"`
+
+	// Deploys Cassandra Reaper next to the datacenter, in a deployment of its own rather
+	// than as the sidecar of earlier releases, and registers the cluster with it to
+	// schedule its repairs. Reaper connects to the nodes with the credentials of spec.jmx.
 	Reaper *ReaperConfig `json:"reaper,omitempty"`
 
 	// Configuration for disabling the simple log tailing sidecar container. Our default is to have it enabled.
@@ -209,17 +576,247 @@ type CassandraDatacenterSpec struct {
 	// Container image for the log tailing sidecar container.
 	SystemLoggerImage string `json:"systemLoggerImage,omitempty"`
 
+	// Format of the logs of Cassandra, which the server-system-logger sidecar prints:
+	// Text, the pattern of Cassandra, or JSON, one object per line for log agents. With
+	// JSON, the operator generates the logback.xml of the nodes, and the standard output
+	// of the cassandra container is in JSON as well. Changing it restarts the pods.
+	// +kubebuilder:validation:Enum=Text;JSON
+	// +optional
+	LogFormat LogFormat `json:"logFormat,omitempty"`
+
+	// Levels of the loggers of Cassandra. The operator generates the logback.xml of
+	// the nodes, with the levels in a ConfigMap logback rescans, and sets them through
+	// the management API of the running nodes, so changing them does not restart the pods.
+	// Adding it restarts the pods once, to switch them to the generated logback.xml.
+	// +optional
+	LoggingConfig *LoggingConfig `json:"loggingConfig,omitempty"`
+
+	// Labels added to every resource the operator creates for the datacenter: the
+	// statefulsets and their pods, the services, the pod disruption budgets, the secrets
+	// and the ConfigMaps. The labels of the operator take precedence over these. Changing
+	// them restarts the pods, like any other change of the pod template.
+	// +optional
+	AdditionalLabels map[string]string `json:"additionalLabels,omitempty"`
+
+	// Annotations added to every resource the operator creates for the datacenter, for
+	// instance for cost allocation or service mesh injection. The annotations of the
+	// operator take precedence over these. Changing them restarts the pods.
+	// +optional
+	AdditionalAnnotations map[string]string `json:"additionalAnnotations,omitempty"`
+
 	// AdditionalServiceConfig allows to define additional parameters that are included in the created Services. Note, user can override values set by cass-operator and doing so could break cass-operator functionality.
 	// Avoid label "cass-operator" and anything that starts with "cassandra.datastax.com/"
 	AdditionalServiceConfig ServiceConfig `json:"additionalServiceConfig,omitempty"`
 
 	// Tolerations applied to the Cassandra pod. Note that these cannot be overridden with PodTemplateSpec.
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Adds a sidecar container to the Cassandra pods that uploads snapshots to, and
+	// downloads them from, object storage for CassandraBackup and CassandraRestore.
+	BackupSidecar *BackupSidecarConfig `json:"backupSidecar,omitempty"`
+
+	// Injects the Medusa backup agent in the Cassandra pods, which a backup operator
+	// drives through its gRPC API without having to mutate the pods.
+	// +optional
+	BackupAgent *BackupAgentConfig `json:"backupAgent,omitempty"`
+
+	// Repairs the operator runs on a schedule. The nodes of the datacenter are
+	// repaired one at a time, so no two repairs of a schedule work on the same
+	// token ranges at once.
+	Repairs []RepairSchedule `json:"repairs,omitempty"`
+
+	// Keyspaces whose NetworkTopologyStrategy replication factor in the
+	// datacenter the operator keeps as declared, altering them whenever it
+	// changes. The factors of the other datacenters are left to them. When the
+	// replication factor of the datacenter grows, its nodes are then repaired
+	// one at a time.
+	Keyspaces []KeyspaceReplication `json:"keyspaces,omitempty"`
+
+	// Keep the replication of system_auth, system_distributed and system_traces in
+	// step with the datacenters of the cluster, with as many replicas in each of them
+	// as it has nodes, up to 3. A datacenter is added to it once its nodes have joined
+	// the cluster, and removed from it before its nodes are decommissioned with
+	// decommissionOnDelete. Its replicas are lowered before it is scaled down.
+	ManageSystemKeyspaces bool `json:"manageSystemKeyspaces,omitempty"`
+
+	// Full query logging of Cassandra 4.0 nodes, which logs every query to
+	// binary files that can be replayed or inspected with fqltool.
+	FullQueryLogging *QueryLoggingConfig `json:"fullQueryLogging,omitempty"`
+
+	// Audit logging of Cassandra 4.0 nodes, which logs the queries and logins
+	// of clients, and whether they succeeded.
+	AuditLogging *QueryLoggingConfig `json:"auditLogging,omitempty"`
+
+	// Change data capture of the nodes, which keeps the commit log segments of
+	// the tables with cdc enabled in the cdc_raw_directory, for instance for the
+	// forwarder sidecar to send on the changes.
+	// +optional
+	CDC *CDCConfig `json:"cdc,omitempty"`
+
+	// Settings of the PodDisruptionBudget of the datacenter. Without them the
+	// budget keeps all but one node of the datacenter available.
+	PodDisruptionBudget *PodDisruptionBudgetConfig `json:"podDisruptionBudget,omitempty"`
+
+	// Has the Prometheus operator scrape the metrics of the nodes, by creating
+	// a ServiceMonitor or a PodMonitor for the datacenter.
+	Monitoring *MonitoringConfig `json:"monitoring,omitempty"`
+
+	// Settings of the Metric Collector for Apache Cassandra (MCAC) agent that
+	// runs in the server containers and serves the metrics of the nodes.
+	MetricsCollector *MetricsCollectorConfig `json:"metricsCollector,omitempty"`
+
+	// Encryption of the traffic of the nodes, set up by the operator
+	Encryption *EncryptionConfig `json:"encryption,omitempty"`
+
+	// Reads the superuser secret and the config secret from HashiCorp Vault
+	// instead of kubernetes secrets.
+	// +optional
+	Vault *VaultConfig `json:"vault,omitempty"`
+
+	// Accepts authenticated JMX connections from outside the pods, such as
+	// the ones of Reaper.
+	// +optional
+	Jmx *JmxConfig `json:"jmx,omitempty"`
 }
 
 type NetworkingConfig struct {
 	NodePort    *NodePortConfig `json:"nodePort,omitempty"`
 	HostNetwork bool            `json:"hostNetwork,omitempty"`
+
+	// Replaces the default ports of the nodes, for example when they are
+	// already taken on the workers with hostNetwork. Cannot be combined with nodePort.
+	// +optional
+	Ports *PortsConfig `json:"ports,omitempty"`
+
+	// What the nodes broadcast as their broadcast_address and
+	// broadcast_rpc_address, and the seeds of the additional seed datacenters
+	// are reached at: the IP of the pod, the IP of its worker, or the DNS name
+	// of the pod. Defaults to HostIP with nodePort, and PodIP otherwise, which
+	// is the IP of the worker with hostNetwork.
+	// +kubebuilder:validation:Enum=PodIP;HostIP;ServiceDNS
+	// +optional
+	AddressType AddressType `json:"addressType,omitempty"`
+
+	// Routes the CQL connections of external drivers using the SNI proxy
+	// pattern to the nodes through a TLS passthrough Ingress, on a host name
+	// of each pod. Requires the client encryption of spec.encryption.
+	// +optional
+	SNI *SNIConfig `json:"sni,omitempty"`
+}
+
+// AddressType is the kind of address the nodes broadcast to each other and to
+// the clients
+type AddressType string
+
+const (
+	AddressTypePodIP      AddressType = "PodIP"
+	AddressTypeHostIP     AddressType = "HostIP"
+	AddressTypeServiceDNS AddressType = "ServiceDNS"
+)
+
+// ConfigRenderer is what renders the configuration files of the nodes
+type ConfigRenderer string
+
+const (
+	ConfigRendererConfigBuilder ConfigRenderer = "config-builder"
+	ConfigRendererOperator      ConfigRenderer = "operator"
+)
+
+// LogFormat is the format of the logs of Cassandra
+type LogFormat string
+
+const (
+	LogFormatText LogFormat = "Text"
+	LogFormatJSON LogFormat = "JSON"
+)
+
+// LoggingConfig sets the levels of the loggers of Cassandra
+type LoggingConfig struct {
+	// Level of the root logger, INFO by default. The system.log only gets the
+	// events at INFO and above, the debug.log all of them.
+	// +kubebuilder:validation:Enum=ALL;TRACE;DEBUG;INFO;WARN;ERROR;OFF
+	// +optional
+	RootLevel string `json:"rootLevel,omitempty"`
+
+	// Levels of loggers by name, such as org.apache.cassandra.gms: TRACE. The
+	// org.apache.cassandra logger is at DEBUG by default.
+	// +optional
+	Loggers map[string]string `json:"loggers,omitempty"`
+}
+
+// RootLoggerName is the name of the root logger of logback
+const RootLoggerName = "ROOT"
+
+// ClusterSeedsSource is a source of seeds of the datacenters of the cluster in
+// other Kubernetes clusters
+type ClusterSeedsSource struct {
+	// IP addresses or host names of seeds
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+
+	// A ConfigMap published by the operator of another Kubernetes cluster with
+	// spec.federation. Its seeds are skipped until it exists.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
+// FederationConfig publishes what the datacenters of the cluster in other
+// Kubernetes clusters need to join this one, or imports it
+type FederationConfig struct {
+	// Publishes the seeds of this datacenter to the <cluster>-<datacenter>-seeds
+	// ConfigMap, and exports the superuser credentials and the internode CA to the
+	// <cluster>-<datacenter>-federation secret, for copying to the other
+	// Kubernetes clusters
+	// +optional
+	Publish bool `json:"publish,omitempty"`
+
+	// A federation secret exported by a datacenter in another Kubernetes cluster,
+	// copied to the namespace of this datacenter, whose superuser credentials and
+	// internode CA this datacenter uses
+	// +optional
+	ImportSecret string `json:"importSecret,omitempty"`
+}
+
+// SNIConfig configures the Ingress of the SNI host names of the pods, which
+// are the name of the pod followed by the domain
+type SNIConfig struct {
+	// The domain of the host names of the pods, whose DNS resolves to the
+	// ingress controller
+	Domain string `json:"domain"`
+
+	// The class of the ingress controller, which must support TLS
+	// passthrough. Defaults to nginx.
+	// +optional
+	IngressClass string `json:"ingressClass,omitempty"`
+
+	// Annotations added to the Ingress, for the settings of other ingress
+	// controllers
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// PortsConfig replaces the ports the nodes listen on. The operator sets them
+// in cassandra.yaml and the JVM options of the nodes, and uses them for the
+// ports of the containers and of the services.
+type PortsConfig struct {
+	// The CQL port, native_transport_port of cassandra.yaml. Defaults to 9042. Cannot be changed.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Native int `json:"native,omitempty"`
+
+	// The internode port, storage_port of cassandra.yaml. Defaults to 7000. Cannot be changed.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Storage int `json:"storage,omitempty"`
+
+	// The JMX port. Defaults to 7199. Nodetool in the server containers then
+	// needs the port as well.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Jmx int `json:"jmx,omitempty"`
 }
 
 type NodePortConfig struct {
@@ -227,6 +824,19 @@ type NodePortConfig struct {
 	NativeSSL    int `json:"nativeSSL,omitempty"`
 	Internode    int `json:"internode,omitempty"`
 	InternodeSSL int `json:"internodeSSL,omitempty"`
+
+	// The type of the service of the ports, NodePort by default. A LoadBalancer
+	// service allocates the same ports on the workers as well.
+	// +kubebuilder:validation:Enum=NodePort;LoadBalancer
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+
+	// Creates a LoadBalancer service for each pod, whose address the node
+	// broadcasts to the clients as its broadcast_rpc_address, so that clients
+	// outside of the Kubernetes cluster connect to each node through its own
+	// load balancer. The nodes only start once their load balancer has an address.
+	// +optional
+	LoadBalancerPerPod bool `json:"loadBalancerPerPod,omitempty"`
 }
 
 // Is the NodePort service enabled?
@@ -234,15 +844,233 @@ func (dc *CassandraDatacenter) IsNodePortEnabled() bool {
 	return dc.Spec.Networking != nil && dc.Spec.Networking.NodePort != nil
 }
 
+// GetNodePortServiceType returns the type of the service of the nodePorts
+func (dc *CassandraDatacenter) GetNodePortServiceType() corev1.ServiceType {
+	if dc.IsNodePortEnabled() && dc.Spec.Networking.NodePort.ServiceType != "" {
+		return dc.Spec.Networking.NodePort.ServiceType
+	}
+	return corev1.ServiceTypeNodePort
+}
+
+// IsLoadBalancerPerPodEnabled returns whether each pod has its own load
+// balancer service
+func (dc *CassandraDatacenter) IsLoadBalancerPerPodEnabled() bool {
+	return dc.IsNodePortEnabled() && dc.Spec.Networking.NodePort.LoadBalancerPerPod
+}
+
+// GetAddressType returns the kind of address the nodes broadcast
+func (dc *CassandraDatacenter) GetAddressType() AddressType {
+	if dc.Spec.Networking != nil && dc.Spec.Networking.AddressType != "" {
+		return dc.Spec.Networking.AddressType
+	}
+	if dc.IsNodePortEnabled() {
+		return AddressTypeHostIP
+	}
+	return AddressTypePodIP
+}
+
+// GetLogFormat returns the format of the logs of Cassandra, Text by default
+func (dc *CassandraDatacenter) GetLogFormat() LogFormat {
+	if dc.Spec.LogFormat != "" {
+		return dc.Spec.LogFormat
+	}
+	return LogFormatText
+}
+
+// UsesGeneratedLogback returns whether the operator generates the
+// logback.xml of the nodes, for the JSON log format or the logging levels
+func (dc *CassandraDatacenter) UsesGeneratedLogback() bool {
+	return dc.GetLogFormat() == LogFormatJSON || dc.Spec.LoggingConfig != nil
+}
+
+// GetLoggingLevels returns the levels of the loggers of the nodes by name.
+// With the generated logback.xml, these are the root logger and
+// org.apache.cassandra at their defaults and the loggers of
+// spec.loggingConfig. The levels of the set-log-level annotation take
+// precedence over them.
+func (dc *CassandraDatacenter) GetLoggingLevels() map[string]string {
+	levels := map[string]string{}
+	if dc.UsesGeneratedLogback() {
+		levels[RootLoggerName] = "INFO"
+		levels["org.apache.cassandra"] = "DEBUG"
+	}
+	if config := dc.Spec.LoggingConfig; config != nil {
+		if config.RootLevel != "" {
+			levels[RootLoggerName] = config.RootLevel
+		}
+		for name, level := range config.Loggers {
+			levels[name] = level
+		}
+	}
+	// The webhook rejects an annotation that is not valid
+	if overrides, err := parseLogLevels(dc.Annotations[SetLogLevelAnnotation]); err == nil {
+		for name, level := range overrides {
+			levels[name] = level
+		}
+	}
+	return levels
+}
+
+// parseLogLevels parses the logger=LEVEL entries of the set-log-level
+// annotation. The levels are not case sensitive.
+func parseLogLevels(value string) (map[string]string, error) {
+	levels := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("entry '%s' is not logger=LEVEL", entry)
+		}
+		name, level := strings.TrimSpace(parts[0]), strings.ToUpper(strings.TrimSpace(parts[1]))
+		if name != RootLoggerName && !loggerNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("'%s' is not a valid logger name", name)
+		}
+		if !isLoggingLevel(level) {
+			return nil, fmt.Errorf("'%s' is not one of %s", parts[1], strings.Join(loggingLevels, ", "))
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
+
+// Directory the ConfigMap of the logging levels is mounted to in the server
+// containers, and its file the generated logback.xml includes
+const (
+	LogbackConfigDir  = "/opt/logback-config"
+	LogbackLevelsFile = "logback-levels.xml"
+)
+
+// RendersConfigInOperator returns whether the operator renders the
+// configuration files of the nodes, rather than the config builder
+func (dc *CassandraDatacenter) RendersConfigInOperator() bool {
+	return dc.Spec.ConfigRenderer == ConfigRendererOperator
+}
+
+// GetRenderedConfigMapName returns the name of the ConfigMap of the
+// configuration files of the hash the operator renders for the nodes of the
+// rack. Each config has a ConfigMap of its own, so the pods that still run
+// with the previous one keep it until they restart.
+func (dc *CassandraDatacenter) GetRenderedConfigMapName(rackName, configHash string) string {
+	if len(configHash) > 8 {
+		configHash = configHash[:8]
+	}
+	return dc.GetRenderedConfigMapPrefix(rackName) + "-" + configHash
+}
+
+// GetRenderedConfigMapPrefix returns the prefix of the names of the ConfigMaps
+// of the configuration files the operator renders for the nodes of the rack
+func (dc *CassandraDatacenter) GetRenderedConfigMapPrefix(rackName string) string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-" + rackName + "-server-config"
+}
+
+// GetLogbackConfigMapName returns the name of the ConfigMap of the logging
+// levels of the generated logback.xml
+func (dc *CassandraDatacenter) GetLogbackConfigMapName() string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-logback-config"
+}
+
+// GetConfigValidationJobName returns the name of the job that renders the
+// config of the hash before it is rolled out. The name of the datacenter is
+// shortened for the name to fit the job-name label of the pods of the job.
+func (dc *CassandraDatacenter) GetConfigValidationJobName(configHash string) string {
+	if len(configHash) > 8 {
+		configHash = configHash[:8]
+	}
+	suffix := "-config-validation-" + configHash
+	prefix := dc.Name
+	if len(prefix)+len(suffix) > 63 {
+		prefix = strings.TrimRight(prefix[:63-len(suffix)], "-.")
+	}
+	return prefix + suffix
+}
+
+// IsSniEnabled returns whether the pods are reached through the SNI Ingress
+func (dc *CassandraDatacenter) IsSniEnabled() bool {
+	return dc.Spec.Networking != nil && dc.Spec.Networking.SNI != nil
+}
+
+// GetSniHostname returns the SNI host name of a pod, or an empty string
+// without spec.networking.sni
+func (dc *CassandraDatacenter) GetSniHostname(podName string) string {
+	if !dc.IsSniEnabled() {
+		return ""
+	}
+	return podName + "." + strings.TrimSuffix(dc.Spec.Networking.SNI.Domain, ".")
+}
+
+// GetSniIngressClass returns the class of the SNI Ingress
+func (dc *CassandraDatacenter) GetSniIngressClass() string {
+	if dc.IsSniEnabled() && dc.Spec.Networking.SNI.IngressClass != "" {
+		return dc.Spec.Networking.SNI.IngressClass
+	}
+	return "nginx"
+}
+
 func (dc *CassandraDatacenter) IsHostNetworkEnabled() bool {
 	networking := dc.Spec.Networking
 	return networking != nil && networking.HostNetwork
 }
 
+func (dc *CassandraDatacenter) getPorts() PortsConfig {
+	if dc.Spec.Networking == nil || dc.Spec.Networking.Ports == nil {
+		return PortsConfig{}
+	}
+	return *dc.Spec.Networking.Ports
+}
+
+// GetNativePort returns the CQL port the nodes listen on, outside of
+// spec.networking.nodePort
+func (dc *CassandraDatacenter) GetNativePort() int {
+	if port := dc.getPorts().Native; port != 0 {
+		return port
+	}
+	return DefaultNativePort
+}
+
+// GetStoragePort returns the internode port the nodes listen on, outside of
+// spec.networking.nodePort
+func (dc *CassandraDatacenter) GetStoragePort() int {
+	if port := dc.getPorts().Storage; port != 0 {
+		return port
+	}
+	return DefaultInternodePort
+}
+
+// GetJmxPort returns the JMX port the nodes listen on
+func (dc *CassandraDatacenter) GetJmxPort() int {
+	if port := dc.getPorts().Jmx; port != 0 {
+		return port
+	}
+	return JmxPort
+}
+
+// DseWorkloads turns on the workloads of DSE on top of Cassandra. They only
+// apply when the server type is dse.
 type DseWorkloads struct {
+	// Spark, along with DSEFS
 	AnalyticsEnabled bool `json:"analyticsEnabled,omitempty"`
-	GraphEnabled     bool `json:"graphEnabled,omitempty"`
-	SearchEnabled    bool `json:"searchEnabled,omitempty"`
+	// DSE Graph, served over gremlin
+	GraphEnabled bool `json:"graphEnabled,omitempty"`
+	// DSE Search, served by Solr
+	SearchEnabled bool `json:"searchEnabled,omitempty"`
+}
+
+// GetDseWorkloads returns the DSE workloads of the datacenter, or nil when it
+// does not run DSE
+func (dc *CassandraDatacenter) GetDseWorkloads() *DseWorkloads {
+	if dc.Spec.ServerType != "dse" {
+		return nil
+	}
+	return dc.Spec.DseWorkloads
+}
+
+// HasDseWorkloads tells whether any DSE workload is turned on
+func (dc *CassandraDatacenter) HasDseWorkloads() bool {
+	workloads := dc.GetDseWorkloads()
+	return workloads != nil && (workloads.AnalyticsEnabled || workloads.GraphEnabled || workloads.SearchEnabled)
 }
 
 // StorageConfig defines additional storage configurations
@@ -261,13 +1089,117 @@ type AdditionalVolumesSlice []AdditionalVolumes
 type StorageConfig struct {
 	CassandraDataVolumeClaimSpec *corev1.PersistentVolumeClaimSpec `json:"cassandraDataVolumeClaimSpec,omitempty"`
 	AdditionalVolumes            AdditionalVolumesSlice            `json:"additionalVolumes,omitempty"`
+	// Additional data directories of the nodes, each on its own persistent
+	// volume (JBOD). The mount paths of the volumes are added to
+	// data_file_directories after /var/lib/cassandra/data, the data directory
+	// on the cassandraDataVolumeClaimSpec volume.
+	// +optional
+	AdditionalDataVolumes AdditionalVolumesSlice `json:"additionalDataVolumes,omitempty"`
+	// Persistent volume claim spec of a separate volume for the commit log,
+	// for instance on faster storage than the data. The volume is mounted at
+	// /var/lib/cassandra-commitlog, which becomes the commitlog_directory.
+	// +optional
+	CommitLogVolumeClaimSpec *corev1.PersistentVolumeClaimSpec `json:"commitLogVolumeClaimSpec,omitempty"`
 }
 
-// GetRacks is a getter for the Rack slice in the spec
-// It ensures there is always at least one rack
-func (dc *CassandraDatacenter) GetRacks() []Rack {
-	if len(dc.Spec.Racks) >= 1 {
-		return dc.Spec.Racks
+// CDCConfig configures the change data capture of the nodes
+type CDCConfig struct {
+	// Sets cdc_enabled on the nodes. The tables to capture the changes of also
+	// need cdc = true.
+	Enabled bool `json:"enabled"`
+
+	// Persistent volume claim spec of a separate volume for the CDC logs, so
+	// that they cannot fill up the data volume. The volume is mounted at
+	// /var/lib/cassandra-cdc, which becomes the cdc_raw_directory. Without it
+	// the CDC logs are kept in /var/lib/cassandra/cdc_raw on the data volume.
+	// Like the volumes of storageConfig, it cannot be added or removed later,
+	// and only its storage request can grow.
+	// +optional
+	VolumeClaimSpec *corev1.PersistentVolumeClaimSpec `json:"volumeClaimSpec,omitempty"`
+
+	// Adds a sidecar container to the Cassandra pods that reads the CDC logs and
+	// forwards the changes, for instance to Pulsar or Kafka
+	// +optional
+	Forwarder *CDCForwarderConfig `json:"forwarder,omitempty"`
+}
+
+// CDCForwarderConfig configures the sidecar container that forwards the
+// changes in the CDC logs
+type CDCForwarderConfig struct {
+	// Container image of the forwarder
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// A secret holding the configuration of the forwarder, such as the address
+	// and the credentials of the Pulsar or Kafka cluster. Every key of the
+	// secret is exposed to the sidecar as an environment variable.
+	// +optional
+	ConfigSecretName string `json:"configSecretName,omitempty"`
+
+	// Kubernetes resource requests and limits for the forwarder.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// CDCDirectory is the cdc_raw_directory of the nodes when the CDC logs have
+// their own volume
+const CDCDirectory = "/var/lib/cassandra-cdc"
+
+// DefaultCDCDirectory is the cdc_raw_directory of the nodes on the data volume
+const DefaultCDCDirectory = "/var/lib/cassandra/cdc_raw"
+
+// GetCDCDirectory returns the cdc_raw_directory of the nodes, or an empty
+// string if CDC is disabled
+func (dc *CassandraDatacenter) GetCDCDirectory() string {
+	if dc.Spec.CDC == nil || !dc.Spec.CDC.Enabled {
+		return ""
+	}
+	if dc.Spec.CDC.VolumeClaimSpec != nil {
+		return CDCDirectory
+	}
+	return DefaultCDCDirectory
+}
+
+// DefaultDataFileDirectory is the data directory of the nodes on the
+// cassandraDataVolumeClaimSpec volume
+const DefaultDataFileDirectory = "/var/lib/cassandra/data"
+
+// CommitLogDirectory is the commit log directory of the nodes when the commit
+// log has its own volume
+const CommitLogDirectory = "/var/lib/cassandra-commitlog"
+
+// GetCommitLogDirectory returns the commit log directory of the nodes, or an
+// empty string if the commit log is on the data volume
+func (dc *CassandraDatacenter) GetCommitLogDirectory() string {
+	if dc.Spec.StorageConfig.CommitLogVolumeClaimSpec == nil {
+		return ""
+	}
+	return CommitLogDirectory
+}
+
+// GetDataFileDirectories returns the data_file_directories of the nodes, or
+// nil if they only use the default data directory
+func (dc *CassandraDatacenter) GetDataFileDirectories() []string {
+	if len(dc.Spec.StorageConfig.AdditionalDataVolumes) == 0 {
+		return nil
+	}
+
+	directories := []string{DefaultDataFileDirectory}
+	for _, volume := range dc.Spec.StorageConfig.AdditionalDataVolumes {
+		directories = append(directories, volume.MountPath)
+	}
+	return directories
+}
+
+// GetRacks is a getter for the Rack slice in the spec
+// It ensures there is always at least one rack
+func (dc *CassandraDatacenter) GetRacks() []Rack {
+	if len(dc.Spec.Racks) >= 1 {
+		return dc.Spec.Racks
+	}
+
+	if len(dc.Status.ZoneRacks) >= 1 {
+		return dc.Status.ZoneRacks
 	}
 
 	return []Rack{{
@@ -275,162 +1207,1664 @@ func (dc *CassandraDatacenter) GetRacks() []Rack {
 	}}
 }
 
-// ServiceConfig defines additional service configurations.
-type ServiceConfig struct {
-	DatacenterService     ServiceConfigAdditions `json:"dcService,omitempty"`
-	SeedService           ServiceConfigAdditions `json:"seedService,omitempty"`
-	AllPodsService        ServiceConfigAdditions `json:"allpodsService,omitempty"`
-	AdditionalSeedService ServiceConfigAdditions `json:"additionalSeedService,omitempty"`
-	NodePortService       ServiceConfigAdditions `json:"nodePortService,omitempty"`
+// GetRack returns the rack with the given name, or nil if there is none
+func (dc *CassandraDatacenter) GetRack(rackName string) *Rack {
+	racks := dc.GetRacks()
+	for i := range racks {
+		if racks[i].Name == rackName {
+			return &racks[i]
+		}
+	}
+	return nil
+}
+
+// ServiceConfig defines additional service configurations.
+type ServiceConfig struct {
+	DatacenterService     ServiceConfigAdditions `json:"dcService,omitempty"`
+	SeedService           ServiceConfigAdditions `json:"seedService,omitempty"`
+	AllPodsService        ServiceConfigAdditions `json:"allpodsService,omitempty"`
+	AdditionalSeedService ServiceConfigAdditions `json:"additionalSeedService,omitempty"`
+	NodePortService       ServiceConfigAdditions `json:"nodePortService,omitempty"`
+}
+
+// ServiceConfigAdditions exposes additional options for each service
+type ServiceConfigAdditions struct {
+	Labels      map[string]string `json:"additionalLabels,omitempty"`
+	Annotations map[string]string `json:"additionalAnnotations,omitempty"`
+
+	// Type of the service, for instance LoadBalancer with the annotations of an
+	// internal load balancer. Only the dcService and the nodePortService can
+	// change type, since the other services must stay headless for the seeds and
+	// the DNS names of the pods. A headless service is recreated to change type.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Overrides whether the service publishes the addresses of the pods that are
+	// not ready
+	// +optional
+	PublishNotReadyAddresses *bool `json:"publishNotReadyAddresses,omitempty"`
+
+	// Restricts the clients of a LoadBalancer service to these CIDRs, if the
+	// cloud provider supports it
+	// +optional
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+
+	// Routes the external traffic of a NodePort or LoadBalancer service to the
+	// pods of the worker it reaches (Local), or of any worker (Cluster)
+	// +kubebuilder:validation:Enum=Local;Cluster
+	// +optional
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+}
+
+// Rack ...
+type Rack struct {
+	// The rack name
+	// +kubebuilder:validation:MinLength=2
+	Name string `json:"name"`
+
+	// Deprecated. Use nodeAffinityLabels instead. Zone name to pin the rack, using node affinity
+	Zone string `json:"zone,omitempty"`
+
+	//NodeAffinityLabels to pin the rack, using node affinity
+	NodeAffinityLabels map[string]string `json:"nodeAffinityLabels,omitempty"`
+
+	// Node affinity of the pods of the rack. Its required terms are combined with
+	// nodeAffinityLabels, so the pods must match both.
+	// +optional
+	NodeAffinity *corev1.NodeAffinity `json:"nodeAffinity,omitempty"`
+
+	// Tolerations of the pods of the rack, in addition to the tolerations of the
+	// datacenter
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Overrides the podAntiAffinity of the datacenter for the pods of the rack
+	// +optional
+	PodAntiAffinity *PodAntiAffinityConfig `json:"podAntiAffinity,omitempty"`
+
+	// Config of the nodes of the rack, merged over the config of the datacenter: its
+	// options take precedence, and its sections and nested objects are merged rather
+	// than replaced. It cannot be set along with configSecret.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Config json.RawMessage `json:"config,omitempty"`
+}
+
+// ZoneRacksConfig defines a rack for every zone of the k8s workers that can
+// run the datacenter, pinned to its zone with node affinity. The racks are
+// picked when the datacenter is created and kept in its status, so they do
+// not change when zones are added to the k8s cluster later.
+type ZoneRacksConfig struct {
+	// Whether racks are defined from the zones
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// The node label holding the zone of the k8s workers,
+	// topology.kubernetes.io/zone by default
+	// +optional
+	ZoneLabel string `json:"zoneLabel,omitempty"`
+}
+
+const DefaultZoneLabel = "topology.kubernetes.io/zone"
+
+// GetZoneLabel returns the node label holding the zone of the k8s workers
+func (config *ZoneRacksConfig) GetZoneLabel() string {
+	if config.ZoneLabel != "" {
+		return config.ZoneLabel
+	}
+	return DefaultZoneLabel
+}
+
+// PodAntiAffinityConfig defines how the server pods are kept apart from each
+// other
+type PodAntiAffinityConfig struct {
+	// The node label whose values are the domains that hold at most one server
+	// pod, kubernetes.io/hostname by default. Use topology.kubernetes.io/zone on
+	// clusters with one zone per rack to only keep the pods in different zones.
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+
+	// Whether the anti-affinity is only preferred, in which case pods share a
+	// domain when there are not enough of them
+	// +optional
+	Preferred bool `json:"preferred,omitempty"`
+}
+
+// MultipleNodesPerWorkerConfig defines how the server pods that share k8s
+// worker nodes are isolated from each other
+type MultipleNodesPerWorkerConfig struct {
+	// Gives the containers of the server pods requests equal to their limits,
+	// with the cpu of the cassandra container rounded up to whole cores, so that
+	// the pods are of the Guaranteed QoS class and the static CPU manager policy
+	// of the kubelet pins the cassandra containers to dedicated cores. Every
+	// container needs a request or a limit for its cpu and its memory.
+	// +optional
+	DedicatedCpus bool `json:"dedicatedCpus,omitempty"`
+
+	// Keeps the pods of different racks off the same worker, so that losing a
+	// worker only takes down nodes of a single rack. The pods are spread over
+	// the workers when there are enough of them either way.
+	// +optional
+	IsolateRacks bool `json:"isolateRacks,omitempty"`
+}
+
+const DefaultPodAntiAffinityTopologyKey = "kubernetes.io/hostname"
+
+// GetTopologyKey returns the node label of the anti-affinity domains
+func (config *PodAntiAffinityConfig) GetTopologyKey() string {
+	if config.TopologyKey != "" {
+		return config.TopologyKey
+	}
+	return DefaultPodAntiAffinityTopologyKey
+}
+
+type CassandraNodeStatus struct {
+	HostID string `json:"hostID,omitempty"`
+	// The rack of the node, as seen by Cassandra
+	Rack string `json:"rack,omitempty"`
+	// The operation mode of the node, such as NORMAL, JOINING, LEAVING or
+	// MOVING
+	OperationMode string `json:"operationMode,omitempty"`
+	// The amount of data stored by the node, in bytes
+	Load int64 `json:"load,omitempty"`
+	// The version of the schema the node has
+	SchemaVersion string `json:"schemaVersion,omitempty"`
+	// The host name external drivers reach the node on through the SNI
+	// Ingress, with spec.networking.sni
+	SniHostname string `json:"sniHostname,omitempty"`
+}
+
+type CassandraStatusMap map[string]CassandraNodeStatus
+
+type DatacenterConditionType string
+
+const (
+	DatacenterReady               DatacenterConditionType = "Ready"
+	DatacenterInitialized         DatacenterConditionType = "Initialized"
+	DatacenterReplacingNodes      DatacenterConditionType = "ReplacingNodes"
+	DatacenterScalingUp           DatacenterConditionType = "ScalingUp"
+	DatacenterScalingDown         DatacenterConditionType = "ScalingDown"
+	DatacenterUpdating            DatacenterConditionType = "Updating"
+	DatacenterStopped             DatacenterConditionType = "Stopped"
+	DatacenterResuming            DatacenterConditionType = "Resuming"
+	DatacenterHibernated          DatacenterConditionType = "Hibernated"
+	DatacenterRollingRestart      DatacenterConditionType = "RollingRestart"
+	DatacenterValid               DatacenterConditionType = "Valid"
+	DatacenterCanaryUpgradePaused DatacenterConditionType = "CanaryUpgradePaused"
+	DatacenterDecommissioning     DatacenterConditionType = "Decommissioning"
+
+	// DatacenterReconciliationPaused is True while the PausedAnnotation stops
+	// the operator from changing the datacenter
+	DatacenterReconciliationPaused DatacenterConditionType = "ReconciliationPaused"
+
+	// DatacenterMaintenancePending is True while a disruptive action waits for
+	// the maintenance window to open
+	DatacenterMaintenancePending DatacenterConditionType = "MaintenancePending"
+
+	// DatacenterConfigValid is False while the config builder fails to render
+	// the config of the datacenter, whose rollout is then held back
+	DatacenterConfigValid DatacenterConditionType = "ConfigValid"
+
+	// DatacenterUpgradingSSTables is True while the sstables of the nodes are
+	// upgraded after a major upgrade, see spec.upgradeSSTables
+	DatacenterUpgradingSSTables DatacenterConditionType = "UpgradingSSTables"
+
+	// DatacenterSchemaDisagreement is True while the scaling of the datacenter
+	// waits for the live nodes of the cluster to agree on the schema version
+	DatacenterSchemaDisagreement DatacenterConditionType = "SchemaDisagreement"
+
+	// DatacenterReplicationFactorUnsafe is True while the datacenter has fewer
+	// nodes than the highest replication factor of its keyspaces, or would have
+	// once it is scaled down
+	DatacenterReplicationFactorUnsafe DatacenterConditionType = "ReplicationFactorUnsafe"
+
+	// DatacenterRollingUpgrade is True while the pods of the datacenter are
+	// rolled over to a new server image
+	DatacenterRollingUpgrade DatacenterConditionType = "RollingUpgrade"
+
+	// DatacenterRequiresAttention is True while the datacenter cannot make
+	// progress without an action of its administrators, such as approving a
+	// canary upgrade or fixing the config. Its message lists the conditions
+	// that need the action.
+	DatacenterRequiresAttention DatacenterConditionType = "RequiresAttention"
+
+	// DatacenterInvalidConfig is True while the config of the datacenter
+	// cannot be rendered, and its rollout is held back
+	DatacenterInvalidConfig DatacenterConditionType = "InvalidConfig"
+
+	// DatacenterMigrating is True while the datacenter migrates the data and
+	// the clients of the datacenter of spec.migrateFrom, with the phase of the
+	// migration as its reason
+	DatacenterMigrating DatacenterConditionType = "Migrating"
+
+	// DatacenterMonitoringUnavailable is True while the monitor of
+	// spec.monitoring cannot be created, because the Prometheus operator is
+	// not installed or the MCAC agent that serves the metrics is disabled
+	DatacenterMonitoringUnavailable DatacenterConditionType = "MonitoringUnavailable"
+)
+
+// DatacenterCondition follows the conventions of the conditions of the
+// Kubernetes API: the reason is a CamelCase word for the cause of the last
+// transition, and the message a sentence for humans.
+type DatacenterCondition struct {
+	Type               DatacenterConditionType `json:"type"`
+	Status             corev1.ConditionStatus  `json:"status"`
+	Reason             string                  `json:"reason"`
+	Message            string                  `json:"message"`
+	LastTransitionTime metav1.Time             `json:"lastTransitionTime,omitempty"`
+
+	// The generation of the CassandraDatacenter the condition was last set
+	// from
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+func NewDatacenterCondition(conditionType DatacenterConditionType, status corev1.ConditionStatus) *DatacenterCondition {
+	return &DatacenterCondition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  "",
+		Message: "",
+	}
+}
+
+func NewDatacenterConditionWithReason(conditionType DatacenterConditionType, status corev1.ConditionStatus, reason string, message string) *DatacenterCondition {
+	return &DatacenterCondition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// CassandraDatacenterStatus defines the observed state of CassandraDatacenter
+// +k8s:openapi-gen=true
+type CassandraDatacenterStatus struct {
+	Conditions []DatacenterCondition `json:"conditions,omitempty"`
+
+	// Deprecated. Use usersUpserted instead. The timestamp at
+	// which CQL superuser credentials were last upserted to the
+	// management API
+	// +optional
+	SuperUserUpserted metav1.Time `json:"superUserUpserted,omitempty"`
+
+	// The timestamp at which managed cassandra users' credentials
+	// were last upserted to the management API
+	// +optional
+	UsersUpserted metav1.Time `json:"usersUpserted,omitempty"`
+
+	// The CQL roles created from the users of the spec. The roles that are
+	// removed from the spec are dropped.
+	// +optional
+	Users []CassandraUserStatus `json:"users,omitempty"`
+
+	// The mode of internode encryption all the nodes were rolled out with
+	// +optional
+	InternodeEncryptionMode InternodeEncryptionMode `json:"internodeEncryptionMode,omitempty"`
+
+	// The UID and resource version of the superuser secret the password of
+	// the superuser role was last set from, with the Online rotation policy
+	// +optional
+	SuperuserSecretVersion string `json:"superuserSecretVersion,omitempty"`
+
+	// The timestamp at which the password of the superuser role was last
+	// changed after a change of the superuser secret
+	// +optional
+	SuperuserPasswordRotated metav1.Time `json:"superuserPasswordRotated,omitempty"`
+
+	// The hash of the certificates of the encryption the nodes run with
+	// +optional
+	CertificatesHash string `json:"certificatesHash,omitempty"`
+
+	// The timestamp at which the certificates of the encryption changed. The
+	// nodes that can reload them do so once the kubelets have updated the
+	// files of the pods.
+	// +optional
+	CertificatesChanged metav1.Time `json:"certificatesChanged,omitempty"`
+
+	// The levels of the loggers the operator set through the management API
+	// on all the ready nodes, from spec.loggingConfig and the set-log-level
+	// annotation
+	// +optional
+	LoggingLevels map[string]string `json:"loggingLevels,omitempty"`
+
+	// The hash of the server-config-init container the config builder last
+	// rendered the config with in a dry run, before rolling it out
+	// +optional
+	ValidatedConfigHash string `json:"validatedConfigHash,omitempty"`
+
+	// Whether the cluster is registered with the Reaper of spec.reaper
+	// +optional
+	ReaperClusterRegistered bool `json:"reaperClusterRegistered,omitempty"`
+
+	// The timestamp when the operator last started a Server node
+	// with the management API
+	// +optional
+	LastServerNodeStarted metav1.Time `json:"lastServerNodeStarted,omitempty"`
+
+	// Last known progress state of the Cassandra Operator
+	// +optional
+	CassandraOperatorProgress ProgressState `json:"cassandraOperatorProgress,omitempty"`
+
+	// A summary of the conditions of the datacenter: Initializing, Ready,
+	// Updating, Stopped, Error, Resuming or Hibernated
+	// +optional
+	Phase DatacenterPhase `json:"phase,omitempty"`
+
+	// The number of server pods of the datacenter that are ready
+	// +optional
+	ReadyNodes int32 `json:"readyNodes,omitempty"`
+
+	// +optional
+	LastRollingRestart metav1.Time `json:"lastRollingRestart,omitempty"`
+
+	// The settings of the rolling restart that was last requested
+	// +optional
+	RollingRestartScope *RollingRestartConfig `json:"rollingRestartScope,omitempty"`
+
+	// +optional
+	NodeStatuses CassandraStatusMap `json:"nodeStatuses"`
+
+	// +optional
+	NodeReplacements []string `json:"nodeReplacements"`
+
+	// +optional
+	QuietPeriod metav1.Time `json:"quietPeriod,omitempty"`
+
+	// The generation of the datacenter the operator last reconciled to the
+	// end. The operator has acted on the latest spec once it is equal to
+	// metadata.generation.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The progress of each of the repair schedules
+	// +optional
+	Repairs []RepairScheduleStatus `json:"repairs,omitempty"`
+
+	// The node that is being decommissioned to scale down the datacenter
+	// +optional
+	Decommission *DecommissionProgress `json:"decommission,omitempty"`
+
+	// The server version the nodes were all last upgraded to
+	// +optional
+	ServerVersion string `json:"serverVersion,omitempty"`
+
+	// The progress of the upgradesstables that followed the last major upgrade
+	// +optional
+	SSTablesUpgrade *SSTablesUpgradeProgress `json:"sstablesUpgrade,omitempty"`
+
+	// The progress of the migration of spec.migrateFrom
+	// +optional
+	Migration *MigrationProgress `json:"migration,omitempty"`
+
+	// The remediations of unhealthy pods of the last hour, which count against
+	// the budget of spec.remediation
+	// +optional
+	Remediations []PodRemediation `json:"remediations,omitempty"`
+
+	// The repairs that followed the last changes to the replication of the
+	// managed keyspaces, named after the keyspaces
+	// +optional
+	KeyspaceRepairs []RepairScheduleStatus `json:"keyspaceRepairs,omitempty"`
+
+	// The keyspace with the highest replication factor in the datacenter, as
+	// last read from the schema. The datacenter cannot be scaled down below it.
+	// +optional
+	MaxReplicationFactor *KeyspaceReplicationFactor `json:"maxReplicationFactor,omitempty"`
+
+	// The storage requests of the volume claim templates of the StatefulSets,
+	// by claim name, while the storage class refuses to expand them. The
+	// storageConfig can be set back to them, even though that shrinks it.
+	// +optional
+	VolumeClaimRequests map[string]resource.Quantity `json:"volumeClaimRequests,omitempty"`
+
+	// The racks defined from the zones of the k8s workers by spec.zoneRacks
+	// +optional
+	ZoneRacks []Rack `json:"zoneRacks,omitempty"`
+
+	// The progress of the rollout of each rack to its statefulset
+	// +optional
+	Racks []RackStatus `json:"racks,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraDatacenter is the Schema for the cassandradatacenters API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=cassandradatacenters,scope=Namespaced,shortName=cassdc;cassdcs
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.spec.size`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyNodes`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.serverVersion`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type CassandraDatacenter struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraDatacenterSpec   `json:"spec,omitempty"`
+	Status CassandraDatacenterStatus `json:"status,omitempty"`
+}
+
+type ManagementApiAuthManualConfig struct {
+	ClientSecretName string `json:"clientSecretName"`
+	ServerSecretName string `json:"serverSecretName"`
+	// +optional
+	SkipSecretValidation bool `json:"skipSecretValidation,omitempty"`
+}
+
+type ManagementApiAuthInsecureConfig struct {
+}
+
+type ManagementApiAuthConfig struct {
+	Insecure *ManagementApiAuthInsecureConfig `json:"insecure,omitempty"`
+	Manual   *ManagementApiAuthManualConfig   `json:"manual,omitempty"`
+	// other strategy configs (e.g. Cert Manager) go here
+}
+
+// ReaperConfig configures the Cassandra Reaper the operator deploys for the
+// datacenter. Reaper stores its state in the reaper_db keyspace of the
+// cluster, which the operator creates.
+type ReaperConfig struct {
+	// Deploys Reaper when true
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Container image of Reaper. Defaults to the reaper image of the image
+	// config of the operator.
+	Image string `json:"image,omitempty"`
+
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// Kubernetes resource requests and limits per reaper container.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// Cassandra Reaper deployed for the datacenter
+const (
+	ReaperKeyspace  = "reaper_db"
+	ReaperPort      = 8080
+	ReaperAdminPort = 8081
+
+	// ReaperLabel is the label of the pods of Reaper, whose value is the
+	// name of their deployment. They do not have the labels of the
+	// datacenter so that its services do not select them.
+	ReaperLabel = "cassandra.datastax.com/reaper"
+)
+
+// IsReaperEnabled returns whether the operator deploys Reaper for the
+// datacenter
+func (dc *CassandraDatacenter) IsReaperEnabled() bool {
+	return dc.Spec.Reaper != nil && dc.Spec.Reaper.Enabled
+}
+
+func (dc *CassandraDatacenter) GetReaperDeploymentName() string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-reaper"
+}
+
+func (dc *CassandraDatacenter) GetReaperServiceName() string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-reaper-service"
+}
+
+// RollingRestartConfig selects the pods a rolling restart applies to. When more
+// than one of racks, pods and podSelector is set, a pod is only restarted if it
+// matches all of them.
+type RollingRestartConfig struct {
+	// Only restart the pods of these racks
+	// +optional
+	Racks []string `json:"racks,omitempty"`
+
+	// Only restart these pods
+	// +optional
+	Pods []string `json:"pods,omitempty"`
+
+	// Only restart the pods matching this selector
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// The number of pods of a rack that are restarted at the same time. Racks
+	// are always restarted one after the other. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxUnavailablePerRack int `json:"maxUnavailablePerRack,omitempty"`
+}
+
+// GetMaxUnavailablePerRack returns the number of pods of a rack that can be
+// restarted at the same time
+func (config *RollingRestartConfig) GetMaxUnavailablePerRack() int {
+	if config == nil || config.MaxUnavailablePerRack < 1 {
+		return 1
+	}
+	return config.MaxUnavailablePerRack
+}
+
+// ConfigRolloutStrategy is how the changes of the config are rolled out to
+// the pods of the racks
+type ConfigRolloutStrategy struct {
+	// The number of pods of each rack the config is rolled out to first.
+	// Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CanaryCount int32 `json:"canaryCount,omitempty"`
+}
+
+// GetCanaryCount returns the number of pods of each rack a change of the
+// config is rolled out to first
+func (strategy *ConfigRolloutStrategy) GetCanaryCount() int32 {
+	if strategy == nil || strategy.CanaryCount < 1 {
+		return 1
+	}
+	return strategy.CanaryCount
+}
+
+// MaintenanceWindow is a recurring period of time during which disruptive
+// actions of the operator may run
+type MaintenanceWindow struct {
+	// When the window opens, in the standard five field cron format, e.g.
+	// "0 2 * * 6" for every Saturday at 2am UTC
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// How long the window stays open, e.g. "4h"
+	Duration metav1.Duration `json:"duration"`
+}
+
+// IsOpen tells whether the window is open at the given time. When it is not,
+// it also returns the time the window opens next.
+func (window *MaintenanceWindow) IsOpen(now time.Time) (bool, time.Time, error) {
+	schedule, err := cron.ParseStandard(window.Schedule)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	// The first opening after the start of the last possible window that
+	// still covers now
+	opening := schedule.Next(now.Add(-window.Duration.Duration))
+	if !opening.After(now) {
+		return true, time.Time{}, nil
+	}
+	return false, opening, nil
+}
+
+// QueryLoggingConfig configures the full query log or the audit log of the
+// nodes. Turning a log on or off does not restart the pods, the operator does
+// it through the management API of every node. Changing logDir restarts them.
+type QueryLoggingConfig struct {
+	// Whether the nodes write the log
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// The directory of the log files, where an emptyDir volume is mounted.
+	// Defaults to /var/log/cassandra/fql for the full query log and to
+	// /var/log/cassandra/audit for the audit log.
+	// +optional
+	LogDir string `json:"logDir,omitempty"`
+}
+
+type PersistentVolumeClaimRetentionPolicyType string
+
+const (
+	RetainPersistentVolumeClaimRetentionPolicyType PersistentVolumeClaimRetentionPolicyType = "Retain"
+	DeletePersistentVolumeClaimRetentionPolicyType PersistentVolumeClaimRetentionPolicyType = "Delete"
+)
+
+// PersistentVolumeClaimRetentionPolicy configures what happens to the
+// persistent volume claims of the nodes when they are not needed anymore,
+// like the policy of the same name of a StatefulSet
+type PersistentVolumeClaimRetentionPolicy struct {
+	// What happens to the claims of all the nodes when the datacenter is
+	// deleted, Delete by default
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +optional
+	WhenDeleted PersistentVolumeClaimRetentionPolicyType `json:"whenDeleted,omitempty"`
+
+	// What happens to the claims of a node that is decommissioned when the
+	// datacenter is scaled down, Delete by default
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +optional
+	WhenScaled PersistentVolumeClaimRetentionPolicyType `json:"whenScaled,omitempty"`
+}
+
+// DeletePVCsWhenDeleted is true if the persistent volume claims of the nodes
+// are deleted along with the datacenter
+func (dc *CassandraDatacenter) DeletePVCsWhenDeleted() bool {
+	policy := dc.Spec.PersistentVolumeClaimRetentionPolicy
+	return policy == nil || policy.WhenDeleted != RetainPersistentVolumeClaimRetentionPolicyType
+}
+
+// DeletionConfirmed is true if the datacenter has no deletion protection, or
+// if its deletion is confirmed with the confirm-deletion annotation
+func (dc *CassandraDatacenter) DeletionConfirmed() bool {
+	return !dc.Spec.DeletionProtection || dc.Annotations[ConfirmDeletionAnnotation] == "true"
+}
+
+// DeletePVCsWhenScaled is true if the persistent volume claims of the nodes
+// that are decommissioned on scale down are deleted
+func (dc *CassandraDatacenter) DeletePVCsWhenScaled() bool {
+	policy := dc.Spec.PersistentVolumeClaimRetentionPolicy
+	return policy == nil || policy.WhenScaled != RetainPersistentVolumeClaimRetentionPolicyType
+}
+
+// AutomaticNodeReplacementConfig configures the replacement of the nodes whose
+// persistent volume failed. The volume of a node that joined the ring has
+// failed when its data PVC is Lost, or when the scheduler cannot place its pod
+// because the PVC is unbound or bound to a volume that no worker can attach.
+// Once the failure has lasted for the grace period, the PVCs and the pod are
+// deleted and the new node replaces the old one in the ring.
+type AutomaticNodeReplacementConfig struct {
+	// Whether the nodes are replaced automatically
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// How long the volume of a node has to fail before the node is replaced,
+	// 600 seconds by default
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	GracePeriodSeconds *int32 `json:"gracePeriodSeconds,omitempty"`
+}
+
+const DefaultNodeReplacementGracePeriodSeconds = 600
+
+// GetGracePeriod returns how long the volume of a node has to fail before the
+// node is replaced
+func (config *AutomaticNodeReplacementConfig) GetGracePeriod() time.Duration {
+	seconds := int32(DefaultNodeReplacementGracePeriodSeconds)
+	if config.GracePeriodSeconds != nil {
+		seconds = *config.GracePeriodSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// RemediationConfig configures the remediation of the pods that are stuck
+// unhealthy. A pod is stuck once its server container has not been ready for
+// the grace period, while it is in CrashLoopBackOff or its node is drained,
+// or for the much longer joining grace period while its node is JOINING. It is
+// restarted first, and replaced if it is stuck again after being restarted in
+// the last hour.
+type RemediationConfig struct {
+	// Whether the stuck pods are remediated
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// How long a pod has to be stuck before it is remediated, 1800 seconds by
+	// default
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	GracePeriodSeconds *int32 `json:"gracePeriodSeconds,omitempty"`
+
+	// How long the node of a pod has to be stuck joining the ring before it is
+	// remediated, 86400 seconds by default. A node is joining the ring while
+	// it streams its data to bootstrap, which can take hours.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	JoiningGracePeriodSeconds *int32 `json:"joiningGracePeriodSeconds,omitempty"`
+
+	// The maximum number of remediations in an hour, 1 by default
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxRemediationsPerHour *int32 `json:"maxRemediationsPerHour,omitempty"`
+
+	// Whether the nodes of the pods that are still stuck after a restart are
+	// replaced, starting without the data of their volumes
+	// +optional
+	ReplaceNodes bool `json:"replaceNodes,omitempty"`
+}
+
+const (
+	DefaultRemediationGracePeriodSeconds        = 1800
+	DefaultRemediationJoiningGracePeriodSeconds = 86400
+	DefaultMaxRemediationsPerHour               = 1
+)
+
+// GetGracePeriod returns how long a pod has to be stuck before it is
+// remediated
+func (config *RemediationConfig) GetGracePeriod() time.Duration {
+	seconds := int32(DefaultRemediationGracePeriodSeconds)
+	if config.GracePeriodSeconds != nil {
+		seconds = *config.GracePeriodSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetJoiningGracePeriod returns how long the node of a pod has to be stuck
+// joining the ring before it is remediated
+func (config *RemediationConfig) GetJoiningGracePeriod() time.Duration {
+	seconds := int32(DefaultRemediationJoiningGracePeriodSeconds)
+	if config.JoiningGracePeriodSeconds != nil {
+		seconds = *config.JoiningGracePeriodSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetMaxRemediationsPerHour returns the maximum number of remediations in an
+// hour
+func (config *RemediationConfig) GetMaxRemediationsPerHour() int {
+	if config.MaxRemediationsPerHour == nil {
+		return DefaultMaxRemediationsPerHour
+	}
+	return int(*config.MaxRemediationsPerHour)
+}
+
+// RemediationAction is how a stuck pod is remediated
+type RemediationAction string
+
+const (
+	RemediationRestart RemediationAction = "Restart"
+	RemediationReplace RemediationAction = "Replace"
+)
+
+// PodRemediation records the remediation of a stuck pod
+type PodRemediation struct {
+	PodName string            `json:"podName"`
+	Action  RemediationAction `json:"action"`
+
+	// Why the pod was stuck
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	Time metav1.Time `json:"time"`
+}
+
+// NodeMaintenancePolicy selects the taints that put k8s workers into
+// maintenance. The pods on a worker tainted to evacuate all data are moved to
+// other workers one at a time, with their data rebuilt when their volumes
+// cannot follow them. The pods on a worker tainted for planned downtime are
+// removed from it, keeping their volumes. An operation that would compromise
+// the availability of the datacenter is failed with an annotation on its pods.
+type NodeMaintenancePolicy struct {
+	// Whether the maintenance taints are handled
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// The key of the maintenance taints, node.vmware.com/drain by default
+	// +optional
+	TaintKey string `json:"taintKey,omitempty"`
+
+	// The value of the taint that evacuates all data from a worker, drain by
+	// default
+	// +optional
+	EvacuateAllDataValue string `json:"evacuateAllDataValue,omitempty"`
+
+	// The value of the taint that evacuates the traffic from a worker for
+	// planned downtime, planned-downtime by default
+	// +optional
+	PlannedDowntimeValue string `json:"plannedDowntimeValue,omitempty"`
+}
+
+// The maintenance taints of the VMware PSP
+const (
+	DefaultNodeMaintenanceTaintKey   = "node.vmware.com/drain"
+	DefaultEvacuateAllDataTaintValue = "drain"
+	DefaultPlannedDowntimeTaintValue = "planned-downtime"
+)
+
+// GetTaintKey returns the key of the maintenance taints
+func (policy *NodeMaintenancePolicy) GetTaintKey() string {
+	if policy == nil || policy.TaintKey == "" {
+		return DefaultNodeMaintenanceTaintKey
+	}
+	return policy.TaintKey
+}
+
+// GetEvacuateAllDataValue returns the value of the taint that evacuates all
+// data from a worker
+func (policy *NodeMaintenancePolicy) GetEvacuateAllDataValue() string {
+	if policy == nil || policy.EvacuateAllDataValue == "" {
+		return DefaultEvacuateAllDataTaintValue
+	}
+	return policy.EvacuateAllDataValue
+}
+
+// GetPlannedDowntimeValue returns the value of the taint that evacuates the
+// traffic from a worker for planned downtime
+func (policy *NodeMaintenancePolicy) GetPlannedDowntimeValue() string {
+	if policy == nil || policy.PlannedDowntimeValue == "" {
+		return DefaultPlannedDowntimeTaintValue
+	}
+	return policy.PlannedDowntimeValue
+}
+
+// PodDisruptionBudgetConfig configures the PodDisruptionBudget of the
+// datacenter
+type PodDisruptionBudgetConfig struct {
+	// The number or percentage of the nodes of the datacenter that can be
+	// unavailable during voluntary disruptions such as node drains
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// Gives each rack a budget of its own instead of one budget for the
+	// whole datacenter, so that disruptions such as upgrades of the k8s
+	// workers can proceed one zone at a time. MaxUnavailable then applies to
+	// each rack, and defaults to one node.
+	// +optional
+	PerRack bool `json:"perRack,omitempty"`
+}
+
+// MonitoringConfig configures how the Prometheus operator scrapes the metrics
+// of the nodes, served by the Metric Collector for Apache Cassandra (MCAC) on
+// the prometheus port, 9103
+type MonitoringConfig struct {
+	// Creates the monitor. When disabled, the monitor the operator created
+	// is deleted.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// The kind of monitor to create, ServiceMonitor by default. A
+	// ServiceMonitor scrapes the pods through the all-pods service of the
+	// datacenter, and a PodMonitor scrapes them directly.
+	// +kubebuilder:validation:Enum=ServiceMonitor;PodMonitor
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// How often the nodes are scraped, such as 30s. Defaults to the interval
+	// of Prometheus.
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// Labels of the monitor, to match the monitor selector of Prometheus
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Relabelings applied to the targets before they are scraped
+	// +optional
+	Relabelings []RelabelConfig `json:"relabelings,omitempty"`
+}
+
+// RelabelConfig is a relabeling of the targets of Prometheus, see
+// https://prometheus.io/docs/prometheus/latest/configuration/configuration/#relabel_config
+type RelabelConfig struct {
+	// +optional
+	SourceLabels []string `json:"sourceLabels,omitempty"`
+	// +optional
+	Separator string `json:"separator,omitempty"`
+	// +optional
+	TargetLabel string `json:"targetLabel,omitempty"`
+	// +optional
+	Regex string `json:"regex,omitempty"`
+	// +optional
+	Modulus uint64 `json:"modulus,omitempty"`
+	// +optional
+	Replacement string `json:"replacement,omitempty"`
+	// +kubebuilder:validation:Enum=replace;keep;drop;hashmod;labelmap;labeldrop;labelkeep
+	// +optional
+	Action string `json:"action,omitempty"`
+}
+
+// EncryptionConfig configures the encryption of the traffic of the nodes
+type EncryptionConfig struct {
+	// Encryption of the traffic between the nodes
+	// +optional
+	Internode *InternodeEncryptionConfig `json:"internode,omitempty"`
+
+	// Encryption of the traffic between the clients and the nodes
+	// +optional
+	Client *ClientEncryptionConfig `json:"client,omitempty"`
+}
+
+// ClientEncryptionConfig configures the encryption of the traffic between the
+// clients and the nodes. The operator sets the client_encryption_options of
+// the nodes, which take precedence over the ones of the config.
+type ClientEncryptionConfig struct {
+	// The cert-manager issuer of the certificate of the nodes. The operator
+	// creates a Certificate whose secret it converts to JKS keystores for the
+	// nodes, and restarts the nodes when cert-manager renews it.
+	// +optional
+	CertManagerIssuerRef *CertManagerIssuerRef `json:"certManagerIssuerRef,omitempty"`
+
+	// Accept unencrypted connections as well
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+
+	// Require the clients to authenticate with a certificate signed by the
+	// CA of the certificate of the nodes
+	// +optional
+	RequireClientAuth bool `json:"requireClientAuth,omitempty"`
+}
+
+// CertManagerIssuerRef references a cert-manager Issuer or ClusterIssuer
+type CertManagerIssuerRef struct {
+	Name string `json:"name"`
+
+	// Issuer or ClusterIssuer. Defaults to Issuer.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Defaults to cert-manager.io
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+type InternodeEncryptionMode string
+
+const (
+	// The nodes accept encrypted connections, but do not open any
+	InternodeEncryptionNone InternodeEncryptionMode = "None"
+	// The nodes encrypt their connections, and still accept unencrypted ones
+	InternodeEncryptionOptional InternodeEncryptionMode = "Optional"
+	// The nodes only accept encrypted connections
+	InternodeEncryptionRequired InternodeEncryptionMode = "Required"
+)
+
+// InternodeEncryptionConfig configures the encryption of the traffic between
+// the nodes. The operator sets the server_encryption_options of the nodes,
+// which take precedence over the ones of the config.
+type InternodeEncryptionConfig struct {
+	// None, Optional or Required. The operator moves the nodes from one mode
+	// to the next with a rolling restart, so that going from None to Required
+	// does not prevent the nodes from talking to each other. Optional relies
+	// on the optional setting of Cassandra 4: on the other versions it is the
+	// same as Required, and the nodes that were restarted with encryption
+	// cannot talk to the other ones until the rollout is done.
+	// +kubebuilder:validation:Enum=None;Optional;Required
+	Mode InternodeEncryptionMode `json:"mode"`
+
+	// A secret with the keystore.jks and truststore.jks keys, such as the
+	// secret of a cert-manager Certificate with JKS keystores, whose
+	// certificate is shared by all the nodes. By default the operator
+	// generates a keystore for each node, signed by the CA of the datacenter.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// The password of the keystores of secretName. Required with secretName.
+	// +optional
+	PasswordSecretRef *corev1.SecretKeySelector `json:"passwordSecretRef,omitempty"`
+}
+
+// JmxConfig configures remote JMX on the nodes
+type JmxConfig struct {
+	// A secret with the username and password keys of the JMX user, which
+	// has read and write access. The operator writes the jmxremote.password
+	// and jmxremote.access files of the nodes from it, and restarts the
+	// nodes when it changes. Nodetool in the server containers then needs
+	// these credentials as well.
+	CredentialsSecret string `json:"credentialsSecret"`
+}
+
+// Remote JMX of the server containers
+const (
+	JmxPort           = 7199
+	JmxCredentialsDir = "/etc/jmx-credentials"
+)
+
+// IsRemoteJmxEnabled returns whether the nodes accept JMX connections from
+// outside the pods
+func (dc *CassandraDatacenter) IsRemoteJmxEnabled() bool {
+	return dc.Spec.Jmx != nil && dc.Spec.Jmx.CredentialsSecret != ""
+}
+
+// HibernateConfig configures the hibernation of the datacenter
+type HibernateConfig struct {
+	Enabled bool `json:"enabled"`
+
+	// Keeps a single node of the datacenter running instead of one per rack.
+	// +optional
+	SingleNode bool `json:"singleNode,omitempty"`
+}
+
+// IsHibernated returns whether the datacenter should only keep one node per
+// rack, or a single node, running
+func (dc *CassandraDatacenter) IsHibernated() bool {
+	return dc.Spec.Hibernate != nil && dc.Spec.Hibernate.Enabled && !dc.Spec.Stopped
+}
+
+// GetHibernatedNodeCount returns the number of nodes of the datacenter that
+// keep running while it is hibernated
+func (dc *CassandraDatacenter) GetHibernatedNodeCount() int {
+	if dc.Spec.Hibernate != nil && dc.Spec.Hibernate.SingleNode {
+		return 1
+	}
+	return len(dc.GetRacks())
+}
+
+// DrainConfig configures how the nodes are drained before they stop
+type DrainConfig struct {
+	// How long the preStop hook waits for the node to drain before the server
+	// is stopped anyway. The grace period of the pods is extended to give the
+	// hook this long, unless the podTemplateSpec sets one.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// Scale the racks of a stopped datacenter down without the operator
+	// draining their nodes one at a time first. The preStop hook of each pod
+	// still drains its node as it terminates.
+	// +optional
+	SkipDrainOnStop bool `json:"skipDrainOnStop,omitempty"`
+}
+
+// SkipsDrainOnStop returns whether the operator scales the racks of a
+// stopped datacenter down without draining their nodes first
+func (dc *CassandraDatacenter) SkipsDrainOnStop() bool {
+	return dc.Spec.Drain != nil && dc.Spec.Drain.SkipDrainOnStop
+}
+
+// ProbesConfig tunes the probes of the server container
+type ProbesConfig struct {
+	// +optional
+	Liveness *ProbeConfig `json:"liveness,omitempty"`
+
+	// +optional
+	Readiness *ProbeConfig `json:"readiness,omitempty"`
+
+	// Adds a startup probe on the liveness endpoint of the management API.
+	// Its failureThreshold defaults to 60 and its periodSeconds to 10, giving
+	// the node ten minutes to start.
+	// +optional
+	Startup *ProbeConfig `json:"startup,omitempty"`
+}
+
+// ProbeConfig sets the timing of a probe. The fields that are not set keep
+// their defaults.
+type ProbeConfig struct {
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+}
+
+// BackupAgentConfig configures the Medusa backup agent, which runs as a
+// sidecar of the Cassandra containers
+type BackupAgentConfig struct {
+	// Container image of Medusa. Defaults to the medusa image of the image
+	// config of the operator.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// A secret whose medusa.ini key holds the configuration of Medusa, such as
+	// its [storage] section. The operator renders it with the settings the
+	// agent needs in the pods, and restarts the pods when it changes. The
+	// other keys of the secret, such as the credentials of the storage, are
+	// mounted in /etc/medusa-secrets.
+	// +kubebuilder:validation:MinLength=1
+	ConfigSecret string `json:"configSecret"`
+
+	// Kubernetes resource requests and limits of the backup agent
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// The Medusa backup agent of the server pods
+const (
+	BackupAgentGrpcPort   = 50051
+	BackupAgentConfigDir  = "/etc/medusa"
+	BackupAgentSecretsDir = "/etc/medusa-secrets"
+)
+
+func (dc *CassandraDatacenter) GetBackupAgentConfigSecretName() string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-medusa-config"
+}
+
+// VaultConfig has the operator read secrets of the datacenter from HashiCorp
+// Vault, either itself through the API of Vault, or through the Secrets
+// Store CSI driver that syncs them to kubernetes secrets.
+type VaultConfig struct {
+	// The mount path of the Kubernetes auth method of Vault. Defaults to
+	// kubernetes.
+	// +optional
+	AuthPath string `json:"authPath,omitempty"`
+
+	// The role of the Kubernetes auth method the operator logs in with,
+	// using the token of its service account. It must be one of the roles the
+	// VAULT_ROLES environment variable of the operator allows for the
+	// namespace of the datacenter, and defaults to the only one.
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// The path of a KV secret with the username and password of the
+	// superuser, such as secret/data/cassandra/superuser. It takes the place
+	// of superuserSecretName.
+	// +optional
+	SuperuserSecretPath string `json:"superuserSecretPath,omitempty"`
+
+	// The path of a KV secret with the config key of configSecret, which it
+	// takes the place of.
+	// +optional
+	ConfigSecretPath string `json:"configSecretPath,omitempty"`
+
+	// How often the operator reads the secrets of Vault again, in seconds,
+	// so that their changes are applied like the ones of kubernetes secrets.
+	// Defaults to 300.
+	// +kubebuilder:validation:Minimum=10
+	// +optional
+	RefreshIntervalSeconds int32 `json:"refreshIntervalSeconds,omitempty"`
+
+	// A SecretProviderClass of the Secrets Store CSI driver, mounted in the
+	// server pods at /etc/vault-secrets. The driver syncs the secrets the
+	// class lists to kubernetes secrets once the pods start: superuserSecretName
+	// can reference one of them, which the operator then waits for instead
+	// of generating it.
+	// +optional
+	SecretProviderClass string `json:"secretProviderClass,omitempty"`
+}
+
+// DefaultVaultRefreshIntervalSeconds is how often the secrets of Vault are
+// read again by default
+const DefaultVaultRefreshIntervalSeconds = 300
+
+// VaultSecretsDir is where the SecretProviderClass of Vault is mounted in the
+// server containers
+const VaultSecretsDir = "/etc/vault-secrets"
+
+// GetVaultSuperuserSecretPath returns the path of the superuser secret in
+// Vault, empty when it is a kubernetes secret
+func (dc *CassandraDatacenter) GetVaultSuperuserSecretPath() string {
+	if dc.Spec.Vault == nil {
+		return ""
+	}
+	return dc.Spec.Vault.SuperuserSecretPath
+}
+
+// GetVaultConfigSecretPath returns the path of the config secret in Vault,
+// empty when it is a kubernetes secret
+func (dc *CassandraDatacenter) GetVaultConfigSecretPath() string {
+	if dc.Spec.Vault == nil {
+		return ""
+	}
+	return dc.Spec.Vault.ConfigSecretPath
+}
+
+// UsesConfigSecret tells whether the config of the nodes comes from a secret,
+// of kubernetes or of Vault, or from a ConfigMap, rather than from the config
+// of the spec
+func (dc *CassandraDatacenter) UsesConfigSecret() bool {
+	return dc.Spec.ConfigSecret != "" || len(dc.Spec.ConfigSecrets) > 0 || dc.GetVaultConfigSecretPath() != "" ||
+		dc.Spec.ConfigConfigMap != ""
+}
+
+// GetConfigSecretNames returns the names of the kubernetes secrets of the
+// config in the order their configs are merged: configSecret, then
+// configSecrets
+func (dc *CassandraDatacenter) GetConfigSecretNames() []string {
+	var names []string
+	if dc.Spec.ConfigSecret != "" {
+		names = append(names, dc.Spec.ConfigSecret)
+	}
+	return append(names, dc.Spec.ConfigSecrets...)
+}
+
+// GetVaultRefreshInterval returns how often the operator reads the secrets
+// of Vault, zero when it reads none
+func (dc *CassandraDatacenter) GetVaultRefreshInterval() time.Duration {
+	if dc.GetVaultSuperuserSecretPath() == "" && dc.GetVaultConfigSecretPath() == "" {
+		return 0
+	}
+	seconds := dc.Spec.Vault.RefreshIntervalSeconds
+	if seconds <= 0 {
+		seconds = DefaultVaultRefreshIntervalSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetVaultSecretProviderClass returns the SecretProviderClass mounted in the
+// server pods, empty when there is none
+func (dc *CassandraDatacenter) GetVaultSecretProviderClass() string {
+	if dc.Spec.Vault == nil {
+		return ""
+	}
+	return dc.Spec.Vault.SecretProviderClass
+}
+
+// Paths of the keystores of the internode encryption in the server containers
+const (
+	InternodeEncryptionDir = "/etc/internode-encryption"
+	// InternodeKeystorePasswordPlaceholder stands for the password of the
+	// keystores in the configuration, until an init container of the pods
+	// replaces it with the password from the secret
+	InternodeKeystorePasswordPlaceholder = "__INTERNODE_KEYSTORE_PASSWORD__"
+)
+
+// Paths of the keystores of the client encryption in the server containers
+const (
+	ClientEncryptionDir = "/etc/client-encryption"
+	// ClientKeystorePasswordPlaceholder stands for the password of the
+	// keystores in the configuration, like the one of the internode
+	// encryption
+	ClientKeystorePasswordPlaceholder = "__CLIENT_KEYSTORE_PASSWORD__"
+)
+
+// GetClientEncryption returns the client encryption settings of the
+// datacenter, or nil if the operator does not set it up
+func (dc *CassandraDatacenter) GetClientEncryption() *ClientEncryptionConfig {
+	if dc.Spec.Encryption == nil || dc.Spec.Encryption.Client == nil ||
+		dc.Spec.Encryption.Client.CertManagerIssuerRef == nil {
+		return nil
+	}
+	return dc.Spec.Encryption.Client
+}
+
+// SupportsCertificateReload tells whether the nodes reload the keystores of
+// the encryption when they change, instead of being restarted. Cassandra 4
+// reloads them with the management API.
+func (dc *CassandraDatacenter) SupportsCertificateReload() bool {
+	return dc.Spec.ServerType == "cassandra" && serverconfig.IsCassandraAtLeast(dc.Spec.ServerVersion, 4, 0)
+}
+
+// GetCertificatesHash returns the hash of the certificates of the encryption
+// that can change while the nodes run: the certificate of the client
+// encryption and the keystores of the secretName of the internode encryption
+func (dc *CassandraDatacenter) GetCertificatesHash() string {
+	var hashes []string
+	if dc.GetClientEncryption() != nil {
+		hashes = append(hashes, dc.Annotations[ClientCertificateHashAnnotation])
+	}
+	if config := dc.GetInternodeEncryption(); config != nil && config.SecretName != "" {
+		hashes = append(hashes, dc.Annotations[InternodeKeystoresHashAnnotation])
+	}
+	return strings.Join(hashes, ",")
+}
+
+// GetClientCertificateName returns the name of the cert-manager Certificate
+// of the nodes, which is also the name of its secret
+func (dc *CassandraDatacenter) GetClientCertificateName() string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-client-tls"
+}
+
+// GetClientKeystoresSecretName returns the name of the secret of the
+// keystores the operator converts the certificate of the nodes to
+func (dc *CassandraDatacenter) GetClientKeystoresSecretName() string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-client-keystores"
+}
+
+// GetInternodeEncryption returns the internode encryption settings of the
+// datacenter, or nil if the operator does not set it up
+func (dc *CassandraDatacenter) GetInternodeEncryption() *InternodeEncryptionConfig {
+	if dc.Spec.Encryption == nil {
+		return nil
+	}
+	return dc.Spec.Encryption.Internode
+}
+
+// GetInternodeKeystoresSecretName returns the name of the secret of the
+// keystores the operator generates for the nodes
+func (dc *CassandraDatacenter) GetInternodeKeystoresSecretName() string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-internode-keystores"
+}
+
+// supportsOptionalInternodeEncryption returns whether the nodes can encrypt
+// their connections while still accepting unencrypted ones, which the
+// optional setting of Cassandra 4 does
+func (dc *CassandraDatacenter) supportsOptionalInternodeEncryption() bool {
+	return dc.Spec.ServerType == "cassandra" && serverconfig.IsCassandraAtLeast(dc.Spec.ServerVersion, 4, 0)
+}
+
+// internodeEncryptionSteps returns the modes the nodes go through one at a
+// time, starting from the nodes set up before internode encryption, which
+// neither have keystores nor accept encrypted connections. Optional is only
+// a step of its own on the servers that support it: elsewhere it is the same
+// as Required.
+func (dc *CassandraDatacenter) internodeEncryptionSteps() []InternodeEncryptionMode {
+	steps := []InternodeEncryptionMode{"", InternodeEncryptionNone}
+	if dc.supportsOptionalInternodeEncryption() {
+		steps = append(steps, InternodeEncryptionOptional)
+	}
+	return append(steps, InternodeEncryptionRequired)
+}
+
+func (dc *CassandraDatacenter) internodeEncryptionStep(steps []InternodeEncryptionMode, mode InternodeEncryptionMode) int {
+	if mode == InternodeEncryptionOptional && !dc.supportsOptionalInternodeEncryption() {
+		mode = InternodeEncryptionRequired
+	}
+	for i, step := range steps {
+		if step == mode {
+			return i
+		}
+	}
+	return 0
+}
+
+// GetInternodeEncryptionMode returns the mode of internode encryption the
+// nodes are configured with. Once the datacenter is initialized, the mode of
+// the spec is reached one step at a time from the mode of the status, each
+// step being rolled out to all the nodes before the next one.
+func (dc *CassandraDatacenter) GetInternodeEncryptionMode() InternodeEncryptionMode {
+	config := dc.GetInternodeEncryption()
+	if config == nil {
+		return ""
+	}
+
+	steps := dc.internodeEncryptionSteps()
+	desired := dc.internodeEncryptionStep(steps, config.Mode)
+	if dc.GetConditionStatus(DatacenterInitialized) != corev1.ConditionTrue {
+		return steps[desired]
+	}
+
+	current := dc.internodeEncryptionStep(steps, dc.Status.InternodeEncryptionMode)
+	switch {
+	case desired > current+1:
+		return steps[current+1]
+	case desired < current-1:
+		return steps[current-1]
+	default:
+		return steps[desired]
+	}
+}
+
+// MetricsCollectorConfig configures the Metric Collector for Apache Cassandra
+// (MCAC) agent bundled in the management API images
+type MetricsCollectorConfig struct {
+	// Runs the agent in the server containers. Defaults to true. When
+	// disabled, the pods and the services do not expose the prometheus port.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Image of the version of the agent to run instead of the one of the
+	// server image. The image must have the agent in /opt/mcac-agent, from
+	// where an init container copies it into the pods.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Content of the metric-collector.yaml file that configures the agent.
+	// The operator renders it into a ConfigMap mounted into the server
+	// containers, and restarts the pods when it changes.
+	// +optional
+	Config string `json:"config,omitempty"`
+}
+
+// MCAC agent installation in the management API images
+const (
+	MetricsCollectorDir        = "/opt/mcac-agent"
+	MetricsCollectorConfigFile = "metric-collector.yaml"
+	MetricsCollectorPort       = 9103
+)
+
+// IsMetricsCollectorEnabled returns whether the MCAC agent runs in the
+// server containers
+func (dc *CassandraDatacenter) IsMetricsCollectorEnabled() bool {
+	config := dc.Spec.MetricsCollector
+	return config == nil || config.Enabled == nil || *config.Enabled
 }
 
-// ServiceConfigAdditions exposes additional options for each service
-type ServiceConfigAdditions struct {
-	Labels      map[string]string `json:"additionalLabels,omitempty"`
-	Annotations map[string]string `json:"additionalAnnotations,omitempty"`
+// GetMetricsCollectorConfigMapName returns the name of the ConfigMap of the
+// configuration of the MCAC agent
+func (dc *CassandraDatacenter) GetMetricsCollectorConfigMapName() string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-metrics-collector-config"
 }
 
-// Rack ...
-type Rack struct {
-	// The rack name
-	// +kubebuilder:validation:MinLength=2
-	Name string `json:"name"`
+const (
+	ServiceMonitorKind = "ServiceMonitor"
+	PodMonitorKind     = "PodMonitor"
+)
 
-	// Deprecated. Use nodeAffinityLabels instead. Zone name to pin the rack, using node affinity
-	Zone string `json:"zone,omitempty"`
+// GetMonitoringKind returns the kind of monitor of the datacenter, or an
+// empty string if monitoring is not enabled
+func (dc *CassandraDatacenter) GetMonitoringKind() string {
+	if dc.Spec.Monitoring == nil || !dc.Spec.Monitoring.Enabled {
+		return ""
+	}
+	if dc.Spec.Monitoring.Kind == "" {
+		return ServiceMonitorKind
+	}
+	return dc.Spec.Monitoring.Kind
+}
 
-	//NodeAffinityLabels to pin the rack, using node affinity
-	NodeAffinityLabels map[string]string `json:"nodeAffinityLabels,omitempty"`
+const (
+	DefaultFullQueryLogDir = "/var/log/cassandra/fql"
+	DefaultAuditLogDir     = "/var/log/cassandra/audit"
+)
+
+// GetFullQueryLogDir returns the directory of the full query log, or an empty
+// string if full query logging is not configured
+func (dc *CassandraDatacenter) GetFullQueryLogDir() string {
+	return getLogDir(dc.Spec.FullQueryLogging, DefaultFullQueryLogDir)
 }
 
-type CassandraNodeStatus struct {
-	HostID string `json:"hostID,omitempty"`
+// GetAuditLogDir returns the directory of the audit log, or an empty string if
+// audit logging is not configured
+func (dc *CassandraDatacenter) GetAuditLogDir() string {
+	return getLogDir(dc.Spec.AuditLogging, DefaultAuditLogDir)
 }
 
-type CassandraStatusMap map[string]CassandraNodeStatus
+func getLogDir(config *QueryLoggingConfig, defaultDir string) string {
+	if config == nil {
+		return ""
+	}
+	if config.LogDir == "" {
+		return defaultDir
+	}
+	return config.LogDir
+}
 
-type DatacenterConditionType string
+type RepairType string
 
 const (
-	DatacenterReady          DatacenterConditionType = "Ready"
-	DatacenterInitialized    DatacenterConditionType = "Initialized"
-	DatacenterReplacingNodes DatacenterConditionType = "ReplacingNodes"
-	DatacenterScalingUp      DatacenterConditionType = "ScalingUp"
-	DatacenterScalingDown    DatacenterConditionType = "ScalingDown"
-	DatacenterUpdating       DatacenterConditionType = "Updating"
-	DatacenterStopped        DatacenterConditionType = "Stopped"
-	DatacenterResuming       DatacenterConditionType = "Resuming"
-	DatacenterRollingRestart DatacenterConditionType = "RollingRestart"
-	DatacenterValid          DatacenterConditionType = "Valid"
+	RepairTypeFull        RepairType = "full"
+	RepairTypeIncremental RepairType = "incremental"
 )
 
-type DatacenterCondition struct {
-	Type               DatacenterConditionType `json:"type"`
-	Status             corev1.ConditionStatus  `json:"status"`
-	Reason             string                  `json:"reason"`
-	Message            string                  `json:"message"`
-	LastTransitionTime metav1.Time             `json:"lastTransitionTime,omitempty"`
+type RepairSchedule struct {
+	// A name for the schedule, unique within the datacenter
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// When the repair starts, in the standard five field cron format, e.g.
+	// "0 2 * * 0" for every Sunday at 2am UTC
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// Whether to run a full or an incremental repair. Defaults to full.
+	// +kubebuilder:validation:Enum=full;incremental
+	// +optional
+	Type RepairType `json:"type,omitempty"`
+
+	// The keyspaces to repair
+	// +kubebuilder:validation:MinItems=1
+	Keyspaces []string `json:"keyspaces"`
 }
 
-func NewDatacenterCondition(conditionType DatacenterConditionType, status corev1.ConditionStatus) *DatacenterCondition {
-	return &DatacenterCondition{
-		Type:    conditionType,
-		Status:  status,
-		Reason:  "",
-		Message: "",
-	}
+// KeyspaceReplication is the replication of a keyspace the operator manages
+type KeyspaceReplication struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// The replication factor of each datacenter of the cluster. The datacenters
+	// left out do not replicate the keyspace. Only the factor of the datacenter
+	// is managed, the others are used when the keyspace is switched to
+	// NetworkTopologyStrategy.
+	// +kubebuilder:validation:MinProperties=1
+	Replication map[string]int32 `json:"replication"`
 }
 
-func NewDatacenterConditionWithReason(conditionType DatacenterConditionType, status corev1.ConditionStatus, reason string, message string) *DatacenterCondition {
-	return &DatacenterCondition{
-		Type:    conditionType,
-		Status:  status,
-		Reason:  reason,
-		Message: message,
-	}
+// KeyspaceReplicationFactor is the replication factor of a keyspace in the
+// datacenter
+type KeyspaceReplicationFactor struct {
+	// +optional
+	Keyspace string `json:"keyspace,omitempty"`
+
+	// +optional
+	ReplicationFactor int32 `json:"replicationFactor,omitempty"`
+
+	// When the replication factors of the keyspaces were read from the schema
+	LastCheckTime metav1.Time `json:"lastCheckTime"`
 }
 
-// CassandraDatacenterStatus defines the observed state of CassandraDatacenter
-// +k8s:openapi-gen=true
-type CassandraDatacenterStatus struct {
-	Conditions []DatacenterCondition `json:"conditions,omitempty"`
+type RepairScheduleStatus struct {
+	Name string `json:"name"`
 
-	// Deprecated. Use usersUpserted instead. The timestamp at
-	// which CQL superuser credentials were last upserted to the
-	// management API
+	// The time the last run of the schedule started
 	// +optional
-	SuperUserUpserted metav1.Time `json:"superUserUpserted,omitempty"`
+	LastRepairStart *metav1.Time `json:"lastRepairStart,omitempty"`
 
-	// The timestamp at which managed cassandra users' credentials
-	// were last upserted to the management API
+	// The time the last run of the schedule finished on every node
 	// +optional
-	UsersUpserted metav1.Time `json:"usersUpserted,omitempty"`
+	LastRepairTime *metav1.Time `json:"lastRepairTime,omitempty"`
 
-	// The timestamp when the operator last started a Server node
-	// with the management API
+	// The time the next run of the schedule is due
 	// +optional
-	LastServerNodeStarted metav1.Time `json:"lastServerNodeStarted,omitempty"`
+	NextRepairTime *metav1.Time `json:"nextRepairTime,omitempty"`
 
-	// Last known progress state of the Cassandra Operator
+	// The pods the current run has yet to repair. The first pod is the one
+	// being repaired.
 	// +optional
-	CassandraOperatorProgress ProgressState `json:"cassandraOperatorProgress,omitempty"`
+	PendingPods []string `json:"pendingPods,omitempty"`
 
+	// The error of the last failed repair of a node
 	// +optional
-	LastRollingRestart metav1.Time `json:"lastRollingRestart,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+}
 
+// DecommissionProgress is the progress of the node that is being
+// decommissioned. The data it has streamed is estimated from how much the load
+// of the other nodes grew since it started.
+type DecommissionProgress struct {
+	// The pod of the node
+	PodName string `json:"podName"`
+
+	// The time the node started to decommission
 	// +optional
-	NodeStatuses CassandraStatusMap `json:"nodeStatuses"`
+	StartTime metav1.Time `json:"startTime,omitempty"`
 
+	// The estimated percentage of the data of the node that has been streamed
+	// to the other nodes
+	StreamedPercent int32 `json:"streamedPercent"`
+
+	// The load of the node in bytes when it started to decommission
 	// +optional
-	NodeReplacements []string `json:"nodeReplacements"`
+	InitialLoad int64 `json:"initialLoad,omitempty"`
 
+	// The load of the other nodes in bytes when it started to decommission
 	// +optional
-	QuietPeriod metav1.Time `json:"quietPeriod,omitempty"`
+	OtherNodesInitialLoad int64 `json:"otherNodesInitialLoad,omitempty"`
+}
 
+// UpgradeSSTablesConfig configures the upgradesstables that follows major
+// upgrades
+type UpgradeSSTablesConfig struct {
+	// Whether upgradesstables runs after major upgrades
 	// +optional
-	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	Enabled bool `json:"enabled,omitempty"`
+
+	// The maximum number of nodes of a rack that run upgradesstables at the
+	// same time. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// Number of concurrent compaction jobs to use on each node
+	// +optional
+	Jobs *int `json:"jobs,omitempty"`
 }
 
-// +genclient
-// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// GetConcurrency returns the maximum number of nodes of a rack that run
+// upgradesstables at the same time
+func (config *UpgradeSSTablesConfig) GetConcurrency() int {
+	if config.Concurrency < 1 {
+		return 1
+	}
+	return config.Concurrency
+}
 
-// CassandraDatacenter is the Schema for the cassandradatacenters API
-// +k8s:openapi-gen=true
-// +kubebuilder:subresource:status
-// +kubebuilder:resource:path=cassandradatacenters,scope=Namespaced,shortName=cassdc;cassdcs
-type CassandraDatacenter struct {
-	metav1.TypeMeta   `json:",inline"`
-	metav1.ObjectMeta `json:"metadata,omitempty"`
+// RackStatus is the progress of the rollout of a rack, from its statefulset
+// and the nodes of its pods
+type RackStatus struct {
+	Name string `json:"name"`
 
-	Spec   CassandraDatacenterSpec   `json:"spec,omitempty"`
-	Status CassandraDatacenterStatus `json:"status,omitempty"`
+	// The number of pods the statefulset of the rack wants
+	Replicas int32 `json:"replicas"`
+
+	// The number of pods of the rack that run the latest revision of its
+	// statefulset
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+
+	// The number of pods of the rack that are ready
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// The number of pods of the rack whose node the operator started
+	StartedNodes int32 `json:"startedNodes"`
 }
 
-type ManagementApiAuthManualConfig struct {
-	ClientSecretName string `json:"clientSecretName"`
-	ServerSecretName string `json:"serverSecretName"`
+// SSTablesUpgradeProgress is the progress of the upgradesstables that follows
+// a major upgrade
+type SSTablesUpgradeProgress struct {
+	// The server version the nodes were upgraded from
+	FromVersion string `json:"fromVersion"`
+
+	// The server version the nodes were upgraded to
+	ToVersion string `json:"toVersion"`
+
 	// +optional
-	SkipSecretValidation bool `json:"skipSecretValidation,omitempty"`
-}
+	StartTime metav1.Time `json:"startTime,omitempty"`
 
-type ManagementApiAuthInsecureConfig struct {
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// The pods whose sstables are still to be upgraded, in the order of the
+	// racks
+	// +optional
+	PendingPods []string `json:"pendingPods,omitempty"`
+
+	// The pods upgradesstables failed on
+	// +optional
+	FailedPods []string `json:"failedPods,omitempty"`
 }
 
-type ManagementApiAuthConfig struct {
-	Insecure *ManagementApiAuthInsecureConfig `json:"insecure,omitempty"`
-	Manual   *ManagementApiAuthManualConfig   `json:"manual,omitempty"`
-	// other strategy configs (e.g. Cert Manager) go here
+// MigrationConfig names the datacenter a datacenter replaces
+type MigrationConfig struct {
+	// The name of the CassandraDatacenter to migrate from, which must be of
+	// the same cluster and in the same namespace
+	Datacenter string `json:"datacenter"`
+
+	// Hold the decommission of the datacenter once its clients were switched
+	// over, until this is cleared, leaving a window to switch them back
+	// +optional
+	HoldDecommission bool `json:"holdDecommission,omitempty"`
 }
 
-type ReaperConfig struct {
-	Enabled bool `json:"enabled,omitempty"`
+// MigrationPhase is the phase a migration of spec.migrateFrom is in
+type MigrationPhase string
 
-	Image string `json:"image,omitempty"`
+const (
+	// The nodes of the datacenter stream their data from the source
+	// datacenter, one at a time
+	MigrationPhaseRebuilding MigrationPhase = "Rebuilding"
 
-	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// The rebuild failed on some nodes and the migration stops, until it is
+	// started over
+	MigrationPhaseRebuildFailed MigrationPhase = "RebuildFailed"
 
-	// Kubernetes resource requests and limits per reaper container.
-	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// The client service of the source datacenter selects the pods of the
+	// datacenter, and the decommission of the source datacenter is held
+	MigrationPhaseClientsSwitched MigrationPhase = "ClientsSwitched"
+
+	// The source datacenter is decommissioned and deleted
+	MigrationPhaseDecommissioning MigrationPhase = "Decommissioning"
+
+	MigrationPhaseCompleted MigrationPhase = "Completed"
+)
+
+// MigrationProgress is the progress of the migration of spec.migrateFrom
+type MigrationProgress struct {
+	// The datacenter the data and the clients are migrated from
+	SourceDatacenter string `json:"sourceDatacenter"`
+
+	Phase MigrationPhase `json:"phase"`
+
+	// +optional
+	StartTime metav1.Time `json:"startTime,omitempty"`
+
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// The pods still to be rebuilt from the source datacenter, in the order
+	// of the racks
+	// +optional
+	PendingPods []string `json:"pendingPods,omitempty"`
+
+	// The pods the rebuild failed on
+	// +optional
+	FailedPods []string `json:"failedPods,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -466,6 +2900,30 @@ func (dc *CassandraDatacenter) GetRackLabels(rackName string) map[string]string
 	return labels
 }
 
+// ComputePhase returns the phase of the datacenter from its conditions and
+// the progress of the operator
+func (status *CassandraDatacenterStatus) ComputePhase() DatacenterPhase {
+	isTrue := func(conditionType DatacenterConditionType) bool {
+		return status.GetConditionStatus(conditionType) == corev1.ConditionTrue
+	}
+
+	switch {
+	case status.GetConditionStatus(DatacenterValid) == corev1.ConditionFalse:
+		return PhaseError
+	case isTrue(DatacenterStopped):
+		return PhaseStopped
+	case isTrue(DatacenterResuming):
+		return PhaseResuming
+	case !isTrue(DatacenterInitialized):
+		return PhaseInitializing
+	case status.CassandraOperatorProgress == ProgressReady && isTrue(DatacenterHibernated):
+		return PhaseHibernated
+	case status.CassandraOperatorProgress == ProgressReady && isTrue(DatacenterReady):
+		return PhaseReady
+	}
+	return PhaseUpdating
+}
+
 func (status *CassandraDatacenterStatus) GetConditionStatus(conditionType DatacenterConditionType) corev1.ConditionStatus {
 	for _, condition := range status.Conditions {
 		if condition.Type == conditionType {
@@ -517,6 +2975,12 @@ func (dc *CassandraDatacenter) GetDatacenterLabels() map[string]string {
 	return labels
 }
 
+// IsReconciliationPaused returns true when the operator should leave the
+// CassandraDatacenter alone, as requested with the PausedAnnotation
+func (dc *CassandraDatacenter) IsReconciliationPaused() bool {
+	return dc.Annotations[PausedAnnotation] == "true"
+}
+
 // GetClusterLabels returns a new map with the cluster label key and cluster name value
 func (dc *CassandraDatacenter) GetClusterLabels() map[string]string {
 	return map[string]string{
@@ -528,6 +2992,23 @@ func (dc *CassandraDatacenter) GetSeedServiceName() string {
 	return dc.Spec.ClusterName + "-seed-service"
 }
 
+// HasAdditionalSeeds returns true if seeds from outside of the datacenter's
+// Kubernetes cluster or from other CassandraDatacenters are configured
+func (dc *CassandraDatacenter) HasAdditionalSeeds() bool {
+	return len(dc.Spec.AdditionalSeeds) > 0 || len(dc.Spec.AdditionalSeedDatacenters) > 0 ||
+		len(dc.Spec.ClusterSeeds) > 0
+}
+
+// GetAdditionalSeedDatacenterKeys returns the namespaced names of the
+// CassandraDatacenters in AdditionalSeedDatacenters
+func (dc *CassandraDatacenter) GetAdditionalSeedDatacenterKeys() []types.NamespacedName {
+	keys := make([]types.NamespacedName, 0, len(dc.Spec.AdditionalSeedDatacenters))
+	for _, ref := range dc.Spec.AdditionalSeedDatacenters {
+		keys = append(keys, objectReferenceKey(ref, dc.Namespace))
+	}
+	return keys
+}
+
 func (dc *CassandraDatacenter) GetAdditionalSeedsServiceName() string {
 	return dc.Spec.ClusterName + "-" + dc.Name + fmt.Sprintf("-additional-seed-service")
 }
@@ -544,8 +3025,53 @@ func (dc *CassandraDatacenter) GetNodePortServiceName() string {
 	return dc.Spec.ClusterName + "-" + dc.Name + "-node-port-service"
 }
 
+// GetPodLoadBalancerServiceName returns the name of the load balancer service
+// of a pod
+func (dc *CassandraDatacenter) GetPodLoadBalancerServiceName(podName string) string {
+	return podName + "-lb-service"
+}
+
+// IsFederationPublished returns whether the datacenter publishes its seeds and
+// exports its secrets for the other Kubernetes clusters
+func (dc *CassandraDatacenter) IsFederationPublished() bool {
+	return dc.Spec.Federation != nil && dc.Spec.Federation.Publish
+}
+
+// GetFederationImportSecret returns the name of the federation secret the
+// datacenter imports, if any
+func (dc *CassandraDatacenter) GetFederationImportSecret() string {
+	if dc.Spec.Federation == nil {
+		return ""
+	}
+	return dc.Spec.Federation.ImportSecret
+}
+
+// GetSeedsConfigMapName returns the name of the ConfigMap the seeds of the
+// datacenter are published to
+func (dc *CassandraDatacenter) GetSeedsConfigMapName() string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-seeds"
+}
+
+// GetFederationSecretName returns the name of the secret the superuser
+// credentials and the internode CA of the datacenter are exported to
+func (dc *CassandraDatacenter) GetFederationSecretName() string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-federation"
+}
+
+// GetSniIngressName returns the name of the Ingress of the SNI host names
+func (dc *CassandraDatacenter) GetSniIngressName() string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-sni-ingress"
+}
+
+// GetPodSniServiceName returns the name of the service the SNI Ingress
+// routes the host name of a pod to
+func (dc *CassandraDatacenter) GetPodSniServiceName(podName string) string {
+	return podName + "-sni-service"
+}
+
 func (dc *CassandraDatacenter) ShouldGenerateSuperuserSecret() bool {
-	return len(dc.Spec.SuperuserSecretName) == 0
+	return len(dc.Spec.SuperuserSecretName) == 0 && dc.GetVaultSuperuserSecretPath() == "" &&
+		dc.GetFederationImportSecret() == ""
 }
 
 func (dc *CassandraDatacenter) GetSuperuserSecretNamespacedName() types.NamespacedName {
@@ -553,6 +3079,8 @@ func (dc *CassandraDatacenter) GetSuperuserSecretNamespacedName() types.Namespac
 	namespace := dc.ObjectMeta.Namespace
 	if len(dc.Spec.SuperuserSecretName) > 0 {
 		name = dc.Spec.SuperuserSecretName
+	} else if importSecret := dc.GetFederationImportSecret(); importSecret != "" {
+		name = importSecret
 	}
 
 	return types.NamespacedName{
@@ -561,6 +3089,28 @@ func (dc *CassandraDatacenter) GetSuperuserSecretNamespacedName() types.Namespac
 	}
 }
 
+// GetRackConfig returns the config of the spec for the nodes of the rack: the
+// config of the rack merged over the one of the datacenter
+func (dc *CassandraDatacenter) GetRackConfig(rackName string) (json.RawMessage, error) {
+	rack := dc.GetRack(rackName)
+	if rack == nil || len(rack.Config) == 0 {
+		return dc.Spec.Config, nil
+	}
+
+	merged := map[string]interface{}{}
+	if len(dc.Spec.Config) > 0 {
+		if err := json.Unmarshal(dc.Spec.Config, &merged); err != nil {
+			return nil, errors.Wrap(err, "Error parsing Spec.Config for CassandraDatacenter resource")
+		}
+	}
+	rackConfig := map[string]interface{}{}
+	if err := json.Unmarshal(rack.Config, &rackConfig); err != nil {
+		return nil, errors.Wrapf(err, "Error parsing the config of rack %s", rackName)
+	}
+	serverconfig.MergeConfig(merged, rackConfig)
+	return json.Marshal(merged)
+}
+
 // GetConfigAsJSON gets a JSON-encoded string suitable for passing to configBuilder
 func (dc *CassandraDatacenter) GetConfigAsJSON(config []byte) (string, error) {
 
@@ -568,7 +3118,7 @@ func (dc *CassandraDatacenter) GetConfigAsJSON(config []byte) (string, error) {
 	// resolve to the seed nodes. This obviates the need to update the
 	// cassandra.yaml whenever the seed nodes change.
 	seeds := []string{dc.GetSeedServiceName()}
-	if len(dc.Spec.AdditionalSeeds) > 0 {
+	if dc.HasAdditionalSeeds() {
 		seeds = append(seeds, dc.GetAdditionalSeedsServiceName())
 	}
 
@@ -576,14 +3126,14 @@ func (dc *CassandraDatacenter) GetConfigAsJSON(config []byte) (string, error) {
 	solrEnabled := 0
 	sparkEnabled := 0
 
-	if dc.Spec.ServerType == "dse" && dc.Spec.DseWorkloads != nil {
-		if dc.Spec.DseWorkloads.AnalyticsEnabled == true {
+	if workloads := dc.GetDseWorkloads(); workloads != nil {
+		if workloads.AnalyticsEnabled {
 			sparkEnabled = 1
 		}
-		if dc.Spec.DseWorkloads.GraphEnabled == true {
+		if workloads.GraphEnabled {
 			graphEnabled = 1
 		}
-		if dc.Spec.DseWorkloads.SearchEnabled == true {
+		if workloads.SearchEnabled {
 			solrEnabled = 1
 		}
 	}
@@ -598,6 +3148,10 @@ func (dc *CassandraDatacenter) GetConfigAsJSON(config []byte) (string, error) {
 		internode = dc.Spec.Networking.NodePort.Internode
 		internodeSSL = dc.Spec.Networking.NodePort.InternodeSSL
 	}
+	if ports := dc.getPorts(); ports.Native != 0 || ports.Storage != 0 {
+		native = ports.Native
+		internode = ports.Storage
+	}
 
 	modelValues := serverconfig.GetModelValues(
 		seeds,
@@ -636,9 +3190,214 @@ func (dc *CassandraDatacenter) GetConfigAsJSON(config []byte) (string, error) {
 		}
 	}
 
+	// The logs are turned on and off at runtime, only their directories are
+	// part of the configuration. Directories set in Spec.Config take precedence.
+	if logDir := dc.GetFullQueryLogDir(); logDir != "" {
+		path := []string{"cassandra-yaml", "full_query_logging_options", "log_dir"}
+		if !modelParsed.Exists(path...) {
+			if _, err := modelParsed.Set(logDir, path...); err != nil {
+				return "", errors.Wrap(err, "Error setting the full query log directory")
+			}
+		}
+	}
+	if logDir := dc.GetAuditLogDir(); logDir != "" {
+		path := []string{"cassandra-yaml", "audit_logging_options", "audit_logs_dir"}
+		if !modelParsed.Exists(path...) {
+			if _, err := modelParsed.Set(logDir, path...); err != nil {
+				return "", errors.Wrap(err, "Error setting the audit log directory")
+			}
+		}
+	}
+
+	// Likewise data_file_directories set in Spec.Config take precedence over
+	// the mount paths of the additional data volumes
+	if directories := dc.GetDataFileDirectories(); directories != nil {
+		path := []string{"cassandra-yaml", "data_file_directories"}
+		if !modelParsed.Exists(path...) {
+			if _, err := modelParsed.Set(directories, path...); err != nil {
+				return "", errors.Wrap(err, "Error setting the data file directories")
+			}
+		}
+	}
+	if directory := dc.GetCommitLogDirectory(); directory != "" {
+		path := []string{"cassandra-yaml", "commitlog_directory"}
+		if !modelParsed.Exists(path...) {
+			if _, err := modelParsed.Set(directory, path...); err != nil {
+				return "", errors.Wrap(err, "Error setting the commit log directory")
+			}
+		}
+	}
+
+	if directory := dc.GetCDCDirectory(); directory != "" {
+		for key, value := range map[string]interface{}{"cdc_enabled": true, "cdc_raw_directory": directory} {
+			path := []string{"cassandra-yaml", key}
+			if !modelParsed.Exists(path...) {
+				if _, err := modelParsed.Set(value, path...); err != nil {
+					return "", errors.Wrap(err, "Error setting the CDC configuration")
+				}
+			}
+		}
+	}
+
+	// The JVM options set in Spec.Config take precedence over the ones
+	// computed from the resources
+	for _, option := range dc.getAutoTunedJvmOptions(modelParsed) {
+		if !modelParsed.Exists(option.path...) {
+			if _, err := modelParsed.Set(option.value, option.path...); err != nil {
+				return "", errors.Wrap(err, "Error setting the auto-tuned JVM options")
+			}
+		}
+	}
+
+	if mode := dc.GetInternodeEncryptionMode(); mode != "" {
+		for key, value := range dc.getServerEncryptionOptions(mode) {
+			if _, err := modelParsed.Set(value, "cassandra-yaml", "server_encryption_options", key); err != nil {
+				return "", errors.Wrap(err, "Error setting the server encryption options")
+			}
+		}
+	}
+
+	if config := dc.GetClientEncryption(); config != nil {
+		options := map[string]interface{}{
+			"enabled":             true,
+			"optional":            config.Optional,
+			"require_client_auth": config.RequireClientAuth,
+			"keystore":            ClientEncryptionDir + "/keystore.jks",
+			"keystore_password":   ClientKeystorePasswordPlaceholder,
+			"truststore":          ClientEncryptionDir + "/truststore.jks",
+			"truststore_password": ClientKeystorePasswordPlaceholder,
+		}
+		for key, value := range options {
+			if _, err := modelParsed.Set(value, "cassandra-yaml", "client_encryption_options", key); err != nil {
+				return "", errors.Wrap(err, "Error setting the client encryption options")
+			}
+		}
+	}
+
+	// The webhook rejects such configs as well, but they must not be rolled
+	// out when it is not deployed either
+	if dc.Spec.ServerType == "cassandra" {
+		if generated, ok := modelParsed.Data().(map[string]interface{}); ok {
+			if err := serverconfig.ValidateConfig(dc.Spec.ServerVersion, generated); err != nil {
+				return "", errors.Wrap(err, "Rejected Spec.Config for the server version, attempted to")
+			}
+		}
+	}
+
 	return modelParsed.String(), nil
 }
 
+type jvmOption struct {
+	path  []string
+	value interface{}
+}
+
+// getAutoTunedJvmOptions returns the JVM options of spec.autoTuneJvm. G1 gets
+// half of the memory of the container, up to 31G so that the JVM still
+// compresses its pointers, and sizes its young generation itself. CMS gets
+// half of the memory up to 8G, beyond which its pauses get too long, and a
+// young generation of 100M per CPU up to a quarter of the heap, as
+// cassandra-env.sh does. The rest of the memory is left to the off-heap
+// structures and the page cache. The heap sizes set in config, which take
+// precedence, bound the computed ones so that the JVM still starts.
+func (dc *CassandraDatacenter) getAutoTunedJvmOptions(config *gabs.Container) []jvmOption {
+	if !dc.Spec.AutoTuneJvm || dc.Spec.ServerType != "cassandra" {
+		return nil
+	}
+	memory := dc.Spec.Resources.Limits.Memory()
+	if memory.IsZero() {
+		memory = dc.Spec.Resources.Requests.Memory()
+	}
+	const mebibyte = int64(1024 * 1024)
+	memoryMiB := memory.Value() / mebibyte
+	if memoryMiB <= 0 {
+		return nil
+	}
+
+	cms := strings.HasPrefix(dc.Spec.ServerVersion, "3.")
+	bucket, heapMiB := "jvm-server-options", minInt64(memoryMiB/2, 31*1024)
+	if cms {
+		bucket, heapMiB = "jvm-options", minInt64(memoryMiB/2, 8*1024)
+	}
+	initialMiB, maxMiB := heapMiB, heapMiB
+	if size, ok := jvmSizeMiB(config, bucket, "max_heap_size"); ok {
+		maxMiB = size
+		initialMiB = minInt64(initialMiB, size)
+	}
+	if size, ok := jvmSizeMiB(config, bucket, "initial_heap_size"); ok && size > maxMiB {
+		maxMiB = size
+	}
+	options := []jvmOption{
+		{[]string{bucket, "initial_heap_size"}, fmt.Sprintf("%dM", initialMiB)},
+		{[]string{bucket, "max_heap_size"}, fmt.Sprintf("%dM", maxMiB)},
+	}
+
+	if cms {
+		cpus := dc.Spec.Resources.Limits.Cpu()
+		if cpus.IsZero() {
+			cpus = dc.Spec.Resources.Requests.Cpu()
+		}
+		newGenMiB := maxMiB / 4
+		if cores := (cpus.MilliValue() + 999) / 1000; cores > 0 {
+			newGenMiB = minInt64(100*cores, newGenMiB)
+		}
+		return append(options,
+			jvmOption{[]string{bucket, "heap_size_young_generation"}, fmt.Sprintf("%dM", newGenMiB)},
+			jvmOption{[]string{bucket, "garbage_collector"}, "CMS"},
+		)
+	}
+
+	// The collector is set for each JDK the version runs on
+	for _, jdkBucket := range serverconfig.JvmOptionsBuckets(dc.Spec.ServerVersion)[1:] {
+		options = append(options, jvmOption{[]string{jdkBucket, "garbage_collector"}, "G1GC"})
+	}
+	return options
+}
+
+// jvmSizeMiB reads a size of the JVM options of the config, such as 16G or
+// 800M, in mebibytes
+func jvmSizeMiB(config *gabs.Container, bucket string, option string) (int64, bool) {
+	if !config.Exists(bucket, option) {
+		return 0, false
+	}
+	value, ok := config.Search(bucket, option).Data().(string)
+	if !ok || value == "" {
+		return 0, false
+	}
+
+	multiplier := int64(1)
+	units := map[byte]int64{'k': 1 << 10, 'm': 1 << 20, 'g': 1 << 30, 't': 1 << 40}
+	if unit, ok := units[strings.ToLower(value)[len(value)-1]]; ok {
+		multiplier = unit
+		value = value[:len(value)-1]
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size <= 0 {
+		return 0, false
+	}
+	return size * multiplier >> 20, true
+}
+
+// getServerEncryptionOptions returns the server_encryption_options of the
+// internode encryption mode
+func (dc *CassandraDatacenter) getServerEncryptionOptions(mode InternodeEncryptionMode) map[string]interface{} {
+	internodeEncryption := "all"
+	if mode == InternodeEncryptionNone {
+		internodeEncryption = "none"
+	}
+	options := map[string]interface{}{
+		"internode_encryption": internodeEncryption,
+		"keystore":             InternodeEncryptionDir + "/keystore.jks",
+		"keystore_password":    InternodeKeystorePasswordPlaceholder,
+		"truststore":           InternodeEncryptionDir + "/truststore.jks",
+		"truststore_password":  InternodeKeystorePasswordPlaceholder,
+	}
+	if dc.supportsOptionalInternodeEncryption() {
+		options["optional"] = mode != InternodeEncryptionRequired
+	}
+	return options
+}
+
 // Gets the defined CQL port for NodePort.
 // 0 will be returned if NodePort is not configured.
 // The SSL port will be returned if it is defined,
@@ -682,8 +3441,8 @@ func namedPort(name string, port int) corev1.ContainerPort {
 // GetContainerPorts will return the container ports for the pods in a statefulset based on the provided config
 func (dc *CassandraDatacenter) GetContainerPorts() ([]corev1.ContainerPort, error) {
 
-	nativePort := DefaultNativePort
-	internodePort := DefaultInternodePort
+	nativePort := dc.GetNativePort()
+	internodePort := dc.GetStoragePort()
 
 	// Note: Port Names cannot be more than 15 characters
 
@@ -692,12 +3451,16 @@ func (dc *CassandraDatacenter) GetContainerPorts() ([]corev1.ContainerPort, erro
 		namedPort("tls-native", 9142),
 		namedPort("internode", internodePort),
 		namedPort("tls-internode", 7001),
-		namedPort("jmx", 7199),
+		namedPort("jmx", dc.GetJmxPort()),
 		namedPort("mgmt-api-http", 8080),
-		namedPort("prometheus", 9103),
-		namedPort("thrift", 9160),
 	}
 
+	if dc.IsMetricsCollectorEnabled() {
+		ports = append(ports, namedPort("prometheus", MetricsCollectorPort))
+	}
+
+	ports = append(ports, namedPort("thrift", 9160))
+
 	if dc.Spec.ServerType == "dse" {
 		ports = append(
 			ports,
@@ -705,8 +3468,8 @@ func (dc *CassandraDatacenter) GetContainerPorts() ([]corev1.ContainerPort, erro
 		)
 	}
 
-	if dc.Spec.DseWorkloads != nil {
-		if dc.Spec.DseWorkloads.AnalyticsEnabled {
+	if workloads := dc.GetDseWorkloads(); workloads != nil {
+		if workloads.AnalyticsEnabled {
 			ports = append(
 				ports,
 				namedPort("spark-app-4040", 4040),
@@ -733,14 +3496,14 @@ func (dc *CassandraDatacenter) GetContainerPorts() ([]corev1.ContainerPort, erro
 			)
 		}
 
-		if dc.Spec.DseWorkloads.GraphEnabled {
+		if workloads.GraphEnabled {
 			ports = append(
 				ports,
 				namedPort("gremlin", 8182),
 			)
 		}
 
-		if dc.Spec.DseWorkloads.SearchEnabled {
+		if workloads.SearchEnabled {
 			ports = append(
 				ports,
 				namedPort("solr", 8983),
@@ -766,3 +3529,16 @@ func SplitRacks(nodeCount, rackCount int) []int {
 
 	return topology
 }
+
+// AnnotatedDatacenters returns the names of the datacenters in the
+// DatacenterAnnotation of a config secret or ConfigMap, which lists every
+// datacenter sharing it, separated by commas
+func AnnotatedDatacenters(annotations map[string]string) []string {
+	var names []string
+	for _, name := range strings.Split(annotations[DatacenterAnnotation], ",") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -4,10 +4,13 @@
 package v1beta1
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -98,6 +101,88 @@ func Test_GenerateBaseConfigString(t *testing.T) {
 			want:      "",
 			errString: "Error parsing Spec.Config for CassandraDatacenter resource: invalid character ':' after top-level value",
 		},
+		{
+			name: "Query logging directories",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName:      "exampleCluster",
+					FullQueryLogging: &QueryLoggingConfig{Enabled: true},
+					AuditLogging:     &QueryLoggingConfig{LogDir: "/audit"},
+				},
+			},
+			want:      `{"cassandra-yaml":{"audit_logging_options":{"audit_logs_dir":"/audit"},"full_query_logging_options":{"log_dir":"/var/log/cassandra/fql"}},"cluster-info":{"name":"exampleCluster","seeds":"exampleCluster-seed-service"},"datacenter-info":{"graph-enabled":0,"name":"exampleDC","solr-enabled":0,"spark-enabled":0}}`,
+			errString: "",
+		},
+		{
+			name: "CDC on its own volume",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "exampleCluster",
+					CDC: &CDCConfig{
+						Enabled:         true,
+						VolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{},
+					},
+				},
+			},
+			want:      `{"cassandra-yaml":{"cdc_enabled":true,"cdc_raw_directory":"/var/lib/cassandra-cdc"},"cluster-info":{"name":"exampleCluster","seeds":"exampleCluster-seed-service"},"datacenter-info":{"graph-enabled":0,"name":"exampleDC","solr-enabled":0,"spark-enabled":0}}`,
+			errString: "",
+		},
+		{
+			name: "Query logging directory from config",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName:      "exampleCluster",
+					Config:           []byte(`{"cassandra-yaml":{"full_query_logging_options":{"log_dir":"/fql"}}}`),
+					FullQueryLogging: &QueryLoggingConfig{Enabled: true},
+				},
+			},
+			want:      `{"cassandra-yaml":{"full_query_logging_options":{"log_dir":"/fql"}},"cluster-info":{"name":"exampleCluster","seeds":"exampleCluster-seed-service"},"datacenter-info":{"graph-enabled":0,"name":"exampleDC","solr-enabled":0,"spark-enabled":0}}`,
+			errString: "",
+		},
+		{
+			name: "Additional data directories",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "exampleCluster",
+					StorageConfig: StorageConfig{
+						AdditionalDataVolumes: AdditionalVolumesSlice{
+							{Name: "data1", MountPath: "/var/lib/cassandra-data1"},
+							{Name: "data2", MountPath: "/var/lib/cassandra-data2"},
+						},
+					},
+				},
+			},
+			want:      `{"cassandra-yaml":{"data_file_directories":["/var/lib/cassandra/data","/var/lib/cassandra-data1","/var/lib/cassandra-data2"]},"cluster-info":{"name":"exampleCluster","seeds":"exampleCluster-seed-service"},"datacenter-info":{"graph-enabled":0,"name":"exampleDC","solr-enabled":0,"spark-enabled":0}}`,
+			errString: "",
+		},
+		{
+			name: "Commit log directory",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "exampleCluster",
+					StorageConfig: StorageConfig{
+						CommitLogVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{},
+					},
+				},
+			},
+			want:      `{"cassandra-yaml":{"commitlog_directory":"/var/lib/cassandra-commitlog"},"cluster-info":{"name":"exampleCluster","seeds":"exampleCluster-seed-service"},"datacenter-info":{"graph-enabled":0,"name":"exampleDC","solr-enabled":0,"spark-enabled":0}}`,
+			errString: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -252,3 +337,409 @@ func TestCassandraDatacenter_GetRackLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestCassandraDatacenter_PersistentVolumeClaimRetentionPolicy(t *testing.T) {
+	dc := &CassandraDatacenter{}
+	assert.True(t, dc.DeletePVCsWhenDeleted())
+	assert.True(t, dc.DeletePVCsWhenScaled())
+
+	dc.Spec.PersistentVolumeClaimRetentionPolicy = &PersistentVolumeClaimRetentionPolicy{
+		WhenDeleted: RetainPersistentVolumeClaimRetentionPolicyType,
+	}
+	assert.False(t, dc.DeletePVCsWhenDeleted())
+	assert.True(t, dc.DeletePVCsWhenScaled())
+
+	dc.Spec.PersistentVolumeClaimRetentionPolicy = &PersistentVolumeClaimRetentionPolicy{
+		WhenScaled: RetainPersistentVolumeClaimRetentionPolicyType,
+	}
+	assert.True(t, dc.DeletePVCsWhenDeleted())
+	assert.False(t, dc.DeletePVCsWhenScaled())
+}
+
+func TestMaintenanceWindow_IsOpen(t *testing.T) {
+	// Every Saturday at 2am UTC for 4 hours
+	window := &MaintenanceWindow{
+		Schedule: "0 2 * * 6",
+		Duration: metav1.Duration{Duration: 4 * time.Hour},
+	}
+	saturday := time.Date(2021, time.March, 6, 0, 0, 0, 0, time.UTC)
+
+	open, _, err := window.IsOpen(saturday.Add(3 * time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, open)
+
+	open, _, err = window.IsOpen(saturday.Add(2 * time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, open, "the window is open from the time it opens")
+
+	open, opensAt, err := window.IsOpen(saturday.Add(6 * time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, open, "the window is closed once its duration has passed")
+	assert.Equal(t, saturday.Add(7*24*time.Hour+2*time.Hour), opensAt)
+
+	open, opensAt, err = window.IsOpen(saturday.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, open)
+	assert.Equal(t, saturday.Add(2*time.Hour), opensAt)
+
+	window.Schedule = "saturday night"
+	_, _, err = window.IsOpen(saturday)
+	assert.Error(t, err)
+}
+
+func TestCassandraDatacenterStatus_ComputePhase(t *testing.T) {
+	withConditions := func(progress ProgressState, conditions ...DatacenterCondition) CassandraDatacenterStatus {
+		return CassandraDatacenterStatus{CassandraOperatorProgress: progress, Conditions: conditions}
+	}
+	condition := func(conditionType DatacenterConditionType, status corev1.ConditionStatus) DatacenterCondition {
+		return *NewDatacenterCondition(conditionType, status)
+	}
+
+	tests := []struct {
+		name   string
+		status CassandraDatacenterStatus
+		want   DatacenterPhase
+	}{
+		{"new", withConditions(ProgressUpdating), PhaseInitializing},
+		{"ready", withConditions(ProgressReady,
+			condition(DatacenterInitialized, corev1.ConditionTrue),
+			condition(DatacenterReady, corev1.ConditionTrue)), PhaseReady},
+		{"updating", withConditions(ProgressUpdating,
+			condition(DatacenterInitialized, corev1.ConditionTrue),
+			condition(DatacenterReady, corev1.ConditionTrue)), PhaseUpdating},
+		{"stopped", withConditions(ProgressReady,
+			condition(DatacenterInitialized, corev1.ConditionTrue),
+			condition(DatacenterStopped, corev1.ConditionTrue)), PhaseStopped},
+		{"resuming", withConditions(ProgressUpdating,
+			condition(DatacenterInitialized, corev1.ConditionTrue),
+			condition(DatacenterResuming, corev1.ConditionTrue)), PhaseResuming},
+		{"hibernated", withConditions(ProgressReady,
+			condition(DatacenterInitialized, corev1.ConditionTrue),
+			condition(DatacenterReady, corev1.ConditionTrue),
+			condition(DatacenterHibernated, corev1.ConditionTrue)), PhaseHibernated},
+		{"invalid", withConditions(ProgressUpdating,
+			condition(DatacenterValid, corev1.ConditionFalse)), PhaseError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.status.ComputePhase())
+		})
+	}
+}
+
+func TestCassandraDatacenter_GetInternodeEncryptionMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		initialized bool
+		status      InternodeEncryptionMode
+		spec        InternodeEncryptionMode
+		want        InternodeEncryptionMode
+	}{
+		{"new datacenter", false, "", InternodeEncryptionRequired, InternodeEncryptionRequired},
+		{"set up", true, "", InternodeEncryptionRequired, InternodeEncryptionNone},
+		{"set up without encryption", true, "", InternodeEncryptionNone, InternodeEncryptionNone},
+		{"enabled", true, InternodeEncryptionNone, InternodeEncryptionRequired, InternodeEncryptionOptional},
+		{"required", true, InternodeEncryptionOptional, InternodeEncryptionRequired, InternodeEncryptionRequired},
+		{"disabled", true, InternodeEncryptionRequired, InternodeEncryptionNone, InternodeEncryptionOptional},
+		{"rolled out", true, InternodeEncryptionRequired, InternodeEncryptionRequired, InternodeEncryptionRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &CassandraDatacenter{
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Encryption: &EncryptionConfig{
+						Internode: &InternodeEncryptionConfig{Mode: tt.spec},
+					},
+				},
+				Status: CassandraDatacenterStatus{InternodeEncryptionMode: tt.status},
+			}
+			if tt.initialized {
+				dc.Status.SetCondition(*NewDatacenterCondition(DatacenterInitialized, corev1.ConditionTrue))
+			}
+			assert.Equal(t, tt.want, dc.GetInternodeEncryptionMode())
+		})
+	}
+
+	assert.Equal(t, InternodeEncryptionMode(""), (&CassandraDatacenter{}).GetInternodeEncryptionMode())
+}
+
+func TestCassandraDatacenter_GetInternodeEncryptionMode_WithoutOptional(t *testing.T) {
+	tests := []struct {
+		name   string
+		status InternodeEncryptionMode
+		spec   InternodeEncryptionMode
+		want   InternodeEncryptionMode
+	}{
+		{"enabled", InternodeEncryptionNone, InternodeEncryptionRequired, InternodeEncryptionRequired},
+		{"optional", InternodeEncryptionNone, InternodeEncryptionOptional, InternodeEncryptionRequired},
+		{"disabled", InternodeEncryptionRequired, InternodeEncryptionNone, InternodeEncryptionNone},
+		{"rolled out as optional", InternodeEncryptionOptional, InternodeEncryptionRequired, InternodeEncryptionRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &CassandraDatacenter{
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.4",
+					Encryption: &EncryptionConfig{
+						Internode: &InternodeEncryptionConfig{Mode: tt.spec},
+					},
+				},
+				Status: CassandraDatacenterStatus{InternodeEncryptionMode: tt.status},
+			}
+			dc.Status.SetCondition(*NewDatacenterCondition(DatacenterInitialized, corev1.ConditionTrue))
+			assert.Equal(t, tt.want, dc.GetInternodeEncryptionMode())
+		})
+	}
+}
+
+func TestCassandraDatacenter_GetConfigAsJSON_InternodeEncryption(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ClusterName:   "cluster",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+			Encryption: &EncryptionConfig{
+				Internode: &InternodeEncryptionConfig{Mode: InternodeEncryptionOptional},
+			},
+		},
+	}
+
+	config, err := dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, config, `"server_encryption_options":{`)
+	assert.Contains(t, config, `"internode_encryption":"all"`)
+	assert.Contains(t, config, `"optional":true`)
+	assert.Contains(t, config, `"keystore":"/etc/internode-encryption/keystore.jks"`)
+	assert.Contains(t, config, `"keystore_password":"`+InternodeKeystorePasswordPlaceholder+`"`)
+
+	dc.Spec.ServerVersion = "3.11.10"
+	config, err = dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, config, `"optional"`)
+}
+
+func TestCassandraDatacenter_GetConfigAsJSON_ClientEncryption(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ClusterName:   "cluster",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+			Encryption: &EncryptionConfig{
+				Client: &ClientEncryptionConfig{
+					CertManagerIssuerRef: &CertManagerIssuerRef{Name: "ca-issuer"},
+					RequireClientAuth:    true,
+				},
+			},
+		},
+	}
+
+	config, err := dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, config, `"client_encryption_options":{`)
+	assert.Contains(t, config, `"enabled":true`)
+	assert.Contains(t, config, `"require_client_auth":true`)
+	assert.Contains(t, config, `"keystore":"/etc/client-encryption/keystore.jks"`)
+	assert.Contains(t, config, `"keystore_password":"`+ClientKeystorePasswordPlaceholder+`"`)
+
+	dc.Spec.Encryption.Client.CertManagerIssuerRef = nil
+	config, err = dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, config, `"client_encryption_options"`)
+}
+
+func TestCassandraDatacenter_GetConfigAsJSON_Ports(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ClusterName:   "cluster",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+			Networking: &NetworkingConfig{
+				HostNetwork: true,
+				Ports:       &PortsConfig{Native: 9043, Storage: 7010, Jmx: 7299},
+			},
+		},
+	}
+
+	config, err := dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, config, `"native_transport_port":9043`)
+	assert.Contains(t, config, `"storage_port":7010`)
+
+	ports, err := dc.GetContainerPorts()
+	assert.NoError(t, err)
+	assert.Contains(t, ports, corev1.ContainerPort{Name: "native", ContainerPort: 9043})
+	assert.Contains(t, ports, corev1.ContainerPort{Name: "internode", ContainerPort: 7010})
+	assert.Contains(t, ports, corev1.ContainerPort{Name: "jmx", ContainerPort: 7299})
+
+	dc.Spec.Networking.Ports = nil
+	config, err = dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, config, `"native_transport_port"`)
+	assert.Equal(t, JmxPort, dc.GetJmxPort())
+}
+
+func TestCassandraDatacenter_GetConfigAsJSON_AutoTuneJvm(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ClusterName:   "cluster",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+			AutoTuneJvm:   true,
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("96Gi"),
+					corev1.ResourceCPU:    resource.MustParse("8"),
+				},
+			},
+		},
+	}
+
+	config, err := dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, config, `"jvm-server-options":{"initial_heap_size":"31744M","max_heap_size":"31744M"}`)
+	assert.Contains(t, config, `"jvm11-server-options":{"garbage_collector":"G1GC"}`)
+
+	// The options of the config take precedence, and the initial heap never
+	// exceeds the max heap
+	config, err = dc.GetConfigAsJSON([]byte(`{"jvm-server-options":{"max_heap_size":"16G"}}`))
+	assert.NoError(t, err)
+	assert.Contains(t, config, `"jvm-server-options":{"initial_heap_size":"16384M","max_heap_size":"16G"}`)
+
+	config, err = dc.GetConfigAsJSON([]byte(`{"jvm-server-options":{"initial_heap_size":"40960m"}}`))
+	assert.NoError(t, err)
+	assert.Contains(t, config, `"jvm-server-options":{"initial_heap_size":"40960m","max_heap_size":"40960M"}`)
+
+	dc.Spec.ServerVersion = "3.11.7"
+	config, err = dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, config, `"jvm-options":{"garbage_collector":"CMS","heap_size_young_generation":"800M","initial_heap_size":"8192M","max_heap_size":"8192M"}`)
+
+	config, err = dc.GetConfigAsJSON([]byte(`{"jvm-options":{"max_heap_size":"1G"}}`))
+	assert.NoError(t, err)
+	assert.Contains(t, config, `"jvm-options":{"garbage_collector":"CMS","heap_size_young_generation":"256M","initial_heap_size":"1024M","max_heap_size":"1G"}`)
+
+	dc.Spec.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")}
+	config, err = dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, config, `"jvm-options":{"garbage_collector":"CMS","heap_size_young_generation":"512M","initial_heap_size":"2048M","max_heap_size":"2048M"}`)
+
+	dc.Spec.AutoTuneJvm = false
+	config, err = dc.GetConfigAsJSON(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, config, `"jvm-options"`)
+}
+
+func TestCassandraDatacenter_GetConfigAsJSON_ServerVersion(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ClusterName:   "cluster",
+			ServerType:    "cassandra",
+			ServerVersion: "5.0.0",
+		},
+	}
+
+	config := []byte(`{"jvm17-server-options":{"garbage_collector":"G1GC"},"cassandra-yaml":{"commitlog_segment_size":"64MiB"}}`)
+	_, err := dc.GetConfigAsJSON(config)
+	assert.NoError(t, err)
+
+	// The config is not rolled out to nodes that would not start with it
+	dc.Spec.ServerVersion = "4.0.0"
+	_, err = dc.GetConfigAsJSON(config)
+	assert.EqualError(t, err, "Rejected Spec.Config for the server version, attempted to: define config jvm17-server-options "+
+		"with cassandra-4.0.0, which reads jvm-server-options, jvm8-server-options, jvm11-server-options")
+}
+
+func TestCassandraDatacenter_Federation(t *testing.T) {
+	dc := &CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc2", Namespace: "ns1"},
+		Spec: CassandraDatacenterSpec{
+			ClusterName:  "cluster1",
+			ClusterSeeds: []ClusterSeedsSource{{ConfigMapName: "cluster1-dc1-seeds"}},
+			Federation:   &FederationConfig{ImportSecret: "cluster1-dc1-federation"},
+		},
+	}
+	assert.True(t, dc.HasAdditionalSeeds())
+	assert.False(t, dc.ShouldGenerateSuperuserSecret())
+	assert.Equal(t, "cluster1-dc1-federation", dc.GetSuperuserSecretNamespacedName().Name)
+	assert.Equal(t, "cluster1-dc2-seeds", dc.GetSeedsConfigMapName())
+	assert.False(t, dc.IsFederationPublished())
+}
+
+func TestCassandraDatacenter_GetAddressType(t *testing.T) {
+	dc := &CassandraDatacenter{}
+	assert.Equal(t, AddressTypePodIP, dc.GetAddressType())
+
+	dc.Spec.Networking = &NetworkingConfig{NodePort: &NodePortConfig{Native: 30042}}
+	assert.Equal(t, AddressTypeHostIP, dc.GetAddressType())
+
+	dc.Spec.Networking.AddressType = AddressTypePodIP
+	assert.Equal(t, AddressTypePodIP, dc.GetAddressType())
+}
+
+func TestCassandraDatacenter_GetSniHostname(t *testing.T) {
+	dc := &CassandraDatacenter{}
+	assert.Equal(t, "", dc.GetSniHostname("cluster1-dc1-r1-sts-0"))
+
+	dc.Spec.Networking = &NetworkingConfig{SNI: &SNIConfig{Domain: "cassandra.example.com."}}
+	assert.Equal(t, "cluster1-dc1-r1-sts-0.cassandra.example.com", dc.GetSniHostname("cluster1-dc1-r1-sts-0"))
+	assert.Equal(t, "nginx", dc.GetSniIngressClass())
+}
+
+func TestCassandraDatacenter_Vault(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			ClusterName: "cluster",
+			Vault:       &VaultConfig{SecretProviderClass: "vault-cassandra"},
+		},
+	}
+	assert.True(t, dc.ShouldGenerateSuperuserSecret())
+	assert.False(t, dc.UsesConfigSecret())
+	assert.Equal(t, time.Duration(0), dc.GetVaultRefreshInterval())
+
+	dc.Spec.Vault.SuperuserSecretPath = "secret/data/cassandra/superuser"
+	dc.Spec.Vault.ConfigSecretPath = "secret/data/cassandra/config"
+	assert.False(t, dc.ShouldGenerateSuperuserSecret())
+	assert.True(t, dc.UsesConfigSecret())
+	assert.Equal(t, 5*time.Minute, dc.GetVaultRefreshInterval())
+
+	dc.Spec.Vault.RefreshIntervalSeconds = 60
+	assert.Equal(t, time.Minute, dc.GetVaultRefreshInterval())
+}
+
+func TestCassandraDatacenter_GetConfigValidationJobName(t *testing.T) {
+	dc := &CassandraDatacenter{ObjectMeta: metav1.ObjectMeta{Name: "dc1"}}
+	assert.Equal(t, "dc1-config-validation-0123abcd", dc.GetConfigValidationJobName("0123abcdef"))
+
+	dc.Name = strings.Repeat("a", 35) + "-" + strings.Repeat("b", 20)
+	name := dc.GetConfigValidationJobName("0123abcdef")
+	assert.Equal(t, strings.Repeat("a", 35)+"-config-validation-0123abcd", name)
+	assert.True(t, len(name) <= 63)
+}
+
+func TestCassandraDatacenter_GetRackConfig(t *testing.T) {
+	dc := &CassandraDatacenter{
+		Spec: CassandraDatacenterSpec{
+			Config: []byte(`{"cassandra-yaml":{"concurrent_compactors":2,"concurrent_reads":32}}`),
+			Racks: []Rack{
+				{Name: "rack1"},
+				{Name: "rack2", Config: []byte(`{"cassandra-yaml":{"concurrent_compactors":4}}`)},
+			},
+		},
+	}
+
+	config, err := dc.GetRackConfig("rack1")
+	assert.NoError(t, err)
+	assert.Equal(t, string(dc.Spec.Config), string(config))
+
+	config, err = dc.GetRackConfig("rack2")
+	assert.NoError(t, err)
+	assert.Contains(t, string(config), `"concurrent_compactors":4`)
+	assert.Contains(t, string(config), `"concurrent_reads":32`)
+
+	dc.Spec.Racks[1].Config = []byte(`{"cassandra-yaml":`)
+	_, err = dc.GetRackConfig("rack2")
+	assert.Error(t, err)
+}
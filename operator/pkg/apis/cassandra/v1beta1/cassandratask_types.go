@@ -0,0 +1,203 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CassandraTaskCommand is the operation a CassandraTask runs against each node
+type CassandraTaskCommand string
+
+const (
+	CommandCleanup         CassandraTaskCommand = "cleanup"
+	CommandRebuild         CassandraTaskCommand = "rebuild"
+	CommandUpgradeSSTables CassandraTaskCommand = "upgradesstables"
+	CommandFlush           CassandraTaskCommand = "flush"
+	CommandGarbageCollect  CassandraTaskCommand = "garbagecollect"
+	CommandCompact         CassandraTaskCommand = "compact"
+	CommandCompactionStats CassandraTaskCommand = "compactionstats"
+	CommandRestart         CassandraTaskCommand = "restart"
+
+	// CommandRelocateRack replaces the nodes of a rack one at a time, so that
+	// they follow the placement of the rack after it was moved, such as to
+	// another zone
+	CommandRelocateRack CassandraTaskCommand = "relocaterack"
+)
+
+// TaskState is the state of a CassandraTask, or of the task on a single pod
+type TaskState string
+
+const (
+	TaskStatePending   TaskState = "Pending"
+	TaskStateRunning   TaskState = "Running"
+	TaskStateSucceeded TaskState = "Succeeded"
+	TaskStateFailed    TaskState = "Failed"
+)
+
+// CassandraTaskArgs are the optional arguments passed along with the command
+type CassandraTaskArgs struct {
+	// Keyspace to run the command against. Defaults to all keyspaces.
+	// +optional
+	KeyspaceName string `json:"keyspaceName,omitempty"`
+
+	// Tables to run the command against. Defaults to all tables in the keyspace.
+	// +optional
+	Tables []string `json:"tables,omitempty"`
+
+	// Number of concurrent compaction jobs to use on each node. Only used by
+	// cleanup, upgradesstables and garbagecollect.
+	// +optional
+	Jobs *int `json:"jobs,omitempty"`
+
+	// The datacenter to stream data from. Required by rebuild.
+	// +optional
+	SourceDatacenter string `json:"sourceDatacenter,omitempty"`
+
+	// The rack whose nodes are relocated. Required by relocaterack.
+	// +optional
+	Rack string `json:"rack,omitempty"`
+}
+
+// CassandraTaskSpec defines the desired state of CassandraTask
+// +k8s:openapi-gen=true
+type CassandraTaskSpec struct {
+	// The CassandraDatacenter the task runs against. If the namespace is
+	// left empty, the namespace of the CassandraTask is used.
+	Datacenter corev1.ObjectReference `json:"datacenter"`
+
+	// +kubebuilder:validation:Enum=cleanup;rebuild;upgradesstables;flush;garbagecollect;compact;compactionstats;restart;relocaterack
+	Command CassandraTaskCommand `json:"command"`
+
+	// +optional
+	Args CassandraTaskArgs `json:"args,omitempty"`
+
+	// The names of the pods the command runs on. Defaults to all the pods of
+	// the datacenter, or of the rack for relocaterack.
+	// +optional
+	Pods []string `json:"pods,omitempty"`
+
+	// The maximum number of pods the command runs on at the same time.
+	// Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// The number of times the command is retried on a pod before that pod
+	// is marked as failed.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRetries int `json:"maxRetries,omitempty"`
+}
+
+// CassandraTaskPodStatus is the progress of the task on a single pod
+type CassandraTaskPodStatus struct {
+	State TaskState `json:"state"`
+
+	// +optional
+	Attempts int `json:"attempts,omitempty"`
+
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// The output of the command on the pod, for the commands that read from
+	// the node such as compactionstats. Long outputs are truncated.
+	// +optional
+	Output string `json:"output,omitempty"`
+
+	// The UID of the pod a restart or a relocation deleted, which tells it
+	// apart from the pod that replaces it
+	// +optional
+	RestartedUID types.UID `json:"restartedUID,omitempty"`
+
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// CassandraTaskStatus defines the observed state of CassandraTask
+// +k8s:openapi-gen=true
+type CassandraTaskStatus struct {
+	// +optional
+	State TaskState `json:"state,omitempty"`
+
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Progress of the task keyed by pod name
+	// +optional
+	Pods map[string]CassandraTaskPodStatus `json:"pods,omitempty"`
+
+	// +optional
+	Succeeded int `json:"succeeded,omitempty"`
+
+	// +optional
+	Failed int `json:"failed,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraTask is the Schema for the cassandratasks API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=cassandratasks,scope=Namespaced,shortName=casstask
+type CassandraTask struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraTaskSpec   `json:"spec,omitempty"`
+	Status CassandraTaskStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraTaskList contains a list of CassandraTask
+type CassandraTaskList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraTask `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraTask{}, &CassandraTaskList{})
+}
+
+func objectReferenceKey(ref corev1.ObjectReference, defaultNamespace string) types.NamespacedName {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: ref.Name}
+}
+
+// GetDatacenterKey returns the namespaced name of the CassandraDatacenter
+// targeted by the task
+func (task *CassandraTask) GetDatacenterKey() types.NamespacedName {
+	return objectReferenceKey(task.Spec.Datacenter, task.Namespace)
+}
+
+// GetConcurrency returns the number of pods the task may run on at once. A
+// rack is relocated one node at a time.
+func (task *CassandraTask) GetConcurrency() int {
+	if task.Spec.Concurrency < 1 || task.Spec.Command == CommandRelocateRack {
+		return 1
+	}
+	return task.Spec.Concurrency
+}
+
+// ReturnsOutput tells whether the command of the task reads from the nodes
+// and records what they answer in the status, rather than running an
+// operation on them
+func (task *CassandraTask) ReturnsOutput() bool {
+	return task.Spec.Command == CommandCompactionStats
+}
+
+// IsFinished reports whether the task has reached a terminal state
+func (status *CassandraTaskStatus) IsFinished() bool {
+	return status.State == TaskStateSucceeded || status.State == TaskStateFailed
+}
@@ -0,0 +1,8 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package v1beta1
+
+// Hub marks v1beta1 as the version the other versions of CassandraDatacenter
+// are converted to and from. It is also the version that is stored.
+func (*CassandraDatacenter) Hub() {}
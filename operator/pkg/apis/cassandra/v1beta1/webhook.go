@@ -7,17 +7,84 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
 	"reflect"
+	"regexp"
 	"strings"
 
+	"github.com/Jeffail/gabs"
 	"github.com/k8ssandra/cass-operator/operator/pkg/images"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/serverconfig"
+	vaultroles "github.com/k8ssandra/cass-operator/operator/pkg/vault"
+	"github.com/robfig/cron"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 var log = logf.Log.WithName("api")
 
+// The names of the init container that copies the internode keystore of its
+// node and of the volume of the keystores of all the nodes, as the
+// reconciliation names them
+const (
+	internodeKeystoreContainerName = "internode-keystore-init"
+	internodeKeystoresVolumeName   = "internode-keystores"
+)
+
+// The names of the containers and init containers the reconciliation can add
+// to the pods. A sidecar or an init container of the datacenter with one of
+// these names is merged into the one of the operator.
+var (
+	operatorContainerNames = map[string]bool{
+		"cassandra":            true,
+		"server-system-logger": true,
+		"backup-sidecar":       true,
+		"medusa":               true,
+		"cdc-forwarder":        true,
+	}
+	operatorInitContainerNames = map[string]bool{
+		"server-config-init":           true,
+		"mcac-agent-init":              true,
+		internodeKeystoreContainerName: true,
+		"client-keystore-init":         true,
+		"jmx-credentials-init":         true,
+		"broadcast-address-init":       true,
+		"logback-init":                 true,
+	}
+)
+
+// The names of the volumes the reconciliation can add to the pods, which the
+// volumes of the storage config cannot take. server-logs is not one of them,
+// as an additional volume of that name replaces the emptyDir of the logs.
+var operatorVolumeNames = map[string]bool{
+	"server-data":                true,
+	"server-commitlog":           true,
+	"server-cdc":                 true,
+	"server-config":              true,
+	"encryption-cred-storage":    true,
+	"rendered-config":            true,
+	"full-query-logs":            true,
+	"audit-logs":                 true,
+	"mcac-agent":                 true,
+	"mcac-config":                true,
+	"logback-config":             true,
+	internodeKeystoresVolumeName: true,
+	"internode-encryption":       true,
+	"client-keystores":           true,
+	"vault-secrets":              true,
+	"jmx-credentials":            true,
+	"medusa-config":              true,
+	"medusa-secrets":             true,
+	"pod-annotations":            true,
+}
+
 func attemptedTo(action string, actionStrArgs ...interface{}) error {
 	var msg string
 	if actionStrArgs != nil {
@@ -28,6 +95,35 @@ func attemptedTo(action string, actionStrArgs ...interface{}) error {
 	return fmt.Errorf("CassandraDatacenter write rejected, attempted to %s", msg)
 }
 
+// validateConfigSections checks that the sections and options of the config
+// are the ones of the server version
+func validateConfigSections(dc CassandraDatacenter, c map[string]interface{}) error {
+	isDse := dc.Spec.ServerType == "dse"
+	isCassandra3 := dc.Spec.ServerType == "cassandra" && strings.HasPrefix(dc.Spec.ServerVersion, "3.")
+	isCassandra4 := dc.Spec.ServerType == "cassandra" && serverconfig.IsCassandraAtLeast(dc.Spec.ServerVersion, 4, 0)
+
+	_, hasJvmOptions := c["jvm-options"]
+	_, hasJvmServerOptions := c["jvm-server-options"]
+	_, hasDseYaml := c["dse-yaml"]
+
+	serverStr := fmt.Sprintf("%s-%s", dc.Spec.ServerType, dc.Spec.ServerVersion)
+	if hasJvmOptions && (isDse || isCassandra4) {
+		return attemptedTo("define config jvm-options with %s", serverStr)
+	}
+	if hasJvmServerOptions && isCassandra3 {
+		return attemptedTo("define config jvm-server-options with %s", serverStr)
+	}
+	if hasDseYaml && (isCassandra3 || isCassandra4) {
+		return attemptedTo("define config dse-yaml with %s", serverStr)
+	}
+	if dc.Spec.ServerType == "cassandra" {
+		if err := serverconfig.ValidateConfig(dc.Spec.ServerVersion, c); err != nil {
+			return attemptedTo("%v", err)
+		}
+	}
+	return nil
+}
+
 // ValidateSingleDatacenter checks that no values are improperly set on a CassandraDatacenter
 func ValidateSingleDatacenter(dc CassandraDatacenter) error {
 	// Ensure serverVersion and serverType are compatible
@@ -48,28 +144,237 @@ func ValidateSingleDatacenter(dc CassandraDatacenter) error {
 		if !images.IsOssVersionSupported(dc.Spec.ServerVersion) {
 			return attemptedTo("use unsupported Cassandra version '%s'", dc.Spec.ServerVersion)
 		}
+		if !dc.RendersConfigInOperator() && dc.Spec.ConfigBuilderImage == "" &&
+			!images.IsOssVersionRenderedByConfigBuilder(dc.Spec.ServerVersion) {
+			return attemptedTo("use Cassandra version '%s', whose configuration the default config builder image cannot render, without a configBuilderImage or the operator configRenderer", dc.Spec.ServerVersion)
+		}
 	}
 
-	isDse := dc.Spec.ServerType == "dse"
-	isCassandra3 := dc.Spec.ServerType == "cassandra" && strings.HasPrefix(dc.Spec.ServerVersion, "3.")
-	isCassandra4 := dc.Spec.ServerType == "cassandra" && strings.HasPrefix(dc.Spec.ServerVersion, "4.")
+	isCassandra4 := dc.Spec.ServerType == "cassandra" && serverconfig.IsCassandraAtLeast(dc.Spec.ServerVersion, 4, 0)
 
 	var c map[string]interface{}
 	_ = json.Unmarshal(dc.Spec.Config, &c)
+	if err := validateConfigSections(dc, c); err != nil {
+		return err
+	}
 
-	_, hasJvmOptions := c["jvm-options"]
-	_, hasJvmServerOptions := c["jvm-server-options"]
-	_, hasDseYaml := c["dse-yaml"]
+	// The configs of the racks are validated merged over the one of the
+	// datacenter, as the nodes of the racks get them
+	for _, rack := range dc.Spec.Racks {
+		if len(rack.Config) == 0 {
+			continue
+		}
+		if dc.UsesConfigSecret() {
+			return attemptedTo("set the config of rack %s along with configSecret", rack.Name)
+		}
+		rackConfig, err := dc.GetRackConfig(rack.Name)
+		if err != nil {
+			return attemptedTo("set the invalid config of rack %s", rack.Name)
+		}
+		var merged map[string]interface{}
+		_ = json.Unmarshal(rackConfig, &merged)
+		if err := validateConfigSections(dc, merged); err != nil {
+			return err
+		}
+	}
 
 	serverStr := fmt.Sprintf("%s-%s", dc.Spec.ServerType, dc.Spec.ServerVersion)
-	if hasJvmOptions && (isDse || isCassandra4) {
-		return attemptedTo("define config jvm-options with %s", serverStr)
+
+	if dc.Spec.FullQueryLogging != nil && !isCassandra4 {
+		return attemptedTo("configure full query logging with %s", serverStr)
 	}
-	if hasJvmServerOptions && isCassandra3 {
-		return attemptedTo("define config jvm-server-options with %s", serverStr)
+	if dc.Spec.AuditLogging != nil && !isCassandra4 {
+		return attemptedTo("configure audit logging with %s", serverStr)
 	}
-	if hasDseYaml && (isCassandra3 || isCassandra4) {
-		return attemptedTo("define config dse-yaml with %s", serverStr)
+	for _, logDir := range []string{dc.GetFullQueryLogDir(), dc.GetAuditLogDir()} {
+		if logDir != "" && !path.IsAbs(logDir) {
+			return attemptedTo("use relative log directory '%s'", logDir)
+		}
+	}
+
+	if err := validateDataVolumes(dc.Spec.StorageConfig); err != nil {
+		return err
+	}
+
+	if err := validateSidecars(dc.Spec); err != nil {
+		return err
+	}
+
+	if err := validateAdditionalMetadata(dc.Spec); err != nil {
+		return err
+	}
+
+	for _, service := range []struct {
+		name   string
+		config ServiceConfigAdditions
+	}{
+		{"seedService", dc.Spec.AdditionalServiceConfig.SeedService},
+		{"allpodsService", dc.Spec.AdditionalServiceConfig.AllPodsService},
+		{"additionalSeedService", dc.Spec.AdditionalServiceConfig.AdditionalSeedService},
+	} {
+		if service.config.Type != "" {
+			return attemptedTo("change the type of the %s of additionalServiceConfig, which must stay headless", service.name)
+		}
+	}
+
+	if internode := dc.GetInternodeEncryption(); internode != nil && internode.SecretName != "" && internode.PasswordSecretRef == nil {
+		return attemptedTo("use internode encryption secret '%s' without a passwordSecretRef", internode.SecretName)
+	}
+
+	// The secret of the keystores the operator generates holds the private
+	// key of every node, so only the init container that copies the keystore
+	// of its node mounts it
+	if internode := dc.GetInternodeEncryption(); internode != nil && internode.SecretName == "" && dc.Spec.PodTemplateSpec != nil {
+		podSpec := dc.Spec.PodTemplateSpec.Spec
+		for _, container := range append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...) {
+			for _, mount := range container.VolumeMounts {
+				if mount.Name == internodeKeystoresVolumeName && container.Name != internodeKeystoreContainerName {
+					return attemptedTo("mount the internode keystores of all the nodes in container %s", container.Name)
+				}
+			}
+		}
+	}
+
+	if networking := dc.Spec.Networking; networking != nil && networking.Ports != nil {
+		if networking.NodePort != nil {
+			return attemptedTo("use both the ports and the nodePort of spec.networking")
+		}
+		usedPorts := map[int]string{
+			8080: "the management API",
+			9142: "tls-native",
+			7001: "tls-internode",
+		}
+		for _, port := range []struct {
+			name   string
+			number int
+		}{
+			{"native", dc.GetNativePort()},
+			{"storage", dc.GetStoragePort()},
+			{"jmx", dc.GetJmxPort()},
+		} {
+			if other, ok := usedPorts[port.number]; ok {
+				return attemptedTo("use port %d for both %s and %s", port.number, port.name, other)
+			}
+			usedPorts[port.number] = port.name
+		}
+	}
+
+	if dc.IsHostNetworkEnabled() && dc.GetAddressType() == AddressTypeServiceDNS {
+		// Pods of the host network have no DNS name of their own
+		return attemptedTo("broadcast the DNS names of the pods with hostNetwork")
+	}
+
+	if dc.GetLogFormat() == LogFormatJSON && dc.Spec.ServerType == "dse" {
+		return attemptedTo("use the JSON log format with DSE, whose logback.xml the operator does not generate")
+	}
+
+	if dc.Spec.AutoTuneJvm {
+		if dc.Spec.ServerType != "cassandra" {
+			return attemptedTo("enable autoTuneJvm with %s, whose JVM options it does not compute", serverStr)
+		}
+		if dc.Spec.Resources.Limits.Memory().IsZero() && dc.Spec.Resources.Requests.Memory().IsZero() {
+			return attemptedTo("enable autoTuneJvm without the memory of the resources")
+		}
+	}
+
+	if dc.RendersConfigInOperator() {
+		if dc.Spec.ServerType != "cassandra" {
+			return attemptedTo("render the config of %s in the operator, which only renders the one of Cassandra", serverStr)
+		}
+		if dc.UsesConfigSecret() {
+			return attemptedTo("render the config of configSecret in the operator")
+		}
+		if dc.Spec.AutoTuneJvm {
+			return attemptedTo("enable autoTuneJvm with the config rendered in the operator")
+		}
+		if dc.Spec.ConfigRolloutStrategy != nil {
+			// The ConfigMap of the rack is the same for the canary pods and the rest
+			return attemptedTo("use configRolloutStrategy with the config rendered in the operator")
+		}
+		for _, rack := range dc.GetRacks() {
+			rackConfig, err := dc.GetRackConfig(rack.Name)
+			if err != nil {
+				return attemptedTo("set the invalid config of rack %s", rack.Name)
+			}
+			config, err := dc.GetConfigAsJSON(rackConfig)
+			if err != nil {
+				return attemptedTo("render the invalid config, %s", err)
+			}
+			if _, err := serverconfig.RenderConfig(dc.Spec.ServerVersion, rack.Name, config); err != nil {
+				return attemptedTo("render the config in the operator, whose %s", err)
+			}
+
+			// The cassandra containers of all the racks get the JVM options
+			// of the datacenter
+			var c map[string]interface{}
+			_ = json.Unmarshal(rack.Config, &c)
+			for _, bucket := range serverconfig.JvmOptionsBuckets(dc.Spec.ServerVersion) {
+				if _, ok := c[bucket]; ok {
+					return attemptedTo("set %s in the config of rack %s with the config rendered in the operator", bucket, rack.Name)
+				}
+			}
+		}
+	}
+
+	if err := validateLoggingConfig(dc); err != nil {
+		return err
+	}
+
+	if value, ok := dc.Annotations[SetLogLevelAnnotation]; ok {
+		if _, err := parseLogLevels(value); err != nil {
+			return attemptedTo("set the log levels of the %s annotation, whose %s", SetLogLevelAnnotation, err)
+		}
+	}
+
+	if dc.IsSniEnabled() {
+		if dc.GetClientEncryption() == nil {
+			return attemptedTo("route SNI host names without the client encryption of spec.encryption")
+		}
+		if domain := dc.Spec.Networking.SNI.Domain; len(validation.IsDNS1123Subdomain(domain)) > 0 {
+			return attemptedTo("use the invalid SNI domain '%s'", domain)
+		}
+	}
+
+	if vault := dc.Spec.Vault; vault != nil {
+		if vault.SuperuserSecretPath != "" || vault.ConfigSecretPath != "" {
+			if _, err := vaultroles.RoleFor(dc.Namespace, vault.Role); err != nil {
+				return attemptedTo("read secrets of vault, but %s", err)
+			}
+		}
+		if vault.SuperuserSecretPath != "" && dc.Spec.SuperuserSecretName != "" {
+			return attemptedTo("use both superuserSecretName and the superuserSecretPath of vault")
+		}
+		if vault.ConfigSecretPath != "" && dc.Spec.ConfigSecret != "" {
+			return attemptedTo("use both configSecret and the configSecretPath of vault")
+		}
+		if vault.ConfigSecretPath != "" && len(dc.Spec.ConfigSecrets) > 0 {
+			return attemptedTo("use both configSecrets and the configSecretPath of vault")
+		}
+	}
+
+	for _, source := range dc.Spec.ClusterSeeds {
+		if (len(source.Addresses) > 0) == (source.ConfigMapName != "") {
+			return attemptedTo("add cluster seeds without exactly one of addresses and configMapName")
+		}
+	}
+
+	if importSecret := dc.GetFederationImportSecret(); importSecret != "" {
+		if dc.Spec.SuperuserSecretName != "" || dc.GetVaultSuperuserSecretPath() != "" {
+			return attemptedTo("use both the importSecret of spec.federation and another superuser secret")
+		}
+	}
+
+	if dc.IsReaperEnabled() {
+		if !dc.IsRemoteJmxEnabled() {
+			return attemptedTo("deploy Reaper without remote JMX in spec.jmx")
+		}
+		if dc.GetVaultSuperuserSecretPath() != "" {
+			return attemptedTo("deploy Reaper with the superuser secret of vault, which Reaper cannot read")
+		}
+	}
+
+	if dc.Spec.BackupAgent != nil && dc.GetVaultSuperuserSecretPath() != "" {
+		return attemptedTo("inject the backup agent with the superuser secret of vault, which the agent cannot read")
 	}
 
 	// if using multiple nodes per worker, requests and limits should be set for both cpu and memory
@@ -83,6 +388,130 @@ func ValidateSingleDatacenter(dc CassandraDatacenter) error {
 		}
 	}
 
+	if dc.Spec.MultipleNodesPerWorker != nil {
+		if !dc.Spec.AllowMultipleNodesPerWorker {
+			return attemptedTo("isolate the pods sharing workers without allowMultipleNodesPerWorker")
+		}
+		// The management API and the internode traffic of every node of the
+		// cluster listen on the same ports, which pods sharing the network of
+		// a worker cannot both bind
+		if dc.IsHostNetworkEnabled() {
+			return attemptedTo("isolate the pods sharing workers with hostNetwork, which is not supported: the nodes have no ports of their own and would all bind the same ones")
+		}
+		// A container without cpu or memory leaves the pods Burstable
+		if dc.Spec.MultipleNodesPerWorker.DedicatedCpus {
+			for _, container := range append(append([]corev1.Container{}, dc.Spec.InitContainers...), dc.Spec.Sidecars...) {
+				resources := container.Resources
+				for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+					request, limit := resources.Requests[name], resources.Limits[name]
+					if request.IsZero() && limit.IsZero() {
+						return attemptedTo("dedicate cpus to the pods with container %s, which has no %s request or limit", container.Name, name)
+					}
+				}
+			}
+		}
+	}
+
+	for _, constraint := range dc.Spec.TopologySpreadConstraints {
+		if constraint.LabelSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(constraint.LabelSelector); err != nil {
+				return attemptedTo("spread the pods with an invalid labelSelector: %v", err)
+			}
+		}
+		if dc.Spec.AllowMultipleNodesPerWorker {
+			continue
+		}
+		// The required anti-affinity already keeps one pod in each domain
+		for _, rack := range dc.GetRacks() {
+			podAntiAffinity := dc.Spec.PodAntiAffinity
+			if rack.PodAntiAffinity != nil {
+				podAntiAffinity = rack.PodAntiAffinity
+			}
+			topologyKey := DefaultPodAntiAffinityTopologyKey
+			if podAntiAffinity != nil {
+				if podAntiAffinity.Preferred {
+					continue
+				}
+				topologyKey = podAntiAffinity.GetTopologyKey()
+			}
+			if constraint.TopologyKey == topologyKey {
+				return attemptedTo("spread the pods over %s, which the podAntiAffinity of rack %s already keeps one pod in without allowMultipleNodesPerWorker", topologyKey, rack.Name)
+			}
+		}
+	}
+
+	if restart := dc.Spec.RollingRestart; restart != nil {
+		rackNames := make(map[string]bool)
+		for _, rack := range dc.GetRacks() {
+			rackNames[rack.Name] = true
+		}
+		for _, rackName := range restart.Racks {
+			if !rackNames[rackName] {
+				return attemptedTo("restart unknown rack '%s'", rackName)
+			}
+		}
+
+		if restart.PodSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(restart.PodSelector); err != nil {
+				return attemptedTo("restart pods with an invalid podSelector: %v", err)
+			}
+		}
+	}
+
+	for _, key := range dc.GetAdditionalSeedDatacenterKeys() {
+		if key.Name == "" {
+			return attemptedTo("use an additional seed datacenter without a name")
+		}
+		if key.Name == dc.Name && key.Namespace == dc.Namespace {
+			return attemptedTo("use the datacenter itself as an additional seed datacenter")
+		}
+	}
+
+	if migration := dc.Spec.MigrateFrom; migration != nil {
+		if migration.Datacenter == "" {
+			return attemptedTo("migrate from a datacenter without naming it")
+		}
+		if migration.Datacenter == dc.Name {
+			return attemptedTo("migrate the datacenter from itself")
+		}
+	}
+
+	if budget := dc.Spec.PodDisruptionBudget; budget != nil && budget.MaxUnavailable != nil {
+		maxUnavailable, err := intstr.GetValueFromIntOrPercent(budget.MaxUnavailable, int(dc.Spec.Size), true)
+		if err != nil || maxUnavailable < 0 {
+			return attemptedTo("use invalid podDisruptionBudget maxUnavailable '%s'", budget.MaxUnavailable.String())
+		}
+	}
+
+	repairNames := make(map[string]bool)
+	for _, repair := range dc.Spec.Repairs {
+		if repairNames[repair.Name] {
+			return attemptedTo("define repair schedule '%s' more than once", repair.Name)
+		}
+		repairNames[repair.Name] = true
+
+		if _, err := cron.ParseStandard(repair.Schedule); err != nil {
+			return attemptedTo("use invalid schedule '%s' for repair '%s'", repair.Schedule, repair.Name)
+		}
+	}
+
+	if window := dc.Spec.MaintenanceWindow; window != nil {
+		if _, err := cron.ParseStandard(window.Schedule); err != nil {
+			return attemptedTo("use invalid schedule '%s' for the maintenance window", window.Schedule)
+		}
+		if window.Duration.Duration <= 0 {
+			return attemptedTo("use a maintenance window that is never open")
+		}
+	}
+
+	if drain := dc.Spec.Drain; drain != nil && drain.TimeoutSeconds != nil && dc.Spec.PodTemplateSpec != nil {
+		// The grace period of the podTemplateSpec is not extended for the drain
+		gracePeriod := dc.Spec.PodTemplateSpec.Spec.TerminationGracePeriodSeconds
+		if gracePeriod != nil && int64(*drain.TimeoutSeconds) >= *gracePeriod {
+			return attemptedTo("drain the nodes for longer than the terminationGracePeriodSeconds of podTemplateSpec")
+		}
+	}
+
 	return nil
 }
 
@@ -106,11 +535,47 @@ func ValidateDatacenterFieldChanges(oldDc CassandraDatacenter, newDc CassandraDa
 		return attemptedTo("change serviceAccount")
 	}
 
-	// StorageConfig changes are disallowed
-	if !reflect.DeepEqual(oldDc.Spec.StorageConfig, newDc.Spec.StorageConfig) {
+	// The nodes restarted without keystores could not talk to the ones that
+	// still require encryption, so the mode is lowered to None first, which
+	// goes through Optional on the servers that support it
+	if oldDc.GetInternodeEncryption() != nil && newDc.GetInternodeEncryption() == nil {
+		if mode := oldDc.Status.InternodeEncryptionMode; mode != "" && mode != InternodeEncryptionNone {
+			return attemptedTo("remove spec.encryption.internode while the nodes run with the %s mode, set the mode to None first", mode)
+		}
+	}
+
+	// The nodes of a rolling restart could not reach the ones that have not
+	// restarted yet on another storage port, and the clients and the other
+	// datacenters only know the native port they were set up with
+	if oldDc.GetStoragePort() != newDc.GetStoragePort() {
+		return attemptedTo("change the storage port of spec.networking.ports")
+	}
+	if oldDc.GetNativePort() != newDc.GetNativePort() {
+		return attemptedTo("change the native port of spec.networking.ports")
+	}
+
+	// The webhook cannot read the schema, so it relies on the highest
+	// replication factor the operator last read from it
+	if rf := oldDc.Status.MaxReplicationFactor; rf != nil &&
+		newDc.Spec.Size < oldDc.Spec.Size && newDc.Spec.Size < rf.ReplicationFactor {
+		return attemptedTo("scale down to %d nodes, below the replication factor %d of keyspace %s",
+			newDc.Spec.Size, rf.ReplicationFactor, rf.Keyspace)
+	}
+
+	if err := validateStorageChanges(oldDc.Spec.StorageConfig, newDc.Spec.StorageConfig, oldDc.Status.VolumeClaimRequests); err != nil {
+		return err
+	}
+
+	// StorageConfig changes are disallowed, except for larger storage requests
+	// which expand the volumes
+	if !reflect.DeepEqual(withStorageRequestsOf(oldDc.Spec.StorageConfig, newDc.Spec.StorageConfig), newDc.Spec.StorageConfig) {
 		return attemptedTo("change storageConfig")
 	}
 
+	if err := validateCDCVolumeChanges(oldDc.Spec.CDC, newDc.Spec.CDC, oldDc.Status.VolumeClaimRequests); err != nil {
+		return err
+	}
+
 	// Topology changes - Racks
 	// - Rack Name and Zone changes are disallowed.
 	// - Removing racks is not supported.
@@ -161,11 +626,322 @@ func ValidateDatacenterFieldChanges(oldDc CassandraDatacenter, newDc CassandraDa
 	return nil
 }
 
+// validateStorageChanges rejects the storage changes that would destroy or
+// strand the data of existing nodes with a message naming the change. The
+// persistent volume claims of a StatefulSet can only be expanded, so the
+// reconciliation of such a change would never complete. A storage request can
+// still be shrunk back to the one of the StatefulSets in claimRequests, which
+// undoes an expansion the storage class refused.
+func validateStorageChanges(oldStorage StorageConfig, newStorage StorageConfig, claimRequests map[string]resource.Quantity) error {
+	if oldStorage.CassandraDataVolumeClaimSpec != nil && newStorage.CassandraDataVolumeClaimSpec != nil {
+		if err := validateClaimChanges("cassandraDataVolumeClaimSpec", *oldStorage.CassandraDataVolumeClaimSpec, *newStorage.CassandraDataVolumeClaimSpec, claimRequests["server-data"]); err != nil {
+			return err
+		}
+	}
+
+	if oldStorage.CommitLogVolumeClaimSpec != nil && newStorage.CommitLogVolumeClaimSpec != nil {
+		if err := validateClaimChanges("commitLogVolumeClaimSpec", *oldStorage.CommitLogVolumeClaimSpec, *newStorage.CommitLogVolumeClaimSpec, claimRequests["server-commitlog"]); err != nil {
+			return err
+		}
+	}
+
+	if err := validateVolumeChanges("additional volume", oldStorage.AdditionalVolumes, newStorage.AdditionalVolumes, claimRequests); err != nil {
+		return err
+	}
+
+	return validateVolumeChanges("additional data volume", oldStorage.AdditionalDataVolumes, newStorage.AdditionalDataVolumes, claimRequests)
+}
+
+// validateCDCVolumeChanges rejects the changes to the CDC volume that
+// storageConfig rejects for the other volumes, since it also has a volume
+// claim template
+func validateCDCVolumeChanges(oldCDC *CDCConfig, newCDC *CDCConfig, claimRequests map[string]resource.Quantity) error {
+	var oldClaim, newClaim *corev1.PersistentVolumeClaimSpec
+	if oldCDC != nil {
+		oldClaim = oldCDC.VolumeClaimSpec
+	}
+	if newCDC != nil {
+		newClaim = newCDC.VolumeClaimSpec
+	}
+
+	if oldClaim == nil && newClaim == nil {
+		return nil
+	}
+	if oldClaim == nil || newClaim == nil {
+		return attemptedTo("add or remove cdc.volumeClaimSpec")
+	}
+
+	if err := validateClaimChanges("cdc.volumeClaimSpec", *oldClaim, *newClaim, claimRequests["server-cdc"]); err != nil {
+		return err
+	}
+
+	claim := oldClaim.DeepCopy()
+	setStorageRequest(claim, *newClaim)
+	if !reflect.DeepEqual(*claim, *newClaim) {
+		return attemptedTo("change cdc.volumeClaimSpec")
+	}
+	return nil
+}
+
+func validateVolumeChanges(kind string, oldVolumes AdditionalVolumesSlice, newVolumes AdditionalVolumesSlice, claimRequests map[string]resource.Quantity) error {
+	for _, oldVolume := range oldVolumes {
+		for _, newVolume := range newVolumes {
+			if oldVolume.Name != newVolume.Name {
+				continue
+			}
+			if err := validateClaimChanges(fmt.Sprintf("%s '%s'", kind, oldVolume.Name), oldVolume.PVCSpec, newVolume.PVCSpec, claimRequests[oldVolume.Name]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateDataVolumes rejects additional volumes that take the name of a
+// volume of the operator, and additional data volumes whose claims or data
+// directories would collide with the other volumes of the pods
+func validateDataVolumes(storage StorageConfig) error {
+	names := map[string]bool{}
+	for name := range operatorVolumeNames {
+		names[name] = true
+	}
+	for _, volume := range storage.AdditionalVolumes {
+		if operatorVolumeNames[volume.Name] {
+			return attemptedTo("use volume name '%s' for an additional volume, which is reserved for a volume of the operator", volume.Name)
+		}
+		names[volume.Name] = true
+	}
+
+	mountPaths := map[string]bool{}
+	for _, volume := range storage.AdditionalDataVolumes {
+		if names[volume.Name] {
+			return attemptedTo("use duplicate volume name '%s' for an additional data volume", volume.Name)
+		}
+		names[volume.Name] = true
+
+		if !path.IsAbs(volume.MountPath) {
+			return attemptedTo("use relative mount path '%s' for additional data volume '%s'", volume.MountPath, volume.Name)
+		}
+		mountPath := path.Clean(volume.MountPath)
+		if mountPath == "/var/lib/cassandra" || mountPath == DefaultDataFileDirectory || mountPath == CommitLogDirectory || mountPath == CDCDirectory ||
+			strings.HasPrefix(mountPath, DefaultDataFileDirectory+"/") || mountPaths[mountPath] {
+			return attemptedTo("mount additional data volume '%s' at '%s', which overlaps another data directory", volume.Name, volume.MountPath)
+		}
+		mountPaths[mountPath] = true
+	}
+
+	return nil
+}
+
+// validateSidecars rejects sidecars and init containers that the pods could
+// not run. Their names must be unique across both lists, since the init
+// containers and the containers of a pod share a namespace of names. Only the
+// ones that are merged into a container of the operator or of the
+// podTemplateSpec can leave out the image.
+func validateSidecars(spec CassandraDatacenterSpec) error {
+	var templateContainers, templateInitContainers []corev1.Container
+	if spec.PodTemplateSpec != nil {
+		templateContainers = spec.PodTemplateSpec.Spec.Containers
+		templateInitContainers = spec.PodTemplateSpec.Spec.InitContainers
+	}
+
+	names := map[string]bool{}
+	for _, containers := range []struct {
+		kind       string
+		containers []corev1.Container
+		merged     map[string]bool
+		reserved   map[string]bool
+		template   []corev1.Container
+	}{
+		{"sidecar", spec.Sidecars, operatorContainerNames, operatorInitContainerNames, templateContainers},
+		{"init container", spec.InitContainers, operatorInitContainerNames, operatorContainerNames, templateInitContainers},
+	} {
+		for _, container := range containers.containers {
+			if len(validation.IsDNS1123Label(container.Name)) > 0 {
+				return attemptedTo("add %s with invalid name '%s', which must be a DNS-1123 label", containers.kind, container.Name)
+			}
+			if names[container.Name] {
+				return attemptedTo("add %s with duplicate name '%s'", containers.kind, container.Name)
+			}
+			names[container.Name] = true
+
+			// Taking the name of a container of the other list would put two
+			// containers of the same name in the pod
+			if containers.reserved[container.Name] {
+				return attemptedTo("add %s with name '%s', which is reserved for a container of the operator", containers.kind, container.Name)
+			}
+
+			if container.Image == "" && !containers.merged[container.Name] && !hasContainer(containers.template, container.Name) {
+				return attemptedTo("add %s '%s' without an image, which is only left out to change a container of the operator or of the podTemplateSpec", containers.kind, container.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasContainer(containers []corev1.Container, name string) bool {
+	for _, container := range containers {
+		if container.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// withStorageRequestsOf returns a copy of the storage config with the storage
+// requests of the volumes that are also in the other storage config
+func withStorageRequestsOf(storage StorageConfig, other StorageConfig) StorageConfig {
+	storage = *storage.DeepCopy()
+
+	if storage.CassandraDataVolumeClaimSpec != nil && other.CassandraDataVolumeClaimSpec != nil {
+		setStorageRequest(storage.CassandraDataVolumeClaimSpec, *other.CassandraDataVolumeClaimSpec)
+	}
+
+	if storage.CommitLogVolumeClaimSpec != nil && other.CommitLogVolumeClaimSpec != nil {
+		setStorageRequest(storage.CommitLogVolumeClaimSpec, *other.CommitLogVolumeClaimSpec)
+	}
+
+	setStorageRequestsOf(storage.AdditionalVolumes, other.AdditionalVolumes)
+	setStorageRequestsOf(storage.AdditionalDataVolumes, other.AdditionalDataVolumes)
+
+	return storage
+}
+
+func setStorageRequestsOf(volumes AdditionalVolumesSlice, others AdditionalVolumesSlice) {
+	for i := range volumes {
+		for _, otherVolume := range others {
+			if volumes[i].Name == otherVolume.Name {
+				setStorageRequest(&volumes[i].PVCSpec, otherVolume.PVCSpec)
+			}
+		}
+	}
+}
+
+func setStorageRequest(claim *corev1.PersistentVolumeClaimSpec, other corev1.PersistentVolumeClaimSpec) {
+	request, ok := other.Resources.Requests[corev1.ResourceStorage]
+	if !ok {
+		return
+	}
+	if claim.Resources.Requests == nil {
+		claim.Resources.Requests = corev1.ResourceList{}
+	}
+	claim.Resources.Requests[corev1.ResourceStorage] = request
+}
+
+// validateAdditionalMetadata rejects additional labels and annotations that
+// are not valid, or that would be overridden by the ones of the operator
+func validateAdditionalMetadata(spec CassandraDatacenterSpec) error {
+	for _, metadata := range []struct {
+		kind   string
+		values map[string]string
+	}{
+		{"label", spec.AdditionalLabels},
+		{"annotation", spec.AdditionalAnnotations},
+	} {
+		for key, value := range metadata.values {
+			if strings.HasPrefix(key, "cassandra.datastax.com/") || key == oplabels.ManagedByLabel {
+				return attemptedTo("add additional %s '%s', which is reserved for the operator", metadata.kind, key)
+			}
+			if len(validation.IsQualifiedName(key)) > 0 {
+				return attemptedTo("add additional %s with invalid name '%s'", metadata.kind, key)
+			}
+			if metadata.kind == "label" && len(validation.IsValidLabelValue(value)) > 0 {
+				return attemptedTo("add additional label '%s' with invalid value '%s'", key, value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// loggingLevels are the levels of the loggers of logback
+var loggingLevels = []string{"ALL", "TRACE", "DEBUG", "INFO", "WARN", "ERROR", "OFF"}
+
+// loggerNameRegexp matches the names of the loggers of Cassandra and of its
+// libraries, which are java class or package names
+var loggerNameRegexp = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// validateLoggingConfig rejects the logging levels of spec.loggingConfig that
+// logback does not know, and the loggers it has no valid name for
+func validateLoggingConfig(dc *CassandraDatacenter) error {
+	config := dc.Spec.LoggingConfig
+	if config == nil {
+		return nil
+	}
+	if dc.Spec.ServerType == "dse" {
+		return attemptedTo("set the logging levels of loggingConfig with DSE, whose logback.xml the operator does not generate")
+	}
+
+	for name, level := range config.Loggers {
+		if name == RootLoggerName || !loggerNameRegexp.MatchString(name) {
+			return attemptedTo("set the level of logger '%s' in loggingConfig, which is not a valid logger name", name)
+		}
+		if !isLoggingLevel(level) {
+			return attemptedTo("set logger '%s' to level '%s', which is not one of %s", name, level, strings.Join(loggingLevels, ", "))
+		}
+	}
+
+	return nil
+}
+
+func isLoggingLevel(level string) bool {
+	for _, l := range loggingLevels {
+		if level == l {
+			return true
+		}
+	}
+	return false
+}
+
+func validateClaimChanges(volume string, oldClaim corev1.PersistentVolumeClaimSpec, newClaim corev1.PersistentVolumeClaimSpec, statefulSetRequest resource.Quantity) error {
+	oldClassName := ""
+	if oldClaim.StorageClassName != nil {
+		oldClassName = *oldClaim.StorageClassName
+	}
+	newClassName := ""
+	if newClaim.StorageClassName != nil {
+		newClassName = *newClaim.StorageClassName
+	}
+	if oldClassName != newClassName {
+		return attemptedTo("change storageClassName of %s from '%s' to '%s'",
+			volume,
+			oldClassName,
+			newClassName)
+	}
+
+	oldRequest := oldClaim.Resources.Requests[corev1.ResourceStorage]
+	newRequest := newClaim.Resources.Requests[corev1.ResourceStorage]
+	if newRequest.Cmp(oldRequest) < 0 && (statefulSetRequest.IsZero() || newRequest.Cmp(statefulSetRequest) != 0) {
+		return attemptedTo("shrink storage request of %s from %s to %s",
+			volume,
+			oldRequest.String(),
+			newRequest.String())
+	}
+
+	return nil
+}
+
 // +kubebuilder:webhook:path=/validate-cassandradatacenter,mutating=false,failurePolicy=ignore,groups=cassandra.datastax.com,resources=cassandradatacenters,verbs=create;update,versions=v1beta1,name=validate-cassandradatacenter-webhook
 var _ webhook.Validator = &CassandraDatacenter{}
 
+// validateSize checks that the datacenter has at least one node. The CRD
+// cannot require size, as v1 objects set nodesPerRack instead, and the
+// minimum of the schema does not apply to a size that was left out.
+func validateSize(dc CassandraDatacenter) error {
+	if dc.Spec.Size < 1 {
+		return attemptedTo("set size to %d, a datacenter needs at least one node, use stopped to scale it down to none", dc.Spec.Size)
+	}
+	return nil
+}
+
 func (dc *CassandraDatacenter) ValidateCreate() error {
 	log.Info("Validating webhook called for create")
+	if err := validateSize(*dc); err != nil {
+		return err
+	}
+
 	err := ValidateSingleDatacenter(*dc)
 	if err != nil {
 		return err
@@ -181,6 +957,10 @@ func (dc *CassandraDatacenter) ValidateUpdate(old runtime.Object) error {
 		return errors.New("old object in ValidateUpdate cannot be cast to CassandraDatacenter")
 	}
 
+	if err := validateSize(*dc); err != nil {
+		return err
+	}
+
 	err := ValidateSingleDatacenter(*dc)
 	if err != nil {
 		return err
@@ -192,3 +972,121 @@ func (dc *CassandraDatacenter) ValidateUpdate(old runtime.Object) error {
 func (dc *CassandraDatacenter) ValidateDelete() error {
 	return nil
 }
+
+// +kubebuilder:webhook:path=/mutate-cassandra-datastax-com-v1beta1-cassandradatacenter,mutating=true,failurePolicy=ignore,groups=cassandra.datastax.com,resources=cassandradatacenters,verbs=create,versions=v1beta1,name=mutate-cassandradatacenter-webhook
+var _ webhook.Defaulter = &CassandraDatacenter{}
+
+// Default fills in the settings of a new CassandraDatacenter that would
+// otherwise be copied into every datacenter. Settings that are set are left
+// alone. The webhook is only called on create, as changing num_tokens or the
+// heap of existing nodes is not a default the user asked for.
+func (dc *CassandraDatacenter) Default() {
+	log.Info("Defaulting webhook called for create")
+
+	if dc.Spec.PodDisruptionBudget == nil {
+		maxUnavailable := intstr.FromInt(1)
+		dc.Spec.PodDisruptionBudget = &PodDisruptionBudgetConfig{MaxUnavailable: &maxUnavailable}
+	}
+
+	// ConfigSecret takes precedence over Config
+	if dc.UsesConfigSecret() {
+		return
+	}
+
+	config, err := defaultConfig(*dc)
+	if err != nil {
+		// Invalid config is rejected by the validating webhook and reconciliation
+		log.Error(err, "Failed to default the config of the CassandraDatacenter")
+		return
+	}
+	dc.Spec.Config = config
+}
+
+// defaultConfig returns the config of the datacenter with num_tokens and the
+// heap size set, unless the config already sets them
+func defaultConfig(dc CassandraDatacenter) (json.RawMessage, error) {
+	config := gabs.New()
+	if len(dc.Spec.Config) > 0 {
+		parsed, err := gabs.ParseJSON(dc.Spec.Config)
+		if err != nil {
+			return nil, err
+		}
+		config = parsed
+	}
+
+	changed := false
+
+	if numTokens := defaultNumTokens(dc); numTokens > 0 && !config.Exists("cassandra-yaml", "num_tokens") {
+		if _, err := config.Set(numTokens, "cassandra-yaml", "num_tokens"); err != nil {
+			return nil, err
+		}
+		changed = true
+	}
+
+	jvmOptions := "jvm-server-options"
+	if dc.Spec.ServerType == "cassandra" && strings.HasPrefix(dc.Spec.ServerVersion, "3.") {
+		jvmOptions = "jvm-options"
+	}
+	if heapSize := defaultHeapSize(dc); heapSize != "" && !dc.Spec.AutoTuneJvm &&
+		!config.Exists(jvmOptions, "initial_heap_size") && !config.Exists(jvmOptions, "max_heap_size") {
+		for _, option := range []string{"initial_heap_size", "max_heap_size"} {
+			if _, err := config.Set(heapSize, jvmOptions, option); err != nil {
+				return nil, err
+			}
+		}
+		changed = true
+	}
+
+	if !changed {
+		return dc.Spec.Config, nil
+	}
+	return config.Bytes(), nil
+}
+
+// defaultNumTokens returns the number of tokens of the nodes of a new
+// datacenter, or 0 to leave it to the server. Cassandra 4.0 allocates tokens
+// well enough to use far fewer than the 256 of Cassandra 3.11.
+func defaultNumTokens(dc CassandraDatacenter) int {
+	if dc.Spec.ServerType != "cassandra" {
+		return 0
+	}
+	if serverconfig.IsCassandraAtLeast(dc.Spec.ServerVersion, 4, 0) {
+		return 16
+	}
+	if strings.HasPrefix(dc.Spec.ServerVersion, "3.") {
+		return 256
+	}
+	return 0
+}
+
+// defaultHeapSize returns the heap size for the memory of the Cassandra
+// container, as cassandra-env.sh calculates it: the larger of half the memory
+// up to 1G and a quarter of the memory up to 8G. It returns "" if the memory
+// of the container is not set.
+func defaultHeapSize(dc CassandraDatacenter) string {
+	memory := dc.Spec.Resources.Limits.Memory()
+	if memory.IsZero() {
+		memory = dc.Spec.Resources.Requests.Memory()
+	}
+	if memory.IsZero() {
+		return ""
+	}
+
+	const mebibyte = int64(1024 * 1024)
+	memoryMiB := memory.Value() / mebibyte
+	heapMiB := minInt64(memoryMiB/2, 1024)
+	if quarter := minInt64(memoryMiB/4, 8192); quarter > heapMiB {
+		heapMiB = quarter
+	}
+	if heapMiB <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dM", heapMiB)
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -5,16 +5,29 @@ package v1beta1
 
 import (
 	"encoding/json"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	vaultroles "github.com/k8ssandra/cass-operator/operator/pkg/vault"
 )
 
 func Test_ValidateSingleDatacenter(t *testing.T) {
+	os.Setenv(vaultroles.RolesEnv, "*=cass-operator,*=cassandra")
+	defer os.Unsetenv(vaultroles.RolesEnv)
+
+	invalidMaxUnavailable := intstr.FromString("one")
+	drainTimeoutSeconds := int32(600)
+	gracePeriodSeconds := int64(300)
+
 	tests := []struct {
 		name      string
 		dc        *CassandraDatacenter
@@ -233,45 +246,1739 @@ func Test_ValidateSingleDatacenter(t *testing.T) {
 			},
 			errString: "use multiple nodes per worker without cpu and memory requests and limits",
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := ValidateSingleDatacenter(*tt.dc)
-			if err == nil {
-				if tt.errString != "" {
-					t.Errorf("ValidateSingleDatacenter() err = %v, want %v", err, tt.errString)
-				}
-			} else {
-				if !strings.HasSuffix(err.Error(), tt.errString) {
-					t.Errorf("ValidateSingleDatacenter() err = %v, want suffix %v", err, tt.errString)
-				}
-			}
-		})
-	}
-}
-
-func Test_ValidateDatacenterFieldChanges(t *testing.T) {
-	storageSize := resource.MustParse("1Gi")
-	storageName := "server-data"
-
-	tests := []struct {
-		name      string
-		oldDc     *CassandraDatacenter
-		newDc     *CassandraDatacenter
-		errString string
-	}{
 		{
-			name: "No significant changes",
+			name: "Multiple nodes per worker isolation requires multiple nodes per worker",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:             "cassandra",
+					ServerVersion:          "3.11.7",
+					MultipleNodesPerWorker: &MultipleNodesPerWorkerConfig{IsolateRacks: true},
+				},
+			},
+			errString: "isolate the pods sharing workers without allowMultipleNodesPerWorker",
+		},
+		{
+			name: "Multiple nodes per worker isolation with host network",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:                  "cassandra",
+					ServerVersion:               "3.11.7",
+					AllowMultipleNodesPerWorker: true,
+					MultipleNodesPerWorker:      &MultipleNodesPerWorkerConfig{DedicatedCpus: true},
+					Networking:                  &NetworkingConfig{HostNetwork: true},
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1000m"),
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+						},
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1000m"),
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+						},
+					},
+				},
+			},
+			errString: "isolate the pods sharing workers with hostNetwork, which is not supported: the nodes have no ports of their own and would all bind the same ones",
+		},
+		{
+			name: "Multiple nodes per worker dedicated cpus with a sidecar without cpu",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:                  "cassandra",
+					ServerVersion:               "3.11.7",
+					AllowMultipleNodesPerWorker: true,
+					MultipleNodesPerWorker:      &MultipleNodesPerWorkerConfig{DedicatedCpus: true},
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1000m"),
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+						},
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1000m"),
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+						},
+					},
+					Sidecars: []corev1.Container{{
+						Name: "sidecar",
+						Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+						},
+					}},
+				},
+			},
+			errString: "dedicate cpus to the pods with container sidecar, which has no cpu request or limit",
+		},
+		{
+			name: "Repair schedule valid",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					Repairs: []RepairSchedule{
+						{Name: "weekly", Schedule: "0 2 * * 0", Keyspaces: []string{"ks1"}},
+						{Name: "daily", Schedule: "@daily", Type: RepairTypeIncremental, Keyspaces: []string{"ks1"}},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Repair schedule invalid cron",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					Repairs: []RepairSchedule{
+						{Name: "weekly", Schedule: "every sunday", Keyspaces: []string{"ks1"}},
+					},
+				},
+			},
+			errString: "use invalid schedule 'every sunday' for repair 'weekly'",
+		},
+		{
+			name: "Repair schedule duplicate name",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					Repairs: []RepairSchedule{
+						{Name: "weekly", Schedule: "0 2 * * 0", Keyspaces: []string{"ks1"}},
+						{Name: "weekly", Schedule: "0 3 * * 0", Keyspaces: []string{"ks2"}},
+					},
+				},
+			},
+			errString: "define repair schedule 'weekly' more than once",
+		},
+		{
+			name: "Maintenance window valid",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					MaintenanceWindow: &MaintenanceWindow{
+						Schedule: "0 2 * * 6",
+						Duration: metav1.Duration{Duration: 4 * time.Hour},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Maintenance window invalid cron",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					MaintenanceWindow: &MaintenanceWindow{
+						Schedule: "saturday night",
+						Duration: metav1.Duration{Duration: 4 * time.Hour},
+					},
+				},
+			},
+			errString: "use invalid schedule 'saturday night' for the maintenance window",
+		},
+		{
+			name: "Maintenance window without duration",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					MaintenanceWindow: &MaintenanceWindow{
+						Schedule: "0 2 * * 6",
+						Duration: metav1.Duration{Duration: 0},
+					},
+				},
+			},
+			errString: "use a maintenance window that is never open",
+		},
+		{
+			name: "Drain timeout longer than the grace period",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					Drain:         &DrainConfig{TimeoutSeconds: &drainTimeoutSeconds},
+					PodTemplateSpec: &corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{TerminationGracePeriodSeconds: &gracePeriodSeconds},
+					},
+				},
+			},
+			errString: "drain the nodes for longer than the terminationGracePeriodSeconds of podTemplateSpec",
+		},
+		{
+			name: "Migration from the datacenter itself",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					MigrateFrom:   &MigrationConfig{Datacenter: "exampleDC"},
+				},
+			},
+			errString: "migrate the datacenter from itself",
+		},
+		{
+			name: "Topology spread over the zones",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+						{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Topology spread over the workers of the anti-affinity",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+						{MaxSkew: 1, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: corev1.DoNotSchedule},
+					},
+				},
+			},
+			errString: "spread the pods over kubernetes.io/hostname, which the podAntiAffinity of rack default already keeps one pod in without allowMultipleNodesPerWorker",
+		},
+		{
+			name: "Topology spread over the workers of a preferred anti-affinity",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:      "cassandra",
+					ServerVersion:   "3.11.7",
+					PodAntiAffinity: &PodAntiAffinityConfig{Preferred: true},
+					TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+						{MaxSkew: 1, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: corev1.ScheduleAnyway},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Rolling restart of a rack",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					Racks:         []Rack{{Name: "rack1"}, {Name: "rack2"}},
+					RollingRestart: &RollingRestartConfig{
+						Racks:                 []string{"rack2"},
+						MaxUnavailablePerRack: 2,
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Rolling restart of an unknown rack",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					Racks:         []Rack{{Name: "rack1"}},
+					RollingRestart: &RollingRestartConfig{
+						Racks: []string{"rack3"},
+					},
+				},
+			},
+			errString: "restart unknown rack 'rack3'",
+		},
+		{
+			name: "Additional seeds from another datacenter",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "exampleDC",
+					Namespace: "ns1",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					AdditionalSeedDatacenters: []corev1.ObjectReference{
+						{Name: "exampleDC", Namespace: "ns2"},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Additional seeds from the datacenter itself",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "exampleDC",
+					Namespace: "ns1",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					AdditionalSeedDatacenters: []corev1.ObjectReference{
+						{Name: "exampleDC"},
+					},
+				},
+			},
+			errString: "use the datacenter itself as an additional seed datacenter",
+		},
+		{
+			name: "Full query logging with Cassandra 4.0",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:       "cassandra",
+					ServerVersion:    "4.0.0",
+					FullQueryLogging: &QueryLoggingConfig{Enabled: true},
+					AuditLogging:     &QueryLoggingConfig{Enabled: true, LogDir: "/audit"},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Full query logging with Cassandra 3.11",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:       "cassandra",
+					ServerVersion:    "3.11.7",
+					FullQueryLogging: &QueryLoggingConfig{Enabled: true},
+				},
+			},
+			errString: "configure full query logging with cassandra-3.11.7",
+		},
+		{
+			name: "Audit logging with a relative directory",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					AuditLogging:  &QueryLoggingConfig{LogDir: "audit"},
+				},
+			},
+			errString: "use relative log directory 'audit'",
+		},
+		{
+			name: "Invalid podDisruptionBudget maxUnavailable",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					PodDisruptionBudget: &PodDisruptionBudgetConfig{
+						MaxUnavailable: &invalidMaxUnavailable,
+					},
+				},
+			},
+			errString: "use invalid podDisruptionBudget maxUnavailable 'one'",
+		},
+		{
+			name: "Additional data volume named like another volume",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					StorageConfig: StorageConfig{
+						AdditionalDataVolumes: AdditionalVolumesSlice{{
+							Name:      "server-data",
+							MountPath: "/var/lib/cassandra-data1",
+						}},
+					},
+				},
+			},
+			errString: "use duplicate volume name 'server-data' for an additional data volume",
+		},
+		{
+			name: "Additional data volume in the default data directory",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					StorageConfig: StorageConfig{
+						AdditionalDataVolumes: AdditionalVolumesSlice{{
+							Name:      "data1",
+							MountPath: "/var/lib/cassandra/data/data1",
+						}},
+					},
+				},
+			},
+			errString: "mount additional data volume 'data1' at '/var/lib/cassandra/data/data1', which overlaps another data directory",
+		},
+		{
+			name: "Sidecars",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:     "cassandra",
+					ServerVersion:  "4.0.0",
+					Sidecars:       []corev1.Container{{Name: "cassandra"}, {Name: "agent", Image: "agent:1.0"}},
+					InitContainers: []corev1.Container{{Name: "agent-init", Image: "agent:1.0"}},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Sidecar without a name",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Sidecars:      []corev1.Container{{Image: "agent:1.0"}},
+				},
+			},
+			errString: "add sidecar with invalid name '', which must be a DNS-1123 label",
+		},
+		{
+			name: "Init container with the name of a sidecar",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:     "cassandra",
+					ServerVersion:  "4.0.0",
+					Sidecars:       []corev1.Container{{Name: "agent", Image: "agent:1.0"}},
+					InitContainers: []corev1.Container{{Name: "agent", Image: "agent:1.0"}},
+				},
+			},
+			errString: "add init container with duplicate name 'agent'",
+		},
+		{
+			name: "Sidecar without an image",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Sidecars:      []corev1.Container{{Name: "agent"}},
+				},
+			},
+			errString: "add sidecar 'agent' without an image, which is only left out to change a container of the operator or of the podTemplateSpec",
+		},
+		{
+			name: "Init container merged into a container of the podTemplateSpec",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					PodTemplateSpec: &corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							InitContainers: []corev1.Container{{Name: "agent-init", Image: "agent:1.0"}},
+						},
+					},
+					InitContainers: []corev1.Container{{Name: "agent-init", Args: []string{"--verbose"}}, {Name: "server-config-init", Args: []string{"--verbose"}}},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Init container without an image named after a container of the podTemplateSpec",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					PodTemplateSpec: &corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "agent", Image: "agent:1.0"}},
+						},
+					},
+					InitContainers: []corev1.Container{{Name: "agent"}},
+				},
+			},
+			errString: "add init container 'agent' without an image, which is only left out to change a container of the operator or of the podTemplateSpec",
+		},
+		{
+			name: "Init container with the name of a container of the operator",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:     "cassandra",
+					ServerVersion:  "4.0.0",
+					InitContainers: []corev1.Container{{Name: "cassandra", Image: "agent:1.0"}},
+				},
+			},
+			errString: "add init container with name 'cassandra', which is reserved for a container of the operator",
+		},
+		{
+			name: "Sidecar with the name of an init container of the operator",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Sidecars:      []corev1.Container{{Name: "server-config-init", Image: "agent:1.0"}},
+				},
+			},
+			errString: "add sidecar with name 'server-config-init', which is reserved for a container of the operator",
+		},
+		{
+			name: "Additional volume with the name of a volume of the operator",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					StorageConfig: StorageConfig{
+						AdditionalVolumes: AdditionalVolumesSlice{{
+							Name:      "server-commitlog",
+							MountPath: "/var/lib/cassandra/commitlog",
+						}},
+					},
+				},
+			},
+			errString: "use volume name 'server-commitlog' for an additional volume, which is reserved for a volume of the operator",
+		},
+		{
+			name: "Additional volume replacing the logs volume",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					StorageConfig: StorageConfig{
+						AdditionalVolumes: AdditionalVolumesSlice{{
+							Name:      "server-logs",
+							MountPath: "/var/log/cassandra",
+						}},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Ports along with nodePort",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Networking: &NetworkingConfig{
+						NodePort: &NodePortConfig{Native: 30042},
+						Ports:    &PortsConfig{Native: 9043},
+					},
+				},
+			},
+			errString: "use both the ports and the nodePort of spec.networking",
+		},
+		{
+			name: "Ports used twice",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Networking: &NetworkingConfig{
+						HostNetwork: true,
+						Ports:       &PortsConfig{Storage: 9042},
+					},
+				},
+			},
+			errString: "use port 9042 for both storage and native",
+		},
+		{
+			name: "Port of the TLS internode traffic",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Networking: &NetworkingConfig{
+						Ports: &PortsConfig{Jmx: 7001},
+					},
+				},
+			},
+			errString: "use port 7001 for both jmx and tls-internode",
+		},
+		{
+			name: "Cluster seeds with both addresses and a ConfigMap",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					ClusterSeeds: []ClusterSeedsSource{
+						{Addresses: []string{"192.168.0.1"}, ConfigMapName: "cluster1-dc2-seeds"},
+					},
+				},
+			},
+			errString: "add cluster seeds without exactly one of addresses and configMapName",
+		},
+		{
+			name: "Federation secret with a superuser secret",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:          "cassandra",
+					ServerVersion:       "4.0.0",
+					SuperuserSecretName: "superuser",
+					Federation:          &FederationConfig{ImportSecret: "cluster1-dc1-federation"},
+				},
+			},
+			errString: "use both the importSecret of spec.federation and another superuser secret",
+		},
+		{
+			name: "Additional labels",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:       "cassandra",
+					ServerVersion:    "4.0.0",
+					AdditionalLabels: map[string]string{"example.com/team": "data"},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Reserved additional label",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:       "cassandra",
+					ServerVersion:    "4.0.0",
+					AdditionalLabels: map[string]string{"cassandra.datastax.com/datacenter": "dc2"},
+				},
+			},
+			errString: "add additional label 'cassandra.datastax.com/datacenter', which is reserved for the operator",
+		},
+		{
+			name: "Invalid additional label value",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:       "cassandra",
+					ServerVersion:    "4.0.0",
+					AdditionalLabels: map[string]string{"example.com/team": "data team"},
+				},
+			},
+			errString: "add additional label 'example.com/team' with invalid value 'data team'",
+		},
+		{
+			name: "Invalid additional annotation",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:            "cassandra",
+					ServerVersion:         "4.0.0",
+					AdditionalAnnotations: map[string]string{"example.com/cost center": "42"},
+				},
+			},
+			errString: "add additional annotation with invalid name 'example.com/cost center'",
+		},
+		{
+			name: "Internal load balancer for the dc service",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					AdditionalServiceConfig: ServiceConfig{
+						DatacenterService: ServiceConfigAdditions{
+							Annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "true"},
+							Type:        corev1.ServiceTypeLoadBalancer,
+						},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Load balancer for the seed service",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					AdditionalServiceConfig: ServiceConfig{
+						SeedService: ServiceConfigAdditions{Type: corev1.ServiceTypeLoadBalancer},
+					},
+				},
+			},
+			errString: "change the type of the seedService of additionalServiceConfig, which must stay headless",
+		},
+		{
+			name: "JSON logs",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					LogFormat:     LogFormatJSON,
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "JSON logs with DSE",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.4",
+					LogFormat:     LogFormatJSON,
+				},
+			},
+			errString: "use the JSON log format with DSE, whose logback.xml the operator does not generate",
+		},
+		{
+			name: "Logging levels",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					LoggingConfig: &LoggingConfig{
+						RootLevel: "WARN",
+						Loggers: map[string]string{
+							"org.apache.cassandra.gms":   "TRACE",
+							"com.datastax.mgmtapi$Inner": "OFF",
+						},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Logging levels with DSE",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.4",
+					LoggingConfig: &LoggingConfig{
+						Loggers: map[string]string{"com.datastax.bdp": "DEBUG"},
+					},
+				},
+			},
+			errString: "set the logging levels of loggingConfig with DSE, whose logback.xml the operator does not generate",
+		},
+		{
+			name: "Unknown logging level",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					LoggingConfig: &LoggingConfig{
+						Loggers: map[string]string{"org.apache.cassandra.gms": "VERBOSE"},
+					},
+				},
+			},
+			errString: "set logger 'org.apache.cassandra.gms' to level 'VERBOSE', which is not one of ALL, TRACE, DEBUG, INFO, WARN, ERROR, OFF",
+		},
+		{
+			name: "Invalid logger name",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					LoggingConfig: &LoggingConfig{
+						Loggers: map[string]string{"org.apache cassandra>": "DEBUG"},
+					},
+				},
+			},
+			errString: "set the level of logger 'org.apache cassandra>' in loggingConfig, which is not a valid logger name",
+		},
+		{
+			name: "Root logger in loggers",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					LoggingConfig: &LoggingConfig{
+						Loggers: map[string]string{"ROOT": "DEBUG"},
+					},
+				},
+			},
+			errString: "set the level of logger 'ROOT' in loggingConfig, which is not a valid logger name",
+		},
+		{
+			name: "Set log level annotation",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "exampleDC",
+					Annotations: map[string]string{SetLogLevelAnnotation: "org.apache.cassandra.db=debug, ROOT=WARN"},
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Set log level annotation without level",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "exampleDC",
+					Annotations: map[string]string{SetLogLevelAnnotation: "org.apache.cassandra.db"},
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+				},
+			},
+			errString: "set the log levels of the cassandra.datastax.com/set-log-level annotation, whose entry 'org.apache.cassandra.db' is not logger=LEVEL",
+		},
+		{
+			name: "Set log level annotation with unknown level",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "exampleDC",
+					Annotations: map[string]string{SetLogLevelAnnotation: "org.apache.cassandra.db=LOUD"},
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+				},
+			},
+			errString: "set the log levels of the cassandra.datastax.com/set-log-level annotation, whose 'LOUD' is not one of ALL, TRACE, DEBUG, INFO, WARN, ERROR, OFF",
+		},
+		{
+			name: "Auto-tuned JVM",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					AutoTuneJvm:   true,
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("16Gi")},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Auto-tuned JVM with DSE",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "dse",
+					ServerVersion: "6.8.4",
+					AutoTuneJvm:   true,
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("16Gi")},
+					},
+				},
+			},
+			errString: "enable autoTuneJvm with dse-6.8.4, whose JVM options it does not compute",
+		},
+		{
+			name: "Auto-tuned JVM without memory",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "3.11.7",
+					AutoTuneJvm:   true,
+				},
+			},
+			errString: "enable autoTuneJvm without the memory of the resources",
+		},
+		{
+			name: "Cassandra 5.0 with JDK 17 options",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:         "cassandra",
+					ServerVersion:      "5.0.0",
+					ConfigBuilderImage: "example/cass-config-builder:1.0.5",
+					Config:             json.RawMessage(`{"jvm17-server-options":{"garbage_collector":"G1GC"},"cassandra-yaml":{"read_request_timeout":"10s"}}`),
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Cassandra 4.1 with JDK 17 options",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:         "cassandra",
+					ServerVersion:      "4.1.0",
+					ConfigBuilderImage: "example/cass-config-builder:1.0.5",
+					Config:             json.RawMessage(`{"jvm17-server-options":{"garbage_collector":"G1GC"}}`),
+				},
+			},
+			errString: "define config jvm17-server-options with cassandra-4.1.0, which reads jvm-server-options, jvm8-server-options, jvm11-server-options",
+		},
+		{
+			name: "Cassandra 4.1 with the default config builder",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.1.0",
+				},
+			},
+			errString: "use Cassandra version '4.1.0', whose configuration the default config builder image cannot render, without a configBuilderImage or the operator configRenderer",
+		},
+		{
+			name: "Cassandra 5.0 rendered by the operator",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:     "cassandra",
+					ServerVersion:  "5.0.0",
+					ConfigRenderer: ConfigRendererOperator,
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Cassandra 4.0 with the options of Cassandra 4.1",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Config:        json.RawMessage(`{"cassandra-yaml":{"write_request_timeout":"2s"}}`),
+				},
+			},
+			errString: "set write_request_timeout in cassandra-yaml with cassandra-4.0.0, which only reads write_request_timeout_in_ms",
+		},
+		{
+			name: "ServiceDNS address type with host network",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Networking: &NetworkingConfig{
+						HostNetwork: true,
+						AddressType: AddressTypeServiceDNS,
+					},
+				},
+			},
+			errString: "broadcast the DNS names of the pods with hostNetwork",
+		},
+		{
+			name: "SNI without client encryption",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Networking: &NetworkingConfig{
+						SNI: &SNIConfig{Domain: "cassandra.example.com"},
+					},
+				},
+			},
+			errString: "route SNI host names without the client encryption of spec.encryption",
+		},
+		{
+			name: "SNI with an invalid domain",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Networking: &NetworkingConfig{
+						SNI: &SNIConfig{Domain: "Cassandra_example"},
+					},
+					Encryption: &EncryptionConfig{
+						Client: &ClientEncryptionConfig{
+							CertManagerIssuerRef: &CertManagerIssuerRef{Name: "ca-issuer"},
+						},
+					},
+				},
+			},
+			errString: "use the invalid SNI domain 'Cassandra_example'",
+		},
+		{
+			name: "SNI",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Networking: &NetworkingConfig{
+						SNI: &SNIConfig{Domain: "cassandra.example.com"},
+					},
+					Encryption: &EncryptionConfig{
+						Client: &ClientEncryptionConfig{
+							CertManagerIssuerRef: &CertManagerIssuerRef{Name: "ca-issuer"},
+						},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Ports with host network",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Networking: &NetworkingConfig{
+						HostNetwork: true,
+						Ports:       &PortsConfig{Native: 9043, Storage: 7010, Jmx: 7299},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Vault secret without a role",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Vault: &VaultConfig{
+						SuperuserSecretPath: "secret/data/cassandra/superuser",
+					},
+				},
+			},
+			errString: "several roles of vault are allowed for namespace , pick one of cass-operator, cassandra",
+		},
+		{
+			name: "Vault secret with a role of another namespace",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "exampleDC",
+					Namespace: "team-a",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Vault: &VaultConfig{
+						Role:                "cassandra-team-b",
+						SuperuserSecretPath: "secret/data/cassandra/superuser",
+					},
+				},
+			},
+			errString: "role cassandra-team-b of vault is not allowed for namespace team-a by the VAULT_ROLES environment variable of the operator",
+		},
+		{
+			name: "Vault config secret along with configSecret",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					ConfigSecret:  "config",
+					Vault: &VaultConfig{
+						Role:             "cass-operator",
+						ConfigSecretPath: "secret/data/cassandra/config",
+					},
+				},
+			},
+			errString: "use both configSecret and the configSecretPath of vault",
+		},
+		{
+			name: "Vault config secret along with configSecrets",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					ConfigSecrets: []string{"config-overrides"},
+					Vault: &VaultConfig{
+						Role:             "cass-operator",
+						ConfigSecretPath: "secret/data/cassandra/config",
+					},
+				},
+			},
+			errString: "use both configSecrets and the configSecretPath of vault",
+		},
+		{
+			name: "Vault secrets",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Vault: &VaultConfig{
+						Role:                "cass-operator",
+						SuperuserSecretPath: "secret/data/cassandra/superuser",
+						ConfigSecretPath:    "secret/data/cassandra/config",
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Reaper without remote JMX",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Reaper:        &ReaperConfig{Enabled: true},
+				},
+			},
+			errString: "deploy Reaper without remote JMX in spec.jmx",
+		},
+		{
+			name: "Reaper with the superuser secret of Vault",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Reaper:        &ReaperConfig{Enabled: true},
+					Jmx:           &JmxConfig{CredentialsSecret: "jmx-credentials"},
+					Vault: &VaultConfig{
+						Role:                "cass-operator",
+						SuperuserSecretPath: "secret/data/cassandra/superuser",
+					},
+				},
+			},
+			errString: "deploy Reaper with the superuser secret of vault, which Reaper cannot read",
+		},
+		{
+			name: "Backup agent with the superuser secret of Vault",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					BackupAgent:   &BackupAgentConfig{ConfigSecret: "medusa"},
+					Vault: &VaultConfig{
+						Role:                "cass-operator",
+						SuperuserSecretPath: "secret/data/cassandra/superuser",
+					},
+				},
+			},
+			errString: "inject the backup agent with the superuser secret of vault, which the agent cannot read",
+		},
+		{
+			name: "Reaper",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Reaper:        &ReaperConfig{Enabled: true},
+					Jmx:           &JmxConfig{CredentialsSecret: "jmx-credentials"},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Config rendered in the operator",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:     "cassandra",
+					ServerVersion:  "4.0.0",
+					ConfigRenderer: ConfigRendererOperator,
+					Config:         json.RawMessage(`{"cassandra-yaml":{"num_tokens":8},"jvm-server-options":{"max_heap_size":"4G"}}`),
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Config rendered in the operator with DSE",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:     "dse",
+					ServerVersion:  "6.8.4",
+					ConfigRenderer: ConfigRendererOperator,
+				},
+			},
+			errString: "render the config of dse-6.8.4 in the operator, which only renders the one of Cassandra",
+		},
+		{
+			name: "Config rendered in the operator with unsupported options",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:     "cassandra",
+					ServerVersion:  "4.0.0",
+					ConfigRenderer: ConfigRendererOperator,
+					Config:         json.RawMessage(`{"jvm-server-options":{"garbage_collector":"G1GC"}}`),
+				},
+			},
+			errString: "render the config in the operator, whose option garbage_collector of jvm-server-options is not supported",
+		},
+		{
+			name: "Config of a rack",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Config:        json.RawMessage(`{"cassandra-yaml":{"num_tokens":16}}`),
+					Racks: []Rack{
+						{Name: "rack1", Config: json.RawMessage(`{"jvm-server-options":{"garbage_collector":"G1GC"}}`)},
+					},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Config of a rack for another version",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Racks: []Rack{
+						{Name: "rack1", Config: json.RawMessage(`{"jvm-options":{"max_heap_size":"4G"}}`)},
+					},
+				},
+			},
+			errString: "define config jvm-options with cassandra-4.0.0",
+		},
+		{
+			name: "Config of a rack with configSecret",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					ConfigSecret:  "config",
+					Racks: []Rack{
+						{Name: "rack1", Config: json.RawMessage(`{"cassandra-yaml":{"num_tokens":16}}`)},
+					},
+				},
+			},
+			errString: "set the config of rack rack1 along with configSecret",
+		},
+		{
+			name: "JVM options of a rack with the config rendered in the operator",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:     "cassandra",
+					ServerVersion:  "4.0.0",
+					ConfigRenderer: ConfigRendererOperator,
+					Racks: []Rack{
+						{Name: "rack1", Config: json.RawMessage(`{"jvm-server-options":{"max_heap_size":"4G"}}`)},
+					},
+				},
+			},
+			errString: "set jvm-server-options in the config of rack rack1 with the config rendered in the operator",
+		},
+		{
+			name: "Internode keystores of all the nodes mounted in the server container",
+			dc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    "cassandra",
+					ServerVersion: "4.0.0",
+					Encryption: &EncryptionConfig{
+						Internode: &InternodeEncryptionConfig{Mode: InternodeEncryptionRequired},
+					},
+					PodTemplateSpec: &corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{
+								Name:         "cassandra",
+								VolumeMounts: []corev1.VolumeMount{{Name: "internode-keystores", MountPath: "/keystores"}},
+							}},
+						},
+					},
+				},
+			},
+			errString: "mount the internode keystores of all the nodes in container cassandra",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSingleDatacenter(*tt.dc)
+			if err == nil {
+				if tt.errString != "" {
+					t.Errorf("ValidateSingleDatacenter() err = %v, want %v", err, tt.errString)
+				}
+			} else {
+				if !strings.HasSuffix(err.Error(), tt.errString) {
+					t.Errorf("ValidateSingleDatacenter() err = %v, want suffix %v", err, tt.errString)
+				}
+			}
+		})
+	}
+}
+
+func Test_ValidateDatacenterFieldChanges(t *testing.T) {
+	storageSize := resource.MustParse("1Gi")
+	storageName := "server-data"
+	newStorageName := "other-data"
+	smallerStorageSize := resource.MustParse("512Mi")
+	largerStorageSize := resource.MustParse("2Gi")
+
+	tests := []struct {
+		name      string
+		oldDc     *CassandraDatacenter
+		newDc     *CassandraDatacenter
+		errString string
+	}{
+		{
+			name: "No significant changes",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName:                 "oldname",
+					AllowMultipleNodesPerWorker: false,
+					SuperuserSecretName:         "hush",
+					ServiceAccount:              "admin",
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
+					Racks: []Rack{{
+						Name: "rack0",
+						Zone: "zone0",
+					}, {
+						Name: "rack1",
+						Zone: "zone1",
+					}, {
+						Name: "rack2",
+						Zone: "zone2",
+					}},
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName:                 "oldname",
+					AllowMultipleNodesPerWorker: false,
+					SuperuserSecretName:         "hush",
+					ServiceAccount:              "admin",
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
+					Racks: []Rack{{
+						Name: "rack0",
+						Zone: "zone0",
+					}, {
+						Name: "rack1",
+						Zone: "zone1",
+					}, {
+						Name: "rack2",
+						Zone: "zone2",
+					}},
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Clustername changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "oldname",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ClusterName: "newname",
+				},
+			},
+			errString: "change clusterName",
+		},
+		{
+			name: "AllowMultipleNodesPerWorker changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					AllowMultipleNodesPerWorker: false,
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					AllowMultipleNodesPerWorker: true,
+				},
+			},
+			errString: "change allowMultipleNodesPerWorker",
+		},
+		{
+			name: "SuperuserSecretName changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					SuperuserSecretName: "hush",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					SuperuserSecretName: "newsecret",
+				},
+			},
+			errString: "change superuserSecretName",
+		},
+		{
+			name: "ServiceAccount changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServiceAccount: "admin",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServiceAccount: "newadmin",
+				},
+			},
+			errString: "change serviceAccount",
+		},
+		{
+			name: "Storage port changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					Networking: &NetworkingConfig{Ports: &PortsConfig{Storage: 7010}},
+				},
+			},
+			errString: "change the storage port of spec.networking.ports",
+		},
+		{
+			name: "Native port changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					Networking: &NetworkingConfig{Ports: &PortsConfig{Native: 9043}},
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					Networking: &NetworkingConfig{Ports: &PortsConfig{Native: 9044, Jmx: 7299}},
+				},
+			},
+			errString: "change the native port of spec.networking.ports",
+		},
+		{
+			name: "StorageConfig changes",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteMany"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
+				},
+			},
+			errString: "change storageConfig",
+		},
+		{
+			name: "StorageClassName changed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &newStorageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
+				},
+			},
+			errString: "change storageClassName of cassandraDataVolumeClaimSpec from 'server-data' to 'other-data'",
+		},
+		{
+			name: "Storage request shrunk",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": smallerStorageSize},
+							},
+						},
+					},
+				},
+			},
+			errString: "shrink storage request of cassandraDataVolumeClaimSpec from 1Gi to 512Mi",
+		},
+		{
+			name: "Storage request shrunk back to the one of the statefulsets",
 			oldDc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ClusterName:                 "oldname",
-					AllowMultipleNodesPerWorker: false,
-					SuperuserSecretName:         "hush",
-					ServiceAccount:              "admin",
 					StorageConfig: StorageConfig{
 						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
 							StorageClassName: &storageName,
@@ -281,16 +1988,9 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 							},
 						},
 					},
-					Racks: []Rack{{
-						Name: "rack0",
-						Zone: "zone0",
-					}, {
-						Name: "rack1",
-						Zone: "zone1",
-					}, {
-						Name: "rack2",
-						Zone: "zone2",
-					}},
+				},
+				Status: CassandraDatacenterStatus{
+					VolumeClaimRequests: map[string]resource.Quantity{"server-data": smallerStorageSize},
 				},
 			},
 			newDc: &CassandraDatacenter{
@@ -298,41 +1998,35 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ClusterName:                 "oldname",
-					AllowMultipleNodesPerWorker: false,
-					SuperuserSecretName:         "hush",
-					ServiceAccount:              "admin",
 					StorageConfig: StorageConfig{
 						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
 							StorageClassName: &storageName,
 							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
 							Resources: corev1.ResourceRequirements{
-								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": smallerStorageSize},
 							},
 						},
 					},
-					Racks: []Rack{{
-						Name: "rack0",
-						Zone: "zone0",
-					}, {
-						Name: "rack1",
-						Zone: "zone1",
-					}, {
-						Name: "rack2",
-						Zone: "zone2",
-					}},
 				},
 			},
 			errString: "",
 		},
 		{
-			name: "Clustername changed",
+			name: "Storage request increased",
 			oldDc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ClusterName: "oldname",
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
 				},
 			},
 			newDc: &CassandraDatacenter{
@@ -340,19 +2034,38 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ClusterName: "newname",
+					StorageConfig: StorageConfig{
+						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": largerStorageSize},
+							},
+						},
+					},
 				},
 			},
-			errString: "change clusterName",
+			errString: "",
 		},
 		{
-			name: "AllowMultipleNodesPerWorker changed",
+			name: "Additional volume storage request shrunk",
 			oldDc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					AllowMultipleNodesPerWorker: false,
+					StorageConfig: StorageConfig{
+						AdditionalVolumes: AdditionalVolumesSlice{{
+							Name:      "logs",
+							MountPath: "/var/log/cassandra",
+							PVCSpec: corev1.PersistentVolumeClaimSpec{
+								StorageClassName: &storageName,
+								Resources: corev1.ResourceRequirements{
+									Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+								},
+							},
+						}},
+					},
 				},
 			},
 			newDc: &CassandraDatacenter{
@@ -360,19 +2073,38 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					AllowMultipleNodesPerWorker: true,
+					StorageConfig: StorageConfig{
+						AdditionalVolumes: AdditionalVolumesSlice{{
+							Name:      "logs",
+							MountPath: "/var/log/cassandra",
+							PVCSpec: corev1.PersistentVolumeClaimSpec{
+								StorageClassName: &storageName,
+								Resources: corev1.ResourceRequirements{
+									Requests: map[corev1.ResourceName]resource.Quantity{"storage": smallerStorageSize},
+								},
+							},
+						}},
+					},
 				},
 			},
-			errString: "change allowMultipleNodesPerWorker",
+			errString: "shrink storage request of additional volume 'logs' from 1Gi to 512Mi",
 		},
 		{
-			name: "SuperuserSecretName changed",
+			name: "Additional data volume storage class changed",
 			oldDc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					SuperuserSecretName: "hush",
+					StorageConfig: StorageConfig{
+						AdditionalDataVolumes: AdditionalVolumesSlice{{
+							Name:      "data1",
+							MountPath: "/var/lib/cassandra-data1",
+							PVCSpec: corev1.PersistentVolumeClaimSpec{
+								StorageClassName: &storageName,
+							},
+						}},
+					},
 				},
 			},
 			newDc: &CassandraDatacenter{
@@ -380,19 +2112,34 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					SuperuserSecretName: "newsecret",
+					StorageConfig: StorageConfig{
+						AdditionalDataVolumes: AdditionalVolumesSlice{{
+							Name:      "data1",
+							MountPath: "/var/lib/cassandra-data1",
+							PVCSpec: corev1.PersistentVolumeClaimSpec{
+								StorageClassName: &newStorageName,
+							},
+						}},
+					},
 				},
 			},
-			errString: "change superuserSecretName",
+			errString: "change storageClassName of additional data volume 'data1' from 'server-data' to 'other-data'",
 		},
 		{
-			name: "ServiceAccount changed",
+			name: "Commit log storage request shrunk",
 			oldDc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ServiceAccount: "admin",
+					StorageConfig: StorageConfig{
+						CommitLogVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+							},
+						},
+					},
 				},
 			},
 			newDc: &CassandraDatacenter{
@@ -400,22 +2147,50 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					ServiceAccount: "newadmin",
+					StorageConfig: StorageConfig{
+						CommitLogVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+							Resources: corev1.ResourceRequirements{
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": smallerStorageSize},
+							},
+						},
+					},
 				},
 			},
-			errString: "change serviceAccount",
+			errString: "shrink storage request of commitLogVolumeClaimSpec from 1Gi to 512Mi",
 		},
 		{
-			name: "StorageConfig changes",
+			name: "Commit log volume added",
 			oldDc: &CassandraDatacenter{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "exampleDC",
 				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
 				Spec: CassandraDatacenterSpec{
 					StorageConfig: StorageConfig{
-						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+						CommitLogVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+						},
+					},
+				},
+			},
+			errString: "change storageConfig",
+		},
+		{
+			name: "CDC volume shrunk",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					CDC: &CDCConfig{
+						Enabled: true,
+						VolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
 							StorageClassName: &storageName,
-							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"},
 							Resources: corev1.ResourceRequirements{
 								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
 							},
@@ -428,18 +2203,40 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 					Name: "exampleDC",
 				},
 				Spec: CassandraDatacenterSpec{
-					StorageConfig: StorageConfig{
-						CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+					CDC: &CDCConfig{
+						Enabled: false,
+						VolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
 							StorageClassName: &storageName,
-							AccessModes:      []corev1.PersistentVolumeAccessMode{"ReadWriteMany"},
 							Resources: corev1.ResourceRequirements{
-								Requests: map[corev1.ResourceName]resource.Quantity{"storage": storageSize},
+								Requests: map[corev1.ResourceName]resource.Quantity{"storage": smallerStorageSize},
 							},
 						},
 					},
 				},
 			},
-			errString: "change storageConfig",
+			errString: "shrink storage request of cdc.volumeClaimSpec from 1Gi to 512Mi",
+		},
+		{
+			name: "CDC volume removed",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					CDC: &CDCConfig{
+						Enabled: true,
+						VolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &storageName,
+						},
+					},
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+			},
+			errString: "add or remove cdc.volumeClaimSpec",
 		},
 		{
 			name: "Removing a rack",
@@ -741,6 +2538,96 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 			},
 			errString: "add racks without increasing size enough to prevent existing nodes from moving to new racks to maintain balance.\nNew racks added: 2, size increased by: 7. Expected size increase to be at least 8",
 		},
+		{
+			name: "Scale down below the replication factor",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					Size: 4,
+				},
+				Status: CassandraDatacenterStatus{
+					MaxReplicationFactor: &KeyspaceReplicationFactor{Keyspace: "ks", ReplicationFactor: 3},
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					Size: 2,
+				},
+			},
+			errString: "scale down to 2 nodes, below the replication factor 3 of keyspace ks",
+		},
+		{
+			name: "Scale down to the replication factor",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					Size: 4,
+				},
+				Status: CassandraDatacenterStatus{
+					MaxReplicationFactor: &KeyspaceReplicationFactor{Keyspace: "ks", ReplicationFactor: 3},
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					Size: 3,
+				},
+			},
+			errString: "",
+		},
+		{
+			name: "Remove the internode encryption while it is required",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					Encryption: &EncryptionConfig{
+						Internode: &InternodeEncryptionConfig{Mode: InternodeEncryptionRequired},
+					},
+				},
+				Status: CassandraDatacenterStatus{
+					InternodeEncryptionMode: InternodeEncryptionRequired,
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+			},
+			errString: "remove spec.encryption.internode while the nodes run with the Required mode, set the mode to None first",
+		},
+		{
+			name: "Remove the internode encryption once the nodes run without it",
+			oldDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					Encryption: &EncryptionConfig{
+						Internode: &InternodeEncryptionConfig{Mode: InternodeEncryptionNone},
+					},
+				},
+				Status: CassandraDatacenterStatus{
+					InternodeEncryptionMode: InternodeEncryptionNone,
+				},
+			},
+			newDc: &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+			},
+			errString: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -758,3 +2645,114 @@ func Test_ValidateDatacenterFieldChanges(t *testing.T) {
 		})
 	}
 }
+
+func Test_ValidateSize(t *testing.T) {
+	dc := &CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "exampleDC",
+		},
+		Spec: CassandraDatacenterSpec{
+			ServerType:    "dse",
+			ServerVersion: "6.8.0",
+			Size:          3,
+		},
+	}
+	if err := dc.ValidateCreate(); err != nil {
+		t.Errorf("ValidateCreate() err = %v, want nil", err)
+	}
+
+	scaledToNone := dc.DeepCopy()
+	scaledToNone.Spec.Size = 0
+	if err := scaledToNone.ValidateUpdate(dc); err == nil || !strings.Contains(err.Error(), "set size to 0") {
+		t.Errorf("ValidateUpdate() err = %v, want the size rejected", err)
+	}
+
+	noSize := dc.DeepCopy()
+	noSize.Spec.Size = 0
+	if err := noSize.ValidateCreate(); err == nil || !strings.Contains(err.Error(), "set size to 0") {
+		t.Errorf("ValidateCreate() err = %v, want the size rejected", err)
+	}
+}
+
+func Test_Default(t *testing.T) {
+	tests := []struct {
+		name       string
+		serverType string
+		version    string
+		memory     string
+		config     string
+		want       string
+	}{
+		{
+			name:       "Cassandra 4.0",
+			serverType: "cassandra",
+			version:    "4.0.0",
+			memory:     "16Gi",
+			want:       `{"cassandra-yaml":{"num_tokens":16},"jvm-server-options":{"initial_heap_size":"4096M","max_heap_size":"4096M"}}`,
+		},
+		{
+			name:       "Cassandra 3.11 with little memory",
+			serverType: "cassandra",
+			version:    "3.11.7",
+			memory:     "2Gi",
+			want:       `{"cassandra-yaml":{"num_tokens":256},"jvm-options":{"initial_heap_size":"1024M","max_heap_size":"1024M"}}`,
+		},
+		{
+			name:       "DSE with a lot of memory",
+			serverType: "dse",
+			version:    "6.8.4",
+			memory:     "64Gi",
+			want:       `{"jvm-server-options":{"initial_heap_size":"8192M","max_heap_size":"8192M"}}`,
+		},
+		{
+			name:       "Settings in the config are kept",
+			serverType: "cassandra",
+			version:    "4.0.0",
+			memory:     "16Gi",
+			config:     `{"cassandra-yaml":{"num_tokens":8},"jvm-server-options":{"max_heap_size":"2G"}}`,
+			want:       `{"cassandra-yaml":{"num_tokens":8},"jvm-server-options":{"max_heap_size":"2G"}}`,
+		},
+		{
+			name:       "No resources",
+			serverType: "dse",
+			version:    "6.8.4",
+			config:     `{"dse-yaml":{"authorization_options":{"enabled":true}}}`,
+			want:       `{"dse-yaml":{"authorization_options":{"enabled":true}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &CassandraDatacenter{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "exampleDC",
+				},
+				Spec: CassandraDatacenterSpec{
+					ServerType:    tt.serverType,
+					ServerVersion: tt.version,
+				},
+			}
+			if tt.memory != "" {
+				dc.Spec.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(tt.memory)}
+			}
+			if tt.config != "" {
+				dc.Spec.Config = json.RawMessage(tt.config)
+			}
+
+			dc.Default()
+
+			var got, want map[string]interface{}
+			if err := json.Unmarshal(dc.Spec.Config, &got); err != nil {
+				t.Fatalf("Default() set invalid config %s: %v", dc.Spec.Config, err)
+			}
+			_ = json.Unmarshal([]byte(tt.want), &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Default() config = %s, want %s", dc.Spec.Config, tt.want)
+			}
+
+			if dc.Spec.PodDisruptionBudget == nil || dc.Spec.PodDisruptionBudget.MaxUnavailable == nil ||
+				dc.Spec.PodDisruptionBudget.MaxUnavailable.IntValue() != 1 {
+				t.Errorf("Default() podDisruptionBudget = %v, want maxUnavailable 1", dc.Spec.PodDisruptionBudget)
+			}
+		})
+	}
+}
@@ -8,7 +8,10 @@ import (
 	json "encoding/json"
 
 	v1 "k8s.io/api/core/v1"
+	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -50,6 +53,251 @@ func (in AdditionalVolumesSlice) DeepCopy() AdditionalVolumesSlice {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutomaticNodeReplacementConfig) DeepCopyInto(out *AutomaticNodeReplacementConfig) {
+	*out = *in
+	if in.GracePeriodSeconds != nil {
+		in, out := &in.GracePeriodSeconds, &out.GracePeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutomaticNodeReplacementConfig.
+func (in *AutomaticNodeReplacementConfig) DeepCopy() *AutomaticNodeReplacementConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AutomaticNodeReplacementConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupAgentConfig) DeepCopyInto(out *BackupAgentConfig) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupAgentConfig.
+func (in *BackupAgentConfig) DeepCopy() *BackupAgentConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupAgentConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupPodStatus) DeepCopyInto(out *BackupPodStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupPodStatus.
+func (in *BackupPodStatus) DeepCopy() *BackupPodStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupPodStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupSidecarConfig) DeepCopyInto(out *BackupSidecarConfig) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSidecarConfig.
+func (in *BackupSidecarConfig) DeepCopy() *BackupSidecarConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSidecarConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStorageLocation) DeepCopyInto(out *BackupStorageLocation) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageLocation.
+func (in *BackupStorageLocation) DeepCopy() *BackupStorageLocation {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStorageLocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CDCConfig) DeepCopyInto(out *CDCConfig) {
+	*out = *in
+	if in.VolumeClaimSpec != nil {
+		in, out := &in.VolumeClaimSpec, &out.VolumeClaimSpec
+		*out = new(v1.PersistentVolumeClaimSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Forwarder != nil {
+		in, out := &in.Forwarder, &out.Forwarder
+		*out = new(CDCForwarderConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CDCConfig.
+func (in *CDCConfig) DeepCopy() *CDCConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CDCConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CDCForwarderConfig) DeepCopyInto(out *CDCForwarderConfig) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CDCForwarderConfig.
+func (in *CDCForwarderConfig) DeepCopy() *CDCForwarderConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CDCForwarderConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraBackup) DeepCopyInto(out *CassandraBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraBackup.
+func (in *CassandraBackup) DeepCopy() *CassandraBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CassandraBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraBackupList) DeepCopyInto(out *CassandraBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CassandraBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraBackupList.
+func (in *CassandraBackupList) DeepCopy() *CassandraBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CassandraBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraBackupSpec) DeepCopyInto(out *CassandraBackupSpec) {
+	*out = *in
+	out.Datacenter = in.Datacenter
+	out.Storage = in.Storage
+	if in.Keyspaces != nil {
+		in, out := &in.Keyspaces, &out.Keyspaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraBackupSpec.
+func (in *CassandraBackupSpec) DeepCopy() *CassandraBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraBackupStatus) DeepCopyInto(out *CassandraBackupStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make(map[string]BackupPodStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraBackupStatus.
+func (in *CassandraBackupStatus) DeepCopy() *CassandraBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CassandraDatacenter) DeepCopyInto(out *CassandraDatacenter) {
 	*out = *in
@@ -114,6 +362,11 @@ func (in *CassandraDatacenterList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 	*out = *in
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
 	if in.DockerImageRunsAsCassandra != nil {
 		in, out := &in.DockerImageRunsAsCassandra, &out.DockerImageRunsAsCassandra
 		*out = new(bool)
@@ -124,6 +377,11 @@ func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 		*out = make(json.RawMessage, len(*in))
 		copy(*out, *in)
 	}
+	if in.ConfigSecrets != nil {
+		in, out := &in.ConfigSecrets, &out.ConfigSecrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	in.ManagementApiAuth.DeepCopyInto(&out.ManagementApiAuth)
 	if in.NodeAffinityLabels != nil {
 		in, out := &in.NodeAffinityLabels, &out.NodeAffinityLabels
@@ -142,12 +400,94 @@ func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ZoneRacks != nil {
+		in, out := &in.ZoneRacks, &out.ZoneRacks
+		*out = new(ZoneRacksConfig)
+		**out = **in
+	}
 	in.StorageConfig.DeepCopyInto(&out.StorageConfig)
 	if in.ReplaceNodes != nil {
 		in, out := &in.ReplaceNodes, &out.ReplaceNodes
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Hibernate != nil {
+		in, out := &in.Hibernate, &out.Hibernate
+		*out = new(HibernateConfig)
+		**out = **in
+	}
+	if in.MigrateFrom != nil {
+		in, out := &in.MigrateFrom, &out.MigrateFrom
+		*out = new(MigrationConfig)
+		**out = **in
+	}
+	if in.PersistentVolumeClaimRetentionPolicy != nil {
+		in, out := &in.PersistentVolumeClaimRetentionPolicy, &out.PersistentVolumeClaimRetentionPolicy
+		*out = new(PersistentVolumeClaimRetentionPolicy)
+		**out = **in
+	}
+	if in.AutomaticNodeReplacement != nil {
+		in, out := &in.AutomaticNodeReplacement, &out.AutomaticNodeReplacement
+		*out = new(AutomaticNodeReplacementConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Remediation != nil {
+		in, out := &in.Remediation, &out.Remediation
+		*out = new(RemediationConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeMaintenancePolicy != nil {
+		in, out := &in.NodeMaintenancePolicy, &out.NodeMaintenancePolicy
+		*out = new(NodeMaintenancePolicy)
+		**out = **in
+	}
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(DrainConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(ProbesConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeSSTables != nil {
+		in, out := &in.UpgradeSSTables, &out.UpgradeSSTables
+		*out = new(UpgradeSSTablesConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigRolloutStrategy != nil {
+		in, out := &in.ConfigRolloutStrategy, &out.ConfigRolloutStrategy
+		*out = new(ConfigRolloutStrategy)
+		**out = **in
+	}
+	if in.MultipleNodesPerWorker != nil {
+		in, out := &in.MultipleNodesPerWorker, &out.MultipleNodesPerWorker
+		*out = new(MultipleNodesPerWorkerConfig)
+		**out = **in
+	}
+	if in.PodAntiAffinity != nil {
+		in, out := &in.PodAntiAffinity, &out.PodAntiAffinity
+		*out = new(PodAntiAffinityConfig)
+		**out = **in
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RollingRestart != nil {
+		in, out := &in.RollingRestart, &out.RollingRestart
+		*out = new(RollingRestartConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MaintenanceWindow)
+		**out = **in
+	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
@@ -170,6 +510,20 @@ func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 		*out = new(v1.PodTemplateSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Users != nil {
 		in, out := &in.Users, &out.Users
 		*out = make([]CassandraUser, len(*in))
@@ -185,11 +539,47 @@ func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AdditionalSeedDatacenters != nil {
+		in, out := &in.AdditionalSeedDatacenters, &out.AdditionalSeedDatacenters
+		*out = make([]v1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterSeeds != nil {
+		in, out := &in.ClusterSeeds, &out.ClusterSeeds
+		*out = make([]ClusterSeedsSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Federation != nil {
+		in, out := &in.Federation, &out.Federation
+		*out = new(FederationConfig)
+		**out = **in
+	}
 	if in.Reaper != nil {
 		in, out := &in.Reaper, &out.Reaper
 		*out = new(ReaperConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LoggingConfig != nil {
+		in, out := &in.LoggingConfig, &out.LoggingConfig
+		*out = new(LoggingConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalLabels != nil {
+		in, out := &in.AdditionalLabels, &out.AdditionalLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AdditionalAnnotations != nil {
+		in, out := &in.AdditionalAnnotations, &out.AdditionalAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	in.AdditionalServiceConfig.DeepCopyInto(&out.AdditionalServiceConfig)
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
@@ -198,246 +588,1493 @@ func (in *CassandraDatacenterSpec) DeepCopyInto(out *CassandraDatacenterSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.BackupSidecar != nil {
+		in, out := &in.BackupSidecar, &out.BackupSidecar
+		*out = new(BackupSidecarConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BackupAgent != nil {
+		in, out := &in.BackupAgent, &out.BackupAgent
+		*out = new(BackupAgentConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Repairs != nil {
+		in, out := &in.Repairs, &out.Repairs
+		*out = make([]RepairSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Keyspaces != nil {
+		in, out := &in.Keyspaces, &out.Keyspaces
+		*out = make([]KeyspaceReplication, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FullQueryLogging != nil {
+		in, out := &in.FullQueryLogging, &out.FullQueryLogging
+		*out = new(QueryLoggingConfig)
+		**out = **in
+	}
+	if in.AuditLogging != nil {
+		in, out := &in.AuditLogging, &out.AuditLogging
+		*out = new(QueryLoggingConfig)
+		**out = **in
+	}
+	if in.CDC != nil {
+		in, out := &in.CDC, &out.CDC
+		*out = new(CDCConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudgetConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(MonitoringConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MetricsCollector != nil {
+		in, out := &in.MetricsCollector, &out.MetricsCollector
+		*out = new(MetricsCollectorConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(EncryptionConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultConfig)
+		**out = **in
+	}
+	if in.Jmx != nil {
+		in, out := &in.Jmx, &out.Jmx
+		*out = new(JmxConfig)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraDatacenterSpec.
+func (in *CassandraDatacenterSpec) DeepCopy() *CassandraDatacenterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraDatacenterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraDatacenterStatus) DeepCopyInto(out *CassandraDatacenterStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]DatacenterCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.SuperUserUpserted.DeepCopyInto(&out.SuperUserUpserted)
+	in.UsersUpserted.DeepCopyInto(&out.UsersUpserted)
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]CassandraUserStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.SuperuserPasswordRotated.DeepCopyInto(&out.SuperuserPasswordRotated)
+	in.CertificatesChanged.DeepCopyInto(&out.CertificatesChanged)
+	if in.LoggingLevels != nil {
+		in, out := &in.LoggingLevels, &out.LoggingLevels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.LastServerNodeStarted.DeepCopyInto(&out.LastServerNodeStarted)
+	in.LastRollingRestart.DeepCopyInto(&out.LastRollingRestart)
+	if in.RollingRestartScope != nil {
+		in, out := &in.RollingRestartScope, &out.RollingRestartScope
+		*out = new(RollingRestartConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeStatuses != nil {
+		in, out := &in.NodeStatuses, &out.NodeStatuses
+		*out = make(CassandraStatusMap, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeReplacements != nil {
+		in, out := &in.NodeReplacements, &out.NodeReplacements
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.QuietPeriod.DeepCopyInto(&out.QuietPeriod)
+	if in.Repairs != nil {
+		in, out := &in.Repairs, &out.Repairs
+		*out = make([]RepairScheduleStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Decommission != nil {
+		in, out := &in.Decommission, &out.Decommission
+		*out = new(DecommissionProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SSTablesUpgrade != nil {
+		in, out := &in.SSTablesUpgrade, &out.SSTablesUpgrade
+		*out = new(SSTablesUpgradeProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(MigrationProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Remediations != nil {
+		in, out := &in.Remediations, &out.Remediations
+		*out = make([]PodRemediation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.KeyspaceRepairs != nil {
+		in, out := &in.KeyspaceRepairs, &out.KeyspaceRepairs
+		*out = make([]RepairScheduleStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MaxReplicationFactor != nil {
+		in, out := &in.MaxReplicationFactor, &out.MaxReplicationFactor
+		*out = new(KeyspaceReplicationFactor)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeClaimRequests != nil {
+		in, out := &in.VolumeClaimRequests, &out.VolumeClaimRequests
+		*out = make(map[string]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.ZoneRacks != nil {
+		in, out := &in.ZoneRacks, &out.ZoneRacks
+		*out = make([]Rack, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Racks != nil {
+		in, out := &in.Racks, &out.Racks
+		*out = make([]RackStatus, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraDatacenterStatus.
+func (in *CassandraDatacenterStatus) DeepCopy() *CassandraDatacenterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraDatacenterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraNodeStatus) DeepCopyInto(out *CassandraNodeStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraNodeStatus.
+func (in *CassandraNodeStatus) DeepCopy() *CassandraNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraRestore) DeepCopyInto(out *CassandraRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraRestore.
+func (in *CassandraRestore) DeepCopy() *CassandraRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CassandraRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraRestoreList) DeepCopyInto(out *CassandraRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CassandraRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraRestoreList.
+func (in *CassandraRestoreList) DeepCopy() *CassandraRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CassandraRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraRestoreSpec) DeepCopyInto(out *CassandraRestoreSpec) {
+	*out = *in
+	out.Datacenter = in.Datacenter
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraRestoreSpec.
+func (in *CassandraRestoreSpec) DeepCopy() *CassandraRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraRestoreStatus) DeepCopyInto(out *CassandraRestoreStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make(map[string]BackupPodStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraRestoreStatus.
+func (in *CassandraRestoreStatus) DeepCopy() *CassandraRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in CassandraStatusMap) DeepCopyInto(out *CassandraStatusMap) {
+	{
+		in := &in
+		*out = make(CassandraStatusMap, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+		return
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraStatusMap.
+func (in CassandraStatusMap) DeepCopy() CassandraStatusMap {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraStatusMap)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraTask) DeepCopyInto(out *CassandraTask) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraTask.
+func (in *CassandraTask) DeepCopy() *CassandraTask {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraTask)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CassandraTask) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraTaskArgs) DeepCopyInto(out *CassandraTaskArgs) {
+	*out = *in
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraTaskArgs.
+func (in *CassandraTaskArgs) DeepCopy() *CassandraTaskArgs {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraTaskArgs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraTaskList) DeepCopyInto(out *CassandraTaskList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CassandraTask, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraTaskList.
+func (in *CassandraTaskList) DeepCopy() *CassandraTaskList {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraTaskList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CassandraTaskList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraTaskPodStatus) DeepCopyInto(out *CassandraTaskPodStatus) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraTaskPodStatus.
+func (in *CassandraTaskPodStatus) DeepCopy() *CassandraTaskPodStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraTaskPodStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraTaskSpec) DeepCopyInto(out *CassandraTaskSpec) {
+	*out = *in
+	out.Datacenter = in.Datacenter
+	in.Args.DeepCopyInto(&out.Args)
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraTaskSpec.
+func (in *CassandraTaskSpec) DeepCopy() *CassandraTaskSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraTaskSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraTaskStatus) DeepCopyInto(out *CassandraTaskStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make(map[string]CassandraTaskPodStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraTaskStatus.
+func (in *CassandraTaskStatus) DeepCopy() *CassandraTaskStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraTaskStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraUser) DeepCopyInto(out *CassandraUser) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraUser.
+func (in *CassandraUser) DeepCopy() *CassandraUser {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CassandraUserStatus) DeepCopyInto(out *CassandraUserStatus) {
+	*out = *in
+	in.Upserted.DeepCopyInto(&out.Upserted)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraUserStatus.
+func (in *CassandraUserStatus) DeepCopy() *CassandraUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CassandraUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerIssuerRef) DeepCopyInto(out *CertManagerIssuerRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerIssuerRef.
+func (in *CertManagerIssuerRef) DeepCopy() *CertManagerIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientEncryptionConfig) DeepCopyInto(out *ClientEncryptionConfig) {
+	*out = *in
+	if in.CertManagerIssuerRef != nil {
+		in, out := &in.CertManagerIssuerRef, &out.CertManagerIssuerRef
+		*out = new(CertManagerIssuerRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientEncryptionConfig.
+func (in *ClientEncryptionConfig) DeepCopy() *ClientEncryptionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientEncryptionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSeedsSource) DeepCopyInto(out *ClusterSeedsSource) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSeedsSource.
+func (in *ClusterSeedsSource) DeepCopy() *ClusterSeedsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSeedsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigRolloutStrategy) DeepCopyInto(out *ConfigRolloutStrategy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigRolloutStrategy.
+func (in *ConfigRolloutStrategy) DeepCopy() *ConfigRolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigRolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatacenterCondition) DeepCopyInto(out *DatacenterCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatacenterCondition.
+func (in *DatacenterCondition) DeepCopy() *DatacenterCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(DatacenterCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DecommissionProgress) DeepCopyInto(out *DecommissionProgress) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DecommissionProgress.
+func (in *DecommissionProgress) DeepCopy() *DecommissionProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(DecommissionProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrainConfig) DeepCopyInto(out *DrainConfig) {
+	*out = *in
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrainConfig.
+func (in *DrainConfig) DeepCopy() *DrainConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DrainConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DseWorkloads) DeepCopyInto(out *DseWorkloads) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DseWorkloads.
+func (in *DseWorkloads) DeepCopy() *DseWorkloads {
+	if in == nil {
+		return nil
+	}
+	out := new(DseWorkloads)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EncryptionConfig) DeepCopyInto(out *EncryptionConfig) {
+	*out = *in
+	if in.Internode != nil {
+		in, out := &in.Internode, &out.Internode
+		*out = new(InternodeEncryptionConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Client != nil {
+		in, out := &in.Client, &out.Client
+		*out = new(ClientEncryptionConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptionConfig.
+func (in *EncryptionConfig) DeepCopy() *EncryptionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EncryptionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationConfig) DeepCopyInto(out *FederationConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationConfig.
+func (in *FederationConfig) DeepCopy() *FederationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HibernateConfig) DeepCopyInto(out *HibernateConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HibernateConfig.
+func (in *HibernateConfig) DeepCopy() *HibernateConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(HibernateConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InternodeEncryptionConfig) DeepCopyInto(out *InternodeEncryptionConfig) {
+	*out = *in
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InternodeEncryptionConfig.
+func (in *InternodeEncryptionConfig) DeepCopy() *InternodeEncryptionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(InternodeEncryptionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JmxConfig) DeepCopyInto(out *JmxConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JmxConfig.
+func (in *JmxConfig) DeepCopy() *JmxConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(JmxConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyspaceReplication) DeepCopyInto(out *KeyspaceReplication) {
+	*out = *in
+	if in.Replication != nil {
+		in, out := &in.Replication, &out.Replication
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyspaceReplication.
+func (in *KeyspaceReplication) DeepCopy() *KeyspaceReplication {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyspaceReplication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyspaceReplicationFactor) DeepCopyInto(out *KeyspaceReplicationFactor) {
+	*out = *in
+	in.LastCheckTime.DeepCopyInto(&out.LastCheckTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyspaceReplicationFactor.
+func (in *KeyspaceReplicationFactor) DeepCopy() *KeyspaceReplicationFactor {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyspaceReplicationFactor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoggingConfig) DeepCopyInto(out *LoggingConfig) {
+	*out = *in
+	if in.Loggers != nil {
+		in, out := &in.Loggers, &out.Loggers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggingConfig.
+func (in *LoggingConfig) DeepCopy() *LoggingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(LoggingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagementApiAuthConfig) DeepCopyInto(out *ManagementApiAuthConfig) {
+	*out = *in
+	if in.Insecure != nil {
+		in, out := &in.Insecure, &out.Insecure
+		*out = new(ManagementApiAuthInsecureConfig)
+		**out = **in
+	}
+	if in.Manual != nil {
+		in, out := &in.Manual, &out.Manual
+		*out = new(ManagementApiAuthManualConfig)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementApiAuthConfig.
+func (in *ManagementApiAuthConfig) DeepCopy() *ManagementApiAuthConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagementApiAuthConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagementApiAuthInsecureConfig) DeepCopyInto(out *ManagementApiAuthInsecureConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementApiAuthInsecureConfig.
+func (in *ManagementApiAuthInsecureConfig) DeepCopy() *ManagementApiAuthInsecureConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagementApiAuthInsecureConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagementApiAuthManualConfig) DeepCopyInto(out *ManagementApiAuthManualConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementApiAuthManualConfig.
+func (in *ManagementApiAuthManualConfig) DeepCopy() *ManagementApiAuthManualConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagementApiAuthManualConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsCollectorConfig) DeepCopyInto(out *MetricsCollectorConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsCollectorConfig.
+func (in *MetricsCollectorConfig) DeepCopy() *MetricsCollectorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsCollectorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationConfig) DeepCopyInto(out *MigrationConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationConfig.
+func (in *MigrationConfig) DeepCopy() *MigrationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationProgress) DeepCopyInto(out *MigrationProgress) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PendingPods != nil {
+		in, out := &in.PendingPods, &out.PendingPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedPods != nil {
+		in, out := &in.FailedPods, &out.FailedPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationProgress.
+func (in *MigrationProgress) DeepCopy() *MigrationProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringConfig) DeepCopyInto(out *MonitoringConfig) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Relabelings != nil {
+		in, out := &in.Relabelings, &out.Relabelings
+		*out = make([]RelabelConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringConfig.
+func (in *MonitoringConfig) DeepCopy() *MonitoringConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultipleNodesPerWorkerConfig) DeepCopyInto(out *MultipleNodesPerWorkerConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultipleNodesPerWorkerConfig.
+func (in *MultipleNodesPerWorkerConfig) DeepCopy() *MultipleNodesPerWorkerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MultipleNodesPerWorkerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkingConfig) DeepCopyInto(out *NetworkingConfig) {
+	*out = *in
+	if in.NodePort != nil {
+		in, out := &in.NodePort, &out.NodePort
+		*out = new(NodePortConfig)
+		**out = **in
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = new(PortsConfig)
+		**out = **in
+	}
+	if in.SNI != nil {
+		in, out := &in.SNI, &out.SNI
+		*out = new(SNIConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkingConfig.
+func (in *NetworkingConfig) DeepCopy() *NetworkingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMaintenancePolicy) DeepCopyInto(out *NodeMaintenancePolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMaintenancePolicy.
+func (in *NodeMaintenancePolicy) DeepCopy() *NodeMaintenancePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMaintenancePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePortConfig) DeepCopyInto(out *NodePortConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePortConfig.
+func (in *NodePortConfig) DeepCopy() *NodePortConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePortConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PersistentVolumeClaimRetentionPolicy) DeepCopyInto(out *PersistentVolumeClaimRetentionPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistentVolumeClaimRetentionPolicy.
+func (in *PersistentVolumeClaimRetentionPolicy) DeepCopy() *PersistentVolumeClaimRetentionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PersistentVolumeClaimRetentionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodAntiAffinityConfig) DeepCopyInto(out *PodAntiAffinityConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodAntiAffinityConfig.
+func (in *PodAntiAffinityConfig) DeepCopy() *PodAntiAffinityConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PodAntiAffinityConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodDisruptionBudgetConfig) DeepCopyInto(out *PodDisruptionBudgetConfig) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDisruptionBudgetConfig.
+func (in *PodDisruptionBudgetConfig) DeepCopy() *PodDisruptionBudgetConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PodDisruptionBudgetConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodRemediation) DeepCopyInto(out *PodRemediation) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraDatacenterSpec.
-func (in *CassandraDatacenterSpec) DeepCopy() *CassandraDatacenterSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodRemediation.
+func (in *PodRemediation) DeepCopy() *PodRemediation {
 	if in == nil {
 		return nil
 	}
-	out := new(CassandraDatacenterSpec)
+	out := new(PodRemediation)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CassandraDatacenterStatus) DeepCopyInto(out *CassandraDatacenterStatus) {
+func (in *PortsConfig) DeepCopyInto(out *PortsConfig) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]DatacenterCondition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortsConfig.
+func (in *PortsConfig) DeepCopy() *PortsConfig {
+	if in == nil {
+		return nil
 	}
-	in.SuperUserUpserted.DeepCopyInto(&out.SuperUserUpserted)
-	in.UsersUpserted.DeepCopyInto(&out.UsersUpserted)
-	in.LastServerNodeStarted.DeepCopyInto(&out.LastServerNodeStarted)
-	in.LastRollingRestart.DeepCopyInto(&out.LastRollingRestart)
-	if in.NodeStatuses != nil {
-		in, out := &in.NodeStatuses, &out.NodeStatuses
-		*out = make(CassandraStatusMap, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	out := new(PortsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeConfig) DeepCopyInto(out *ProbeConfig) {
+	*out = *in
+	if in.InitialDelaySeconds != nil {
+		in, out := &in.InitialDelaySeconds, &out.InitialDelaySeconds
+		*out = new(int32)
+		**out = **in
 	}
-	if in.NodeReplacements != nil {
-		in, out := &in.NodeReplacements, &out.NodeReplacements
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
 	}
-	in.QuietPeriod.DeepCopyInto(&out.QuietPeriod)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraDatacenterStatus.
-func (in *CassandraDatacenterStatus) DeepCopy() *CassandraDatacenterStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeConfig.
+func (in *ProbeConfig) DeepCopy() *ProbeConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(CassandraDatacenterStatus)
+	out := new(ProbeConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CassandraNodeStatus) DeepCopyInto(out *CassandraNodeStatus) {
+func (in *ProbesConfig) DeepCopyInto(out *ProbesConfig) {
 	*out = *in
+	if in.Liveness != nil {
+		in, out := &in.Liveness, &out.Liveness
+		*out = new(ProbeConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(ProbeConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Startup != nil {
+		in, out := &in.Startup, &out.Startup
+		*out = new(ProbeConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraNodeStatus.
-func (in *CassandraNodeStatus) DeepCopy() *CassandraNodeStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbesConfig.
+func (in *ProbesConfig) DeepCopy() *ProbesConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(CassandraNodeStatus)
+	out := new(ProbesConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in CassandraStatusMap) DeepCopyInto(out *CassandraStatusMap) {
-	{
-		in := &in
-		*out = make(CassandraStatusMap, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-		return
-	}
+func (in *QueryLoggingConfig) DeepCopyInto(out *QueryLoggingConfig) {
+	*out = *in
+	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraStatusMap.
-func (in CassandraStatusMap) DeepCopy() CassandraStatusMap {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryLoggingConfig.
+func (in *QueryLoggingConfig) DeepCopy() *QueryLoggingConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(CassandraStatusMap)
+	out := new(QueryLoggingConfig)
 	in.DeepCopyInto(out)
-	return *out
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CassandraUser) DeepCopyInto(out *CassandraUser) {
+func (in *Rack) DeepCopyInto(out *Rack) {
 	*out = *in
+	if in.NodeAffinityLabels != nil {
+		in, out := &in.NodeAffinityLabels, &out.NodeAffinityLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeAffinity != nil {
+		in, out := &in.NodeAffinity, &out.NodeAffinity
+		*out = new(v1.NodeAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodAntiAffinity != nil {
+		in, out := &in.PodAntiAffinity, &out.PodAntiAffinity
+		*out = new(PodAntiAffinityConfig)
+		**out = **in
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(json.RawMessage, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CassandraUser.
-func (in *CassandraUser) DeepCopy() *CassandraUser {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rack.
+func (in *Rack) DeepCopy() *Rack {
 	if in == nil {
 		return nil
 	}
-	out := new(CassandraUser)
+	out := new(Rack)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DatacenterCondition) DeepCopyInto(out *DatacenterCondition) {
+func (in *RackStatus) DeepCopyInto(out *RackStatus) {
 	*out = *in
-	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatacenterCondition.
-func (in *DatacenterCondition) DeepCopy() *DatacenterCondition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RackStatus.
+func (in *RackStatus) DeepCopy() *RackStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(DatacenterCondition)
+	out := new(RackStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DseWorkloads) DeepCopyInto(out *DseWorkloads) {
+func (in *ReaperConfig) DeepCopyInto(out *ReaperConfig) {
 	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DseWorkloads.
-func (in *DseWorkloads) DeepCopy() *DseWorkloads {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReaperConfig.
+func (in *ReaperConfig) DeepCopy() *ReaperConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(DseWorkloads)
+	out := new(ReaperConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagementApiAuthConfig) DeepCopyInto(out *ManagementApiAuthConfig) {
+func (in *RelabelConfig) DeepCopyInto(out *RelabelConfig) {
 	*out = *in
-	if in.Insecure != nil {
-		in, out := &in.Insecure, &out.Insecure
-		*out = new(ManagementApiAuthInsecureConfig)
-		**out = **in
-	}
-	if in.Manual != nil {
-		in, out := &in.Manual, &out.Manual
-		*out = new(ManagementApiAuthManualConfig)
-		**out = **in
+	if in.SourceLabels != nil {
+		in, out := &in.SourceLabels, &out.SourceLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementApiAuthConfig.
-func (in *ManagementApiAuthConfig) DeepCopy() *ManagementApiAuthConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RelabelConfig.
+func (in *RelabelConfig) DeepCopy() *RelabelConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(ManagementApiAuthConfig)
+	out := new(RelabelConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagementApiAuthInsecureConfig) DeepCopyInto(out *ManagementApiAuthInsecureConfig) {
+func (in *RemediationConfig) DeepCopyInto(out *RemediationConfig) {
 	*out = *in
+	if in.GracePeriodSeconds != nil {
+		in, out := &in.GracePeriodSeconds, &out.GracePeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.JoiningGracePeriodSeconds != nil {
+		in, out := &in.JoiningGracePeriodSeconds, &out.JoiningGracePeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxRemediationsPerHour != nil {
+		in, out := &in.MaxRemediationsPerHour, &out.MaxRemediationsPerHour
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementApiAuthInsecureConfig.
-func (in *ManagementApiAuthInsecureConfig) DeepCopy() *ManagementApiAuthInsecureConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationConfig.
+func (in *RemediationConfig) DeepCopy() *RemediationConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(ManagementApiAuthInsecureConfig)
+	out := new(RemediationConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagementApiAuthManualConfig) DeepCopyInto(out *ManagementApiAuthManualConfig) {
+func (in *RepairSchedule) DeepCopyInto(out *RepairSchedule) {
 	*out = *in
+	if in.Keyspaces != nil {
+		in, out := &in.Keyspaces, &out.Keyspaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementApiAuthManualConfig.
-func (in *ManagementApiAuthManualConfig) DeepCopy() *ManagementApiAuthManualConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepairSchedule.
+func (in *RepairSchedule) DeepCopy() *RepairSchedule {
 	if in == nil {
 		return nil
 	}
-	out := new(ManagementApiAuthManualConfig)
+	out := new(RepairSchedule)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkingConfig) DeepCopyInto(out *NetworkingConfig) {
+func (in *RepairScheduleStatus) DeepCopyInto(out *RepairScheduleStatus) {
 	*out = *in
-	if in.NodePort != nil {
-		in, out := &in.NodePort, &out.NodePort
-		*out = new(NodePortConfig)
-		**out = **in
+	if in.LastRepairStart != nil {
+		in, out := &in.LastRepairStart, &out.LastRepairStart
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRepairTime != nil {
+		in, out := &in.LastRepairTime, &out.LastRepairTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRepairTime != nil {
+		in, out := &in.NextRepairTime, &out.NextRepairTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PendingPods != nil {
+		in, out := &in.PendingPods, &out.PendingPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkingConfig.
-func (in *NetworkingConfig) DeepCopy() *NetworkingConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepairScheduleStatus.
+func (in *RepairScheduleStatus) DeepCopy() *RepairScheduleStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(NetworkingConfig)
+	out := new(RepairScheduleStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NodePortConfig) DeepCopyInto(out *NodePortConfig) {
+func (in *RollingRestartConfig) DeepCopyInto(out *RollingRestartConfig) {
 	*out = *in
+	if in.Racks != nil {
+		in, out := &in.Racks, &out.Racks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePortConfig.
-func (in *NodePortConfig) DeepCopy() *NodePortConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingRestartConfig.
+func (in *RollingRestartConfig) DeepCopy() *RollingRestartConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(NodePortConfig)
+	out := new(RollingRestartConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Rack) DeepCopyInto(out *Rack) {
+func (in *SNIConfig) DeepCopyInto(out *SNIConfig) {
 	*out = *in
-	if in.NodeAffinityLabels != nil {
-		in, out := &in.NodeAffinityLabels, &out.NodeAffinityLabels
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
@@ -446,29 +2083,43 @@ func (in *Rack) DeepCopyInto(out *Rack) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Rack.
-func (in *Rack) DeepCopy() *Rack {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SNIConfig.
+func (in *SNIConfig) DeepCopy() *SNIConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(Rack)
+	out := new(SNIConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ReaperConfig) DeepCopyInto(out *ReaperConfig) {
+func (in *SSTablesUpgradeProgress) DeepCopyInto(out *SSTablesUpgradeProgress) {
 	*out = *in
-	in.Resources.DeepCopyInto(&out.Resources)
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PendingPods != nil {
+		in, out := &in.PendingPods, &out.PendingPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedPods != nil {
+		in, out := &in.FailedPods, &out.FailedPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReaperConfig.
-func (in *ReaperConfig) DeepCopy() *ReaperConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSTablesUpgradeProgress.
+func (in *SSTablesUpgradeProgress) DeepCopy() *SSTablesUpgradeProgress {
 	if in == nil {
 		return nil
 	}
-	out := new(ReaperConfig)
+	out := new(SSTablesUpgradeProgress)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -511,6 +2162,16 @@ func (in *ServiceConfigAdditions) DeepCopyInto(out *ServiceConfigAdditions) {
 			(*out)[key] = val
 		}
 	}
+	if in.PublishNotReadyAddresses != nil {
+		in, out := &in.PublishNotReadyAddresses, &out.PublishNotReadyAddresses
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -539,6 +2200,18 @@ func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AdditionalDataVolumes != nil {
+		in, out := &in.AdditionalDataVolumes, &out.AdditionalDataVolumes
+		*out = make(AdditionalVolumesSlice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CommitLogVolumeClaimSpec != nil {
+		in, out := &in.CommitLogVolumeClaimSpec, &out.CommitLogVolumeClaimSpec
+		*out = new(v1.PersistentVolumeClaimSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -551,3 +2224,56 @@ func (in *StorageConfig) DeepCopy() *StorageConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeSSTablesConfig) DeepCopyInto(out *UpgradeSSTablesConfig) {
+	*out = *in
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeSSTablesConfig.
+func (in *UpgradeSSTablesConfig) DeepCopy() *UpgradeSSTablesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeSSTablesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultConfig) DeepCopyInto(out *VaultConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultConfig.
+func (in *VaultConfig) DeepCopy() *VaultConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZoneRacksConfig) DeepCopyInto(out *ZoneRacksConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneRacksConfig.
+func (in *ZoneRacksConfig) DeepCopy() *ZoneRacksConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ZoneRacksConfig)
+	in.DeepCopyInto(out)
+	return out
+}
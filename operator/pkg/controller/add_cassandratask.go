@@ -0,0 +1,13 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package controller
+
+import (
+	"github.com/k8ssandra/cass-operator/operator/pkg/controller/cassandratask"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, cassandratask.Add)
+}
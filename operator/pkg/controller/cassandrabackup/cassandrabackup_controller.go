@@ -0,0 +1,348 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package cassandrabackup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+	"github.com/k8ssandra/cass-operator/operator/pkg/requeue"
+	"github.com/k8ssandra/cass-operator/operator/pkg/sharding"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+var log = logf.Log.WithName("cassandrabackup_controller")
+
+// Add creates a new CassandraBackup Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	shard, err := sharding.FromEnv()
+	if err != nil {
+		return err
+	}
+	return add(mgr, newReconciler(mgr, shard))
+}
+
+func newReconciler(mgr manager.Manager, shard *sharding.Shard) reconcile.Reconciler {
+	return &ReconcileCassandraBackup{
+		client:   mgr.GetClient(),
+		scheme:   mgr.GetScheme(),
+		recorder: mgr.GetEventRecorderFor("cass-operator"),
+		shard:    shard,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	requeuePolicy, err := requeue.FromEnv()
+	if err != nil {
+		return err
+	}
+	c, err := controller.New(
+		"cassandrabackup-controller",
+		mgr,
+		controller.Options{Reconciler: requeue.Wrap(r, requeuePolicy)})
+	if err != nil {
+		return err
+	}
+
+	return c.Watch(
+		&source.Kind{Type: &api.CassandraBackup{}},
+		&handler.EnqueueRequestForObject{},
+		predicate.GenerationChangedPredicate{})
+}
+
+// blank assignment to verify that ReconcileCassandraBackup implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileCassandraBackup{}
+
+// ReconcileCassandraBackup reconciles a CassandraBackup object
+type ReconcileCassandraBackup struct {
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	shard    *sharding.Shard
+}
+
+// Reconcile takes a snapshot on every pod of the CassandraDatacenter and has
+// the backup sidecar of each pod upload it to object storage.
+func (r *ReconcileCassandraBackup) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.Background()
+	logger := log.
+		WithValues("requestNamespace", request.Namespace).
+		WithValues("requestName", request.Name)
+
+	backup := &api.CassandraBackup{}
+	if err := r.client.Get(ctx, request.NamespacedName, backup); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("CassandraBackup resource not found. Ignoring since object must be deleted.")
+			return result.Done().Output()
+		}
+		return result.Error(err).Output()
+	}
+
+	if backup.Status.IsFinished() {
+		return result.Done().Output()
+	}
+
+	if key := backup.GetDatacenterKey(); !r.shard.Owns(key.Namespace, key.Name) {
+		return result.Done().Output()
+	}
+
+	dc := &api.CassandraDatacenter{}
+	if err := r.client.Get(ctx, backup.GetDatacenterKey(), dc); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("CassandraDatacenter for backup not found, waiting for it to be created",
+				"datacenter", backup.GetDatacenterKey())
+			return result.RequeueSoon(10).Output()
+		}
+		return result.Error(err).Output()
+	}
+
+	patch := client.MergeFrom(backup.DeepCopy())
+
+	if dc.Spec.BackupSidecar == nil {
+		r.recorder.Eventf(backup, corev1.EventTypeWarning, events.FailedBackup,
+			"CassandraDatacenter %s has no backupSidecar configured", dc.Name)
+		now := metav1.Now()
+		backup.Status.State = api.TaskStateFailed
+		backup.Status.CompletionTime = &now
+		if err := r.client.Status().Patch(ctx, backup, patch); err != nil {
+			return result.Error(err).Output()
+		}
+		return result.Done().Output()
+	}
+
+	podList := &corev1.PodList{}
+	listOptions := []client.ListOption{
+		client.InNamespace(dc.Namespace),
+		client.MatchingLabels(dc.GetDatacenterLabels()),
+	}
+	if err := r.client.List(ctx, podList, listOptions...); err != nil {
+		return result.Error(err).Output()
+	}
+
+	mgmtClient, err := httphelper.NewMgmtClient(ctx, r.client, dc, logger)
+	if err != nil {
+		return result.Error(err).Output()
+	}
+
+	if backup.Status.StartTime == nil {
+		now := metav1.Now()
+		backup.Status.StartTime = &now
+		backup.Status.State = api.TaskStateRunning
+		backup.Status.SnapshotName = backup.Name
+		backup.Status.DatacenterUID = dc.UID
+		backup.Status.Pods = make(map[string]api.BackupPodStatus, len(podList.Items))
+		for _, pod := range podList.Items {
+			backup.Status.Pods[pod.Name] = api.BackupPodStatus{State: api.TaskStatePending}
+		}
+		r.recorder.Eventf(backup, corev1.EventTypeNormal, events.StartedBackup,
+			"Backing up %d pods of datacenter %s", len(backup.Status.Pods), dc.Name)
+	}
+
+	pods := nextPods(backup, podList.Items)
+	backupPods(&mgmtClient, backup, pods)
+
+	finished := completeIfDone(backup)
+	if finished {
+		if backup.Status.State == api.TaskStateFailed {
+			r.recorder.Eventf(backup, corev1.EventTypeWarning, events.FailedBackup,
+				"Backup of datacenter %s failed", dc.Name)
+		} else {
+			r.recorder.Eventf(backup, corev1.EventTypeNormal, events.CompletedBackup,
+				"Backup of datacenter %s completed, %d bytes uploaded", dc.Name, backup.Status.TotalBytes)
+		}
+	}
+
+	if err := r.client.Status().Patch(ctx, backup, patch); err != nil {
+		logger.Error(err, "error patching CassandraBackup status")
+		return result.Error(err).Output()
+	}
+
+	if finished {
+		return result.Done().Output()
+	}
+
+	return result.RequeueSoon(10).Output()
+}
+
+// nextPods returns the pods whose upload is running, and the started pods
+// that have not been backed up yet
+func nextPods(backup *api.CassandraBackup, pods []corev1.Pod) []*corev1.Pod {
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+
+	var next []*corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		status, ok := backup.Status.Pods[pod.Name]
+		if !ok {
+			continue
+		}
+		if status.State == api.TaskStateRunning || (status.State == api.TaskStatePending && isPodStarted(pod)) {
+			next = append(next, pod)
+		}
+	}
+	return next
+}
+
+func isPodStarted(pod *corev1.Pod) bool {
+	return pod.Labels[api.CassNodeState] == "Started" && utils.IsCassandraContainerReady(pod)
+}
+
+// backupPods moves the given pods on with their backup in parallel. The
+// pending pods take their snapshot at the same time, so the snapshots of the
+// datacenter are as close together as possible. The sidecars upload them in
+// the background, and are asked how far they got on the next reconciliations.
+func backupPods(mgmtClient *httphelper.NodeMgmtClient, backup *api.CassandraBackup, pods []*corev1.Pod) {
+	statuses := make([]api.BackupPodStatus, len(pods))
+	for i, pod := range pods {
+		statuses[i] = backup.Status.Pods[pod.Name]
+	}
+
+	var wg sync.WaitGroup
+	for i, pod := range pods {
+		wg.Add(1)
+		go func(i int, pod *corev1.Pod) {
+			defer wg.Done()
+			statuses[i] = backupPod(mgmtClient, backup, pod, statuses[i])
+		}(i, pod)
+	}
+	wg.Wait()
+
+	for i, pod := range pods {
+		backup.Status.Pods[pod.Name] = statuses[i]
+	}
+}
+
+// backupPod takes the snapshot of a pending pod and starts its upload, or
+// checks on the upload of a running pod, and returns the new status of the pod
+func backupPod(mgmtClient *httphelper.NodeMgmtClient, backup *api.CassandraBackup, pod *corev1.Pod, status api.BackupPodStatus) api.BackupPodStatus {
+	snapshotName := backup.Status.SnapshotName
+
+	if status.State == api.TaskStatePending {
+		status.Attempts++
+		if err := mgmtClient.CallCreateSnapshotEndpoint(pod, snapshotName, backup.Spec.Keyspaces); err != nil {
+			return retryOrFail(mgmtClient, backup, pod, status, err)
+		}
+
+		transferID, err := mgmtClient.CallBackupSidecarUploadEndpoint(pod, httphelper.BackupTransferRequest{
+			SnapshotName: snapshotName,
+			Provider:     backup.Spec.Storage.Provider,
+			Bucket:       backup.Spec.Storage.Bucket,
+			Path:         httphelper.BackupPath(backup.Spec.Storage, backup.Name, pod.Name),
+		})
+		if err != nil {
+			return retryOrFail(mgmtClient, backup, pod, status, err)
+		}
+
+		status.State = api.TaskStateRunning
+		status.TransferID = transferID
+		return status
+	}
+
+	transfer, err := mgmtClient.CallBackupSidecarTransferEndpoint(pod, status.TransferID)
+	if mgmtapi.IsJobNotFound(err) {
+		return retryOrFail(mgmtClient, backup, pod, status,
+			fmt.Errorf("the backup sidecar of pod %s lost upload %s", pod.Name, status.TransferID))
+	}
+	if err != nil {
+		// The upload may still be running, it is checked again later
+		log.Error(err, "failed to check on the upload", "pod", pod.Name)
+		status.LastError = err.Error()
+		return status
+	}
+
+	// The sidecar counts the bytes as it goes, so the totals of the status
+	// show how far along a running upload is
+	status.BytesTransferred = transfer.BytesTransferred
+
+	switch transfer.Status {
+	case mgmtapi.JobCompleted:
+		// The snapshot is in object storage now, so free up the disk space it
+		// holds on to. A failure here does not fail the backup.
+		if err := mgmtClient.CallDeleteSnapshotEndpoint(pod, snapshotName); err != nil {
+			log.Error(err, "failed to clear snapshot after upload", "pod", pod.Name)
+		}
+		status.State = api.TaskStateSucceeded
+		status.TransferID = ""
+		status.LastError = ""
+	case mgmtapi.JobError:
+		return retryOrFail(mgmtClient, backup, pod, status,
+			fmt.Errorf("upload %s failed on pod %s: %s", status.TransferID, pod.Name, transfer.Error))
+	}
+	return status
+}
+
+// retryOrFail clears the snapshot of a failed attempt, and puts the pod back
+// to pending until it ran out of attempts
+func retryOrFail(mgmtClient *httphelper.NodeMgmtClient, backup *api.CassandraBackup, pod *corev1.Pod, status api.BackupPodStatus, err error) api.BackupPodStatus {
+	log.Error(err, "failed to back up pod", "pod", pod.Name, "attempts", status.Attempts)
+	if err := mgmtClient.CallDeleteSnapshotEndpoint(pod, backup.Status.SnapshotName); err != nil {
+		log.Error(err, "failed to clear snapshot of the failed attempt", "pod", pod.Name)
+	}
+
+	status.TransferID = ""
+	status.BytesTransferred = 0
+	status.LastError = err.Error()
+	if status.Attempts >= api.BackupTransferAttempts {
+		status.State = api.TaskStateFailed
+	} else {
+		status.State = api.TaskStatePending
+	}
+	return status
+}
+
+// completeIfDone totals the bytes uploaded so far, and marks the backup as
+// finished once every pod has either succeeded or failed.
+func completeIfDone(backup *api.CassandraBackup) bool {
+	var total int64
+	done, failed := 0, 0
+	for _, status := range backup.Status.Pods {
+		total += status.BytesTransferred
+		switch status.State {
+		case api.TaskStateSucceeded:
+			done++
+		case api.TaskStateFailed:
+			done++
+			failed++
+		}
+	}
+	backup.Status.TotalBytes = total
+
+	if done < len(backup.Status.Pods) {
+		return false
+	}
+
+	now := metav1.Now()
+	backup.Status.CompletionTime = &now
+	if failed > 0 {
+		backup.Status.State = api.TaskStateFailed
+	} else {
+		backup.Status.State = api.TaskStateSucceeded
+	}
+	return true
+}
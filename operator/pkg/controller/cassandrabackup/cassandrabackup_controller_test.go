@@ -0,0 +1,213 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package cassandrabackup
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+func makePod(name string, ready bool) corev1.Pod {
+	state := "Started"
+	if !ready {
+		state = "Ready-to-Start"
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{api.CassNodeState: state},
+		},
+		Status: corev1.PodStatus{
+			PodIP: "1.2.3.4",
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "cassandra", Ready: ready},
+			},
+		},
+	}
+}
+
+func makeBackup(podNames ...string) *api.CassandraBackup {
+	backup := &api.CassandraBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+		Spec: api.CassandraBackupSpec{
+			Datacenter: corev1.ObjectReference{Name: "dc1"},
+			Storage: api.BackupStorageLocation{
+				Provider: api.BackupStorageS3,
+				Bucket:   "backups",
+			},
+		},
+		Status: api.CassandraBackupStatus{
+			State:        api.TaskStateRunning,
+			SnapshotName: "nightly",
+			Pods:         map[string]api.BackupPodStatus{},
+		},
+	}
+	for _, name := range podNames {
+		backup.Status.Pods[name] = api.BackupPodStatus{State: api.TaskStatePending}
+	}
+	return backup
+}
+
+func TestNextPods(t *testing.T) {
+	pods := []corev1.Pod{
+		makePod("pod-c", true),
+		makePod("pod-b", false),
+		makePod("pod-a", true),
+		makePod("pod-new", true),
+		makePod("pod-d", false),
+		makePod("pod-e", true),
+	}
+	pods[5].Labels[api.CassNodeState] = "Starting"
+
+	backup := makeBackup("pod-a", "pod-b", "pod-c", "pod-d", "pod-e")
+	backup.Status.Pods["pod-c"] = api.BackupPodStatus{State: api.TaskStateSucceeded}
+	backup.Status.Pods["pod-d"] = api.BackupPodStatus{State: api.TaskStateRunning, TransferID: "upload-d"}
+
+	next := nextPods(backup, pods)
+	assert.Equal(t, 2, len(next))
+	assert.Equal(t, "pod-a", next[0].Name)
+	assert.Equal(t, "pod-d", next[1].Name, "the uploads that are running are checked on")
+}
+
+func TestCompleteIfDone(t *testing.T) {
+	backup := makeBackup("pod-a", "pod-b")
+	backup.Status.Pods["pod-a"] = api.BackupPodStatus{State: api.TaskStateSucceeded, BytesTransferred: 100}
+
+	assert.False(t, completeIfDone(backup))
+	assert.Equal(t, int64(100), backup.Status.TotalBytes)
+	assert.Equal(t, api.TaskStateRunning, backup.Status.State)
+
+	backup.Status.Pods["pod-b"] = api.BackupPodStatus{State: api.TaskStateSucceeded, BytesTransferred: 50}
+	assert.True(t, completeIfDone(backup))
+	assert.Equal(t, int64(150), backup.Status.TotalBytes)
+	assert.Equal(t, api.TaskStateSucceeded, backup.Status.State)
+	assert.NotNil(t, backup.Status.CompletionTime)
+}
+
+func TestCompleteIfDone_Failed(t *testing.T) {
+	backup := makeBackup("pod-a", "pod-b")
+	backup.Status.Pods["pod-a"] = api.BackupPodStatus{State: api.TaskStateSucceeded}
+	backup.Status.Pods["pod-b"] = api.BackupPodStatus{State: api.TaskStateFailed, LastError: "boom"}
+
+	assert.True(t, completeIfDone(backup))
+	assert.Equal(t, api.TaskStateFailed, backup.Status.State)
+}
+
+func respond(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func onRequest(mockHttpClient *mocks.HttpClient, method, path string) *mock.Call {
+	return mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.Method == method && req.URL.Path == path
+			}))
+}
+
+func TestBackupPods(t *testing.T) {
+	mockHttpClient := &mocks.HttpClient{}
+	onRequest(mockHttpClient, http.MethodPost, "/api/v0/ops/node/snapshots").
+		Return(respond(http.StatusOK, "OK"), nil).
+		Once()
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/backups" && strings.HasSuffix(req.URL.Host, ":8091")
+			})).
+		Return(respond(http.StatusAccepted, `{"id": "upload-a", "status": "WAITING"}`), nil).
+		Once()
+
+	mgmtClient := &httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      zap.Logger(true),
+		Protocol: "http",
+	}
+
+	backup := makeBackup("pod-a")
+	pod := makePod("pod-a", true)
+	backupPods(mgmtClient, backup, []*corev1.Pod{&pod})
+
+	mockHttpClient.AssertExpectations(t)
+	status := backup.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStateRunning, status.State, "the upload runs in the background")
+	assert.Equal(t, "upload-a", status.TransferID)
+	assert.Equal(t, int32(1), status.Attempts)
+
+	onRequest(mockHttpClient, http.MethodGet, "/api/v0/transfers/upload-a").
+		Return(respond(http.StatusOK, `{"id": "upload-a", "status": "WAITING", "bytes_transferred": 512}`), nil).
+		Once()
+	backupPods(mgmtClient, backup, []*corev1.Pod{&pod})
+	assert.Equal(t, api.TaskStateRunning, backup.Status.Pods["pod-a"].State)
+	assert.Equal(t, int64(512), backup.Status.Pods["pod-a"].BytesTransferred, "the progress of a running upload is shown")
+
+	onRequest(mockHttpClient, http.MethodGet, "/api/v0/transfers/upload-a").
+		Return(respond(http.StatusOK, `{"id": "upload-a", "status": "COMPLETED", "bytes_transferred": 1024}`), nil).
+		Once()
+	onRequest(mockHttpClient, http.MethodDelete, "/api/v0/ops/node/snapshots").
+		Return(respond(http.StatusOK, "OK"), nil).
+		Once()
+	backupPods(mgmtClient, backup, []*corev1.Pod{&pod})
+
+	mockHttpClient.AssertExpectations(t)
+	status = backup.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStateSucceeded, status.State)
+	assert.Equal(t, int64(1024), status.BytesTransferred)
+	assert.Empty(t, status.TransferID)
+}
+
+func TestBackupPods_RetriesFailedUploads(t *testing.T) {
+	mockHttpClient := &mocks.HttpClient{}
+	onRequest(mockHttpClient, http.MethodGet, "/api/v0/transfers/upload-a").
+		Return(respond(http.StatusNotFound, ""), nil).
+		Once()
+	onRequest(mockHttpClient, http.MethodDelete, "/api/v0/ops/node/snapshots").
+		Return(respond(http.StatusOK, "OK"), nil).
+		Once()
+
+	mgmtClient := &httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      zap.Logger(true),
+		Protocol: "http",
+	}
+
+	backup := makeBackup("pod-a")
+	backup.Status.Pods["pod-a"] = api.BackupPodStatus{State: api.TaskStateRunning, TransferID: "upload-a", Attempts: 1}
+	pod := makePod("pod-a", true)
+	backupPods(mgmtClient, backup, []*corev1.Pod{&pod})
+
+	status := backup.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStatePending, status.State, "an upload lost by a restarted sidecar is started again")
+	assert.Empty(t, status.TransferID)
+	assert.NotEmpty(t, status.LastError)
+
+	backup.Status.Pods["pod-a"] = api.BackupPodStatus{State: api.TaskStateRunning, TransferID: "upload-a", Attempts: api.BackupTransferAttempts}
+	onRequest(mockHttpClient, http.MethodGet, "/api/v0/transfers/upload-a").
+		Return(respond(http.StatusOK, `{"id": "upload-a", "status": "ERROR", "error": "access denied"}`), nil).
+		Once()
+	onRequest(mockHttpClient, http.MethodDelete, "/api/v0/ops/node/snapshots").
+		Return(respond(http.StatusOK, "OK"), nil).
+		Once()
+	backupPods(mgmtClient, backup, []*corev1.Pod{&pod})
+
+	mockHttpClient.AssertExpectations(t)
+	status = backup.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStateFailed, status.State, "the pod fails once it ran out of attempts")
+	assert.Contains(t, status.LastError, "access denied")
+}
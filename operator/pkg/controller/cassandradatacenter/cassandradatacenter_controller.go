@@ -4,12 +4,16 @@
 package cassandradatacenter
 
 import (
+	"context"
 	"fmt"
 
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 
 	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/requeue"
+	"github.com/k8ssandra/cass-operator/operator/pkg/sharding"
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+	"github.com/k8ssandra/cass-operator/operator/pkg/watchnamespace"
 
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -21,6 +25,7 @@ import (
 	"github.com/k8ssandra/cass-operator/operator/pkg/reconciliation"
 	corev1 "k8s.io/api/core/v1"
 	types "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -33,34 +38,64 @@ var log = logf.Log.WithName("cassandradatacenter_controller")
 // Add creates a new CassandraDatacenter Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
-	return add(mgr, reconciliation.NewReconciler(mgr))
+	selector, err := watchnamespace.GetSelector()
+	if err != nil {
+		return err
+	}
+	shard, err := sharding.FromEnv()
+	if err != nil {
+		return err
+	}
+	return add(mgr, reconciliation.NewReconciler(mgr, selector, shard))
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	maxConcurrentReconciles, err := utils.GetMaxConcurrentReconciles()
+	if err != nil {
+		return err
+	}
+	requeuePolicy, err := requeue.FromEnv()
+	if err != nil {
+		return err
+	}
+
 	// Create a new controller
 	c, err := controller.New(
 		"cassandradatacenter-controller",
 		mgr,
-		controller.Options{Reconciler: r})
+		controller.Options{Reconciler: requeue.Wrap(r, requeuePolicy), MaxConcurrentReconciles: maxConcurrentReconciles})
 	if err != nil {
 		return err
 	}
 
+	// Changing annotations does not change the generation, so pausing and
+	// resuming reconciliation, and setting log levels, are picked up separately
+	dcChangedPredicate := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if (predicate.GenerationChangedPredicate{}).Update(e) {
+				return true
+			}
+			oldAnnotations, newAnnotations := e.MetaOld.GetAnnotations(), e.MetaNew.GetAnnotations()
+			return oldAnnotations[api.PausedAnnotation] != newAnnotations[api.PausedAnnotation] ||
+				oldAnnotations[api.SetLogLevelAnnotation] != newAnnotations[api.SetLogLevelAnnotation]
+		},
+	}
+
 	// Watch for changes to primary resource CassandraDatacenter
 	err = c.Watch(
 		&source.Kind{Type: &api.CassandraDatacenter{}},
 		&handler.EnqueueRequestForObject{},
 		// This allows us to update the status on every reconcile call without
 		// triggering an infinite loop.
-		predicate.GenerationChangedPredicate{})
+		dcChangedPredicate)
 	if err != nil {
 		return err
 	}
 
 	// Here we list all the types that we create that are owned by the primary resource.
 	//
-	// Watch for changes to secondary resources StatefulSets, PodDisruptionBudgets, and Services and requeue the
+	// Watch for changes to secondary resources StatefulSets, PodDisruptionBudgets, Services, and Deployments and requeue the
 	// CassandraDatacenter that owns them.
 
 	managedByCassandraOperatorPredicate := predicate.Funcs{
@@ -79,11 +114,6 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		},
 	}
 
-	// NOTE: We do not currently watch PVC resources, but if we did, we'd have to
-	// account for the fact that they might use the old managed-by label value
-	// (oplabels.ManagedByLabelDefunctValue) for CassandraDatacenters originally
-	// created in version 1.1.0 or earlier.
-
 	err = c.Watch(
 		&source.Kind{Type: &appsv1.StatefulSet{}},
 		&handler.EnqueueRequestForOwner{
@@ -120,17 +150,31 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// The deployment of Reaper, see CheckReaper
+	err = c.Watch(
+		&source.Kind{Type: &appsv1.Deployment{}},
+		&handler.EnqueueRequestForOwner{
+			IsController: true,
+			OwnerType:    &api.CassandraDatacenter{},
+		},
+		managedByCassandraOperatorPredicate,
+	)
+	if err != nil {
+		return err
+	}
+
+	// The config secrets and the config ConfigMaps are both mapped to the
+	// datacenters sharing them by their annotation
 	configSecretMapFn := handler.ToRequestsFunc(func(mapObj handler.MapObject) []reconcile.Request {
-		log.Info("config secret watch called", "Secret", mapObj.Meta.GetName())
+		log.Info("config secret watch called", "Name", mapObj.Meta.GetName())
 
 		requests := make([]reconcile.Request, 0)
-		secret := mapObj.Object.(*corev1.Secret)
-		if v, ok := secret.Annotations[api.DatacenterAnnotation]; ok {
-			log.Info("adding reconciliation request for config secret", "Secret", secret.Name)
+		for _, name := range api.AnnotatedDatacenters(mapObj.Meta.GetAnnotations()) {
+			log.Info("adding reconciliation request for config", "Name", mapObj.Meta.GetName(), "cassandraDatacenter", name)
 			requests = append(requests, reconcile.Request{
 				NamespacedName: types.NamespacedName{
-					Namespace: secret.Namespace,
-					Name: v,
+					Namespace: mapObj.Meta.GetNamespace(),
+					Name: name,
 				},
 			})
 		}
@@ -166,6 +210,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// The ConfigMap of the config, see configConfigMap
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: configSecretMapFn}, configSecretPredicate)
+	if err != nil {
+		return err
+	}
+
 	// Setup watches for Nodes to check for taints being added
 
 	nodeMapFn := handler.ToRequestsFunc(
@@ -215,17 +265,17 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		},
 	}
 
-	if utils.IsPSPEnabled() {
-		err = c.Watch(
-			&source.Kind{Type: &corev1.Node{}},
-			&handler.EnqueueRequestsFromMapFunc{
-				ToRequests: nodeMapFn,
-			},
-			nodeTaintsChangedPredicate,
-		)
-		if err != nil {
-			return err
-		}
+	// Only the datacenters with a node maintenance policy are mapped to nodes,
+	// see emm.IsEnabled
+	err = c.Watch(
+		&source.Kind{Type: &corev1.Node{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: nodeMapFn,
+		},
+		nodeTaintsChangedPredicate,
+	)
+	if err != nil {
+		return err
 	}
 
 	// Setup watches for pvc to check for taints being added
@@ -239,12 +289,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 			pvcLabels := pvc.ObjectMeta.Labels
 			pvcNamespace := pvc.ObjectMeta.Namespace
 
-			managedByValue, ok := pvcLabels[oplabels.ManagedByLabel]
-			if !ok {
-				return requests
-			}
-
-			if (managedByValue == oplabels.ManagedByLabelValue) || (managedByValue == oplabels.ManagedByLabelDefunctValue) {
+			if oplabels.HasManagedByCassandraOperatorLabel(pvcLabels) {
 
 				dcName := pvcLabels[api.DatacenterLabel]
 
@@ -263,16 +308,14 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 			return requests
 		})
 
-	if utils.IsPSPEnabled() {
-		err = c.Watch(
-			&source.Kind{Type: &corev1.PersistentVolumeClaim{}},
-			&handler.EnqueueRequestsFromMapFunc{
-				ToRequests: pvcMapFn,
-			},
-		)
-		if err != nil {
-			return err
-		}
+	err = c.Watch(
+		&source.Kind{Type: &corev1.PersistentVolumeClaim{}},
+		&handler.EnqueueRequestsFromMapFunc{
+			ToRequests: pvcMapFn,
+		},
+	)
+	if err != nil {
+		return err
 	}
 
 	// Setup watches for Secrets. These secrets are often not owned by or created by
@@ -302,6 +345,59 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Setup watches for Namespaces when they are selected by their labels, so the
+	// datacenters of a namespace get reconciled once it starts matching
+
+	namespaceFilter := rd.NamespaceFilter
+	if namespaceFilter.HasSelector() {
+		namespaceMapFn := handler.ToRequestsFunc(
+			func(a handler.MapObject) []reconcile.Request {
+				namespace := a.Meta.GetName()
+				log.Info("Namespace watch called", "namespace", namespace)
+
+				dcList := &api.CassandraDatacenterList{}
+				if err := mgr.GetClient().List(context.Background(), dcList, client.InNamespace(namespace)); err != nil {
+					log.Error(err, "Failed to list the CassandraDatacenters of the namespace", "namespace", namespace)
+					return nil
+				}
+
+				requests := []reconcile.Request{}
+				for _, dc := range dcList.Items {
+					requests = append(requests, reconcile.Request{
+						NamespacedName: types.NamespacedName{
+							Name:      dc.Name,
+							Namespace: dc.Namespace,
+						}},
+					)
+				}
+				return requests
+			})
+
+		namespaceSelectedPredicate := predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return namespaceFilter.Matches(e.Meta.GetLabels())
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return namespaceFilter.Matches(e.MetaOld.GetLabels()) != namespaceFilter.Matches(e.MetaNew.GetLabels())
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return false
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return false
+			},
+		}
+
+		err = c.Watch(
+			&source.Kind{Type: &corev1.Namespace{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: namespaceMapFn},
+			namespaceSelectedPredicate,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,419 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package cassandrarestore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+	"github.com/k8ssandra/cass-operator/operator/pkg/reconciliation"
+	"github.com/k8ssandra/cass-operator/operator/pkg/requeue"
+	"github.com/k8ssandra/cass-operator/operator/pkg/sharding"
+)
+
+var log = logf.Log.WithName("cassandrarestore_controller")
+
+// Add creates a new CassandraRestore Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	shard, err := sharding.FromEnv()
+	if err != nil {
+		return err
+	}
+	return add(mgr, newReconciler(mgr, shard))
+}
+
+func newReconciler(mgr manager.Manager, shard *sharding.Shard) reconcile.Reconciler {
+	return &ReconcileCassandraRestore{
+		client:   mgr.GetClient(),
+		scheme:   mgr.GetScheme(),
+		recorder: mgr.GetEventRecorderFor("cass-operator"),
+		shard:    shard,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	requeuePolicy, err := requeue.FromEnv()
+	if err != nil {
+		return err
+	}
+	c, err := controller.New(
+		"cassandrarestore-controller",
+		mgr,
+		controller.Options{Reconciler: requeue.Wrap(r, requeuePolicy)})
+	if err != nil {
+		return err
+	}
+
+	return c.Watch(
+		&source.Kind{Type: &api.CassandraRestore{}},
+		&handler.EnqueueRequestForObject{},
+		predicate.GenerationChangedPredicate{})
+}
+
+// blank assignment to verify that ReconcileCassandraRestore implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileCassandraRestore{}
+
+// ReconcileCassandraRestore reconciles a CassandraRestore object
+type ReconcileCassandraRestore struct {
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	shard    *sharding.Shard
+}
+
+// Reconcile has the backup sidecar of every pod of the target
+// CassandraDatacenter download the files of one node of the backup, then
+// requests a rolling restart so Cassandra loads the restored sstables.
+func (r *ReconcileCassandraRestore) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.Background()
+	logger := log.
+		WithValues("requestNamespace", request.Namespace).
+		WithValues("requestName", request.Name)
+
+	restore := &api.CassandraRestore{}
+	if err := r.client.Get(ctx, request.NamespacedName, restore); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("CassandraRestore resource not found. Ignoring since object must be deleted.")
+			return result.Done().Output()
+		}
+		return result.Error(err).Output()
+	}
+
+	if restore.Status.IsFinished() {
+		return result.Done().Output()
+	}
+
+	patch := client.MergeFrom(restore.DeepCopy())
+
+	backup := &api.CassandraBackup{}
+	backupKey := types.NamespacedName{Namespace: restore.Namespace, Name: restore.Spec.Backup}
+	if err := r.client.Get(ctx, backupKey, backup); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("CassandraBackup for restore not found, waiting for it to be created", "backup", backupKey)
+			return result.RequeueSoon(10).Output()
+		}
+		return result.Error(err).Output()
+	}
+
+	switch backup.Status.State {
+	case api.TaskStateSucceeded:
+	case api.TaskStateFailed:
+		return r.fail(ctx, restore, patch, fmt.Sprintf("CassandraBackup %s failed and cannot be restored", backup.Name))
+	default:
+		logger.Info("Waiting for CassandraBackup to complete", "backup", backupKey)
+		return result.RequeueSoon(10).Output()
+	}
+
+	if key := restore.GetDatacenterKey(); !r.shard.Owns(key.Namespace, key.Name) {
+		return result.Done().Output()
+	}
+
+	dc := &api.CassandraDatacenter{}
+	if err := r.client.Get(ctx, restore.GetDatacenterKey(), dc); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("CassandraDatacenter for restore not found, waiting for it to be created",
+				"datacenter", restore.GetDatacenterKey())
+			return result.RequeueSoon(10).Output()
+		}
+		return result.Error(err).Output()
+	}
+
+	if dc.Spec.BackupSidecar == nil {
+		return r.fail(ctx, restore, patch, fmt.Sprintf("CassandraDatacenter %s has no backupSidecar configured", dc.Name))
+	}
+
+	// The files of a node only hold the token ranges it owned, and a new
+	// datacenter picks new tokens for its nodes. Backups taken before the UID
+	// was recorded go by the name of the datacenter.
+	if backup.Status.DatacenterUID != "" && backup.Status.DatacenterUID != dc.UID ||
+		backup.Status.DatacenterUID == "" && backup.GetDatacenterKey() != restore.GetDatacenterKey() {
+		return r.fail(ctx, restore, patch, fmt.Sprintf(
+			"CassandraDatacenter %s is not the datacenter backup %s was taken of, restoring into a new datacenter is not supported",
+			dc.Name, backup.Name))
+	}
+
+	if int(dc.Spec.Size) != len(backup.Status.Pods) {
+		return r.fail(ctx, restore, patch, fmt.Sprintf(
+			"CassandraDatacenter %s has %d nodes but backup %s has %d", dc.Name, dc.Spec.Size, backup.Name, len(backup.Status.Pods)))
+	}
+
+	podList := &corev1.PodList{}
+	listOptions := []client.ListOption{
+		client.InNamespace(dc.Namespace),
+		client.MatchingLabels(dc.GetDatacenterLabels()),
+	}
+	if err := r.client.List(ctx, podList, listOptions...); err != nil {
+		return result.Error(err).Output()
+	}
+
+	if restore.Status.StartTime == nil {
+		if len(podList.Items) != len(backup.Status.Pods) {
+			logger.Info("Waiting for all pods of the datacenter to be created before restoring")
+			return result.RequeueSoon(10).Output()
+		}
+		for _, pod := range podList.Items {
+			if _, ok := backup.Status.Pods[pod.Name]; !ok {
+				return r.fail(ctx, restore, patch, fmt.Sprintf(
+					"Pod %s was not backed up by backup %s", pod.Name, backup.Name))
+			}
+		}
+
+		now := metav1.Now()
+		restore.Status.StartTime = &now
+		restore.Status.State = api.TaskStateRunning
+		restore.Status.Pods = mapPodsToSources(podList.Items, backup)
+		r.recorder.Eventf(restore, corev1.EventTypeNormal, events.StartedRestore,
+			"Restoring backup %s into datacenter %s", backup.Name, dc.Name)
+	}
+
+	mgmtClient, err := httphelper.NewMgmtClient(ctx, r.client, dc, logger)
+	if err != nil {
+		return result.Error(err).Output()
+	}
+
+	pods := nextPods(restore, podList.Items)
+	restorePods(&mgmtClient, restore, backup, pods)
+
+	finished := completeIfDone(restore)
+	if finished {
+		if restore.Status.State == api.TaskStateFailed {
+			r.recorder.Eventf(restore, corev1.EventTypeWarning, events.FailedRestore,
+				"Restore of backup %s into datacenter %s failed", backup.Name, dc.Name)
+		} else {
+			// Cassandra only picks up the restored sstables when it starts, and
+			// every node has restored files, so the restart cannot be limited
+			// to some of the pods
+			dcPatch := client.MergeFrom(dc.DeepCopy())
+			dc.Spec.RollingRestartRequested = true
+			dc.Spec.RollingRestart = nil
+			if err := r.client.Patch(ctx, dc, dcPatch); err != nil {
+				logger.Error(err, "error requesting rolling restart after restore")
+				return result.Error(err).Output()
+			}
+
+			r.recorder.Eventf(restore, corev1.EventTypeNormal, events.CompletedRestore,
+				"Restored backup %s into datacenter %s, %d bytes downloaded", backup.Name, dc.Name, restore.Status.TotalBytes)
+		}
+	}
+
+	if err := r.client.Status().Patch(ctx, restore, patch); err != nil {
+		logger.Error(err, "error patching CassandraRestore status")
+		return result.Error(err).Output()
+	}
+
+	if finished {
+		return result.Done().Output()
+	}
+
+	return result.RequeueSoon(10).Output()
+}
+
+func (r *ReconcileCassandraRestore) fail(ctx context.Context, restore *api.CassandraRestore, patch client.Patch, message string) (reconcile.Result, error) {
+	r.recorder.Event(restore, corev1.EventTypeWarning, events.FailedRestore, message)
+
+	now := metav1.Now()
+	restore.Status.State = api.TaskStateFailed
+	restore.Status.CompletionTime = &now
+	if err := r.client.Status().Patch(ctx, restore, patch); err != nil {
+		return result.Error(err).Output()
+	}
+	return result.Done().Output()
+}
+
+// mapPodsToSources pairs every pod of the target datacenter with a backed up
+// pod. Both sides are sorted by name and hold the same pods, so every node's
+// files are put back where they came from.
+func mapPodsToSources(pods []corev1.Pod, backup *api.CassandraBackup) map[string]api.BackupPodStatus {
+	var targets []string
+	for _, pod := range pods {
+		targets = append(targets, pod.Name)
+	}
+	sort.Strings(targets)
+
+	var sources []string
+	for name := range backup.Status.Pods {
+		sources = append(sources, name)
+	}
+	sort.Strings(sources)
+
+	statuses := make(map[string]api.BackupPodStatus, len(targets))
+	for i, target := range targets {
+		statuses[target] = api.BackupPodStatus{
+			State:  api.TaskStatePending,
+			Source: sources[i],
+		}
+	}
+	return statuses
+}
+
+// nextPods returns the pods whose download is running, and the pods with a
+// running backup sidecar that have not been restored yet. The cassandra
+// container does not need to be ready, as the restored files are only loaded
+// on the next restart anyway.
+func nextPods(restore *api.CassandraRestore, pods []corev1.Pod) []*corev1.Pod {
+	var next []*corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		status, ok := restore.Status.Pods[pod.Name]
+		if !ok {
+			continue
+		}
+		if status.State == api.TaskStateRunning || (status.State == api.TaskStatePending && isBackupSidecarReady(pod)) {
+			next = append(next, pod)
+		}
+	}
+	return next
+}
+
+func isBackupSidecarReady(pod *corev1.Pod) bool {
+	if pod.Status.PodIP == "" {
+		return false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == reconciliation.BackupSidecarContainerName {
+			return status.Ready
+		}
+	}
+	return false
+}
+
+// restorePods starts the downloads of the pending pods, and checks on the
+// downloads the sidecars of the running pods run in the background
+func restorePods(mgmtClient *httphelper.NodeMgmtClient, restore *api.CassandraRestore, backup *api.CassandraBackup, pods []*corev1.Pod) {
+	statuses := make([]api.BackupPodStatus, len(pods))
+	for i, pod := range pods {
+		statuses[i] = restore.Status.Pods[pod.Name]
+	}
+
+	var wg sync.WaitGroup
+	for i, pod := range pods {
+		wg.Add(1)
+		go func(i int, pod *corev1.Pod) {
+			defer wg.Done()
+			statuses[i] = restorePod(mgmtClient, backup, pod, statuses[i])
+		}(i, pod)
+	}
+	wg.Wait()
+
+	for i, pod := range pods {
+		restore.Status.Pods[pod.Name] = statuses[i]
+	}
+}
+
+// restorePod moves a pod on with its download, and returns its new status
+func restorePod(mgmtClient *httphelper.NodeMgmtClient, backup *api.CassandraBackup, pod *corev1.Pod, status api.BackupPodStatus) api.BackupPodStatus {
+	if status.State == api.TaskStatePending {
+		status.Attempts++
+		transferID, err := mgmtClient.CallBackupSidecarDownloadEndpoint(pod, httphelper.BackupTransferRequest{
+			SnapshotName: backup.Status.SnapshotName,
+			Provider:     backup.Spec.Storage.Provider,
+			Bucket:       backup.Spec.Storage.Bucket,
+			Path:         httphelper.BackupPath(backup.Spec.Storage, backup.Name, status.Source),
+		})
+		if err != nil {
+			return retryOrFail(pod, status, err)
+		}
+
+		status.State = api.TaskStateRunning
+		status.TransferID = transferID
+		return status
+	}
+
+	transfer, err := mgmtClient.CallBackupSidecarTransferEndpoint(pod, status.TransferID)
+	if mgmtapi.IsJobNotFound(err) {
+		return retryOrFail(pod, status,
+			fmt.Errorf("the backup sidecar of pod %s lost download %s", pod.Name, status.TransferID))
+	}
+	if err != nil {
+		// The download may still be running, it is checked again later
+		log.Error(err, "failed to check on the download", "pod", pod.Name)
+		status.LastError = err.Error()
+		return status
+	}
+
+	// The sidecar counts the bytes as it goes, so the totals of the status
+	// show how far along a running download is
+	status.BytesTransferred = transfer.BytesTransferred
+
+	switch transfer.Status {
+	case mgmtapi.JobCompleted:
+		status.State = api.TaskStateSucceeded
+		status.TransferID = ""
+		status.LastError = ""
+	case mgmtapi.JobError:
+		return retryOrFail(pod, status,
+			fmt.Errorf("download %s failed on pod %s: %s", status.TransferID, pod.Name, transfer.Error))
+	}
+	return status
+}
+
+// retryOrFail puts the pod back to pending after a failed download, until it
+// ran out of attempts
+func retryOrFail(pod *corev1.Pod, status api.BackupPodStatus, err error) api.BackupPodStatus {
+	log.Error(err, "failed to restore pod", "pod", pod.Name, "attempts", status.Attempts)
+	status.TransferID = ""
+	status.BytesTransferred = 0
+	status.LastError = err.Error()
+	if status.Attempts >= api.BackupTransferAttempts {
+		status.State = api.TaskStateFailed
+	} else {
+		status.State = api.TaskStatePending
+	}
+	return status
+}
+
+// completeIfDone totals the bytes downloaded so far, and marks the restore as
+// finished once every pod has either succeeded or failed.
+func completeIfDone(restore *api.CassandraRestore) bool {
+	var total int64
+	done, failed := 0, 0
+	for _, status := range restore.Status.Pods {
+		total += status.BytesTransferred
+		switch status.State {
+		case api.TaskStateSucceeded:
+			done++
+		case api.TaskStateFailed:
+			done++
+			failed++
+		}
+	}
+	restore.Status.TotalBytes = total
+
+	if done < len(restore.Status.Pods) {
+		return false
+	}
+
+	now := metav1.Now()
+	restore.Status.CompletionTime = &now
+	if failed > 0 {
+		restore.Status.State = api.TaskStateFailed
+	} else {
+		restore.Status.State = api.TaskStateSucceeded
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package cassandrarestore
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+	"github.com/k8ssandra/cass-operator/operator/pkg/reconciliation"
+)
+
+func makePod(name string, sidecarReady bool) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.PodStatus{
+			PodIP: "1.2.3.4",
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "cassandra", Ready: false},
+				{Name: reconciliation.BackupSidecarContainerName, Ready: sidecarReady},
+			},
+		},
+	}
+}
+
+func makeBackup(podNames ...string) *api.CassandraBackup {
+	backup := &api.CassandraBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+		Status: api.CassandraBackupStatus{
+			State: api.TaskStateSucceeded,
+			Pods:  map[string]api.BackupPodStatus{},
+		},
+	}
+	for _, name := range podNames {
+		backup.Status.Pods[name] = api.BackupPodStatus{State: api.TaskStateSucceeded}
+	}
+	return backup
+}
+
+func TestMapPodsToSources(t *testing.T) {
+	backup := makeBackup("pod-1", "pod-0", "pod-2")
+	pods := []corev1.Pod{
+		makePod("pod-2", true),
+		makePod("pod-0", true),
+		makePod("pod-1", true),
+	}
+
+	statuses := mapPodsToSources(pods, backup)
+	assert.Equal(t, 3, len(statuses))
+	assert.Equal(t, "pod-0", statuses["pod-0"].Source)
+	assert.Equal(t, "pod-1", statuses["pod-1"].Source)
+	assert.Equal(t, "pod-2", statuses["pod-2"].Source)
+	assert.Equal(t, api.TaskStatePending, statuses["pod-0"].State)
+}
+
+func TestNextPods(t *testing.T) {
+	backup := makeBackup("pod-a", "pod-b", "pod-c", "pod-d")
+	pods := []corev1.Pod{
+		makePod("pod-a", true),
+		makePod("pod-b", false),
+		makePod("pod-c", true),
+		makePod("pod-d", false),
+	}
+
+	restore := &api.CassandraRestore{}
+	restore.Status.Pods = mapPodsToSources(pods, backup)
+	restore.Status.Pods["pod-c"] = api.BackupPodStatus{State: api.TaskStateSucceeded, Source: "pod-c"}
+	restore.Status.Pods["pod-d"] = api.BackupPodStatus{State: api.TaskStateRunning, Source: "pod-d", TransferID: "download-d"}
+
+	next := nextPods(restore, pods)
+	assert.Equal(t, 2, len(next), "the cassandra container does not need to be ready, the sidecar does")
+	assert.Equal(t, "pod-a", next[0].Name)
+	assert.Equal(t, "pod-d", next[1].Name, "the downloads that are running are checked on")
+}
+
+func TestRestorePods(t *testing.T) {
+	respond := func(body string) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}
+	}
+
+	mockHttpClient := &mocks.HttpClient{}
+	for i := 0; i < 2; i++ {
+		mockHttpClient.On("Do",
+			mock.MatchedBy(
+				func(req *http.Request) bool {
+					return req != nil && req.Method == http.MethodPost && req.URL.Path == "/api/v0/restores"
+				})).
+			Return(respond(`{"id": "download-a", "status": "WAITING"}`), nil).
+			Once()
+	}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.Method == http.MethodGet && req.URL.Path == "/api/v0/transfers/download-a"
+			})).
+		Return(respond(`{"id": "download-a", "status": "ERROR", "bytes_transferred": 512, "error": "connection reset"}`), nil).
+		Once()
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.Method == http.MethodGet && req.URL.Path == "/api/v0/transfers/download-a"
+			})).
+		Return(respond(`{"id": "download-a", "status": "COMPLETED", "bytes_transferred": 2048}`), nil).
+		Once()
+
+	mgmtClient := &httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      zap.Logger(true),
+		Protocol: "http",
+	}
+
+	backup := makeBackup("pod-a")
+	pod := makePod("pod-a", true)
+	restore := &api.CassandraRestore{}
+	restore.Status.Pods = mapPodsToSources([]corev1.Pod{pod}, backup)
+
+	restorePods(mgmtClient, restore, backup, []*corev1.Pod{&pod})
+	assert.Equal(t, api.TaskStateRunning, restore.Status.Pods["pod-a"].State, "the download runs in the background")
+	assert.Equal(t, "download-a", restore.Status.Pods["pod-a"].TransferID)
+
+	restorePods(mgmtClient, restore, backup, []*corev1.Pod{&pod})
+	assert.Equal(t, api.TaskStatePending, restore.Status.Pods["pod-a"].State, "a failed download is started again")
+	assert.Contains(t, restore.Status.Pods["pod-a"].LastError, "connection reset")
+	assert.Equal(t, int64(0), restore.Status.Pods["pod-a"].BytesTransferred, "a download started again starts from scratch")
+
+	restorePods(mgmtClient, restore, backup, []*corev1.Pod{&pod})
+	restorePods(mgmtClient, restore, backup, []*corev1.Pod{&pod})
+
+	mockHttpClient.AssertExpectations(t)
+	status := restore.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStateSucceeded, status.State)
+	assert.Equal(t, int64(2048), status.BytesTransferred)
+	assert.Equal(t, int32(2), status.Attempts)
+}
+
+func TestIsBackupSidecarReady_NoIP(t *testing.T) {
+	pod := makePod("pod-a", true)
+	pod.Status.PodIP = ""
+	assert.False(t, isBackupSidecarReady(&pod))
+}
+
+func TestCompleteIfDone(t *testing.T) {
+	restore := &api.CassandraRestore{}
+	restore.Status.State = api.TaskStateRunning
+	restore.Status.Pods = map[string]api.BackupPodStatus{
+		"pod-a": {State: api.TaskStateSucceeded, BytesTransferred: 10},
+		"pod-b": {State: api.TaskStatePending},
+	}
+
+	assert.False(t, completeIfDone(restore))
+	assert.Equal(t, int64(10), restore.Status.TotalBytes)
+
+	restore.Status.Pods["pod-b"] = api.BackupPodStatus{State: api.TaskStateFailed, LastError: "boom"}
+	assert.True(t, completeIfDone(restore))
+	assert.Equal(t, api.TaskStateFailed, restore.Status.State)
+	assert.NotNil(t, restore.Status.CompletionTime)
+}
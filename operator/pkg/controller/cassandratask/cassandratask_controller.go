@@ -0,0 +1,651 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package cassandratask
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/jobtracker"
+	"github.com/k8ssandra/cass-operator/operator/pkg/reconciliation"
+	"github.com/k8ssandra/cass-operator/operator/pkg/requeue"
+	"github.com/k8ssandra/cass-operator/operator/pkg/sharding"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+var log = logf.Log.WithName("cassandratask_controller")
+
+// How often the jobs running on the pods are checked
+const jobPollSeconds = 5
+
+// The longest output of a pod kept in the status of a task, so that the
+// status stays well under the size limit of the object
+const maxOutputLength = 4096
+
+// Add creates a new CassandraTask Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	shard, err := sharding.FromEnv()
+	if err != nil {
+		return err
+	}
+	return add(mgr, newReconciler(mgr, shard))
+}
+
+func newReconciler(mgr manager.Manager, shard *sharding.Shard) reconcile.Reconciler {
+	return &ReconcileCassandraTask{
+		client:   mgr.GetClient(),
+		scheme:   mgr.GetScheme(),
+		recorder: mgr.GetEventRecorderFor("cass-operator"),
+		shard:    shard,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	requeuePolicy, err := requeue.FromEnv()
+	if err != nil {
+		return err
+	}
+	c, err := controller.New(
+		"cassandratask-controller",
+		mgr,
+		controller.Options{Reconciler: requeue.Wrap(r, requeuePolicy)})
+	if err != nil {
+		return err
+	}
+
+	// Status updates do not change the generation, so progress is driven
+	// by requeueing rather than by watching our own status patches.
+	return c.Watch(
+		&source.Kind{Type: &api.CassandraTask{}},
+		&handler.EnqueueRequestForObject{},
+		predicate.GenerationChangedPredicate{})
+}
+
+// blank assignment to verify that ReconcileCassandraTask implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileCassandraTask{}
+
+// ReconcileCassandraTask reconciles a CassandraTask object
+type ReconcileCassandraTask struct {
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	shard    *sharding.Shard
+}
+
+// Reconcile runs the command of a CassandraTask on the pods of its
+// CassandraDatacenter, a few pods at a time, and records the progress in the
+// status of the task.
+func (r *ReconcileCassandraTask) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.Background()
+	logger := log.
+		WithValues("requestNamespace", request.Namespace).
+		WithValues("requestName", request.Name)
+
+	task := &api.CassandraTask{}
+	if err := r.client.Get(ctx, request.NamespacedName, task); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("CassandraTask resource not found. Ignoring since object must be deleted.")
+			return result.Done().Output()
+		}
+		return result.Error(err).Output()
+	}
+
+	if task.Status.IsFinished() {
+		return result.Done().Output()
+	}
+
+	if key := task.GetDatacenterKey(); !r.shard.Owns(key.Namespace, key.Name) {
+		return result.Done().Output()
+	}
+
+	dc := &api.CassandraDatacenter{}
+	if err := r.client.Get(ctx, task.GetDatacenterKey(), dc); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("CassandraDatacenter for task not found, waiting for it to be created",
+				"datacenter", task.GetDatacenterKey())
+			return result.RequeueSoon(10).Output()
+		}
+		return result.Error(err).Output()
+	}
+
+	podLabels := dc.GetDatacenterLabels()
+	if task.Spec.Command == api.CommandRelocateRack {
+		if !hasRack(dc, task.Spec.Args.Rack) {
+			return r.failTask(ctx, task, fmt.Sprintf("rack %q is not a rack of datacenter %s", task.Spec.Args.Rack, dc.Name))
+		}
+		// Only the pods of the rack are relocated
+		podLabels = dc.GetRackLabels(task.Spec.Args.Rack)
+	}
+
+	podList := &corev1.PodList{}
+	listOptions := []client.ListOption{
+		client.InNamespace(dc.Namespace),
+		client.MatchingLabels(podLabels),
+	}
+	if err := r.client.List(ctx, podList, listOptions...); err != nil {
+		return result.Error(err).Output()
+	}
+
+	if task.Status.StartTime == nil && len(podList.Items) == 0 {
+		return r.failTask(ctx, task, fmt.Sprintf("no pods of datacenter %s match the task", dc.Name))
+	}
+
+	mgmtClient, err := httphelper.NewMgmtClient(ctx, r.client, dc, logger)
+	if err != nil {
+		return result.Error(err).Output()
+	}
+
+	patch := client.MergeFrom(task.DeepCopy())
+
+	if task.Status.StartTime == nil {
+		now := metav1.Now()
+		task.Status.StartTime = &now
+		task.Status.State = api.TaskStateRunning
+		initPodStatuses(task, podList.Items)
+		r.recorder.Eventf(task, corev1.EventTypeNormal, events.StartedTask,
+			"Running %s on %d pods of datacenter %s", task.Spec.Command, len(task.Status.Pods), dc.Name)
+	}
+
+	if err := failRemovedPods(ctx, r.client, task, podList.Items); err != nil {
+		return result.Error(err).Output()
+	}
+
+	pods := nextPods(task, podList.Items)
+	tracker := &jobtracker.Tracker{Client: r.client, MgmtClient: &mgmtClient}
+	runOnPods(ctx, logger, tracker, dc, task, pods)
+
+	finished := completeIfDone(task)
+	if finished {
+		if task.Status.State == api.TaskStateFailed {
+			r.recorder.Eventf(task, corev1.EventTypeWarning, events.FailedTask,
+				"Task %s failed on %d pods", task.Spec.Command, task.Status.Failed)
+		} else {
+			r.recorder.Eventf(task, corev1.EventTypeNormal, events.CompletedTask,
+				"Task %s completed on %d pods", task.Spec.Command, task.Status.Succeeded)
+		}
+	}
+
+	if err := r.client.Status().Patch(ctx, task, patch); err != nil {
+		logger.Error(err, "error patching CassandraTask status")
+		return result.Error(err).Output()
+	}
+
+	if finished {
+		return result.Done().Output()
+	}
+
+	if hasRunningPods(task) {
+		return result.RequeueSoon(jobPollSeconds).Output()
+	}
+
+	if len(pods) == 0 {
+		// Every remaining pod is waiting to become ready
+		return result.RequeueSoon(10).Output()
+	}
+
+	return result.RequeueSoon(1).Output()
+}
+
+func hasRack(dc *api.CassandraDatacenter, rackName string) bool {
+	for _, rack := range dc.GetRacks() {
+		if rack.Name == rackName {
+			return true
+		}
+	}
+	return false
+}
+
+// failTask marks a task that cannot run as failed
+func (r *ReconcileCassandraTask) failTask(ctx context.Context, task *api.CassandraTask, reason string) (reconcile.Result, error) {
+	patch := client.MergeFrom(task.DeepCopy())
+	now := metav1.Now()
+	task.Status.State = api.TaskStateFailed
+	task.Status.CompletionTime = &now
+	r.recorder.Eventf(task, corev1.EventTypeWarning, events.FailedTask,
+		"Task %s cannot run: %s", task.Spec.Command, reason)
+	if err := r.client.Status().Patch(ctx, task, patch); err != nil {
+		return result.Error(err).Output()
+	}
+	return result.Done().Output()
+}
+
+// initPodStatuses records the pods the task will run on, the pods it names or
+// else all of them. Pods created after the task started are not included, and
+// the pods it names that do not exist are marked as failed.
+func initPodStatuses(task *api.CassandraTask, pods []corev1.Pod) {
+	task.Status.Pods = make(map[string]api.CassandraTaskPodStatus, len(pods))
+	if len(task.Spec.Pods) == 0 {
+		for _, pod := range pods {
+			task.Status.Pods[pod.Name] = api.CassandraTaskPodStatus{State: api.TaskStatePending}
+		}
+		return
+	}
+
+	existing := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		existing[pod.Name] = true
+	}
+	for _, name := range task.Spec.Pods {
+		if existing[name] {
+			task.Status.Pods[name] = api.CassandraTaskPodStatus{State: api.TaskStatePending}
+			continue
+		}
+		now := metav1.Now()
+		task.Status.Pods[name] = api.CassandraTaskPodStatus{
+			State:          api.TaskStateFailed,
+			LastError:      fmt.Sprintf("pod %s is not a pod of the datacenter", name),
+			CompletionTime: &now,
+		}
+	}
+}
+
+// failRemovedPods fails the pods of the task that are gone for good, as they
+// would keep the task from ever finishing. A pod that is not listed may only
+// be recreated by its StatefulSet, as the restarts and relocations of the task
+// do; it is gone once its StatefulSet is, or was scaled down below it.
+func failRemovedPods(ctx context.Context, c client.Client, task *api.CassandraTask, pods []corev1.Pod) error {
+	listed := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		listed[pod.Name] = true
+	}
+
+	for name, status := range task.Status.Pods {
+		if listed[name] || (status.State != api.TaskStatePending && status.State != api.TaskStateRunning) {
+			continue
+		}
+
+		removed, err := isPodRemoved(ctx, c, task.GetDatacenterKey().Namespace, name)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			continue
+		}
+
+		now := metav1.Now()
+		status.State = api.TaskStateFailed
+		status.LastError = fmt.Sprintf("pod %s was removed from the datacenter", name)
+		status.CompletionTime = &now
+		task.Status.Pods[name] = status
+	}
+	return nil
+}
+
+// isPodRemoved tells whether the StatefulSet of the pod no longer has it
+func isPodRemoved(ctx context.Context, c client.Client, namespace string, podName string) (bool, error) {
+	ordinal := podOrdinal(podName)
+	if ordinal < 0 {
+		return true, nil
+	}
+
+	sts := &appsv1.StatefulSet{}
+	key := types.NamespacedName{Namespace: namespace, Name: podName[:strings.LastIndex(podName, "-")]}
+	if err := c.Get(ctx, key, sts); errors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	return int32(ordinal) >= replicas, nil
+}
+
+// nextPods returns the pods the command should run on next, limited by the
+// concurrency of the task. The pods the command is already running on come
+// first, then pending pods are picked in name order so the progress of a task
+// is predictable. The pods of a rack are relocated from the highest ordinal
+// down, the order the StatefulSet rolls them out to the new placement of the
+// rack, and whether or not they are ready, as the pods that were rolled out
+// already cannot start until they are relocated.
+func nextPods(task *api.CassandraTask, pods []corev1.Pod) []*corev1.Pod {
+	relocating := task.Spec.Command == api.CommandRelocateRack
+	sort.Slice(pods, func(i, j int) bool {
+		if relocating {
+			return podOrdinal(pods[i].Name) > podOrdinal(pods[j].Name)
+		}
+		return pods[i].Name < pods[j].Name
+	})
+
+	var next []*corev1.Pod
+	for i := range pods {
+		if status, ok := task.Status.Pods[pods[i].Name]; ok && status.State == api.TaskStateRunning {
+			next = append(next, &pods[i])
+		}
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		if len(next) >= task.GetConcurrency() {
+			break
+		}
+
+		status, ok := task.Status.Pods[pod.Name]
+		if !ok || status.State != api.TaskStatePending {
+			continue
+		}
+
+		if relocating || isPodStarted(pod) {
+			next = append(next, pod)
+		}
+	}
+
+	return next
+}
+
+// isPodStarted reports whether the node of the pod started and is ready. The
+// readiness probe alone does not tell a node that is still bootstrapping
+// apart from a started one.
+func isPodStarted(pod *corev1.Pod) bool {
+	return pod.Labels[api.CassNodeState] == "Started" && utils.IsCassandraContainerReady(pod)
+}
+
+// runOnPods starts the command of the task on the given pods in parallel, or
+// checks on the jobs it is running as, and records the outcome for each of
+// them once it is done.
+func runOnPods(ctx context.Context, logger logr.Logger, tracker *jobtracker.Tracker, dc *api.CassandraDatacenter, task *api.CassandraTask, pods []*corev1.Pod) {
+	done := make([]bool, len(pods))
+	deleted := make([]bool, len(pods))
+	outputs := make([]string, len(pods))
+	errs := make([]error, len(pods))
+
+	var wg sync.WaitGroup
+	for i, pod := range pods {
+		wg.Add(1)
+		go func(i int, pod *corev1.Pod) {
+			defer wg.Done()
+			if task.ReturnsOutput() {
+				done[i] = true
+				outputs[i], errs[i] = readOutput(tracker.MgmtClient, task, pod)
+				return
+			}
+			if task.Spec.Command == api.CommandRestart {
+				done[i], deleted[i], errs[i] = restartPod(ctx, tracker, task, pod)
+				return
+			}
+			if task.Spec.Command == api.CommandRelocateRack {
+				done[i], deleted[i], errs[i] = relocatePod(ctx, logger, tracker, dc, task, pod)
+				return
+			}
+			done[i], errs[i] = runCommand(ctx, tracker, task, pod)
+		}(i, pod)
+	}
+	wg.Wait()
+
+	for i, pod := range pods {
+		if done[i] {
+			recordResult(task, pod.Name, errs[i])
+			if errs[i] == nil && outputs[i] != "" {
+				recordOutput(task, pod.Name, outputs[i])
+			}
+			continue
+		}
+
+		if errs[i] != nil {
+			logger.Error(errs[i], "error checking on the job of the task", "pod", pod.Name)
+		}
+		status := task.Status.Pods[pod.Name]
+		status.State = api.TaskStateRunning
+		// The pod that is draining, or failed to be relocated, is deleted later
+		if deleted[i] {
+			status.RestartedUID = pod.UID
+		}
+		task.Status.Pods[pod.Name] = status
+	}
+}
+
+// runCommand starts the command of the task on the pod, or checks on the job
+// it is running as, and returns true once it is done
+func runCommand(ctx context.Context, tracker *jobtracker.Tracker, task *api.CassandraTask, pod *corev1.Pod) (bool, error) {
+	operation, err := taskOperation(tracker.MgmtClient, task, pod)
+	if err != nil {
+		return true, err
+	}
+	return tracker.Poll(ctx, pod, operation)
+}
+
+// restartPod drains the node of the pod in the background and deletes the
+// pod once it is drained, then waits for the pod that replaces it to be
+// started and ready. It returns true once the new pod is ready, and whether
+// it deleted the pod.
+func restartPod(ctx context.Context, tracker *jobtracker.Tracker, task *api.CassandraTask, pod *corev1.Pod) (bool, bool, error) {
+	status := task.Status.Pods[pod.Name]
+	if status.RestartedUID == "" {
+		drained, err := tracker.Poll(ctx, pod, jobtracker.Operation{
+			Key: "task-" + string(task.UID),
+			Run: func() error {
+				return tracker.MgmtClient.CallDrainEndpoint(pod)
+			},
+		})
+		if !drained || err != nil {
+			return drained, false, err
+		}
+		if err := tracker.Client.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+			return true, false, err
+		}
+		return false, true, nil
+	}
+
+	if pod.UID == status.RestartedUID {
+		// The pod is still terminating
+		return false, false, nil
+	}
+	return isPodStarted(pod), false, nil
+}
+
+// relocatePod drains the node of the pod in the background, then replaces it
+// through the replaceNodes of the datacenter, like a node whose volume failed:
+// its claims and itself are deleted, so that the new pod gets new volumes
+// wherever the rack is placed now, and its node takes over the token ranges
+// of the old one. It returns true once the node was replaced, and whether it
+// deleted the pod. The errors of the API server are retried on the next
+// reconciliation.
+func relocatePod(ctx context.Context, logger logr.Logger, tracker *jobtracker.Tracker, dc *api.CassandraDatacenter, task *api.CassandraTask, pod *corev1.Pod) (bool, bool, error) {
+	status := task.Status.Pods[pod.Name]
+	if status.RestartedUID == "" {
+		drained, err := tracker.Poll(ctx, pod, jobtracker.Operation{
+			Key: "task-" + string(task.UID),
+			Run: func() error {
+				return tracker.MgmtClient.CallDrainEndpoint(pod)
+			},
+		})
+		if !drained || err != nil {
+			return drained, false, err
+		}
+		if err := reconciliation.ReplaceNode(ctx, tracker.Client, *tracker.MgmtClient, logger, dc, pod); err != nil {
+			return false, false, err
+		}
+		return false, true, nil
+	}
+
+	if pod.UID == status.RestartedUID {
+		// The pod is still terminating
+		return false, false, nil
+	}
+	if utils.IndexOfString(dc.Spec.ReplaceNodes, pod.Name) > -1 || utils.IndexOfString(dc.Status.NodeReplacements, pod.Name) > -1 {
+		return false, false, nil
+	}
+	return isPodStarted(pod), false, nil
+}
+
+// podOrdinal returns the ordinal of a pod of a StatefulSet from its name
+func podOrdinal(name string) int {
+	ordinal, err := strconv.Atoi(name[strings.LastIndex(name, "-")+1:])
+	if err != nil {
+		return -1
+	}
+	return ordinal
+}
+
+// readOutput runs the command of the task that reads from the node, and
+// returns what the node answered
+func readOutput(mgmtClient *httphelper.NodeMgmtClient, task *api.CassandraTask, pod *corev1.Pod) (string, error) {
+	switch task.Spec.Command {
+	case api.CommandCompactionStats:
+		return mgmtClient.CallCompactionsEndpoint(pod)
+	}
+	return "", fmt.Errorf("task command %s has no output", task.Spec.Command)
+}
+
+// taskOperation returns the management API calls of the command of the task
+func taskOperation(mgmtClient *httphelper.NodeMgmtClient, task *api.CassandraTask, pod *corev1.Pod) (jobtracker.Operation, error) {
+	args := task.Spec.Args
+	jobs := -1
+	if args.Jobs != nil {
+		jobs = *args.Jobs
+	}
+
+	// The UID of the task keeps the jobs of tasks running the same command apart
+	operation := jobtracker.Operation{Key: "task-" + string(task.UID)}
+
+	switch task.Spec.Command {
+	case api.CommandCleanup:
+		operation.Submit = func() (string, error) {
+			return mgmtClient.CallKeyspaceCleanupAsyncEndpoint(pod, jobs, args.KeyspaceName, args.Tables)
+		}
+		operation.Run = func() error {
+			return mgmtClient.CallKeyspaceCleanupEndpoint(pod, jobs, args.KeyspaceName, args.Tables)
+		}
+	case api.CommandRebuild:
+		operation.Submit = func() (string, error) {
+			return mgmtClient.CallRebuildAsyncEndpoint(pod, args.SourceDatacenter)
+		}
+		operation.Run = func() error {
+			return mgmtClient.CallRebuildEndpoint(pod, args.SourceDatacenter)
+		}
+	case api.CommandUpgradeSSTables:
+		operation.Submit = func() (string, error) {
+			return mgmtClient.CallUpgradeSSTablesAsyncEndpoint(pod, jobs, args.KeyspaceName, args.Tables)
+		}
+		operation.Run = func() error {
+			return mgmtClient.CallUpgradeSSTablesEndpoint(pod, jobs, args.KeyspaceName, args.Tables)
+		}
+	case api.CommandFlush:
+		operation.Submit = func() (string, error) {
+			return mgmtClient.CallFlushAsyncEndpoint(pod, args.KeyspaceName, args.Tables)
+		}
+		operation.Run = func() error {
+			return mgmtClient.CallFlushEndpoint(pod, args.KeyspaceName, args.Tables)
+		}
+	case api.CommandGarbageCollect:
+		operation.Submit = func() (string, error) {
+			return mgmtClient.CallGarbageCollectAsyncEndpoint(pod, jobs, args.KeyspaceName, args.Tables)
+		}
+		operation.Run = func() error {
+			return mgmtClient.CallGarbageCollectEndpoint(pod, jobs, args.KeyspaceName, args.Tables)
+		}
+	case api.CommandCompact:
+		operation.Submit = func() (string, error) {
+			return mgmtClient.CallCompactAsyncEndpoint(pod, args.KeyspaceName, args.Tables)
+		}
+		operation.Run = func() error {
+			return mgmtClient.CallCompactEndpoint(pod, args.KeyspaceName, args.Tables)
+		}
+	default:
+		return operation, fmt.Errorf("unknown task command %s", task.Spec.Command)
+	}
+
+	return operation, nil
+}
+
+// hasRunningPods tells whether the command is running as a job on any pod
+func hasRunningPods(task *api.CassandraTask) bool {
+	for _, status := range task.Status.Pods {
+		if status.State == api.TaskStateRunning {
+			return true
+		}
+	}
+	return false
+}
+
+func recordResult(task *api.CassandraTask, podName string, err error) {
+	status := task.Status.Pods[podName]
+	status.Attempts++
+
+	if err == nil {
+		now := metav1.Now()
+		status.State = api.TaskStateSucceeded
+		status.LastError = ""
+		status.CompletionTime = &now
+	} else {
+		status.LastError = err.Error()
+		status.State = api.TaskStatePending
+		if status.Attempts > task.Spec.MaxRetries {
+			now := metav1.Now()
+			status.State = api.TaskStateFailed
+			status.CompletionTime = &now
+		}
+	}
+
+	task.Status.Pods[podName] = status
+}
+
+// recordOutput keeps the output of the command on the pod, truncated to
+// maxOutputLength
+func recordOutput(task *api.CassandraTask, podName string, output string) {
+	if len(output) > maxOutputLength {
+		output = output[:maxOutputLength]
+	}
+	status := task.Status.Pods[podName]
+	status.Output = output
+	task.Status.Pods[podName] = status
+}
+
+// completeIfDone updates the counters of the task, and marks it as finished
+// once every pod has either succeeded or failed.
+func completeIfDone(task *api.CassandraTask) bool {
+	succeeded, failed := 0, 0
+	for _, status := range task.Status.Pods {
+		switch status.State {
+		case api.TaskStateSucceeded:
+			succeeded++
+		case api.TaskStateFailed:
+			failed++
+		}
+	}
+	task.Status.Succeeded = succeeded
+	task.Status.Failed = failed
+
+	if succeeded+failed < len(task.Status.Pods) {
+		return false
+	}
+
+	now := metav1.Now()
+	task.Status.CompletionTime = &now
+	if failed > 0 {
+		task.Status.State = api.TaskStateFailed
+	} else {
+		task.Status.State = api.TaskStateSucceeded
+	}
+	return true
+}
@@ -0,0 +1,550 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package cassandratask
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/jobtracker"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+func makePod(name string, ready bool) corev1.Pod {
+	state := "Started"
+	if !ready {
+		state = "Ready-to-Start"
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{api.CassNodeState: state},
+		},
+		Status: corev1.PodStatus{
+			PodIP: "1.2.3.4",
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "cassandra", Ready: ready},
+			},
+		},
+	}
+}
+
+func makeTask(concurrency, maxRetries int) *api.CassandraTask {
+	return &api.CassandraTask{
+		ObjectMeta: metav1.ObjectMeta{Name: "cleanup", Namespace: "default"},
+		Spec: api.CassandraTaskSpec{
+			Datacenter:  corev1.ObjectReference{Name: "dc1"},
+			Command:     api.CommandCleanup,
+			Concurrency: concurrency,
+			MaxRetries:  maxRetries,
+		},
+	}
+}
+
+func TestGetDatacenterKey(t *testing.T) {
+	task := makeTask(1, 0)
+	assert.Equal(t, "default", task.GetDatacenterKey().Namespace)
+	assert.Equal(t, "dc1", task.GetDatacenterKey().Name)
+
+	task.Spec.Datacenter.Namespace = "other"
+	assert.Equal(t, "other", task.GetDatacenterKey().Namespace)
+}
+
+func TestNextPods(t *testing.T) {
+	pods := []corev1.Pod{
+		makePod("pod-c", true),
+		makePod("pod-b", false),
+		makePod("pod-a", true),
+		makePod("pod-d", true),
+	}
+
+	task := makeTask(2, 0)
+	initPodStatuses(task, pods)
+
+	next := nextPods(task, pods)
+	assert.Equal(t, 2, len(next))
+	assert.Equal(t, "pod-a", next[0].Name)
+	assert.Equal(t, "pod-c", next[1].Name)
+
+	recordResult(task, "pod-a", nil)
+	recordResult(task, "pod-c", nil)
+
+	next = nextPods(task, pods)
+	assert.Equal(t, 1, len(next), "pod-b is not ready and should be skipped")
+	assert.Equal(t, "pod-d", next[0].Name)
+}
+
+func TestNextPods_SkipsNodesNotStarted(t *testing.T) {
+	pod := makePod("pod-a", true)
+	pod.Labels[api.CassNodeState] = "Starting"
+
+	task := makeTask(1, 0)
+	initPodStatuses(task, []corev1.Pod{pod})
+	assert.Equal(t, 0, len(nextPods(task, []corev1.Pod{pod})), "a ready node still starting should be skipped")
+}
+
+func TestNextPods_IgnoresNewPods(t *testing.T) {
+	pods := []corev1.Pod{makePod("pod-a", true)}
+
+	task := makeTask(1, 0)
+	initPodStatuses(task, pods)
+	recordResult(task, "pod-a", nil)
+
+	pods = append(pods, makePod("pod-b", true))
+	assert.Equal(t, 0, len(nextPods(task, pods)))
+}
+
+func TestInitPodStatuses_SelectedPods(t *testing.T) {
+	pods := []corev1.Pod{makePod("pod-a", true), makePod("pod-b", true)}
+
+	task := makeTask(1, 0)
+	task.Spec.Pods = []string{"pod-b", "pod-c"}
+	initPodStatuses(task, pods)
+
+	assert.Equal(t, 2, len(task.Status.Pods))
+	assert.Equal(t, api.TaskStatePending, task.Status.Pods["pod-b"].State)
+	assert.Equal(t, api.TaskStateFailed, task.Status.Pods["pod-c"].State, "a pod that does not exist should fail")
+	assert.NotEmpty(t, task.Status.Pods["pod-c"].LastError)
+
+	next := nextPods(task, pods)
+	assert.Equal(t, 1, len(next))
+	assert.Equal(t, "pod-b", next[0].Name)
+
+	recordResult(task, "pod-b", nil)
+	assert.True(t, completeIfDone(task))
+	assert.Equal(t, api.TaskStateFailed, task.Status.State)
+}
+
+func TestRecordResult_Retries(t *testing.T) {
+	pods := []corev1.Pod{makePod("pod-a", true)}
+
+	task := makeTask(1, 1)
+	initPodStatuses(task, pods)
+
+	recordResult(task, "pod-a", fmt.Errorf("boom"))
+	status := task.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStatePending, status.State)
+	assert.Equal(t, 1, status.Attempts)
+	assert.Equal(t, "boom", status.LastError)
+	assert.False(t, completeIfDone(task))
+
+	recordResult(task, "pod-a", fmt.Errorf("boom"))
+	status = task.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStateFailed, status.State)
+	assert.Equal(t, 2, status.Attempts)
+	assert.NotNil(t, status.CompletionTime)
+
+	assert.True(t, completeIfDone(task))
+	assert.Equal(t, api.TaskStateFailed, task.Status.State)
+	assert.Equal(t, 1, task.Status.Failed)
+	assert.Equal(t, 0, task.Status.Succeeded)
+}
+
+func TestCompleteIfDone(t *testing.T) {
+	pods := []corev1.Pod{makePod("pod-a", true), makePod("pod-b", true)}
+
+	task := makeTask(1, 0)
+	initPodStatuses(task, pods)
+
+	recordResult(task, "pod-a", nil)
+	assert.False(t, completeIfDone(task))
+	assert.Equal(t, 1, task.Status.Succeeded)
+
+	recordResult(task, "pod-b", nil)
+	assert.True(t, completeIfDone(task))
+	assert.Equal(t, api.TaskStateSucceeded, task.Status.State)
+	assert.Equal(t, 2, task.Status.Succeeded)
+	assert.NotNil(t, task.Status.CompletionTime)
+}
+
+// mockEndpoint makes the mock answer the requests to the path once
+func mockEndpoint(mockHttpClient *mocks.HttpClient, path string, statusCode int, body string) {
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == path
+			})).
+		Return(&http.Response{
+			StatusCode: statusCode,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil).
+		Once()
+}
+
+func makeTracker(mockHttpClient *mocks.HttpClient, pod *corev1.Pod) *jobtracker.Tracker {
+	return &jobtracker.Tracker{
+		Client: fake.NewFakeClient(pod),
+		MgmtClient: &httphelper.NodeMgmtClient{
+			Client:   mockHttpClient,
+			Log:      zap.Logger(true),
+			Protocol: "http",
+		},
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	tests := []struct {
+		command  api.CassandraTaskCommand
+		args     api.CassandraTaskArgs
+		endpoint string
+	}{
+		{api.CommandCleanup, api.CassandraTaskArgs{}, "/api/v1/ops/keyspace/cleanup"},
+		{api.CommandFlush, api.CassandraTaskArgs{}, "/api/v1/ops/tables/flush"},
+		{api.CommandGarbageCollect, api.CassandraTaskArgs{}, "/api/v1/ops/tables/garbagecollect"},
+		{api.CommandUpgradeSSTables, api.CassandraTaskArgs{}, "/api/v1/ops/tables/sstables/upgrade"},
+		{api.CommandRebuild, api.CassandraTaskArgs{SourceDatacenter: "dc2"}, "/api/v1/ops/node/rebuild"},
+		{api.CommandCompact, api.CassandraTaskArgs{KeyspaceName: "ks1", Tables: []string{"t1"}}, "/api/v1/ops/tables/compact"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.command), func(t *testing.T) {
+			mockHttpClient := &mocks.HttpClient{}
+			mockEndpoint(mockHttpClient, tt.endpoint, http.StatusAccepted, "job-1")
+			mockEndpoint(mockHttpClient, "/api/v0/ops/executor/job", http.StatusOK, `{"id": "job-1", "status": "WAITING"}`)
+			mockEndpoint(mockHttpClient, "/api/v0/ops/executor/job", http.StatusOK, `{"id": "job-1", "status": "COMPLETED"}`)
+
+			task := makeTask(1, 0)
+			task.UID = "task-uid"
+			task.Spec.Command = tt.command
+			task.Spec.Args = tt.args
+
+			pod := makePod("pod-a", true)
+			tracker := makeTracker(mockHttpClient, &pod)
+
+			// Submitting the job annotates the pod with its ID
+			done, err := runCommand(context.Background(), tracker, task, &pod)
+			assert.NoError(t, err)
+			assert.False(t, done)
+			assert.Equal(t, "job-1", jobtracker.JobID(&pod, "task-task-uid"))
+
+			done, err = runCommand(context.Background(), tracker, task, &pod)
+			assert.NoError(t, err)
+			assert.False(t, done)
+
+			done, err = runCommand(context.Background(), tracker, task, &pod)
+			assert.NoError(t, err)
+			assert.True(t, done)
+			assert.Empty(t, jobtracker.JobID(&pod, "task-task-uid"))
+			mockHttpClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunCommand_FailedJob(t *testing.T) {
+	mockHttpClient := &mocks.HttpClient{}
+	mockEndpoint(mockHttpClient, "/api/v0/ops/executor/job", http.StatusOK, `{"id": "job-1", "status": "ERROR", "error": "boom"}`)
+
+	task := makeTask(1, 0)
+	pod := makePod("pod-a", true)
+	pod.Annotations = map[string]string{jobtracker.AnnotationName("task-"): "job-1"}
+	tracker := makeTracker(mockHttpClient, &pod)
+
+	done, err := runCommand(context.Background(), tracker, task, &pod)
+	assert.True(t, done)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+	assert.Empty(t, jobtracker.JobID(&pod, "task-"))
+}
+
+func TestRunCommand_InBackgroundWithoutJobs(t *testing.T) {
+	mockHttpClient := &mocks.HttpClient{}
+	mockEndpoint(mockHttpClient, "/api/v1/ops/keyspace/cleanup", http.StatusNotFound, "")
+	mockEndpoint(mockHttpClient, "/api/v0/ops/keyspace/cleanup", http.StatusOK, "OK")
+
+	task := makeTask(1, 0)
+	pod := makePod("pod-a", true)
+	tracker := makeTracker(mockHttpClient, &pod)
+
+	done, err := runCommand(context.Background(), tracker, task, &pod)
+	assert.NoError(t, err)
+	assert.False(t, done, "the command runs in the background rather than block the reconciliation")
+
+	for i := 0; i < 100 && !done; i++ {
+		time.Sleep(10 * time.Millisecond)
+		done, err = runCommand(context.Background(), tracker, task, &pod)
+	}
+	assert.NoError(t, err)
+	assert.True(t, done)
+	mockHttpClient.AssertExpectations(t)
+}
+
+func TestRunCommand_RebuildRequiresSource(t *testing.T) {
+	task := makeTask(1, 0)
+	task.Spec.Command = api.CommandRebuild
+
+	pod := makePod("pod-a", true)
+	done, err := runCommand(context.Background(), makeTracker(&mocks.HttpClient{}, &pod), task, &pod)
+	assert.True(t, done)
+	assert.Error(t, err)
+}
+
+func TestNextPods_RunningPodsFirst(t *testing.T) {
+	pods := []corev1.Pod{makePod("pod-a", true), makePod("pod-b", true)}
+
+	task := makeTask(1, 0)
+	initPodStatuses(task, pods)
+	task.Status.Pods["pod-b"] = api.CassandraTaskPodStatus{State: api.TaskStateRunning}
+
+	next := nextPods(task, pods)
+	assert.Equal(t, 1, len(next))
+	assert.Equal(t, "pod-b", next[0].Name)
+	assert.True(t, hasRunningPods(task))
+}
+
+func TestRunOnPods_Output(t *testing.T) {
+	mockHttpClient := &mocks.HttpClient{}
+	mockEndpoint(mockHttpClient, "/api/v0/ops/tables/compactions", http.StatusOK, `[{"keyspace": "ks1", "columnfamily": "t1"}]`)
+
+	task := makeTask(1, 0)
+	task.Spec.Command = api.CommandCompactionStats
+	pod := makePod("pod-a", true)
+	initPodStatuses(task, []corev1.Pod{pod})
+
+	runOnPods(context.Background(), zap.Logger(true), makeTracker(mockHttpClient, &pod), nil, task, []*corev1.Pod{&pod})
+	status := task.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStateSucceeded, status.State)
+	assert.Equal(t, `[{"keyspace": "ks1", "columnfamily": "t1"}]`, status.Output)
+	mockHttpClient.AssertExpectations(t)
+
+	recordOutput(task, "pod-a", strings.Repeat("x", maxOutputLength+1))
+	assert.Equal(t, maxOutputLength, len(task.Status.Pods["pod-a"].Output))
+}
+
+func TestRunOnPods_Restart(t *testing.T) {
+	mockHttpClient := &mocks.HttpClient{}
+	mockEndpoint(mockHttpClient, "/api/v0/ops/node/drain", http.StatusOK, "OK")
+
+	task := makeTask(1, 0)
+	task.Spec.Command = api.CommandRestart
+	pod := makePod("pod-a", true)
+	pod.UID = "uid-1"
+	initPodStatuses(task, []corev1.Pod{pod})
+	tracker := makeTracker(mockHttpClient, &pod)
+	logger := zap.Logger(true)
+
+	// The node is drained in the background, then the pod deleted
+	runOnPods(context.Background(), logger, tracker, nil, task, []*corev1.Pod{&pod})
+	status := task.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStateRunning, status.State)
+	assert.Empty(t, status.RestartedUID, "the pod is only deleted once the node is drained")
+
+	for i := 0; i < 100 && status.RestartedUID == ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+		runOnPods(context.Background(), logger, tracker, nil, task, []*corev1.Pod{&pod})
+		status = task.Status.Pods["pod-a"]
+	}
+	assert.Equal(t, api.TaskStateRunning, status.State)
+	assert.Equal(t, pod.UID, status.RestartedUID)
+	err := tracker.Client.Get(context.Background(), client.ObjectKey{Name: "pod-a"}, &corev1.Pod{})
+	assert.True(t, errors.IsNotFound(err), "the pod should be deleted")
+	mockHttpClient.AssertExpectations(t)
+
+	// The pod is still terminating
+	runOnPods(context.Background(), logger, tracker, nil, task, []*corev1.Pod{&pod})
+	assert.Equal(t, api.TaskStateRunning, task.Status.Pods["pod-a"].State)
+
+	replacement := makePod("pod-a", false)
+	replacement.UID = "uid-2"
+	runOnPods(context.Background(), logger, tracker, nil, task, []*corev1.Pod{&replacement})
+	assert.Equal(t, api.TaskStateRunning, task.Status.Pods["pod-a"].State, "the new pod is not ready yet")
+
+	replacement = makePod("pod-a", true)
+	replacement.UID = "uid-2"
+	runOnPods(context.Background(), logger, tracker, nil, task, []*corev1.Pod{&replacement})
+	assert.Equal(t, api.TaskStateSucceeded, task.Status.Pods["pod-a"].State)
+	assert.True(t, completeIfDone(task))
+}
+
+func TestNextPods_RelocateRack(t *testing.T) {
+	pods := []corev1.Pod{
+		makePod("cluster1-dc1-r1-sts-0", true),
+		makePod("cluster1-dc1-r1-sts-2", false),
+		makePod("cluster1-dc1-r1-sts-1", true),
+	}
+
+	task := makeTask(3, 0)
+	task.Spec.Command = api.CommandRelocateRack
+	initPodStatuses(task, pods)
+
+	// One pod at a time from the highest ordinal, even if it is not ready
+	next := nextPods(task, pods)
+	assert.Equal(t, 1, len(next))
+	assert.Equal(t, "cluster1-dc1-r1-sts-2", next[0].Name)
+}
+
+func TestRunOnPods_RelocateRack(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(api.SchemeGroupVersion, &api.CassandraDatacenter{})
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockEndpoint(mockHttpClient, "/api/v0/ops/node/drain", http.StatusOK, "OK")
+
+	dc := &api.CassandraDatacenter{ObjectMeta: metav1.ObjectMeta{Name: "dc1"}}
+	pod := makePod("pod-a", true)
+	pod.UID = "uid-1"
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "server-data-pod-a",
+			Finalizers: []string{api.DeletionProtectionFinalizer},
+		},
+	}
+	tracker := makeTracker(mockHttpClient, &pod)
+	tracker.Client = fake.NewFakeClientWithScheme(s, dc, &pod, pvc)
+	logger := zap.Logger(true)
+
+	task := makeTask(1, 0)
+	task.Spec.Command = api.CommandRelocateRack
+	initPodStatuses(task, []corev1.Pod{pod})
+
+	// The node is drained in the background before anything is deleted
+	runOnPods(context.Background(), logger, tracker, dc, task, []*corev1.Pod{&pod})
+	status := task.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStateRunning, status.State)
+	assert.Empty(t, status.RestartedUID, "the node is only replaced once it is drained")
+	assert.NoError(t, tracker.Client.Get(context.Background(), client.ObjectKey{Name: "server-data-pod-a"}, &corev1.PersistentVolumeClaim{}))
+
+	// Then it is marked for replacement, and its claims and pod deleted
+	for i := 0; i < 100 && status.RestartedUID == ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+		runOnPods(context.Background(), logger, tracker, dc, task, []*corev1.Pod{&pod})
+		status = task.Status.Pods["pod-a"]
+	}
+	assert.Equal(t, api.TaskStateRunning, status.State)
+	assert.Equal(t, pod.UID, status.RestartedUID)
+	assert.Equal(t, []string{"pod-a"}, dc.Spec.ReplaceNodes)
+	err := tracker.Client.Get(context.Background(), client.ObjectKey{Name: "server-data-pod-a"}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, errors.IsNotFound(err), "the claim should be deleted")
+	err = tracker.Client.Get(context.Background(), client.ObjectKey{Name: "pod-a"}, &corev1.Pod{})
+	assert.True(t, errors.IsNotFound(err), "the pod should be deleted")
+	mockHttpClient.AssertExpectations(t)
+
+	// The new pod is started, but the node is still being replaced
+	replacement := makePod("pod-a", true)
+	replacement.UID = "uid-2"
+	dc.Spec.ReplaceNodes = nil
+	dc.Status.NodeReplacements = []string{"pod-a"}
+	runOnPods(context.Background(), logger, tracker, dc, task, []*corev1.Pod{&replacement})
+	assert.Equal(t, api.TaskStateRunning, task.Status.Pods["pod-a"].State)
+
+	dc.Status.NodeReplacements = nil
+	runOnPods(context.Background(), logger, tracker, dc, task, []*corev1.Pod{&replacement})
+	assert.Equal(t, api.TaskStateSucceeded, task.Status.Pods["pod-a"].State)
+}
+
+func TestRunOnPods_RelocateRackRetriesErrors(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(api.SchemeGroupVersion, &api.CassandraDatacenter{})
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockEndpoint(mockHttpClient, "/api/v0/ops/node/drain", http.StatusOK, "OK")
+	mockEndpoint(mockHttpClient, "/api/v0/ops/node/drain", http.StatusOK, "OK")
+
+	dc := &api.CassandraDatacenter{ObjectMeta: metav1.ObjectMeta{Name: "dc1"}}
+	pod := makePod("pod-a", true)
+	pod.UID = "uid-1"
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "server-data-pod-a"}}
+	// The datacenter cannot be updated yet
+	tracker := makeTracker(mockHttpClient, &pod)
+	tracker.Client = fake.NewFakeClientWithScheme(s, &pod, pvc)
+	logger := zap.Logger(true)
+
+	task := makeTask(1, 0)
+	task.Spec.Command = api.CommandRelocateRack
+	initPodStatuses(task, []corev1.Pod{pod})
+
+	runUntilDrained := func() {
+		for i := 0; i < 100; i++ {
+			runOnPods(context.Background(), logger, tracker, dc, task, []*corev1.Pod{&pod})
+			if jobtracker.JobID(&pod, "task-"+string(task.UID)) == "" && i > 0 {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	runUntilDrained()
+	status := task.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStateRunning, status.State, "the error should be retried rather than fail the pod")
+	assert.Empty(t, status.RestartedUID)
+	assert.NoError(t, tracker.Client.Get(context.Background(), client.ObjectKey{Name: "pod-a"}, &corev1.Pod{}))
+
+	assert.NoError(t, tracker.Client.Create(context.Background(), dc))
+	runUntilDrained()
+	status = task.Status.Pods["pod-a"]
+	assert.Equal(t, api.TaskStateRunning, status.State)
+	assert.Equal(t, pod.UID, status.RestartedUID)
+	err := tracker.Client.Get(context.Background(), client.ObjectKey{Name: "pod-a"}, &corev1.Pod{})
+	assert.True(t, errors.IsNotFound(err), "the pod should be deleted")
+	mockHttpClient.AssertExpectations(t)
+}
+
+func TestReconcile_FailsWithoutPods(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(api.SchemeGroupVersion, &api.CassandraDatacenter{}, &api.CassandraTask{})
+
+	dc := &api.CassandraDatacenter{ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "default"}}
+	task := makeTask(1, 0)
+	r := &ReconcileCassandraTask{
+		client:   fake.NewFakeClientWithScheme(s, dc, task),
+		scheme:   s,
+		recorder: record.NewFakeRecorder(100),
+	}
+
+	key := types.NamespacedName{Name: "cleanup", Namespace: "default"}
+	_, err := r.Reconcile(reconcile.Request{NamespacedName: key})
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.client.Get(context.Background(), key, task))
+	assert.Equal(t, api.TaskStateFailed, task.Status.State, "a task that matches no pods should fail")
+	assert.NotNil(t, task.Status.CompletionTime)
+}
+
+func TestFailRemovedPods(t *testing.T) {
+	replicas := int32(2)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1-dc1-r1-sts", Namespace: "default"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, sts)
+
+	task := makeTask(1, 0)
+	task.Status.Pods = map[string]api.CassandraTaskPodStatus{
+		"cluster1-dc1-r1-sts-0": {State: api.TaskStatePending},
+		"cluster1-dc1-r1-sts-1": {State: api.TaskStateRunning},
+		"cluster1-dc1-r1-sts-2": {State: api.TaskStatePending},
+		"cluster1-dc1-r2-sts-0": {State: api.TaskStateRunning},
+		"cluster1-dc1-r2-sts-1": {State: api.TaskStateSucceeded},
+	}
+	pods := []corev1.Pod{makePod("cluster1-dc1-r1-sts-0", true)}
+
+	assert.NoError(t, failRemovedPods(context.Background(), c, task, pods))
+	assert.Equal(t, api.TaskStatePending, task.Status.Pods["cluster1-dc1-r1-sts-0"].State)
+	assert.Equal(t, api.TaskStateRunning, task.Status.Pods["cluster1-dc1-r1-sts-1"].State, "the pod is recreated by its StatefulSet")
+	assert.Equal(t, api.TaskStateFailed, task.Status.Pods["cluster1-dc1-r1-sts-2"].State, "the StatefulSet was scaled down")
+	assert.Contains(t, task.Status.Pods["cluster1-dc1-r1-sts-2"].LastError, "was removed from the datacenter")
+	assert.Equal(t, api.TaskStateFailed, task.Status.Pods["cluster1-dc1-r2-sts-0"].State, "the StatefulSet was deleted")
+	assert.Equal(t, api.TaskStateSucceeded, task.Status.Pods["cluster1-dc1-r2-sts-1"].State)
+	assert.False(t, completeIfDone(task), "the pods that are still there keep the task running")
+}
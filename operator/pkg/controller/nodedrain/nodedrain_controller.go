@@ -0,0 +1,410 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package nodedrain
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/requeue"
+	"github.com/k8ssandra/cass-operator/operator/pkg/sharding"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+	"github.com/k8ssandra/cass-operator/operator/pkg/watchnamespace"
+)
+
+var log = logf.Log.WithName("nodedrain_controller")
+
+// EnabledEnv is the environment variable of the operator that turns the
+// draining of the nodes of cordoned workers on
+const EnabledEnv = "DRAIN_NODES_OF_CORDONED_WORKERS"
+
+// enabledFromEnv returns whether the controller is turned on. It is off unless
+// EnabledEnv is set to true.
+func enabledFromEnv() (bool, error) {
+	value := os.Getenv(EnabledEnv)
+	if value == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("bad value for %s env: %v", EnabledEnv, err)
+	}
+	return enabled, nil
+}
+
+// Add creates a new node drain Controller and adds it to the Manager, when
+// EnabledEnv turns it on. The Manager will set fields on the Controller and
+// Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	if enabled, err := enabledFromEnv(); err != nil || !enabled {
+		return err
+	}
+	selector, err := watchnamespace.GetSelector()
+	if err != nil {
+		return err
+	}
+	shard, err := sharding.FromEnv()
+	if err != nil {
+		return err
+	}
+	return add(mgr, newReconciler(mgr, watchnamespace.NewFilter(mgr.GetClient(), selector), shard))
+}
+
+func newReconciler(mgr manager.Manager, namespaceFilter *watchnamespace.Filter, shard *sharding.Shard) reconcile.Reconciler {
+	return &ReconcileNodeDrain{
+		client:          mgr.GetClient(),
+		scheme:          mgr.GetScheme(),
+		recorder:        mgr.GetEventRecorderFor("cass-operator"),
+		namespaceFilter: namespaceFilter,
+		shard:           shard,
+		newMgmtClient:   httphelper.NewMgmtClient,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	requeuePolicy, err := requeue.FromEnv()
+	if err != nil {
+		return err
+	}
+	c, err := controller.New(
+		"nodedrain-controller",
+		mgr,
+		controller.Options{Reconciler: requeue.Wrap(r, requeuePolicy)})
+	if err != nil {
+		return err
+	}
+
+	// Only cordoning and uncordoning k8s workers matters. Cordoned workers are
+	// also picked up when the operator starts.
+	unschedulableChangedPredicate := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			node, ok := e.Object.(*corev1.Node)
+			return ok && node.Spec.Unschedulable
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			nodeOld, ok1 := e.ObjectOld.(*corev1.Node)
+			nodeNew, ok2 := e.ObjectNew.(*corev1.Node)
+			if !ok1 || !ok2 {
+				log.Error(nil, "Failed to cast update.Event objects to type Node", "objectOld", e.ObjectOld, "objectNew", e.ObjectNew)
+				return true
+			}
+			return nodeOld.Spec.Unschedulable != nodeNew.Spec.Unschedulable
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+
+	return c.Watch(
+		&source.Kind{Type: &corev1.Node{}},
+		&handler.EnqueueRequestForObject{},
+		unschedulableChangedPredicate)
+}
+
+// blank assignment to verify that ReconcileNodeDrain implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileNodeDrain{}
+
+// ReconcileNodeDrain drains the Cassandra nodes running on a k8s worker as
+// soon as the worker is cordoned, so they stop serving traffic cleanly
+// before their pods are evicted
+type ReconcileNodeDrain struct {
+	client          client.Client
+	scheme          *runtime.Scheme
+	recorder        record.EventRecorder
+	namespaceFilter *watchnamespace.Filter
+	shard           *sharding.Shard
+	newMgmtClient   func(context.Context, client.Client, *api.CassandraDatacenter, logr.Logger) (httphelper.NodeMgmtClient, error)
+}
+
+// Reconcile drains the Cassandra nodes of a cordoned k8s worker and marks
+// their pods with the DrainedForNodeAnnotation, then deletes the pods it
+// marked. When the worker is uncordoned before the pods were deleted, the
+// drained pods are restarted, since a drained node does not serve traffic
+// again until Cassandra restarts.
+func (r *ReconcileNodeDrain) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.Background()
+	logger := log.WithValues("node", request.Name)
+
+	node := &corev1.Node{}
+	if err := r.client.Get(ctx, request.NamespacedName, node); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("Node not found. Ignoring since object must be deleted.")
+			return result.Done().Output()
+		}
+		return result.Error(err).Output()
+	}
+
+	pods, err := r.cassandraPodsOnNode(ctx, node.Name)
+	if err != nil {
+		logger.Error(err, "error listing the Cassandra pods of the node")
+		return result.Error(err).Output()
+	}
+
+	if node.Spec.Unschedulable {
+		return r.drainPods(ctx, logger, node, pods).Output()
+	}
+	return r.restartDrainedPods(ctx, logger, node, pods).Output()
+}
+
+// cassandraPodsOnNode returns the server pods managed by the operator that are
+// scheduled on the k8s worker
+func (r *ReconcileNodeDrain) cassandraPodsOnNode(ctx context.Context, nodeName string) ([]*corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	if err := r.client.List(ctx, podList, client.MatchingLabels{oplabels.ManagedByLabel: oplabels.ManagedByLabelValue}); err != nil {
+		return nil, err
+	}
+
+	var pods []*corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Spec.NodeName == nodeName && pod.Labels[api.DatacenterLabel] != "" && pod.DeletionTimestamp == nil {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// datacenterForPod returns the datacenter of the pod, or nil when the pod
+// should be left alone because its datacenter is gone, paused, not managed or
+// of another shard
+func (r *ReconcileNodeDrain) datacenterForPod(ctx context.Context, pod *corev1.Pod) (*api.CassandraDatacenter, error) {
+	if !r.shard.Owns(pod.Namespace, pod.Labels[api.DatacenterLabel]) {
+		return nil, nil
+	}
+
+	managed, err := r.namespaceFilter.IsManaged(ctx, pod.Namespace)
+	if err != nil || !managed {
+		return nil, err
+	}
+
+	dc := &api.CassandraDatacenter{}
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Labels[api.DatacenterLabel]}
+	if err := r.client.Get(ctx, key, dc); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if dc.IsReconciliationPaused() {
+		return nil, nil
+	}
+	return dc, nil
+}
+
+// drainPods drains the Cassandra nodes of the cordoned k8s worker, one pod of
+// a datacenter at a time and only when the PodDisruptionBudgets of the pod
+// allow one more disruption. A pod that was drained is then deleted, once the
+// budgets still hold without it: the drained node fails its readiness probe
+// and takes up the budget, so an eviction of the pod would be refused.
+func (r *ReconcileNodeDrain) drainPods(ctx context.Context, logger logr.Logger, node *corev1.Node, pods []*corev1.Pod) result.ReconcileResult {
+	disrupted := map[types.NamespacedName]bool{}
+	waiting := false
+	for _, pod := range pods {
+		drained := pod.Annotations[api.DrainedForNodeAnnotation] == node.Name
+		if !drained && !utils.IsCassandraContainerReady(pod) {
+			continue
+		}
+
+		dc, err := r.datacenterForPod(ctx, pod)
+		if err != nil {
+			return result.Error(err)
+		}
+		if dc == nil {
+			continue
+		}
+		dcKey := types.NamespacedName{Namespace: dc.Namespace, Name: dc.Name}
+
+		if drained {
+			disrupted[dcKey] = true
+			allowed, err := r.budgetsAllow(ctx, dc, pod, true)
+			if err != nil {
+				logger.Error(err, "error checking the PodDisruptionBudgets of the pod", "pod", pod.Name)
+				return result.Error(err)
+			}
+			if !allowed {
+				logger.Info("Waiting for the PodDisruptionBudgets to allow deleting the drained pod", "pod", pod.Name)
+				waiting = true
+				continue
+			}
+
+			r.recorder.Eventf(dc, corev1.EventTypeNormal, events.DeletingDrainedPod,
+				"Deleting pod %s, which was drained for cordoned node %s", pod.Name, node.Name)
+			if err := r.client.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+				logger.Error(err, "error deleting the drained pod", "pod", pod.Name)
+				return result.Error(err)
+			}
+			continue
+		}
+
+		if disrupted[dcKey] {
+			waiting = true
+			continue
+		}
+		allowed, err := r.canDisrupt(ctx, dc, pod)
+		if err != nil {
+			logger.Error(err, "error checking the other pods of the datacenter", "pod", pod.Name)
+			return result.Error(err)
+		}
+		if !allowed {
+			logger.Info("Waiting for the other pods of the datacenter to be ready before draining", "pod", pod.Name)
+			waiting = true
+			continue
+		}
+
+		mgmtClient, err := r.newMgmtClient(ctx, r.client, dc, logger)
+		if err != nil {
+			return result.Error(err)
+		}
+
+		if err := mgmtClient.CallDrainEndpoint(pod); err != nil {
+			logger.Error(err, "error draining the Cassandra node of a cordoned node", "pod", pod.Name)
+			r.recorder.Eventf(dc, corev1.EventTypeWarning, events.FailedToDrainForNodeMaintenance,
+				"Failed to drain pod %s on cordoned node %s: %v", pod.Name, node.Name, err)
+			waiting = true
+			continue
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[api.DrainedForNodeAnnotation] = node.Name
+		if err := r.client.Patch(ctx, pod, patch); err != nil {
+			logger.Error(err, "error annotating the drained pod", "pod", pod.Name)
+			return result.Error(err)
+		}
+
+		r.recorder.Eventf(dc, corev1.EventTypeNormal, events.DrainedForNodeMaintenance,
+			"Drained pod %s because node %s was cordoned", pod.Name, node.Name)
+		disrupted[dcKey] = true
+		// Come back to delete the drained pod
+		waiting = true
+	}
+
+	if waiting {
+		return result.RequeueSoon(10)
+	}
+	return result.Done()
+}
+
+// canDisrupt returns whether the node of the pod can be drained: every other
+// pod of its datacenter is ready and was not drained, and the
+// PodDisruptionBudgets that cover the pod allow a disruption
+func (r *ReconcileNodeDrain) canDisrupt(ctx context.Context, dc *api.CassandraDatacenter, pod *corev1.Pod) (bool, error) {
+	podList := &corev1.PodList{}
+	dcLabels := client.MatchingLabels{api.DatacenterLabel: dc.Name}
+	if err := r.client.List(ctx, podList, client.InNamespace(dc.Namespace), dcLabels); err != nil {
+		return false, err
+	}
+	for i := range podList.Items {
+		other := &podList.Items[i]
+		if other.Name == pod.Name {
+			continue
+		}
+		if other.DeletionTimestamp != nil || other.Annotations[api.DrainedForNodeAnnotation] != "" ||
+			!utils.IsCassandraContainerReady(other) {
+			return false, nil
+		}
+	}
+	return r.budgetsAllow(ctx, dc, pod, false)
+}
+
+// budgetsAllow returns whether the PodDisruptionBudgets that cover the pod
+// allow disrupting it. A pod that is already disrupted, such as a drained one,
+// counts as unhealthy in the budgets, which allow taking it down as long as
+// the other pods keep them healthy.
+func (r *ReconcileNodeDrain) budgetsAllow(ctx context.Context, dc *api.CassandraDatacenter, pod *corev1.Pod, disrupted bool) (bool, error) {
+	budgets := &policyv1beta1.PodDisruptionBudgetList{}
+	dcLabels := client.MatchingLabels{api.DatacenterLabel: dc.Name}
+	if err := r.client.List(ctx, budgets, client.InNamespace(dc.Namespace), dcLabels); err != nil {
+		return false, err
+	}
+	for _, budget := range budgets.Items {
+		selector, err := metav1.LabelSelectorAsSelector(budget.Spec.Selector)
+		if err != nil {
+			return false, err
+		}
+		if !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if disrupted && budget.Status.CurrentHealthy < budget.Status.DesiredHealthy {
+			return false, nil
+		}
+		if !disrupted && budget.Status.DisruptionsAllowed < 1 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (r *ReconcileNodeDrain) restartDrainedPods(ctx context.Context, logger logr.Logger, node *corev1.Node, pods []*corev1.Pod) result.ReconcileResult {
+	waiting := false
+	for _, pod := range pods {
+		if pod.Annotations[api.DrainedForNodeAnnotation] != node.Name {
+			continue
+		}
+
+		dc, err := r.datacenterForPod(ctx, pod)
+		if err != nil {
+			return result.Error(err)
+		}
+		if dc == nil {
+			continue
+		}
+
+		allowed, err := r.budgetsAllow(ctx, dc, pod, true)
+		if err != nil {
+			logger.Error(err, "error checking the PodDisruptionBudgets of the pod", "pod", pod.Name)
+			return result.Error(err)
+		}
+		if !allowed {
+			logger.Info("Waiting for the PodDisruptionBudgets to allow restarting the drained pod", "pod", pod.Name)
+			waiting = true
+			continue
+		}
+
+		r.recorder.Eventf(dc, corev1.EventTypeNormal, events.RestartingDrainedPod,
+			"Restarting pod %s because node %s was uncordoned after the pod was drained", pod.Name, node.Name)
+		if err := r.client.Delete(ctx, pod); err != nil {
+			logger.Error(err, "error deleting the drained pod", "pod", pod.Name)
+			return result.Error(err)
+		}
+	}
+
+	if waiting {
+		return result.RequeueSoon(10)
+	}
+	return result.Done()
+}
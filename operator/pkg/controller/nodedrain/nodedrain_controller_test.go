@@ -0,0 +1,280 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package nodedrain
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+)
+
+func makePod(name, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				oplabels.ManagedByLabel: oplabels.ManagedByLabelValue,
+				api.DatacenterLabel:     "dc1",
+			},
+		},
+		Spec: corev1.PodSpec{NodeName: nodeName},
+		Status: corev1.PodStatus{
+			PodIP: "1.2.3.4",
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "cassandra", Ready: true},
+			},
+		},
+	}
+}
+
+func setupReconciler(mockHttpClient *mocks.HttpClient, objects ...runtime.Object) *ReconcileNodeDrain {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "default"},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(api.SchemeGroupVersion, dc)
+
+	return &ReconcileNodeDrain{
+		client:   fake.NewFakeClientWithScheme(s, append(objects, dc)...),
+		scheme:   s,
+		recorder: record.NewFakeRecorder(100),
+		newMgmtClient: func(ctx context.Context, client client.Client, dc *api.CassandraDatacenter, logger logr.Logger) (httphelper.NodeMgmtClient, error) {
+			return httphelper.NodeMgmtClient{Client: mockHttpClient, Log: logger, Protocol: "http"}, nil
+		},
+	}
+}
+
+func reconcileNode(t *testing.T, r *ReconcileNodeDrain, name string) {
+	_, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	assert.NoError(t, err)
+}
+
+func getPod(t *testing.T, r *ReconcileNodeDrain, name string) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	err := r.client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, pod)
+	return pod, err
+}
+
+func TestReconcile_DrainsPodsOfCordonedNode(t *testing.T) {
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/node/drain"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("OK")),
+		}, nil).
+		Once()
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+		Spec:       corev1.NodeSpec{Unschedulable: true},
+	}
+	r := setupReconciler(mockHttpClient, node, makePod("pod-a", "worker-1"), makePod("pod-b", "worker-2"))
+
+	reconcileNode(t, r, "worker-1")
+	mockHttpClient.AssertExpectations(t)
+
+	pod, err := getPod(t, r, "pod-a")
+	assert.NoError(t, err)
+	assert.Equal(t, "worker-1", pod.Annotations[api.DrainedForNodeAnnotation])
+
+	pod, err = getPod(t, r, "pod-b")
+	assert.NoError(t, err)
+	assert.NotContains(t, pod.Annotations, api.DrainedForNodeAnnotation)
+
+	// A pod that is already drained is not drained again
+	reconcileNode(t, r, "worker-1")
+	mockHttpClient.AssertNumberOfCalls(t, "Do", 1)
+}
+
+func TestReconcile_RestartsDrainedPodsOfUncordonedNode(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+	}
+	drained := makePod("pod-a", "worker-1")
+	drained.Annotations = map[string]string{api.DrainedForNodeAnnotation: "worker-1"}
+	r := setupReconciler(&mocks.HttpClient{}, node, drained, makePod("pod-b", "worker-1"))
+
+	reconcileNode(t, r, "worker-1")
+
+	_, err := getPod(t, r, "pod-a")
+	assert.Error(t, err, "the drained pod should have been deleted")
+	_, err = getPod(t, r, "pod-b")
+	assert.NoError(t, err)
+}
+
+func TestReconcile_SkipsPausedDatacenters(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+		Spec:       corev1.NodeSpec{Unschedulable: true},
+	}
+	r := setupReconciler(&mocks.HttpClient{}, node, makePod("pod-a", "worker-1"))
+
+	dc := &api.CassandraDatacenter{}
+	assert.NoError(t, r.client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "dc1"}, dc))
+	dc.Annotations = map[string]string{api.PausedAnnotation: "true"}
+	assert.NoError(t, r.client.Update(context.Background(), dc))
+
+	// The mock HTTP client fails the test if it is called
+	reconcileNode(t, r, "worker-1")
+
+	pod, err := getPod(t, r, "pod-a")
+	assert.NoError(t, err)
+	assert.NotContains(t, pod.Annotations, api.DrainedForNodeAnnotation)
+}
+
+func drainedPods(t *testing.T, r *ReconcileNodeDrain) []string {
+	podList := &corev1.PodList{}
+	assert.NoError(t, r.client.List(context.Background(), podList))
+	var drained []string
+	for _, pod := range podList.Items {
+		if pod.Annotations[api.DrainedForNodeAnnotation] != "" {
+			drained = append(drained, pod.Name)
+		}
+	}
+	return drained
+}
+
+func TestReconcile_DrainsOnePodOfDatacenterAtATime(t *testing.T) {
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do", mock.Anything).
+		Return(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("OK")),
+			}
+		}, nil)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+		Spec:       corev1.NodeSpec{Unschedulable: true},
+	}
+	r := setupReconciler(mockHttpClient, node, makePod("pod-a", "worker-1"), makePod("pod-b", "worker-1"))
+
+	reconcileNode(t, r, "worker-1")
+	mockHttpClient.AssertNumberOfCalls(t, "Do", 1)
+	drained := drainedPods(t, r)
+	assert.Len(t, drained, 1)
+
+	// The drained pod is deleted, and the other one waits for it to be gone
+	reconcileNode(t, r, "worker-1")
+	mockHttpClient.AssertNumberOfCalls(t, "Do", 1)
+	_, err := getPod(t, r, drained[0])
+	assert.Error(t, err, "the drained pod should have been deleted")
+	assert.Empty(t, drainedPods(t, r))
+
+	reconcileNode(t, r, "worker-1")
+	mockHttpClient.AssertNumberOfCalls(t, "Do", 2)
+	assert.Len(t, drainedPods(t, r), 1)
+}
+
+func TestReconcile_RespectsPodDisruptionBudget(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+		Spec:       corev1.NodeSpec{Unschedulable: true},
+	}
+	budget := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dc1-pdb",
+			Namespace: "default",
+			Labels:    map[string]string{api.DatacenterLabel: "dc1"},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{api.DatacenterLabel: "dc1"}},
+		},
+		Status: policyv1beta1.PodDisruptionBudgetStatus{DisruptionsAllowed: 0},
+	}
+	notReady := makePod("pod-b", "worker-2")
+	notReady.Status.ContainerStatuses[0].Ready = false
+	r := setupReconciler(&mocks.HttpClient{}, node, budget, makePod("pod-a", "worker-1"))
+
+	// The mock HTTP client fails the test if it is called
+	reconcileNode(t, r, "worker-1")
+	assert.Empty(t, drainedPods(t, r))
+
+	assert.NoError(t, r.client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "dc1-pdb"}, budget))
+	budget.Status.DisruptionsAllowed = 1
+	assert.NoError(t, r.client.Update(context.Background(), budget))
+	assert.NoError(t, r.client.Create(context.Background(), notReady))
+	reconcileNode(t, r, "worker-1")
+	assert.Empty(t, drainedPods(t, r), "another pod of the datacenter is not ready")
+}
+
+func TestReconcile_DeletesDrainedPodWithinPodDisruptionBudget(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+		Spec:       corev1.NodeSpec{Unschedulable: true},
+	}
+	// The drained pod and another one of the datacenter are down
+	budget := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dc1-pdb",
+			Namespace: "default",
+			Labels:    map[string]string{api.DatacenterLabel: "dc1"},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{api.DatacenterLabel: "dc1"}},
+		},
+		Status: policyv1beta1.PodDisruptionBudgetStatus{CurrentHealthy: 1, DesiredHealthy: 2},
+	}
+	drained := makePod("pod-a", "worker-1")
+	drained.Annotations = map[string]string{api.DrainedForNodeAnnotation: "worker-1"}
+	r := setupReconciler(&mocks.HttpClient{}, node, budget, drained)
+
+	reconcileNode(t, r, "worker-1")
+	_, err := getPod(t, r, "pod-a")
+	assert.NoError(t, err, "the drained pod should wait for the budget")
+
+	assert.NoError(t, r.client.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "dc1-pdb"}, budget))
+	budget.Status.CurrentHealthy = 2
+	assert.NoError(t, r.client.Update(context.Background(), budget))
+	reconcileNode(t, r, "worker-1")
+	_, err = getPod(t, r, "pod-a")
+	assert.Error(t, err, "the drained pod should have been deleted")
+}
+
+func TestEnabledFromEnv(t *testing.T) {
+	defer os.Unsetenv(EnabledEnv)
+
+	os.Unsetenv(EnabledEnv)
+	enabled, err := enabledFromEnv()
+	assert.NoError(t, err)
+	assert.False(t, enabled, "the controller is off by default")
+
+	os.Setenv(EnabledEnv, "true")
+	enabled, err = enabledFromEnv()
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	os.Setenv(EnabledEnv, "yes")
+	_, err = enabledFromEnv()
+	assert.Error(t, err)
+}
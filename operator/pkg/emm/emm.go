@@ -1,8 +1,10 @@
 // Copyright DataStax, Inc.
 // Please see the included license file for details.
 
-// PSP EMM operations are triggered by a user through a UI and appear to the
-// operator as taints on k8s nodes. To allow the EMM operation to proceed, all
+// EMM (enhanced maintenance mode) operations appear to the operator as taints
+// on k8s nodes. They were first triggered by a user through the UI of the
+// VMware PSP, but the taints can be set by any tool with the
+// nodeMaintenancePolicy of a datacenter. To allow the EMM operation to proceed, all
 // pods must be removed from the tainted node. EMM can be cancelled by adding
 // a failure annotation to pods on the node.
 //
@@ -20,7 +22,7 @@
 // temporarily taken offline to replace defective memory which was causing
 // cassandra to crash.
 
-package psp
+package emm
 
 import (
 	"fmt"
@@ -37,14 +39,6 @@ import (
 const (
 	EMMFailureAnnotation   = "appplatform.vmware.com/emm-failure"
 	VolumeHealthAnnotation = "volumehealth.storage.kubernetes.io/health"
-	EMMTaintKey            = "node.vmware.com/drain"
-)
-
-type EMMTaintValue string
-
-const (
-	EvacuateAllData EMMTaintValue = "drain"
-	PlannedDowntime               = "planned-downtime"
 )
 
 type VolumeHealth string
@@ -128,11 +122,24 @@ func filterPodsByRackName(pods []*corev1.Pod, rackName string) []*corev1.Pod {
 	return utils.FilterPodsWithLabel(pods, api.RackLabel, rackName)
 }
 
+// IsEnabled tells whether the EMM taints of the k8s nodes are handled for the
+// datacenter. Without a nodeMaintenancePolicy, they are only handled when the
+// operator runs with the VMware PSP integration.
+func IsEnabled(dc *api.CassandraDatacenter) bool {
+	if policy := dc.Spec.NodeMaintenancePolicy; policy != nil {
+		return policy.Enabled
+	}
+	return utils.IsPSPEnabled()
+}
+
 //
 // EMMOperations impl
 //
 type EMMServiceImpl struct {
 	EMMSPI
+
+	// The taints that start EMM operations, the VMware PSP ones when nil
+	Policy *api.NodeMaintenancePolicy
 }
 
 func (impl *EMMServiceImpl) getPodPVCSelectedNodeName(podName string) (string, error) {
@@ -211,7 +218,7 @@ func (impl *EMMServiceImpl) emmFailureStillProcessing() (bool, error) {
 }
 
 func (impl *EMMServiceImpl) getPlannedDownTimeNodeNameSet() (utils.StringSet, error) {
-	nodes, err := impl.getNodesWithTaintKeyValueEffect(EMMTaintKey, string(PlannedDowntime), corev1.TaintEffectNoSchedule)
+	nodes, err := impl.getNodesWithTaintKeyValueEffect(impl.Policy.GetTaintKey(), impl.Policy.GetPlannedDowntimeValue(), corev1.TaintEffectNoSchedule)
 	if err != nil {
 		return nil, err
 	}
@@ -219,7 +226,7 @@ func (impl *EMMServiceImpl) getPlannedDownTimeNodeNameSet() (utils.StringSet, er
 }
 
 func (impl *EMMServiceImpl) getEvacuateAllDataNodeNameSet() (utils.StringSet, error) {
-	nodes, err := impl.getNodesWithTaintKeyValueEffect(EMMTaintKey, string(EvacuateAllData), corev1.TaintEffectNoSchedule)
+	nodes, err := impl.getNodesWithTaintKeyValueEffect(impl.Policy.GetTaintKey(), impl.Policy.GetEvacuateAllDataValue(), corev1.TaintEffectNoSchedule)
 	if err != nil {
 		return nil, err
 	}
@@ -274,9 +281,18 @@ func (impl *EMMServiceImpl) getNodeNameSet() (utils.StringSet, error) {
 	}
 	totalNodes := len(nodes)
 
+	// On the VMware PSP, only the agent nodes run workloads. Elsewhere, any
+	// node that is not cordoned can take the pods.
+	isAgent := func(node *corev1.Node) bool {
+		if utils.IsPSPEnabled() {
+			return node.Labels["kubernetes.io/role"] == "agent"
+		}
+		return !node.Spec.Unschedulable
+	}
+
 	agentNodesIndex := []int{}
 	for i := 0; i < totalNodes; i++ {
-		if nodes[i].Labels["kubernetes.io/role"] == "agent" {
+		if isAgent(nodes[i]) {
 			agentNodesIndex = append(agentNodesIndex, i)
 		}
 	}
@@ -309,7 +325,7 @@ func (impl *EMMServiceImpl) performEvacuateDataPodReplace() (bool, error) {
 		//
 		// NOTE: This arguably belongs in our check for stuck pods that
 		// CheckPodsReady() does. Keeping all the logic together for the
-		// time being since it is all pretty specific to PSP at the moment.
+		// time being since it is all pretty specific to EMM at the moment.
 		deletedPodsOrPVCs := false
 		for _, pod := range downPods {
 			if utils.IsPodUnschedulable(pod) {
@@ -477,7 +493,7 @@ func (impl *EMMServiceImpl) removePodAnnotation(pod *corev1.Pod, key string) err
 	return nil
 }
 
-// Check nodes for vmware PSP draining taints. This function embodies the
+// Check nodes for EMM draining taints. This function embodies the
 // business logic around when EMM operations are executed.
 func checkNodeEMM(provider EMMService) result.ReconcileResult {
 	logger := provider.getLogger()
@@ -797,13 +813,13 @@ func checkPVCHealth(provider EMMService) result.ReconcileResult {
 func CheckPVCHealth(spi EMMSPI) result.ReconcileResult {
 	service := &EMMServiceImpl{EMMSPI: spi}
 	logger := service.getLogger()
-	logger.Info("psp::CheckPVCHealth")
+	logger.Info("emm::CheckPVCHealth")
 	return checkPVCHealth(service)
 }
 
-func CheckEMM(spi EMMSPI) result.ReconcileResult {
-	service := &EMMServiceImpl{EMMSPI: spi}
+func CheckEMM(spi EMMSPI, policy *api.NodeMaintenancePolicy) result.ReconcileResult {
+	service := &EMMServiceImpl{EMMSPI: spi, Policy: policy}
 	logger := service.getLogger()
-	logger.Info("psp::CheckEMM")
+	logger.Info("emm::CheckEMM")
 	return checkNodeEMM(service)
 }
@@ -1,9 +1,10 @@
 // Copyright DataStax, Inc.
 // Please see the included license file for details.
 
-package psp
+package emm
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/mock"
@@ -15,6 +16,7 @@ import (
 	logrtesting "github.com/go-logr/logr/testing"
 
 	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
 )
 
@@ -543,3 +545,79 @@ func Test_removeAllNotReadyPodsOnEMMNodes(t *testing.T) {
 	require.True(t, changed, "should have removed a not ready pod")
 	require.Nil(t, err, "should not have encountered an error")
 }
+
+func Test_getEvacuateAllDataNodeNameSet_customTaint(t *testing.T) {
+	testObj := &MockEMMSPI{}
+	service := &EMMServiceImpl{
+		EMMSPI: testObj,
+		Policy: &api.NodeMaintenancePolicy{
+			Enabled:              true,
+			TaintKey:             "example.com/maintenance",
+			EvacuateAllDataValue: "evacuate",
+		},
+	}
+
+	customNode := &corev1.Node{}
+	customNode.Name = "node2"
+	customNode.Spec.Taints = []corev1.Taint{
+		{
+			Key:    "example.com/maintenance",
+			Value:  "evacuate",
+			Effect: corev1.TaintEffectNoSchedule,
+		},
+	}
+	testObj.On("GetAllNodesInDC").Return([]*corev1.Node{evacuateDataNode("node1"), customNode}, nil)
+
+	nodes, err := service.getEvacuateAllDataNodeNameSet()
+	require.Nil(t, err)
+	require.Equal(t, utils.StringSet{"node2": true}, nodes, "only the taint of the policy should be handled")
+
+	nodes, err = service.getPlannedDownTimeNodeNameSet()
+	require.Nil(t, err)
+	require.Empty(t, nodes)
+}
+
+func Test_IsEnabled(t *testing.T) {
+	defer os.Unsetenv("ENABLE_VMWARE_PSP")
+	os.Unsetenv("ENABLE_VMWARE_PSP")
+
+	dc := &api.CassandraDatacenter{}
+	require.False(t, IsEnabled(dc))
+
+	dc.Spec.NodeMaintenancePolicy = &api.NodeMaintenancePolicy{Enabled: true}
+	require.True(t, IsEnabled(dc))
+
+	os.Setenv("ENABLE_VMWARE_PSP", "true")
+	dc.Spec.NodeMaintenancePolicy = nil
+	require.True(t, IsEnabled(dc), "the PSP taints are handled by default with the PSP integration")
+
+	dc.Spec.NodeMaintenancePolicy = &api.NodeMaintenancePolicy{Enabled: false}
+	require.False(t, IsEnabled(dc))
+}
+
+func Test_getNodeNameSet(t *testing.T) {
+	defer os.Unsetenv("ENABLE_VMWARE_PSP")
+	os.Unsetenv("ENABLE_VMWARE_PSP")
+
+	testObj := &MockEMMSPI{}
+	service := &EMMServiceImpl{EMMSPI: testObj}
+
+	agent := &corev1.Node{}
+	agent.Name = "agent"
+	agent.Labels = map[string]string{"kubernetes.io/role": "agent"}
+	worker := &corev1.Node{}
+	worker.Name = "worker"
+	cordoned := &corev1.Node{}
+	cordoned.Name = "cordoned"
+	cordoned.Spec.Unschedulable = true
+	testObj.On("GetAllNodes").Return([]*corev1.Node{agent, worker, cordoned}, nil)
+
+	nodes, err := service.getNodeNameSet()
+	require.Nil(t, err)
+	require.Equal(t, utils.StringSet{"agent": true, "worker": true}, nodes)
+
+	os.Setenv("ENABLE_VMWARE_PSP", "true")
+	nodes, err = service.getNodeNameSet()
+	require.Nil(t, err)
+	require.Equal(t, utils.StringSet{"agent": true}, nodes, "only the agent nodes run workloads on the PSP")
+}
@@ -25,10 +25,83 @@ const (
 	ScalingDownRack                   string = "ScalingDownRack"
 	CreatedSuperuser                  string = "CreatedSuperuser" // deprecated
 	CreatedUsers                      string = "CreatedUsers"
+	DroppedUser                       string = "DroppedUser"
+	RotatedSuperuserPassword          string = "RotatedSuperuserPassword"
 	FinishedReplaceNode               string = "FinishedReplaceNode"
 	ReplacingNode                     string = "ReplacingNode"
 	StartingCassandraAndReplacingNode string = "StartingCassandraAndReplacingNode"
 	StartingCassandra                 string = "StartingCassandra"
+	StartedTask                       string = "StartedTask"
+	CompletedTask                     string = "CompletedTask"
+	FailedTask                        string = "FailedTask"
+	StartedBackup                     string = "StartedBackup"
+	CompletedBackup                   string = "CompletedBackup"
+	FailedBackup                      string = "FailedBackup"
+	StartedRestore                    string = "StartedRestore"
+	CompletedRestore                  string = "CompletedRestore"
+	FailedRestore                     string = "FailedRestore"
+	StartedRepair                     string = "StartedRepair"
+	CompletedRepair                   string = "CompletedRepair"
+	FailedRepair                      string = "FailedRepair"
+	CanaryUpgradePaused               string = "CanaryUpgradePaused"
+	CanaryUpgradeApproved             string = "CanaryUpgradeApproved"
+	ReplacingPod                      string = "ReplacingPod"
+	DecommissioningDatacenter         string = "DecommissioningDatacenter"
+	UpdatedQueryLogging               string = "UpdatedQueryLogging"
+	UpdatedLoggingLevels              string = "UpdatedLoggingLevels"
+	UpdatedLiveSettings               string = "UpdatedLiveSettings"
+	ExpandingVolumes                  string = "ExpandingVolumes"
+	DetectedVolumeFailure             string = "DetectedVolumeFailure"
+	RecoveredVolumeFailure            string = "RecoveredVolumeFailure"
+	DefinedZoneRacks                  string = "DefinedZoneRacks"
+	MissingPermissions                string = "MissingPermissions"
+	WaitingForMaintenanceWindow       string = "WaitingForMaintenanceWindow"
+	DrainedForNodeMaintenance         string = "DrainedForNodeMaintenance"
+	FailedToDrainForNodeMaintenance   string = "FailedToDrainForNodeMaintenance"
+	RestartingDrainedPod              string = "RestartingDrainedPod"
+	DeletingDrainedPod                string = "DeletingDrainedPod"
+	MonitoringUnavailable             string = "MonitoringUnavailable"
+	CertManagerUnavailable            string = "CertManagerUnavailable"
+	RenewedClientCertificate          string = "RenewedClientCertificate"
+	ReloadedCertificates              string = "ReloadedCertificates"
+	RegisteredWithReaper              string = "RegisteredWithReaper"
+	RevertedStatefulSetDrift          string = "RevertedStatefulSetDrift"
+	ImmutableStatefulSetChange        string = "ImmutableStatefulSetChange"
+	RecreatingStatefulSet             string = "RecreatingStatefulSet"
+	HibernatingDatacenter             string = "HibernatingDatacenter"
+	ValidatedConfig                   string = "ValidatedConfig"
+	FailedConfigValidation            string = "FailedConfigValidation"
+	ConfigCanariesHealthy             string = "ConfigCanariesHealthy"
+	StartedSSTablesUpgrade            string = "StartedSSTablesUpgrade"
+	CompletedSSTablesUpgrade          string = "CompletedSSTablesUpgrade"
+	FailedSSTablesUpgrade             string = "FailedSSTablesUpgrade"
+	RemediatingPod                    string = "RemediatingPod"
+	RemediationBudgetExhausted        string = "RemediationBudgetExhausted"
+	WaitingForSchemaAgreement         string = "WaitingForSchemaAgreement"
+	AlteredKeyspaceReplication        string = "AlteredKeyspaceReplication"
+	FailedKeyspaceReplication         string = "FailedKeyspaceReplication"
+	ReplicationFactorUnsafe           string = "ReplicationFactorUnsafe"
+	BootstrapComplete                 string = "BootstrapComplete"
+	DecommissionStarted               string = "DecommissionStarted"
+	ConfigRolloutStarted              string = "ConfigRolloutStarted"
+	ConfigRolloutFinished             string = "ConfigRolloutFinished"
+	ScaledUpDatacenter                string = "ScaledUpDatacenter"
+	ScaledDownDatacenter              string = "ScaledDownDatacenter"
+	DeletionBlocked                   string = "DeletionBlocked"
+	OrphanedResource                  string = "OrphanedResource"
+	AdoptedResource                   string = "AdoptedResource"
+	AdoptionFailed                    string = "AdoptionFailed"
+	StartedMigration                  string = "StartedMigration"
+	SwitchedClients                   string = "SwitchedClients"
+	FailedMigration                   string = "FailedMigration"
+	CompletedMigration                string = "CompletedMigration"
+)
+
+const (
+	// Annotations of the events about a pod or a rack, so that the events of a
+	// node can be found without parsing their messages
+	PodAnnotation  string = "cassandra.datastax.com/pod"
+	RackAnnotation string = "cassandra.datastax.com/rack"
 )
 
 type LoggingEventRecorder struct {
@@ -47,6 +120,10 @@ func (r *LoggingEventRecorder) Eventf(object runtime.Object, eventtype, reason,
 }
 
 func (r *LoggingEventRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
-	r.ReqLogger.Info(fmt.Sprintf(messageFmt, args...), "reason", reason, "eventType", eventtype)
+	keysAndValues := []interface{}{"reason", reason, "eventType", eventtype}
+	if len(annotations) > 0 {
+		keysAndValues = append(keysAndValues, "annotations", annotations)
+	}
+	r.ReqLogger.Info(fmt.Sprintf(messageFmt, args...), keysAndValues...)
 	r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
 }
@@ -0,0 +1,144 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package httphelper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+)
+
+// The backup sidecar runs next to the cassandra container, shares its data
+// volume, and serves a small HTTP API with the same security settings as the
+// management API. The operator only tells it what to copy where; the sidecar
+// owns the object storage clients and credentials. A transfer of the data of
+// a node takes a while, so the sidecar runs it in the background and reports
+// its progress with the statuses of the jobs of the management API.
+
+// BackupTransferRequest describes the files a backup sidecar uploads or downloads
+type BackupTransferRequest struct {
+	SnapshotName string                    `json:"snapshot_name"`
+	Provider     api.BackupStorageProvider `json:"provider"`
+	Bucket       string                    `json:"bucket"`
+	// The path of the node's files inside the bucket
+	Path string `json:"path"`
+}
+
+// BackupTransfer is the progress of a transfer of the backup sidecar
+type BackupTransfer struct {
+	ID               string            `json:"id"`
+	Status           mgmtapi.JobStatus `json:"status"`
+	BytesTransferred int64             `json:"bytes_transferred,omitempty"`
+	Error            string            `json:"error,omitempty"`
+}
+
+// BackupPath returns the path in object storage the files of a single node of
+// a backup are stored under
+func BackupPath(location api.BackupStorageLocation, backupName, podName string) string {
+	path := backupName + "/" + podName
+	if location.Prefix != "" {
+		path = location.Prefix + "/" + path
+	}
+	return path
+}
+
+// CallBackupSidecarUploadEndpoint starts uploading the files of a snapshot to
+// object storage and returns the ID of the transfer
+func (client *NodeMgmtClient) CallBackupSidecarUploadEndpoint(pod *corev1.Pod, transfer BackupTransferRequest) (string, error) {
+	client.Log.Info(
+		"calling backup sidecar upload - POST /api/v0/backups",
+		"pod", pod.Name,
+		"snapshotName", transfer.SnapshotName,
+	)
+
+	return client.startBackupSidecarTransfer(pod, "/api/v0/backups", transfer)
+}
+
+// CallBackupSidecarDownloadEndpoint starts downloading the files of a backed
+// up node into the data directory of the pod and returns the ID of the
+// transfer
+func (client *NodeMgmtClient) CallBackupSidecarDownloadEndpoint(pod *corev1.Pod, transfer BackupTransferRequest) (string, error) {
+	client.Log.Info(
+		"calling backup sidecar download - POST /api/v0/restores",
+		"pod", pod.Name,
+		"snapshotName", transfer.SnapshotName,
+	)
+
+	return client.startBackupSidecarTransfer(pod, "/api/v0/restores", transfer)
+}
+
+// CallBackupSidecarTransferEndpoint returns the progress of a transfer
+// started on the pod. The sidecar answers with a status that
+// mgmtapi.IsJobNotFound recognizes when it restarted since, and lost the
+// transfer.
+func (client *NodeMgmtClient) CallBackupSidecarTransferEndpoint(pod *corev1.Pod, transferID string) (*BackupTransfer, error) {
+	client.Log.Info(
+		"calling backup sidecar transfer - GET /api/v0/transfers",
+		"pod", pod.Name,
+		"transferId", transferID,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	request := nodeMgmtRequest{
+		endpoint: "/api/v0/transfers/" + url.PathEscape(transferID),
+		host:     podHost,
+		port:     api.BackupSidecarPort,
+		method:   http.MethodGet,
+	}
+
+	res, err := callNodeMgmtEndpoint(client, request, "")
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := &BackupTransfer{}
+	if err := json.Unmarshal(res, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func (client *NodeMgmtClient) startBackupSidecarTransfer(pod *corev1.Pod, endpoint string, transfer BackupTransferRequest) (string, error) {
+	body, err := json.Marshal(transfer)
+	if err != nil {
+		return "", err
+	}
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return "", err
+	}
+
+	request := nodeMgmtRequest{
+		endpoint: endpoint,
+		host:     podHost,
+		port:     api.BackupSidecarPort,
+		method:   http.MethodPost,
+		body:     body,
+	}
+
+	res, err := callNodeMgmtEndpoint(client, request, "application/json")
+	if err != nil {
+		return "", err
+	}
+
+	started := &BackupTransfer{}
+	if err := json.Unmarshal(res, started); err != nil {
+		return "", err
+	}
+	if started.ID == "" {
+		return "", fmt.Errorf("backup sidecar of pod %s did not return the ID of the transfer", pod.Name)
+	}
+	return started.ID, nil
+}
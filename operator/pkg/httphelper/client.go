@@ -4,69 +4,85 @@
 package httphelper
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"io/ioutil"
-	"net/http"
-	"net/url"
-	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 
 	corev1 "k8s.io/api/core/v1"
+
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
 )
 
+// NodeMgmtClient calls the management API of the pods of a datacenter. The
+// requests themselves are sent by a mgmtapi.Client, which components that do
+// not deal with pods can use directly.
 type NodeMgmtClient struct {
 	Client   HttpClient
 	Log      logr.Logger
 	Protocol string
+	// Ctx is the context of the requests, which cancels them when it is done.
+	// It is context.Background() when nil.
+	Ctx context.Context
+	// Retry is how the requests that failed are sent again, when they are
+	// safe to send twice. They are not retried when it is zero.
+	Retry mgmtapi.RetryPolicy
+	// Workers is how many pods ForEachPod calls at once, DefaultWorkers when
+	// zero
+	Workers int
+}
+
+// DefaultWorkers is how many pods a NodeMgmtClient calls at once by default
+const DefaultWorkers = 10
+
+// DefaultRetryPolicy is how the clients of the operator retry the requests
+// that failed, for the nodes that are briefly unreachable
+var DefaultRetryPolicy = mgmtapi.RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     time.Second,
+}
+
+// ForEachPod runs call for each of the pods, on up to Workers pods at once,
+// and returns once every call returned. The calls to the same pod run one
+// after the other within its call, which must be safe to run concurrently
+// with the calls to the other pods.
+func (client *NodeMgmtClient) ForEachPod(pods []*corev1.Pod, call func(pod *corev1.Pod)) {
+	workers := client.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for _, pod := range pods {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(pod *corev1.Pod) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			call(pod)
+		}(pod)
+	}
+	wg.Wait()
 }
 
 type nodeMgmtRequest struct {
 	endpoint string
 	host     string
+	port     int
 	method   string
 	timeout  time.Duration
 	body     []byte
 }
 
-func buildEndpoint(path string, queryParams ...string) string {
-	params := url.Values{}
-	for i := 0; i < len(queryParams)-1; i = i + 2 {
-		params[queryParams[i]] = []string{queryParams[i+1]}
-	}
-
-	url := &url.URL{
-		Path:     path,
-		RawQuery: params.Encode(),
-	}
-	return url.String()
-}
-
-type EndpointState struct {
-	HostID                 string `json:"HOST_ID"`
-	IsAlive                string `json:"IS_ALIVE"`
-	NativeTransportAddress string `json:"NATIVE_TRANSPORT_ADDRESS"`
-	RpcAddress             string `json:"RPC_ADDRESS"`
-	Status                 string `json:"STATUS"`
-	Load                   string `json:"LOAD"`
-}
-
-func (x *EndpointState) GetRpcAddress() string {
-	if x.NativeTransportAddress != "" {
-		return x.NativeTransportAddress
-	} else {
-		return x.RpcAddress
-	}
-}
+type EndpointState = mgmtapi.EndpointState
 
-type CassMetadataEndpoints struct {
-	Entity []EndpointState `json:"entity"`
-}
+type CassMetadataEndpoints = mgmtapi.Endpoints
 
 type NoPodIPError error
 
@@ -100,32 +116,37 @@ func parseMetadataEndpointsResponseBody(body []byte) (*CassMetadataEndpoints, er
 	return endpoints, nil
 }
 
-func (client *NodeMgmtClient) CallMetadataEndpointsEndpoint(pod *corev1.Pod) (CassMetadataEndpoints, error) {
-	client.Log.Info("requesting Cassandra metadata endpoints from Node Management API", "pod", pod.Name)
-
-	podHost, err := BuildPodHostFromPod(pod)
-	if err != nil {
-		return CassMetadataEndpoints{}, err
+// ctx returns the context the requests are sent with
+func (client *NodeMgmtClient) ctx() context.Context {
+	if client.Ctx == nil {
+		return context.Background()
 	}
+	return client.Ctx
+}
 
-	request := nodeMgmtRequest{
-		endpoint: "/api/v0/metadata/endpoints",
-		host:     podHost,
-		method:   http.MethodGet,
+// api returns the client of the management API the requests are sent with
+func (client *NodeMgmtClient) api() *mgmtapi.Client {
+	return &mgmtapi.Client{
+		HTTPClient: client.Client,
+		Protocol:   client.Protocol,
+		Retry:      client.Retry,
+		Log:        client.Log,
 	}
+}
 
-	bytes, err := callNodeMgmtEndpoint(client, request, "")
+func (client *NodeMgmtClient) CallMetadataEndpointsEndpoint(pod *corev1.Pod) (CassMetadataEndpoints, error) {
+	client.Log.Info("requesting Cassandra metadata endpoints from Node Management API", "pod", pod.Name)
+
+	podHost, err := BuildPodHostFromPod(pod)
 	if err != nil {
 		return CassMetadataEndpoints{}, err
-
 	}
 
-	endpoints, err := parseMetadataEndpointsResponseBody(bytes)
+	endpoints, err := client.api().GetEndpoints(client.ctx(), podHost)
 	if err != nil {
 		return CassMetadataEndpoints{}, err
-	} else {
-		return *endpoints, nil
 	}
+	return *endpoints, nil
 }
 
 // Create a new superuser with the given username and password
@@ -135,29 +156,52 @@ func (client *NodeMgmtClient) CallCreateRoleEndpoint(pod *corev1.Pod, username s
 		"pod", pod.Name,
 	)
 
-	postData := url.Values{}
-	postData.Set("username", username)
-	postData.Set("password", password)
-	postData.Set("can_login", "true")
-	postData.Set("is_superuser", strconv.FormatBool(superuser))
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return err
+	}
+
+	return client.api().CreateRole(client.ctx(), podHost, mgmtapi.CreateRoleRequest{
+		Username:  username,
+		Password:  password,
+		Superuser: superuser,
+	})
+}
+
+// Change the password of the role with the given username
+func (client *NodeMgmtClient) CallChangeRolePasswordEndpoint(pod *corev1.Pod, username string, password string) error {
+	client.Log.Info(
+		"calling Management API change role password - POST /api/v0/ops/auth/role/password",
+		"pod", pod.Name,
+	)
 
 	podHost, err := BuildPodHostFromPod(pod)
 	if err != nil {
 		return err
 	}
 
-	request := nodeMgmtRequest{
-		endpoint: fmt.Sprintf("/api/v0/ops/auth/role?%s", postData.Encode()),
-		host:     podHost,
-		method:   http.MethodPost,
+	return client.api().ChangeRolePassword(client.ctx(), podHost, username, password)
+}
+
+// Drop the role with the given username
+func (client *NodeMgmtClient) CallDropRoleEndpoint(pod *corev1.Pod, username string) error {
+	client.Log.Info(
+		"calling Management API drop role - DELETE /api/v0/ops/auth/role",
+		"pod", pod.Name,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return err
 	}
-	_, err = callNodeMgmtEndpoint(client, request, "")
-	return err
+
+	return client.api().DropRole(client.ctx(), podHost, username)
 }
 
-func (client *NodeMgmtClient) CallProbeClusterEndpoint(pod *corev1.Pod, consistencyLevel string, rfPerDc int) error {
+// Reload the keystores and truststores of the encryption of the node
+func (client *NodeMgmtClient) CallReloadCertificatesEndpoint(pod *corev1.Pod) error {
 	client.Log.Info(
-		"calling Management API cluster health - GET /api/v0/probes/cluster",
+		"calling Management API reload certificates - POST /api/v0/ops/node/reload-certificates",
 		"pod", pod.Name,
 	)
 
@@ -166,14 +210,24 @@ func (client *NodeMgmtClient) CallProbeClusterEndpoint(pod *corev1.Pod, consiste
 		return err
 	}
 
-	request := nodeMgmtRequest{
-		endpoint: fmt.Sprintf("/api/v0/probes/cluster?consistency_level=%s&rf_per_dc=%d", consistencyLevel, rfPerDc),
-		host:     podHost,
-		method:   http.MethodGet,
+	return client.api().ReloadCertificates(client.ctx(), podHost)
+}
+
+func (client *NodeMgmtClient) CallProbeClusterEndpoint(pod *corev1.Pod, consistencyLevel string, rfPerDc int) error {
+	client.Log.Info(
+		"calling Management API cluster health - GET /api/v0/probes/cluster",
+		"pod", pod.Name,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return err
 	}
 
-	_, err = callNodeMgmtEndpoint(client, request, "")
-	return err
+	return client.api().ProbeCluster(client.ctx(), podHost, mgmtapi.ProbeClusterRequest{
+		ConsistencyLevel: consistencyLevel,
+		RFPerDC:          rfPerDc,
+	})
 }
 
 func (client *NodeMgmtClient) CallDrainEndpoint(pod *corev1.Pod) error {
@@ -187,15 +241,7 @@ func (client *NodeMgmtClient) CallDrainEndpoint(pod *corev1.Pod) error {
 		return err
 	}
 
-	request := nodeMgmtRequest{
-		endpoint: "/api/v0/ops/node/drain",
-		host:     podHost,
-		method:   http.MethodPost,
-		timeout:  time.Minute * 2,
-	}
-
-	_, err = callNodeMgmtEndpoint(client, request, "")
-	return err
+	return client.api().Drain(client.ctx(), podHost)
 }
 
 func (client *NodeMgmtClient) CallKeyspaceCleanupEndpoint(pod *corev1.Pod, jobs int, keyspaceName string, tables []string) error {
@@ -203,81 +249,188 @@ func (client *NodeMgmtClient) CallKeyspaceCleanupEndpoint(pod *corev1.Pod, jobs
 		"calling Management API keyspace cleanup - POST /api/v0/ops/keyspace/cleanup",
 		"pod", pod.Name,
 	)
-	postData := make(map[string]interface{})
-	if jobs > -1 {
-		postData["jobs"] = strconv.Itoa(jobs)
-	}
 
-	if keyspaceName != "" {
-		postData["keyspace_name"] = keyspaceName
+	return client.callTableOperationEndpoint(pod, (*mgmtapi.Client).Cleanup, jobs, keyspaceName, tables)
+}
+
+// CallFlushEndpoint flushes the memtables of the given keyspace and tables to disk
+func (client *NodeMgmtClient) CallFlushEndpoint(pod *corev1.Pod, keyspaceName string, tables []string) error {
+	client.Log.Info(
+		"calling Management API flush - POST /api/v0/ops/tables/flush",
+		"pod", pod.Name,
+	)
+
+	return client.callTableOperationEndpoint(pod, (*mgmtapi.Client).Flush, -1, keyspaceName, tables)
+}
+
+// CallGarbageCollectEndpoint removes deleted data from the sstables of the given keyspace and tables
+func (client *NodeMgmtClient) CallGarbageCollectEndpoint(pod *corev1.Pod, jobs int, keyspaceName string, tables []string) error {
+	client.Log.Info(
+		"calling Management API garbage collect - POST /api/v0/ops/tables/garbagecollect",
+		"pod", pod.Name,
+	)
+
+	return client.callTableOperationEndpoint(pod, (*mgmtapi.Client).GarbageCollect, jobs, keyspaceName, tables)
+}
+
+// CallCompactEndpoint runs a major compaction of the given keyspace and tables
+func (client *NodeMgmtClient) CallCompactEndpoint(pod *corev1.Pod, keyspaceName string, tables []string) error {
+	client.Log.Info(
+		"calling Management API compact - POST /api/v0/ops/tables/compact",
+		"pod", pod.Name,
+	)
+
+	return client.callTableOperationEndpoint(pod, (*mgmtapi.Client).Compact, -1, keyspaceName, tables)
+}
+
+// CallCompactionsEndpoint returns the compactions running on the pod
+func (client *NodeMgmtClient) CallCompactionsEndpoint(pod *corev1.Pod) (string, error) {
+	client.Log.Info(
+		"calling Management API compactions - GET /api/v0/ops/tables/compactions",
+		"pod", pod.Name,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return "", err
 	}
 
-	if len(tables) > 0 {
-		postData["tables"] = tables
+	return client.api().GetCompactions(client.ctx(), podHost)
+}
+
+// CallThreadPoolsEndpoint returns the statistics of the thread pools of the pod
+func (client *NodeMgmtClient) CallThreadPoolsEndpoint(pod *corev1.Pod) (string, error) {
+	client.Log.Info(
+		"calling Management API thread pools - GET /api/v0/ops/node/threadpools",
+		"pod", pod.Name,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return "", err
 	}
 
-	body, err := json.Marshal(postData)
+	return client.api().GetThreadPools(client.ctx(), podHost)
+}
+
+// CallPendingHintsEndpoint returns the hints the pod holds for other nodes
+func (client *NodeMgmtClient) CallPendingHintsEndpoint(pod *corev1.Pod) (string, error) {
+	client.Log.Info(
+		"calling Management API pending hints - GET /api/v0/ops/node/hints",
+		"pod", pod.Name,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
 	if err != nil {
-		return err
+		return "", err
 	}
 
+	return client.api().GetPendingHints(client.ctx(), podHost)
+}
+
+// CallUpgradeSSTablesEndpoint rewrites the sstables of the given keyspace and tables in the current format
+func (client *NodeMgmtClient) CallUpgradeSSTablesEndpoint(pod *corev1.Pod, jobs int, keyspaceName string, tables []string) error {
+	client.Log.Info(
+		"calling Management API upgrade sstables - POST /api/v0/ops/tables/sstables/upgrade",
+		"pod", pod.Name,
+	)
+
+	return client.callTableOperationEndpoint(pod, (*mgmtapi.Client).UpgradeSSTables, jobs, keyspaceName, tables)
+}
+
+type tableOperation func(*mgmtapi.Client, context.Context, string, mgmtapi.TableOperationRequest) error
+
+func (client *NodeMgmtClient) callTableOperationEndpoint(pod *corev1.Pod, operation tableOperation, jobs int, keyspaceName string, tables []string) error {
 	podHost, err := BuildPodHostFromPod(pod)
 	if err != nil {
 		return err
 	}
 
-	request := nodeMgmtRequest{
-		endpoint: "/api/v0/ops/keyspace/cleanup",
-		host:     podHost,
-		method:   http.MethodPost,
-		timeout:  time.Second * 20,
-		body:     body,
+	return operation(client.api(), client.ctx(), podHost, tableOperationRequest(jobs, keyspaceName, tables))
+}
+
+// tableOperationRequest selects the tables of an operation, with the default
+// number of jobs of the management API when jobs is negative
+func tableOperationRequest(jobs int, keyspaceName string, tables []string) mgmtapi.TableOperationRequest {
+	request := mgmtapi.TableOperationRequest{
+		KeyspaceName: keyspaceName,
+		Tables:       tables,
+	}
+	if jobs > -1 {
+		request.Jobs = &jobs
+	}
+	return request
+}
+
+// CallRebuildEndpoint streams the data owned by the node from the given source datacenter
+func (client *NodeMgmtClient) CallRebuildEndpoint(pod *corev1.Pod, sourceDatacenter string) error {
+	client.Log.Info(
+		"calling Management API rebuild - POST /api/v0/ops/node/rebuild",
+		"pod", pod.Name,
+		"sourceDatacenter", sourceDatacenter,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return err
 	}
 
-	_, err = callNodeMgmtEndpoint(client, request, "application/json")
-	return err
+	return client.api().Rebuild(client.ctx(), podHost, sourceDatacenter)
 }
 
 // CreateKeyspace calls management API to create a new Keyspace.
 func (client *NodeMgmtClient) CreateKeyspace(pod *corev1.Pod, keyspaceName string, replicationSettings []map[string]string) error {
-	return client.modifyKeyspace("create", pod, keyspaceName, replicationSettings)
+	return client.modifyKeyspace((*mgmtapi.Client).CreateKeyspace, pod, keyspaceName, replicationSettings)
 }
 
 // AlterKeyspace modifies the keyspace by calling management API
 func (client *NodeMgmtClient) AlterKeyspace(pod *corev1.Pod, keyspaceName string, replicationSettings []map[string]string) error {
-	return client.modifyKeyspace("alter", pod, keyspaceName, replicationSettings)
+	return client.modifyKeyspace((*mgmtapi.Client).AlterKeyspace, pod, keyspaceName, replicationSettings)
 }
 
-func (client *NodeMgmtClient) modifyKeyspace(endpoint string, pod *corev1.Pod, keyspaceName string, replicationSettings []map[string]string) error {
-	postData := make(map[string]interface{})
+// CallListKeyspacesEndpoint returns the names of the keyspaces of the cluster
+func (client *NodeMgmtClient) CallListKeyspacesEndpoint(pod *corev1.Pod) ([]string, error) {
+	client.Log.Info(
+		"calling Management API list keyspaces - GET /api/v0/ops/keyspace",
+		"pod", pod.Name,
+	)
 
-	if keyspaceName == "" || replicationSettings == nil {
-		return fmt.Errorf("Keyspacename and replication settings are required")
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return nil, err
 	}
 
-	postData["keyspace_name"] = keyspaceName
-	postData["replication_settings"] = replicationSettings
+	return client.api().ListKeyspaces(client.ctx(), podHost)
+}
 
-	body, err := json.Marshal(postData)
+// CallGetKeyspaceReplicationEndpoint returns the replication of a keyspace
+func (client *NodeMgmtClient) CallGetKeyspaceReplicationEndpoint(pod *corev1.Pod, keyspaceName string) (map[string]string, error) {
+	client.Log.Info(
+		"calling Management API keyspace replication - GET /api/v0/ops/keyspace/replication",
+		"pod", pod.Name,
+		"keyspace", keyspaceName,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	return client.api().GetKeyspaceReplication(client.ctx(), podHost, keyspaceName)
+}
+
+type keyspaceOperation func(*mgmtapi.Client, context.Context, string, mgmtapi.KeyspaceRequest) error
+
+func (client *NodeMgmtClient) modifyKeyspace(operation keyspaceOperation, pod *corev1.Pod, keyspaceName string, replicationSettings []map[string]string) error {
 	podHost, err := BuildPodHostFromPod(pod)
 	if err != nil {
 		return err
 	}
 
-	request := nodeMgmtRequest{
-		endpoint: fmt.Sprintf("/api/v0/ops/keyspace/%s", endpoint),
-		host:     podHost,
-		method:   http.MethodPost,
-		timeout:  time.Second * 20,
-		body:     body,
-	}
-
-	_, err = callNodeMgmtEndpoint(client, request, "application/json")
-	return err
+	return operation(client.api(), client.ctx(), podHost, mgmtapi.KeyspaceRequest{
+		KeyspaceName:        keyspaceName,
+		ReplicationSettings: replicationSettings,
+	})
 }
 
 func (client *NodeMgmtClient) CallLifecycleStartEndpointWithReplaceIp(pod *corev1.Pod, replaceIp string) error {
@@ -292,21 +445,7 @@ func (client *NodeMgmtClient) CallLifecycleStartEndpointWithReplaceIp(pod *corev
 		"replaceIP", replaceIp,
 	)
 
-	endpoint := "/api/v0/lifecycle/start"
-
-	if replaceIp != "" {
-		endpoint = buildEndpoint(endpoint, "replace_ip", replaceIp)
-	}
-
-	request := nodeMgmtRequest{
-		endpoint: endpoint,
-		host:     podIP,
-		method:   http.MethodPost,
-		timeout:  10 * time.Second,
-	}
-
-	_, err := callNodeMgmtEndpoint(client, request, "")
-	return err
+	return client.api().StartNode(client.ctx(), podIP, replaceIp)
 }
 
 func (client *NodeMgmtClient) CallLifecycleStartEndpoint(pod *corev1.Pod) error {
@@ -324,14 +463,7 @@ func (client *NodeMgmtClient) CallReloadSeedsEndpoint(pod *corev1.Pod) error {
 		return err
 	}
 
-	request := nodeMgmtRequest{
-		endpoint: "/api/v0/ops/seeds/reload",
-		host:     podHost,
-		method:   http.MethodPost,
-	}
-
-	_, err = callNodeMgmtEndpoint(client, request, "")
-	return err
+	return client.api().ReloadSeeds(client.ctx(), podHost)
 }
 
 func (client *NodeMgmtClient) CallDecommissionNodeEndpoint(pod *corev1.Pod) error {
@@ -345,74 +477,146 @@ func (client *NodeMgmtClient) CallDecommissionNodeEndpoint(pod *corev1.Pod) erro
 		return err
 	}
 
-	request := nodeMgmtRequest{
-		endpoint: "/api/v0/ops/node/decommission",
-		host:     podHost,
-		method:   http.MethodPost,
+	return client.api().Decommission(client.ctx(), podHost)
+}
+
+// CallRepairEndpoint repairs the ranges of a keyspace the node is a replica
+// for, or only its primary ranges, and only returns once the repair is done
+func (client *NodeMgmtClient) CallRepairEndpoint(pod *corev1.Pod, keyspaceName string, full bool, primaryRange bool) error {
+	client.Log.Info(
+		"calling Management API repair - POST /api/v0/ops/node/repair",
+		"pod", pod.Name,
+		"keyspace", keyspaceName,
+		"full", full,
+		"primaryRange", primaryRange,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return err
 	}
 
-	_, err = callNodeMgmtEndpoint(client, request, "")
-	return err
+	return client.api().Repair(client.ctx(), podHost, mgmtapi.RepairRequest{
+		KeyspaceName: keyspaceName,
+		Full:         full,
+		PrimaryRange: primaryRange,
+	})
 }
 
-func callNodeMgmtEndpoint(client *NodeMgmtClient, request nodeMgmtRequest, contentType string) ([]byte, error) {
-	client.Log.Info("client::callNodeMgmtEndpoint")
-
-	url := fmt.Sprintf("%s://%s:8080%s", client.Protocol, request.host, request.endpoint)
+func (client *NodeMgmtClient) CallCreateSnapshotEndpoint(pod *corev1.Pod, snapshotName string, keyspaces []string) error {
+	client.Log.Info(
+		"calling Management API create snapshot - POST /api/v0/ops/node/snapshots",
+		"pod", pod.Name,
+		"snapshotName", snapshotName,
+	)
 
-	var reqBody io.Reader
-	if len(request.body) > 0 {
-		reqBody = bytes.NewBuffer(request.body)
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return err
 	}
 
-	req, err := http.NewRequest(request.method, url, reqBody)
+	return client.api().CreateSnapshot(client.ctx(), podHost, mgmtapi.CreateSnapshotRequest{
+		SnapshotName: snapshotName,
+		Keyspaces:    keyspaces,
+	})
+}
+
+func (client *NodeMgmtClient) CallDeleteSnapshotEndpoint(pod *corev1.Pod, snapshotName string) error {
+	client.Log.Info(
+		"calling Management API delete snapshot - DELETE /api/v0/ops/node/snapshots",
+		"pod", pod.Name,
+		"snapshotName", snapshotName,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
 	if err != nil {
-		client.Log.Error(err, "unable to create request for Node Management Endpoint")
-		return nil, err
+		return err
 	}
-	req.Close = true
 
-	if request.timeout == 0 {
-		request.timeout = 60 * time.Second
-	}
+	return client.api().DeleteSnapshot(client.ctx(), podHost, snapshotName)
+}
 
-	if request.timeout > 0 {
-		ctx, cancel := context.WithTimeout(context.Background(), request.timeout)
-		defer cancel()
-		req = req.WithContext(ctx)
-	}
+// Paths of the management API endpoints of the query logs of Cassandra 4.0
+const (
+	FullQueryLoggingEndpoint = mgmtapi.FullQueryLoggingEndpoint
+	AuditLoggingEndpoint     = mgmtapi.AuditLoggingEndpoint
+)
 
-	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
+// CallGetQueryLoggingEndpoint returns whether the query log of endpoint, either
+// FullQueryLoggingEndpoint or AuditLoggingEndpoint, is enabled on the node
+func (client *NodeMgmtClient) CallGetQueryLoggingEndpoint(pod *corev1.Pod, endpoint string) (bool, error) {
+	client.Log.Info(
+		"calling Management API query logging - GET "+endpoint,
+		"pod", pod.Name,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return false, err
 	}
 
-	res, err := client.Client.Do(req)
+	return client.api().GetQueryLogging(client.ctx(), podHost, endpoint)
+}
+
+// CallSetQueryLoggingEndpoint turns the query log of endpoint, either
+// FullQueryLoggingEndpoint or AuditLoggingEndpoint, on or off on the node
+func (client *NodeMgmtClient) CallSetQueryLoggingEndpoint(pod *corev1.Pod, endpoint string, enabled bool) error {
+	client.Log.Info(
+		"calling Management API query logging - POST "+endpoint,
+		"pod", pod.Name,
+		"enabled", enabled,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
 	if err != nil {
-		client.Log.Error(err, "unable to perform request to Node Management Endpoint")
-		return nil, err
+		return err
 	}
 
-	defer func() {
-		err := res.Body.Close()
-		if err != nil {
-			client.Log.Error(err, "unable to close response body")
-		}
-	}()
+	return client.api().SetQueryLogging(client.ctx(), podHost, endpoint, enabled)
+}
 
-	body, err := ioutil.ReadAll(res.Body)
+// CallSetLoggingLevelEndpoint sets the level of the logger of the node, or
+// resets it to the one of its parent with a blank level
+func (client *NodeMgmtClient) CallSetLoggingLevelEndpoint(pod *corev1.Pod, logger, level string) error {
+	client.Log.Info(
+		"calling Management API logging - POST /api/v0/ops/node/logging",
+		"pod", pod.Name,
+		"logger", logger,
+		"level", level,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
 	if err != nil {
-		client.Log.Error(err, "Unable to read response from Node Management Endpoint")
-		return nil, err
+		return err
 	}
 
-	goodStatus := res.StatusCode >= 200 && res.StatusCode < 300
-	if !goodStatus {
-		client.Log.Info("incorrect status code when calling Node Management Endpoint",
-			"statusCode", res.StatusCode,
-			"pod", request.host)
+	return client.api().SetLoggingLevel(client.ctx(), podHost, logger, level)
+}
+
+// CallSetSettingEndpoint changes a setting of the running node, such as its
+// compactionthroughput
+func (client *NodeMgmtClient) CallSetSettingEndpoint(pod *corev1.Pod, setting string, value int64) error {
+	client.Log.Info(
+		"calling Management API setting - POST /api/v0/ops/node/"+setting,
+		"pod", pod.Name,
+		"value", value,
+	)
 
-		return nil, fmt.Errorf("incorrect status code of %d when calling endpoint", res.StatusCode)
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return err
 	}
 
-	return body, nil
+	return client.api().SetSetting(client.ctx(), podHost, setting, value)
+}
+
+func callNodeMgmtEndpoint(client *NodeMgmtClient, request nodeMgmtRequest, contentType string) ([]byte, error) {
+	return client.api().Do(client.ctx(), request.host, mgmtapi.Request{
+		Method:      request.method,
+		Path:        request.endpoint,
+		Body:        request.body,
+		ContentType: contentType,
+		Port:        request.port,
+		Timeout:     request.timeout,
+	})
 }
@@ -4,14 +4,23 @@
 package httphelper
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
 )
 
 func Test_BuildPodHostFromPod(t *testing.T) {
@@ -102,3 +111,69 @@ func Test_parseMetadataEndpointsResponseBody(t *testing.T) {
 	assert.Equal(t, "10.233.90.45", endpoints.Entity[0].RpcAddress)
 	assert.Equal(t, "95c157dc-2811-446a-a541-9faaab2e6930", endpoints.Entity[0].HostID)
 }
+
+func Test_ForEachPod(t *testing.T) {
+	var pods []*corev1.Pod
+	for i := 0; i < 12; i++ {
+		pods = append(pods, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i)}})
+	}
+
+	client := &NodeMgmtClient{Workers: 3}
+	var lock sync.Mutex
+	called := map[string]bool{}
+	running, maxRunning := 0, 0
+	client.ForEachPod(pods, func(pod *corev1.Pod) {
+		lock.Lock()
+		called[pod.Name] = true
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+	})
+
+	assert.Len(t, called, len(pods), "every pod should be called")
+	assert.True(t, maxRunning > 1, "the pods should be called in parallel")
+	assert.True(t, maxRunning <= 3, "no more than Workers pods should be called at once")
+}
+
+type httpClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type contextKey struct{}
+
+func Test_NodeMgmtClientContextAndRetry(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-foo"},
+		Status:     corev1.PodStatus{PodIP: "1.2.3.4"},
+	}
+
+	attempts := 0
+	client := &NodeMgmtClient{
+		Client: httpClientFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			assert.Equal(t, "reconcile", req.Context().Value(contextKey{}), "the requests should have the context of the client")
+			if attempts == 1 {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("[]"))}, nil
+		}),
+		Log:   logf.Log.WithName("httphelper_test"),
+		Ctx:   context.WithValue(context.Background(), contextKey{}, "reconcile"),
+		Retry: mgmtapi.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+	}
+
+	compactions, err := client.CallCompactionsEndpoint(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", compactions)
+	assert.Equal(t, 2, attempts, "the failed request should be retried with the retry policy of the client")
+}
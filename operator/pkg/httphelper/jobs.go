@@ -0,0 +1,105 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package httphelper
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+)
+
+// CallJobDetailsEndpoint returns the progress of a job submitted to the node
+func (client *NodeMgmtClient) CallJobDetailsEndpoint(pod *corev1.Pod, jobID string) (*mgmtapi.Job, error) {
+	client.Log.Info(
+		"calling Management API job details - GET /api/v0/ops/executor/job",
+		"pod", pod.Name,
+		"jobId", jobID,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.api().GetJob(client.ctx(), podHost, jobID)
+}
+
+// CallKeyspaceCleanupAsyncEndpoint submits a cleanup and returns the ID of its job
+func (client *NodeMgmtClient) CallKeyspaceCleanupAsyncEndpoint(pod *corev1.Pod, jobs int, keyspaceName string, tables []string) (string, error) {
+	client.Log.Info(
+		"calling Management API keyspace cleanup - POST /api/v1/ops/keyspace/cleanup",
+		"pod", pod.Name,
+	)
+
+	return client.callAsyncTableOperationEndpoint(pod, (*mgmtapi.Client).CleanupAsync, jobs, keyspaceName, tables)
+}
+
+// CallFlushAsyncEndpoint submits a flush and returns the ID of its job
+func (client *NodeMgmtClient) CallFlushAsyncEndpoint(pod *corev1.Pod, keyspaceName string, tables []string) (string, error) {
+	client.Log.Info(
+		"calling Management API flush - POST /api/v1/ops/tables/flush",
+		"pod", pod.Name,
+	)
+
+	return client.callAsyncTableOperationEndpoint(pod, (*mgmtapi.Client).FlushAsync, -1, keyspaceName, tables)
+}
+
+// CallGarbageCollectAsyncEndpoint submits a garbage collection and returns the ID of its job
+func (client *NodeMgmtClient) CallGarbageCollectAsyncEndpoint(pod *corev1.Pod, jobs int, keyspaceName string, tables []string) (string, error) {
+	client.Log.Info(
+		"calling Management API garbage collect - POST /api/v1/ops/tables/garbagecollect",
+		"pod", pod.Name,
+	)
+
+	return client.callAsyncTableOperationEndpoint(pod, (*mgmtapi.Client).GarbageCollectAsync, jobs, keyspaceName, tables)
+}
+
+// CallCompactAsyncEndpoint submits a major compaction and returns the ID of its job
+func (client *NodeMgmtClient) CallCompactAsyncEndpoint(pod *corev1.Pod, keyspaceName string, tables []string) (string, error) {
+	client.Log.Info(
+		"calling Management API compact - POST /api/v1/ops/tables/compact",
+		"pod", pod.Name,
+	)
+
+	return client.callAsyncTableOperationEndpoint(pod, (*mgmtapi.Client).CompactAsync, -1, keyspaceName, tables)
+}
+
+// CallUpgradeSSTablesAsyncEndpoint submits an upgrade of the sstables and returns the ID of its job
+func (client *NodeMgmtClient) CallUpgradeSSTablesAsyncEndpoint(pod *corev1.Pod, jobs int, keyspaceName string, tables []string) (string, error) {
+	client.Log.Info(
+		"calling Management API upgrade sstables - POST /api/v1/ops/tables/sstables/upgrade",
+		"pod", pod.Name,
+	)
+
+	return client.callAsyncTableOperationEndpoint(pod, (*mgmtapi.Client).UpgradeSSTablesAsync, jobs, keyspaceName, tables)
+}
+
+// CallRebuildAsyncEndpoint submits a rebuild from the source datacenter and returns the ID of its job
+func (client *NodeMgmtClient) CallRebuildAsyncEndpoint(pod *corev1.Pod, sourceDatacenter string) (string, error) {
+	client.Log.Info(
+		"calling Management API rebuild - POST /api/v1/ops/node/rebuild",
+		"pod", pod.Name,
+		"sourceDatacenter", sourceDatacenter,
+	)
+
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return "", err
+	}
+
+	return client.api().RebuildAsync(client.ctx(), podHost, sourceDatacenter)
+}
+
+type asyncTableOperation func(*mgmtapi.Client, context.Context, string, mgmtapi.TableOperationRequest) (string, error)
+
+func (client *NodeMgmtClient) callAsyncTableOperationEndpoint(pod *corev1.Pod, operation asyncTableOperation, jobs int, keyspaceName string, tables []string) (string, error) {
+	podHost, err := BuildPodHostFromPod(pod)
+	if err != nil {
+		return "", err
+	}
+
+	return operation(client.api(), client.ctx(), podHost, tableOperationRequest(jobs, keyspaceName, tables))
+}
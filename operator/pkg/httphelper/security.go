@@ -13,7 +13,10 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/go-logr/logr"
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+	"github.com/k8ssandra/cass-operator/operator/pkg/statecache"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -29,6 +32,10 @@ const (
 	caCertPath = "/management-api-certs/ca.crt"
 	tlsCrt     = "/management-api-certs/tls.crt"
 	tlsKey     = "/management-api-certs/tls.key"
+
+	// managementApiClientKind is the kind of the state cache entry of the
+	// client built from the secret of the manual managementApiAuth
+	managementApiClientKind = "management_api_client"
 )
 
 // API for Node Management mAuth Config
@@ -48,6 +55,27 @@ func BuildManagementApiHttpClient(dc *api.CassandraDatacenter, client client.Cli
 	return provider.BuildHttpClient(client, ctx)
 }
 
+// NewMgmtClient builds a NodeMgmtClient for the pods of the given datacenter
+func NewMgmtClient(ctx context.Context, client client.Client, dc *api.CassandraDatacenter, logger logr.Logger) (NodeMgmtClient, error) {
+	httpClient, err := BuildManagementApiHttpClient(dc, client, ctx)
+	if err != nil {
+		return NodeMgmtClient{}, err
+	}
+
+	protocol, err := GetManagementApiProtocol(dc)
+	if err != nil {
+		return NodeMgmtClient{}, err
+	}
+
+	return NodeMgmtClient{
+		Client:   httpClient,
+		Log:      logger,
+		Protocol: protocol,
+		Ctx:      ctx,
+		Retry:    DefaultRetryPolicy,
+	}, nil
+}
+
 func AddManagementApiServerSecurity(dc *api.CassandraDatacenter, pod *corev1.PodTemplateSpec) error {
 	provider, err := BuildManagmenetApiSecurityProvider(dc)
 	if err != nil {
@@ -119,7 +147,7 @@ func (provider *InsecureManagementApiSecurityProvider) GetProtocol() string {
 }
 
 func (provider *InsecureManagementApiSecurityProvider) BuildHttpClient(client client.Client, ctx context.Context) (HttpClient, error) {
-	return http.DefaultClient, nil
+	return mgmtapi.DefaultHTTPClient, nil
 }
 
 func (provider *InsecureManagementApiSecurityProvider) AddServerSecurity(pod *corev1.PodTemplateSpec) error {
@@ -131,8 +159,9 @@ func (provider *InsecureManagementApiSecurityProvider) ValidateConfig(client cli
 }
 
 type ManualManagementApiSecurityProvider struct {
-	Namespace string
-	Config    *api.ManagementApiAuthManualConfig
+	Namespace  string
+	Config     *api.ManagementApiAuthManualConfig
+	Datacenter types.NamespacedName
 }
 
 func buildManualApiSecurityProvider(dc *api.CassandraDatacenter) (ManagementApiSecurityProvider, error) {
@@ -140,6 +169,7 @@ func buildManualApiSecurityProvider(dc *api.CassandraDatacenter) (ManagementApiS
 		provider := &ManualManagementApiSecurityProvider{}
 		provider.Config = dc.Spec.ManagementApiAuth.Manual
 		provider.Namespace = dc.ObjectMeta.Namespace
+		provider.Datacenter = types.NamespacedName{Namespace: dc.Namespace, Name: dc.Name}
 		return provider, nil
 	}
 	return nil, nil
@@ -703,6 +733,13 @@ func (provider *ManualManagementApiSecurityProvider) BuildHttpClient(client clie
 		return nil, err
 	}
 
+	// The client, and the connections it keeps open to the pods, are reused
+	// until the secret or the manual config of the datacenter change
+	versions := []string{secret.ResourceVersion, fmt.Sprintf("%+v", *provider.Config)}
+	if cached, ok := statecache.Datacenters.Get(provider.Datacenter, managementApiClientKind, versions...); ok {
+		return cached.(HttpClient), nil
+	}
+
 	err = validateSecretStructure(secret)
 	if err != nil {
 		// Secret didn't look the way we expect
@@ -734,9 +771,9 @@ func (provider *ManualManagementApiSecurityProvider) BuildHttpClient(client clie
 		VerifyPeerCertificate: buildVerifyPeerCertificateNoHostCheck(caCertPool),
 	}
 	tlsConfig.BuildNameToCertificate()
-	transport := &http.Transport{TLSClientConfig: tlsConfig}
-	httpClient := &http.Client{Transport: transport}
+	httpClient := &http.Client{Transport: mgmtapi.NewPooledTransport(tlsConfig)}
 
+	statecache.Datacenters.Put(provider.Datacenter, managementApiClientKind, httpClient, versions...)
 	return httpClient, nil
 }
 
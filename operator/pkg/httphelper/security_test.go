@@ -4,6 +4,7 @@
 package httphelper
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/pem"
 	"io/ioutil"
@@ -11,6 +12,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 )
 
 func helperLoadBytes(t *testing.T, name string) []byte {
@@ -98,3 +104,43 @@ func Test_validatePrivateKey(t *testing.T) {
 		t, 1, len(errs),
 		"Should consider an empty key as an invalid key")
 }
+
+func Test_ManualBuildHttpClient_ReusesClientUntilSecretChanges(t *testing.T) {
+	clientSecret := func(resourceVersion string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mgmt-client", Namespace: "ns", ResourceVersion: resourceVersion},
+			Type:       "kubernetes.io/tls",
+			Data: map[string][]byte{
+				"ca.crt":  helperLoadBytes(t, "ca.crt"),
+				"tls.crt": helperLoadBytes(t, "client.crt"),
+				"tls.key": helperLoadBytes(t, "client.key"),
+			},
+		}
+	}
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns"},
+		Spec: api.CassandraDatacenterSpec{
+			ManagementApiAuth: api.ManagementApiAuthConfig{
+				Manual: &api.ManagementApiAuthManualConfig{ClientSecretName: "mgmt-client", ServerSecretName: "mgmt-server"},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	client := fake.NewFakeClient(clientSecret("1"))
+	first, err := BuildManagementApiHttpClient(dc, client, ctx)
+	assert.NoError(t, err)
+	second, err := BuildManagementApiHttpClient(dc, client, ctx)
+	assert.NoError(t, err)
+	assert.True(t, first == second, "the client should be reused while the secret does not change")
+
+	client = fake.NewFakeClient(clientSecret("2"))
+	third, err := BuildManagementApiHttpClient(dc, client, ctx)
+	assert.NoError(t, err)
+	assert.False(t, first == third, "the client should be built again once the secret changed")
+
+	dc.Spec.ManagementApiAuth.Manual.SkipSecretValidation = true
+	fourth, err := BuildManagementApiHttpClient(dc, client, ctx)
+	assert.NoError(t, err)
+	assert.False(t, third == fourth, "the client should be built again once the manual config changed")
+}
@@ -0,0 +1,128 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package images
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// EnvImageConfigFile is the path of a YAML file, usually mounted from a
+	// ConfigMap, that maps server versions to images
+	EnvImageConfigFile = "IMAGE_CONFIG_FILE"
+)
+
+// ImageConfig overrides the default images of the operator. It lets platform
+// teams pin the exact digests of the images used by their clusters, rather
+// than the tags baked into the operator. For example:
+//
+//	cassandra:
+//	  "3.11.10": registry.example.com/cass-management-api@sha256:0123...
+//	dse:
+//	  "6.8.4": registry.example.com/dse-server@sha256:4567...
+//	configBuilder: registry.example.com/cass-config-builder@sha256:89ab...
+//
+// The images of the file are used as they are: the default registry override
+// does not apply to them.
+type ImageConfig struct {
+	Cassandra        map[string]string `yaml:"cassandra,omitempty"`
+	DSE              map[string]string `yaml:"dse,omitempty"`
+	UBICassandra     map[string]string `yaml:"ubiCassandra,omitempty"`
+	UBIDSE           map[string]string `yaml:"ubiDse,omitempty"`
+	ConfigBuilder    string            `yaml:"configBuilder,omitempty"`
+	UBIConfigBuilder string            `yaml:"ubiConfigBuilder,omitempty"`
+	SystemLogger     string            `yaml:"systemLogger,omitempty"`
+	BusyBox          string            `yaml:"busybox,omitempty"`
+	Reaper           string            `yaml:"reaper,omitempty"`
+	Medusa           string            `yaml:"medusa,omitempty"`
+}
+
+// ParseImageConfig reads an image config from YAML
+func ParseImageConfig(data []byte) (*ImageConfig, error) {
+	config := &ImageConfig{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// LoadImageConfig reads the image config of the operator. It returns nil
+// when none is configured. The file is read on each call, so that updates of
+// the ConfigMap it is mounted from are picked up without a restart.
+func LoadImageConfig() (*ImageConfig, error) {
+	path := os.Getenv(EnvImageConfigFile)
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read image config %s: %w", path, err)
+	}
+	config, err := ParseImageConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse image config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// ServerImage returns the image configured for the server, or an empty
+// string if there is none
+func (config *ImageConfig) ServerImage(serverType, version string, ubi bool) string {
+	if config == nil {
+		return ""
+	}
+
+	var images map[string]string
+	switch {
+	case serverType == "dse" && ubi:
+		images = config.UBIDSE
+	case serverType == "dse":
+		images = config.DSE
+	case serverType == "cassandra" && ubi:
+		images = config.UBICassandra
+	case serverType == "cassandra":
+		images = config.Cassandra
+	}
+	return images[version]
+}
+
+// Image returns the image configured for one of the images of the operator
+// other than the server images, or an empty string if there is none
+func (config *ImageConfig) Image(name Image) string {
+	if config == nil {
+		return ""
+	}
+
+	switch name {
+	case ConfigBuilder:
+		return config.ConfigBuilder
+	case UBIConfigBuilder:
+		return config.UBIConfigBuilder
+	case SystemLoggerImage:
+		return config.SystemLogger
+	case BusyBox:
+		return config.BusyBox
+	case Reaper:
+		return config.Reaper
+	case Medusa:
+		return config.Medusa
+	}
+	return ""
+}
+
+// loadImageConfigOrLog is for the lookups that cannot fail: an image config
+// that cannot be read is logged, and the defaults are used instead
+func loadImageConfigOrLog() *ImageConfig {
+	config, err := LoadImageConfig()
+	if err != nil {
+		log.Error(err, "Could not load image config, using the default images")
+		return nil
+	}
+	return config
+}
@@ -14,14 +14,18 @@ import (
 )
 
 var ValidDsePrefixes = []string{"6.8"}
-var ValidOssPrefixes = []string{"3.11", "4.0"}
+var ValidOssPrefixes = []string{"3.11", "4.0", "4.1", "5.0"}
+
+// The Cassandra versions the default config builder image knows how to render
+// the configuration of
+var defaultConfigBuilderOssPrefixes = []string{"3.11", "4.0"}
 
 const (
 	envDefaultRegistryOverride            = "DEFAULT_CONTAINER_REGISTRY_OVERRIDE"
 	envDefaultRegistryOverridePullSecrets = "DEFAULT_CONTAINER_REGISTRY_OVERRIDE_PULL_SECRETS"
 	EnvBaseImageOS                        = "BASE_IMAGE_OS"
 	ValidDseVersionRegexp                 = "6\\.8\\.\\d+"
-	ValidOssVersionRegexp                 = "(3\\.11\\.\\d+)|(4\\.0\\.\\d+)"
+	ValidOssVersionRegexp                 = "(3\\.11\\.\\d+)|(4\\.0\\.\\d+)|(4\\.1\\.\\d+)|(5\\.0\\.\\d+)"
 	UbiImageSuffix                        = "-ubi7"
 )
 
@@ -71,6 +75,8 @@ const (
 	BusyBox
 	BaseImageOS
 	SystemLoggerImage
+	Reaper
+	Medusa
 
 	// NOTE: This line MUST be last in the const expression
 	ImageEnumLength int = iota
@@ -104,6 +110,8 @@ var imageLookupMap map[Image]string = map[Image]string{
 
 	BusyBox:           "busybox:1.32.0-uclibc",
 	SystemLoggerImage: "k8ssandra/system-logger:9c4c3692",
+	Reaper:            "thelastpickle/cassandra-reaper:2.2.2",
+	Medusa:            "k8ssandra/medusa:0.11.3",
 }
 
 var versionToOSSCassandra map[string]Image = map[string]Image{
@@ -148,6 +156,26 @@ func IsOssVersionSupported(version string) bool {
 	return validVersions.MatchString(version)
 }
 
+// IsOssVersionRenderedByConfigBuilder returns whether the config builder image
+// of the operator renders the configuration of the Cassandra version. A config
+// builder image set in the image config is trusted to know the version.
+func IsOssVersionRenderedByConfigBuilder(version string) bool {
+	configBuilder := ConfigBuilder
+	if shouldUseUBI() {
+		configBuilder = UBIConfigBuilder
+	}
+	if loadImageConfigOrLog().Image(configBuilder) != "" {
+		return true
+	}
+
+	for _, prefix := range defaultConfigBuilderOssPrefixes {
+		if strings.HasPrefix(version, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
 func stripRegistry(image string) string {
 	comps := strings.Split(image, "/")
 
@@ -185,6 +213,10 @@ func CalculateDockerImageRunsAsCassandra(version string) bool {
 }
 
 func GetImage(name Image) string {
+	if image := loadImageConfigOrLog().Image(name); image != "" {
+		return image
+	}
+
 	image, ok := imageLookupMap[name]
 	if !ok {
 		if name == BaseImageOS {
@@ -225,6 +257,14 @@ func GetCassandraImage(serverType, version string) (string, error) {
 		cassandraMap = versionToUBIOSSCassandra
 	}
 
+	imageConfig, err := LoadImageConfig()
+	if err != nil {
+		return "", err
+	}
+	if image := imageConfig.ServerImage(serverType, version, shouldUseUBI()); image != "" {
+		return image, nil
+	}
+
 	switch serverType {
 	case "dse":
 		imageKey, found = dseMap[version]
@@ -252,10 +292,10 @@ func GetCassandraImage(serverType, version string) (string, error) {
 		}
 
 		if shouldUseUBI() {
-			return fmt.Sprintf("%s%s", fallbackImageName, UbiImageSuffix), nil
+			fallbackImageName = fmt.Sprintf("%s%s", fallbackImageName, UbiImageSuffix)
 		}
 
-		return fallbackImageName, nil
+		return applyDefaultRegistryOverride(fallbackImageName), nil
 	}
 
 	return GetImage(imageKey), nil
@@ -273,13 +313,28 @@ func GetSystemLoggerImage() string {
 	return GetImage(SystemLoggerImage)
 }
 
+// AddDefaultRegistryImagePullSecrets adds the pull secrets of the default
+// registry override, a comma-separated list of secret names, to the pod
 func AddDefaultRegistryImagePullSecrets(podSpec *corev1.PodSpec) bool {
-	secretName := os.Getenv(envDefaultRegistryOverridePullSecrets)
-	if secretName != "" {
-		podSpec.ImagePullSecrets = append(
-			podSpec.ImagePullSecrets,
-			corev1.LocalObjectReference{Name: secretName})
-		return true
+	added := false
+	for _, secretName := range strings.Split(os.Getenv(envDefaultRegistryOverridePullSecrets), ",") {
+		secretName = strings.TrimSpace(secretName)
+		if secretName != "" {
+			AddImagePullSecret(podSpec, secretName)
+			added = true
+		}
 	}
-	return false
+	return added
+}
+
+// AddImagePullSecret adds a pull secret to the pod, unless it already has it
+func AddImagePullSecret(podSpec *corev1.PodSpec, secretName string) {
+	for _, secret := range podSpec.ImagePullSecrets {
+		if secret.Name == secretName {
+			return
+		}
+	}
+	podSpec.ImagePullSecrets = append(
+		podSpec.ImagePullSecrets,
+		corev1.LocalObjectReference{Name: secretName})
 }
@@ -5,12 +5,14 @@ package images
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func tempSetEnv(name, value string) (func(), error) {
@@ -57,6 +59,28 @@ func Test_DefaultRegistryOverride(t *testing.T) {
 	assert.True(t, strings.HasPrefix(image, "localhost:5000/"))
 }
 
+func Test_DefaultRegistryOverride_FallbackImages(t *testing.T) {
+	restore, err := tempSetEnv(envDefaultRegistryOverride, "localhost:5000")
+	require.NoError(t, err)
+	defer restore()
+
+	image, err := GetCassandraImage("cassandra", "3.11.99")
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:5000/datastax/cassandra-mgmtapi:3.11.99", image)
+}
+
+func Test_AddDefaultRegistryImagePullSecrets(t *testing.T) {
+	restore, err := tempSetEnv(envDefaultRegistryOverridePullSecrets, "regcred, other-regcred")
+	require.NoError(t, err)
+	defer restore()
+
+	podSpec := &corev1.PodSpec{
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "other-regcred"}},
+	}
+	assert.True(t, AddDefaultRegistryImagePullSecrets(podSpec))
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "other-regcred"}, {Name: "regcred"}}, podSpec.ImagePullSecrets)
+}
+
 func Test_CalculateDockerImageRunsAsCassandra(t *testing.T) {
 	tests := []struct {
 		version string
@@ -82,3 +106,59 @@ func Test_CalculateDockerImageRunsAsCassandra(t *testing.T) {
 		assert.Equal(t, got, tt.want, fmt.Sprintf("Version: %s should not have returned %v", tt.version, got))
 	}
 }
+
+func Test_ImageConfig(t *testing.T) {
+	file, err := ioutil.TempFile("", "image-config-*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`
+cassandra:
+  "3.11.10": registry.example.com/cass-management-api@sha256:0123
+configBuilder: registry.example.com/cass-config-builder@sha256:4567
+`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	restore, err := tempSetEnv(EnvImageConfigFile, file.Name())
+	require.NoError(t, err)
+	defer restore()
+	restoreRegistry, err := tempSetEnv(envDefaultRegistryOverride, "localhost:5000")
+	require.NoError(t, err)
+	defer restoreRegistry()
+
+	image, err := GetCassandraImage("cassandra", "3.11.10")
+	assert.NoError(t, err)
+	assert.Equal(t, "registry.example.com/cass-management-api@sha256:0123", image)
+	assert.Equal(t, "registry.example.com/cass-config-builder@sha256:4567", GetConfigBuilderImage())
+
+	// Versions and images that are not in the config keep their defaults
+	image, err = GetCassandraImage("cassandra", "4.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:5000/k8ssandra/cass-management-api:4.0.0-v0.1.25", image)
+	assert.Equal(t, "localhost:5000/k8ssandra/system-logger:9c4c3692", GetSystemLoggerImage())
+
+	// The config builder of the image config is trusted with every version
+	assert.True(t, IsOssVersionRenderedByConfigBuilder("5.0.0"))
+}
+
+func Test_IsOssVersionRenderedByConfigBuilder(t *testing.T) {
+	assert.True(t, IsOssVersionRenderedByConfigBuilder("3.11.10"))
+	assert.True(t, IsOssVersionRenderedByConfigBuilder("4.0.1"))
+	assert.False(t, IsOssVersionRenderedByConfigBuilder("4.1.0"))
+	assert.False(t, IsOssVersionRenderedByConfigBuilder("5.0.0"))
+}
+
+func Test_ImageConfig_Invalid(t *testing.T) {
+	_, err := ParseImageConfig([]byte(`cassandra: ["3.11.10"]`))
+	assert.Error(t, err)
+
+	_, err = ParseImageConfig([]byte(`casandra: {}`))
+	assert.Error(t, err, "unknown fields should be rejected")
+
+	restore, err := tempSetEnv(EnvImageConfigFile, "/does/not/exist.yaml")
+	require.NoError(t, err)
+	defer restore()
+
+	_, err = GetCassandraImage("cassandra", "3.11.10")
+	assert.Error(t, err)
+}
@@ -0,0 +1,210 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package jobtracker runs long-running operations of the management API, such
+// as cleanups, as asynchronous jobs that are followed across reconciliations
+// rather than blocking them. The ID of the job running on a pod is recorded
+// in an annotation of the pod, so that it survives restarts of the operator.
+// The operations the management API cannot run as jobs are run in the
+// background of the operator instead, and followed the same way.
+package jobtracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+)
+
+// Operation is a long-running operation of the management API
+type Operation struct {
+	// Key names the operation in the annotation of the pod. It must be a
+	// valid annotation name, and unique among the operations that can run on
+	// the pod at the same time.
+	Key string
+	// Submit starts the operation as a job and returns the ID of the job. The
+	// operations without it are always run in the background.
+	Submit func() (string, error)
+	// Run performs the operation synchronously, for the management APIs that
+	// cannot run it as a job. It runs in the background of the operator.
+	Run func() error
+}
+
+// backgroundJobPrefix starts the IDs of the jobs the operator runs itself
+const backgroundJobPrefix = "cass-operator-"
+
+type backgroundJob struct {
+	done bool
+	err  error
+}
+
+// backgroundJobs are the operations running in the background of the
+// operator. A restart of the operator loses them, the way a restart of
+// Cassandra loses the jobs of the management API.
+var backgroundJobs = struct {
+	sync.Mutex
+	jobs map[string]*backgroundJob
+}{jobs: map[string]*backgroundJob{}}
+
+// startBackgroundJob runs the operation in a goroutine and returns the ID of
+// its job
+func startBackgroundJob(run func() error) string {
+	jobID := backgroundJobPrefix + uuid.New().String()
+	job := &backgroundJob{}
+
+	backgroundJobs.Lock()
+	backgroundJobs.jobs[jobID] = job
+	backgroundJobs.Unlock()
+
+	go func() {
+		err := run()
+		backgroundJobs.Lock()
+		defer backgroundJobs.Unlock()
+		job.done = true
+		job.err = err
+	}()
+	return jobID
+}
+
+// backgroundJobStatus returns whether the job still exists, and whether it is
+// done along with its error
+func backgroundJobStatus(jobID string) (bool, bool, error) {
+	backgroundJobs.Lock()
+	defer backgroundJobs.Unlock()
+	job, ok := backgroundJobs.jobs[jobID]
+	if !ok {
+		return false, false, nil
+	}
+	return true, job.done, job.err
+}
+
+func forgetBackgroundJob(jobID string) {
+	backgroundJobs.Lock()
+	defer backgroundJobs.Unlock()
+	delete(backgroundJobs.jobs, jobID)
+}
+
+// Tracker starts operations on pods and follows their jobs
+type Tracker struct {
+	Client     client.Client
+	MgmtClient *httphelper.NodeMgmtClient
+}
+
+// AnnotationName returns the annotation of a pod that records the job of the
+// operation with the given key
+func AnnotationName(key string) string {
+	return api.MgmtApiJobAnnotationPrefix + key
+}
+
+// JobID returns the ID of the job of the operation running on the pod, if any
+func JobID(pod *corev1.Pod, key string) string {
+	return pod.Annotations[AnnotationName(key)]
+}
+
+// Poll starts the operation on the pod, or checks on the job started by an
+// earlier call. It returns true once the operation is done, along with the
+// error it failed with, if any. While the operation is running it returns
+// false, and an error only when the progress of the job could not be read.
+func (t *Tracker) Poll(ctx context.Context, pod *corev1.Pod, operation Operation) (bool, error) {
+	if jobID := JobID(pod, operation.Key); jobID != "" {
+		return t.checkJob(ctx, pod, operation, jobID)
+	}
+
+	var jobID string
+	var err error
+	if operation.Submit != nil {
+		jobID, err = operation.Submit()
+	}
+	if operation.Submit == nil || mgmtapi.IsNotSupported(err) {
+		t.MgmtClient.Log.Info("running the operation in the background of the operator",
+			"pod", pod.Name,
+			"operation", operation.Key)
+		jobID, err = startBackgroundJob(operation.Run), nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	if err := t.setJobID(ctx, pod, operation.Key, jobID); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func (t *Tracker) checkJob(ctx context.Context, pod *corev1.Pod, operation Operation, jobID string) (bool, error) {
+	if strings.HasPrefix(jobID, backgroundJobPrefix) {
+		return t.checkBackgroundJob(ctx, pod, operation, jobID)
+	}
+
+	job, err := t.MgmtClient.CallJobDetailsEndpoint(pod, jobID)
+	if mgmtapi.IsJobNotFound(err) {
+		// The node restarted, and took the job with it
+		if err := t.setJobID(ctx, pod, operation.Key, ""); err != nil {
+			return false, err
+		}
+		return true, fmt.Errorf("job %s of %s was lost by pod %s", jobID, operation.Key, pod.Name)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	switch job.Status {
+	case mgmtapi.JobCompleted:
+		if err := t.setJobID(ctx, pod, operation.Key, ""); err != nil {
+			return false, err
+		}
+		return true, nil
+	case mgmtapi.JobError:
+		if err := t.setJobID(ctx, pod, operation.Key, ""); err != nil {
+			return false, err
+		}
+		return true, fmt.Errorf("job %s of %s failed on pod %s: %s", jobID, operation.Key, pod.Name, job.Error)
+	}
+	return false, nil
+}
+
+func (t *Tracker) checkBackgroundJob(ctx context.Context, pod *corev1.Pod, operation Operation, jobID string) (bool, error) {
+	found, done, jobErr := backgroundJobStatus(jobID)
+	if !found {
+		// The operator restarted, and took the job with it
+		if err := t.setJobID(ctx, pod, operation.Key, ""); err != nil {
+			return false, err
+		}
+		return true, fmt.Errorf("job %s of %s on pod %s was lost by the operator", jobID, operation.Key, pod.Name)
+	}
+	if !done {
+		return false, nil
+	}
+
+	if err := t.setJobID(ctx, pod, operation.Key, ""); err != nil {
+		return false, err
+	}
+	forgetBackgroundJob(jobID)
+	if jobErr != nil {
+		return true, fmt.Errorf("job %s of %s failed on pod %s: %v", jobID, operation.Key, pod.Name, jobErr)
+	}
+	return true, nil
+}
+
+// setJobID records the job of the operation on the pod, or forgets it when
+// jobID is empty
+func (t *Tracker) setJobID(ctx context.Context, pod *corev1.Pod, key, jobID string) error {
+	patch := client.MergeFrom(pod.DeepCopy())
+	if jobID == "" {
+		delete(pod.Annotations, AnnotationName(key))
+	} else {
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[AnnotationName(key)] = jobID
+	}
+	return t.Client.Patch(ctx, pod, patch)
+}
@@ -0,0 +1,123 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package jobtracker
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+func TestPoll_LostJob(t *testing.T) {
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/executor/job"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil).
+		Once()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pod-a",
+			Namespace:   "default",
+			Annotations: map[string]string{AnnotationName("cleanup"): "job-1"},
+		},
+		Status: corev1.PodStatus{PodIP: "1.2.3.4"},
+	}
+	tracker := &Tracker{
+		Client: fake.NewFakeClient(pod),
+		MgmtClient: &httphelper.NodeMgmtClient{
+			Client:   mockHttpClient,
+			Log:      zap.Logger(true),
+			Protocol: "http",
+		},
+	}
+
+	submitted := false
+	done, err := tracker.Poll(context.Background(), pod, Operation{
+		Key: "cleanup",
+		Submit: func() (string, error) {
+			submitted = true
+			return "job-2", nil
+		},
+	})
+
+	// The operation failed, and is submitted again by the next poll
+	assert.True(t, done)
+	assert.Error(t, err)
+	assert.False(t, submitted)
+	assert.Empty(t, JobID(pod, "cleanup"))
+	mockHttpClient.AssertExpectations(t)
+}
+
+func TestPoll_InBackground(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Namespace: "default"},
+		Status:     corev1.PodStatus{PodIP: "1.2.3.4"},
+	}
+	tracker := &Tracker{
+		Client: fake.NewFakeClient(pod),
+		MgmtClient: &httphelper.NodeMgmtClient{
+			Client:   &mocks.HttpClient{},
+			Log:      zap.Logger(true),
+			Protocol: "http",
+		},
+	}
+
+	release := make(chan struct{})
+	operation := Operation{
+		Key: "drain",
+		Run: func() error {
+			<-release
+			return fmt.Errorf("boom")
+		},
+	}
+
+	// The operation without a job of the management API runs in the
+	// background of the operator
+	done, err := tracker.Poll(context.Background(), pod, operation)
+	assert.False(t, done)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(JobID(pod, "drain"), backgroundJobPrefix))
+
+	done, err = tracker.Poll(context.Background(), pod, operation)
+	assert.False(t, done)
+	assert.NoError(t, err)
+
+	close(release)
+	for i := 0; i < 100 && !done; i++ {
+		time.Sleep(10 * time.Millisecond)
+		done, err = tracker.Poll(context.Background(), pod, operation)
+	}
+	assert.True(t, done)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+	assert.Empty(t, JobID(pod, "drain"))
+
+	// A job of an operator that restarted is lost
+	pod.Annotations = map[string]string{AnnotationName("drain"): backgroundJobPrefix + "lost"}
+	done, err = tracker.Poll(context.Background(), pod, operation)
+	assert.True(t, done)
+	assert.Error(t, err)
+	assert.Empty(t, JobID(pod, "drain"))
+}
@@ -0,0 +1,261 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package mgmtapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// DefaultPort is the port the management API listens on in the pods
+const DefaultPort = 8080
+
+// DefaultTimeout is the timeout of requests that do not set their own
+const DefaultTimeout = 60 * time.Second
+
+// HTTPClient sends the requests of a Client. *http.Client implements it.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RetryPolicy tells how many times a failed request is sent again
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a request is sent before the error is
+	// returned. Zero and one both mean that requests are not retried.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. It doubles for every
+	// following retry.
+	Backoff time.Duration
+}
+
+// Client calls the management API of Cassandra nodes
+type Client struct {
+	HTTPClient HTTPClient
+	// Protocol is either http or https, http when empty
+	Protocol string
+	// Port is the port of the management API, DefaultPort when zero
+	Port  int
+	Retry RetryPolicy
+	Log   logr.Logger
+}
+
+// NewClient returns a client that calls the management API over plain HTTP
+func NewClient(httpClient HTTPClient, logger logr.Logger) *Client {
+	return &Client{
+		HTTPClient: httpClient,
+		Protocol:   "http",
+		Log:        logger,
+	}
+}
+
+// NewTLSClient returns a client that calls the management API over HTTPS
+// with the given TLS configuration, which usually holds the client
+// certificate and the CA of the management API
+func NewTLSClient(tlsConfig *tls.Config, logger logr.Logger) *Client {
+	return &Client{
+		HTTPClient: &http.Client{Transport: NewPooledTransport(tlsConfig)},
+		Protocol:   "https",
+		Log:        logger,
+	}
+}
+
+// NewPooledTransport returns a transport that keeps idle connections open to
+// every node it called, rather than to the 100 hosts of http.DefaultTransport,
+// so that polling all the nodes of a large datacenter reuses its connections
+// instead of opening new ones on every reconciliation. tlsConfig may be nil.
+func NewPooledTransport(tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConns:        0,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// DefaultHTTPClient is the HTTP client shared by the plain HTTP clients of
+// the management API, over a pooled transport
+var DefaultHTTPClient = newDefaultHTTPClient()
+
+func newDefaultHTTPClient() *http.Client {
+	transport := NewPooledTransport(nil)
+	transport.Proxy = http.ProxyFromEnvironment
+	return &http.Client{Transport: transport}
+}
+
+// Request is a call to an endpoint of the management API
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   []byte
+	// ContentType of the body, if any
+	ContentType string
+	// Port overrides the port of the client, for the agents that serve the
+	// same API on another port
+	Port int
+	// Timeout is DefaultTimeout when zero. A negative timeout only relies on
+	// the context.
+	Timeout time.Duration
+	// Idempotent requests are retried like GET requests
+	Idempotent bool
+}
+
+// StatusError is returned when the management API answers with a status other
+// than 2xx
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("incorrect status code of %d when calling endpoint %s %s", e.StatusCode, e.Method, e.Path)
+}
+
+// Do sends the request to the management API of host and returns the body of
+// the response
+func (c *Client) Do(ctx context.Context, host string, request Request) ([]byte, error) {
+	c.Log.Info("sending request to Node Management Endpoint",
+		"pod", host,
+		"method", request.Method,
+		"path", request.Path)
+
+	attempts := c.Retry.MaxAttempts
+	if attempts < 1 || !request.retryable() {
+		attempts = 1
+	}
+	backoff := c.Retry.Backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var body []byte
+		body, err = c.do(ctx, host, request)
+		if err == nil || attempt >= attempts || !isRetryableError(err) {
+			return body, err
+		}
+
+		c.Log.Info("retrying request to Node Management Endpoint",
+			"pod", host,
+			"path", request.Path,
+			"attempt", attempt,
+			"error", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) do(ctx context.Context, host string, request Request) ([]byte, error) {
+	protocol := c.Protocol
+	if protocol == "" {
+		protocol = "http"
+	}
+
+	port := request.Port
+	if port == 0 {
+		port = c.Port
+	}
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	endpoint := &url.URL{Path: request.Path, RawQuery: request.Query.Encode()}
+	url := fmt.Sprintf("%s://%s:%d%s", protocol, host, port, endpoint.String())
+
+	var reqBody io.Reader
+	if len(request.Body) > 0 {
+		reqBody = bytes.NewReader(request.Body)
+	}
+
+	timeout := request.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, request.Method, url, reqBody)
+	if err != nil {
+		c.Log.Error(err, "unable to create request for Node Management Endpoint")
+		return nil, err
+	}
+	req.Close = true
+
+	if request.ContentType != "" {
+		req.Header.Set("Content-Type", request.ContentType)
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		c.Log.Error(err, "unable to perform request to Node Management Endpoint")
+		return nil, err
+	}
+
+	defer func() {
+		err := res.Body.Close()
+		if err != nil {
+			c.Log.Error(err, "unable to close response body")
+		}
+	}()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		c.Log.Error(err, "Unable to read response from Node Management Endpoint")
+		return nil, err
+	}
+
+	goodStatus := res.StatusCode >= 200 && res.StatusCode < 300
+	if !goodStatus {
+		c.Log.Info("incorrect status code when calling Node Management Endpoint",
+			"statusCode", res.StatusCode,
+			"pod", host)
+
+		return nil, &StatusError{
+			Method:     request.Method,
+			Path:       request.Path,
+			StatusCode: res.StatusCode,
+			Body:       body,
+		}
+	}
+
+	return body, nil
+}
+
+// retryable tells whether sending the request twice has the same effect as
+// sending it once
+func (request Request) retryable() bool {
+	return request.Idempotent || request.Method == http.MethodGet
+}
+
+// isRetryableError tells whether a request that failed with err may succeed if
+// it is sent again: the node could not be reached, or failed on its side
+func isRetryableError(err error) bool {
+	if statusErr, ok := err.(*StatusError); ok {
+		return statusErr.StatusCode >= 500
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package mgmtapi
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// newTestClient returns a client of the management API served by handler,
+// and the host to call it with
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, string) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	assert.NoError(t, err)
+
+	client := NewClient(server.Client(), logf.Log.WithName("mgmtapi_test"))
+	client.Port = port
+	return client, serverURL.Hostname()
+}
+
+func TestGetEndpoints(t *testing.T) {
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v0/metadata/endpoints", r.URL.Path)
+		_, _ = w.Write([]byte(`{"entity": [{"HOST_ID": "host-1", "RPC_ADDRESS": "10.0.0.1", "NATIVE_TRANSPORT_ADDRESS": "10.0.0.2", "STATUS": "BOOT,-123", "LOAD": "1.2345678E7", "RACK": "r1"}]}`))
+	})
+
+	endpoints, err := client.GetEndpoints(context.Background(), host)
+	assert.NoError(t, err)
+	assert.Len(t, endpoints.Entity, 1)
+	assert.Equal(t, "host-1", endpoints.Entity[0].HostID)
+	assert.Equal(t, "10.0.0.2", endpoints.Entity[0].GetRpcAddress())
+	assert.Equal(t, "JOINING", endpoints.Entity[0].GetOperationMode())
+	assert.Equal(t, "r1", endpoints.Entity[0].Rack)
+	load, err := endpoints.Entity[0].GetLoad()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12345678), load)
+}
+
+func TestDropRole(t *testing.T) {
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/api/v0/ops/auth/role", r.URL.Path)
+		assert.Equal(t, "app", r.URL.Query().Get("username"))
+	})
+
+	err := client.DropRole(context.Background(), host, "app")
+	assert.NoError(t, err)
+}
+
+func TestReloadCertificates(t *testing.T) {
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v0/ops/node/reload-certificates", r.URL.Path)
+	})
+
+	err := client.ReloadCertificates(context.Background(), host)
+	assert.NoError(t, err)
+}
+
+func TestGetCompactions(t *testing.T) {
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v0/ops/tables/compactions", r.URL.Path)
+		_, _ = w.Write([]byte(`[]`))
+	})
+
+	compactions, err := client.GetCompactions(context.Background(), host)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", compactions)
+}
+
+func TestGetThreadPools(t *testing.T) {
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v0/ops/node/threadpools", r.URL.Path)
+		_, _ = w.Write([]byte(`[{"name": "MutationStage", "pending_tasks": 0}]`))
+	})
+
+	threadPools, err := client.GetThreadPools(context.Background(), host)
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"name": "MutationStage", "pending_tasks": 0}]`, threadPools)
+}
+
+func TestListKeyspaces(t *testing.T) {
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v0/ops/keyspace", r.URL.Path)
+		_, _ = w.Write([]byte(`["system_auth", "ks"]`))
+	})
+
+	keyspaces, err := client.ListKeyspaces(context.Background(), host)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"system_auth", "ks"}, keyspaces)
+}
+
+func TestGetKeyspaceReplication(t *testing.T) {
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v0/ops/keyspace/replication", r.URL.Path)
+		assert.Equal(t, "ks", r.URL.Query().Get("keyspaceName"))
+		_, _ = w.Write([]byte(`{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc1": "3"}`))
+	})
+
+	replication, err := client.GetKeyspaceReplication(context.Background(), host, "ks")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", replication["dc1"])
+}
+
+func TestTableOperationRequestBody(t *testing.T) {
+	jobs := 2
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v0/ops/keyspace/cleanup", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"jobs": "2", "keyspace_name": "ks", "tables": ["t1"]}`, string(body))
+	})
+
+	err := client.Cleanup(context.Background(), host, TableOperationRequest{Jobs: &jobs, KeyspaceName: "ks", Tables: []string{"t1"}})
+	assert.NoError(t, err)
+}
+
+func TestStatusError(t *testing.T) {
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("unknown keyspace"))
+	})
+
+	err := client.Repair(context.Background(), host, RepairRequest{KeyspaceName: "ks"})
+	statusErr, ok := err.(*StatusError)
+	assert.True(t, ok, "expected a *StatusError, got %v", err)
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+	assert.Equal(t, "/api/v0/ops/node/repair", statusErr.Path)
+	assert.Equal(t, "unknown keyspace", string(statusErr.Body))
+}
+
+func TestRetries(t *testing.T) {
+	calls := 0
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"entity": true}`))
+	})
+	client.Retry = RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+
+	enabled, err := client.GetQueryLogging(context.Background(), host, AuditLoggingEndpoint)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	assert.Equal(t, 3, calls)
+
+	// Draining a node twice is not safe, so it is not retried
+	calls = 0
+	err = client.Drain(context.Background(), host)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetries_ClientErrorsAreNotRetried(t *testing.T) {
+	calls := 0
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	})
+	client.Retry = RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+
+	_, err := client.GetEndpoints(context.Background(), host)
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestContextDeadline(t *testing.T) {
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.Drain(ctx, host)
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < DefaultTimeout, "the deadline of the context should end the request")
+}
@@ -0,0 +1,25 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package mgmtapi is a client of the management API, the HTTP agent that runs
+// next to Cassandra in the pods of cass-operator and that the operator calls
+// to manage the nodes (https://github.com/datastax/management-api-for-apache-cassandra).
+//
+// It does not depend on the CassandraDatacenter types, so that other
+// components can call the management API of the nodes without re-implementing
+// the requests:
+//
+//	client := mgmtapi.NewClient(mgmtapi.DefaultHTTPClient, logger)
+//	endpoints, err := client.GetEndpoints(ctx, podIP)
+//
+// When the management API of the datacenter is secured with
+// spec.managementApiAuth.manual, build the client with NewTLSClient and the
+// client certificate of the datacenter.
+//
+// Every call takes a context, and also has a default timeout that matches the
+// operation: a deadline of the context that comes earlier wins. Requests that
+// fail, either because the node could not be reached or because it answered
+// with a 5xx status, are retried according to the RetryPolicy of the client
+// when they are safe to send again. Statuses other than 2xx are returned as a
+// *StatusError.
+package mgmtapi
@@ -0,0 +1,123 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package mgmtapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The v1 endpoints of the long-running operations return as soon as the
+// operation is submitted, with the ID of a job whose progress is then read
+// with GetJob. Management APIs that predate them answer with a status that
+// IsNotSupported recognizes, and the v0 endpoints that only return once the
+// operation is done have to be used instead.
+
+// JobStatus is the progress of a job of the management API
+type JobStatus string
+
+const (
+	JobWaiting   JobStatus = "WAITING"
+	JobCompleted JobStatus = "COMPLETED"
+	JobError     JobStatus = "ERROR"
+)
+
+// Job is an asynchronous operation running on a node
+type Job struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Status     JobStatus `json:"status"`
+	SubmitTime string    `json:"submit_time,omitempty"`
+	EndTime    string    `json:"end_time,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// IsNotSupported tells whether err was returned because the management API
+// of the node does not have the endpoint that was called
+func IsNotSupported(err error) bool {
+	statusErr, ok := err.(*StatusError)
+	return ok && (statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusMethodNotAllowed)
+}
+
+// IsJobNotFound tells whether err was returned by GetJob because the node
+// does not know the job, which happens when Cassandra restarted since the
+// job was submitted
+func IsJobNotFound(err error) bool {
+	statusErr, ok := err.(*StatusError)
+	return ok && statusErr.StatusCode == http.StatusNotFound
+}
+
+// submitJob posts an operation to a v1 endpoint and returns the ID of its job
+func (c *Client) submitJob(ctx context.Context, host string, request Request, body interface{}) (string, error) {
+	response, err := c.post(ctx, host, request, body)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(strings.TrimSpace(string(response)), `"`), nil
+}
+
+// GetJob returns the progress of a job submitted to the node
+func (c *Client) GetJob(ctx context.Context, host, jobID string) (*Job, error) {
+	query := url.Values{}
+	query.Set("job_id", jobID)
+
+	body, err := c.Do(ctx, host, Request{
+		Method: http.MethodGet,
+		Path:   "/api/v0/ops/executor/job",
+		Query:  query,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{}
+	if err := json.Unmarshal(body, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// CleanupAsync submits a cleanup and returns the ID of its job
+func (c *Client) CleanupAsync(ctx context.Context, host string, tables TableOperationRequest) (string, error) {
+	return c.submitJob(ctx, host, Request{Path: "/api/v1/ops/keyspace/cleanup"}, tables)
+}
+
+// FlushAsync submits a flush and returns the ID of its job
+func (c *Client) FlushAsync(ctx context.Context, host string, tables TableOperationRequest) (string, error) {
+	return c.submitJob(ctx, host, Request{Path: "/api/v1/ops/tables/flush"}, tables)
+}
+
+// GarbageCollectAsync submits a garbage collection and returns the ID of its
+// job
+func (c *Client) GarbageCollectAsync(ctx context.Context, host string, tables TableOperationRequest) (string, error) {
+	return c.submitJob(ctx, host, Request{Path: "/api/v1/ops/tables/garbagecollect"}, tables)
+}
+
+// CompactAsync submits a major compaction and returns the ID of its job
+func (c *Client) CompactAsync(ctx context.Context, host string, tables TableOperationRequest) (string, error) {
+	return c.submitJob(ctx, host, Request{Path: "/api/v1/ops/tables/compact"}, tables)
+}
+
+// UpgradeSSTablesAsync submits an upgrade of the sstables and returns the ID
+// of its job
+func (c *Client) UpgradeSSTablesAsync(ctx context.Context, host string, tables TableOperationRequest) (string, error) {
+	return c.submitJob(ctx, host, Request{Path: "/api/v1/ops/tables/sstables/upgrade"}, tables)
+}
+
+// RebuildAsync submits a rebuild from the source datacenter and returns the
+// ID of its job
+func (c *Client) RebuildAsync(ctx context.Context, host, sourceDatacenter string) (string, error) {
+	if sourceDatacenter == "" {
+		return "", fmt.Errorf("a source datacenter is required to rebuild a node")
+	}
+
+	query := url.Values{}
+	query.Set("src_dc", sourceDatacenter)
+
+	return c.submitJob(ctx, host, Request{Path: "/api/v1/ops/node/rebuild", Query: query}, nil)
+}
@@ -0,0 +1,478 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package mgmtapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operations such as compactions and streaming can take hours to complete,
+// and the management API only returns once they are done. The operator waits
+// for them in the background, see jobtracker.
+const longRunningOperationTimeout = 12 * time.Hour
+
+// Repairing a keyspace on a large node can take hours
+const repairTimeout = 12 * time.Hour
+
+// Paths of the management API endpoints of the query logs of Cassandra 4.0
+const (
+	FullQueryLoggingEndpoint = "/api/v0/ops/node/fullquerylogging"
+	AuditLoggingEndpoint     = "/api/v0/ops/node/auditlogging"
+)
+
+// EndpointState is the gossip state of a node, as seen by the node that was
+// asked for it
+type EndpointState struct {
+	HostID                 string `json:"HOST_ID"`
+	IsAlive                string `json:"IS_ALIVE"`
+	NativeTransportAddress string `json:"NATIVE_TRANSPORT_ADDRESS"`
+	RpcAddress             string `json:"RPC_ADDRESS"`
+	Status                 string `json:"STATUS"`
+	Load                   string `json:"LOAD"`
+	Datacenter             string `json:"DC"`
+	Rack                   string `json:"RACK"`
+	Schema                 string `json:"SCHEMA"`
+}
+
+// GetRpcAddress returns the address clients connect to
+func (x *EndpointState) GetRpcAddress() string {
+	if x.NativeTransportAddress != "" {
+		return x.NativeTransportAddress
+	} else {
+		return x.RpcAddress
+	}
+}
+
+// GetOperationMode returns the operation mode of the node from its gossip
+// status: NORMAL, JOINING, LEAVING, LEFT, MOVING or SHUTDOWN. It returns an
+// empty string when the gossip state of the node has no status yet.
+func (x *EndpointState) GetOperationMode() string {
+	status := strings.SplitN(x.Status, ",", 2)[0]
+	switch status {
+	case "BOOT", "BOOT_REPLACE":
+		return "JOINING"
+	}
+	return strings.ToUpper(status)
+}
+
+// GetLoad returns the amount of data stored by the node, in bytes
+func (x *EndpointState) GetLoad() (int64, error) {
+	if x.Load == "" {
+		return 0, nil
+	}
+	load, err := strconv.ParseFloat(x.Load, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(load), nil
+}
+
+// Endpoints is the response of GetEndpoints
+type Endpoints struct {
+	Entity []EndpointState `json:"entity"`
+}
+
+// CreateRoleRequest describes a role that can log in
+type CreateRoleRequest struct {
+	Username  string
+	Password  string
+	Superuser bool
+}
+
+// ProbeClusterRequest asks whether the cluster can serve requests
+type ProbeClusterRequest struct {
+	ConsistencyLevel string
+	RFPerDC          int
+}
+
+// TableOperationRequest selects the tables of an operation on sstables. All
+// the keyspaces are selected when KeyspaceName is empty, and all the tables of
+// the keyspace when Tables is empty.
+type TableOperationRequest struct {
+	// Jobs is the number of sstables processed concurrently, the management
+	// API default when nil
+	Jobs         *int
+	KeyspaceName string
+	Tables       []string
+}
+
+// MarshalJSON encodes the request the way the management API expects it
+func (r TableOperationRequest) MarshalJSON() ([]byte, error) {
+	postData := make(map[string]interface{})
+	if r.Jobs != nil {
+		postData["jobs"] = strconv.Itoa(*r.Jobs)
+	}
+
+	if r.KeyspaceName != "" {
+		postData["keyspace_name"] = r.KeyspaceName
+	}
+
+	if len(r.Tables) > 0 {
+		postData["tables"] = r.Tables
+	}
+
+	return json.Marshal(postData)
+}
+
+// KeyspaceRequest creates or alters a keyspace. Each replication setting maps
+// dc_name and replication_factor to their values.
+type KeyspaceRequest struct {
+	KeyspaceName        string              `json:"keyspace_name"`
+	ReplicationSettings []map[string]string `json:"replication_settings"`
+}
+
+// RepairRequest repairs the ranges of a keyspace the node is a replica for.
+// With PrimaryRange, only the ranges the node is the first replica of are
+// repaired, like nodetool repair -pr.
+type RepairRequest struct {
+	KeyspaceName string `json:"keyspace_name"`
+	Full         bool   `json:"full"`
+	PrimaryRange bool   `json:"primary_range,omitempty"`
+}
+
+// CreateSnapshotRequest takes a snapshot of the given keyspaces, or of all of
+// them when Keyspaces is empty
+type CreateSnapshotRequest struct {
+	SnapshotName string   `json:"snapshot_name"`
+	Keyspaces    []string `json:"keyspaces,omitempty"`
+}
+
+type queryLoggingResponse struct {
+	Entity bool `json:"entity"`
+}
+
+// post sends body, if any, encoded in JSON
+func (c *Client) post(ctx context.Context, host string, request Request, body interface{}) ([]byte, error) {
+	request.Method = http.MethodPost
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		request.Body = data
+		request.ContentType = "application/json"
+	}
+	return c.Do(ctx, host, request)
+}
+
+// GetEndpoints returns the gossip state of all the nodes of the cluster
+func (c *Client) GetEndpoints(ctx context.Context, host string) (*Endpoints, error) {
+	body, err := c.Do(ctx, host, Request{
+		Method: http.MethodGet,
+		Path:   "/api/v0/metadata/endpoints",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := &Endpoints{}
+	if err := json.Unmarshal(body, endpoints); err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// CreateRole creates a role that can log in
+func (c *Client) CreateRole(ctx context.Context, host string, role CreateRoleRequest) error {
+	query := url.Values{}
+	query.Set("username", role.Username)
+	query.Set("password", role.Password)
+	query.Set("can_login", "true")
+	query.Set("is_superuser", strconv.FormatBool(role.Superuser))
+
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/auth/role", Query: query}, nil)
+	return err
+}
+
+// ChangeRolePassword changes the password of an existing role. The sessions
+// opened with the former password are not closed.
+func (c *Client) ChangeRolePassword(ctx context.Context, host string, username, password string) error {
+	query := url.Values{}
+	query.Set("username", username)
+	query.Set("password", password)
+
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/auth/role/password", Query: query, Idempotent: true}, nil)
+	return err
+}
+
+// DropRole drops a role. Dropping a role that does not exist succeeds.
+func (c *Client) DropRole(ctx context.Context, host string, username string) error {
+	query := url.Values{}
+	query.Set("username", username)
+
+	_, err := c.Do(ctx, host, Request{
+		Method:     http.MethodDelete,
+		Path:       "/api/v0/ops/auth/role",
+		Query:      query,
+		Idempotent: true,
+	})
+	return err
+}
+
+// ProbeCluster returns an error unless enough replicas of every range are up
+// to serve requests at the given consistency level
+func (c *Client) ProbeCluster(ctx context.Context, host string, probe ProbeClusterRequest) error {
+	query := url.Values{}
+	query.Set("consistency_level", probe.ConsistencyLevel)
+	query.Set("rf_per_dc", strconv.Itoa(probe.RFPerDC))
+
+	_, err := c.Do(ctx, host, Request{
+		Method: http.MethodGet,
+		Path:   "/api/v0/probes/cluster",
+		Query:  query,
+	})
+	return err
+}
+
+// Drain flushes the memtables of the node and stops it from serving requests
+func (c *Client) Drain(ctx context.Context, host string) error {
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/node/drain", Timeout: 2 * time.Minute}, nil)
+	return err
+}
+
+// ReloadCertificates makes the node read again the keystores and truststores
+// of the encryption, so that renewed certificates are used by the new
+// connections without a restart. Only Cassandra 4 and later support it.
+func (c *Client) ReloadCertificates(ctx context.Context, host string) error {
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/node/reload-certificates", Idempotent: true}, nil)
+	return err
+}
+
+// Cleanup removes the data the node no longer owns
+func (c *Client) Cleanup(ctx context.Context, host string, tables TableOperationRequest) error {
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/keyspace/cleanup", Timeout: 20 * time.Second}, tables)
+	return err
+}
+
+// Flush flushes the memtables of the tables to disk
+func (c *Client) Flush(ctx context.Context, host string, tables TableOperationRequest) error {
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/tables/flush", Timeout: longRunningOperationTimeout}, tables)
+	return err
+}
+
+// GarbageCollect removes deleted data from the sstables of the tables
+func (c *Client) GarbageCollect(ctx context.Context, host string, tables TableOperationRequest) error {
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/tables/garbagecollect", Timeout: longRunningOperationTimeout}, tables)
+	return err
+}
+
+// Compact runs a major compaction of the tables
+func (c *Client) Compact(ctx context.Context, host string, tables TableOperationRequest) error {
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/tables/compact", Timeout: longRunningOperationTimeout}, tables)
+	return err
+}
+
+// GetCompactions returns the compactions running on the node, as the JSON the
+// management API answers with
+func (c *Client) GetCompactions(ctx context.Context, host string) (string, error) {
+	body, err := c.Do(ctx, host, Request{
+		Method: http.MethodGet,
+		Path:   "/api/v0/ops/tables/compactions",
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// GetThreadPools returns the statistics of the thread pools of the node, as
+// the JSON the management API answers with
+func (c *Client) GetThreadPools(ctx context.Context, host string) (string, error) {
+	body, err := c.Do(ctx, host, Request{
+		Method: http.MethodGet,
+		Path:   "/api/v0/ops/node/threadpools",
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// GetPendingHints returns the hints the node holds for each of the other
+// nodes, as the JSON the management API answers with
+func (c *Client) GetPendingHints(ctx context.Context, host string) (string, error) {
+	body, err := c.Do(ctx, host, Request{
+		Method: http.MethodGet,
+		Path:   "/api/v0/ops/node/hints",
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// UpgradeSSTables rewrites the sstables of the tables in the current format
+func (c *Client) UpgradeSSTables(ctx context.Context, host string, tables TableOperationRequest) error {
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/tables/sstables/upgrade", Timeout: longRunningOperationTimeout}, tables)
+	return err
+}
+
+// Rebuild streams the data owned by the node from the source datacenter
+func (c *Client) Rebuild(ctx context.Context, host, sourceDatacenter string) error {
+	if sourceDatacenter == "" {
+		return fmt.Errorf("a source datacenter is required to rebuild a node")
+	}
+
+	query := url.Values{}
+	query.Set("src_dc", sourceDatacenter)
+
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/node/rebuild", Query: query, Timeout: longRunningOperationTimeout}, nil)
+	return err
+}
+
+// CreateKeyspace creates a keyspace
+func (c *Client) CreateKeyspace(ctx context.Context, host string, keyspace KeyspaceRequest) error {
+	return c.modifyKeyspace(ctx, host, "create", keyspace)
+}
+
+// AlterKeyspace changes the replication of a keyspace
+func (c *Client) AlterKeyspace(ctx context.Context, host string, keyspace KeyspaceRequest) error {
+	return c.modifyKeyspace(ctx, host, "alter", keyspace)
+}
+
+func (c *Client) modifyKeyspace(ctx context.Context, host, operation string, keyspace KeyspaceRequest) error {
+	if keyspace.KeyspaceName == "" || keyspace.ReplicationSettings == nil {
+		return fmt.Errorf("Keyspacename and replication settings are required")
+	}
+
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/keyspace/" + operation, Timeout: 20 * time.Second}, keyspace)
+	return err
+}
+
+// ListKeyspaces returns the names of the keyspaces of the cluster
+func (c *Client) ListKeyspaces(ctx context.Context, host string) ([]string, error) {
+	body, err := c.Do(ctx, host, Request{Method: http.MethodGet, Path: "/api/v0/ops/keyspace"})
+	if err != nil {
+		return nil, err
+	}
+
+	var keyspaces []string
+	if err := json.Unmarshal(body, &keyspaces); err != nil {
+		return nil, err
+	}
+	return keyspaces, nil
+}
+
+// GetKeyspaceReplication returns the replication of a keyspace: its class,
+// and the replication factor of each datacenter with NetworkTopologyStrategy
+func (c *Client) GetKeyspaceReplication(ctx context.Context, host, keyspaceName string) (map[string]string, error) {
+	query := url.Values{}
+	query.Set("keyspaceName", keyspaceName)
+
+	body, err := c.Do(ctx, host, Request{Method: http.MethodGet, Path: "/api/v0/ops/keyspace/replication", Query: query})
+	if err != nil {
+		return nil, err
+	}
+
+	replication := map[string]string{}
+	if err := json.Unmarshal(body, &replication); err != nil {
+		return nil, err
+	}
+	return replication, nil
+}
+
+// StartNode starts Cassandra. When replaceIP is set, the node replaces the
+// dead node that had this address.
+func (c *Client) StartNode(ctx context.Context, host, replaceIP string) error {
+	query := url.Values{}
+	if replaceIP != "" {
+		query.Set("replace_ip", replaceIP)
+	}
+
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/lifecycle/start", Query: query, Timeout: 10 * time.Second}, nil)
+	return err
+}
+
+// ReloadSeeds makes the node read its seeds again
+func (c *Client) ReloadSeeds(ctx context.Context, host string) error {
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/seeds/reload", Idempotent: true}, nil)
+	return err
+}
+
+// Decommission streams the data of the node to the other nodes and removes it
+// from the cluster
+func (c *Client) Decommission(ctx context.Context, host string) error {
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/node/decommission"}, nil)
+	return err
+}
+
+// Repair repairs the ranges of a keyspace the node is a replica for, and only
+// returns once the repair is done
+func (c *Client) Repair(ctx context.Context, host string, repair RepairRequest) error {
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/node/repair", Timeout: repairTimeout}, repair)
+	return err
+}
+
+// CreateSnapshot takes a snapshot of the keyspaces of the node
+func (c *Client) CreateSnapshot(ctx context.Context, host string, snapshot CreateSnapshotRequest) error {
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/node/snapshots", Timeout: 2 * time.Minute}, snapshot)
+	return err
+}
+
+// DeleteSnapshot deletes a snapshot of the node
+func (c *Client) DeleteSnapshot(ctx context.Context, host, snapshotName string) error {
+	query := url.Values{}
+	query.Set("snapshotNames", snapshotName)
+
+	_, err := c.Do(ctx, host, Request{
+		Method:     http.MethodDelete,
+		Path:       "/api/v0/ops/node/snapshots",
+		Query:      query,
+		Idempotent: true,
+	})
+	return err
+}
+
+// GetQueryLogging returns whether the query log of endpoint, either
+// FullQueryLoggingEndpoint or AuditLoggingEndpoint, is enabled on the node
+func (c *Client) GetQueryLogging(ctx context.Context, host, endpoint string) (bool, error) {
+	body, err := c.Do(ctx, host, Request{Method: http.MethodGet, Path: endpoint})
+	if err != nil {
+		return false, err
+	}
+
+	response := &queryLoggingResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return false, err
+	}
+	return response.Entity, nil
+}
+
+// SetQueryLogging turns the query log of endpoint, either
+// FullQueryLoggingEndpoint or AuditLoggingEndpoint, on or off on the node
+func (c *Client) SetQueryLogging(ctx context.Context, host, endpoint string, enabled bool) error {
+	query := url.Values{}
+	query.Set("enabled", strconv.FormatBool(enabled))
+
+	_, err := c.post(ctx, host, Request{Path: endpoint, Query: query, Idempotent: true}, nil)
+	return err
+}
+
+// SetLoggingLevel sets the level of the logger of the node, or resets it to
+// the one of its parent with a blank level
+func (c *Client) SetLoggingLevel(ctx context.Context, host, logger, level string) error {
+	query := url.Values{}
+	query.Set("target", logger)
+	query.Set("rawLevel", level)
+
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/node/logging", Query: query, Idempotent: true}, nil)
+	return err
+}
+
+// SetSetting changes a setting of the running node, such as the
+// compactionthroughput nodetool setcompactionthroughput changes
+func (c *Client) SetSetting(ctx context.Context, host, setting string, value int64) error {
+	query := url.Values{}
+	query.Set("value", strconv.FormatInt(value, 10))
+
+	_, err := c.post(ctx, host, Request{Path: "/api/v0/ops/node/" + setting, Query: query, Idempotent: true}, nil)
+	return err
+}
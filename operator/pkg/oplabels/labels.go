@@ -13,10 +13,6 @@ func AddManagedByLabel(m map[string]string) {
 	m[ManagedByLabel] = ManagedByLabelValue
 }
 
-func AddDefunctManagedByLabel(m map[string]string) {
-	m[ManagedByLabel] = ManagedByLabelDefunctValue
-}
-
 func HasManagedByCassandraOperatorLabel(m map[string]string) bool {
 	v, ok := m[ManagedByLabel]
 	return ok && v == ManagedByLabelValue
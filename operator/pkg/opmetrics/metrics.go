@@ -0,0 +1,206 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package opmetrics defines the Prometheus metrics of the operator. They are
+// registered with the controller-runtime metrics registry, and served on its
+// metrics endpoint.
+package opmetrics
+
+import (
+	"errors"
+	"net"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+)
+
+// Metrics of the reconciliation of CassandraDatacenters, labeled with their
+// namespace so that an operator managing several namespaces can be monitored
+// per namespace.
+var (
+	datacenterReconcileTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cass_operator_datacenter_reconcile_total",
+			Help: "Number of reconciliations of CassandraDatacenters, by namespace and result",
+		},
+		[]string{"namespace", "result"},
+	)
+
+	datacenterReconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "cass_operator_datacenter_reconcile_duration_seconds",
+			Help: "Duration of the reconciliations of CassandraDatacenters, by namespace",
+		},
+		[]string{"namespace"},
+	)
+
+	datacenterReconcileErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cass_operator_datacenter_reconcile_errors_total",
+			Help: "Number of reconciliations of a CassandraDatacenter that failed, by type of error",
+		},
+		[]string{"namespace", "name", "type"},
+	)
+)
+
+// stateCacheLookups counts the lookups of the state the reconciliations keep
+// from one to the next, which hit when the resources the state was derived
+// from did not change
+var stateCacheLookups = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cass_operator_state_cache_lookups_total",
+		Help: "Number of lookups of the state kept between reconciliations, by kind and result",
+	},
+	[]string{"kind", "result"},
+)
+
+// Metrics of the state of each CassandraDatacenter, labeled with its
+// namespace and name
+var (
+	datacenterReconciliationPaused = newDatacenterGauge(
+		"cass_operator_datacenter_reconciliation_paused",
+		"Whether the reconciliation of a CassandraDatacenter is paused with the paused annotation")
+
+	datacenterLastReconcileDuration = newDatacenterGauge(
+		"cass_operator_datacenter_last_reconcile_duration_seconds",
+		"Duration of the last reconciliation of a CassandraDatacenter")
+
+	datacenterDesiredNodes = newDatacenterGauge(
+		"cass_operator_datacenter_desired_nodes",
+		"Number of nodes a CassandraDatacenter should have")
+
+	datacenterReadyNodes = newDatacenterGauge(
+		"cass_operator_datacenter_ready_nodes",
+		"Number of server pods of a CassandraDatacenter that are ready")
+
+	datacenterSuperuserCreated = newDatacenterGauge(
+		"cass_operator_datacenter_superuser_created",
+		"Whether the superuser and the other users of a CassandraDatacenter have been created")
+
+	datacenterPendingReplacements = newDatacenterGauge(
+		"cass_operator_datacenter_pending_replacements",
+		"Number of pods of a CassandraDatacenter that are waiting to be replaced")
+
+	datacenterGauges = []*prometheus.GaugeVec{
+		datacenterReconciliationPaused,
+		datacenterLastReconcileDuration,
+		datacenterDesiredNodes,
+		datacenterReadyNodes,
+		datacenterSuperuserCreated,
+		datacenterPendingReplacements,
+	}
+)
+
+func newDatacenterGauge(name, help string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: name, Help: help},
+		[]string{"namespace", "name"},
+	)
+}
+
+func init() {
+	metrics.Registry.MustRegister(datacenterReconcileTotal, datacenterReconcileDuration, datacenterReconcileErrors, stateCacheLookups)
+	for _, gauge := range datacenterGauges {
+		metrics.Registry.MustRegister(gauge)
+	}
+}
+
+// reconcileResultLabel names the outcome of a reconciliation for the metrics
+func reconcileResultLabel(requeue bool, err error) string {
+	switch {
+	case err != nil:
+		return "error"
+	case requeue:
+		return "requeue"
+	default:
+		return "success"
+	}
+}
+
+// The types of errors of ErrorType
+const (
+	ErrorManagementAPI = "management_api"
+	ErrorConflict      = "conflict"
+	ErrorNotFound      = "not_found"
+	ErrorKubernetesAPI = "kubernetes_api"
+	ErrorNetwork       = "network"
+	ErrorOther         = "other"
+)
+
+var errorTypes = []string{ErrorManagementAPI, ErrorConflict, ErrorNotFound, ErrorKubernetesAPI, ErrorNetwork, ErrorOther}
+
+// ErrorType classifies the errors of the reconciliations for the metrics
+func ErrorType(err error) string {
+	var statusErr *mgmtapi.StatusError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &statusErr):
+		return ErrorManagementAPI
+	case apierrors.IsConflict(err):
+		return ErrorConflict
+	case apierrors.IsNotFound(err):
+		return ErrorNotFound
+	case apierrors.ReasonForError(err) != "":
+		return ErrorKubernetesAPI
+	case errors.As(err, &netErr):
+		return ErrorNetwork
+	default:
+		return ErrorOther
+	}
+}
+
+// ObserveReconcile records a reconciliation of a CassandraDatacenter
+func ObserveReconcile(namespace, name string, seconds float64, requeue bool, err error) {
+	datacenterReconcileTotal.WithLabelValues(namespace, reconcileResultLabel(requeue, err)).Inc()
+	datacenterReconcileDuration.WithLabelValues(namespace).Observe(seconds)
+	if err != nil {
+		datacenterReconcileErrors.WithLabelValues(namespace, name, ErrorType(err)).Inc()
+	}
+}
+
+// ObserveStateCacheLookup records a lookup of the state of the kind kept
+// between reconciliations
+func ObserveStateCacheLookup(kind string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	stateCacheLookups.WithLabelValues(kind, result).Inc()
+}
+
+// ObserveReconciliationPaused records whether the reconciliation of the
+// datacenter is paused
+func ObserveReconciliationPaused(dc *api.CassandraDatacenter) {
+	datacenterReconciliationPaused.WithLabelValues(dc.Namespace, dc.Name).Set(boolValue(dc.IsReconciliationPaused()))
+}
+
+// ObserveDatacenter records the state of the datacenter from its spec and
+// status, and the duration of its last reconciliation
+func ObserveDatacenter(dc *api.CassandraDatacenter, lastReconcileSeconds float64) {
+	datacenterLastReconcileDuration.WithLabelValues(dc.Namespace, dc.Name).Set(lastReconcileSeconds)
+	datacenterDesiredNodes.WithLabelValues(dc.Namespace, dc.Name).Set(float64(dc.Spec.Size))
+	datacenterReadyNodes.WithLabelValues(dc.Namespace, dc.Name).Set(float64(dc.Status.ReadyNodes))
+	datacenterSuperuserCreated.WithLabelValues(dc.Namespace, dc.Name).Set(boolValue(!dc.Status.UsersUpserted.IsZero()))
+	datacenterPendingReplacements.WithLabelValues(dc.Namespace, dc.Name).Set(float64(len(dc.Status.NodeReplacements)))
+}
+
+// ForgetDatacenter removes the metrics of a deleted datacenter
+func ForgetDatacenter(namespace, name string) {
+	for _, gauge := range datacenterGauges {
+		gauge.DeleteLabelValues(namespace, name)
+	}
+	for _, errorType := range errorTypes {
+		datacenterReconcileErrors.DeleteLabelValues(namespace, name, errorType)
+	}
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
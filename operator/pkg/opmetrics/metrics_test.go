@@ -0,0 +1,53 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package opmetrics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+)
+
+func TestErrorType(t *testing.T) {
+	resource := schema.GroupResource{Resource: "pods"}
+	assert.Equal(t, ErrorManagementAPI, ErrorType(fmt.Errorf("drain failed: %w", &mgmtapi.StatusError{StatusCode: 500})))
+	assert.Equal(t, ErrorConflict, ErrorType(apierrors.NewConflict(resource, "pod-0", fmt.Errorf("stale"))))
+	assert.Equal(t, ErrorNotFound, ErrorType(apierrors.NewNotFound(resource, "pod-0")))
+	assert.Equal(t, ErrorKubernetesAPI, ErrorType(apierrors.NewForbidden(resource, "pod-0", fmt.Errorf("denied"))))
+	assert.Equal(t, ErrorOther, ErrorType(fmt.Errorf("something else")))
+}
+
+func TestObserveDatacenter(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns1"},
+		Spec:       api.CassandraDatacenterSpec{Size: 3},
+		Status: api.CassandraDatacenterStatus{
+			ReadyNodes:       2,
+			UsersUpserted:    metav1.Now(),
+			NodeReplacements: []string{"pod-2"},
+		},
+	}
+
+	ObserveDatacenter(dc, 1.5)
+	assert.Equal(t, 3.0, testutil.ToFloat64(datacenterDesiredNodes.WithLabelValues("ns1", "dc1")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(datacenterReadyNodes.WithLabelValues("ns1", "dc1")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(datacenterSuperuserCreated.WithLabelValues("ns1", "dc1")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(datacenterPendingReplacements.WithLabelValues("ns1", "dc1")))
+	assert.Equal(t, 1.5, testutil.ToFloat64(datacenterLastReconcileDuration.WithLabelValues("ns1", "dc1")))
+
+	ObserveReconcile("ns1", "dc1", 1.5, false, apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod-0"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(datacenterReconcileErrors.WithLabelValues("ns1", "dc1", ErrorNotFound)))
+
+	ForgetDatacenter("ns1", "dc1")
+	assert.False(t, datacenterReadyNodes.DeleteLabelValues("ns1", "dc1"), "the metrics of the datacenter should be removed")
+	assert.False(t, datacenterReconcileErrors.DeleteLabelValues("ns1", "dc1", ErrorNotFound))
+}
@@ -0,0 +1,200 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package orphans periodically sweeps the resources labeled as managed by the
+// operator whose CassandraDatacenter no longer exists, such as the ones left
+// behind by older versions of the operator without owner references, and
+// reports or deletes them.
+package orphans
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/sharding"
+)
+
+var log = logf.Log.WithName("orphans")
+
+// Mode is what the sweeper does with the orphaned resources it finds
+type Mode string
+
+const (
+	// ModeReport logs the orphaned resources and records an event on them
+	ModeReport Mode = "report"
+
+	// ModeDelete also deletes them, except for the persistent volume claims,
+	// which are only reported as they may have been retained on purpose
+	ModeDelete Mode = "delete"
+
+	// DefaultInterval is the time between two sweeps
+	DefaultInterval = time.Hour
+
+	// The resources younger than this are left alone, as their datacenter
+	// may have been created after the sweep listed the datacenters
+	gracePeriod = 10 * time.Minute
+)
+
+// ParseMode returns the mode named by value, as set in the ORPHAN_GC env var
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(value)); mode {
+	case ModeReport, ModeDelete:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown orphan sweep mode %q, must be %s or %s", value, ModeReport, ModeDelete)
+	}
+}
+
+type orphanKind struct {
+	name    string
+	newList func() runtime.Object
+
+	// The orphans of the kind are only reported, whatever the mode
+	reportOnly bool
+}
+
+var orphanKinds = []orphanKind{
+	{name: "StatefulSet", newList: func() runtime.Object { return &appsv1.StatefulSetList{} }},
+	{name: "Service", newList: func() runtime.Object { return &corev1.ServiceList{} }},
+	{name: "PodDisruptionBudget", newList: func() runtime.Object { return &policyv1beta1.PodDisruptionBudgetList{} }},
+	{name: "ConfigMap", newList: func() runtime.Object { return &corev1.ConfigMapList{} }},
+	{name: "Secret", newList: func() runtime.Object { return &corev1.SecretList{} }},
+	{name: "PersistentVolumeClaim", newList: func() runtime.Object { return &corev1.PersistentVolumeClaimList{} }, reportOnly: true},
+}
+
+// Sweeper finds the orphaned resources of the operator every interval. It is
+// added to the manager, which starts it and stops it with the rest of the
+// operator.
+type Sweeper struct {
+	// The sweeps read from the API server rather than from the cache, so they
+	// never see a datacenter as deleted that the cache has not caught up with
+	reader     client.Reader
+	client     client.Client
+	recorder   record.EventRecorder
+	mode       Mode
+	interval   time.Duration
+	namespaces []string
+	shard      *sharding.Shard
+	now        func() time.Time
+}
+
+// blank assignment to verify that Sweeper implements manager.Runnable
+var _ manager.Runnable = &Sweeper{}
+
+// NewSweeper creates a sweeper of the given namespaces, all namespaces when
+// namespaces is empty. With a shard, it only sweeps the resources of the
+// datacenters of the shard.
+func NewSweeper(mgr manager.Manager, mode Mode, interval time.Duration, namespaces []string, shard *sharding.Shard) *Sweeper {
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	return &Sweeper{
+		reader:     mgr.GetAPIReader(),
+		client:     mgr.GetClient(),
+		recorder:   mgr.GetEventRecorderFor("cass-operator"),
+		mode:       mode,
+		interval:   interval,
+		namespaces: namespaces,
+		shard:      shard,
+		now:        time.Now,
+	}
+}
+
+// Start sweeps every interval until stop is closed
+func (s *Sweeper) Start(stop <-chan struct{}) error {
+	log.Info("Sweeping orphaned resources", "mode", s.mode, "interval", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			for _, namespace := range s.namespaces {
+				if err := s.Sweep(context.Background(), namespace); err != nil {
+					log.Error(err, "error sweeping orphaned resources", "namespace", namespace)
+				}
+			}
+		}
+	}
+}
+
+func managedBySelector() labels.Selector {
+	managedBy, _ := labels.NewRequirement(oplabels.ManagedByLabel, selection.In,
+		[]string{oplabels.ManagedByLabelValue, oplabels.ManagedByLabelDefunctValue})
+	datacenter, _ := labels.NewRequirement(api.DatacenterLabel, selection.Exists, nil)
+	return labels.NewSelector().Add(*managedBy, *datacenter)
+}
+
+// Sweep reports or deletes the orphaned resources of a namespace, all
+// namespaces when namespace is empty
+func (s *Sweeper) Sweep(ctx context.Context, namespace string) error {
+	dcList := &api.CassandraDatacenterList{}
+	if err := s.reader.List(ctx, dcList, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	datacenters := map[string]bool{}
+	for _, dc := range dcList.Items {
+		datacenters[dc.Namespace+"/"+dc.Name] = true
+	}
+
+	listOptions := &client.ListOptions{Namespace: namespace, LabelSelector: managedBySelector()}
+	for _, kind := range orphanKinds {
+		list := kind.newList()
+		if err := s.reader.List(ctx, list, listOptions); err != nil {
+			return err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			object, err := meta.Accessor(item)
+			if err != nil {
+				return err
+			}
+			dcName := object.GetLabels()[api.DatacenterLabel]
+			if datacenters[object.GetNamespace()+"/"+dcName] ||
+				!s.shard.Owns(object.GetNamespace(), dcName) ||
+				object.GetDeletionTimestamp() != nil ||
+				s.now().Sub(object.GetCreationTimestamp().Time) < gracePeriod {
+				continue
+			}
+
+			logger := log.WithValues("kind", kind.name, "namespace", object.GetNamespace(),
+				"name", object.GetName(), "cassandraDatacenter", dcName)
+			if s.mode != ModeDelete || kind.reportOnly {
+				logger.Info("Found orphaned resource of a deleted CassandraDatacenter")
+				s.recorder.Eventf(item, corev1.EventTypeWarning, events.OrphanedResource,
+					"The CassandraDatacenter %s of the %s no longer exists", dcName, kind.name)
+				continue
+			}
+
+			logger.Info("Deleting orphaned resource of a deleted CassandraDatacenter")
+			if err := s.client.Delete(ctx, item, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
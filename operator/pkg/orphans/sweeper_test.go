@@ -0,0 +1,115 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package orphans
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/sharding"
+)
+
+var now = time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+func managedObjectMeta(name, dcName, managedBy string, age time.Duration) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:              name,
+		Namespace:         "test",
+		CreationTimestamp: metav1.NewTime(now.Add(-age)),
+		Labels: map[string]string{
+			oplabels.ManagedByLabel: managedBy,
+			api.DatacenterLabel:     dcName,
+		},
+	}
+}
+
+func setupSweeper(mode Mode, objects ...runtime.Object) (*Sweeper, *record.FakeRecorder) {
+	s := scheme.Scheme
+	s.AddKnownTypes(api.SchemeGroupVersion, &api.CassandraDatacenter{}, &api.CassandraDatacenterList{})
+
+	fakeClient := fake.NewFakeClientWithScheme(s, objects...)
+	recorder := record.NewFakeRecorder(10)
+	return &Sweeper{
+		reader:     fakeClient,
+		client:     fakeClient,
+		recorder:   recorder,
+		mode:       mode,
+		interval:   DefaultInterval,
+		namespaces: []string{""},
+		now:        func() time.Time { return now },
+	}, recorder
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("Delete")
+	assert.NoError(t, err)
+	assert.Equal(t, ModeDelete, mode)
+
+	_, err = ParseMode("purge")
+	assert.Error(t, err)
+}
+
+func TestSweep(t *testing.T) {
+	objects := func() []runtime.Object {
+		return []runtime.Object{
+			&api.CassandraDatacenter{ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "test"}},
+			&corev1.Service{ObjectMeta: managedObjectMeta("live", "dc1", oplabels.ManagedByLabelValue, time.Hour)},
+			&corev1.Service{ObjectMeta: managedObjectMeta("orphan", "dc2", oplabels.ManagedByLabelValue, time.Hour)},
+			&corev1.ConfigMap{ObjectMeta: managedObjectMeta("defunct-orphan", "dc2", oplabels.ManagedByLabelDefunctValue, time.Hour)},
+			&corev1.ConfigMap{ObjectMeta: managedObjectMeta("new", "dc3", oplabels.ManagedByLabelValue, time.Minute)},
+			&corev1.Secret{ObjectMeta: managedObjectMeta("other", "dc2", "someone-else", time.Hour)},
+			&corev1.PersistentVolumeClaim{ObjectMeta: managedObjectMeta("retained", "dc2", oplabels.ManagedByLabelValue, time.Hour)},
+		}
+	}
+
+	exists := func(reader client.Reader, name string, obj runtime.Object) bool {
+		err := reader.Get(context.Background(), types.NamespacedName{Namespace: "test", Name: name}, obj)
+		if errors.IsNotFound(err) {
+			return false
+		}
+		assert.NoError(t, err)
+		return true
+	}
+
+	// Reporting leaves everything in place
+	sweeper, recorder := setupSweeper(ModeReport, objects()...)
+	assert.NoError(t, sweeper.Sweep(context.Background(), ""))
+	assert.Len(t, recorder.Events, 3)
+	assert.True(t, exists(sweeper.reader, "orphan", &corev1.Service{}))
+
+	// Deleting keeps the resources of the live datacenter, the new ones, the
+	// ones of others and the PVCs
+	sweeper, recorder = setupSweeper(ModeDelete, objects()...)
+	assert.NoError(t, sweeper.Sweep(context.Background(), ""))
+	assert.Len(t, recorder.Events, 1, "the PVC is reported")
+	assert.True(t, exists(sweeper.reader, "live", &corev1.Service{}))
+	assert.False(t, exists(sweeper.reader, "orphan", &corev1.Service{}))
+	assert.False(t, exists(sweeper.reader, "defunct-orphan", &corev1.ConfigMap{}))
+	assert.True(t, exists(sweeper.reader, "new", &corev1.ConfigMap{}))
+	assert.True(t, exists(sweeper.reader, "other", &corev1.Secret{}))
+	assert.True(t, exists(sweeper.reader, "retained", &corev1.PersistentVolumeClaim{}))
+
+	// The other shards leave the resources of the datacenter alone
+	for index := 0; index < 2; index++ {
+		shard := &sharding.Shard{Index: index, Count: 2}
+		sweeper, _ = setupSweeper(ModeDelete, objects()...)
+		sweeper.shard = shard
+		assert.NoError(t, sweeper.Sweep(context.Background(), ""))
+		assert.Equal(t, !shard.Owns("test", "dc2"), exists(sweeper.reader, "orphan", &corev1.Service{}))
+	}
+}
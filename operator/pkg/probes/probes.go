@@ -0,0 +1,104 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package probes serves the liveness and readiness probes of the operator,
+// and whether the replica is the leader, for operators running more than one
+// replica.
+package probes
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var log = logf.Log.WithName("probes")
+
+// Server serves /healthz, which always succeeds, /readyz, which succeeds once
+// the replica serves the operator, and /leader, which only succeeds on the
+// leader
+type Server struct {
+	bindAddress string
+
+	// Whether the replicas that are not the leader are ready, which they are
+	// when they serve the webhooks
+	readyWhenStandby bool
+
+	leader int32
+}
+
+// blank assignment to verify that Server implements manager.Runnable
+var _ manager.Runnable = &Server{}
+
+// NewServer creates a probe server listening on bindAddress
+func NewServer(bindAddress string, readyWhenStandby bool) *Server {
+	return &Server{
+		bindAddress:      bindAddress,
+		readyWhenStandby: readyWhenStandby,
+	}
+}
+
+// IsLeader tells whether the replica became the leader
+func (s *Server) IsLeader() bool {
+	return atomic.LoadInt32(&s.leader) == 1
+}
+
+// LeaderRunnable returns the runnable that records the replica as the leader
+// when the manager starts it, which it does once the replica leads
+func (s *Server) LeaderRunnable() manager.Runnable {
+	return manager.RunnableFunc(func(stop <-chan struct{}) error {
+		log.Info("Became the leader")
+		atomic.StoreInt32(&s.leader, 1)
+		<-stop
+		return nil
+	})
+}
+
+// Start serves the probes until stop is closed. Unlike the other runnables,
+// it is not added to the manager, which only starts them on the leader.
+func (s *Server) Start(stop <-chan struct{}) error {
+	server := &http.Server{
+		Addr:    s.bindAddress,
+		Handler: s,
+	}
+
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Error(err, "error shutting down probe server")
+		}
+	}()
+
+	log.Info("Starting probe server", "bindAddress", s.bindAddress)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var ok bool
+	switch r.URL.Path {
+	case "/healthz":
+		ok = true
+	case "/readyz":
+		ok = s.readyWhenStandby || s.IsLeader()
+	case "/leader":
+		ok = s.IsLeader()
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if !ok {
+		http.Error(w, "standby", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok"))
+}
@@ -0,0 +1,43 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package probes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func probe(s *Server, path string) int {
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder.Code
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer(":0", false)
+	assert.Equal(t, http.StatusOK, probe(s, "/healthz"))
+	assert.Equal(t, http.StatusServiceUnavailable, probe(s, "/readyz"))
+	assert.Equal(t, http.StatusServiceUnavailable, probe(s, "/leader"))
+	assert.Equal(t, http.StatusNotFound, probe(s, "/metrics"))
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go s.LeaderRunnable().Start(stop)
+	for i := 0; i < 100 && !s.IsLeader(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, s.IsLeader())
+	assert.Equal(t, http.StatusOK, probe(s, "/readyz"))
+	assert.Equal(t, http.StatusOK, probe(s, "/leader"))
+}
+
+func TestServer_ReadyWhenStandby(t *testing.T) {
+	s := NewServer(":0", true)
+	assert.Equal(t, http.StatusOK, probe(s, "/readyz"))
+	assert.Equal(t, http.StatusServiceUnavailable, probe(s, "/leader"))
+}
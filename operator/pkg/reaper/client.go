@@ -0,0 +1,89 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package reaper is a client of the REST API of Cassandra Reaper
+// (http://cassandra-reaper.io), which the operator registers the clusters of
+// the datacenters with.
+package reaper
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPort is the port of the REST API of Reaper
+const DefaultPort = 8080
+
+// DefaultTimeout is the timeout of the requests to Reaper
+const DefaultTimeout = 30 * time.Second
+
+// HTTPClient sends the requests of a Client. *http.Client implements it.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// StatusError is returned when Reaper answers with a status other than 2xx
+type StatusError struct {
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("reaper returned status %d for %s: %s", e.StatusCode, e.Path, e.Body)
+}
+
+// Client calls the REST API of a Reaper
+type Client struct {
+	HTTPClient HTTPClient
+	// BaseURL is the URL of Reaper, such as http://reaper-service:8080
+	BaseURL string
+}
+
+// NewClient returns a client of the Reaper at baseURL
+func NewClient(httpClient HTTPClient, baseURL string) *Client {
+	return &Client{HTTPClient: httpClient, BaseURL: baseURL}
+}
+
+// AddCluster registers the cluster of seedHost with Reaper, which then
+// discovers its nodes through JMX. Adding a cluster that is already
+// registered is not an error.
+func (c *Client) AddCluster(ctx context.Context, seedHost string, jmxPort int) error {
+	query := url.Values{}
+	query.Set("seedHost", seedHost)
+	query.Set("jmxPort", strconv.Itoa(jmxPort))
+
+	err := c.do(ctx, http.MethodPost, "/cluster?"+query.Encode())
+	if statusErr, ok := err.(*StatusError); ok && statusErr.StatusCode == http.StatusConflict {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) do(ctx context.Context, method, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.BaseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return &StatusError{Path: path, StatusCode: res.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reaper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(server.Client(), server.URL)
+}
+
+func TestAddCluster(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/cluster", r.URL.Path)
+		assert.Equal(t, "cluster1-dc1-all-pods-service", r.URL.Query().Get("seedHost"))
+		assert.Equal(t, "7199", r.URL.Query().Get("jmxPort"))
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	assert.NoError(t, client.AddCluster(context.Background(), "cluster1-dc1-all-pods-service", 7199))
+}
+
+func TestAddCluster_AlreadyRegistered(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+
+	assert.NoError(t, client.AddCluster(context.Background(), "cluster1-dc1-all-pods-service", 7199))
+}
+
+func TestAddCluster_Error(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("failed to connect to seed host\n"))
+	})
+
+	err := client.AddCluster(context.Background(), "cluster1-dc1-all-pods-service", 7199)
+	assert.EqualError(t, err, "reaper returned status 400 for /cluster?jmxPort=7199&seedHost=cluster1-dc1-all-pods-service: failed to connect to seed host")
+}
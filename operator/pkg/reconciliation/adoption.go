@@ -0,0 +1,168 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+func hasVolumeClaimTemplate(sts *appsv1.StatefulSet, name string) bool {
+	for _, pvc := range sts.Spec.VolumeClaimTemplates {
+		if pvc.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// adoptionLabels returns the labels the operator selects the resources of a
+// rack with
+func adoptionLabels(dc *api.CassandraDatacenter, rackName string) map[string]string {
+	labels := dc.GetRackLabels(rackName)
+	oplabels.AddManagedByLabel(labels)
+	return labels
+}
+
+// labeledObject is a pod or a PVC, whose labels the operator adds its own to
+type labeledObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// addLabels patches the labels that are missing on an object
+func (rc *ReconciliationContext) addLabels(object labeledObject, labels map[string]string) error {
+	if mapContains(object.GetLabels(), labels) {
+		return nil
+	}
+	patch := client.MergeFrom(object.DeepCopyObject())
+	object.SetLabels(utils.MergeMap(map[string]string{}, object.GetLabels(), labels))
+	return rc.Client.Patch(rc.Ctx, object, patch)
+}
+
+func (rc *ReconciliationContext) adoptionFailed(statefulSet *appsv1.StatefulSet, reason string) result.ReconcileResult {
+	rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeWarning, events.AdoptionFailed,
+		"Cannot adopt StatefulSet %s: %s", statefulSet.Name, reason)
+	return result.Error(fmt.Errorf("cannot adopt statefulset %s: %s", statefulSet.Name, reason))
+}
+
+// CheckAdoption takes ownership of the StatefulSets of a datacenter with
+// adoptExisting that were created without the operator, under the names it
+// gives them. Their pods and PVCs get the labels of their rack, so the
+// operator finds them, and a StatefulSet whose immutable fields differ from
+// the ones of the operator is recreated without its pods, which the new one
+// adopts. The pods are then rolled forward to the pod template of the
+// operator like on any other update. The services need no adopting, as
+// CheckHeadlessServices overwrites the ones found under its names.
+func (rc *ReconciliationContext) CheckAdoption() result.ReconcileResult {
+	dc := rc.Datacenter
+	logger := rc.ReqLogger
+	if !dc.Spec.AdoptExisting {
+		return result.Continue()
+	}
+
+	for _, rackInfo := range rc.desiredRackInformation {
+		statefulSet := &appsv1.StatefulSet{}
+		err := rc.Client.Get(rc.Ctx, newNamespacedNameForStatefulSet(dc, rackInfo.RackName), statefulSet)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return result.Error(err)
+		}
+
+		if metav1.IsControlledBy(statefulSet, dc) {
+			continue
+		}
+		if controller := metav1.GetControllerOf(statefulSet); controller != nil {
+			return rc.adoptionFailed(statefulSet, fmt.Sprintf("it is controlled by %s %s", controller.Kind, controller.Name))
+		}
+		// The PVCs are named after the volume claim templates, so the pods of
+		// a statefulset without the ones of the operator would lose their data
+		if !hasVolumeClaimTemplate(statefulSet, PvcName) {
+			return rc.adoptionFailed(statefulSet, fmt.Sprintf("it has no %s volume claim template", PvcName))
+		}
+		if statefulSet.GetDeletionTimestamp() != nil {
+			return result.RequeueSoon(2)
+		}
+
+		logger.Info("Adopting statefulset", "statefulSet", statefulSet.Name, "rack", rackInfo.RackName)
+		labels := adoptionLabels(dc, rackInfo.RackName)
+
+		selector, err := metav1.LabelSelectorAsSelector(statefulSet.Spec.Selector)
+		if err != nil {
+			return rc.adoptionFailed(statefulSet, err.Error())
+		}
+		podList := &corev1.PodList{}
+		if err := rc.Client.List(rc.Ctx, podList, &client.ListOptions{Namespace: dc.Namespace, LabelSelector: selector}); err != nil {
+			return result.Error(err)
+		}
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			podLabels := utils.MergeMap(map[string]string{}, labels)
+			// The nodes of the pods were started without the operator
+			if _, notReady := notReadySince(pod); !notReady && pod.Labels[api.CassNodeState] == "" {
+				podLabels[api.CassNodeState] = stateStarted
+			}
+			if err := rc.addLabels(pod, podLabels); err != nil {
+				logger.Error(err, "error labeling adopted pod", "pod", pod.Name)
+				return result.Error(err)
+			}
+
+			for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+				pvc := &corev1.PersistentVolumeClaim{}
+				key := types.NamespacedName{Name: template.Name + "-" + pod.Name, Namespace: dc.Namespace}
+				if err := rc.Client.Get(rc.Ctx, key, pvc); errors.IsNotFound(err) {
+					continue
+				} else if err != nil {
+					return result.Error(err)
+				}
+				if err := rc.addLabels(pvc, labels); err != nil {
+					logger.Error(err, "error labeling adopted PVC", "pvc", pvc.Name)
+					return result.Error(err)
+				}
+			}
+		}
+
+		desiredSts, err := newStatefulSetForCassandraDatacenter(rackInfo.RackName, dc, 0)
+		if err != nil {
+			return result.Error(err)
+		}
+		if immutableStatefulSetFieldsChanged(statefulSet, desiredSts) {
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.RecreatingStatefulSet,
+				"Recreating StatefulSet %s of rack %s to adopt it", statefulSet.Name, rackInfo.RackName)
+			if err := rc.Client.Delete(rc.Ctx, statefulSet, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil {
+				logger.Error(err, "Failed to delete the statefulset", "statefulSet", statefulSet.Name)
+				return result.Error(err)
+			}
+			return result.RequeueSoon(2)
+		}
+
+		if err := setControllerReference(dc, statefulSet, rc.Scheme); err != nil {
+			return rc.adoptionFailed(statefulSet, err.Error())
+		}
+		statefulSet.SetLabels(utils.MergeMap(map[string]string{}, statefulSet.GetLabels(), labels))
+		if err := rc.Client.Update(rc.Ctx, statefulSet); err != nil {
+			logger.Error(err, "error adopting statefulset", "statefulSet", statefulSet.Name)
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.AdoptedResource,
+			"Adopted StatefulSet %s for rack %s", statefulSet.Name, rackInfo.RackName)
+		return result.RequeueSoon(2)
+	}
+
+	return result.Continue()
+}
@@ -0,0 +1,125 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+)
+
+// unmanagedStatefulSet returns a statefulset of the rack, as created without
+// the operator, and a ready pod of it with its PVC
+func unmanagedStatefulSet(t *testing.T, rc *ReconciliationContext, rackName string) (*appsv1.StatefulSet, *corev1.Pod, *corev1.PersistentVolumeClaim) {
+	dc := rc.Datacenter
+	selector := map[string]string{"app": "cassandra"}
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter(rackName, dc, 1)
+	assert.NoError(t, err)
+	statefulSet.OwnerReferences = nil
+	statefulSet.Labels = selector
+	statefulSet.Spec.Selector = &metav1.LabelSelector{MatchLabels: selector}
+	statefulSet.Spec.Template.Labels = selector
+
+	pod := makeReadyPod(statefulSet.Name + "-0")
+	pod.Namespace = dc.Namespace
+	pod.Labels = selector
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: PvcName + "-" + pod.Name, Namespace: dc.Namespace},
+	}
+	for _, obj := range []labeledObject{statefulSet, pod, pvc} {
+		assert.NoError(t, rc.Client.Create(rc.Ctx, obj))
+	}
+	return statefulSet, pod, pvc
+}
+
+func TestCheckAdoption(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 1}}
+	statefulSet, pod, pvc := unmanagedStatefulSet(t, rc, "default")
+
+	// Nothing is adopted without adoptExisting
+	assert.False(t, rc.CheckAdoption().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, pod))
+	assert.Empty(t, pod.Labels[api.RackLabel])
+
+	dc.Spec.AdoptExisting = true
+	assert.True(t, rc.CheckAdoption().Completed(), "the statefulset is recreated")
+
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, pod))
+	assert.True(t, mapContains(pod.Labels, adoptionLabels(dc, "default")))
+	assert.Equal(t, "cassandra", pod.Labels["app"])
+	assert.Equal(t, stateStarted, pod.Labels[api.CassNodeState])
+
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, pvc))
+	assert.True(t, mapContains(pvc.Labels, adoptionLabels(dc, "default")))
+
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: statefulSet.Name, Namespace: statefulSet.Namespace}, &appsv1.StatefulSet{})
+	assert.True(t, errors.IsNotFound(err), "the statefulset is deleted without its pods")
+	assert.True(t, hasEvent(recordedEvents(rc), events.RecreatingStatefulSet))
+}
+
+func TestCheckAdoption_SameImmutableFields(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.AdoptExisting = true
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 1}}
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("default", dc, 1)
+	assert.NoError(t, err)
+	statefulSet.OwnerReferences = nil
+	statefulSet.Labels = nil
+	assert.NoError(t, rc.Client.Create(rc.Ctx, statefulSet))
+
+	assert.True(t, rc.CheckAdoption().Completed())
+	assert.True(t, hasEvent(recordedEvents(rc), events.AdoptedResource))
+
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: statefulSet.Name, Namespace: statefulSet.Namespace}, statefulSet))
+	assert.True(t, mapContains(statefulSet.Labels, adoptionLabels(dc, "default")), "the statefulset is kept")
+}
+
+func TestCheckAdoption_Failures(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.AdoptExisting = true
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 1}}
+	statefulSet, _, _ := unmanagedStatefulSet(t, rc, "default")
+
+	// A statefulset controlled by another resource is left alone
+	isController := true
+	statefulSet.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "1234", Controller: &isController},
+	}
+	assert.NoError(t, rc.Client.Update(rc.Ctx, statefulSet))
+	recResult := rc.CheckAdoption()
+	_, err := recResult.Output()
+	assert.Error(t, err)
+	assert.True(t, hasEvent(recordedEvents(rc), events.AdoptionFailed))
+
+	// And so is one whose pods would lose their data
+	statefulSet.OwnerReferences = nil
+	statefulSet.Spec.VolumeClaimTemplates = nil
+	assert.NoError(t, rc.Client.Update(rc.Ctx, statefulSet))
+	recResult = rc.CheckAdoption()
+	_, err = recResult.Output()
+	assert.Error(t, err)
+	assert.True(t, hasEvent(recordedEvents(rc), events.AdoptionFailed))
+}
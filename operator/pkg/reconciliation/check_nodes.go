@@ -4,13 +4,16 @@
 package reconciliation
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
 
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
@@ -63,6 +66,10 @@ func (rc *ReconciliationContext) getNode(nodeName string) (*corev1.Node, error)
 }
 
 func (rc *ReconciliationContext) removePVC(pvc *corev1.PersistentVolumeClaim) error {
+	if err := rc.unprotectPVC(pvc); err != nil {
+		return err
+	}
+
 	err := rc.Client.Delete(rc.Ctx, pvc)
 	if err != nil {
 		rc.ReqLogger.Error(err, "error during cassandra pvc delete",
@@ -104,7 +111,7 @@ func (rc *ReconciliationContext) getDCPodByName(podName string) *corev1.Pod {
 }
 
 //
-// functions to statisfy EMMSPI interface from psp package
+// functions to statisfy EMMSPI interface from emm package
 //
 
 func (rc *ReconciliationContext) GetAllNodesInDC() ([]*corev1.Node, error) {
@@ -157,12 +164,38 @@ func (rc *ReconciliationContext) GetAllPodsNotReadyInDC() []*corev1.Pod {
 	return findAllPodsNotReady(rc.dcPods)
 }
 
+// GetPodPVCs returns the data PVC of the pod followed by the PVCs of its commit
+// log volume, of its CDC volume and of its additional data volumes
 func (rc *ReconciliationContext) GetPodPVCs(pod *corev1.Pod) ([]*corev1.PersistentVolumeClaim, error) {
 	pvc, err := rc.GetPodPVC(pod.Namespace, pod.Name)
 	if err != nil {
 		return nil, err
 	}
-	return []*corev1.PersistentVolumeClaim{pvc}, nil
+	pvcs := []*corev1.PersistentVolumeClaim{pvc}
+
+	var claimNames []string
+	if rc.Datacenter.Spec.StorageConfig.CommitLogVolumeClaimSpec != nil {
+		claimNames = append(claimNames, CommitLogPvcName)
+	}
+	if cdc := rc.Datacenter.Spec.CDC; cdc != nil && cdc.VolumeClaimSpec != nil {
+		claimNames = append(claimNames, CDCPvcName)
+	}
+	for _, volume := range rc.Datacenter.Spec.StorageConfig.AdditionalDataVolumes {
+		claimNames = append(claimNames, volume.Name)
+	}
+
+	for _, claimName := range claimNames {
+		volumePvc := &corev1.PersistentVolumeClaim{}
+		pvcFullName := fmt.Sprintf("%s-%s", claimName, pod.Name)
+		err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: pod.Namespace, Name: pvcFullName}, volumePvc)
+		if err != nil {
+			rc.ReqLogger.Error(err, "error retrieving PersistentVolumeClaim", "Claim Name", pvcFullName)
+			return nil, err
+		}
+		pvcs = append(pvcs, volumePvc)
+	}
+
+	return pvcs, nil
 }
 
 func (rc *ReconciliationContext) StartNodeReplace(podName string) error {
@@ -171,16 +204,16 @@ func (rc *ReconciliationContext) StartNodeReplace(podName string) error {
 		return fmt.Errorf("Pod with name '%s' not part of datacenter", podName)
 	}
 
-	pvc, err := rc.GetPodPVC(pod.Namespace, pod.Name)
+	pvcs, err := rc.GetPodPVCs(pod)
 	if err != nil {
 		return err
 	}
-	if pvc == nil {
-		return fmt.Errorf("Pod with name '%s' does not have a PVC", podName)
-	}
 
-	// Add the cassandra node to replace nodes
-	rc.Datacenter.Spec.ReplaceNodes = append(rc.Datacenter.Spec.ReplaceNodes, podName)
+	// Add the cassandra node to replace nodes, once when a replacement that
+	// failed halfway is started again
+	if utils.IndexOfString(rc.Datacenter.Spec.ReplaceNodes, podName) < 0 {
+		rc.Datacenter.Spec.ReplaceNodes = append(rc.Datacenter.Spec.ReplaceNodes, podName)
+	}
 
 	// Update CassandraDatacenter
 	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
@@ -188,10 +221,13 @@ func (rc *ReconciliationContext) StartNodeReplace(podName string) error {
 		return err
 	}
 
-	// delete pod and pvc
-	err = rc.removePVC(pvc)
-	if err != nil {
-		return err
+	// delete pod and pvcs, the replacement node starts without data in any of
+	// its data directories
+	for _, pvc := range pvcs {
+		err = rc.removePVC(pvc)
+		if err != nil {
+			return err
+		}
 	}
 
 	err = rc.RemovePod(pod)
@@ -202,6 +238,21 @@ func (rc *ReconciliationContext) StartNodeReplace(podName string) error {
 	return nil
 }
 
+// ReplaceNode starts the replacement of the node of the pod with
+// StartNodeReplace, for the controllers that replace nodes of a datacenter
+// they do not reconcile themselves
+func ReplaceNode(ctx context.Context, c client.Client, mgmtClient httphelper.NodeMgmtClient, logger logr.Logger, dc *api.CassandraDatacenter, pod *corev1.Pod) error {
+	rc := &ReconciliationContext{
+		Ctx:            ctx,
+		Client:         c,
+		NodeMgmtClient: mgmtClient,
+		ReqLogger:      logger,
+		Datacenter:     dc,
+		dcPods:         []*corev1.Pod{pod},
+	}
+	return rc.StartNodeReplace(pod.Name)
+}
+
 func (rc *ReconciliationContext) GetInProgressNodeReplacements() []string {
 	return rc.Datacenter.Status.NodeReplacements
 }
@@ -0,0 +1,207 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+// derivedCondition is a condition that follows from the state of the
+// datacenter, its pods and its other conditions, rather than from a step of
+// the reconciliation
+type derivedCondition struct {
+	conditionType api.DatacenterConditionType
+	derive        func(rc *ReconciliationContext) (status corev1.ConditionStatus, reason, message string)
+}
+
+// derivedConditions are all set in one place, updateDerivedConditions, so
+// that their transitions only depend on the state they are derived from
+var derivedConditions = []derivedCondition{
+	{api.DatacenterRollingUpgrade, deriveRollingUpgrade},
+	{api.DatacenterDecommissioning, deriveDecommissioning},
+	{api.DatacenterInvalidConfig, deriveInvalidConfig},
+	{api.DatacenterRequiresAttention, deriveRequiresAttention},
+}
+
+// The conditions whose status needs an action of the administrators of the
+// datacenter
+var attentionConditions = []struct {
+	conditionType api.DatacenterConditionType
+	status        corev1.ConditionStatus
+}{
+	{api.DatacenterValid, corev1.ConditionFalse},
+	{api.DatacenterConfigValid, corev1.ConditionFalse},
+	{api.DatacenterCanaryUpgradePaused, corev1.ConditionTrue},
+	{api.DatacenterReplicationFactorUnsafe, corev1.ConditionTrue},
+}
+
+// setCondition sets the condition, and returns whether the condition of the
+// datacenter changed, which then needs to be patched. Every condition is set
+// through it: it records the generation of the datacenter the condition was
+// set from, and the transition time when the status changes. A condition set
+// without a reason keeps the reason and the message of the current one while
+// its status holds, and otherwise gets the default ones of its status.
+func (rc *ReconciliationContext) setCondition(condition *api.DatacenterCondition) bool {
+	dc := rc.Datacenter
+	condition.ObservedGeneration = dc.Generation
+
+	current, found := dc.GetCondition(condition.Type)
+	if !found || current.Status != condition.Status {
+		condition.LastTransitionTime = metav1.Now()
+	} else {
+		condition.LastTransitionTime = current.LastTransitionTime
+		if condition.Reason == "" && current.Reason != "" {
+			condition.Reason, condition.Message = current.Reason, current.Message
+		}
+	}
+
+	if condition.Reason == "" {
+		condition.Reason = defaultConditionReason(condition.Type, condition.Status)
+	}
+	if condition.Message == "" {
+		condition.Message = fmt.Sprintf("%s is %s", condition.Type, condition.Status)
+	}
+
+	if found && current == *condition {
+		return false
+	}
+	dc.SetCondition(*condition)
+	return true
+}
+
+// conditionTransitions returns whether setting the condition to status would
+// change its status, which the events of the transitions are recorded for
+func (rc *ReconciliationContext) conditionTransitions(conditionType api.DatacenterConditionType, status corev1.ConditionStatus) bool {
+	return rc.Datacenter.GetConditionStatus(conditionType) != status
+}
+
+// defaultConditionReason returns the reason of a condition set without one,
+// such as NotReady for the Ready condition when it is False
+func defaultConditionReason(conditionType api.DatacenterConditionType, status corev1.ConditionStatus) string {
+	switch status {
+	case corev1.ConditionTrue:
+		return string(conditionType)
+	case corev1.ConditionFalse:
+		return "Not" + string(conditionType)
+	default:
+		return string(conditionType) + "Unknown"
+	}
+}
+
+// updateDerivedConditions sets the derived conditions of the datacenter from
+// its current state, and returns whether any of them changed. Their reason and
+// message follow the state while their status holds, such as the list of
+// conditions RequiresAttention names.
+func (rc *ReconciliationContext) updateDerivedConditions() bool {
+	updated := false
+	for _, derived := range derivedConditions {
+		status, reason, message := derived.derive(rc)
+		updated = rc.setCondition(api.NewDatacenterConditionWithReason(derived.conditionType, status, reason, message)) || updated
+	}
+	return updated
+}
+
+func cassandraImage(pod *corev1.Pod) string {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == CassandraContainerName {
+			return container.Image
+		}
+	}
+	return ""
+}
+
+// desiredServerImage returns the image of the cassandra container of the pods,
+// which the pod template spec of the datacenter may override
+func desiredServerImage(dc *api.CassandraDatacenter) string {
+	if dc.Spec.PodTemplateSpec != nil {
+		for _, container := range dc.Spec.PodTemplateSpec.Spec.Containers {
+			if container.Name == CassandraContainerName && container.Image != "" {
+				return container.Image
+			}
+		}
+	}
+	image, err := makeImage(dc)
+	if err != nil {
+		return ""
+	}
+	return image
+}
+
+func deriveRollingUpgrade(rc *ReconciliationContext) (corev1.ConditionStatus, string, string) {
+	desired := desiredServerImage(rc.Datacenter)
+	if desired == "" {
+		return corev1.ConditionFalse, "ImageUnknown", "The server image of the datacenter is unknown"
+	}
+
+	outdated := 0
+	for _, pod := range rc.dcPods {
+		if image := cassandraImage(pod); image != "" && image != desired {
+			outdated++
+		}
+	}
+	if outdated == 0 {
+		return corev1.ConditionFalse, "ImageUpToDate", fmt.Sprintf("All pods run %s", desired)
+	}
+	return corev1.ConditionTrue, "PodsOutdated",
+		fmt.Sprintf("Upgrading %d of %d pods to %s", outdated, len(rc.dcPods), desired)
+}
+
+func deriveDecommissioning(rc *ReconciliationContext) (corev1.ConditionStatus, string, string) {
+	var decommissioning []string
+	for _, pod := range rc.dcPods {
+		if pod.Labels[api.CassNodeState] == stateDecommissioning {
+			decommissioning = append(decommissioning, pod.Name)
+		}
+	}
+	if len(decommissioning) == 0 {
+		return corev1.ConditionFalse, "NoNodeDecommissioning", "No node is decommissioning"
+	}
+	sort.Strings(decommissioning)
+	return corev1.ConditionTrue, "DecommissioningNodes",
+		"Decommissioning the nodes of pods " + strings.Join(decommissioning, ", ")
+}
+
+func deriveInvalidConfig(rc *ReconciliationContext) (corev1.ConditionStatus, string, string) {
+	configValid, ok := rc.Datacenter.GetCondition(api.DatacenterConfigValid)
+	if !ok || configValid.Status != corev1.ConditionFalse {
+		return corev1.ConditionFalse, "ConfigValid", "The config of the datacenter is valid"
+	}
+	reason := configValid.Reason
+	if reason == "" {
+		reason = "ConfigNotValid"
+	}
+	return corev1.ConditionTrue, reason, configValid.Message
+}
+
+func deriveRequiresAttention(rc *ReconciliationContext) (corev1.ConditionStatus, string, string) {
+	dc := rc.Datacenter
+	reason := ""
+	var messages []string
+	for _, attention := range attentionConditions {
+		condition, ok := dc.GetCondition(attention.conditionType)
+		if !ok || condition.Status != attention.status {
+			continue
+		}
+		if reason == "" {
+			reason = string(attention.conditionType)
+		}
+		message := fmt.Sprintf("%s is %s", attention.conditionType, attention.status)
+		if condition.Message != "" {
+			message += ": " + condition.Message
+		}
+		messages = append(messages, message)
+	}
+
+	if len(messages) == 0 {
+		return corev1.ConditionFalse, "NoActionNeeded", "The datacenter needs no action"
+	}
+	return corev1.ConditionTrue, reason, strings.Join(messages, "; ")
+}
@@ -0,0 +1,115 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func withCassandraImage(pod *corev1.Pod, image string) *corev1.Pod {
+	pod.Spec.Containers = []corev1.Container{{Name: CassandraContainerName, Image: image}}
+	return pod
+}
+
+func TestUpdateDerivedConditions(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Generation = 3
+	dc.Spec.ServerImage = "cassandra:4.0.1"
+	rc.dcPods = []*corev1.Pod{
+		withCassandraImage(makeReadyPod("pod-a"), "cassandra:4.0.1"),
+		withCassandraImage(makeReadyPod("pod-b"), "cassandra:4.0.0"),
+	}
+
+	assert.True(t, rc.updateDerivedConditions())
+	rollingUpgrade, _ := dc.GetCondition(api.DatacenterRollingUpgrade)
+	assert.Equal(t, corev1.ConditionTrue, rollingUpgrade.Status)
+	assert.Equal(t, "PodsOutdated", rollingUpgrade.Reason)
+	assert.Equal(t, "Upgrading 1 of 2 pods to cassandra:4.0.1", rollingUpgrade.Message)
+	assert.Equal(t, int64(3), rollingUpgrade.ObservedGeneration)
+	for _, conditionType := range []api.DatacenterConditionType{
+		api.DatacenterDecommissioning, api.DatacenterInvalidConfig, api.DatacenterRequiresAttention,
+	} {
+		condition, _ := dc.GetCondition(conditionType)
+		assert.Equal(t, corev1.ConditionFalse, condition.Status, string(conditionType))
+		assert.NotEmpty(t, condition.Reason, string(conditionType))
+	}
+
+	assert.False(t, rc.updateDerivedConditions(), "nothing changed")
+
+	rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterConfigValid, corev1.ConditionFalse,
+		configRenderingFailedReason, "bad config"))
+	rc.setCondition(api.NewDatacenterCondition(api.DatacenterCanaryUpgradePaused, corev1.ConditionTrue))
+	assert.True(t, rc.updateDerivedConditions())
+
+	invalidConfig, _ := dc.GetCondition(api.DatacenterInvalidConfig)
+	assert.Equal(t, corev1.ConditionTrue, invalidConfig.Status)
+	assert.Equal(t, configRenderingFailedReason, invalidConfig.Reason)
+	assert.Equal(t, "bad config", invalidConfig.Message)
+
+	attention, _ := dc.GetCondition(api.DatacenterRequiresAttention)
+	assert.Equal(t, corev1.ConditionTrue, attention.Status)
+	assert.Equal(t, "ConfigValid", attention.Reason)
+	assert.Equal(t, "ConfigValid is False: bad config; CanaryUpgradePaused is True", attention.Message)
+
+	// The message follows the conditions without a new transition
+	rc.setCondition(api.NewDatacenterCondition(api.DatacenterCanaryUpgradePaused, corev1.ConditionFalse))
+	assert.True(t, rc.updateDerivedConditions())
+	updated, _ := dc.GetCondition(api.DatacenterRequiresAttention)
+	assert.Equal(t, "ConfigValid is False: bad config", updated.Message)
+	assert.Equal(t, attention.LastTransitionTime, updated.LastTransitionTime)
+}
+
+func TestSetCondition_ObservedGeneration(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Generation = 1
+	assert.True(t, rc.setCondition(api.NewDatacenterCondition(api.DatacenterReady, corev1.ConditionTrue)))
+	set, _ := dc.GetCondition(api.DatacenterReady)
+
+	assert.False(t, rc.setCondition(api.NewDatacenterCondition(api.DatacenterReady, corev1.ConditionTrue)))
+
+	dc.Generation = 2
+	assert.True(t, rc.setCondition(api.NewDatacenterCondition(api.DatacenterReady, corev1.ConditionTrue)),
+		"the new generation needs to be patched")
+	condition, _ := dc.GetCondition(api.DatacenterReady)
+	assert.Equal(t, int64(2), condition.ObservedGeneration)
+	assert.Equal(t, set.LastTransitionTime, condition.LastTransitionTime)
+}
+
+func TestSetCondition_ReasonAndMessage(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	assert.True(t, rc.setCondition(api.NewDatacenterCondition(api.DatacenterReady, corev1.ConditionFalse)))
+	condition, _ := dc.GetCondition(api.DatacenterReady)
+	assert.Equal(t, "NotReady", condition.Reason)
+	assert.Equal(t, "Ready is False", condition.Message)
+
+	assert.True(t, rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterReady, corev1.ConditionFalse,
+		"NodesDown", "2 nodes are down")), "the reason and the message are updated while the status holds")
+	updated, _ := dc.GetCondition(api.DatacenterReady)
+	assert.Equal(t, "NodesDown", updated.Reason)
+	assert.Equal(t, condition.LastTransitionTime, updated.LastTransitionTime)
+
+	assert.False(t, rc.setCondition(api.NewDatacenterCondition(api.DatacenterReady, corev1.ConditionFalse)),
+		"a condition without a reason keeps the current one")
+	updated, _ = dc.GetCondition(api.DatacenterReady)
+	assert.Equal(t, "2 nodes are down", updated.Message)
+
+	assert.True(t, rc.setCondition(api.NewDatacenterCondition(api.DatacenterReady, corev1.ConditionTrue)))
+	updated, _ = dc.GetCondition(api.DatacenterReady)
+	assert.Equal(t, "Ready", updated.Reason)
+	assert.Equal(t, "Ready is True", updated.Message)
+}
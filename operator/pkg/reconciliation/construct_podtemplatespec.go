@@ -6,10 +6,13 @@ package reconciliation
 // This file defines constructors for k8s objects
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"github.com/pkg/errors"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
@@ -18,16 +21,41 @@ import (
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
 	DefaultTerminationGracePeriodSeconds = 120
+	DrainShutdownGracePeriodSeconds      = 30
 	ServerConfigContainerName            = "server-config-init"
 	CassandraContainerName               = "cassandra"
 	PvcName                              = "server-data"
+	CommitLogPvcName                     = "server-commitlog"
 	SystemLoggerContainerName            = "server-system-logger"
+	BackupSidecarContainerName           = "backup-sidecar"
+	MetricsCollectorContainerName        = "mcac-agent-init"
+	MetricsCollectorVolumeName           = "mcac-agent"
+	MetricsCollectorConfigVolumeName     = "mcac-config"
+	InternodeKeystoreContainerName       = "internode-keystore-init"
+	InternodeKeystoresVolumeName         = "internode-keystores"
+	InternodeEncryptionVolumeName        = "internode-encryption"
+	ClientKeystoreContainerName          = "client-keystore-init"
+	ClientKeystoresVolumeName            = "client-keystores"
+	VaultSecretsVolumeName               = "vault-secrets"
+	JmxCredentialsContainerName          = "jmx-credentials-init"
+	JmxCredentialsVolumeName             = "jmx-credentials"
+	BackupAgentContainerName             = "medusa"
+	BackupAgentConfigVolumeName          = "medusa-config"
+	BackupAgentSecretsVolumeName         = "medusa-secrets"
+	BroadcastAddressContainerName        = "broadcast-address-init"
+	PodAnnotationsVolumeName             = "pod-annotations"
+	LogbackContainerName                 = "logback-init"
+	LogbackConfigVolumeName              = "logback-config"
+	RenderedConfigVolumeName             = "rendered-config"
+	CDCPvcName                           = "server-cdc"
+	CDCForwarderContainerName            = "cdc-forwarder"
 )
 
 // calculateNodeAffinity provides a way to decide where to schedule pods within a statefulset based on labels
@@ -64,20 +92,102 @@ func calculateNodeAffinity(labels map[string]string) *corev1.NodeAffinity {
 	}
 }
 
+// calculateRackNodeAffinity combines the node affinity from the labels with the
+// node affinity of the rack
+func calculateRackNodeAffinity(labels map[string]string, rackAffinity *corev1.NodeAffinity) *corev1.NodeAffinity {
+	labelsAffinity := calculateNodeAffinity(labels)
+	if rackAffinity == nil {
+		return labelsAffinity
+	}
+
+	nodeAffinity := rackAffinity.DeepCopy()
+	if labelsAffinity == nil {
+		return nodeAffinity
+	}
+
+	labelSelectors := labelsAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions
+	required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil || len(required.NodeSelectorTerms) == 0 {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = labelsAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		return nodeAffinity
+	}
+
+	// Node selector terms are ORed, so every term has to match the labels
+	for i := range required.NodeSelectorTerms {
+		term := &required.NodeSelectorTerms[i]
+		term.MatchExpressions = append(
+			append([]corev1.NodeSelectorRequirement{}, labelSelectors...),
+			term.MatchExpressions...)
+	}
+
+	return nodeAffinity
+}
+
 // calculatePodAntiAffinity provides a way to keep the db pods of a statefulset away from other db pods
-func calculatePodAntiAffinity(allowMultipleNodesPerWorker bool) *corev1.PodAntiAffinity {
+func calculatePodAntiAffinity(allowMultipleNodesPerWorker bool, config *api.PodAntiAffinityConfig) *corev1.PodAntiAffinity {
 	if allowMultipleNodesPerWorker {
 		return nil
 	}
+
+	topologyKey := api.DefaultPodAntiAffinityTopologyKey
+	if config != nil {
+		topologyKey = config.GetTopologyKey()
+	}
+
+	term := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{
+					Key:      api.ClusterLabel,
+					Operator: metav1.LabelSelectorOpExists,
+				},
+				{
+					Key:      api.DatacenterLabel,
+					Operator: metav1.LabelSelectorOpExists,
+				},
+				{
+					Key:      api.RackLabel,
+					Operator: metav1.LabelSelectorOpExists,
+				},
+			},
+		},
+		TopologyKey: topologyKey,
+	}
+
+	if config != nil && config.Preferred {
+		return &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{
+					Weight:          100,
+					PodAffinityTerm: term,
+				},
+			},
+		}
+	}
+
 	return &corev1.PodAntiAffinity{
-		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
-			{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{term},
+	}
+}
+
+// calculateSharedWorkerAntiAffinity spreads the server pods of the cluster
+// over the workers when allowMultipleNodesPerWorker lets them share one, and
+// with isolateRacks keeps the pods of the other racks of the datacenter off the
+// workers of the pods of the rack. The terms are added to the podAntiAffinity
+// of the podTemplateSpec, if any.
+func calculateSharedWorkerAntiAffinity(dc *api.CassandraDatacenter, rackName string, templateAntiAffinity *corev1.PodAntiAffinity) *corev1.PodAntiAffinity {
+	antiAffinity := &corev1.PodAntiAffinity{}
+	if templateAntiAffinity != nil {
+		antiAffinity = templateAntiAffinity.DeepCopy()
+	}
+
+	antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		corev1.WeightedPodAffinityTerm{
+			Weight: 100,
+			PodAffinityTerm: corev1.PodAffinityTerm{
 				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: dc.GetClusterLabels(),
 					MatchExpressions: []metav1.LabelSelectorRequirement{
-						{
-							Key:      api.ClusterLabel,
-							Operator: metav1.LabelSelectorOpExists,
-						},
 						{
 							Key:      api.DatacenterLabel,
 							Operator: metav1.LabelSelectorOpExists,
@@ -88,10 +198,103 @@ func calculatePodAntiAffinity(allowMultipleNodesPerWorker bool) *corev1.PodAntiA
 						},
 					},
 				},
-				TopologyKey: "kubernetes.io/hostname",
+				TopologyKey: api.DefaultPodAntiAffinityTopologyKey,
 			},
-		},
+		})
+
+	if dc.Spec.MultipleNodesPerWorker.IsolateRacks {
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+			corev1.PodAffinityTerm{
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: dc.GetDatacenterLabels(),
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{
+							Key:      api.RackLabel,
+							Operator: metav1.LabelSelectorOpNotIn,
+							Values:   []string{rackName},
+						},
+					},
+				},
+				TopologyKey: api.DefaultPodAntiAffinityTopologyKey,
+			})
+	}
+
+	return antiAffinity
+}
+
+// guaranteeResources gives the containers of the pods requests equal to their
+// limits, so that the pods are of the Guaranteed QoS class, and the cassandra
+// container whole cores, which the static CPU manager policy of the kubelet
+// pins to it. A container with neither a request nor a limit for its cpu or
+// its memory would leave the pods Burstable, so it fails the pod template.
+func guaranteeResources(baseTemplate *corev1.PodTemplateSpec) error {
+	var unbounded []string
+	guarantee := func(container *corev1.Container) {
+		// The lists can be shared with the spec and the defaults
+		resources := container.Resources.DeepCopy()
+		defer func() { container.Resources = *resources }()
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			quantity := resources.Limits[name]
+			if request, ok := resources.Requests[name]; ok && request.Cmp(quantity) > 0 {
+				quantity = request
+			}
+			if quantity.IsZero() {
+				unbounded = append(unbounded, fmt.Sprintf("the %s of container %s", name, container.Name))
+				continue
+			}
+			if name == corev1.ResourceCPU && container.Name == CassandraContainerName {
+				cores := (quantity.MilliValue() + 999) / 1000
+				quantity = *resource.NewQuantity(cores, resource.DecimalSI)
+			}
+			if resources.Requests == nil {
+				resources.Requests = corev1.ResourceList{}
+			}
+			if resources.Limits == nil {
+				resources.Limits = corev1.ResourceList{}
+			}
+			resources.Requests[name] = quantity.DeepCopy()
+			resources.Limits[name] = quantity.DeepCopy()
+		}
+	}
+
+	for i := range baseTemplate.Spec.InitContainers {
+		guarantee(&baseTemplate.Spec.InitContainers[i])
+	}
+	for i := range baseTemplate.Spec.Containers {
+		guarantee(&baseTemplate.Spec.Containers[i])
+	}
+
+	if len(unbounded) > 0 {
+		return &resourcesNotGuaranteedError{unbounded: unbounded}
 	}
+	return nil
+}
+
+// resourcesNotGuaranteedError lists the resources of the containers of the
+// pods dedicatedCpus cannot guarantee
+type resourcesNotGuaranteedError struct {
+	unbounded []string
+}
+
+func (e *resourcesNotGuaranteedError) Error() string {
+	return fmt.Sprintf("dedicatedCpus needs a request or a limit for %s", strings.Join(e.unbounded, ", "))
+}
+
+// calculateTopologySpreadConstraints returns the topology spread constraints
+// of the spec, whose constraints without a label selector select the server
+// pods of the datacenter
+func calculateTopologySpreadConstraints(dc *api.CassandraDatacenter) []corev1.TopologySpreadConstraint {
+	var constraints []corev1.TopologySpreadConstraint
+	for _, constraint := range dc.Spec.TopologySpreadConstraints {
+		constraint = *constraint.DeepCopy()
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = &metav1.LabelSelector{
+				MatchLabels: dc.GetDatacenterLabels(),
+			}
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints
 }
 
 func selectorFromFieldPath(fieldPath string) *corev1.EnvVarSource {
@@ -115,19 +318,98 @@ func probe(port int, path string, initDelay int, period int) *corev1.Probe {
 	}
 }
 
-func getJvmExtraOpts(dc *api.CassandraDatacenter) string {
-	flags := ""
+// tuneProbe sets the timing of the probe that the config sets
+func tuneProbe(probe *corev1.Probe, config *api.ProbeConfig) {
+	if config == nil {
+		return
+	}
+	if config.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *config.InitialDelaySeconds
+	}
+	if config.PeriodSeconds != nil {
+		probe.PeriodSeconds = *config.PeriodSeconds
+	}
+	if config.TimeoutSeconds != nil {
+		probe.TimeoutSeconds = *config.TimeoutSeconds
+	}
+	if config.FailureThreshold != nil {
+		probe.FailureThreshold = *config.FailureThreshold
+	}
+}
+
+// getJvmExtraOpts returns the JVM options that start the DSE workloads of the
+// datacenter
+func getJvmExtraOpts(dc *api.CassandraDatacenter) (string, error) {
+	var flags []string
+	if workloads := dc.GetDseWorkloads(); workloads != nil {
+		if workloads.AnalyticsEnabled {
+			flags = append(flags, "-Dspark-trackers=true")
+		}
+		if workloads.GraphEnabled {
+			flags = append(flags, "-Dgraph-enabled=true")
+		}
+		if workloads.SearchEnabled {
+			flags = append(flags, "-Dsearch-service=true")
+		}
+	}
+
+	// With LOCAL_JMX=no, cassandra-env.sh turns on the authentication of
+	// remote JMX, with a password file these flags replace. The RMI stubs
+	// point the clients at the IP of the pod rather than its hostname.
+	if dc.IsRemoteJmxEnabled() {
+		flags = append(flags,
+			"-Dcom.sun.management.jmxremote.password.file="+api.JmxCredentialsDir+"/jmxremote.password",
+			"-Dcom.sun.management.jmxremote.access.file="+api.JmxCredentialsDir+"/jmxremote.access",
+			"-Djava.rmi.server.hostname=$(POD_IP)")
+	}
+
+	// cassandra-env.sh sets the JMX port before these options, which take
+	// precedence over it
+	if port := dc.GetJmxPort(); port != api.JmxPort {
+		if dc.IsRemoteJmxEnabled() {
+			flags = append(flags,
+				fmt.Sprintf("-Dcassandra.jmx.remote.port=%d", port),
+				fmt.Sprintf("-Dcom.sun.management.jmxremote.rmi.port=%d", port))
+		} else {
+			flags = append(flags, fmt.Sprintf("-Dcassandra.jmx.local.port=%d", port))
+		}
+	}
 
-	if dc.Spec.DseWorkloads.AnalyticsEnabled == true {
-		flags += "-Dspark-trackers=true "
+	// The heap and the additional options of the JVM of the config rendered
+	// in the operator, which come last to take precedence over the heap
+	// cassandra-env.sh computes
+	if dc.RendersConfigInOperator() {
+		rendered, err := renderServerConfig(dc, "")
+		if err != nil {
+			return "", err
+		}
+		flags = append(flags, rendered.JvmOptions...)
 	}
-	if dc.Spec.DseWorkloads.GraphEnabled == true {
-		flags += "-Dgraph-enabled=true "
+	return strings.Join(flags, " "), nil
+}
+
+// readinessProbeInitialDelay returns how long the readiness probe waits
+// before it starts. DSE starts its workloads, such as loading the Solr cores
+// of DSE Search, before the node serves requests, which takes longer.
+func readinessProbeInitialDelay(dc *api.CassandraDatacenter) int {
+	if dc.HasDseWorkloads() {
+		return 60
 	}
-	if dc.Spec.DseWorkloads.SearchEnabled == true {
-		flags += "-Dsearch-service=true"
+	return 20
+}
+
+// terminationGracePeriodSeconds returns the grace period of the pods, which
+// gives the preStop hook the drain timeout of the spec and the server the
+// time to shut down after it
+func terminationGracePeriodSeconds(dc *api.CassandraDatacenter) int64 {
+	gracePeriodSeconds := int64(DefaultTerminationGracePeriodSeconds)
+	if dc.Spec.Drain != nil && dc.Spec.Drain.TimeoutSeconds != nil {
+		drainGracePeriodSeconds := int64(*dc.Spec.Drain.TimeoutSeconds) + DrainShutdownGracePeriodSeconds
+		if drainGracePeriodSeconds > gracePeriodSeconds {
+			gracePeriodSeconds = drainGracePeriodSeconds
+		}
 	}
-	return flags
+	return gracePeriodSeconds
 }
 
 func combineVolumeMountSlices(defaults []corev1.VolumeMount, overrides []corev1.VolumeMount) []corev1.VolumeMount {
@@ -190,9 +472,106 @@ outerLoop:
 	return out
 }
 
+// mergeContainers merges the containers of spec.sidecars or spec.initContainers
+// into the containers of the pod template. A container with the same name as a
+// container of the template is merged into it, and the others are appended.
+func mergeContainers(containers []corev1.Container, overrides []corev1.Container) []corev1.Container {
+	out := append([]corev1.Container{}, containers...)
+outerLoop:
+	for _, override := range overrides {
+		for i := range out {
+			if out[i].Name == override.Name {
+				mergeContainer(&out[i], *override.DeepCopy())
+				continue outerLoop
+			}
+		}
+		out = append(out, *override.DeepCopy())
+	}
+	return out
+}
+
+// mergeContainer sets the fields the override sets in the container. The env,
+// ports and volume mounts are merged by name, with the ones of the override
+// replacing the ones of the container.
+func mergeContainer(container *corev1.Container, override corev1.Container) {
+	if override.Image != "" {
+		container.Image = override.Image
+	}
+	if override.ImagePullPolicy != "" {
+		container.ImagePullPolicy = override.ImagePullPolicy
+	}
+	if len(override.Command) > 0 {
+		container.Command = override.Command
+	}
+	if len(override.Args) > 0 {
+		container.Args = override.Args
+	}
+	if override.WorkingDir != "" {
+		container.WorkingDir = override.WorkingDir
+	}
+	if !reflect.DeepEqual(override.Resources, corev1.ResourceRequirements{}) {
+		container.Resources = override.Resources
+	}
+	if override.LivenessProbe != nil {
+		container.LivenessProbe = override.LivenessProbe
+	}
+	if override.ReadinessProbe != nil {
+		container.ReadinessProbe = override.ReadinessProbe
+	}
+	if override.StartupProbe != nil {
+		container.StartupProbe = override.StartupProbe
+	}
+	if override.Lifecycle != nil {
+		container.Lifecycle = override.Lifecycle
+	}
+	if override.SecurityContext != nil {
+		container.SecurityContext = override.SecurityContext
+	}
+
+	container.EnvFrom = append(container.EnvFrom, override.EnvFrom...)
+	container.Env = combineEnvSlices(container.Env, override.Env)
+	container.Ports = combinePortSlices(container.Ports, override.Ports)
+	container.VolumeMounts = combineVolumeMountSlices(container.VolumeMounts, override.VolumeMounts)
+}
+
+// storageConfigVolumes returns the commit log volume, the CDC volume, the
+// additional data volumes and the additional volumes of the storage config,
+// which all get a volume claim template
+func storageConfigVolumes(cc *api.CassandraDatacenter) api.AdditionalVolumesSlice {
+	var volumes api.AdditionalVolumesSlice
+	if commitLogClaim := cc.Spec.StorageConfig.CommitLogVolumeClaimSpec; commitLogClaim != nil {
+		volumes = append(volumes, api.AdditionalVolumes{
+			Name:      CommitLogPvcName,
+			MountPath: cc.GetCommitLogDirectory(),
+			PVCSpec:   *commitLogClaim,
+		})
+	}
+	// The volume is kept while CDC is disabled, as the volume claim templates
+	// of a StatefulSet cannot change
+	if cdc := cc.Spec.CDC; cdc != nil && cdc.VolumeClaimSpec != nil {
+		volumes = append(volumes, api.AdditionalVolumes{
+			Name:      CDCPvcName,
+			MountPath: api.CDCDirectory,
+			PVCSpec:   *cdc.VolumeClaimSpec,
+		})
+	}
+	volumes = append(volumes, cc.Spec.StorageConfig.AdditionalDataVolumes...)
+	return append(volumes, cc.Spec.StorageConfig.AdditionalVolumes...)
+}
+
 func generateStorageConfigVolumesMount(cc *api.CassandraDatacenter) []corev1.VolumeMount {
 	var vms []corev1.VolumeMount
-	for _, storage := range cc.Spec.StorageConfig.AdditionalVolumes {
+	for _, storage := range storageConfigVolumes(cc) {
+		vms = append(vms, corev1.VolumeMount{Name: storage.Name, MountPath: storage.MountPath})
+	}
+	return vms
+}
+
+// generateDataVolumesMount mounts the additional data volumes, for the
+// containers that need all the data directories of the node
+func generateDataVolumesMount(cc *api.CassandraDatacenter) []corev1.VolumeMount {
+	var vms []corev1.VolumeMount
+	for _, storage := range cc.Spec.StorageConfig.AdditionalDataVolumes {
 		vms = append(vms, corev1.VolumeMount{Name: storage.Name, MountPath: storage.MountPath})
 	}
 	return vms
@@ -200,12 +579,311 @@ func generateStorageConfigVolumesMount(cc *api.CassandraDatacenter) []corev1.Vol
 
 func generateStorageConfigEmptyVolumes(cc *api.CassandraDatacenter) []corev1.Volume {
 	var volumes []corev1.Volume
-	for _, storage := range cc.Spec.StorageConfig.AdditionalVolumes {
+	for _, storage := range storageConfigVolumes(cc) {
 		volumes = append(volumes, corev1.Volume{Name: storage.Name})
 	}
 	return volumes
 }
 
+// Names of the volumes mounted at the directories of the full query log and
+// the audit log
+const (
+	FullQueryLogsVolumeName = "full-query-logs"
+	AuditLogsVolumeName     = "audit-logs"
+)
+
+type queryLogVolume struct {
+	name   string
+	logDir string
+}
+
+// queryLogVolumes returns the volumes of the configured query logs. They only
+// depend on whether the logs are configured, not on whether they are enabled,
+// so turning the logs on and off does not restart the pods.
+func queryLogVolumes(dc *api.CassandraDatacenter) []queryLogVolume {
+	var volumes []queryLogVolume
+	if logDir := dc.GetFullQueryLogDir(); logDir != "" {
+		volumes = append(volumes, queryLogVolume{name: FullQueryLogsVolumeName, logDir: logDir})
+	}
+	if logDir := dc.GetAuditLogDir(); logDir != "" {
+		volumes = append(volumes, queryLogVolume{name: AuditLogsVolumeName, logDir: logDir})
+	}
+	return volumes
+}
+
+func generateQueryLogVolumes(dc *api.CassandraDatacenter) []corev1.Volume {
+	var volumes []corev1.Volume
+	for _, v := range queryLogVolumes(dc) {
+		volumes = append(volumes, corev1.Volume{
+			Name: v.name,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+	return volumes
+}
+
+func generateQueryLogVolumeMounts(dc *api.CassandraDatacenter) []corev1.VolumeMount {
+	var vms []corev1.VolumeMount
+	for _, v := range queryLogVolumes(dc) {
+		vms = append(vms, corev1.VolumeMount{Name: v.name, MountPath: v.logDir})
+	}
+	return vms
+}
+
+// generateMetricsCollectorVolumes returns the volumes of the version of the
+// MCAC agent and of its configuration, when they are set in the spec
+func generateMetricsCollectorVolumes(dc *api.CassandraDatacenter) []corev1.Volume {
+	config := dc.Spec.MetricsCollector
+	if config == nil || !dc.IsMetricsCollectorEnabled() {
+		return nil
+	}
+
+	var volumes []corev1.Volume
+	if config.Image != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: MetricsCollectorVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+	if config.Config != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: MetricsCollectorConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: dc.GetMetricsCollectorConfigMapName()},
+				},
+			},
+		})
+	}
+	return volumes
+}
+
+func generateMetricsCollectorVolumeMounts(dc *api.CassandraDatacenter) []corev1.VolumeMount {
+	var vms []corev1.VolumeMount
+	for _, volume := range generateMetricsCollectorVolumes(dc) {
+		switch volume.Name {
+		case MetricsCollectorVolumeName:
+			vms = append(vms, corev1.VolumeMount{Name: volume.Name, MountPath: api.MetricsCollectorDir})
+		case MetricsCollectorConfigVolumeName:
+			vms = append(vms, corev1.VolumeMount{
+				Name:      volume.Name,
+				MountPath: api.MetricsCollectorDir + "/config/" + api.MetricsCollectorConfigFile,
+				SubPath:   api.MetricsCollectorConfigFile,
+			})
+		}
+	}
+	return vms
+}
+
+// generateLogbackVolumes returns the volume of the ConfigMap of the logging
+// levels the generated logback.xml includes. It is mounted as a directory,
+// since the kubelet does not update the files of subPath mounts.
+func generateLogbackVolumes(dc *api.CassandraDatacenter) []corev1.Volume {
+	if !dc.UsesGeneratedLogback() {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: LogbackConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: dc.GetLogbackConfigMapName()},
+				},
+			},
+		},
+	}
+}
+
+func generateLogbackVolumeMounts(dc *api.CassandraDatacenter) []corev1.VolumeMount {
+	var vms []corev1.VolumeMount
+	for _, volume := range generateLogbackVolumes(dc) {
+		vms = append(vms, corev1.VolumeMount{Name: volume.Name, MountPath: api.LogbackConfigDir})
+	}
+	return vms
+}
+
+// generateInternodeEncryptionVolumes returns the volumes of the keystores of
+// the internode encryption: the secret with them and, when the operator
+// generates a keystore for each node, the directory the keystore of the node
+// is copied to
+func generateInternodeEncryptionVolumes(dc *api.CassandraDatacenter) []corev1.Volume {
+	config := dc.GetInternodeEncryption()
+	if config == nil {
+		return nil
+	}
+
+	if config.SecretName != "" {
+		return []corev1.Volume{
+			{
+				Name: InternodeKeystoresVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: config.SecretName},
+				},
+			},
+		}
+	}
+	return []corev1.Volume{
+		{
+			Name: InternodeKeystoresVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: dc.GetInternodeKeystoresSecretName()},
+			},
+		},
+		{
+			Name: InternodeEncryptionVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+}
+
+func internodeKeystoresFromSpec(dc *api.CassandraDatacenter) bool {
+	config := dc.GetInternodeEncryption()
+	return config != nil && config.SecretName != ""
+}
+
+// generateInternodeEncryptionVolumeMounts mounts the secret of the keystores
+// of the spec directly, so that the nodes can reload them when they change
+func generateInternodeEncryptionVolumeMounts(dc *api.CassandraDatacenter) []corev1.VolumeMount {
+	config := dc.GetInternodeEncryption()
+	if config == nil {
+		return nil
+	}
+	if config.SecretName != "" {
+		return []corev1.VolumeMount{
+			{Name: InternodeKeystoresVolumeName, MountPath: api.InternodeEncryptionDir, ReadOnly: true},
+		}
+	}
+	return []corev1.VolumeMount{
+		{Name: InternodeEncryptionVolumeName, MountPath: api.InternodeEncryptionDir},
+	}
+}
+
+func generateClientEncryptionVolumes(dc *api.CassandraDatacenter) []corev1.Volume {
+	if dc.GetClientEncryption() == nil {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: ClientKeystoresVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: dc.GetClientKeystoresSecretName()},
+			},
+		},
+	}
+}
+
+func generateClientEncryptionVolumeMounts(dc *api.CassandraDatacenter) []corev1.VolumeMount {
+	if dc.GetClientEncryption() == nil {
+		return nil
+	}
+	return []corev1.VolumeMount{
+		{Name: ClientKeystoresVolumeName, MountPath: api.ClientEncryptionDir, ReadOnly: true},
+	}
+}
+
+// generateVaultSecretsVolumes returns the CSI volume of the
+// SecretProviderClass of Vault, which has the driver sync its secrets
+func generateVaultSecretsVolumes(dc *api.CassandraDatacenter) []corev1.Volume {
+	secretProviderClass := dc.GetVaultSecretProviderClass()
+	if secretProviderClass == "" {
+		return nil
+	}
+	readOnly := true
+	return []corev1.Volume{
+		{
+			Name: VaultSecretsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				CSI: &corev1.CSIVolumeSource{
+					Driver:           "secrets-store.csi.k8s.io",
+					ReadOnly:         &readOnly,
+					VolumeAttributes: map[string]string{"secretProviderClass": secretProviderClass},
+				},
+			},
+		},
+	}
+}
+
+func generateVaultSecretsVolumeMounts(dc *api.CassandraDatacenter) []corev1.VolumeMount {
+	if dc.GetVaultSecretProviderClass() == "" {
+		return nil
+	}
+	return []corev1.VolumeMount{
+		{Name: VaultSecretsVolumeName, MountPath: api.VaultSecretsDir, ReadOnly: true},
+	}
+}
+
+func generateJmxCredentialsVolumes(dc *api.CassandraDatacenter) []corev1.Volume {
+	if !dc.IsRemoteJmxEnabled() {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: JmxCredentialsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+}
+
+func generateJmxCredentialsVolumeMounts(dc *api.CassandraDatacenter) []corev1.VolumeMount {
+	if !dc.IsRemoteJmxEnabled() {
+		return nil
+	}
+	return []corev1.VolumeMount{
+		{Name: JmxCredentialsVolumeName, MountPath: api.JmxCredentialsDir, ReadOnly: true},
+	}
+}
+
+// generatePodAnnotationsVolumes returns the downward API volume of the
+// address of the load balancer of the pod, which the kubelet updates once the
+// operator has recorded it
+func generatePodAnnotationsVolumes(dc *api.CassandraDatacenter) []corev1.Volume {
+	if !dc.IsLoadBalancerPerPodEnabled() {
+		return nil
+	}
+	fieldPath := fmt.Sprintf("metadata.annotations['%s']", api.BroadcastRpcAddressAnnotation)
+	return []corev1.Volume{
+		{
+			Name: PodAnnotationsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				DownwardAPI: &corev1.DownwardAPIVolumeSource{
+					Items: []corev1.DownwardAPIVolumeFile{
+						{Path: "broadcast-rpc-address", FieldRef: &corev1.ObjectFieldSelector{FieldPath: fieldPath}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func generateBackupAgentVolumes(dc *api.CassandraDatacenter) []corev1.Volume {
+	config := dc.Spec.BackupAgent
+	if config == nil {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: BackupAgentConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: dc.GetBackupAgentConfigSecretName()},
+			},
+		},
+		{
+			Name: BackupAgentSecretsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: config.ConfigSecret},
+			},
+		},
+	}
+}
+
 func addVolumes(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTemplateSpec) {
 	vServerConfig := corev1.Volume{
 		Name: "server-config",
@@ -231,6 +909,15 @@ func addVolumes(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTemplateSpe
 	}
 
 	volumeDefaults := []corev1.Volume{vServerConfig, vServerLogs, vServerEncryption}
+	volumeDefaults = append(volumeDefaults, generateQueryLogVolumes(dc)...)
+	volumeDefaults = append(volumeDefaults, generateMetricsCollectorVolumes(dc)...)
+	volumeDefaults = append(volumeDefaults, generateLogbackVolumes(dc)...)
+	volumeDefaults = append(volumeDefaults, generateInternodeEncryptionVolumes(dc)...)
+	volumeDefaults = append(volumeDefaults, generateClientEncryptionVolumes(dc)...)
+	volumeDefaults = append(volumeDefaults, generateVaultSecretsVolumes(dc)...)
+	volumeDefaults = append(volumeDefaults, generateJmxCredentialsVolumes(dc)...)
+	volumeDefaults = append(volumeDefaults, generateBackupAgentVolumes(dc)...)
+	volumeDefaults = append(volumeDefaults, generatePodAnnotationsVolumes(dc)...)
 
 	volumeDefaults = combineVolumeSlices(
 		volumeDefaults, baseTemplate.Spec.Volumes)
@@ -272,6 +959,33 @@ func buildInitContainers(dc *api.CassandraDatacenter, rackName string, baseTempl
 
 	serverCfg.Name = ServerConfigContainerName
 
+	if dc.RendersConfigInOperator() {
+		if err := buildRenderedConfigContainer(dc, rackName, serverCfg, baseTemplate); err != nil {
+			return errors.Wrap(err, "failed to render the config")
+		}
+	} else if err := buildConfigBuilderContainer(dc, rackName, serverCfg); err != nil {
+		return err
+	}
+
+	if !foundOverrides {
+		// Note that append makes a copy, so we must do this after
+		// serverCfg has been properly set up.
+		baseTemplate.Spec.InitContainers = append(baseTemplate.Spec.InitContainers, *serverCfg)
+	}
+
+	buildInternodeKeystoreInitContainer(dc, baseTemplate)
+	buildClientKeystoreInitContainer(dc, baseTemplate)
+	buildJmxCredentialsInitContainer(dc, baseTemplate)
+	buildMetricsCollectorInitContainer(dc, baseTemplate)
+	buildBroadcastAddressInitContainer(dc, baseTemplate)
+	buildLogbackInitContainer(dc, baseTemplate)
+
+	return nil
+}
+
+// buildConfigBuilderContainer sets up the server-config-init container that
+// renders the config with the config builder
+func buildConfigBuilderContainer(dc *api.CassandraDatacenter, rackName string, serverCfg *corev1.Container) error {
 	if serverCfg.Image == "" {
 		if dc.GetConfigBuilderImage() != "" {
 			serverCfg.Image = dc.GetConfigBuilderImage()
@@ -292,11 +1006,11 @@ func buildInitContainers(dc *api.CassandraDatacenter, rackName string, baseTempl
 
 	// Convert the bool to a string for the env var setting
 	useHostIpForBroadcast := "false"
-	if dc.IsNodePortEnabled() {
+	if dc.GetAddressType() == api.AddressTypeHostIP {
 		useHostIpForBroadcast = "true"
 	}
 
-	configEnvVar, err := getConfigDataEnVars(dc)
+	configEnvVar, err := getConfigDataEnVars(dc, rackName)
 	if err != nil {
 		return errors.Wrap(err, "failed to get config env vars")
 	}
@@ -319,20 +1033,77 @@ func buildInitContainers(dc *api.CassandraDatacenter, rackName string, baseTempl
 	}
 
 	serverCfg.Env = combineEnvSlices(envDefaults, serverCfg.Env)
+	return nil
+}
 
-	if !foundOverrides {
-		// Note that append makes a copy, so we must do this after
-		// serverCfg has been properly set up.
-		baseTemplate.Spec.InitContainers = append(baseTemplate.Spec.InitContainers, *serverCfg)
+// buildRenderedConfigContainer sets up the server-config-init container that
+// copies the config the operator renders in the ConfigMap of the rack and of
+// its hash, see CheckRenderedConfig, and adds the addresses of the pod. The
+// hash of the rendered config restarts the pods when it changes.
+func buildRenderedConfigContainer(dc *api.CassandraDatacenter, rackName string, serverCfg *corev1.Container, baseTemplate *corev1.PodTemplateSpec) error {
+	rendered, err := renderServerConfig(dc, rackName)
+	if err != nil {
+		return err
 	}
+	configHash, err := renderedConfigHash(rendered)
+	if err != nil {
+		return err
+	}
+
+	if serverCfg.Image == "" {
+		serverCfg.Image = images.GetImage(images.BusyBox)
+	}
+	serverCfg.Command = []string{"/bin/sh", "-c", renderedConfigScript(dc.GetAddressType() == api.AddressTypeHostIP)}
+
+	serverCfg.VolumeMounts = combineVolumeMountSlices([]corev1.VolumeMount{
+		{Name: "server-config", MountPath: "/config"},
+		{Name: RenderedConfigVolumeName, MountPath: "/rendered-config"},
+	}, serverCfg.VolumeMounts)
+
+	serverCfg.Resources = *getResourcesOrDefault(&dc.Spec.ConfigBuilderResources, &DefaultsConfigInitContainer)
+
+	serverCfg.Env = combineEnvSlices([]corev1.EnvVar{
+		{Name: "POD_IP", ValueFrom: selectorFromFieldPath("status.podIP")},
+		{Name: "HOST_IP", ValueFrom: selectorFromFieldPath("status.hostIP")},
+		{Name: "CONFIG_HASH", Value: configHash},
+	}, serverCfg.Env)
 
+	baseTemplate.Spec.Volumes = append(baseTemplate.Spec.Volumes, corev1.Volume{
+		Name: RenderedConfigVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: dc.GetRenderedConfigMapName(rackName, configHash)},
+			},
+		},
+	})
 	return nil
 }
 
-func getConfigDataEnVars(dc *api.CassandraDatacenter) ([]corev1.EnvVar, error) {
+// renderedConfigScript returns the script that copies the rendered config to
+// /config and adds the addresses of the pod to its cassandra.yaml, which the
+// config builder sets from the same environment variables. The broadcast
+// addresses are the IP of the k8s worker with useHostIP.
+func renderedConfigScript(useHostIP bool) string {
+	broadcastIP := "$POD_IP"
+	if useHostIP {
+		broadcastIP = "$HOST_IP"
+	}
+	addresses := []string{
+		"listen_address: $POD_IP",
+		"rpc_address: 0.0.0.0",
+		"broadcast_rpc_address: " + broadcastIP,
+	}
+	if useHostIP {
+		addresses = append(addresses, "broadcast_address: "+broadcastIP)
+	}
+	return fmt.Sprintf(`cp /rendered-config/* /config/ && printf '%%s\n' "%s" >> /config/cassandra.yaml`,
+		strings.Join(addresses, `" "`))
+}
+
+func getConfigDataEnVars(dc *api.CassandraDatacenter, rackName string) ([]corev1.EnvVar, error) {
 	envVars := make([]corev1.EnvVar, 0)
 
-	if len(dc.Spec.ConfigSecret) > 0 {
+	if dc.UsesConfigSecret() {
 		envVars = append(envVars, corev1.EnvVar{
 			Name: "CONFIG_FILE_DATA",
 			ValueFrom: &corev1.EnvVarSource{
@@ -356,7 +1127,12 @@ func getConfigDataEnVars(dc *api.CassandraDatacenter) ([]corev1.EnvVar, error) {
 		return nil, fmt.Errorf("datacenter %s is missing %s annotation", dc.Name, api.ConfigHashAnnotation)
 	}
 
-	configData, err := dc.GetConfigAsJSON(dc.Spec.Config)
+	config, err := dc.GetRackConfig(rackName)
+	if err != nil {
+		return envVars, err
+	}
+
+	configData, err := dc.GetConfigAsJSON(config)
 
 	if err != nil {
 		return envVars, err
@@ -366,6 +1142,242 @@ func getConfigDataEnVars(dc *api.CassandraDatacenter) ([]corev1.EnvVar, error) {
 	return envVars, nil
 }
 
+// keystorePasswordAwk replaces the placeholder variable in each line with the
+// KEYSTORE_PASSWORD environment variable as a single-quoted YAML string. awk
+// reads the password from its environment, so that none of its characters is
+// interpreted by the shell, a regular expression or YAML. \047 is the single
+// quote, which the shell script quoting the program cannot hold.
+const keystorePasswordAwk = `BEGIN { password = ENVIRON["KEYSTORE_PASSWORD"]; gsub("\047", "\047\047", password); password = "\047" password "\047" } ` +
+	`{ line = $0; gsub("\"" placeholder "\"|\047" placeholder "\047", placeholder, line); out = ""; ` +
+	`while ((i = index(line, placeholder)) > 0) { out = out substr(line, 1, i - 1) password; line = substr(line, i + length(placeholder)) } ` +
+	`print out line }`
+
+// replaceKeystorePasswordScript returns the script that replaces the
+// placeholder in the cassandra.yaml written by the server-config-init
+// container with the password of the KEYSTORE_PASSWORD environment variable
+func replaceKeystorePasswordScript(placeholder string) string {
+	return fmt.Sprintf(`awk -v placeholder=%s '%s' /config/cassandra.yaml > /config/cassandra.yaml.tmp && mv /config/cassandra.yaml.tmp /config/cassandra.yaml`,
+		placeholder, keystorePasswordAwk)
+}
+
+// buildInternodeKeystoreInitContainer adds the init container that puts the
+// password of the keystores of the internode encryption in the configuration
+// written by the server-config-init container, after copying the keystore of
+// the node when the operator generates them
+func buildInternodeKeystoreInitContainer(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTemplateSpec) {
+	config := dc.GetInternodeEncryption()
+	if config == nil {
+		return
+	}
+
+	for _, c := range baseTemplate.Spec.InitContainers {
+		if c.Name == InternodeKeystoreContainerName {
+			return
+		}
+	}
+
+	replacePassword := replaceKeystorePasswordScript(api.InternodeKeystorePasswordPlaceholder)
+	script := fmt.Sprintf(`cp "/keystores/$POD_NAME.jks" %[1]s/keystore.jks && cp /keystores/truststore.jks %[1]s/truststore.jks && %[2]s`,
+		api.InternodeEncryptionDir, replacePassword)
+	password := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: dc.GetInternodeKeystoresSecretName()},
+		Key:                  internodeKeystorePasswordKey,
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{Name: "server-config", MountPath: "/config"},
+		{Name: InternodeKeystoresVolumeName, MountPath: "/keystores", ReadOnly: true},
+		{Name: InternodeEncryptionVolumeName, MountPath: api.InternodeEncryptionDir},
+	}
+	if config.SecretName != "" {
+		// The secret is mounted in the server container as it is
+		script = replacePassword
+		password = config.PasswordSecretRef
+		volumeMounts = volumeMounts[:1]
+	}
+
+	baseTemplate.Spec.InitContainers = append(baseTemplate.Spec.InitContainers, corev1.Container{
+		Name:    InternodeKeystoreContainerName,
+		Image:   images.GetImage(images.BusyBox),
+		Command: []string{"/bin/sh", "-c", script},
+		Env: []corev1.EnvVar{
+			{Name: "POD_NAME", ValueFrom: selectorFromFieldPath("metadata.name")},
+			{Name: "KEYSTORE_PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: password}},
+		},
+		VolumeMounts: volumeMounts,
+		Resources:    *getResourcesOrDefault(&dc.Spec.ConfigBuilderResources, &DefaultsConfigInitContainer),
+	})
+}
+
+// buildClientKeystoreInitContainer adds the init container that puts the
+// password of the keystores of the client encryption in the configuration
+// written by the server-config-init container
+func buildClientKeystoreInitContainer(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTemplateSpec) {
+	if dc.GetClientEncryption() == nil {
+		return
+	}
+
+	for _, c := range baseTemplate.Spec.InitContainers {
+		if c.Name == ClientKeystoreContainerName {
+			return
+		}
+	}
+
+	baseTemplate.Spec.InitContainers = append(baseTemplate.Spec.InitContainers, corev1.Container{
+		Name:    ClientKeystoreContainerName,
+		Image:   images.GetImage(images.BusyBox),
+		Command: []string{"/bin/sh", "-c", replaceKeystorePasswordScript(api.ClientKeystorePasswordPlaceholder)},
+		Env: []corev1.EnvVar{
+			{Name: "KEYSTORE_PASSWORD", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: dc.GetClientKeystoresSecretName()},
+					Key:                  clientKeystorePasswordKey,
+				},
+			}},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "server-config", MountPath: "/config"},
+		},
+		Resources: *getResourcesOrDefault(&dc.Spec.ConfigBuilderResources, &DefaultsConfigInitContainer),
+	})
+}
+
+// buildJmxCredentialsInitContainer adds the init container that writes the
+// password and access files of remote JMX from the credentials secret. The
+// JVM only reads a password file that no one else than the owner can read,
+// which a secret volume with an fsGroup is not.
+func buildJmxCredentialsInitContainer(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTemplateSpec) {
+	if !dc.IsRemoteJmxEnabled() {
+		return
+	}
+
+	for _, c := range baseTemplate.Spec.InitContainers {
+		if c.Name == JmxCredentialsContainerName {
+			return
+		}
+	}
+
+	credential := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: dc.Spec.Jmx.CredentialsSecret},
+				Key:                  key,
+			},
+		}
+	}
+	baseTemplate.Spec.InitContainers = append(baseTemplate.Spec.InitContainers, corev1.Container{
+		Name:  JmxCredentialsContainerName,
+		Image: images.GetImage(images.BusyBox),
+		Command: []string{"/bin/sh", "-c", `echo "$JMX_USERNAME $JMX_PASSWORD" > /jmx-credentials/jmxremote.password && ` +
+			`echo "$JMX_USERNAME readwrite" > /jmx-credentials/jmxremote.access && ` +
+			`chmod 400 /jmx-credentials/jmxremote.password /jmx-credentials/jmxremote.access`},
+		Env: []corev1.EnvVar{
+			{Name: "JMX_USERNAME", ValueFrom: credential("username")},
+			{Name: "JMX_PASSWORD", ValueFrom: credential("password")},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: JmxCredentialsVolumeName, MountPath: "/jmx-credentials"},
+		},
+		Resources: *getResourcesOrDefault(&dc.Spec.ConfigBuilderResources, &DefaultsConfigInitContainer),
+	})
+}
+
+// buildBroadcastAddressInitContainer adds the init container that sets the
+// broadcast addresses of the cassandra.yaml of the config builder: the DNS name
+// of the pod with the ServiceDNS address type, and the address of the load
+// balancer of the pod, which it waits for, as the broadcast_rpc_address
+func buildBroadcastAddressInitContainer(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTemplateSpec) {
+	serviceDNS := dc.GetAddressType() == api.AddressTypeServiceDNS
+	if !serviceDNS && !dc.IsLoadBalancerPerPodEnabled() {
+		return
+	}
+
+	for _, c := range baseTemplate.Spec.InitContainers {
+		if c.Name == BroadcastAddressContainerName {
+			return
+		}
+	}
+
+	var commands []string
+	volumeMounts := []corev1.VolumeMount{
+		{Name: "server-config", MountPath: "/config"},
+	}
+	if serviceDNS {
+		// The pods of the StatefulSets have a DNS name in the all pods service
+		commands = append(commands,
+			`sed -i '/^broadcast_address:/d;/^broadcast_rpc_address:/d' /config/cassandra.yaml`,
+			`echo "broadcast_address: $(hostname -f)" >> /config/cassandra.yaml`,
+			`echo "broadcast_rpc_address: $(hostname -f)" >> /config/cassandra.yaml`)
+	}
+	if dc.IsLoadBalancerPerPodEnabled() {
+		commands = append(commands,
+			`until [ -s /pod-annotations/broadcast-rpc-address ]; do echo "waiting for the load balancer of the pod"; sleep 5; done`,
+			`sed -i '/^broadcast_rpc_address:/d' /config/cassandra.yaml`,
+			`echo "broadcast_rpc_address: $(cat /pod-annotations/broadcast-rpc-address)" >> /config/cassandra.yaml`)
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: PodAnnotationsVolumeName, MountPath: "/pod-annotations"})
+	}
+
+	baseTemplate.Spec.InitContainers = append(baseTemplate.Spec.InitContainers, corev1.Container{
+		Name:         BroadcastAddressContainerName,
+		Image:        images.GetImage(images.BusyBox),
+		Command:      []string{"/bin/sh", "-c", strings.Join(commands, " && ")},
+		VolumeMounts: volumeMounts,
+		Resources:    *getResourcesOrDefault(&dc.Spec.ConfigBuilderResources, &DefaultsConfigInitContainer),
+	})
+}
+
+// buildLogbackInitContainer adds the init container that writes the
+// logback.xml the operator generates for the JSON log format or the logging
+// levels over the one of the server-config-init container
+func buildLogbackInitContainer(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTemplateSpec) {
+	if !dc.UsesGeneratedLogback() {
+		return
+	}
+
+	for _, c := range baseTemplate.Spec.InitContainers {
+		if c.Name == LogbackContainerName {
+			return
+		}
+	}
+
+	baseTemplate.Spec.InitContainers = append(baseTemplate.Spec.InitContainers, corev1.Container{
+		Name:    LogbackContainerName,
+		Image:   images.GetImage(images.BusyBox),
+		Command: []string{"/bin/sh", "-c", `printf '%s' "$LOGBACK_XML" > /config/logback.xml`},
+		Env: []corev1.EnvVar{
+			{Name: "LOGBACK_XML", Value: newLogbackXML(dc)},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "server-config", MountPath: "/config"},
+		},
+		Resources: *getResourcesOrDefault(&dc.Spec.ConfigBuilderResources, &DefaultsConfigInitContainer),
+	})
+}
+
+// buildMetricsCollectorInitContainer adds the init container that installs
+// the version of the MCAC agent of the spec over the one of the server image
+func buildMetricsCollectorInitContainer(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTemplateSpec) {
+	config := dc.Spec.MetricsCollector
+	if config == nil || config.Image == "" || !dc.IsMetricsCollectorEnabled() {
+		return
+	}
+
+	for _, c := range baseTemplate.Spec.InitContainers {
+		if c.Name == MetricsCollectorContainerName {
+			return
+		}
+	}
+
+	baseTemplate.Spec.InitContainers = append(baseTemplate.Spec.InitContainers, corev1.Container{
+		Name:    MetricsCollectorContainerName,
+		Image:   config.Image,
+		Command: []string{"/bin/sh", "-c", fmt.Sprintf("cp -r %s/. /mcac-agent/", api.MetricsCollectorDir)},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: MetricsCollectorVolumeName, MountPath: "/mcac-agent"},
+		},
+		Resources: *getResourcesOrDefault(&dc.Spec.ConfigBuilderResources, &DefaultsConfigInitContainer),
+	})
+}
+
 // makeImage takes the server type/version and image from the spec,
 // and returns a docker pullable server container image
 // serverVersion should be a semver-like string
@@ -387,9 +1399,15 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 
 	cassContainer := &corev1.Container{}
 	loggerContainer := &corev1.Container{}
+	backupContainer := &corev1.Container{}
+	agentContainer := &corev1.Container{}
+	cdcContainer := &corev1.Container{}
 
 	foundCass := false
 	foundLogger := false
+	foundBackup := false
+	foundAgent := false
+	foundCDC := false
 	for i, c := range baseTemplate.Spec.Containers {
 		if c.Name == CassandraContainerName {
 			foundCass = true
@@ -397,6 +1415,15 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 		} else if c.Name == SystemLoggerContainerName {
 			foundLogger = true
 			loggerContainer = &baseTemplate.Spec.Containers[i]
+		} else if c.Name == BackupSidecarContainerName {
+			foundBackup = true
+			backupContainer = &baseTemplate.Spec.Containers[i]
+		} else if c.Name == BackupAgentContainerName {
+			foundAgent = true
+			agentContainer = &baseTemplate.Spec.Containers[i]
+		} else if c.Name == CDCForwarderContainerName {
+			foundCDC = true
+			cdcContainer = &baseTemplate.Spec.Containers[i]
 		}
 	}
 
@@ -416,12 +1443,25 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 		cassContainer.Resources = dc.Spec.Resources
 	}
 
+	probes := dc.Spec.Probes
+	if probes == nil {
+		probes = &api.ProbesConfig{}
+	}
+
 	if cassContainer.LivenessProbe == nil {
 		cassContainer.LivenessProbe = probe(8080, "/api/v0/probes/liveness", 15, 15)
+		tuneProbe(cassContainer.LivenessProbe, probes.Liveness)
 	}
 
 	if cassContainer.ReadinessProbe == nil {
-		cassContainer.ReadinessProbe = probe(8080, "/api/v0/probes/readiness", 20, 10)
+		cassContainer.ReadinessProbe = probe(8080, "/api/v0/probes/readiness", readinessProbeInitialDelay(dc), 10)
+		tuneProbe(cassContainer.ReadinessProbe, probes.Readiness)
+	}
+
+	if cassContainer.StartupProbe == nil && probes.Startup != nil {
+		cassContainer.StartupProbe = probe(8080, "/api/v0/probes/liveness", 0, 10)
+		cassContainer.StartupProbe.FailureThreshold = 60
+		tuneProbe(cassContainer.StartupProbe, probes.Startup)
 	}
 
 	if cassContainer.Lifecycle == nil {
@@ -433,6 +1473,11 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 		if err != nil {
 			return err
 		}
+		if dc.Spec.Drain != nil && dc.Spec.Drain.TimeoutSeconds != nil {
+			// The server is stopped anyway once the drain times out
+			timeout := strconv.Itoa(int(*dc.Spec.Drain.TimeoutSeconds))
+			action.Command = append([]string{"timeout", timeout}, action.Command...)
+		}
 		cassContainer.Lifecycle.PreStop = &corev1.Handler{
 			Exec: action,
 		}
@@ -449,10 +1494,25 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 		{Name: "DSE_MGMT_EXPLICIT_START", Value: "true"},
 	}
 
-	if dc.Spec.ServerType == "dse" && dc.Spec.DseWorkloads != nil {
+	if dc.IsRemoteJmxEnabled() {
+		envDefaults = append(
+			envDefaults,
+			corev1.EnvVar{Name: "POD_IP", ValueFrom: selectorFromFieldPath("status.podIP")},
+			corev1.EnvVar{Name: "LOCAL_JMX", Value: "no"})
+	}
+
+	if dc.HasDseWorkloads() || dc.IsRemoteJmxEnabled() || dc.RendersConfigInOperator() {
+		jvmExtraOpts, err := getJvmExtraOpts(dc)
+		if err != nil {
+			return err
+		}
 		envDefaults = append(
 			envDefaults,
-			corev1.EnvVar{Name: "JVM_EXTRA_OPTS", Value: getJvmExtraOpts(dc)})
+			corev1.EnvVar{Name: "JVM_EXTRA_OPTS", Value: jvmExtraOpts})
+	}
+
+	if !dc.IsMetricsCollectorEnabled() {
+		envDefaults = append(envDefaults, corev1.EnvVar{Name: "MGMT_API_DISABLE_MCAC", Value: "true"})
 	}
 
 	cassContainer.Env = combineEnvSlices(envDefaults, cassContainer.Env)
@@ -493,6 +1553,13 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 			},
 		})
 
+	volumeMounts = combineVolumeMountSlices(volumeMounts, generateQueryLogVolumeMounts(dc))
+	volumeMounts = combineVolumeMountSlices(volumeMounts, generateMetricsCollectorVolumeMounts(dc))
+	volumeMounts = combineVolumeMountSlices(volumeMounts, generateLogbackVolumeMounts(dc))
+	volumeMounts = combineVolumeMountSlices(volumeMounts, generateInternodeEncryptionVolumeMounts(dc))
+	volumeMounts = combineVolumeMountSlices(volumeMounts, generateClientEncryptionVolumeMounts(dc))
+	volumeMounts = combineVolumeMountSlices(volumeMounts, generateVaultSecretsVolumeMounts(dc))
+	volumeMounts = combineVolumeMountSlices(volumeMounts, generateJmxCredentialsVolumeMounts(dc))
 	volumeMounts = combineVolumeMountSlices(volumeMounts, cassContainer.VolumeMounts)
 	cassContainer.VolumeMounts = combineVolumeMountSlices(volumeMounts, generateStorageConfigVolumesMount(dc))
 
@@ -516,6 +1583,25 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 
 	loggerContainer.Resources = *getResourcesOrDefault(&dc.Spec.SystemLoggerResources, &DefaultsLoggerContainer)
 
+	// Backup sidecar container
+
+	if dc.Spec.BackupSidecar != nil {
+		buildBackupSidecarContainer(dc, backupContainer)
+	}
+
+	// Backup agent container
+
+	if dc.Spec.BackupAgent != nil {
+		buildBackupAgentContainer(dc, agentContainer)
+	}
+
+	// CDC forwarder container
+
+	forwardCDC := dc.GetCDCDirectory() != "" && dc.Spec.CDC.Forwarder != nil
+	if forwardCDC {
+		buildCDCForwarderContainer(dc, cdcContainer)
+	}
+
 	// Note that append() can make copies of each element,
 	// so we call it after modifying any existing elements.
 
@@ -529,9 +1615,143 @@ func buildContainers(dc *api.CassandraDatacenter, baseTemplate *corev1.PodTempla
 		}
 	}
 
+	if dc.Spec.BackupSidecar != nil && !foundBackup {
+		baseTemplate.Spec.Containers = append(baseTemplate.Spec.Containers, *backupContainer)
+	}
+
+	if dc.Spec.BackupAgent != nil && !foundAgent {
+		baseTemplate.Spec.Containers = append(baseTemplate.Spec.Containers, *agentContainer)
+	}
+
+	if forwardCDC && !foundCDC {
+		baseTemplate.Spec.Containers = append(baseTemplate.Spec.Containers, *cdcContainer)
+	}
+
 	return nil
 }
 
+// buildCDCForwarderContainer configures the container that forwards the
+// changes in the CDC logs. It mounts the volume the cdc_raw_directory is on,
+// and finds the directory in CDC_RAW_DIRECTORY.
+func buildCDCForwarderContainer(dc *api.CassandraDatacenter, cdcContainer *corev1.Container) {
+	config := dc.Spec.CDC.Forwarder
+
+	cdcContainer.Name = CDCForwarderContainerName
+	if cdcContainer.Image == "" {
+		cdcContainer.Image = config.Image
+	}
+
+	if reflect.DeepEqual(cdcContainer.Resources, corev1.ResourceRequirements{}) {
+		cdcContainer.Resources = config.Resources
+	}
+
+	cdcContainer.Env = combineEnvSlices(
+		[]corev1.EnvVar{{Name: "CDC_RAW_DIRECTORY", Value: dc.GetCDCDirectory()}},
+		cdcContainer.Env)
+
+	if config.ConfigSecretName != "" {
+		cdcContainer.EnvFrom = append(cdcContainer.EnvFrom, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: config.ConfigSecretName},
+			},
+		})
+	}
+
+	mount := corev1.VolumeMount{Name: PvcName, MountPath: "/var/lib/cassandra"}
+	if dc.Spec.CDC.VolumeClaimSpec != nil {
+		mount = corev1.VolumeMount{Name: CDCPvcName, MountPath: api.CDCDirectory}
+	}
+	cdcContainer.VolumeMounts = combineVolumeMountSlices([]corev1.VolumeMount{mount}, cdcContainer.VolumeMounts)
+}
+
+// buildBackupSidecarContainer configures the container that moves snapshots
+// between the data volume and object storage. It shares the data volume with
+// the cassandra container so it can read snapshots and stage restored files.
+func buildBackupSidecarContainer(dc *api.CassandraDatacenter, backupContainer *corev1.Container) {
+	config := dc.Spec.BackupSidecar
+
+	backupContainer.Name = BackupSidecarContainerName
+	if backupContainer.Image == "" {
+		backupContainer.Image = config.Image
+	}
+
+	if reflect.DeepEqual(backupContainer.Resources, corev1.ResourceRequirements{}) {
+		backupContainer.Resources = config.Resources
+	}
+
+	backupContainer.Ports = combinePortSlices(
+		[]corev1.ContainerPort{{Name: "backup", ContainerPort: api.BackupSidecarPort}},
+		backupContainer.Ports)
+
+	if config.SecretName != "" {
+		backupContainer.EnvFrom = append(backupContainer.EnvFrom, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: config.SecretName},
+			},
+		})
+	}
+
+	backupContainer.VolumeMounts = combineVolumeMountSlices(
+		append([]corev1.VolumeMount{
+			{
+				Name:      PvcName,
+				MountPath: "/var/lib/cassandra",
+			},
+		}, generateDataVolumesMount(dc)...),
+		backupContainer.VolumeMounts)
+}
+
+// buildBackupAgentContainer configures the Medusa backup agent, which serves
+// its gRPC API to a backup operator. It reads the cassandra.yaml of the node
+// from the server-config volume and the files of the data volume, and takes
+// the snapshots through the management API.
+func buildBackupAgentContainer(dc *api.CassandraDatacenter, agentContainer *corev1.Container) {
+	config := dc.Spec.BackupAgent
+
+	agentContainer.Name = BackupAgentContainerName
+	if agentContainer.Image == "" {
+		agentContainer.Image = config.Image
+		if agentContainer.Image == "" {
+			agentContainer.Image = images.GetImage(images.Medusa)
+		}
+	}
+	if agentContainer.ImagePullPolicy == "" {
+		agentContainer.ImagePullPolicy = config.ImagePullPolicy
+	}
+
+	if reflect.DeepEqual(agentContainer.Resources, corev1.ResourceRequirements{}) {
+		agentContainer.Resources = config.Resources
+	}
+
+	agentContainer.Ports = combinePortSlices(
+		[]corev1.ContainerPort{{Name: "grpc", ContainerPort: api.BackupAgentGrpcPort}},
+		agentContainer.Ports)
+
+	superuserSecret := dc.GetSuperuserSecretNamespacedName().Name
+	credential := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: superuserSecret},
+				Key:                  key,
+			},
+		}
+	}
+	agentContainer.Env = combineEnvSlices([]corev1.EnvVar{
+		{Name: "MEDUSA_MODE", Value: "GRPC"},
+		{Name: "CQL_USERNAME", ValueFrom: credential("username")},
+		{Name: "CQL_PASSWORD", ValueFrom: credential("password")},
+	}, agentContainer.Env)
+
+	agentContainer.VolumeMounts = combineVolumeMountSlices(
+		append([]corev1.VolumeMount{
+			{Name: "server-config", MountPath: "/etc/cassandra"},
+			{Name: PvcName, MountPath: "/var/lib/cassandra"},
+			{Name: BackupAgentConfigVolumeName, MountPath: api.BackupAgentConfigDir, ReadOnly: true},
+			{Name: BackupAgentSecretsVolumeName, MountPath: api.BackupAgentSecretsDir, ReadOnly: true},
+		}, generateDataVolumesMount(dc)...),
+		agentContainer.VolumeMounts)
+}
+
 func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[string]string,
 	rackName string) (*corev1.PodTemplateSpec, error) {
 
@@ -549,6 +1769,17 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 	}
 	baseTemplate.Spec.ServiceAccountName = serviceAccount
 
+	if baseTemplate.Spec.PriorityClassName == "" {
+		baseTemplate.Spec.PriorityClassName = dc.Spec.PriorityClassName
+	}
+	if baseTemplate.Spec.RuntimeClassName == nil && dc.Spec.RuntimeClassName != "" {
+		runtimeClassName := dc.Spec.RuntimeClassName
+		baseTemplate.Spec.RuntimeClassName = &runtimeClassName
+	}
+	if baseTemplate.Spec.SchedulerName == "" {
+		baseTemplate.Spec.SchedulerName = dc.Spec.SchedulerName
+	}
+
 	// Host networking
 
 	if dc.IsHostNetworkEnabled() {
@@ -557,8 +1788,7 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 	}
 
 	if baseTemplate.Spec.TerminationGracePeriodSeconds == nil {
-		// Note: we cannot take the address of a constant
-		gracePeriodSeconds := int64(DefaultTerminationGracePeriodSeconds)
+		gracePeriodSeconds := terminationGracePeriodSeconds(dc)
 		baseTemplate.Spec.TerminationGracePeriodSeconds = &gracePeriodSeconds
 	}
 
@@ -574,8 +1804,12 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 		}
 	}
 
-	// Adds custom registry pull secret if needed
+	// Adds the pull secrets of the datacenter, and the custom registry pull
+	// secrets if needed
 
+	for _, secret := range dc.Spec.ImagePullSecrets {
+		images.AddImagePullSecret(&baseTemplate.Spec, secret.Name)
+	}
 	_ = images.AddDefaultRegistryImagePullSecrets(&baseTemplate.Spec)
 
 	// Labels
@@ -593,20 +1827,77 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 
 	podAnnotations := map[string]string{}
 
+	// Restarts the pods when the configuration of the MCAC agent changes,
+	// since it is only read when the server starts
+	if config := dc.Spec.MetricsCollector; config != nil && config.Config != "" && dc.IsMetricsCollectorEnabled() {
+		podAnnotations[api.MetricsCollectorConfigHashAnnotation] = fmt.Sprintf("%x", sha256.Sum256([]byte(config.Config)))
+	}
+
+	// Restarts the pods when the certificates of the encryption change, unless
+	// the nodes reload them
+	if !dc.SupportsCertificateReload() {
+		if certificateHash, ok := dc.Annotations[api.ClientCertificateHashAnnotation]; ok && dc.GetClientEncryption() != nil {
+			podAnnotations[api.ClientCertificateHashAnnotation] = certificateHash
+		}
+		if keystoresHash, ok := dc.Annotations[api.InternodeKeystoresHashAnnotation]; ok && internodeKeystoresFromSpec(dc) {
+			podAnnotations[api.InternodeKeystoresHashAnnotation] = keystoresHash
+		}
+	}
+
+	// Restarts the pods when the credentials of remote JMX change, which
+	// the nodes only read when they start
+	if credentialsVersion, ok := dc.Annotations[api.JmxCredentialsVersionAnnotation]; ok && dc.IsRemoteJmxEnabled() {
+		podAnnotations[api.JmxCredentialsVersionAnnotation] = credentialsVersion
+	}
+
+	// Restarts the pods when the configuration of the backup agent changes
+	if configHash, ok := dc.Annotations[api.BackupAgentConfigHashAnnotation]; ok && dc.Spec.BackupAgent != nil {
+		podAnnotations[api.BackupAgentConfigHashAnnotation] = configHash
+	}
+
 	if baseTemplate.Annotations == nil {
 		baseTemplate.Annotations = make(map[string]string)
 	}
 	baseTemplate.Annotations = utils.MergeMap(baseTemplate.Annotations, podAnnotations)
 
+	addAdditionalTemplateMetadata(dc, &baseTemplate.ObjectMeta)
+
 	// Affinity
 
+	rack := dc.GetRack(rackName)
+	if rack == nil {
+		rack = &api.Rack{Name: rackName}
+	}
+
+	podAntiAffinity := dc.Spec.PodAntiAffinity
+	if rack.PodAntiAffinity != nil {
+		podAntiAffinity = rack.PodAntiAffinity
+	}
+
+	var templateAntiAffinity *corev1.PodAntiAffinity
+	if baseTemplate.Spec.Affinity != nil {
+		templateAntiAffinity = baseTemplate.Spec.Affinity.PodAntiAffinity
+	}
+
 	affinity := &corev1.Affinity{}
-	affinity.NodeAffinity = calculateNodeAffinity(nodeAffinityLabels)
-	affinity.PodAntiAffinity = calculatePodAntiAffinity(dc.Spec.AllowMultipleNodesPerWorker)
+	affinity.NodeAffinity = calculateRackNodeAffinity(nodeAffinityLabels, rack.NodeAffinity)
+	affinity.PodAntiAffinity = calculatePodAntiAffinity(dc.Spec.AllowMultipleNodesPerWorker, podAntiAffinity)
+	if dc.Spec.AllowMultipleNodesPerWorker && dc.Spec.MultipleNodesPerWorker != nil {
+		affinity.PodAntiAffinity = calculateSharedWorkerAntiAffinity(dc, rackName, templateAntiAffinity)
+	}
 	baseTemplate.Spec.Affinity = affinity
 
+	if len(baseTemplate.Spec.TopologySpreadConstraints) == 0 {
+		baseTemplate.Spec.TopologySpreadConstraints = calculateTopologySpreadConstraints(dc)
+	}
+
 	// Tolerations
 	baseTemplate.Spec.Tolerations = dc.Spec.Tolerations
+	if len(rack.Tolerations) > 0 {
+		baseTemplate.Spec.Tolerations = append(
+			append([]corev1.Toleration{}, dc.Spec.Tolerations...),
+			rack.Tolerations...)
+	}
 
 	// Volumes
 
@@ -626,5 +1917,18 @@ func buildPodTemplateSpec(dc *api.CassandraDatacenter, nodeAffinityLabels map[st
 		return nil, err
 	}
 
+	// Sidecars and init containers of the datacenter
+
+	baseTemplate.Spec.InitContainers = mergeContainers(baseTemplate.Spec.InitContainers, dc.Spec.InitContainers)
+	baseTemplate.Spec.Containers = mergeContainers(baseTemplate.Spec.Containers, dc.Spec.Sidecars)
+
+	// Dedicated resources of the pods sharing workers
+
+	if config := dc.Spec.MultipleNodesPerWorker; dc.Spec.AllowMultipleNodesPerWorker && config != nil && config.DedicatedCpus {
+		if err := guaranteeResources(baseTemplate); err != nil {
+			return nil, err
+		}
+	}
+
 	return baseTemplate, nil
 }
@@ -13,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/operator/pkg/images"
 	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
 	"github.com/stretchr/testify/assert"
@@ -21,14 +22,14 @@ import (
 
 func Test_calculatePodAntiAffinity(t *testing.T) {
 	t.Run("check when we allow more than one server pod per node", func(t *testing.T) {
-		paa := calculatePodAntiAffinity(true)
+		paa := calculatePodAntiAffinity(true, nil)
 		if paa != nil {
 			t.Errorf("calculatePodAntiAffinity() = %v, and we want nil", paa)
 		}
 	})
 
 	t.Run("check when we do not allow more than one server pod per node", func(t *testing.T) {
-		paa := calculatePodAntiAffinity(false)
+		paa := calculatePodAntiAffinity(false, nil)
 		if paa == nil ||
 			len(paa.RequiredDuringSchedulingIgnoredDuringExecution) != 1 {
 			t.Errorf("calculatePodAntiAffinity() = %v, and we want one element in RequiredDuringSchedulingIgnoredDuringExecution", paa)
@@ -36,6 +37,106 @@ func Test_calculatePodAntiAffinity(t *testing.T) {
 	})
 }
 
+func Test_calculatePodAntiAffinity_config(t *testing.T) {
+	t.Run("check the topology key of the anti-affinity", func(t *testing.T) {
+		paa := calculatePodAntiAffinity(false, &api.PodAntiAffinityConfig{TopologyKey: "topology.kubernetes.io/zone"})
+		assert.Len(t, paa.RequiredDuringSchedulingIgnoredDuringExecution, 1)
+		assert.Equal(t, "topology.kubernetes.io/zone", paa.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey)
+	})
+
+	t.Run("check a preferred anti-affinity", func(t *testing.T) {
+		paa := calculatePodAntiAffinity(false, &api.PodAntiAffinityConfig{Preferred: true})
+		assert.Empty(t, paa.RequiredDuringSchedulingIgnoredDuringExecution)
+		assert.Len(t, paa.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+		assert.Equal(t, api.DefaultPodAntiAffinityTopologyKey,
+			paa.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.TopologyKey)
+	})
+
+	t.Run("check that multiple nodes per worker disable the anti-affinity", func(t *testing.T) {
+		paa := calculatePodAntiAffinity(true, &api.PodAntiAffinityConfig{TopologyKey: "topology.kubernetes.io/zone"})
+		assert.Nil(t, paa)
+	})
+}
+
+func Test_calculateRackNodeAffinity(t *testing.T) {
+	rackAffinity := &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "disk", Operator: corev1.NodeSelectorOpIn, Values: []string{"ssd"}}}},
+				{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "disk", Operator: corev1.NodeSelectorOpIn, Values: []string{"nvme"}}}},
+			},
+		},
+	}
+
+	t.Run("check the rack affinity without labels", func(t *testing.T) {
+		na := calculateRackNodeAffinity(nil, rackAffinity)
+		assert.Equal(t, rackAffinity, na)
+	})
+
+	t.Run("check the labels are added to every term of the rack affinity", func(t *testing.T) {
+		na := calculateRackNodeAffinity(map[string]string{zoneLabel: "zone1"}, rackAffinity)
+		terms := na.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		assert.Len(t, terms, 2)
+		for _, term := range terms {
+			assert.Len(t, term.MatchExpressions, 2)
+			assert.Equal(t, zoneLabel, term.MatchExpressions[0].Key)
+			assert.Equal(t, "disk", term.MatchExpressions[1].Key)
+		}
+		assert.Len(t, rackAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions, 1,
+			"the rack affinity should not be modified")
+	})
+
+	t.Run("check the labels with a preferred rack affinity", func(t *testing.T) {
+		preferred := &corev1.NodeAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
+				{Weight: 1, Preference: rackAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0]},
+			},
+		}
+		na := calculateRackNodeAffinity(map[string]string{zoneLabel: "zone1"}, preferred)
+		assert.Len(t, na.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+		assert.Equal(t, zoneLabel,
+			na.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0].Key)
+	})
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_rackScheduling(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			Tolerations: []corev1.Toleration{
+				{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "cassandra", Effect: corev1.TaintEffectNoSchedule},
+			},
+			PodAntiAffinity: &api.PodAntiAffinityConfig{Preferred: true},
+			Racks: []api.Rack{
+				{
+					Name: "rack1",
+					Tolerations: []corev1.Toleration{
+						{Key: "spot", Operator: corev1.TolerationOpExists},
+					},
+					PodAntiAffinity: &api.PodAntiAffinityConfig{TopologyKey: "topology.kubernetes.io/zone"},
+				},
+				{
+					Name: "rack2",
+				},
+			},
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, nil, "rack1")
+	assert.NoError(t, err)
+	assert.Len(t, spec.Spec.Tolerations, 2)
+	assert.Equal(t, "topology.kubernetes.io/zone",
+		spec.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey)
+	assert.Len(t, dc.Spec.Tolerations, 1, "the tolerations of the datacenter should not be modified")
+
+	spec, err = buildPodTemplateSpec(dc, nil, "rack2")
+	assert.NoError(t, err)
+	assert.Equal(t, dc.Spec.Tolerations, spec.Spec.Tolerations)
+	assert.Len(t, spec.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+}
+
 func Test_calculateNodeAffinity(t *testing.T) {
 	t.Run("check when we dont have a zone we want to use", func(t *testing.T) {
 		na := calculateNodeAffinity(map[string]string{})
@@ -322,7 +423,7 @@ func TestServerConfigInitContainerEnvVars(t *testing.T) {
 			},
 		}
 
-		configEnVars, err := getConfigDataEnVars(dc)
+		configEnVars, err := getConfigDataEnVars(dc, rack)
 		assert.NoError(t, err, "failed to get config env vars")
 
 		for _, v := range configEnVars {
@@ -779,6 +880,31 @@ func TestCassandraDatacenter_buildPodTemplateSpec_labels_merge(t *testing.T) {
 	}
 }
 
+func TestCassandraDatacenter_buildPodTemplateSpec_imagePullSecrets(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			ImagePullSecrets: []corev1.LocalObjectReference{
+				{Name: "regcred"},
+				{Name: "template-regcred"},
+			},
+			PodTemplateSpec: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "template-regcred"}},
+				},
+			},
+		},
+	}
+
+	spec, err := buildPodTemplateSpec(dc, map[string]string{zoneLabel: "testzone"}, "testrack")
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]corev1.LocalObjectReference{{Name: "template-regcred"}, {Name: "regcred"}},
+		spec.Spec.ImagePullSecrets)
+}
+
 func TestCassandraDatacenter_buildPodTemplateSpec_overrideSecurityContext(t *testing.T) {
 	uid := int64(1111)
 	gid := int64(2222)
@@ -926,6 +1052,870 @@ func TestCassandraDatacenter_buildContainers_EnableSystemLoggerSidecar_CustomIma
 	assert.Equal(t, "alpine", podTemplateSpec.Spec.Containers[1].Image)
 }
 
+func TestCassandraDatacenter_buildContainers_BackupSidecar(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			BackupSidecar: &api.BackupSidecarConfig{
+				Image:      "backup-sidecar:latest",
+				SecretName: "backup-credentials",
+			},
+		},
+	}
+
+	podTemplateSpec := &corev1.PodTemplateSpec{}
+
+	err := buildContainers(dc, podTemplateSpec)
+
+	assert.NoError(t, err, "should not have gotten error from calling buildContainers()")
+
+	containers := podTemplateSpec.Spec.Containers
+	assert.Len(t, containers, 3, "should have three containers in the podTemplateSpec")
+	assert.Equal(t, BackupSidecarContainerName, containers[2].Name)
+
+	sidecar := containers[2]
+	assert.Equal(t, "backup-sidecar:latest", sidecar.Image)
+	assert.Equal(t, int32(api.BackupSidecarPort), sidecar.Ports[0].ContainerPort)
+	assert.Equal(t, "backup-credentials", sidecar.EnvFrom[0].SecretRef.Name)
+	assert.Equal(t, PvcName, sidecar.VolumeMounts[0].Name)
+	assert.Equal(t, "/var/lib/cassandra", sidecar.VolumeMounts[0].MountPath)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_BackupAgent(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dc1",
+			Annotations: map[string]string{api.BackupAgentConfigHashAnnotation: "abc"},
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.10",
+			BackupAgent:   &api.BackupAgentConfig{ConfigSecret: "medusa"},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Equal(t, "abc", podTemplateSpec.Annotations[api.BackupAgentConfigHashAnnotation])
+
+	containers := podTemplateSpec.Spec.Containers
+	assert.Len(t, containers, 3, "should have three containers in the podTemplateSpec")
+	agent := containers[2]
+	assert.Equal(t, BackupAgentContainerName, agent.Name)
+	assert.Equal(t, images.GetImage(images.Medusa), agent.Image)
+	assert.Equal(t, int32(api.BackupAgentGrpcPort), agent.Ports[0].ContainerPort)
+
+	env := make(map[string]corev1.EnvVar)
+	for _, envVar := range agent.Env {
+		env[envVar.Name] = envVar
+	}
+	assert.Equal(t, "GRPC", env["MEDUSA_MODE"].Value)
+	assert.Equal(t, "bob-superuser", env["CQL_USERNAME"].ValueFrom.SecretKeyRef.Name)
+
+	mounts := make(map[string]string)
+	for _, mount := range agent.VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+	}
+	assert.Equal(t, "/etc/cassandra", mounts["server-config"])
+	assert.Equal(t, "/var/lib/cassandra", mounts[PvcName])
+	assert.Equal(t, api.BackupAgentConfigDir, mounts[BackupAgentConfigVolumeName])
+	assert.Equal(t, api.BackupAgentSecretsDir, mounts[BackupAgentSecretsVolumeName])
+
+	volumes := make(map[string]string)
+	for _, volume := range podTemplateSpec.Spec.Volumes {
+		if volume.Secret != nil {
+			volumes[volume.Name] = volume.Secret.SecretName
+		}
+	}
+	assert.Equal(t, "bob-dc1-medusa-config", volumes[BackupAgentConfigVolumeName])
+	assert.Equal(t, "medusa", volumes[BackupAgentSecretsVolumeName])
+
+	// An override of the podTemplateSpec keeps its image
+	dc.Spec.PodTemplateSpec = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: BackupAgentContainerName, Image: "medusa:custom"}},
+		},
+	}
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Len(t, podTemplateSpec.Spec.Containers, 3)
+	assert.Equal(t, "medusa:custom", podTemplateSpec.Spec.Containers[0].Image)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_Sidecars(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.10",
+			PodTemplateSpec: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: CassandraContainerName, Image: "cassandra:template"}},
+				},
+			},
+			Sidecars: []corev1.Container{
+				{
+					Name:  "agent",
+					Image: "agent:1.0",
+				},
+				{
+					Name:  CassandraContainerName,
+					Image: "cassandra:sidecar",
+					Env:   []corev1.EnvVar{{Name: "DS_LICENSE", Value: "declined"}, {Name: "EXTRA", Value: "1"}},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "agent-socket", MountPath: "/var/run/agent"},
+					},
+				},
+			},
+			InitContainers: []corev1.Container{
+				{
+					Name:  "agent-init",
+					Image: "agent-init:1.0",
+				},
+				{
+					Name: ServerConfigContainerName,
+					Args: []string{"--verbose"},
+				},
+			},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+
+	// The sidecars go after the containers of the operator
+	containers := podTemplateSpec.Spec.Containers
+	assert.Len(t, containers, 3)
+	assert.Equal(t, CassandraContainerName, containers[0].Name)
+	assert.Equal(t, SystemLoggerContainerName, containers[1].Name)
+	assert.Equal(t, "agent", containers[2].Name)
+	assert.Equal(t, "agent:1.0", containers[2].Image)
+
+	// A sidecar named like a container of the operator is merged into it, and
+	// takes precedence over the podTemplateSpec
+	cassContainer := containers[0]
+	assert.Equal(t, "cassandra:sidecar", cassContainer.Image)
+	assert.Equal(t, probe(8080, "/api/v0/probes/readiness", readinessProbeInitialDelay(dc), 10), cassContainer.ReadinessProbe)
+
+	env := make(map[string]string)
+	for _, envVar := range cassContainer.Env {
+		env[envVar.Name] = envVar.Value
+	}
+	assert.Equal(t, "declined", env["DS_LICENSE"])
+	assert.Equal(t, "1", env["EXTRA"])
+	assert.Contains(t, env, "MGMT_API_EXPLICIT_START")
+
+	mounts := make(map[string]string)
+	for _, mount := range cassContainer.VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+	}
+	assert.Equal(t, "/var/run/agent", mounts["agent-socket"])
+	assert.Equal(t, "/var/lib/cassandra", mounts[PvcName])
+
+	initContainers := podTemplateSpec.Spec.InitContainers
+	assert.Len(t, initContainers, 2)
+	assert.Equal(t, ServerConfigContainerName, initContainers[0].Name)
+	assert.Equal(t, []string{"--verbose"}, initContainers[0].Args)
+	assert.Equal(t, images.GetConfigBuilderImage(), initContainers[0].Image)
+	assert.Equal(t, "agent-init", initContainers[1].Name)
+
+	// The sidecars of the datacenter are not modified
+	assert.Len(t, dc.Spec.Sidecars[1].Env, 2)
+}
+
+func TestCassandraDatacenter_buildContainers_DseWorkloads(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "dse",
+			ServerVersion: "6.8.4",
+			DseWorkloads: &api.DseWorkloads{
+				GraphEnabled:  true,
+				SearchEnabled: true,
+			},
+		},
+	}
+
+	podTemplateSpec := &corev1.PodTemplateSpec{}
+	assert.NoError(t, buildContainers(dc, podTemplateSpec))
+
+	cassContainer := podTemplateSpec.Spec.Containers[0]
+	assert.Contains(t, cassContainer.Env, corev1.EnvVar{Name: "JVM_EXTRA_OPTS", Value: "-Dgraph-enabled=true -Dsearch-service=true"})
+	assert.Equal(t, int32(60), cassContainer.ReadinessProbe.InitialDelaySeconds)
+
+	ports := map[string]int32{}
+	for _, port := range cassContainer.Ports {
+		ports[port.Name] = port.ContainerPort
+	}
+	assert.Equal(t, int32(8182), ports["gremlin"])
+	assert.Equal(t, int32(8983), ports["solr"])
+	assert.NotContains(t, ports, "spark-master")
+
+	// The workloads are ignored unless the server is DSE
+	dc.Spec.ServerType = "cassandra"
+	dc.Spec.ServerVersion = "3.11.7"
+	podTemplateSpec = &corev1.PodTemplateSpec{}
+	assert.NoError(t, buildContainers(dc, podTemplateSpec))
+
+	cassContainer = podTemplateSpec.Spec.Containers[0]
+	for _, env := range cassContainer.Env {
+		assert.NotEqual(t, "JVM_EXTRA_OPTS", env.Name)
+	}
+	assert.Equal(t, int32(20), cassContainer.ReadinessProbe.InitialDelaySeconds)
+	for _, port := range cassContainer.Ports {
+		assert.NotEqual(t, "gremlin", port.Name)
+	}
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_QueryLogVolumes(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:      "bob",
+			ServerType:       "cassandra",
+			ServerVersion:    "4.0.0",
+			FullQueryLogging: &api.QueryLoggingConfig{Enabled: true},
+			AuditLogging:     &api.QueryLoggingConfig{LogDir: "/audit"},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+
+	volumeNames := make(map[string]bool)
+	for _, volume := range podTemplateSpec.Spec.Volumes {
+		volumeNames[volume.Name] = true
+	}
+	assert.True(t, volumeNames[FullQueryLogsVolumeName])
+	assert.True(t, volumeNames[AuditLogsVolumeName])
+
+	mounts := make(map[string]string)
+	for _, mount := range podTemplateSpec.Spec.Containers[0].VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+	}
+	assert.Equal(t, api.DefaultFullQueryLogDir, mounts[FullQueryLogsVolumeName])
+	assert.Equal(t, "/audit", mounts[AuditLogsVolumeName])
+
+	// Toggling the logs must not change the pods
+	dc.Spec.FullQueryLogging.Enabled = false
+	dc.Spec.AuditLogging.Enabled = true
+	toggled, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Equal(t, podTemplateSpec, toggled)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_MetricsCollector(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+			MetricsCollector: &api.MetricsCollectorConfig{
+				Image:  "example.com/mcac-agent:0.2.0",
+				Config: "data_dir: /var/lib/cassandra/mcac\n",
+			},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+
+	initContainers := podTemplateSpec.Spec.InitContainers
+	assert.Len(t, initContainers, 2)
+	assert.Equal(t, MetricsCollectorContainerName, initContainers[1].Name)
+	assert.Equal(t, "example.com/mcac-agent:0.2.0", initContainers[1].Image)
+
+	mounts := make(map[string]string)
+	for _, mount := range podTemplateSpec.Spec.Containers[0].VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+	}
+	assert.Equal(t, api.MetricsCollectorDir, mounts[MetricsCollectorVolumeName])
+	assert.Equal(t, api.MetricsCollectorDir+"/config/metric-collector.yaml", mounts[MetricsCollectorConfigVolumeName])
+	assert.NotEmpty(t, podTemplateSpec.Annotations[api.MetricsCollectorConfigHashAnnotation])
+
+	disabled := false
+	dc.Spec.MetricsCollector.Enabled = &disabled
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+
+	assert.Len(t, podTemplateSpec.Spec.InitContainers, 1)
+	assert.NotContains(t, podTemplateSpec.Annotations, api.MetricsCollectorConfigHashAnnotation)
+	cassContainer := podTemplateSpec.Spec.Containers[0]
+	assert.Contains(t, cassContainer.Env, corev1.EnvVar{Name: "MGMT_API_DISABLE_MCAC", Value: "true"})
+	for _, port := range cassContainer.Ports {
+		assert.NotEqual(t, "prometheus", port.Name)
+	}
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_InternodeEncryption(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+			Encryption: &api.EncryptionConfig{
+				Internode: &api.InternodeEncryptionConfig{Mode: api.InternodeEncryptionRequired},
+			},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+
+	initContainers := podTemplateSpec.Spec.InitContainers
+	assert.Len(t, initContainers, 2)
+	assert.Equal(t, ServerConfigContainerName, initContainers[0].Name)
+	keystoreInit := initContainers[1]
+	assert.Equal(t, InternodeKeystoreContainerName, keystoreInit.Name)
+	assert.Contains(t, keystoreInit.Command[2], `cp "/keystores/$POD_NAME.jks"`)
+	assert.Contains(t, keystoreInit.Command[2], `ENVIRON["KEYSTORE_PASSWORD"]`)
+	assert.NotContains(t, keystoreInit.Command[2], "$KEYSTORE_PASSWORD", "the shell should not expand the password")
+	assert.Equal(t, "KEYSTORE_PASSWORD", keystoreInit.Env[1].Name)
+	assert.Equal(t, "bob-dc1-internode-keystores", keystoreInit.Env[1].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "password", keystoreInit.Env[1].ValueFrom.SecretKeyRef.Key)
+
+	volumes := make(map[string]corev1.Volume)
+	for _, volume := range podTemplateSpec.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	assert.Equal(t, "bob-dc1-internode-keystores", volumes[InternodeKeystoresVolumeName].Secret.SecretName)
+	assert.NotNil(t, volumes[InternodeEncryptionVolumeName].EmptyDir)
+
+	mounts := make(map[string]string)
+	for _, mount := range podTemplateSpec.Spec.Containers[0].VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+	}
+	assert.Equal(t, api.InternodeEncryptionDir, mounts[InternodeEncryptionVolumeName])
+	assert.NotContains(t, mounts, InternodeKeystoresVolumeName)
+
+	passwordRef := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "keystore-password"},
+		Key:                  "password",
+	}
+	dc.Spec.Encryption.Internode.SecretName = "internode-tls"
+	dc.Spec.Encryption.Internode.PasswordSecretRef = passwordRef
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+
+	keystoreInit = podTemplateSpec.Spec.InitContainers[1]
+	assert.NotContains(t, keystoreInit.Command[2], "cp")
+	assert.Equal(t, passwordRef, keystoreInit.Env[1].ValueFrom.SecretKeyRef)
+	volumes = make(map[string]corev1.Volume)
+	for _, volume := range podTemplateSpec.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	assert.Equal(t, "internode-tls", volumes[InternodeKeystoresVolumeName].Secret.SecretName)
+	assert.NotContains(t, volumes, InternodeEncryptionVolumeName)
+	// Mounted as it is, so that the nodes can reload it
+	mounts = make(map[string]string)
+	for _, mount := range podTemplateSpec.Spec.Containers[0].VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+	}
+	assert.Equal(t, api.InternodeEncryptionDir, mounts[InternodeKeystoresVolumeName])
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_ClientEncryption(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dc1",
+			Annotations: map[string]string{api.ClientCertificateHashAnnotation: "abc"},
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+			Encryption: &api.EncryptionConfig{
+				Client: &api.ClientEncryptionConfig{
+					CertManagerIssuerRef: &api.CertManagerIssuerRef{Name: "ca-issuer"},
+				},
+			},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+
+	initContainers := podTemplateSpec.Spec.InitContainers
+	assert.Len(t, initContainers, 2)
+	assert.Equal(t, ClientKeystoreContainerName, initContainers[1].Name)
+	assert.Equal(t, "bob-dc1-client-keystores", initContainers[1].Env[0].ValueFrom.SecretKeyRef.Name)
+	// Cassandra 4 reloads the renewed certificates without a restart
+	assert.NotContains(t, podTemplateSpec.Annotations, api.ClientCertificateHashAnnotation)
+
+	mounts := make(map[string]string)
+	for _, mount := range podTemplateSpec.Spec.Containers[0].VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+	}
+	assert.Equal(t, api.ClientEncryptionDir, mounts[ClientKeystoresVolumeName])
+
+	dc.Spec.ServerVersion = "3.11.10"
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Equal(t, "abc", podTemplateSpec.Annotations[api.ClientCertificateHashAnnotation])
+
+	dc.Spec.Encryption = nil
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Len(t, podTemplateSpec.Spec.InitContainers, 1)
+	assert.NotContains(t, podTemplateSpec.Annotations, api.ClientCertificateHashAnnotation)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_VaultSecretProviderClass(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+			Vault:         &api.VaultConfig{SecretProviderClass: "vault-cassandra"},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+
+	var volume *corev1.Volume
+	for i := range podTemplateSpec.Spec.Volumes {
+		if podTemplateSpec.Spec.Volumes[i].Name == VaultSecretsVolumeName {
+			volume = &podTemplateSpec.Spec.Volumes[i]
+		}
+	}
+	assert.NotNil(t, volume)
+	assert.Equal(t, "secrets-store.csi.k8s.io", volume.CSI.Driver)
+	assert.Equal(t, "vault-cassandra", volume.CSI.VolumeAttributes["secretProviderClass"])
+
+	mounts := make(map[string]string)
+	for _, mount := range podTemplateSpec.Spec.Containers[0].VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+	}
+	assert.Equal(t, api.VaultSecretsDir, mounts[VaultSecretsVolumeName])
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_RemoteJmx(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dc1",
+			Annotations: map[string]string{api.JmxCredentialsVersionAnnotation: "uid/1"},
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.10",
+			Jmx:           &api.JmxConfig{CredentialsSecret: "jmx-credentials"},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+
+	initContainers := podTemplateSpec.Spec.InitContainers
+	assert.Len(t, initContainers, 2)
+	assert.Equal(t, JmxCredentialsContainerName, initContainers[1].Name)
+	assert.Equal(t, "jmx-credentials", initContainers[1].Env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "username", initContainers[1].Env[0].ValueFrom.SecretKeyRef.Key)
+	assert.Equal(t, "uid/1", podTemplateSpec.Annotations[api.JmxCredentialsVersionAnnotation])
+
+	cassContainer := podTemplateSpec.Spec.Containers[0]
+	env := make(map[string]string)
+	for _, envVar := range cassContainer.Env {
+		env[envVar.Name] = envVar.Value
+	}
+	assert.Equal(t, "no", env["LOCAL_JMX"])
+	assert.Contains(t, env["JVM_EXTRA_OPTS"], "-Dcom.sun.management.jmxremote.password.file="+api.JmxCredentialsDir+"/jmxremote.password")
+	assert.Contains(t, env["JVM_EXTRA_OPTS"], "-Djava.rmi.server.hostname=$(POD_IP)")
+
+	mounts := make(map[string]string)
+	for _, mount := range cassContainer.VolumeMounts {
+		mounts[mount.Name] = mount.MountPath
+	}
+	assert.Equal(t, api.JmxCredentialsDir, mounts[JmxCredentialsVolumeName])
+
+	dc.Spec.Jmx = nil
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Len(t, podTemplateSpec.Spec.InitContainers, 1)
+	assert.NotContains(t, podTemplateSpec.Annotations, api.JmxCredentialsVersionAnnotation)
+	for _, envVar := range podTemplateSpec.Spec.Containers[0].Env {
+		assert.NotEqual(t, "LOCAL_JMX", envVar.Name)
+	}
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_Ports(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.10",
+			Networking: &api.NetworkingConfig{
+				HostNetwork: true,
+				Ports:       &api.PortsConfig{Native: 9043, Jmx: 7299},
+			},
+		},
+	}
+
+	jvmExtraOpts := func() string {
+		podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+		assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+		assert.True(t, podTemplateSpec.Spec.HostNetwork)
+		assert.Equal(t, corev1.DNSClusterFirstWithHostNet, podTemplateSpec.Spec.DNSPolicy)
+		for _, envVar := range podTemplateSpec.Spec.Containers[0].Env {
+			if envVar.Name == "JVM_EXTRA_OPTS" {
+				return envVar.Value
+			}
+		}
+		return ""
+	}
+
+	assert.Contains(t, jvmExtraOpts(), "-Dcassandra.jmx.local.port=7299")
+
+	dc.Spec.Jmx = &api.JmxConfig{CredentialsSecret: "jmx-credentials"}
+	opts := jvmExtraOpts()
+	assert.Contains(t, opts, "-Dcassandra.jmx.remote.port=7299")
+	assert.Contains(t, opts, "-Dcom.sun.management.jmxremote.rmi.port=7299")
+	assert.NotContains(t, opts, "-Dcassandra.jmx.local.port")
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_LoadBalancerPerPod(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.10",
+			Networking: &api.NetworkingConfig{
+				NodePort: &api.NodePortConfig{Native: 30042, LoadBalancerPerPod: true},
+			},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+
+	initContainers := podTemplateSpec.Spec.InitContainers
+	assert.Len(t, initContainers, 2)
+	assert.Equal(t, ServerConfigContainerName, initContainers[0].Name)
+	assert.Equal(t, BroadcastAddressContainerName, initContainers[1].Name)
+
+	var annotationsVolume *corev1.Volume
+	for idx := range podTemplateSpec.Spec.Volumes {
+		if podTemplateSpec.Spec.Volumes[idx].Name == PodAnnotationsVolumeName {
+			annotationsVolume = &podTemplateSpec.Spec.Volumes[idx]
+		}
+	}
+	if assert.NotNil(t, annotationsVolume) {
+		assert.Equal(t, "metadata.annotations['"+api.BroadcastRpcAddressAnnotation+"']",
+			annotationsVolume.DownwardAPI.Items[0].FieldRef.FieldPath)
+	}
+
+	dc.Spec.Networking.NodePort.LoadBalancerPerPod = false
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Len(t, podTemplateSpec.Spec.InitContainers, 1)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_AddressType(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.10",
+			Networking: &api.NetworkingConfig{
+				NodePort:    &api.NodePortConfig{Native: 30042},
+				AddressType: api.AddressTypePodIP,
+			},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	initContainers := podTemplateSpec.Spec.InitContainers
+	assert.Len(t, initContainers, 1)
+	assert.Contains(t, initContainers[0].Env, corev1.EnvVar{Name: "USE_HOST_IP_FOR_BROADCAST", Value: "false"})
+
+	dc.Spec.Networking.AddressType = api.AddressTypeServiceDNS
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	initContainers = podTemplateSpec.Spec.InitContainers
+	assert.Len(t, initContainers, 2)
+	assert.Equal(t, BroadcastAddressContainerName, initContainers[1].Name)
+	assert.Contains(t, initContainers[1].Command[2], `echo "broadcast_address: $(hostname -f)"`)
+	assert.NotContains(t, initContainers[1].Command[2], "pod-annotations")
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_DrainTimeout(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.10",
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Equal(t, int64(DefaultTerminationGracePeriodSeconds), *podTemplateSpec.Spec.TerminationGracePeriodSeconds)
+	assert.Equal(t, "wget", podTemplateSpec.Spec.Containers[0].Lifecycle.PreStop.Exec.Command[0])
+
+	// A short timeout keeps the default grace period
+	timeoutSeconds := int32(60)
+	dc.Spec.Drain = &api.DrainConfig{TimeoutSeconds: &timeoutSeconds}
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Equal(t, int64(DefaultTerminationGracePeriodSeconds), *podTemplateSpec.Spec.TerminationGracePeriodSeconds)
+
+	timeoutSeconds = 600
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Equal(t, int64(630), *podTemplateSpec.Spec.TerminationGracePeriodSeconds)
+	command := podTemplateSpec.Spec.Containers[0].Lifecycle.PreStop.Exec.Command
+	assert.Equal(t, []string{"timeout", "600", "wget"}, command[:3])
+	assert.Contains(t, command[len(command)-1], httphelper.WgetNodeDrainEndpoint)
+
+	// The grace period of the podTemplateSpec takes precedence
+	gracePeriodSeconds := int64(300)
+	dc.Spec.PodTemplateSpec = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{TerminationGracePeriodSeconds: &gracePeriodSeconds},
+	}
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Equal(t, int64(300), *podTemplateSpec.Spec.TerminationGracePeriodSeconds)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_Probes(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.10",
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	cassContainer := podTemplateSpec.Spec.Containers[0]
+	assert.Equal(t, int32(15), cassContainer.LivenessProbe.InitialDelaySeconds)
+	assert.Nil(t, cassContainer.StartupProbe)
+
+	periodSeconds := int32(30)
+	failureThreshold := int32(120)
+	dc.Spec.Probes = &api.ProbesConfig{
+		Readiness: &api.ProbeConfig{PeriodSeconds: &periodSeconds},
+		Startup:   &api.ProbeConfig{FailureThreshold: &failureThreshold},
+	}
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	cassContainer = podTemplateSpec.Spec.Containers[0]
+	assert.Equal(t, int32(30), cassContainer.ReadinessProbe.PeriodSeconds)
+	assert.Equal(t, int32(20), cassContainer.ReadinessProbe.InitialDelaySeconds, "the other fields should keep their defaults")
+	assert.Equal(t, int32(15), cassContainer.LivenessProbe.PeriodSeconds)
+	assert.Equal(t, "/api/v0/probes/liveness", cassContainer.StartupProbe.HTTPGet.Path)
+	assert.Equal(t, int32(120), cassContainer.StartupProbe.FailureThreshold)
+	assert.Equal(t, int32(10), cassContainer.StartupProbe.PeriodSeconds)
+
+	// The probes of the podTemplateSpec take precedence
+	startupProbe := &corev1.Probe{Handler: corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"true"}}}}
+	dc.Spec.PodTemplateSpec = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: CassandraContainerName, StartupProbe: startupProbe}},
+		},
+	}
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Equal(t, startupProbe, podTemplateSpec.Spec.Containers[0].StartupProbe)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_SchedulingClasses(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:       "bob",
+			ServerType:        "cassandra",
+			ServerVersion:     "3.11.10",
+			PriorityClassName: "cassandra-critical",
+			RuntimeClassName:  "gvisor",
+			SchedulerName:     "stork",
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Equal(t, "cassandra-critical", podTemplateSpec.Spec.PriorityClassName)
+	assert.Equal(t, "gvisor", *podTemplateSpec.Spec.RuntimeClassName)
+	assert.Equal(t, "stork", podTemplateSpec.Spec.SchedulerName)
+
+	// The podTemplateSpec takes precedence
+	runtimeClassName := "kata"
+	dc.Spec.PodTemplateSpec = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{RuntimeClassName: &runtimeClassName},
+	}
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Equal(t, "kata", *podTemplateSpec.Spec.RuntimeClassName)
+	assert.Equal(t, "stork", podTemplateSpec.Spec.SchedulerName)
+
+	dc.Spec = api.CassandraDatacenterSpec{ClusterName: "bob", ServerType: "cassandra", ServerVersion: "3.11.10"}
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Nil(t, podTemplateSpec.Spec.RuntimeClassName)
+	assert.Empty(t, podTemplateSpec.Spec.PriorityClassName)
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_TopologySpreadConstraints(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.10",
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule},
+				{
+					MaxSkew:           2,
+					TopologyKey:       "kubernetes.io/hostname",
+					WhenUnsatisfiable: corev1.ScheduleAnyway,
+					LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cassandra"}},
+				},
+			},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	constraints := podTemplateSpec.Spec.TopologySpreadConstraints
+	assert.Len(t, constraints, 2)
+	assert.Equal(t, dc.GetDatacenterLabels(), constraints[0].LabelSelector.MatchLabels)
+	assert.Equal(t, map[string]string{"app": "cassandra"}, constraints[1].LabelSelector.MatchLabels)
+	assert.Nil(t, dc.Spec.TopologySpreadConstraints[0].LabelSelector, "the spec should not be modified")
+	assert.NotNil(t, podTemplateSpec.Spec.Affinity.PodAntiAffinity, "the anti-affinity should still apply")
+}
+
+func TestCassandraDatacenter_buildPodTemplateSpec_MultipleNodesPerWorker(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:                 "bob",
+			ServerType:                  "cassandra",
+			ServerVersion:               "3.11.10",
+			AllowMultipleNodesPerWorker: true,
+			MultipleNodesPerWorker: &api.MultipleNodesPerWorkerConfig{
+				DedicatedCpus: true,
+				IsolateRacks:  true,
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1500m"),
+					corev1.ResourceMemory: resource.MustParse("2Gi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1500m"),
+					corev1.ResourceMemory: resource.MustParse("4Gi"),
+				},
+			},
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+
+	antiAffinity := podTemplateSpec.Spec.Affinity.PodAntiAffinity
+	assert.Len(t, antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+	preferred := antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0]
+	assert.Equal(t, dc.GetClusterLabels(), preferred.PodAffinityTerm.LabelSelector.MatchLabels,
+		"the pods of the other clusters should not be spread from")
+	assert.Len(t, antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 1)
+	required := antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0]
+	assert.Equal(t, api.DefaultPodAntiAffinityTopologyKey, required.TopologyKey)
+	assert.Equal(t, dc.GetDatacenterLabels(), required.LabelSelector.MatchLabels)
+	assert.Equal(t, []metav1.LabelSelectorRequirement{
+		{Key: api.RackLabel, Operator: metav1.LabelSelectorOpNotIn, Values: []string{"testrack"}},
+	}, required.LabelSelector.MatchExpressions)
+
+	for _, container := range append(podTemplateSpec.Spec.InitContainers, podTemplateSpec.Spec.Containers...) {
+		assert.Equal(t, container.Resources.Limits, container.Resources.Requests, "container %s", container.Name)
+		assert.False(t, container.Resources.Limits.Cpu().IsZero(), "container %s", container.Name)
+		assert.False(t, container.Resources.Limits.Memory().IsZero(), "container %s", container.Name)
+		if container.Name == CassandraContainerName {
+			cpu := container.Resources.Limits[corev1.ResourceCPU]
+			memory := container.Resources.Limits[corev1.ResourceMemory]
+			assert.Equal(t, int64(2), cpu.Value(), "the cpu is rounded up to whole cores")
+			assert.Equal(t, "4Gi", memory.String())
+		}
+	}
+	assert.Equal(t, "1500m", dc.Spec.Resources.Limits.Cpu().String(), "the spec should not be modified")
+
+	// A container the pods could not be guaranteed the resources of fails
+	// the pod template
+	dc.Spec.Sidecars = []corev1.Container{{
+		Name:  "sidecar",
+		Image: "sidecar:1.0",
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+		},
+	}}
+	_, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.EqualError(t, err, "dedicatedCpus needs a request or a limit for the cpu of container sidecar")
+	dc.Spec.Sidecars = nil
+
+	// Without isolateRacks, the pods are only spread over the workers
+	dc.Spec.MultipleNodesPerWorker.IsolateRacks = false
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err)
+	antiAffinity = podTemplateSpec.Spec.Affinity.PodAntiAffinity
+	assert.Len(t, antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+	assert.Empty(t, antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+
+	// The podAntiAffinity of the podTemplateSpec is kept
+	userTerm := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "kafka"}},
+		TopologyKey:   api.DefaultPodAntiAffinityTopologyKey,
+	}
+	dc.Spec.MultipleNodesPerWorker.IsolateRacks = true
+	dc.Spec.PodTemplateSpec = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Affinity: &corev1.Affinity{
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{userTerm},
+				},
+			},
+		},
+	}
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err)
+	antiAffinity = podTemplateSpec.Spec.Affinity.PodAntiAffinity
+	assert.Len(t, antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+	assert.Len(t, antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 2)
+	assert.Equal(t, userTerm, antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0])
+	assert.Len(t, dc.Spec.PodTemplateSpec.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 1,
+		"the spec should not be modified")
+}
+
 func Test_makeImage(t *testing.T) {
 	type args struct {
 		serverType    string
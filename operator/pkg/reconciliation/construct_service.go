@@ -22,7 +22,7 @@ func newServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Servi
 	service := makeGenericHeadlessService(dc)
 	service.ObjectMeta.Name = svcName
 
-	nativePort := api.DefaultNativePort
+	nativePort := dc.GetNativePort()
 	if dc.IsNodePortEnabled() {
 		nativePort = dc.GetNodePortNativePort()
 	}
@@ -31,12 +31,16 @@ func newServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Servi
 		namedServicePort("native", nativePort, nativePort),
 		namedServicePort("tls-native", 9142, 9142),
 		namedServicePort("mgmt-api", 8080, 8080),
-		namedServicePort("prometheus", 9103, 9103),
-		namedServicePort("thrift", 9160, 9160),
 	}
 
-	if dc.Spec.DseWorkloads != nil {
-		if dc.Spec.DseWorkloads.AnalyticsEnabled {
+	if dc.IsMetricsCollectorEnabled() {
+		ports = append(ports, namedServicePort("prometheus", api.MetricsCollectorPort, api.MetricsCollectorPort))
+	}
+
+	ports = append(ports, namedServicePort("thrift", 9160, 9160))
+
+	if workloads := dc.GetDseWorkloads(); workloads != nil {
+		if workloads.AnalyticsEnabled {
 			ports = append(
 				ports,
 				namedServicePort("dsefs-public", 5598, 5598),
@@ -48,14 +52,14 @@ func newServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Servi
 			)
 		}
 
-		if dc.Spec.DseWorkloads.GraphEnabled {
+		if workloads.GraphEnabled {
 			ports = append(
 				ports,
 				namedServicePort("gremlin", 8182, 8182),
 			)
 		}
 
-		if dc.Spec.DseWorkloads.SearchEnabled {
+		if workloads.SearchEnabled {
 			ports = append(
 				ports,
 				namedServicePort("solr", 8983, 8983),
@@ -65,6 +69,7 @@ func newServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Servi
 
 	service.Spec.Ports = ports
 
+	addAdditionalMetadata(dc, service)
 	addAdditionalOptions(service, &dc.Spec.AdditionalServiceConfig.DatacenterService)
 
 	utils.AddHashAnnotation(service)
@@ -90,6 +95,26 @@ func addAdditionalOptions(service *corev1.Service, serviceConfig *api.ServiceCon
 			service.Annotations[k] = v
 		}
 	}
+
+	if serviceConfig.Type != "" {
+		service.Spec.Type = serviceConfig.Type
+		// Only a ClusterIP service can be headless
+		if serviceConfig.Type != corev1.ServiceTypeClusterIP && service.Spec.ClusterIP == corev1.ClusterIPNone {
+			service.Spec.ClusterIP = ""
+		}
+	}
+
+	if serviceConfig.PublishNotReadyAddresses != nil {
+		service.Spec.PublishNotReadyAddresses = *serviceConfig.PublishNotReadyAddresses
+	}
+
+	if len(serviceConfig.LoadBalancerSourceRanges) > 0 {
+		service.Spec.LoadBalancerSourceRanges = append([]string{}, serviceConfig.LoadBalancerSourceRanges...)
+	}
+
+	if serviceConfig.ExternalTrafficPolicy != "" {
+		service.Spec.ExternalTrafficPolicy = serviceConfig.ExternalTrafficPolicy
+	}
 }
 
 func namedServicePort(name string, port int, targetPort int) corev1.ServicePort {
@@ -118,6 +143,7 @@ func newSeedServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.S
 	service.Spec.Selector = buildLabelSelectorForSeedService(dc)
 	service.Spec.PublishNotReadyAddresses = true
 
+	addAdditionalMetadata(dc, service)
 	addAdditionalOptions(service, &dc.Spec.AdditionalServiceConfig.SeedService)
 
 	utils.AddHashAnnotation(service)
@@ -139,6 +165,7 @@ func newAdditionalSeedServiceForCassandraDatacenter(dc *api.CassandraDatacenter)
 	service.Spec.ClusterIP = "None"
 	service.Spec.PublishNotReadyAddresses = true
 
+	addAdditionalMetadata(dc, &service)
 	addAdditionalOptions(&service, &dc.Spec.AdditionalServiceConfig.AdditionalSeedService)
 
 	utils.AddHashAnnotation(&service)
@@ -146,7 +173,11 @@ func newAdditionalSeedServiceForCassandraDatacenter(dc *api.CassandraDatacenter)
 	return &service
 }
 
-func newEndpointsForAdditionalSeeds(dc *api.CassandraDatacenter) (*corev1.Endpoints, error) {
+// newEndpointsForAdditionalSeeds creates the endpoints of the additional seed
+// service from the additional seeds of the spec and the other seeds, such as
+// the cluster seeds and the addresses of the seeds of the additional seed
+// datacenters. Host names are resolved to their addresses.
+func newEndpointsForAdditionalSeeds(dc *api.CassandraDatacenter, seedAddresses []string) (*corev1.Endpoints, error) {
 	labels := dc.GetDatacenterLabels()
 	oplabels.AddManagedByLabel(labels)
 	endpoints := corev1.Endpoints{}
@@ -154,18 +185,19 @@ func newEndpointsForAdditionalSeeds(dc *api.CassandraDatacenter) (*corev1.Endpoi
 	endpoints.ObjectMeta.Namespace = dc.Namespace
 	endpoints.ObjectMeta.Labels = labels
 
-	addresses := make([]corev1.EndpointAddress, 0, len(dc.Spec.AdditionalSeeds))
-	for _, additionalSeed := range dc.Spec.AdditionalSeeds {
-		if ip := net.ParseIP(additionalSeed); ip != nil {
+	seeds := append(append([]string{}, dc.Spec.AdditionalSeeds...), seedAddresses...)
+	addresses := make([]corev1.EndpointAddress, 0, len(seeds))
+	for _, seed := range seeds {
+		if ip := net.ParseIP(seed); ip != nil {
 			addresses = append(addresses, corev1.EndpointAddress{
-				IP: additionalSeed,
+				IP: seed,
 			})
 		} else {
-			additionalSeedIPs, err := resolveAddress(additionalSeed)
+			seedIPs, err := resolveAddress(seed)
 			if err != nil {
 				return nil, err
 			}
-			for _, address := range additionalSeedIPs {
+			for _, address := range seedIPs {
 				addresses = append(addresses, corev1.EndpointAddress{
 					IP: address,
 				})
@@ -174,12 +206,18 @@ func newEndpointsForAdditionalSeeds(dc *api.CassandraDatacenter) (*corev1.Endpoi
 	}
 
 	// See: https://godoc.org/k8s.io/api/core/v1#Endpoints
-	endpoints.Subsets = []corev1.EndpointSubset{
-		{
-			Addresses: addresses,
-		},
+	// A subset needs at least one address, which the seed datacenters may not
+	// have yet
+	if len(addresses) > 0 {
+		endpoints.Subsets = []corev1.EndpointSubset{
+			{
+				Addresses: addresses,
+			},
+		}
 	}
 
+	addAdditionalMetadata(dc, &endpoints)
+
 	utils.AddHashAnnotation(&endpoints)
 
 	return &endpoints, nil
@@ -207,7 +245,7 @@ func newNodePortServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *core
 	service := makeGenericHeadlessService(dc)
 	service.ObjectMeta.Name = dc.GetNodePortServiceName()
 
-	service.Spec.Type = "NodePort"
+	service.Spec.Type = dc.GetNodePortServiceType()
 	// Note: ClusterIp = "None" is not valid for NodePort
 	service.Spec.ClusterIP = ""
 	service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeLocal
@@ -231,10 +269,36 @@ func newNodePortServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *core
 		},
 	}
 
+	addAdditionalMetadata(dc, service)
 	addAdditionalOptions(service, &dc.Spec.AdditionalServiceConfig.NodePortService)
 	return service
 }
 
+// newLoadBalancerServiceForPod creates a LoadBalancer service owned by the
+// CassandraDatacenter for a single server pod, whose address the node
+// broadcasts to the clients
+func newLoadBalancerServiceForPod(dc *api.CassandraDatacenter, podName string) *corev1.Service {
+	service := makeGenericHeadlessService(dc)
+	service.ObjectMeta.Name = dc.GetPodLoadBalancerServiceName(podName)
+	service.ObjectMeta.Labels[api.LoadBalancerPodLabel] = podName
+	service.Spec.Selector["statefulset.kubernetes.io/pod-name"] = podName
+
+	service.Spec.Type = corev1.ServiceTypeLoadBalancer
+	service.Spec.ClusterIP = ""
+	service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeLocal
+
+	nativePort := dc.GetNodePortNativePort()
+	service.Spec.Ports = []corev1.ServicePort{
+		{
+			Name: "native", Port: int32(nativePort), TargetPort: intstr.FromInt(nativePort),
+		},
+	}
+
+	addAdditionalMetadata(dc, service)
+
+	return service
+}
+
 // newAllPodsServiceForCassandraDatacenter creates a headless service owned by the CassandraDatacenter,
 // which covers all server pods in the datacenter, whether they are ready or not
 func newAllPodsServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Service {
@@ -243,7 +307,7 @@ func newAllPodsServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev
 	service.ObjectMeta.Labels[api.PromMetricsLabel] = "true"
 	service.Spec.PublishNotReadyAddresses = true
 
-	nativePort := api.DefaultNativePort
+	nativePort := dc.GetNativePort()
 	if dc.IsNodePortEnabled() {
 		nativePort = dc.GetNodePortNativePort()
 	}
@@ -255,11 +319,21 @@ func newAllPodsServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev
 		{
 			Name: "mgmt-api", Port: 8080, TargetPort: intstr.FromInt(8080),
 		},
-		{
-			Name: "prometheus", Port: 9103, TargetPort: intstr.FromInt(9103),
-		},
 	}
 
+	if dc.IsMetricsCollectorEnabled() {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name: "prometheus", Port: api.MetricsCollectorPort, TargetPort: intstr.FromInt(api.MetricsCollectorPort),
+		})
+	}
+
+	if dc.IsRemoteJmxEnabled() {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name: "jmx", Port: int32(dc.GetJmxPort()), TargetPort: intstr.FromInt(dc.GetJmxPort()),
+		})
+	}
+
+	addAdditionalMetadata(dc, service)
 	addAdditionalOptions(service, &dc.Spec.AdditionalServiceConfig.AllPodsService)
 
 	utils.AddHashAnnotation(service)
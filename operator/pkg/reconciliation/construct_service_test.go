@@ -5,6 +5,7 @@ package reconciliation
 
 import (
 	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"reflect"
 	"testing"
@@ -53,3 +54,172 @@ func TestCassandraDatacenter_allPodsServiceLabels(t *testing.T) {
 		t.Errorf("allPodsService labels = %v, want %v", gotLabels, wantLabels)
 	}
 }
+
+func TestCassandraDatacenter_newServiceForCassandraDatacenter_DseWorkloads(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "bob",
+			ServerType:  "dse",
+			DseWorkloads: &api.DseWorkloads{
+				AnalyticsEnabled: true,
+			},
+		},
+	}
+
+	hasPort := func(service *corev1.Service, name string) bool {
+		for _, port := range service.Spec.Ports {
+			if port.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !hasPort(newServiceForCassandraDatacenter(dc), "spark-history") {
+		t.Errorf("the service of a DSE datacenter with analytics should expose the spark ports")
+	}
+
+	dc.Spec.ServerType = "cassandra"
+	if hasPort(newServiceForCassandraDatacenter(dc), "spark-history") {
+		t.Errorf("the service of a Cassandra datacenter should not expose the spark ports")
+	}
+}
+
+func TestCassandraDatacenter_allPodsServicePorts_RemoteJmx(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "bob",
+		},
+	}
+
+	hasJmxPort := func(service *corev1.Service) bool {
+		for _, port := range service.Spec.Ports {
+			if port.Name == "jmx" && port.Port == api.JmxPort {
+				return true
+			}
+		}
+		return false
+	}
+
+	if hasJmxPort(newAllPodsServiceForCassandraDatacenter(dc)) {
+		t.Errorf("the all pods service should not expose the jmx port without remote JMX")
+	}
+
+	dc.Spec.Jmx = &api.JmxConfig{CredentialsSecret: "jmx-credentials"}
+	if !hasJmxPort(newAllPodsServiceForCassandraDatacenter(dc)) {
+		t.Errorf("the all pods service should expose the jmx port with remote JMX")
+	}
+}
+
+func TestCassandraDatacenter_servicePorts_Ports(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "bob",
+			Jmx:         &api.JmxConfig{CredentialsSecret: "jmx-credentials"},
+			Networking: &api.NetworkingConfig{
+				HostNetwork: true,
+				Ports:       &api.PortsConfig{Native: 9043, Jmx: 7299},
+			},
+		},
+	}
+
+	ports := func(service *corev1.Service) map[string]int32 {
+		portsByName := map[string]int32{}
+		for _, port := range service.Spec.Ports {
+			portsByName[port.Name] = port.Port
+		}
+		return portsByName
+	}
+
+	if port := ports(newServiceForCassandraDatacenter(dc))["native"]; port != 9043 {
+		t.Errorf("the service should expose the native port of spec.networking.ports, got %d", port)
+	}
+	allPodsPorts := ports(newAllPodsServiceForCassandraDatacenter(dc))
+	if allPodsPorts["native"] != 9043 || allPodsPorts["jmx"] != 7299 {
+		t.Errorf("the all pods service should expose the ports of spec.networking.ports, got %v", allPodsPorts)
+	}
+}
+
+func TestCassandraDatacenter_newLoadBalancerServiceForPod(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "bob",
+			Networking: &api.NetworkingConfig{
+				NodePort: &api.NodePortConfig{Native: 30042, ServiceType: corev1.ServiceTypeLoadBalancer, LoadBalancerPerPod: true},
+			},
+		},
+	}
+
+	if serviceType := newNodePortServiceForCassandraDatacenter(dc).Spec.Type; serviceType != corev1.ServiceTypeLoadBalancer {
+		t.Errorf("the node port service should be a %s service, got %s", corev1.ServiceTypeLoadBalancer, serviceType)
+	}
+
+	service := newLoadBalancerServiceForPod(dc, "bob-dc1-default-sts-0")
+	if service.Name != "bob-dc1-default-sts-0-lb-service" {
+		t.Errorf("unexpected name of the load balancer service of the pod: %s", service.Name)
+	}
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer || service.Spec.ClusterIP != "" {
+		t.Errorf("the service of the pod should be a load balancer, got %s", service.Spec.Type)
+	}
+	if service.Spec.Selector["statefulset.kubernetes.io/pod-name"] != "bob-dc1-default-sts-0" ||
+		service.Labels[api.LoadBalancerPodLabel] != "bob-dc1-default-sts-0" {
+		t.Errorf("the service should select its pod, got %v", service.Spec.Selector)
+	}
+	if len(service.Spec.Ports) != 1 || service.Spec.Ports[0].Port != 30042 {
+		t.Errorf("the service should expose the native port, got %v", service.Spec.Ports)
+	}
+}
+
+func TestCassandraDatacenter_newServiceForCassandraDatacenter_AdditionalServiceConfig(t *testing.T) {
+	publishNotReadyAddresses := false
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "dc1",
+		},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "bob",
+			AdditionalServiceConfig: api.ServiceConfig{
+				DatacenterService: api.ServiceConfigAdditions{
+					Annotations:              map[string]string{"networking.gke.io/load-balancer-type": "Internal"},
+					Type:                     corev1.ServiceTypeLoadBalancer,
+					LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+					ExternalTrafficPolicy:    corev1.ServiceExternalTrafficPolicyTypeLocal,
+				},
+				AllPodsService: api.ServiceConfigAdditions{
+					PublishNotReadyAddresses: &publishNotReadyAddresses,
+				},
+			},
+		},
+	}
+
+	service := newServiceForCassandraDatacenter(dc)
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer || service.Spec.ClusterIP != "" {
+		t.Errorf("the dc service should be a load balancer, got %s with cluster IP '%s'", service.Spec.Type, service.Spec.ClusterIP)
+	}
+	if service.Annotations["networking.gke.io/load-balancer-type"] != "Internal" {
+		t.Errorf("the dc service should have the additional annotations, got %v", service.Annotations)
+	}
+	if !reflect.DeepEqual(service.Spec.LoadBalancerSourceRanges, []string{"10.0.0.0/8"}) ||
+		service.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyTypeLocal {
+		t.Errorf("the dc service should have the additional spec, got %v", service.Spec)
+	}
+
+	if newAllPodsServiceForCassandraDatacenter(dc).Spec.PublishNotReadyAddresses {
+		t.Errorf("the all pods service should not publish the addresses of the pods that are not ready")
+	}
+	if seedService := newSeedServiceForCassandraDatacenter(dc); seedService.Spec.ClusterIP != corev1.ClusterIPNone || !seedService.Spec.PublishNotReadyAddresses {
+		t.Errorf("the seed service should stay headless, got %v", seedService.Spec)
+	}
+}
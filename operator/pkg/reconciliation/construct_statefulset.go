@@ -23,19 +23,6 @@ import (
 
 const zoneLabel = "failure-domain.beta.kubernetes.io/zone"
 
-func usesDefunctPvcManagedByLabel(sts *appsv1.StatefulSet) bool {
-	usesDefunct := false
-	for _, pvc := range sts.Spec.VolumeClaimTemplates {
-		value, ok := pvc.Labels[oplabels.ManagedByLabel]
-		if ok && value == oplabels.ManagedByLabelDefunctValue {
-			usesDefunct = true
-			break
-		}
-	}
-
-	return usesDefunct
-}
-
 func newNamespacedNameForStatefulSet(
 	dc *api.CassandraDatacenter,
 	rackName string) types.NamespacedName {
@@ -49,25 +36,6 @@ func newNamespacedNameForStatefulSet(
 	}
 }
 
-// We have to account for the fact that they might use the old managed-by label value
-// (oplabels.ManagedByLabelDefunctValue) for CassandraDatacenters originally
-// created in version 1.1.0 or earlier.
-func newStatefulSetForCassandraDatacenterWithDefunctPvcManagedBy(
-	rackName string,
-	dc *api.CassandraDatacenter,
-	replicaCount int) (*appsv1.StatefulSet, error) {
-
-	return newStatefulSetForCassandraDatacenterHelper(rackName, dc, replicaCount, true)
-}
-
-func newStatefulSetForCassandraDatacenter(
-	rackName string,
-	dc *api.CassandraDatacenter,
-	replicaCount int) (*appsv1.StatefulSet, error) {
-
-	return newStatefulSetForCassandraDatacenterHelper(rackName, dc, replicaCount, false)
-}
-
 // Check if we need to define a SecurityContext.
 // If the user defines the DockerImageRunsAsCassandra field, we trust that.
 // Otherwise if ServerType is "dse", the answer is true.
@@ -107,22 +75,17 @@ func rackNodeAffinitylabels(dc *api.CassandraDatacenter, rackName string) (map[s
 }
 
 // Create a statefulset object for the Datacenter.
-func newStatefulSetForCassandraDatacenterHelper(
+func newStatefulSetForCassandraDatacenter(
 	rackName string,
 	dc *api.CassandraDatacenter,
-	replicaCount int,
-	useDefunctManagedByForPvc bool) (*appsv1.StatefulSet, error) {
+	replicaCount int) (*appsv1.StatefulSet, error) {
 
 	replicaCountInt32 := int32(replicaCount)
 
 	// see https://github.com/kubernetes/kubernetes/pull/74941
 	// pvc labels are ignored before k8s 1.15.0
 	pvcLabels := dc.GetRackLabels(rackName)
-	if useDefunctManagedByForPvc {
-		oplabels.AddDefunctManagedByLabel(pvcLabels)
-	} else {
-		oplabels.AddManagedByLabel(pvcLabels)
-	}
+	oplabels.AddManagedByLabel(pvcLabels)
 
 	statefulSetLabels := dc.GetRackLabels(rackName)
 	oplabels.AddManagedByLabel(statefulSetLabels)
@@ -150,7 +113,7 @@ func newStatefulSetForCassandraDatacenterHelper(
 		Spec: *dc.Spec.StorageConfig.CassandraDataVolumeClaimSpec,
 	}}
 
-	for _, storage := range dc.Spec.StorageConfig.AdditionalVolumes {
+	for _, storage := range storageConfigVolumes(dc) {
 		pvc := corev1.PersistentVolumeClaim{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:   storage.Name,
@@ -199,6 +162,8 @@ func newStatefulSetForCassandraDatacenterHelper(
 		result = psp.AddStatefulSetChanges(dc, result)
 	}
 
+	addAdditionalMetadata(dc, result)
+
 	// add a hash here to facilitate checking if updates are needed
 	utils.AddHashAnnotation(result)
 
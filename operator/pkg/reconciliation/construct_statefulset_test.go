@@ -197,6 +197,122 @@ func Test_newStatefulSetForCassandraDatacenterWithAdditionalVolumes(t *testing.T
 	}
 }
 
+func Test_newStatefulSetForCassandraDatacenterWithAdditionalDataVolumes(t *testing.T) {
+	dataStorageClass := "data"
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "c1",
+			StorageConfig: api.StorageConfig{
+				CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+					StorageClassName: &dataStorageClass,
+				},
+				AdditionalDataVolumes: api.AdditionalVolumesSlice{
+					{
+						MountPath: "/var/lib/cassandra-data1",
+						Name:      "server-data1",
+						PVCSpec: corev1.PersistentVolumeClaimSpec{
+							StorageClassName: &dataStorageClass,
+						},
+					},
+				},
+			},
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+		},
+	}
+
+	got, err := newStatefulSetForCassandraDatacenter("r1", dc, 1)
+	assert.NoError(t, err, "newStatefulSetForCassandraDatacenter should not have errored")
+
+	assert.Equal(t, 2, len(got.Spec.VolumeClaimTemplates))
+	assert.Equal(t, "server-data", got.Spec.VolumeClaimTemplates[0].Name)
+	assert.Equal(t, "server-data1", got.Spec.VolumeClaimTemplates[1].Name)
+
+	assert.Contains(t, got.Spec.Template.Spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{Name: "server-data1", MountPath: "/var/lib/cassandra-data1"})
+	for _, volume := range got.Spec.Template.Spec.Volumes {
+		assert.NotEqual(t, "server-data1", volume.Name, "the claim template provides the volume")
+	}
+}
+
+func Test_newStatefulSetForCassandraDatacenterWithCommitLogVolume(t *testing.T) {
+	commitLogStorageClass := "fast"
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "c1",
+			StorageConfig: api.StorageConfig{
+				CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{},
+				CommitLogVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{
+					StorageClassName: &commitLogStorageClass,
+				},
+			},
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+		},
+	}
+
+	got, err := newStatefulSetForCassandraDatacenter("r1", dc, 1)
+	assert.NoError(t, err, "newStatefulSetForCassandraDatacenter should not have errored")
+
+	assert.Equal(t, 2, len(got.Spec.VolumeClaimTemplates))
+	assert.Equal(t, "server-commitlog", got.Spec.VolumeClaimTemplates[1].Name)
+	assert.Equal(t, commitLogStorageClass, *got.Spec.VolumeClaimTemplates[1].Spec.StorageClassName)
+
+	assert.Contains(t, got.Spec.Template.Spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{Name: "server-commitlog", MountPath: "/var/lib/cassandra-commitlog"})
+}
+
+func Test_newStatefulSetForCassandraDatacenterWithCDC(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "c1",
+			StorageConfig: api.StorageConfig{
+				CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{},
+			},
+			CDC: &api.CDCConfig{
+				Enabled:         true,
+				VolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{},
+				Forwarder: &api.CDCForwarderConfig{
+					Image:            "cdc-forwarder:1.0",
+					ConfigSecretName: "cdc-config",
+				},
+			},
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+		},
+	}
+
+	got, err := newStatefulSetForCassandraDatacenter("r1", dc, 1)
+	assert.NoError(t, err, "newStatefulSetForCassandraDatacenter should not have errored")
+
+	assert.Equal(t, 2, len(got.Spec.VolumeClaimTemplates))
+	assert.Equal(t, "server-cdc", got.Spec.VolumeClaimTemplates[1].Name)
+
+	cdcMount := corev1.VolumeMount{Name: "server-cdc", MountPath: "/var/lib/cassandra-cdc"}
+	assert.Contains(t, got.Spec.Template.Spec.Containers[0].VolumeMounts, cdcMount)
+
+	var forwarder *corev1.Container
+	for i, container := range got.Spec.Template.Spec.Containers {
+		if container.Name == CDCForwarderContainerName {
+			forwarder = &got.Spec.Template.Spec.Containers[i]
+		}
+	}
+	assert.NotNil(t, forwarder, "the forwarder should be a container of the pods")
+	assert.Equal(t, "cdc-forwarder:1.0", forwarder.Image)
+	assert.Contains(t, forwarder.VolumeMounts, cdcMount)
+	assert.Contains(t, forwarder.Env, corev1.EnvVar{Name: "CDC_RAW_DIRECTORY", Value: "/var/lib/cassandra-cdc"})
+	assert.Equal(t, "cdc-config", forwarder.EnvFrom[0].SecretRef.Name)
+
+	// The volume stays, but the forwarder goes, while CDC is disabled
+	dc.Spec.CDC.Enabled = false
+	got, err = newStatefulSetForCassandraDatacenter("r1", dc, 1)
+	assert.NoError(t, err, "newStatefulSetForCassandraDatacenter should not have errored")
+	assert.Equal(t, 2, len(got.Spec.VolumeClaimTemplates))
+	for _, container := range got.Spec.Template.Spec.Containers {
+		assert.NotEqual(t, CDCForwarderContainerName, container.Name)
+	}
+}
+
 func Test_newStatefulSetForCassandraPodSecurityContext(t *testing.T) {
 	clusterName := "test"
 	rack := "rack1"
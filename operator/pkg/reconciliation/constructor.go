@@ -6,6 +6,9 @@ package reconciliation
 // This file defines constructors for k8s objects
 
 import (
+	"sort"
+	"strings"
+
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
@@ -19,6 +22,11 @@ import (
 // Create a PodDisruptionBudget object for the Datacenter
 func newPodDisruptionBudgetForDatacenter(dc *api.CassandraDatacenter) *policyv1beta1.PodDisruptionBudget {
 	minAvailable := intstr.FromInt(int(dc.Spec.Size - 1))
+	if dc.Spec.Stopped || dc.IsHibernated() {
+		// Fewer pods than the size run, if any, and they must not keep the
+		// workers from being drained
+		minAvailable = intstr.FromInt(0)
+	}
 	labels := dc.GetDatacenterLabels()
 	oplabels.AddManagedByLabel(labels)
 	selectorLabels := dc.GetDatacenterLabels()
@@ -33,16 +41,179 @@ func newPodDisruptionBudgetForDatacenter(dc *api.CassandraDatacenter) *policyv1b
 			Selector: &metav1.LabelSelector{
 				MatchLabels: selectorLabels,
 			},
-			MinAvailable: &minAvailable,
 		},
 	}
 
+	if budget := dc.Spec.PodDisruptionBudget; budget != nil && budget.MaxUnavailable != nil {
+		maxUnavailable := *budget.MaxUnavailable
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	} else {
+		pdb.Spec.MinAvailable = &minAvailable
+	}
+
+	addAdditionalMetadata(dc, pdb)
+
 	// add a hash here to facilitate checking if updates are needed
 	utils.AddHashAnnotation(pdb)
 
 	return pdb
 }
 
+// Create a PodDisruptionBudget object for one rack of the Datacenter
+func newPodDisruptionBudgetForRack(dc *api.CassandraDatacenter, rackName string) *policyv1beta1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt(1)
+	if budget := dc.Spec.PodDisruptionBudget; budget != nil && budget.MaxUnavailable != nil {
+		maxUnavailable = *budget.MaxUnavailable
+	}
+	labels := dc.GetRackLabels(rackName)
+	oplabels.AddManagedByLabel(labels)
+	selectorLabels := dc.GetRackLabels(rackName)
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        dc.Name + "-" + rackName + "-pdb",
+			Namespace:   dc.Namespace,
+			Labels:      labels,
+			Annotations: map[string]string{},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selectorLabels,
+			},
+			MaxUnavailable: &maxUnavailable,
+		},
+	}
+
+	addAdditionalMetadata(dc, pdb)
+
+	// add a hash here to facilitate checking if updates are needed
+	utils.AddHashAnnotation(pdb)
+
+	return pdb
+}
+
+// Create the PodDisruptionBudget objects of the Datacenter: one for the whole
+// Datacenter, or one per rack
+func newPodDisruptionBudgetsForDatacenter(dc *api.CassandraDatacenter) []*policyv1beta1.PodDisruptionBudget {
+	if budget := dc.Spec.PodDisruptionBudget; budget == nil || !budget.PerRack {
+		return []*policyv1beta1.PodDisruptionBudget{newPodDisruptionBudgetForDatacenter(dc)}
+	}
+
+	var budgets []*policyv1beta1.PodDisruptionBudget
+	for _, rack := range dc.GetRacks() {
+		budgets = append(budgets, newPodDisruptionBudgetForRack(dc, rack.Name))
+	}
+	return budgets
+}
+
+// addAdditionalMetadata adds the additional labels and annotations of the
+// Datacenter to a resource the operator creates for it. The labels and
+// annotations the resource already has take precedence. The keys it adds are
+// recorded on the resource, so that updates remove them once they are taken
+// out of the spec.
+func addAdditionalMetadata(dc *api.CassandraDatacenter, obj metav1.Object) {
+	if len(dc.Spec.AdditionalLabels) == 0 && len(dc.Spec.AdditionalAnnotations) == 0 {
+		return
+	}
+	labels, annotations := withAdditionalMetadata(dc, obj.GetLabels(), obj.GetAnnotations(), false)
+	obj.SetLabels(labels)
+	obj.SetAnnotations(annotations)
+}
+
+// addAdditionalTemplateMetadata adds the additional labels and annotations of
+// the Datacenter to a pod template. Templates are replaced as a whole, so the
+// keys are not recorded, which would also roll the pods out.
+func addAdditionalTemplateMetadata(dc *api.CassandraDatacenter, template *metav1.ObjectMeta) {
+	if len(dc.Spec.AdditionalLabels) > 0 {
+		template.SetLabels(utils.MergeMap(map[string]string{}, dc.Spec.AdditionalLabels, template.GetLabels()))
+	}
+	if len(dc.Spec.AdditionalAnnotations) > 0 {
+		template.SetAnnotations(utils.MergeMap(map[string]string{}, dc.Spec.AdditionalAnnotations, template.GetAnnotations()))
+	}
+}
+
+// updateAdditionalMetadata sets the additional labels and annotations of the
+// Datacenter on an existing resource the operator created for it, removes the
+// ones it set before that are no longer in the spec, and returns whether any
+// of them changed
+func updateAdditionalMetadata(dc *api.CassandraDatacenter, obj metav1.Object) bool {
+	labels, annotations := withoutAdditionalMetadata(obj.GetLabels(), obj.GetAnnotations())
+	labels, annotations = withAdditionalMetadata(dc, labels, annotations, true)
+	if sameEntries(labels, obj.GetLabels()) && sameEntries(annotations, obj.GetAnnotations()) {
+		return false
+	}
+	obj.SetLabels(labels)
+	obj.SetAnnotations(annotations)
+	return true
+}
+
+// withAdditionalMetadata returns copies of labels and annotations with the
+// additional ones of the Datacenter, and the keys it set recorded in the
+// annotations. The entries already there are kept, unless override is set.
+func withAdditionalMetadata(dc *api.CassandraDatacenter, labels, annotations map[string]string, override bool) (map[string]string, map[string]string) {
+	labels = utils.MergeMap(map[string]string{}, labels)
+	annotations = utils.MergeMap(map[string]string{}, annotations)
+	labelKeys := mergeAdditionalEntries(labels, dc.Spec.AdditionalLabels, override)
+	annotationKeys := mergeAdditionalEntries(annotations, dc.Spec.AdditionalAnnotations, override)
+	if len(labelKeys) > 0 {
+		annotations[api.AdditionalLabelsAnnotation] = strings.Join(labelKeys, ",")
+	}
+	if len(annotationKeys) > 0 {
+		annotations[api.AdditionalAnnotationsAnnotation] = strings.Join(annotationKeys, ",")
+	}
+	return labels, annotations
+}
+
+// withoutAdditionalMetadata returns copies of the labels and the annotations
+// of a resource without the additional ones the operator recorded setting
+func withoutAdditionalMetadata(labels, annotations map[string]string) (map[string]string, map[string]string) {
+	labels = utils.MergeMap(map[string]string{}, labels)
+	annotations = utils.MergeMap(map[string]string{}, annotations)
+	for _, key := range additionalKeys(annotations[api.AdditionalLabelsAnnotation]) {
+		delete(labels, key)
+	}
+	for _, key := range additionalKeys(annotations[api.AdditionalAnnotationsAnnotation]) {
+		delete(annotations, key)
+	}
+	delete(annotations, api.AdditionalLabelsAnnotation)
+	delete(annotations, api.AdditionalAnnotationsAnnotation)
+	return labels, annotations
+}
+
+// mergeAdditionalEntries sets the additional entries in entries, but the ones
+// it already has unless override is set, and returns the sorted keys it set
+func mergeAdditionalEntries(entries, additional map[string]string, override bool) []string {
+	var keys []string
+	for key, value := range additional {
+		if _, ok := entries[key]; ok && !override {
+			continue
+		}
+		entries[key] = value
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func additionalKeys(recorded string) []string {
+	if recorded == "" {
+		return nil
+	}
+	return strings.Split(recorded, ",")
+}
+
+func sameEntries(m, other map[string]string) bool {
+	return len(m) == len(other) && containsAll(m, other)
+}
+
+func containsAll(m, entries map[string]string) bool {
+	for k, v := range entries {
+		if current, ok := m[k]; !ok || current != v {
+			return false
+		}
+	}
+	return true
+}
+
 func setOperatorProgressStatus(rc *ReconciliationContext, newState api.ProgressState) error {
 	currentState := rc.Datacenter.Status.CassandraOperatorProgress
 	if currentState == newState {
@@ -52,10 +223,7 @@ func setOperatorProgressStatus(rc *ReconciliationContext, newState api.ProgressS
 
 	patch := client.MergeFrom(rc.Datacenter.DeepCopy())
 	rc.Datacenter.Status.CassandraOperatorProgress = newState
-	// TODO there may be a better place to push status.observedGeneration in the reconcile loop
-	if newState == api.ProgressReady {
-		rc.Datacenter.Status.ObservedGeneration = rc.Datacenter.Generation
-	}
+	rc.Datacenter.Status.Phase = rc.Datacenter.Status.ComputePhase()
 	if err := rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, patch); err != nil {
 		rc.ReqLogger.Error(err, "error updating the Cassandra Operator Progress state")
 		return err
@@ -63,3 +231,22 @@ func setOperatorProgressStatus(rc *ReconciliationContext, newState api.ProgressS
 
 	return nil
 }
+
+// setObservedGeneration records that the operator reconciled the current
+// generation of the datacenter. It is called once every StatefulSet of the
+// datacenter was reconciled, as the progress turns Ready at that point and
+// stays Ready through the changes of spec that follow.
+func setObservedGeneration(rc *ReconciliationContext) error {
+	if rc.Datacenter.Status.ObservedGeneration == rc.Datacenter.Generation {
+		return nil
+	}
+
+	patch := client.MergeFrom(rc.Datacenter.DeepCopy())
+	rc.Datacenter.Status.ObservedGeneration = rc.Datacenter.Generation
+	if err := rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, patch); err != nil {
+		rc.ReqLogger.Error(err, "error updating the observed generation")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+func TestNewPodDisruptionBudgetForDatacenter(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "cluster1",
+			Size:        3,
+		},
+	}
+
+	pdb := newPodDisruptionBudgetForDatacenter(dc)
+	assert.Equal(t, intstr.FromInt(2), *pdb.Spec.MinAvailable)
+	assert.Nil(t, pdb.Spec.MaxUnavailable)
+
+	maxUnavailable := intstr.FromString("50%")
+	dc.Spec.PodDisruptionBudget = &api.PodDisruptionBudgetConfig{MaxUnavailable: &maxUnavailable}
+
+	pdb = newPodDisruptionBudgetForDatacenter(dc)
+	assert.Nil(t, pdb.Spec.MinAvailable)
+	assert.Equal(t, maxUnavailable, *pdb.Spec.MaxUnavailable)
+
+	// No pod has to stay available while the datacenter is stopped or
+	// hibernated
+	dc.Spec.PodDisruptionBudget = nil
+	dc.Spec.Stopped = true
+	pdb = newPodDisruptionBudgetForDatacenter(dc)
+	assert.Equal(t, intstr.FromInt(0), *pdb.Spec.MinAvailable)
+
+	dc.Spec.Stopped = false
+	dc.Spec.Hibernate = &api.HibernateConfig{Enabled: true}
+	pdb = newPodDisruptionBudgetForDatacenter(dc)
+	assert.Equal(t, intstr.FromInt(0), *pdb.Spec.MinAvailable)
+}
+
+func TestNewPodDisruptionBudgetsForDatacenter_PerRack(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:         "cluster1",
+			Size:                6,
+			Racks:               []api.Rack{{Name: "r1"}, {Name: "r2"}},
+			PodDisruptionBudget: &api.PodDisruptionBudgetConfig{PerRack: true},
+		},
+	}
+
+	budgets := newPodDisruptionBudgetsForDatacenter(dc)
+	assert.Len(t, budgets, 2)
+	assert.Equal(t, "dc1-r1-pdb", budgets[0].Name)
+	assert.Equal(t, "r1", budgets[0].Spec.Selector.MatchLabels[api.RackLabel])
+	assert.Equal(t, intstr.FromInt(1), *budgets[0].Spec.MaxUnavailable)
+	assert.Nil(t, budgets[0].Spec.MinAvailable)
+	assert.Equal(t, "dc1-r2-pdb", budgets[1].Name)
+
+	maxUnavailable := intstr.FromInt(2)
+	dc.Spec.PodDisruptionBudget.MaxUnavailable = &maxUnavailable
+
+	budgets = newPodDisruptionBudgetsForDatacenter(dc)
+	assert.Equal(t, maxUnavailable, *budgets[1].Spec.MaxUnavailable)
+}
+
+func TestAdditionalMetadata(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "cluster1",
+			Size:          3,
+			ServerType:    "cassandra",
+			ServerVersion: "3.11.7",
+			StorageConfig: api.StorageConfig{
+				CassandraDataVolumeClaimSpec: &corev1.PersistentVolumeClaimSpec{},
+			},
+			AdditionalLabels:      map[string]string{"example.com/team": "data", api.DatacenterLabel: "other"},
+			AdditionalAnnotations: map[string]string{"sidecar.istio.io/inject": "false"},
+			AdditionalServiceConfig: api.ServiceConfig{
+				SeedService: api.ServiceConfigAdditions{Labels: map[string]string{"example.com/team": "seeds"}},
+			},
+		},
+	}
+
+	pdb := newPodDisruptionBudgetForDatacenter(dc)
+	assert.Equal(t, "data", pdb.Labels["example.com/team"])
+	assert.Equal(t, "false", pdb.Annotations["sidecar.istio.io/inject"])
+	// The labels of the operator take precedence
+	assert.Equal(t, "dc1", pdb.Labels[api.DatacenterLabel])
+
+	sts, err := newStatefulSetForCassandraDatacenter("default", dc, 3)
+	assert.NoError(t, err)
+	for _, labels := range []map[string]string{sts.Labels, sts.Spec.Template.Labels} {
+		assert.Equal(t, "data", labels["example.com/team"])
+		assert.Equal(t, "dc1", labels[api.DatacenterLabel])
+	}
+	assert.Equal(t, "false", sts.Spec.Template.Annotations["sidecar.istio.io/inject"])
+	assert.NotContains(t, sts.Spec.Selector.MatchLabels, "example.com/team")
+	assert.NotContains(t, sts.Spec.VolumeClaimTemplates[0].Labels, "example.com/team")
+
+	// The additional config of a service takes precedence
+	assert.Equal(t, "seeds", newSeedServiceForCassandraDatacenter(dc).Labels["example.com/team"])
+	assert.Equal(t, "data", newServiceForCassandraDatacenter(dc).Labels["example.com/team"])
+
+	// A change of the additional labels changes the hash of the resources
+	old := newAllPodsServiceForCassandraDatacenter(dc)
+	dc.Spec.AdditionalLabels["example.com/team"] = "storage"
+	assert.False(t, utils.ResourcesHaveSameHash(old, newAllPodsServiceForCassandraDatacenter(dc)))
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"example.com/team": "data"}}}
+	delete(dc.Spec.AdditionalLabels, api.DatacenterLabel)
+	assert.True(t, updateAdditionalMetadata(dc, secret))
+	assert.Equal(t, "storage", secret.Labels["example.com/team"])
+	assert.Equal(t, "false", secret.Annotations["sidecar.istio.io/inject"])
+	assert.False(t, updateAdditionalMetadata(dc, secret))
+
+	// The ones taken out of the spec are removed
+	delete(dc.Spec.AdditionalLabels, "example.com/team")
+	dc.Spec.AdditionalAnnotations = nil
+	assert.True(t, updateAdditionalMetadata(dc, secret))
+	assert.Empty(t, secret.Labels)
+	assert.Empty(t, secret.Annotations)
+}
+
+func TestSetObservedGeneration(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Generation = 2
+	dc.Status.CassandraOperatorProgress = api.ProgressReady
+	dc.Status.ObservedGeneration = 1
+
+	// The progress stays Ready through a change of spec, which must not keep
+	// the previous generation observed
+	assert.NoError(t, setOperatorProgressStatus(rc, api.ProgressReady))
+	assert.Equal(t, int64(1), dc.Status.ObservedGeneration)
+
+	assert.NoError(t, setObservedGeneration(rc))
+	stored := &api.CassandraDatacenter{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: dc.Name, Namespace: dc.Namespace}, stored))
+	assert.Equal(t, int64(2), stored.Status.ObservedGeneration)
+}
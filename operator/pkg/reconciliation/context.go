@@ -21,6 +21,7 @@ import (
 	"github.com/k8ssandra/cass-operator/operator/pkg/events"
 	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/operator/pkg/psp"
+	"github.com/k8ssandra/cass-operator/operator/pkg/reaper"
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
 )
 
@@ -36,6 +37,10 @@ type ReconciliationContext struct {
 	PSPHealthUpdater psp.HealthStatusUpdater
 	SecretWatches    dynamicwatch.DynamicWatches
 
+	// ReaperHTTPClient sends the requests to the Reaper of spec.reaper. It
+	// defaults to an http.Client.
+	ReaperHTTPClient reaper.HTTPClient
+
 	// According to golang recommendations the context should not be stored in a struct but given that
 	// this is passed around as a parameter we feel that its a fair compromise. For further discussion
 	// see: golang/go#22602
@@ -47,6 +52,22 @@ type ReconciliationContext struct {
 	statefulSets           []*appsv1.StatefulSet
 	dcPods                 []*corev1.Pod
 	clusterPods            []*corev1.Pod
+
+	// requeueSeconds is when the steps that wait on long-running operations
+	// asked to be checked again, once the others ran, 0 when none did
+	requeueSeconds int
+}
+
+// requeueAfter asks for the datacenter to be reconciled again in secs
+// seconds, at the end of the reconciliation rather than in place of its next
+// steps. The soonest of the requests wins.
+func (rc *ReconciliationContext) requeueAfter(secs int) {
+	if secs < 1 {
+		secs = 1
+	}
+	if rc.requeueSeconds == 0 || secs < rc.requeueSeconds {
+		rc.requeueSeconds = secs
+	}
 }
 
 // CreateReconciliationContext gathers all information needed for computeReconciliationActions into a struct.
@@ -114,6 +135,12 @@ func CreateReconciliationContext(
 		WithValues("datacenterName", dc.Name).
 		WithValues("clusterName", dc.Spec.ClusterName)
 
+	workers, err := utils.GetManagementApiWorkers()
+	if err != nil {
+		rc.ReqLogger.Error(err, "error in GetManagementApiWorkers")
+		return nil, err
+	}
+
 	protocol, err := httphelper.GetManagementApiProtocol(dc)
 	if err != nil {
 		rc.ReqLogger.Error(err, "error in GetManagementApiProtocol")
@@ -124,6 +151,9 @@ func CreateReconciliationContext(
 		Client:   httpClient,
 		Log:      rc.ReqLogger,
 		Protocol: protocol,
+		Workers:  workers,
+		Ctx:      rc.Ctx,
+		Retry:    httphelper.DefaultRetryPolicy,
 	}
 
 	return rc, nil
@@ -8,6 +8,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/k8ssandra/cass-operator/operator/internal/result"
@@ -58,6 +59,29 @@ func (rc *ReconciliationContext) DecommissionNodes(epData httphelper.CassMetadat
 		return result.Continue()
 	}
 
+	// The replication factors are read again, as keyspaces may have been
+	// created or altered since the webhook validated the scale down. The
+	// system keyspaces are only lowered once the scale down is safe.
+	// Only the scale down waits for the keyspaces to be altered, the steps
+	// after this one go on.
+	if err := rc.refreshMaxReplicationFactor(true); err != nil {
+		return result.Error(err)
+	}
+	if unsafe, err := rc.updateReplicationFactorCondition(dc.Spec.Size, scaleDownBelowReplicationFactorReason); err != nil {
+		return result.Error(err)
+	} else if unsafe {
+		logger.Info("Not scaling down below the replication factor of the keyspaces")
+		rc.requeueAfter(60)
+		return result.Continue()
+	}
+
+	if dc.Spec.ManageSystemKeyspaces {
+		if err := rc.lowerSystemKeyspaceReplication(); err != nil {
+			logger.Error(err, "error lowering the replication of the system keyspaces before scaling down")
+			return result.Error(err)
+		}
+	}
+
 	decommRackInfo, err := rc.CalculateRackInfoForDecomm(int(currentSize))
 	if err != nil {
 		logger.Error(err, "error calculating rack info for decommissioning nodes")
@@ -94,7 +118,7 @@ func (rc *ReconciliationContext) DecommissionNodes(epData httphelper.CassMetadat
 				"desiredSize", desiredNodeCount,
 			)
 
-			rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.ScalingDownRack,
+			rc.recordRackEventf(rackInfo.RackName, corev1.EventTypeNormal, events.ScalingDownRack,
 				"Scaling down rack %s", rackInfo.RackName)
 
 			if err := setOperatorProgressStatus(rc, api.ProgressUpdating); err != nil {
@@ -129,6 +153,9 @@ func (rc *ReconciliationContext) DecommissionNodeOnRack(rackName string, epData
 			if err := rc.NodeMgmtClient.CallDecommissionNodeEndpoint(pod); err != nil {
 				rc.ReqLogger.Info(fmt.Sprintf("Error from decommission attempt. This is only an attempt and can"+
 					" fail it will be retried later if decomission has not started. Error: %v", err))
+			} else {
+				rc.recordPodEventf(pod, corev1.EventTypeNormal, events.DecommissionStarted,
+					"Started decommissioning the node of pod %s", pod.Name)
 			}
 
 			rc.ReqLogger.Info("Marking node as decommissioning")
@@ -138,9 +165,14 @@ func (rc *ReconciliationContext) DecommissionNodeOnRack(rackName string, epData
 				return err
 			}
 
-			rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.LabeledPodAsDecommissioning,
+			rc.recordPodEventf(pod, corev1.EventTypeNormal, events.LabeledPodAsDecommissioning,
 				"Labeled node as decommissioning %s", pod.Name)
 
+			// The progress is only informational, and is recorded again while the node decommissions
+			if err := rc.startDecommissionProgress(pod, epData); err != nil {
+				rc.ReqLogger.Error(err, "Failed to record the decommission progress")
+			}
+
 			return nil
 		}
 	}
@@ -162,9 +194,15 @@ func (rc *ReconciliationContext) CheckDecommissioningNodes(epData httphelper.Cas
 					rc.ReqLogger.Info("Decommission has not started trying again")
 					if err := rc.NodeMgmtClient.CallDecommissionNodeEndpoint(pod); err != nil {
 						rc.ReqLogger.Info(fmt.Sprintf("Error from decomimssion attempt. This is only an attempt and can fail. Error: %v", err))
+					} else {
+						rc.recordPodEventf(pod, corev1.EventTypeNormal, events.DecommissionStarted,
+							"Started decommissioning the node of pod %s", pod.Name)
 					}
 				} else {
 					rc.ReqLogger.Info("Node decommissioning, reconciling again soon")
+					if err := rc.updateDecommissionProgress(pod, epData); err != nil {
+						rc.ReqLogger.Error(err, "Failed to update the decommission progress")
+					}
 				}
 			} else {
 				rc.ReqLogger.Info("Node finished decommissioning")
@@ -179,9 +217,21 @@ func (rc *ReconciliationContext) CheckDecommissioningNodes(epData httphelper.Cas
 	dcPatch := client.MergeFrom(rc.Datacenter.DeepCopy())
 	updated := false
 
-	updated = rc.setCondition(
+	scaledDown := rc.conditionTransitions(api.DatacenterScalingDown, corev1.ConditionFalse)
+	if rc.setCondition(
 		api.NewDatacenterCondition(
-			api.DatacenterScalingDown, corev1.ConditionFalse)) || updated
+			api.DatacenterScalingDown, corev1.ConditionFalse)) {
+		updated = true
+	}
+	if scaledDown {
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.ScaledDownDatacenter,
+			"Scaled down the datacenter to %d nodes", rc.Datacenter.Spec.Size)
+	}
+
+	if rc.Datacenter.Status.Decommission != nil {
+		rc.Datacenter.Status.Decommission = nil
+		updated = true
+	}
 
 	if updated {
 		err := rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, dcPatch)
@@ -200,14 +250,19 @@ func (rc *ReconciliationContext) cleanUpAfterDecommissionedPod(pod *corev1.Pod)
 	if err != nil {
 		return result.Error(err)
 	}
-	rc.ReqLogger.Info("Deleting pod PVCs")
-	err = rc.DeletePodPvcs(pod)
-	if err != nil {
-		return result.Error(err)
+	if rc.Datacenter.DeletePVCsWhenScaled() {
+		rc.ReqLogger.Info("Deleting pod PVCs")
+		err = rc.DeletePodPvcs(pod)
+		if err != nil {
+			return result.Error(err)
+		}
+	} else {
+		rc.ReqLogger.Info("Keeping pod PVCs")
 	}
 
 	dcPatch := client.MergeFrom(rc.Datacenter.DeepCopy())
 	delete(rc.Datacenter.Status.NodeStatuses, pod.Name)
+	rc.Datacenter.Status.Decommission = nil
 
 	err = rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, dcPatch)
 	if err != nil {
@@ -218,6 +273,88 @@ func (rc *ReconciliationContext) cleanUpAfterDecommissionedPod(pod *corev1.Pod)
 	return nil
 }
 
+// startDecommissionProgress records the node that started to decommission in
+// the status, with the loads its progress is estimated from
+func (rc *ReconciliationContext) startDecommissionProgress(pod *corev1.Pod, epData httphelper.CassMetadataEndpoints) error {
+	podsUsedStorage, err := rc.GetUsedStorageForPods(epData)
+	if err != nil {
+		return err
+	}
+
+	var otherNodesLoad float64
+	for podName, used := range podsUsedStorage {
+		if podName != pod.Name {
+			otherNodesLoad += used
+		}
+	}
+
+	dcPatch := client.MergeFrom(rc.Datacenter.DeepCopy())
+	rc.Datacenter.Status.Decommission = &api.DecommissionProgress{
+		PodName:               pod.Name,
+		StartTime:             metav1.Now(),
+		InitialLoad:           int64(podsUsedStorage[pod.Name]),
+		OtherNodesInitialLoad: int64(otherNodesLoad),
+	}
+	if err := rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status for decommission started")
+		return err
+	}
+	return nil
+}
+
+// updateDecommissionProgress updates the estimated percentage of the data of
+// the decommissioning node that has been streamed to the other nodes
+func (rc *ReconciliationContext) updateDecommissionProgress(pod *corev1.Pod, epData httphelper.CassMetadataEndpoints) error {
+	progress := rc.Datacenter.Status.Decommission
+	if progress == nil || progress.PodName != pod.Name {
+		return rc.startDecommissionProgress(pod, epData)
+	}
+
+	podsUsedStorage, err := rc.GetUsedStorageForPods(epData)
+	if err != nil {
+		return err
+	}
+
+	var otherNodesLoad float64
+	for podName, used := range podsUsedStorage {
+		if podName != pod.Name {
+			otherNodesLoad += used
+		}
+	}
+
+	percent := estimateStreamedPercent(progress, int64(otherNodesLoad))
+	if percent == progress.StreamedPercent {
+		return nil
+	}
+
+	dcPatch := client.MergeFrom(rc.Datacenter.DeepCopy())
+	rc.Datacenter.Status.Decommission.StreamedPercent = percent
+	if err := rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status for decommission progress")
+		return err
+	}
+	return nil
+}
+
+// estimateStreamedPercent estimates how much of the data of a decommissioning
+// node has been streamed from how much the load of the other nodes grew. Writes
+// and compactions make this inexact, so it stays below 100 until the node has
+// left.
+func estimateStreamedPercent(progress *api.DecommissionProgress, otherNodesLoad int64) int32 {
+	if progress.InitialLoad <= 0 {
+		return 0
+	}
+
+	percent := (otherNodesLoad - progress.OtherNodesInitialLoad) * 100 / progress.InitialLoad
+	if percent < 0 {
+		return 0
+	}
+	if percent > 99 {
+		return 99
+	}
+	return int32(percent)
+}
+
 func HasStartedDecommissioning(pod *v1.Pod, epData httphelper.CassMetadataEndpoints) bool {
 	for idx := range epData.Entity {
 		ep := &epData.Entity[idx]
@@ -262,6 +399,10 @@ func (rc *ReconciliationContext) DeletePodPvcs(pod *v1.Pod) error {
 			return err
 		}
 
+		if err := rc.unprotectPVC(podPvc); err != nil {
+			return err
+		}
+
 		err = rc.Client.Delete(rc.Ctx, podPvc)
 		if err != nil {
 			rc.ReqLogger.Error(err, "Failed to delete pod PVC", "Claim Name", pvcName)
@@ -21,6 +21,7 @@ import (
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -143,6 +144,55 @@ func TestRemoveResourcesWhenDone(t *testing.T) {
 	}
 }
 
+func TestUpdateDecommissionProgress(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.dcPods = []*v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0"},
+		Status:     v1.PodStatus{PodIP: "192.168.101.10"},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-1"},
+		Status:     v1.PodStatus{PodIP: "192.168.101.11"},
+	}}
+	rc.Datacenter.Status.Decommission = &api.DecommissionProgress{
+		PodName:               "pod-1",
+		InitialLoad:           1000,
+		OtherNodesInitialLoad: 2000,
+	}
+
+	epData := httphelper.CassMetadataEndpoints{
+		Entity: []httphelper.EndpointState{
+			{RpcAddress: "192.168.101.10", Status: "NORMAL", Load: "2400"},
+			{RpcAddress: "192.168.101.11", Status: "LEAVING", Load: "1000"},
+		},
+	}
+
+	assert.NoError(t, rc.updateDecommissionProgress(rc.dcPods[1], epData))
+	assert.Equal(t, int32(40), rc.Datacenter.Status.Decommission.StreamedPercent)
+
+	// A node that started decommissioning before the progress was recorded
+	rc.Datacenter.Status.Decommission = nil
+	assert.NoError(t, rc.updateDecommissionProgress(rc.dcPods[1], epData))
+	assert.Equal(t, "pod-1", rc.Datacenter.Status.Decommission.PodName)
+	assert.Equal(t, int64(1000), rc.Datacenter.Status.Decommission.InitialLoad)
+	assert.Equal(t, int64(2400), rc.Datacenter.Status.Decommission.OtherNodesInitialLoad)
+	assert.Equal(t, int32(0), rc.Datacenter.Status.Decommission.StreamedPercent)
+}
+
+func TestEstimateStreamedPercent(t *testing.T) {
+	progress := &api.DecommissionProgress{
+		PodName:               "pod-1",
+		InitialLoad:           1000,
+		OtherNodesInitialLoad: 2000,
+	}
+
+	assert.Equal(t, int32(0), estimateStreamedPercent(progress, 1900))
+	assert.Equal(t, int32(25), estimateStreamedPercent(progress, 2250))
+	assert.Equal(t, int32(99), estimateStreamedPercent(progress, 3500))
+	assert.Equal(t, int32(0), estimateStreamedPercent(&api.DecommissionProgress{PodName: "pod-1"}, 3500))
+}
+
 type statusMock struct {
 	called int
 }
@@ -7,6 +7,6 @@ var (
 	// Provides reasonable defaults for the configuration container.
 	DefaultsConfigInitContainer = buildResourceRequirements(1000, 256)
 
-	// Provides reasonable defaults for the reaper sidecar container.
+	// Provides reasonable defaults for the reaper container.
 	DefaultsReaperContainer = buildResourceRequirements(2000, 512)
 )
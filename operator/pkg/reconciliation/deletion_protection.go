@@ -0,0 +1,117 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+)
+
+func hasDeletionProtectionFinalizer(pvc *corev1.PersistentVolumeClaim) bool {
+	for _, finalizer := range pvc.GetFinalizers() {
+		if finalizer == api.DeletionProtectionFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckDeletionProtection adds the deletion protection finalizer to the PVCs of
+// a datacenter with deletionProtection, and removes it once the deletion is
+// confirmed. The datacenter itself is held by the finalizer of the operator,
+// see ProcessDeletion. The PVCs of a datacenter whose protection was turned
+// off are released when it is deleted, or by the operator before deleting
+// them.
+func (rc *ReconciliationContext) CheckDeletionProtection() result.ReconcileResult {
+	if !rc.Datacenter.Spec.DeletionProtection {
+		return result.Continue()
+	}
+
+	if err := rc.syncPVCProtection(!rc.Datacenter.DeletionConfirmed()); err != nil {
+		return result.Error(err)
+	}
+	return result.Continue()
+}
+
+// syncPVCProtection adds the deletion protection finalizer to or removes it
+// from all the PVCs of the datacenter
+func (rc *ReconciliationContext) syncPVCProtection(protect bool) error {
+	pvcList, err := rc.listPVCs()
+	if err != nil {
+		rc.ReqLogger.Error(err, "error listing the PVCs of the datacenter")
+		return err
+	}
+
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		if !protect {
+			if err := rc.unprotectPVC(pvc); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Finalizers cannot be added to a PVC that is being deleted
+		if hasDeletionProtectionFinalizer(pvc) || pvc.GetDeletionTimestamp() != nil {
+			continue
+		}
+		rc.ReqLogger.Info("Protecting PVC from deletion", "pvc", pvc.Name)
+		patch := client.MergeFrom(pvc.DeepCopy())
+		pvc.SetFinalizers(append(pvc.GetFinalizers(), api.DeletionProtectionFinalizer))
+		if err := rc.Client.Patch(rc.Ctx, pvc, patch); err != nil {
+			rc.ReqLogger.Error(err, "error adding the deletion protection finalizer to PVC", "pvc", pvc.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+// unprotectPVC removes the deletion protection finalizer from a PVC, for the
+// operator to delete it or for a confirmed deletion to go through
+func (rc *ReconciliationContext) unprotectPVC(pvc *corev1.PersistentVolumeClaim) error {
+	if !hasDeletionProtectionFinalizer(pvc) {
+		return nil
+	}
+
+	var finalizers []string
+	for _, finalizer := range pvc.GetFinalizers() {
+		if finalizer != api.DeletionProtectionFinalizer {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+
+	rc.ReqLogger.Info("Removing the deletion protection of PVC", "pvc", pvc.Name)
+	patch := client.MergeFrom(pvc.DeepCopy())
+	pvc.SetFinalizers(finalizers)
+	if err := rc.Client.Patch(rc.Ctx, pvc, patch); err != nil {
+		rc.ReqLogger.Error(err, "error removing the deletion protection finalizer from PVC", "pvc", pvc.Name)
+		return err
+	}
+	return nil
+}
+
+// checkDeletionConfirmed holds the deletion of a datacenter with
+// deletionProtection until it is confirmed. It returns Continue once the
+// deletion can go on, after releasing the PVCs of the datacenter.
+func (rc *ReconciliationContext) checkDeletionConfirmed() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.DeletionConfirmed() {
+		rc.ReqLogger.Info("Holding the deletion of the datacenter until it is confirmed",
+			"annotation", api.ConfirmDeletionAnnotation)
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.DeletionBlocked,
+			"The datacenter has deletionProtection, annotate it with %s=true to confirm its deletion",
+			api.ConfirmDeletionAnnotation)
+		// The annotation triggers the next reconciliation
+		return result.Done()
+	}
+
+	if err := rc.syncPVCProtection(false); err != nil {
+		return result.Error(err)
+	}
+	return result.Continue()
+}
@@ -0,0 +1,74 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func createDatacenterPVC(t *testing.T, rc *ReconciliationContext, name string) types.NamespacedName {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: rc.Datacenter.Namespace,
+			Labels:    map[string]string{api.DatacenterLabel: rc.Datacenter.Name},
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pvc))
+	return types.NamespacedName{Name: name, Namespace: rc.Datacenter.Namespace}
+}
+
+func getPVCFinalizers(t *testing.T, rc *ReconciliationContext, key types.NamespacedName) []string {
+	pvc := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, pvc))
+	return pvc.GetFinalizers()
+}
+
+func TestCheckDeletionProtection(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	key := createDatacenterPVC(t, rc, "server-data-pod-0")
+
+	assert.False(t, rc.CheckDeletionProtection().Completed())
+	assert.Empty(t, getPVCFinalizers(t, rc, key), "no deletion protection")
+
+	rc.Datacenter.Spec.DeletionProtection = true
+	assert.False(t, rc.CheckDeletionProtection().Completed())
+	assert.Equal(t, []string{api.DeletionProtectionFinalizer}, getPVCFinalizers(t, rc, key))
+
+	rc.Datacenter.Annotations = map[string]string{api.ConfirmDeletionAnnotation: "true"}
+	assert.False(t, rc.CheckDeletionProtection().Completed())
+	assert.Empty(t, getPVCFinalizers(t, rc, key), "deletion confirmed")
+}
+
+func TestProcessDeletion_DeletionProtection(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	key := createDatacenterPVC(t, rc, "server-data-pod-0")
+	rc.Datacenter.Spec.DeletionProtection = true
+	assert.False(t, rc.CheckDeletionProtection().Completed())
+
+	now := metav1.Now()
+	rc.Datacenter.SetDeletionTimestamp(&now)
+	rc.Datacenter.SetFinalizers([]string{api.Finalizer})
+
+	recResult := rc.checkDeletionConfirmed()
+	assert.True(t, recResult.Completed(), "deletion held until confirmed")
+	assert.Equal(t, []string{api.DeletionProtectionFinalizer}, getPVCFinalizers(t, rc, key))
+	assert.True(t, hasFinalizer(rc.Datacenter))
+
+	rc.Datacenter.Annotations = map[string]string{api.ConfirmDeletionAnnotation: "true"}
+	recResult = rc.checkDeletionConfirmed()
+	assert.False(t, recResult.Completed(), "deletion confirmed")
+	assert.Empty(t, getPVCFinalizers(t, rc, key))
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,15 +27,24 @@ import (
 	"github.com/k8ssandra/cass-operator/operator/internal/result"
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/operator/pkg/dynamicwatch"
+	"github.com/k8ssandra/cass-operator/operator/pkg/emm"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
 	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/opmetrics"
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
 	"github.com/k8ssandra/cass-operator/operator/pkg/psp"
+	"github.com/k8ssandra/cass-operator/operator/pkg/sharding"
+	"github.com/k8ssandra/cass-operator/operator/pkg/statecache"
+	"github.com/k8ssandra/cass-operator/operator/pkg/watchnamespace"
 )
 
 // Use a var so we can mock this function
 var setControllerReference = controllerutil.SetControllerReference
 
 // key: Node.Name, value: CassandraDatacenter.Name
+// The reconciliations of different datacenters run concurrently with
+// MaxConcurrentReconciles, so the map is only used under nodeToDcLock, and
+// never hands out the slices it holds.
 var nodeToDc = make(map[string][]types.NamespacedName)
 var nodeToDcLock = sync.RWMutex{}
 
@@ -43,17 +53,19 @@ func DatacentersForNode(nodeName string) []types.NamespacedName {
 	nodeToDcLock.RLock()
 	defer nodeToDcLock.RUnlock()
 
-	dcs, ok := nodeToDc[nodeName]
-	if ok {
-		return dcs
-	}
-	return []types.NamespacedName{}
+	return append([]types.NamespacedName{}, nodeToDc[nodeName]...)
 }
 
 func (rc *ReconciliationContext) RemoveDcFromNodeToDcMap(dcToRemove types.NamespacedName) {
 	nodeToDcLock.Lock()
 	defer nodeToDcLock.Unlock()
 
+	removeDcFromNodeToDcMap(dcToRemove)
+}
+
+// removeDcFromNodeToDcMap removes the datacenter from the map, whose lock
+// must be held
+func removeDcFromNodeToDcMap(dcToRemove types.NamespacedName) {
 	for nodeName, dcs := range nodeToDc {
 		var newDcs = []types.NamespacedName{}
 		for _, dc := range dcs {
@@ -61,7 +73,11 @@ func (rc *ReconciliationContext) RemoveDcFromNodeToDcMap(dcToRemove types.Namesp
 				newDcs = append(newDcs, dc)
 			}
 		}
-		nodeToDc[nodeName] = newDcs
+		if len(newDcs) == 0 {
+			delete(nodeToDc, nodeName)
+		} else {
+			nodeToDc[nodeName] = newDcs
+		}
 	}
 }
 
@@ -69,6 +85,7 @@ func (rc *ReconciliationContext) RemoveDcFromNodeToDcMap(dcToRemove types.Namesp
 // Every CassandraDatacenter with pods will have produced at least
 // one call to the reconcile loop.  Therefore this map will be
 // populated with the information for all current CassandraDatacenters.
+// The nodes the datacenter no longer has pods on are removed from it.
 func (rc *ReconciliationContext) updateDcMaps() error {
 
 	dcName := rc.Datacenter.ObjectMeta.Name
@@ -82,6 +99,7 @@ func (rc *ReconciliationContext) updateDcMaps() error {
 		})
 
 	listOptions := &client.ListOptions{
+		Namespace:     rc.Datacenter.Namespace,
 		LabelSelector: labelSelector,
 	}
 
@@ -94,18 +112,24 @@ func (rc *ReconciliationContext) updateDcMaps() error {
 		return err
 	}
 
+	dcToAdd := types.NamespacedName{
+		Namespace: rc.Datacenter.Namespace,
+		Name:      dcName,
+	}
+
 	nodeToDcLock.Lock()
 	defer nodeToDcLock.Unlock()
 
-	for _, pod := range podList.Items {
-		dcToAdd := types.NamespacedName{
-			Namespace: pod.ObjectMeta.Namespace,
-			Name:      dcName,
-		}
+	removeDcFromNodeToDcMap(dcToAdd)
 
+	for _, pod := range podList.Items {
 		// Update node map
 
 		nodeName := pod.Spec.NodeName
+		if nodeName == "" {
+			// Not scheduled yet
+			continue
+		}
 
 		needToAdd := true
 		for _, dc := range nodeToDc[nodeName] {
@@ -129,7 +153,7 @@ func (rc *ReconciliationContext) updateDcMaps() error {
 func (rc *ReconciliationContext) calculateReconciliationActions() (reconcile.Result, error) {
 
 	rc.ReqLogger.Info("handler::calculateReconciliationActions")
-	if utils.IsPSPEnabled() {
+	if emm.IsEnabled(rc.Datacenter) {
 		if err := rc.updateDcMaps(); err != nil {
 			// We will not skip reconciliation if the map update failed
 			// return result.Error(err).Output()
@@ -145,6 +169,10 @@ func (rc *ReconciliationContext) calculateReconciliationActions() (reconcile.Res
 		return result.Error(err).Output()
 	}
 
+	if result := rc.CheckDeletionProtection(); result.Completed() {
+		return result.Output()
+	}
+
 	if result := rc.CheckHeadlessServices(); result.Completed() {
 		return result.Output()
 	}
@@ -159,6 +187,10 @@ func (rc *ReconciliationContext) calculateReconciliationActions() (reconcile.Res
 		}
 	}
 
+	if result := rc.CheckZoneRacks(); result.Completed() {
+		return result.Output()
+	}
+
 	if err := rc.CalculateRackInformation(); err != nil {
 		return result.Error(err).Output()
 	}
@@ -198,6 +230,16 @@ type ReconcileCassandraDatacenter struct {
 	// during reconciliation where we update the mappings for the watches.
 	// Putting it here allows us to get it to both places.
 	SecretWatches dynamicwatch.DynamicWatches
+
+	// NamespaceFilter selects the namespaces whose CassandraDatacenters are
+	// managed, and accessChecker makes sure the operator can manage them.
+	// Both are optional.
+	NamespaceFilter *watchnamespace.Filter
+	accessChecker   *watchnamespace.AccessChecker
+
+	// Shard selects the datacenters this operator manages when they are
+	// sharded between several deployments of the operator. Optional.
+	Shard *sharding.Shard
 }
 
 // Reconcile reads that state of the cluster for a Datacenter object
@@ -208,7 +250,12 @@ type ReconcileCassandraDatacenter struct {
 // if the returned error is non-nil or Result.Requeue is true,
 // otherwise upon completion it will remove the work from the queue.
 // See: https://godoc.org/sigs.k8s.io/controller-runtime/pkg/reconcile#Result
-func (r *ReconcileCassandraDatacenter) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+func (r *ReconcileCassandraDatacenter) Reconcile(request reconcile.Request) (res reconcile.Result, err error) {
+
+	// The datacenters of the other shards are left to their operators
+	if !r.Shard.Owns(request.Namespace, request.Name) {
+		return result.Done().Output()
+	}
 
 	startReconcile := time.Now()
 
@@ -218,15 +265,21 @@ func (r *ReconcileCassandraDatacenter) Reconcile(request reconcile.Request) (rec
 		// loopID is used to tie all events together that are spawned by the same reconciliation loop
 		WithValues("loopID", uuid.New().String())
 
+	var rc *ReconciliationContext
+
 	defer func() {
 		reconcileDuration := time.Since(startReconcile).Seconds()
 		logger.Info("Reconcile loop completed",
 			"duration", reconcileDuration)
+		opmetrics.ObserveReconcile(request.Namespace, request.Name, reconcileDuration, res.Requeue || res.RequeueAfter > 0, err)
+		if rc != nil && rc.Datacenter.GetDeletionTimestamp() == nil {
+			opmetrics.ObserveDatacenter(rc.Datacenter, reconcileDuration)
+		}
 	}()
 
 	logger.Info("======== handler::Reconcile has been called")
 
-	rc, err := CreateReconciliationContext(&request, r.client, r.scheme, r.recorder, r.SecretWatches, logger)
+	rc, err = CreateReconciliationContext(&request, r.client, r.scheme, r.recorder, r.SecretWatches, logger)
 
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -234,6 +287,8 @@ func (r *ReconcileCassandraDatacenter) Reconcile(request reconcile.Request) (rec
 			// Owned objects are automatically garbage collected.
 			// Return and don't requeue
 			logger.Info("CassandraDatacenter resource not found. Ignoring since object must be deleted.")
+			opmetrics.ForgetDatacenter(request.Namespace, request.Name)
+			statecache.Datacenters.Forget(request.NamespacedName)
 			return result.Done().Output()
 		}
 
@@ -242,6 +297,39 @@ func (r *ReconcileCassandraDatacenter) Reconcile(request reconcile.Request) (rec
 		return result.Error(err).Output()
 	}
 
+	// The deletion of a datacenter is processed even once its namespace is no
+	// longer selected, so that the finalizer does not block it
+	if rc.Datacenter.GetDeletionTimestamp() == nil {
+		if managed, err := r.NamespaceFilter.IsManaged(rc.Ctx, request.Namespace); err != nil {
+			logger.Error(err, "Failed to check whether the namespace is managed")
+			return result.Error(err).Output()
+		} else if !managed {
+			logger.Info("Ignoring the CassandraDatacenter since its namespace does not match " + watchnamespace.SelectorEnv)
+			return result.Done().Output()
+		}
+	}
+
+	opmetrics.ObserveReconciliationPaused(rc.Datacenter)
+	if rc.Datacenter.IsReconciliationPaused() {
+		logger.Info("Ending reconciliation early because the CassandraDatacenter is paused")
+		return rc.UpdateStatusWhilePaused().Output()
+	}
+
+	if recResult := rc.CheckReconciliationResumed(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	missing, err := r.accessChecker.MissingPermissions(rc.Ctx, request.Namespace)
+	if err != nil {
+		logger.Error(err, "Failed to check the permissions of the operator in the namespace")
+		return result.Error(err).Output()
+	}
+	if len(missing) > 0 {
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeWarning, events.MissingPermissions,
+			"The operator is missing permissions in namespace %s: %s", request.Namespace, strings.Join(missing, ", "))
+		return result.RequeueSoon(60).Output()
+	}
+
 	if err := rc.isValid(rc.Datacenter); err != nil {
 		logger.Error(err, "CassandraDatacenter resource is invalid")
 		rc.Recorder.Eventf(rc.Datacenter, "Warning", "ValidationFailed", err.Error())
@@ -266,7 +354,7 @@ func (r *ReconcileCassandraDatacenter) Reconcile(request reconcile.Request) (rec
 		return result.RequeueSoon(secs).Output()
 	}
 
-	res, err := rc.calculateReconciliationActions()
+	res, err = rc.calculateReconciliationActions()
 	if err != nil {
 		logger.Error(err, "calculateReconciliationActions returned an error")
 		rc.Recorder.Eventf(rc.Datacenter, "Warning", "ReconcileFailed", err.Error())
@@ -275,9 +363,9 @@ func (r *ReconcileCassandraDatacenter) Reconcile(request reconcile.Request) (rec
 }
 
 func (rc *ReconciliationContext) addFinalizer() error {
-	if len(rc.Datacenter.GetFinalizers()) < 1 && rc.Datacenter.GetDeletionTimestamp() == nil {
+	if !hasFinalizer(rc.Datacenter) && rc.Datacenter.GetDeletionTimestamp() == nil {
 		rc.ReqLogger.Info("Adding Finalizer for the CassandraDatacenter")
-		rc.Datacenter.SetFinalizers([]string{"finalizer.cassandra.datastax.com"})
+		rc.Datacenter.SetFinalizers(append(rc.Datacenter.GetFinalizers(), api.Finalizer))
 
 		// Update CR
 		err := rc.Client.Update(rc.Ctx, rc.Datacenter)
@@ -289,6 +377,27 @@ func (rc *ReconciliationContext) addFinalizer() error {
 	return nil
 }
 
+func hasFinalizer(dc *api.CassandraDatacenter) bool {
+	for _, finalizer := range dc.GetFinalizers() {
+		if finalizer == api.Finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFinalizer removes the finalizer of the operator and keeps the ones
+// of other controllers
+func removeFinalizer(dc *api.CassandraDatacenter) {
+	var finalizers []string
+	for _, finalizer := range dc.GetFinalizers() {
+		if finalizer != api.Finalizer {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	dc.SetFinalizers(finalizers)
+}
+
 func (rc *ReconciliationContext) isValid(dc *api.CassandraDatacenter) error {
 	var errs []error = []error{}
 
@@ -326,13 +435,21 @@ func (rc *ReconciliationContext) isValid(dc *api.CassandraDatacenter) error {
 }
 
 // NewReconciler returns a new reconcile.Reconciler
-func NewReconciler(mgr manager.Manager) reconcile.Reconciler {
+//
+// The client of the manager reads the datacenters, pods and secrets from the
+// informer cache, so the reconciliations do not call the kube-apiserver for
+// them. Only its writes, and the reads of the orphan sweeper through the API
+// reader of the manager, go to the kube-apiserver.
+func NewReconciler(mgr manager.Manager, selector labels.Selector, shard *sharding.Shard) reconcile.Reconciler {
 	client := mgr.GetClient()
 	dynamicWatches := dynamicwatch.NewDynamicSecretWatches(client)
 	return &ReconcileCassandraDatacenter{
-		client:        mgr.GetClient(),
-		scheme:        mgr.GetScheme(),
-		recorder:      mgr.GetEventRecorderFor("cass-operator"),
-		SecretWatches: dynamicWatches,
+		client:          mgr.GetClient(),
+		scheme:          mgr.GetScheme(),
+		recorder:        mgr.GetEventRecorderFor("cass-operator"),
+		SecretWatches:   dynamicWatches,
+		NamespaceFilter: watchnamespace.NewFilter(client, selector),
+		accessChecker:   watchnamespace.NewAccessChecker(client),
+		Shard:           shard,
 	}
 }
@@ -26,6 +26,7 @@ import (
 
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
 )
 
 func TestCalculateReconciliationActions(t *testing.T) {
@@ -392,3 +393,57 @@ func TestReconcile_CassandraDatacenterToBeDeleted(t *testing.T) {
 		t.Error("Reconcile did not return an empty result.")
 	}
 }
+
+func TestAddFinalizer_KeepsOtherFinalizers(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.SetFinalizers([]string{"other.example.com"})
+
+	err := rc.addFinalizer()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"other.example.com", api.Finalizer}, rc.Datacenter.GetFinalizers())
+
+	removeFinalizer(rc.Datacenter)
+	assert.Equal(t, []string{"other.example.com"}, rc.Datacenter.GetFinalizers())
+}
+
+func TestUpdateDcMaps(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dcKey := types.NamespacedName{Namespace: dc.Namespace, Name: dc.Name}
+	defer rc.RemoveDcFromNodeToDcMap(dcKey)
+
+	pod := makeReadyPod("default-0")
+	pod.Namespace = dc.Namespace
+	pod.Labels = map[string]string{
+		oplabels.ManagedByLabel: oplabels.ManagedByLabelValue,
+		api.DatacenterLabel:     dc.Name,
+	}
+	pod.Spec.NodeName = "node1"
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+
+	// The map is read while other datacenters update it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			DatacentersForNode("node1")
+		}
+	}()
+	assert.NoError(t, rc.updateDcMaps())
+	<-done
+	assert.Equal(t, []types.NamespacedName{dcKey}, DatacentersForNode("node1"))
+
+	// The node the pod moved away from is forgotten
+	pod.Spec.NodeName = "node2"
+	assert.NoError(t, rc.Client.Update(rc.Ctx, pod))
+	assert.NoError(t, rc.updateDcMaps())
+	assert.Empty(t, DatacentersForNode("node1"))
+	assert.Equal(t, []types.NamespacedName{dcKey}, DatacentersForNode("node2"))
+
+	rc.RemoveDcFromNodeToDcMap(dcKey)
+	assert.Empty(t, DatacentersForNode("node2"))
+}
@@ -0,0 +1,51 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+)
+
+// The reason of the Updating condition while a rack update rolls out a
+// change of the config, so the end of the rollout gets its own event
+const configRolloutReason = "ConfigRollout"
+
+func podEventAnnotations(pod *corev1.Pod) map[string]string {
+	return map[string]string{
+		events.PodAnnotation:  pod.Name,
+		events.RackAnnotation: pod.Labels[api.RackLabel],
+	}
+}
+
+func rackEventAnnotations(rackName string) map[string]string {
+	return map[string]string{
+		events.RackAnnotation: rackName,
+	}
+}
+
+// recordPodEventf records an event of the datacenter about one of its pods
+func (rc *ReconciliationContext) recordPodEventf(pod *corev1.Pod, eventtype, reason, messageFmt string, args ...interface{}) {
+	rc.Recorder.AnnotatedEventf(rc.Datacenter, podEventAnnotations(pod), eventtype, reason, messageFmt, args...)
+}
+
+// recordRackEventf records an event of the datacenter about one of its racks
+func (rc *ReconciliationContext) recordRackEventf(rackName string, eventtype, reason, messageFmt string, args ...interface{}) {
+	rc.Recorder.AnnotatedEventf(rc.Datacenter, rackEventAnnotations(rackName), eventtype, reason, messageFmt, args...)
+}
+
+// setUpdatingCondition sets the Updating condition for an update of a rack,
+// with the config rollout reason when the update changes the config. A config
+// change keeps the reason until the update completes, even when an update of
+// the other racks started first, as an update without a reason keeps the
+// current one.
+func (rc *ReconciliationContext) setUpdatingCondition(configChanged bool) bool {
+	if !configChanged {
+		return rc.setCondition(api.NewDatacenterCondition(api.DatacenterUpdating, corev1.ConditionTrue))
+	}
+	return rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterUpdating, corev1.ConditionTrue,
+		configRolloutReason, "Rolling out a change of the config"))
+}
@@ -0,0 +1,108 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+)
+
+// recordedEvents drains the events the fake recorder of rc got
+func recordedEvents(rc *ReconciliationContext) []string {
+	var recorded []string
+	recorder := rc.Recorder.(*record.FakeRecorder)
+	for {
+		select {
+		case event := <-recorder.Events:
+			recorded = append(recorded, event)
+		default:
+			return recorded
+		}
+	}
+}
+
+func hasEvent(recorded []string, reason string) bool {
+	for _, event := range recorded {
+		if strings.Contains(event, " "+reason+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSetUpdatingCondition(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	assert.True(t, rc.setUpdatingCondition(false))
+	started, _ := dc.GetCondition(api.DatacenterUpdating)
+	assert.Equal(t, "Updating", started.Reason)
+
+	assert.True(t, rc.setUpdatingCondition(true), "a config change while updating sets the reason")
+	condition, _ := dc.GetCondition(api.DatacenterUpdating)
+	assert.Equal(t, configRolloutReason, condition.Reason)
+	assert.Equal(t, started.LastTransitionTime, condition.LastTransitionTime)
+
+	assert.False(t, rc.setUpdatingCondition(true))
+	assert.False(t, rc.setUpdatingCondition(false), "the config rollout reason is kept")
+	condition, _ = dc.GetCondition(api.DatacenterUpdating)
+	assert.Equal(t, configRolloutReason, condition.Reason)
+}
+
+func TestCheckClearActionConditions_ConfigRolloutFinished(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	rc.setUpdatingCondition(true)
+	recordedEvents(rc)
+
+	assert.True(t, rc.CheckClearActionConditions().Completed())
+	assert.Equal(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterUpdating))
+	assert.True(t, hasEvent(recordedEvents(rc), events.ConfigRolloutFinished))
+
+	rc.setUpdatingCondition(false)
+	assert.True(t, rc.CheckClearActionConditions().Completed())
+	assert.False(t, hasEvent(recordedEvents(rc), events.ConfigRolloutFinished),
+		"an update that does not change the config is not a config rollout")
+}
+
+func TestCheckClearActionConditions_ConfigRolloutFinishedAfterPatch(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.setUpdatingCondition(true)
+	recordedEvents(rc)
+
+	// The datacenter is not known to the client, so its status cannot be patched
+	rc.Client = fake.NewFakeClient()
+	assert.True(t, rc.CheckClearActionConditions().Completed())
+	assert.False(t, hasEvent(recordedEvents(rc), events.ConfigRolloutFinished),
+		"the rollout is not finished until its condition is cleared")
+}
+
+func TestRecordPodEventf(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	pod := makeReadyPod("pod-a")
+	pod.Labels = map[string]string{api.RackLabel: "rack1"}
+	assert.Equal(t, map[string]string{
+		events.PodAnnotation:  "pod-a",
+		events.RackAnnotation: "rack1",
+	}, podEventAnnotations(pod))
+
+	rc.recordPodEventf(pod, corev1.EventTypeNormal, events.DecommissionStarted,
+		"Started decommissioning the node of pod %s", pod.Name)
+	assert.Equal(t, []string{"Normal DecommissionStarted Started decommissioning the node of pod pod-a"}, recordedEvents(rc))
+}
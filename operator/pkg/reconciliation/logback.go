@@ -0,0 +1,108 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+// jsonEscape escapes the output of a logback pattern as the content of a JSON
+// string: its backslashes, quotes, line breaks and tabs
+func jsonEscape(pattern string) string {
+	return fmt.Sprintf(`%%replace(%%replace(%%replace(%s){'([\\"])','\\$1'}){'\r?\n','\\n'}){'\t','\\t'}`, pattern)
+}
+
+// Patterns of the logs of Cassandra. The text pattern is the one of the
+// logback.xml of Cassandra. The JSON pattern writes one object per line, and
+// %nopex keeps logback from appending the stack traces after the object.
+var (
+	logbackTextPattern = `%-5level [%thread] %date{ISO8601} %F:%L - %msg%n`
+	logbackJSONPattern = fmt.Sprintf(`{"timestamp":"%%date{yyyy-MM-dd'T'HH:mm:ss.SSSXXX}","level":"%%level",`+
+		`"thread":"%s","logger":"%%logger","message":"%s"}%%nopex%%n`, jsonEscape("%thread"), jsonEscape("%msg%ex"))
+)
+
+// logbackPattern returns the pattern of the log format of the Datacenter
+func logbackPattern(dc *api.CassandraDatacenter) string {
+	if dc.GetLogFormat() == api.LogFormatJSON {
+		return logbackJSONPattern
+	}
+	return logbackTextPattern
+}
+
+// newLogbackXML renders the logback.xml of the nodes, with the appenders of
+// the logback.xml of Cassandra: the system.log the server-system-logger
+// sidecar tails, the debug.log and the standard output of the container. The
+// levels of the loggers are included from the ConfigMap of the datacenter,
+// which logback rescans when the kubelet updates it.
+func newLogbackXML(dc *api.CassandraDatacenter) string {
+	encoder := fmt.Sprintf("<encoder><pattern>%s</pattern></encoder>", logbackPattern(dc))
+	return `<configuration scan="true" scanPeriod="60 seconds">
+  <jmxConfigurator />
+  <appender name="SYSTEMLOG" class="ch.qos.logback.core.rolling.RollingFileAppender">
+    <filter class="ch.qos.logback.classic.filter.ThresholdFilter"><level>INFO</level></filter>
+    <file>${cassandra.logdir}/system.log</file>
+    <rollingPolicy class="ch.qos.logback.core.rolling.SizeAndTimeBasedRollingPolicy">
+      <fileNamePattern>${cassandra.logdir}/system.log.%d{yyyy-MM-dd}.%i.zip</fileNamePattern>
+      <maxFileSize>50MB</maxFileSize>
+      <maxHistory>7</maxHistory>
+      <totalSizeCap>5GB</totalSizeCap>
+    </rollingPolicy>
+    ` + encoder + `
+  </appender>
+  <appender name="DEBUGLOG" class="ch.qos.logback.core.rolling.RollingFileAppender">
+    <file>${cassandra.logdir}/debug.log</file>
+    <rollingPolicy class="ch.qos.logback.core.rolling.SizeAndTimeBasedRollingPolicy">
+      <fileNamePattern>${cassandra.logdir}/debug.log.%d{yyyy-MM-dd}.%i.zip</fileNamePattern>
+      <maxFileSize>50MB</maxFileSize>
+      <maxHistory>7</maxHistory>
+      <totalSizeCap>5GB</totalSizeCap>
+    </rollingPolicy>
+    ` + encoder + `
+  </appender>
+  <appender name="ASYNCDEBUGLOG" class="ch.qos.logback.classic.AsyncAppender">
+    <queueSize>1024</queueSize>
+    <discardingThreshold>0</discardingThreshold>
+    <includeCallerData>true</includeCallerData>
+    <appender-ref ref="DEBUGLOG" />
+  </appender>
+  <appender name="STDOUT" class="ch.qos.logback.core.ConsoleAppender">
+    <filter class="ch.qos.logback.classic.filter.ThresholdFilter"><level>INFO</level></filter>
+    ` + encoder + `
+  </appender>
+  <root level="INFO">
+    <appender-ref ref="SYSTEMLOG" />
+    <appender-ref ref="STDOUT" />
+    <appender-ref ref="ASYNCDEBUGLOG" />
+  </root>
+  <logger name="org.apache.cassandra" level="DEBUG" />
+  <include optional="true" file="` + api.LogbackConfigDir + "/" + api.LogbackLevelsFile + `" />
+</configuration>
+`
+}
+
+// newLogbackLevelsXML renders the levels of the loggers of the datacenter, in
+// the format of the files logback.xml includes
+func newLogbackLevelsXML(dc *api.CassandraDatacenter) string {
+	levels := dc.GetLoggingLevels()
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		if name != api.RootLoggerName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("<included>\n")
+	fmt.Fprintf(&b, "  <root level=\"%s\" />\n", levels[api.RootLoggerName])
+	for _, name := range names {
+		fmt.Fprintf(&b, "  <logger name=\"%s\" level=\"%s\" />\n", name, levels[name])
+	}
+	b.WriteString("</included>\n")
+	return b.String()
+}
@@ -0,0 +1,127 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestNewLogbackXML(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+			LogFormat:     api.LogFormatJSON,
+		},
+	}
+
+	logback := newLogbackXML(dc)
+	var config struct {
+		Appenders []struct {
+			Name    string `xml:"name,attr"`
+			Pattern string `xml:"encoder>pattern"`
+		} `xml:"appender"`
+	}
+	assert.NoError(t, xml.Unmarshal([]byte(logback), &config))
+
+	patterns := map[string]string{}
+	for _, appender := range config.Appenders {
+		patterns[appender.Name] = appender.Pattern
+	}
+	for _, name := range []string{"SYSTEMLOG", "DEBUGLOG", "STDOUT"} {
+		pattern := patterns[name]
+		assert.True(t, strings.HasPrefix(pattern, `{"timestamp":"%date{`), "appender %s should log in JSON, got %s", name, pattern)
+		assert.Contains(t, pattern, `"message":"%replace(%replace(%replace(%msg%ex){'([\\"])','\\$1'}){'\r?\n','\\n'}){'\t','\\t'}"`)
+		assert.True(t, strings.HasSuffix(pattern, "}%nopex%n"))
+	}
+
+	dc.Spec.LogFormat = api.LogFormatText
+	assert.Contains(t, newLogbackXML(dc), "<pattern>"+logbackTextPattern+"</pattern>")
+	assert.Contains(t, newLogbackXML(dc), `<include optional="true" file="/opt/logback-config/logback-levels.xml" />`)
+}
+
+func TestNewLogbackLevelsXML(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+		},
+	}
+
+	assert.Equal(t, `<included>
+  <root level="INFO" />
+  <logger name="org.apache.cassandra" level="DEBUG" />
+</included>
+`, newLogbackLevelsXML(dc))
+
+	dc.Spec.LoggingConfig = &api.LoggingConfig{
+		RootLevel: "WARN",
+		Loggers: map[string]string{
+			"org.apache.cassandra.gms": "TRACE",
+			"org.apache.cassandra":     "INFO",
+		},
+	}
+	assert.Equal(t, `<included>
+  <root level="WARN" />
+  <logger name="org.apache.cassandra" level="INFO" />
+  <logger name="org.apache.cassandra.gms" level="TRACE" />
+</included>
+`, newLogbackLevelsXML(dc))
+}
+
+func TestLogbackInitContainer(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:   "bob",
+			ServerType:    "cassandra",
+			ServerVersion: "4.0.0",
+		},
+	}
+
+	podTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Len(t, podTemplateSpec.Spec.InitContainers, 1)
+
+	dc.Spec.LogFormat = api.LogFormatJSON
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	initContainers := podTemplateSpec.Spec.InitContainers
+	assert.Len(t, initContainers, 2)
+	assert.Equal(t, LogbackContainerName, initContainers[1].Name)
+	assert.Contains(t, initContainers[1].Env, corev1.EnvVar{Name: "LOGBACK_XML", Value: newLogbackXML(dc)})
+	assert.Contains(t, initContainers[1].VolumeMounts, corev1.VolumeMount{Name: "server-config", MountPath: "/config"})
+
+	// The logging levels are mounted from their ConfigMap, and are not part of
+	// the pod template
+	dc.Spec.LogFormat = ""
+	dc.Spec.LoggingConfig = &api.LoggingConfig{RootLevel: "DEBUG"}
+	podTemplateSpec, err = buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Len(t, podTemplateSpec.Spec.InitContainers, 2)
+	assert.Contains(t, podTemplateSpec.Spec.Volumes, corev1.Volume{
+		Name: LogbackConfigVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "bob--logback-config"},
+			},
+		},
+	})
+	cassandra := podTemplateSpec.Spec.Containers[0]
+	assert.Equal(t, CassandraContainerName, cassandra.Name)
+	assert.Contains(t, cassandra.VolumeMounts, corev1.VolumeMount{Name: LogbackConfigVolumeName, MountPath: api.LogbackConfigDir})
+
+	dc.Spec.LoggingConfig.RootLevel = "WARN"
+	otherTemplateSpec, err := buildPodTemplateSpec(dc, map[string]string{}, "testrack")
+	assert.NoError(t, err, "should not have gotten error when building podTemplateSpec")
+	assert.Equal(t, podTemplateSpec, otherTemplateSpec)
+}
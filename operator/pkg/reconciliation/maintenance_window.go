@@ -0,0 +1,86 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+)
+
+// The longest a reconciliation waiting for the maintenance window is requeued
+// for, so that changes to the window are picked up
+const maintenanceWindowMaxRequeueSeconds = 300
+
+// waitForMaintenanceWindow returns whether a disruptive action, such as a
+// rolling restart, must wait for the maintenance window of the datacenter to
+// open. Outside the window it sets the MaintenancePending condition and has
+// the reconciliation requeued for when the window opens. Only the action
+// waits: the caller skips it, and the rest of the datacenter keeps being
+// reconciled.
+func (rc *ReconciliationContext) waitForMaintenanceWindow(action string) (bool, error) {
+	dc := rc.Datacenter
+	logger := rc.ReqLogger
+	window := dc.Spec.MaintenanceWindow
+
+	if window == nil {
+		return false, rc.clearMaintenancePending()
+	}
+
+	now := time.Now().UTC()
+	open, opensAt, err := window.IsOpen(now)
+	if err != nil {
+		logger.Error(err, "invalid maintenance window schedule", "schedule", window.Schedule)
+		return false, err
+	}
+	if open {
+		return false, rc.clearMaintenancePending()
+	}
+
+	logger.Info("waiting for the maintenance window", "action", action, "opensAt", opensAt)
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	message := fmt.Sprintf("The %s waits for the maintenance window opening at %s",
+		action, opensAt.Format(time.RFC3339))
+	pending := rc.conditionTransitions(api.DatacenterMaintenancePending, corev1.ConditionTrue)
+	if rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterMaintenancePending, corev1.ConditionTrue,
+		"OutsideMaintenanceWindow", message)) {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			logger.Error(err, "error patching datacenter status for maintenance pending")
+			return false, err
+		}
+	}
+	if pending {
+		rc.Recorder.Event(dc, corev1.EventTypeNormal, events.WaitingForMaintenanceWindow, message)
+	}
+
+	secs := int(opensAt.Sub(now).Seconds()) + 1
+	if secs > maintenanceWindowMaxRequeueSeconds {
+		secs = maintenanceWindowMaxRequeueSeconds
+	}
+	rc.requeueAfter(secs)
+	return true, nil
+}
+
+// clearMaintenancePending sets the MaintenancePending condition back to False
+// once a waiting action may proceed
+func (rc *ReconciliationContext) clearMaintenancePending() error {
+	dc := rc.Datacenter
+	if dc.GetConditionStatus(api.DatacenterMaintenancePending) != corev1.ConditionTrue {
+		return nil
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	rc.setCondition(api.NewDatacenterCondition(api.DatacenterMaintenancePending, corev1.ConditionFalse))
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status for maintenance pending")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestWaitForMaintenanceWindow_NoWindow(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	wait, err := rc.waitForMaintenanceWindow("rolling restart")
+	assert.NoError(t, err)
+	assert.False(t, wait)
+	_, hasCondition := rc.Datacenter.GetCondition(api.DatacenterMaintenancePending)
+	assert.False(t, hasCondition)
+}
+
+func TestWaitForMaintenanceWindow_Closed(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	// Only open for a minute on the first of January
+	rc.Datacenter.Spec.MaintenanceWindow = &api.MaintenanceWindow{
+		Schedule: "0 0 1 1 *",
+		Duration: metav1.Duration{Duration: time.Minute},
+	}
+
+	wait, err := rc.waitForMaintenanceWindow("rolling restart")
+	assert.NoError(t, err)
+	assert.True(t, wait)
+	assert.True(t, rc.requeueSeconds > 0)
+	assert.True(t, rc.requeueSeconds <= maintenanceWindowMaxRequeueSeconds)
+
+	condition, _ := rc.Datacenter.GetCondition(api.DatacenterMaintenancePending)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Equal(t, "OutsideMaintenanceWindow", condition.Reason)
+	assert.Contains(t, condition.Message, "rolling restart")
+
+	// Once the window is open, the action proceeds and the condition is cleared
+	rc.Datacenter.Spec.MaintenanceWindow = &api.MaintenanceWindow{
+		Schedule: "* * * * *",
+		Duration: metav1.Duration{Duration: time.Hour},
+	}
+	wait, err = rc.waitForMaintenanceWindow("rolling restart")
+	assert.NoError(t, err)
+	assert.False(t, wait)
+	assert.Equal(t, corev1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterMaintenancePending))
+}
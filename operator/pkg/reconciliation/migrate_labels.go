@@ -0,0 +1,104 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+)
+
+func usesDefunctPvcManagedByLabel(sts *appsv1.StatefulSet) bool {
+	for _, pvc := range sts.Spec.VolumeClaimTemplates {
+		if pvc.Labels[oplabels.ManagedByLabel] == oplabels.ManagedByLabelDefunctValue {
+			return true
+		}
+	}
+	return false
+}
+
+// When Cass Operator was released, it accidentally used the managed-by label
+// value of "cassandra-operator" (oplabels.ManagedByLabelDefunctValue), which
+// the datacenters created with version 1.1.0 or earlier still carry on their
+// PVCs, services and the volume claim templates of their statefulsets.
+// CheckManagedByLabelMigration moves them over to the current value, so that
+// the rest of the operator only has to deal with it. The statefulsets are
+// recreated without their pods, as their volume claim templates cannot be
+// updated: CheckRackCreation recreates them and they adopt the pods again.
+func (rc *ReconciliationContext) CheckManagedByLabelMigration() result.ReconcileResult {
+	dc := rc.Datacenter
+
+	pvcList := &corev1.PersistentVolumeClaimList{}
+	pvcSelector := map[string]string{
+		api.DatacenterLabel:     dc.Name,
+		oplabels.ManagedByLabel: oplabels.ManagedByLabelDefunctValue,
+	}
+	if err := rc.Client.List(rc.Ctx, pvcList, client.InNamespace(dc.Namespace), client.MatchingLabels(pvcSelector)); err != nil {
+		rc.ReqLogger.Error(err, "error listing the PVCs with the defunct managed-by label")
+		return result.Error(err)
+	}
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		patch := client.MergeFrom(pvc.DeepCopy())
+		rc.ReqLogger.Info("Migrating the managed-by label of PVC", "pvc", pvc.Name)
+		oplabels.AddManagedByLabel(pvc.Labels)
+		if err := rc.Client.Patch(rc.Ctx, pvc, patch); err != nil {
+			rc.ReqLogger.Error(err, "error migrating the managed-by label of PVC", "pvc", pvc.Name)
+			return result.Error(err)
+		}
+	}
+
+	// The services of the cluster are shared by its datacenters
+	serviceList := &corev1.ServiceList{}
+	serviceSelector := map[string]string{
+		api.ClusterLabel:        dc.Spec.ClusterName,
+		oplabels.ManagedByLabel: oplabels.ManagedByLabelDefunctValue,
+	}
+	if err := rc.Client.List(rc.Ctx, serviceList, client.InNamespace(dc.Namespace), client.MatchingLabels(serviceSelector)); err != nil {
+		rc.ReqLogger.Error(err, "error listing the services with the defunct managed-by label")
+		return result.Error(err)
+	}
+	for i := range serviceList.Items {
+		service := &serviceList.Items[i]
+		patch := client.MergeFrom(service.DeepCopy())
+		rc.ReqLogger.Info("Migrating the managed-by label of service", "service", service.Name)
+		oplabels.AddManagedByLabel(service.Labels)
+		if err := rc.Client.Patch(rc.Ctx, service, patch); err != nil {
+			rc.ReqLogger.Error(err, "error migrating the managed-by label of service", "service", service.Name)
+			return result.Error(err)
+		}
+	}
+
+	for idx := range rc.desiredRackInformation {
+		rackName := rc.desiredRackInformation[idx].RackName
+		statefulSet := rc.statefulSets[idx]
+		if statefulSet == nil || !usesDefunctPvcManagedByLabel(statefulSet) {
+			continue
+		}
+
+		if statefulSet.GetDeletionTimestamp() != nil {
+			return result.RequeueSoon(2)
+		}
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.RecreatingStatefulSet,
+			"Recreating StatefulSet %s of rack %s to migrate the managed-by label of its volume claim templates",
+			statefulSet.Name, rackName)
+
+		rc.ReqLogger.Info("Deleting the statefulset without its pods to recreate it",
+			"statefulSet", statefulSet.Name)
+		if err := rc.Client.Delete(rc.Ctx, statefulSet, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil {
+			rc.ReqLogger.Error(err, "Failed to delete the statefulset", "statefulSet", statefulSet.Name)
+			return result.Error(err)
+		}
+		return result.RequeueSoon(2)
+	}
+
+	return result.Continue()
+}
@@ -0,0 +1,63 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+)
+
+func TestCheckManagedByLabelMigration(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	defunctLabels := dc.GetDatacenterLabels()
+	defunctLabels[oplabels.ManagedByLabel] = oplabels.ManagedByLabelDefunctValue
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "server-data-pod-0", Namespace: dc.Namespace, Labels: defunctLabels},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: dc.GetSeedServiceName(), Namespace: dc.Namespace, Labels: dc.GetClusterLabels()},
+	}
+	service.Labels[oplabels.ManagedByLabel] = oplabels.ManagedByLabelDefunctValue
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pvc))
+	assert.NoError(t, rc.Client.Create(rc.Ctx, service))
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("default", dc, 1)
+	assert.NoError(t, err)
+	for i := range statefulSet.Spec.VolumeClaimTemplates {
+		statefulSet.Spec.VolumeClaimTemplates[i].Labels[oplabels.ManagedByLabel] = oplabels.ManagedByLabelDefunctValue
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, statefulSet))
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 1}}
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+
+	recResult := rc.CheckManagedByLabelMigration()
+	assert.True(t, recResult.Completed(), "the statefulset is recreated")
+
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, pvc))
+	assert.Equal(t, oplabels.ManagedByLabelValue, pvc.Labels[oplabels.ManagedByLabel])
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, service))
+	assert.Equal(t, oplabels.ManagedByLabelValue, service.Labels[oplabels.ManagedByLabel])
+
+	err = rc.Client.Get(rc.Ctx, types.NamespacedName{Name: statefulSet.Name, Namespace: statefulSet.Namespace}, &appsv1.StatefulSet{})
+	assert.True(t, errors.IsNotFound(err), "the statefulset is deleted without its pods")
+
+	// The recreated statefulset uses the current value
+	recreated, err := newStatefulSetForCassandraDatacenter("default", dc, 1)
+	assert.NoError(t, err)
+	rc.statefulSets = []*appsv1.StatefulSet{recreated}
+	assert.False(t, rc.CheckManagedByLabelMigration().Completed())
+	assert.False(t, usesDefunctPvcManagedByLabel(recreated))
+}
@@ -0,0 +1,63 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+// rackStatus returns the progress of the rollout of a rack to its statefulset.
+// The updated replicas of a statefulset whose controller has not caught up
+// with its latest generation are those of a previous revision, so none count.
+func rackStatus(rackName string, statefulSet *appsv1.StatefulSet, podsOfRack []*corev1.Pod) api.RackStatus {
+	status := api.RackStatus{Name: rackName}
+	if statefulSet != nil {
+		if statefulSet.Spec.Replicas != nil {
+			status.Replicas = *statefulSet.Spec.Replicas
+		}
+		if statefulSet.Status.ObservedGeneration >= statefulSet.Generation {
+			status.UpdatedReplicas = statefulSet.Status.UpdatedReplicas
+		}
+		status.ReadyReplicas = statefulSet.Status.ReadyReplicas
+	}
+	for _, pod := range podsOfRack {
+		if isServerStarted(pod) {
+			status.StartedNodes++
+		}
+	}
+	return status
+}
+
+// UpdateRackStatuses records the progress of each rack in the status of the
+// datacenter, so that tools can tell how far the rollout of the latest spec
+// went
+func (rc *ReconciliationContext) UpdateRackStatuses() result.ReconcileResult {
+	dc := rc.Datacenter
+
+	var statuses []api.RackStatus
+	for idx, rackInfo := range rc.desiredRackInformation {
+		podsOfRack := FilterPodListByLabels(rc.dcPods, map[string]string{api.RackLabel: rackInfo.RackName})
+		statuses = append(statuses, rackStatus(rackInfo.RackName, rc.statefulSets[idx], podsOfRack))
+	}
+
+	if reflect.DeepEqual(statuses, dc.Status.Racks) {
+		return result.Continue()
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.Racks = statuses
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error updating the progress of the racks")
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
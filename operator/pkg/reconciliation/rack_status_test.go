@@ -0,0 +1,65 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func makeRackPod(name, rackName, nodeState string) *corev1.Pod {
+	pod := makeReadyPod(name)
+	pod.Labels = map[string]string{api.RackLabel: rackName, api.CassNodeState: nodeState}
+	return pod
+}
+
+func TestUpdateRackStatuses(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	replicas := int32(2)
+	rc.desiredRackInformation = []*RackInformation{
+		{RackName: "r1", NodeCount: 2},
+		{RackName: "r2", NodeCount: 2},
+	}
+	rc.statefulSets = []*appsv1.StatefulSet{
+		{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+			Status:     appsv1.StatefulSetStatus{ObservedGeneration: 2, UpdatedReplicas: 2, ReadyReplicas: 2},
+		},
+		{
+			// The controller has not caught up with the latest revision of the rack
+			ObjectMeta: metav1.ObjectMeta{Generation: 3},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+			Status:     appsv1.StatefulSetStatus{ObservedGeneration: 2, UpdatedReplicas: 2, ReadyReplicas: 1},
+		},
+	}
+	rc.dcPods = []*corev1.Pod{
+		makeRackPod("r1-0", "r1", stateStarted),
+		makeRackPod("r1-1", "r1", stateStartedNotReady),
+		makeRackPod("r2-0", "r2", stateStarted),
+		makeRackPod("r2-1", "r2", stateReadyToStart),
+	}
+
+	assert.False(t, rc.UpdateRackStatuses().Completed())
+
+	expected := []api.RackStatus{
+		{Name: "r1", Replicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2, StartedNodes: 2},
+		{Name: "r2", Replicas: 2, UpdatedReplicas: 0, ReadyReplicas: 1, StartedNodes: 1},
+	}
+	assert.Equal(t, expected, rc.Datacenter.Status.Racks)
+
+	dc := &api.CassandraDatacenter{}
+	key := types.NamespacedName{Name: rc.Datacenter.Name, Namespace: rc.Datacenter.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, dc))
+	assert.Equal(t, expected, dc.Status.Racks)
+}
@@ -0,0 +1,178 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+)
+
+// medusaIniKey is the key of the configuration of Medusa, in the config
+// secret of spec.backupAgent and in the secret the operator renders it to
+const medusaIniKey = "medusa.ini"
+
+// iniSetting is a key of a section of an INI file
+type iniSetting struct {
+	section, key, value string
+}
+
+// backupAgentSettings are the settings of medusa.ini the backup agent needs
+// in the pods. Medusa takes the snapshots through the management API, and
+// reads the CQL credentials from the environment.
+var backupAgentSettings = []iniSetting{
+	{"cassandra", "config_file", "/etc/cassandra/cassandra.yaml"},
+	{"cassandra", "use_sudo", "False"},
+	{"grpc", "enabled", "1"},
+	{"kubernetes", "enabled", "1"},
+	{"kubernetes", "cassandra_url", "http://127.0.0.1:8080/api/v0/ops/node/snapshots"},
+	{"kubernetes", "use_mgmt_api", "1"},
+}
+
+// renderIni sets the settings in the content of an INI file, replacing the
+// values the file has for them and keeping its other sections and keys
+func renderIni(content string, settings []iniSetting) string {
+	pending := map[string][]iniSetting{}
+	var sections []string
+	for _, setting := range settings {
+		if _, ok := pending[setting.section]; !ok {
+			sections = append(sections, setting.section)
+		}
+		pending[setting.section] = append(pending[setting.section], setting)
+	}
+
+	var out []string
+	// The missing keys of a section go after its last line that is not blank
+	flush := func(section string) {
+		end := len(out)
+		for end > 0 && strings.TrimSpace(out[end-1]) == "" {
+			end--
+		}
+		var lines []string
+		for _, setting := range pending[section] {
+			lines = append(lines, fmt.Sprintf("%s = %s", setting.key, setting.value))
+		}
+		out = append(out[:end:end], append(lines, out[end:]...)...)
+		delete(pending, section)
+	}
+
+	section := ""
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			flush(section)
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			out = append(out, line)
+			continue
+		}
+
+		key := trimmed
+		if i := strings.IndexAny(trimmed, "=:"); i >= 0 {
+			key = strings.TrimSpace(trimmed[:i])
+		}
+		replaced := false
+		for i, setting := range pending[section] {
+			if strings.EqualFold(setting.key, key) {
+				out = append(out, fmt.Sprintf("%s = %s", setting.key, setting.value))
+				pending[section] = append(pending[section][:i:i], pending[section][i+1:]...)
+				replaced = true
+				break
+			}
+		}
+		if !replaced && (line != "" || len(out) > 0) {
+			out = append(out, line)
+		}
+	}
+	flush(section)
+
+	for _, section := range sections {
+		if _, ok := pending[section]; ok {
+			out = append(out, "", fmt.Sprintf("[%s]", section))
+			flush(section)
+		}
+	}
+
+	return strings.TrimLeft(strings.Join(out, "\n"), "\n") + "\n"
+}
+
+// CheckBackupAgentConfig renders the medusa.ini of the config secret of
+// spec.backupAgent to the secret mounted in the backup agent, and records
+// its hash in the datacenter so that the pods are restarted when it changes
+func (rc *ReconciliationContext) CheckBackupAgentConfig() result.ReconcileResult {
+	dc := rc.Datacenter
+	config := dc.Spec.BackupAgent
+	if config == nil {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_backup_agent::CheckBackupAgentConfig")
+
+	userSecret, err := rc.retrieveSecret(types.NamespacedName{Name: config.ConfigSecret, Namespace: dc.Namespace})
+	if err != nil {
+		rc.ReqLogger.Error(err, "failed to get the config secret of the backup agent", "secret", config.ConfigSecret)
+		return result.Error(err)
+	}
+	userIni, ok := userSecret.Data[medusaIniKey]
+	if !ok {
+		return result.Error(fmt.Errorf("invalid config secret %s of the backup agent: %s is required", userSecret.Name, medusaIniKey))
+	}
+	medusaIni := []byte(renderIni(string(userIni), backupAgentSettings))
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: dc.GetBackupAgentConfigSecretName(), Namespace: dc.Namespace}
+	err = rc.Client.Get(rc.Ctx, key, secret)
+	if errors.IsNotFound(err) {
+		labels := dc.GetDatacenterLabels()
+		oplabels.AddManagedByLabel(labels)
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    labels,
+			},
+			Data: map[string][]byte{medusaIniKey: medusaIni},
+		}
+		addAdditionalMetadata(dc, secret)
+		if err := rc.SetDatacenterAsOwner(secret); err != nil {
+			return result.Error(err)
+		}
+		if err := rc.createResource(secret); err != nil {
+			return result.Error(err)
+		}
+	} else if err != nil {
+		return result.Error(err)
+	} else if patch := client.MergeFrom(secret.DeepCopy()); updateAdditionalMetadata(dc, secret) || string(secret.Data[medusaIniKey]) != string(medusaIni) {
+		rc.ReqLogger.Info("updating the configuration of the backup agent", "secret", key.Name)
+		secret.Data = map[string][]byte{medusaIniKey: medusaIni}
+		if err := rc.Client.Patch(rc.Ctx, secret, patch); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	configHash := fmt.Sprintf("%x", sha256.Sum256(medusaIni))
+	if dc.Annotations[api.BackupAgentConfigHashAnnotation] == configHash {
+		return result.Continue()
+	}
+
+	patch := client.MergeFrom(dc.DeepCopy())
+	if dc.Annotations == nil {
+		dc.Annotations = map[string]string{}
+	}
+	dc.Annotations[api.BackupAgentConfigHashAnnotation] = configHash
+	if err := rc.Client.Patch(rc.Ctx, dc, patch); err != nil {
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
@@ -0,0 +1,93 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestRenderIni(t *testing.T) {
+	settings := []iniSetting{
+		{"cassandra", "use_sudo", "False"},
+		{"cassandra", "config_file", "/etc/cassandra/cassandra.yaml"},
+		{"grpc", "enabled", "1"},
+	}
+
+	content := `[storage]
+storage_provider = s3
+bucket_name = backups
+
+[cassandra]
+use_sudo = True
+`
+	assert.Equal(t, `[storage]
+storage_provider = s3
+bucket_name = backups
+
+[cassandra]
+use_sudo = False
+config_file = /etc/cassandra/cassandra.yaml
+
+[grpc]
+enabled = 1
+`, renderIni(content, settings))
+
+	assert.Equal(t, `[cassandra]
+use_sudo = False
+config_file = /etc/cassandra/cassandra.yaml
+
+[grpc]
+enabled = 1
+`, renderIni("", settings))
+}
+
+func TestCheckBackupAgentConfig(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.BackupAgent = &api.BackupAgentConfig{ConfigSecret: "medusa"}
+
+	// The pods cannot start without the secret
+	assert.True(t, rc.CheckBackupAgentConfig().Completed())
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "medusa", Namespace: dc.Namespace},
+		Data: map[string][]byte{
+			"medusa.ini":  []byte("[storage]\nstorage_provider = s3\n"),
+			"credentials": []byte("[default]\n"),
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, secret))
+
+	assert.False(t, rc.CheckBackupAgentConfig().Completed())
+	firstHash := dc.Annotations[api.BackupAgentConfigHashAnnotation]
+	assert.NotEmpty(t, firstHash)
+
+	rendered := &corev1.Secret{}
+	key := types.NamespacedName{Name: dc.GetBackupAgentConfigSecretName(), Namespace: dc.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, rendered))
+	assert.Contains(t, string(rendered.Data["medusa.ini"]), "storage_provider = s3")
+	assert.Contains(t, string(rendered.Data["medusa.ini"]), "[grpc]\nenabled = 1")
+	assert.NotContains(t, rendered.Data, "credentials")
+
+	// A new configuration restarts the pods
+	secret.Data["medusa.ini"] = []byte("[storage]\nstorage_provider = google_storage\n")
+	assert.NoError(t, rc.Client.Update(rc.Ctx, secret))
+	assert.False(t, rc.CheckBackupAgentConfig().Completed())
+	assert.NotEqual(t, firstHash, dc.Annotations[api.BackupAgentConfigHashAnnotation])
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, rendered))
+	assert.Contains(t, string(rendered.Data["medusa.ini"]), "storage_provider = google_storage")
+
+	delete(secret.Data, "medusa.ini")
+	assert.NoError(t, rc.Client.Update(rc.Ctx, secret))
+	assert.True(t, rc.CheckBackupAgentConfig().Completed())
+}
@@ -0,0 +1,113 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+)
+
+// certificatesReloadDelay is how long the kubelets may take to update the
+// secrets mounted in the pods: their sync period plus the TTL of their cache
+// of secrets, which are both a minute by default
+const certificatesReloadDelay = 2 * time.Minute
+
+// CheckCertificatesReload makes the nodes reload the certificates of the
+// encryption when they change, once the kubelets have updated them in the
+// pods. The nodes that cannot reload them are restarted instead: the hash of
+// the certificates is part of their pods. The rest of the datacenter is
+// reconciled while the kubelets update the certificates.
+func (rc *ReconciliationContext) CheckCertificatesReload() result.ReconcileResult {
+	dc := rc.Datacenter
+	certificatesHash := dc.GetCertificatesHash()
+	if dc.Status.CertificatesHash == certificatesHash {
+		return result.Continue()
+	}
+
+	// The nodes started with these certificates, or were restarted
+	// with them
+	if dc.Status.CertificatesHash == "" || certificatesHash == "" || !dc.SupportsCertificateReload() {
+		return rc.setCertificatesHash(certificatesHash)
+	}
+
+	if dc.Status.CertificatesChanged.IsZero() {
+		patch := client.MergeFrom(dc.DeepCopy())
+		dc.Status.CertificatesChanged = metav1.Now()
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, patch); err != nil {
+			return result.Error(err)
+		}
+	}
+	if wait := certificatesReloadDelay - time.Since(dc.Status.CertificatesChanged.Time); wait > 0 {
+		rc.ReqLogger.Info("waiting for the kubelets to update the certificates of the pods", "delay", wait)
+		rc.requeueAfter(int(wait.Seconds()) + 1)
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_certificates::CheckCertificatesReload")
+	var readyPods []*corev1.Pod
+	for _, pod := range rc.dcPods {
+		// The other nodes read the certificates when they start
+		if isServerReady(pod) {
+			readyPods = append(readyPods, pod)
+		}
+	}
+
+	var mu sync.Mutex
+	var unsupportedPod string
+	var reloadErr error
+	rc.NodeMgmtClient.ForEachPod(readyPods, func(pod *corev1.Pod) {
+		err := rc.NodeMgmtClient.CallReloadCertificatesEndpoint(pod)
+		if err == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if mgmtapi.IsNotSupported(err) {
+			unsupportedPod = pod.Name
+			return
+		}
+		rc.ReqLogger.Error(err, "failed to reload the certificates", "pod", pod.Name)
+		if reloadErr == nil {
+			reloadErr = err
+		}
+	})
+
+	if unsupportedPod != "" {
+		rc.ReqLogger.Info("the management API cannot reload the certificates, restarting the nodes", "pod", unsupportedPod)
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.ReloadedCertificates,
+			"Restarting the nodes since the management API of pod %s cannot reload the certificates", unsupportedPod)
+		patch := client.MergeFrom(dc.DeepCopy())
+		dc.Spec.RollingRestartRequested = true
+		if err := rc.Client.Patch(rc.Ctx, dc, patch); err != nil {
+			return result.Error(err)
+		}
+		return rc.setCertificatesHash(certificatesHash)
+	}
+	if reloadErr != nil {
+		return result.Error(reloadErr)
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.ReloadedCertificates,
+		"Reloaded the certificates of the encryption on the nodes")
+	return rc.setCertificatesHash(certificatesHash)
+}
+
+func (rc *ReconciliationContext) setCertificatesHash(certificatesHash string) result.ReconcileResult {
+	dc := rc.Datacenter
+	patch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.CertificatesHash = certificatesHash
+	dc.Status.CertificatesChanged = metav1.Time{}
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, patch); err != nil {
+		return result.Error(err)
+	}
+	return result.Continue()
+}
@@ -0,0 +1,81 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+func TestCheckCertificatesReload(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/node/reload-certificates"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("OK")),
+		}, nil).
+		Once()
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	rc.dcPods = []*corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: rc.Datacenter.Namespace},
+		Status: corev1.PodStatus{
+			PodIP:             "10.0.0.0",
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+		},
+	}}
+
+	dc := rc.Datacenter
+	dc.Spec.ServerType = "cassandra"
+	dc.Spec.ServerVersion = "4.0.0"
+	dc.Spec.Encryption = &api.EncryptionConfig{
+		Client: &api.ClientEncryptionConfig{
+			CertManagerIssuerRef: &api.CertManagerIssuerRef{Name: "ca-issuer"},
+		},
+	}
+	dc.Annotations = map[string]string{api.ClientCertificateHashAnnotation: "first"}
+
+	// The nodes started with the first certificate
+	assert.False(t, rc.CheckCertificatesReload().Completed())
+	assert.Equal(t, "first", dc.Status.CertificatesHash)
+
+	// The renewed certificate is reloaded once the kubelets updated it
+	dc.Annotations[api.ClientCertificateHashAnnotation] = "renewed"
+	assert.False(t, rc.CheckCertificatesReload().Completed())
+	assert.True(t, rc.requeueSeconds > 0)
+	assert.False(t, dc.Status.CertificatesChanged.IsZero())
+	assert.Equal(t, "first", dc.Status.CertificatesHash)
+
+	dc.Status.CertificatesChanged = metav1.NewTime(time.Now().Add(-certificatesReloadDelay))
+	assert.False(t, rc.CheckCertificatesReload().Completed())
+	assert.Equal(t, "renewed", dc.Status.CertificatesHash)
+	assert.True(t, dc.Status.CertificatesChanged.IsZero())
+	assert.False(t, dc.Spec.RollingRestartRequested)
+	mockHttpClient.AssertExpectations(t)
+
+	// Cassandra 3.11 nodes are restarted with the pods
+	dc.Spec.ServerVersion = "3.11.10"
+	dc.Annotations[api.ClientCertificateHashAnnotation] = "renewed-again"
+	assert.False(t, rc.CheckCertificatesReload().Completed())
+	assert.Equal(t, "renewed-again", dc.Status.CertificatesHash)
+}
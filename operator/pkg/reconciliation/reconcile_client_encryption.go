@@ -0,0 +1,251 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+// The certificates are resources of cert-manager, whose types are not
+// compiled in, so they are handled as unstructured objects
+const (
+	certManagerAPIVersion      = "cert-manager.io/v1"
+	certManagerCertificateKind = "Certificate"
+)
+
+// Keys of the secret of the keystores the operator converts the certificate
+// of the client encryption to
+const (
+	clientKeystorePasswordKey = "password"
+	clientKeystoreKey         = "keystore.jks"
+	clientTruststoreKey       = "truststore.jks"
+)
+
+func emptyClientCertificate(dc *api.CassandraDatacenter) *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetAPIVersion(certManagerAPIVersion)
+	certificate.SetKind(certManagerCertificateKind)
+	certificate.SetNamespace(dc.Namespace)
+	certificate.SetName(dc.GetClientCertificateName())
+	return certificate
+}
+
+// newClientCertificateForCassandraDatacenter creates the cert-manager
+// Certificate of the nodes, valid for the names of the services the clients
+// connect through and for the SNI host names of the pods
+func newClientCertificateForCassandraDatacenter(dc *api.CassandraDatacenter) *unstructured.Unstructured {
+	issuerRef := dc.GetClientEncryption().CertManagerIssuerRef
+	certificate := emptyClientCertificate(dc)
+
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(labels)
+	certificate.SetLabels(labels)
+
+	var dnsNames []interface{}
+	for _, name := range []string{dc.GetDatacenterServiceName(), dc.GetAllPodsServiceName()} {
+		dnsNames = append(dnsNames,
+			name,
+			fmt.Sprintf("%s.%s.svc", name, dc.Namespace),
+			fmt.Sprintf("*.%s.%s.svc", name, dc.Namespace))
+	}
+	if dc.IsSniEnabled() {
+		dnsNames = append(dnsNames, "*."+strings.TrimSuffix(dc.Spec.Networking.SNI.Domain, "."))
+	}
+
+	issuer := map[string]interface{}{"name": issuerRef.Name}
+	if issuerRef.Kind != "" {
+		issuer["kind"] = issuerRef.Kind
+	}
+	if issuerRef.Group != "" {
+		issuer["group"] = issuerRef.Group
+	}
+
+	certificate.Object["spec"] = map[string]interface{}{
+		"secretName": dc.GetClientCertificateName(),
+		"commonName": fmt.Sprintf("%s.%s.svc", dc.GetDatacenterServiceName(), dc.Namespace),
+		"dnsNames":   dnsNames,
+		"issuerRef":  issuer,
+	}
+
+	addAdditionalMetadata(dc, certificate)
+
+	utils.AddHashAnnotation(certificate)
+
+	return certificate
+}
+
+// CheckClientEncryption creates or updates the cert-manager Certificate of
+// the nodes, and converts its secret to the keystores mounted in the pods.
+// When cert-manager renews the certificate, the hash of the datacenter
+// changes, which makes the nodes reload the new keystores or restarts them.
+// Until cert-manager issues the certificate, the rest of the datacenter is
+// reconciled: the pods wait for the keystores to be mounted.
+func (rc *ReconciliationContext) CheckClientEncryption() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.GetClientEncryption() == nil {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_client_encryption::CheckClientEncryption")
+
+	desiredCertificate := newClientCertificateForCassandraDatacenter(dc)
+	if err := setControllerReference(dc, desiredCertificate, rc.Scheme); err != nil {
+		return result.Error(err)
+	}
+
+	currentCertificate := emptyClientCertificate(dc)
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: currentCertificate.GetName(), Namespace: currentCertificate.GetNamespace()}, currentCertificate)
+	if meta.IsNoMatchError(err) {
+		rc.ReqLogger.Info("cert-manager is not installed, cannot set up the client encryption")
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.CertManagerUnavailable,
+			"Cannot create the Certificate %s since cert-manager is not installed", desiredCertificate.GetName())
+		rc.requeueAfter(30)
+		return result.Continue()
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return result.Error(err)
+	}
+
+	if errors.IsNotFound(err) {
+		rc.ReqLogger.Info("Creating a certificate", "name", desiredCertificate.GetName())
+		if err := rc.createResource(desiredCertificate); err != nil {
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created Certificate %s", desiredCertificate.GetName())
+	} else if !utils.ResourcesHaveSameHash(currentCertificate, desiredCertificate) {
+		rc.ReqLogger.Info("Updating a certificate", "name", desiredCertificate.GetName())
+		if err := rc.updateResource(desiredCertificate, currentCertificate); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	certificateSecret, err := rc.retrieveSecret(types.NamespacedName{Name: dc.GetClientCertificateName(), Namespace: dc.Namespace})
+	if errors.IsNotFound(err) {
+		rc.ReqLogger.Info("waiting for cert-manager to issue the certificate", "name", desiredCertificate.GetName())
+		rc.requeueAfter(5)
+		return result.Continue()
+	}
+	if err != nil {
+		return result.Error(err)
+	}
+
+	if err := rc.checkClientKeystores(certificateSecret); err != nil {
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+// checkClientKeystores converts the certificate of the secret of cert-manager
+// to the keystores of the nodes, when it changes
+func (rc *ReconciliationContext) checkClientKeystores(certificateSecret *corev1.Secret) error {
+	dc := rc.Datacenter
+	certificateHash := fmt.Sprintf("%x", sha256.Sum256(append(append([]byte{},
+		certificateSecret.Data[corev1.TLSCertKey]...), certificateSecret.Data["ca.crt"]...)))
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: dc.GetClientKeystoresSecretName(), Namespace: dc.Namespace}
+	err := rc.Client.Get(rc.Ctx, key, secret)
+	exists := err == nil
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if !exists || secret.Annotations[api.ClientCertificateHashAnnotation] != certificateHash {
+		if !exists {
+			labels := dc.GetDatacenterLabels()
+			oplabels.AddManagedByLabel(labels)
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+					Labels:    labels,
+				},
+			}
+			addAdditionalMetadata(dc, secret)
+			if err := rc.SetDatacenterAsOwner(secret); err != nil {
+				return err
+			}
+		}
+
+		password := string(secret.Data[clientKeystorePasswordKey])
+		if password == "" {
+			if password, err = generateUtf8Password(); err != nil {
+				return err
+			}
+		}
+
+		keystore, err := utils.ConvertToJKS(certificateSecret.Data[corev1.TLSCertKey], certificateSecret.Data[corev1.TLSPrivateKeyKey], dc.Name, password)
+		if err != nil {
+			return fmt.Errorf("failed to convert the certificate of secret %s: %w", certificateSecret.Name, err)
+		}
+		ca := certificateSecret.Data["ca.crt"]
+		if len(ca) == 0 {
+			ca = certificateSecret.Data[corev1.TLSCertKey]
+		}
+		truststore, err := utils.ConvertToTruststore(ca, password)
+		if err != nil {
+			return fmt.Errorf("failed to convert the CA of secret %s: %w", certificateSecret.Name, err)
+		}
+
+		secret.Data = map[string][]byte{
+			clientKeystorePasswordKey: []byte(password),
+			clientKeystoreKey:         keystore,
+			clientTruststoreKey:       truststore,
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[api.ClientCertificateHashAnnotation] = certificateHash
+
+		rc.ReqLogger.Info("converting the client certificate to keystores", "secret", key.Name)
+		if exists {
+			err = rc.Client.Update(rc.Ctx, secret)
+		} else {
+			err = rc.createResource(secret)
+		}
+		if err != nil {
+			return err
+		}
+	} else if updateAdditionalMetadata(dc, secret) {
+		if err := rc.Client.Update(rc.Ctx, secret); err != nil {
+			return err
+		}
+	}
+
+	// See CheckCertificatesReload
+	if previousHash, ok := dc.Annotations[api.ClientCertificateHashAnnotation]; !ok || previousHash != certificateHash {
+		patch := client.MergeFrom(dc.DeepCopy())
+		if dc.Annotations == nil {
+			dc.Annotations = map[string]string{}
+		}
+		dc.Annotations[api.ClientCertificateHashAnnotation] = certificateHash
+		if err := rc.Client.Patch(rc.Ctx, dc, patch); err != nil {
+			return err
+		}
+		if ok {
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.RenewedClientCertificate,
+				"The certificate of secret %s changed", certificateSecret.Name)
+		}
+	}
+
+	return nil
+}
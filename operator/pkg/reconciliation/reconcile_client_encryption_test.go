@@ -0,0 +1,95 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+func TestNewClientCertificateForCassandraDatacenter(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "cluster1",
+			Encryption: &api.EncryptionConfig{
+				Client: &api.ClientEncryptionConfig{
+					CertManagerIssuerRef: &api.CertManagerIssuerRef{Name: "ca-issuer", Kind: "ClusterIssuer"},
+				},
+			},
+		},
+	}
+
+	certificate := newClientCertificateForCassandraDatacenter(dc)
+	assert.Equal(t, "Certificate", certificate.GetKind())
+	assert.Equal(t, "cluster1-dc1-client-tls", certificate.GetName())
+	assert.True(t, oplabels.HasManagedByCassandraOperatorLabel(certificate.GetLabels()))
+
+	secretName, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName")
+	assert.Equal(t, "cluster1-dc1-client-tls", secretName)
+	issuerRef, _, _ := unstructured.NestedStringMap(certificate.Object, "spec", "issuerRef")
+	assert.Equal(t, map[string]string{"name": "ca-issuer", "kind": "ClusterIssuer"}, issuerRef)
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	assert.Contains(t, dnsNames, "cluster1-dc1-service.ns1.svc")
+	assert.Contains(t, dnsNames, "*.cluster1-dc1-all-pods-service.ns1.svc")
+
+	dc.Spec.Networking = &api.NetworkingConfig{SNI: &api.SNIConfig{Domain: "cassandra.example.com"}}
+	certificate = newClientCertificateForCassandraDatacenter(dc)
+	dnsNames, _, _ = unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	assert.Contains(t, dnsNames, "*.cassandra.example.com")
+}
+
+func TestCheckClientKeystores(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Encryption = &api.EncryptionConfig{
+		Client: &api.ClientEncryptionConfig{
+			CertManagerIssuerRef: &api.CertManagerIssuerRef{Name: "ca-issuer"},
+		},
+	}
+
+	newCertificateSecret := func() *corev1.Secret {
+		key, cert, err := utils.GetNewCAandKey("cluster1-dc1-service", dc.Namespace)
+		assert.NoError(t, err)
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: dc.GetClientCertificateName(), Namespace: dc.Namespace},
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       []byte(cert),
+				corev1.TLSPrivateKeyKey: []byte(key),
+				"ca.crt":                []byte(cert),
+			},
+		}
+	}
+
+	assert.NoError(t, rc.checkClientKeystores(newCertificateSecret()))
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: dc.GetClientKeystoresSecretName(), Namespace: dc.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, secret))
+	assert.NotEmpty(t, secret.Data["password"])
+	assert.NotEmpty(t, secret.Data["keystore.jks"])
+	assert.NotEmpty(t, secret.Data["truststore.jks"])
+	hash := dc.Annotations[api.ClientCertificateHashAnnotation]
+	assert.NotEmpty(t, hash)
+	assert.Equal(t, hash, secret.Annotations[api.ClientCertificateHashAnnotation])
+
+	// A renewed certificate changes the hash, and keeps the password
+	password := secret.Data["password"]
+	assert.NoError(t, rc.checkClientKeystores(newCertificateSecret()))
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, secret))
+	assert.Equal(t, password, secret.Data["password"])
+	assert.NotEqual(t, hash, dc.Annotations[api.ClientCertificateHashAnnotation])
+	assert.Equal(t, dc.Annotations[api.ClientCertificateHashAnnotation], secret.Annotations[api.ClientCertificateHashAnnotation])
+}
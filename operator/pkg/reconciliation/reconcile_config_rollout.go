@@ -0,0 +1,99 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+)
+
+// configEnvVarNames are the env vars of the server-config-init container
+// that carry the config of the datacenter
+var configEnvVarNames = []string{"CONFIG_FILE_DATA", "CONFIG_HASH"}
+
+func envVarValue(container *corev1.Container, name string) string {
+	for _, envVar := range container.Env {
+		if envVar.Name == name {
+			return envVar.Value
+		}
+	}
+	return ""
+}
+
+// serverConfigChanged reports whether the config the server-config-init
+// container renders differs between the pod templates
+func serverConfigChanged(current, desired *corev1.PodTemplateSpec) bool {
+	currentContainer := findServerConfigContainer(current)
+	desiredContainer := findServerConfigContainer(desired)
+	if currentContainer == nil || desiredContainer == nil {
+		return false
+	}
+	for _, name := range configEnvVarNames {
+		if envVarValue(currentContainer, name) != envVarValue(desiredContainer, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// configCanaryPartition returns the partition of a rack of nodeCount pods
+// whose canary pods a change of the config is rolled out to first, or 0 when
+// the rack has no more pods than canaries
+func configCanaryPartition(dc *api.CassandraDatacenter, nodeCount int) int32 {
+	partition := int32(nodeCount) - dc.Spec.ConfigRolloutStrategy.GetCanaryCount()
+	if partition < 0 {
+		return 0
+	}
+	return partition
+}
+
+// areConfigCanariesHealthy reports whether the canary pods of the rack, the
+// ones above the partition of its statefulset, are ready, Up/Normal in the
+// ring, and see enough replicas to serve LOCAL_QUORUM requests
+func (rc *ReconciliationContext) areConfigCanariesHealthy(rackName string, partition int32) bool {
+	var canaries []*corev1.Pod
+	for _, pod := range rc.dcPods {
+		if pod.Labels[api.RackLabel] != rackName || podOrdinal(pod) < int(partition) {
+			continue
+		}
+		if !isServerReady(pod) {
+			return false
+		}
+		canaries = append(canaries, pod)
+	}
+	if len(canaries) == 0 {
+		return false
+	}
+
+	endpointStates := MapPodsToEndpointDataByName(canaries, rc.getCassMetadataEndpoints())
+	numRacks := len(rc.Datacenter.GetRacks())
+	for _, pod := range canaries {
+		state, ok := endpointStates[pod.Name]
+		if !ok || !isUpAndNormal(state) {
+			return false
+		}
+		if err := rc.NodeMgmtClient.CallProbeClusterEndpoint(pod, "LOCAL_QUORUM", numRacks); err != nil {
+			rc.ReqLogger.Info("config canary pod failed the health check", "pod", pod.Name, "error", err.Error())
+			return false
+		}
+	}
+	return true
+}
+
+// releaseConfigCanaries rolls out the config to the rest of the pods of the
+// statefulset of a rack, once its canary pods are healthy
+func (rc *ReconciliationContext) releaseConfigCanaries(rackName string, statefulSet *appsv1.StatefulSet) result.ReconcileResult {
+	if err := rc.releaseCanaryPartition(statefulSet); err != nil {
+		return result.Error(err)
+	}
+
+	rc.recordRackEventf(rackName, corev1.EventTypeNormal, events.ConfigCanariesHealthy,
+		"Config canary pods healthy on rack %s, rolling out the config to the remaining pods", rackName)
+
+	return result.Done()
+}
@@ -0,0 +1,202 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+func TestServerConfigChanged(t *testing.T) {
+	template := func(config string) *corev1.PodTemplateSpec {
+		return &corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{
+					Name: ServerConfigContainerName,
+					Env: []corev1.EnvVar{
+						{Name: "RACK_NAME", Value: "rack1"},
+						{Name: "CONFIG_FILE_DATA", Value: config},
+					},
+				}},
+			},
+		}
+	}
+
+	assert.False(t, serverConfigChanged(template(`{}`), template(`{}`)))
+	assert.True(t, serverConfigChanged(template(`{}`), template(`{"cassandra-yaml":{"num_tokens":16}}`)))
+
+	withImage := template(`{}`)
+	withImage.Spec.InitContainers[0].Image = "datastax/cass-config-builder:1.0.4"
+	assert.False(t, serverConfigChanged(template(`{}`), withImage))
+}
+
+func TestConfigCanaryPartition(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{ConfigRolloutStrategy: &api.ConfigRolloutStrategy{}},
+	}
+	assert.Equal(t, int32(2), configCanaryPartition(dc, 3))
+
+	dc.Spec.ConfigRolloutStrategy.CanaryCount = 2
+	assert.Equal(t, int32(1), configCanaryPartition(dc, 3))
+	assert.Equal(t, int32(0), configCanaryPartition(dc, 2))
+}
+
+func TestCheckRackPodTemplate_ConfigRollout(t *testing.T) {
+	rc, _, cleanpMockSrc := setupTest()
+	defer cleanpMockSrc()
+
+	rc.Datacenter.Spec.ServerVersion = "6.8.2"
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1", Zone: "zone-1"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
+		t.Fatalf("failed to add rack to cassandradatacenter: %s", err)
+	}
+
+	rc.Datacenter.Spec.ConfigRolloutStrategy = &api.ConfigRolloutStrategy{}
+	rc.Datacenter.Spec.Config = []byte(`{"cassandra-yaml":{"num_tokens":16}}`)
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Equal(t, int32(1), statefulSetPartition(rc.statefulSets[0]), "only the canary pod should get the config")
+	assert.Equal(t, "true", rc.statefulSets[0].Annotations[api.ConfigCanaryAnnotation])
+
+	statefulSet := rc.statefulSets[0]
+	statefulSet.Status.Replicas = 2
+	statefulSet.Status.ReadyReplicas = 2
+	statefulSet.Status.CurrentReplicas = 1
+	statefulSet.Status.UpdatedReplicas = 1
+
+	canary := makeReadyPod(statefulSet.Name + "-1")
+	canary.Labels = map[string]string{api.RackLabel: "rack1"}
+	canary.Status.PodIP = "10.0.0.1"
+	rc.dcPods = []*corev1.Pod{canary}
+	rc.clusterPods = rc.dcPods
+
+	canaryStatus := "JOINING,1"
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil
+			})).
+		Return(func(req *http.Request) *http.Response {
+			body := "OK"
+			if req.URL.Path == "/api/v0/metadata/endpoints" {
+				body = fmt.Sprintf(`{"entity": [{"RPC_ADDRESS": "10.0.0.1", "IS_ALIVE": "true", "STATUS": "%s"}]}`, canaryStatus)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}
+		}, nil)
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed(), "should wait for the canary node to be Up/Normal")
+	assert.Equal(t, int32(1), statefulSetPartition(rc.statefulSets[0]))
+
+	canaryStatus = "NORMAL,1"
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Nil(t, rc.statefulSets[0].Spec.UpdateStrategy.RollingUpdate, "the rest of the rack should get the config")
+	assert.NotContains(t, rc.statefulSets[0].Annotations, api.ConfigCanaryAnnotation)
+}
+
+func TestCheckRackPodTemplate_ConfigRolloutOneRackAtATime(t *testing.T) {
+	rc, _, cleanpMockSrc := setupTest()
+	defer cleanpMockSrc()
+
+	rc.Datacenter.Spec.ServerVersion = "6.8.2"
+	rc.Datacenter.Spec.Size = 4
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1", Zone: "zone-1"},
+		{Name: "rack2", Zone: "zone-2"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
+		t.Fatalf("failed to add racks to cassandradatacenter: %s", err)
+	}
+	for _, statefulSet := range rc.statefulSets {
+		statefulSet.Status = appsv1.StatefulSetStatus{Replicas: 2, ReadyReplicas: 2, CurrentReplicas: 2, UpdatedReplicas: 2}
+	}
+	rack2Template := rc.statefulSets[1].Spec.Template.DeepCopy()
+
+	rc.Datacenter.Spec.ConfigRolloutStrategy = &api.ConfigRolloutStrategy{}
+	rc.Datacenter.Spec.Config = []byte(`{"cassandra-yaml":{"num_tokens":16}}`)
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Equal(t, int32(1), statefulSetPartition(rc.statefulSets[0]))
+
+	rc.statefulSets[0].Status = appsv1.StatefulSetStatus{Replicas: 2, ReadyReplicas: 2, CurrentReplicas: 1, UpdatedReplicas: 1}
+	canary := makeReadyPod(rack1.Name + "-1")
+	canary.Labels = map[string]string{api.RackLabel: "rack1"}
+	canary.Status.PodIP = "10.0.0.1"
+	rc.dcPods = []*corev1.Pod{canary}
+	rc.clusterPods = rc.dcPods
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil
+			})).
+		Return(func(req *http.Request) *http.Response {
+			body := "OK"
+			if req.URL.Path == "/api/v0/metadata/endpoints" {
+				body = `{"entity": [{"RPC_ADDRESS": "10.0.0.1", "IS_ALIVE": "true", "STATUS": "NORMAL,1"}]}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}
+		}, nil)
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	// The first rack gets the config once its canary pod is healthy, and the
+	// second rack waits for it to be ready again
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Nil(t, rc.statefulSets[0].Spec.UpdateStrategy.RollingUpdate)
+	assert.Equal(t, *rack2Template, rc.statefulSets[1].Spec.Template)
+
+	rc.statefulSets[0].Status = appsv1.StatefulSetStatus{Replicas: 2, ReadyReplicas: 1, CurrentReplicas: 1, UpdatedReplicas: 1}
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Equal(t, *rack2Template, rc.statefulSets[1].Spec.Template, "the first rack is still rolling out the config")
+
+	rc.statefulSets[0].Status = appsv1.StatefulSetStatus{Replicas: 2, ReadyReplicas: 2, CurrentReplicas: 2, UpdatedReplicas: 2}
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Equal(t, int32(1), statefulSetPartition(rc.statefulSets[1]), "the second rack gets its canary pod")
+	assert.Equal(t, "true", rc.statefulSets[1].Annotations[api.ConfigCanaryAnnotation])
+}
@@ -0,0 +1,349 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+)
+
+const (
+	// The interval the job of a dry run of the config builder is polled at
+	configValidationPollSeconds = 5
+
+	// The number of times the job of a dry run retries a failed pod, so that
+	// an evicted or preempted pod does not reject the config
+	configValidationBackoffLimit = 2
+
+	// The longest part of the output of a failed config builder the
+	// ConfigValid condition carries
+	configValidationMaxMessageLength = 1024
+
+	// The reason of the ConfigValid condition when the config builder fails
+	configRenderingFailedReason = "ConfigRenderingFailed"
+
+	// The reason of the ConfigValid condition when dedicatedCpus cannot give
+	// the pods the Guaranteed QoS class
+	resourcesNotGuaranteedReason = "ResourcesNotGuaranteed"
+)
+
+// findServerConfigContainer returns the server-config-init container of the
+// pod template
+func findServerConfigContainer(podTemplate *corev1.PodTemplateSpec) *corev1.Container {
+	for i, c := range podTemplate.Spec.InitContainers {
+		if c.Name == ServerConfigContainerName {
+			return &podTemplate.Spec.InitContainers[i]
+		}
+	}
+	return nil
+}
+
+// configValidationHash hashes the server-config-init container, whose image
+// and environment carry the config it renders
+func configValidationHash(container *corev1.Container) string {
+	b, _ := json.Marshal(container)
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// rackConfigValidation is the server-config-init container of the pod
+// template of a rack, which renders the config of the rack in a dry run
+type rackConfigValidation struct {
+	podTemplate *corev1.PodTemplateSpec
+	container   *corev1.Container
+	hash        string
+}
+
+// buildConfigValidations returns the server-config-init containers of the pod
+// templates of every rack, as the config builder renders a config per rack
+func buildConfigValidations(dc *api.CassandraDatacenter) ([]rackConfigValidation, error) {
+	var validations []rackConfigValidation
+	for _, rack := range dc.GetRacks() {
+		podTemplate, err := buildPodTemplateSpec(dc, map[string]string{}, rack.Name)
+		if err != nil {
+			return nil, err
+		}
+		container := findServerConfigContainer(podTemplate)
+		if container == nil {
+			continue
+		}
+		validations = append(validations, rackConfigValidation{
+			podTemplate: podTemplate,
+			container:   container,
+			hash:        configValidationHash(container),
+		})
+	}
+	return validations, nil
+}
+
+// datacenterConfigValidationHash hashes the configs of all the racks, which
+// the datacenter records once every one of them is rendered
+func datacenterConfigValidationHash(validations []rackConfigValidation) string {
+	h := sha256.New()
+	for _, validation := range validations {
+		h.Write([]byte(validation.hash))
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// isConfigValidationJobFailed returns whether the job gave up on its dry run,
+// once the pods it retried all failed
+func isConfigValidationJobFailed(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// newConfigValidationJob creates the job that runs the server-config-init
+// container of the pods once, with an empty server-config volume. Its pods do
+// not carry the labels of the datacenter, so that they are not taken for
+// nodes by the operator or the services.
+func newConfigValidationJob(dc *api.CassandraDatacenter, podTemplate *corev1.PodTemplateSpec, container corev1.Container, configHash string) *batchv1.Job {
+	labels := map[string]string{api.ConfigValidationLabel: dc.Name}
+	oplabels.AddManagedByLabel(labels)
+
+	container.VolumeMounts = []corev1.VolumeMount{{Name: "server-config", MountPath: "/config"}}
+	container.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+	backoffLimit := int32(configValidationBackoffLimit)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dc.GetConfigValidationJobName(configHash),
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: podTemplate.Spec.ServiceAccountName,
+					ImagePullSecrets:   podTemplate.Spec.ImagePullSecrets,
+					SecurityContext:    podTemplate.Spec.SecurityContext,
+					Containers:         []corev1.Container{container},
+					Volumes: []corev1.Volume{{
+						Name:         "server-config",
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					}},
+				},
+			},
+		},
+	}
+
+	addAdditionalMetadata(dc, job)
+
+	return job
+}
+
+// CheckConfigValidation runs the config builder in a job per rack against the
+// config of a live datacenter before the pod templates of its racks are
+// updated. A config the config builder fails to render sets the ConfigValid
+// condition to False and holds back the rollout until the config changes. The
+// config of a datacenter that has not been validated yet is rendered in a dry
+// run too.
+func (rc *ReconciliationContext) CheckConfigValidation() result.ReconcileResult {
+	dc := rc.Datacenter
+	logger := rc.ReqLogger
+	if !rc.IsInitialized() {
+		return result.Continue()
+	}
+
+	validations, err := buildConfigValidations(dc)
+	if err != nil {
+		logger.Error(err, "failed to build the pod template to validate the config")
+		reason := configRenderingFailedReason
+		if _, ok := err.(*resourcesNotGuaranteedError); ok {
+			reason = resourcesNotGuaranteedReason
+		}
+		if res := rc.rejectConfig(reason, err.Error()); res.Completed() {
+			return res
+		}
+		return result.Error(err)
+	}
+	if len(validations) == 0 {
+		return result.Continue()
+	}
+	configHash := datacenterConfigValidationHash(validations)
+
+	if dc.Status.ValidatedConfigHash == configHash {
+		if dc.GetConditionStatus(api.DatacenterConfigValid) == corev1.ConditionFalse {
+			return rc.setValidatedConfig(configHash)
+		}
+		return result.Continue()
+	}
+	// The config the operator renders is already rendered with the pod template
+	if dc.RendersConfigInOperator() {
+		return rc.setValidatedConfig(configHash)
+	}
+
+	logger.Info("reconcile_config_validation::CheckConfigValidation")
+
+	var names []string
+	desiredNames := map[string]bool{}
+	for _, validation := range validations {
+		name := dc.GetConfigValidationJobName(validation.hash)
+		names = append(names, name)
+		desiredNames[name] = true
+	}
+	if err := rc.deleteConfigValidationJobs(desiredNames); err != nil {
+		return result.Error(err)
+	}
+
+	pending := false
+	for idx, validation := range validations {
+		name := names[idx]
+		job := &batchv1.Job{}
+		err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: name, Namespace: dc.Namespace}, job)
+		if errors.IsNotFound(err) {
+			desiredJob := newConfigValidationJob(dc, validation.podTemplate, *validation.container, validation.hash)
+			if err := setControllerReference(dc, desiredJob, rc.Scheme); err != nil {
+				return result.Error(err)
+			}
+			logger.Info("Rendering the config in a dry run", "job", name)
+			if err := rc.createResource(desiredJob); err != nil {
+				logger.Error(err, "failed to create the config validation job")
+				return result.Error(err)
+			}
+			pending = true
+			continue
+		}
+		if err != nil {
+			return result.Error(err)
+		}
+
+		if isConfigValidationJobFailed(job) {
+			message, err := rc.configValidationFailure(job)
+			if err != nil {
+				return result.Error(err)
+			}
+			if res := rc.rejectConfig(configRenderingFailedReason, message); res.Completed() {
+				return res
+			}
+			return result.Done()
+		}
+		if job.Status.Succeeded == 0 {
+			logger.Info("Waiting for the config validation job", "job", name)
+			pending = true
+		}
+	}
+	if pending {
+		return result.RequeueSoon(configValidationPollSeconds)
+	}
+
+	logger.Info("The config builder rendered the config", "jobs", names)
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.ValidatedConfig,
+		"Rendered the config in jobs %s", strings.Join(names, ", "))
+	return rc.setValidatedConfig(configHash)
+}
+
+// configValidationFailure returns the message of a failed config validation
+// job, with the termination message of its config builder
+func (rc *ReconciliationContext) configValidationFailure(job *batchv1.Job) (string, error) {
+	message := fmt.Sprintf("The config builder failed to render the config in job %s", job.Name)
+
+	podList := &corev1.PodList{}
+	listOptions := []client.ListOption{
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{"job-name": job.Name},
+	}
+	if err := rc.Client.List(rc.Ctx, podList, listOptions...); err != nil {
+		return "", err
+	}
+	for _, pod := range podList.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated == nil {
+				continue
+			}
+			output := strings.TrimSpace(status.State.Terminated.Message)
+			if output == "" {
+				continue
+			}
+			if len(output) > configValidationMaxMessageLength {
+				output = output[len(output)-configValidationMaxMessageLength:]
+			}
+			return message + ": " + output, nil
+		}
+	}
+	return message, nil
+}
+
+// rejectConfig sets the ConfigValid condition to False with the reason and the
+// message, and records it as a warning event when the condition changes
+func (rc *ReconciliationContext) rejectConfig(reason, message string) result.ReconcileResult {
+	dc := rc.Datacenter
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	current, _ := dc.GetCondition(api.DatacenterConfigValid)
+	if rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterConfigValid, corev1.ConditionFalse,
+		reason, message)) {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			rc.ReqLogger.Error(err, "error patching datacenter status for config validation")
+			return result.Error(err)
+		}
+	}
+	if current.Status != corev1.ConditionFalse || current.Message != message {
+		rc.Recorder.Event(dc, corev1.EventTypeWarning, events.FailedConfigValidation, message)
+	}
+	return result.Continue()
+}
+
+// setValidatedConfig records the hash of the validated config in the status,
+// sets the ConfigValid condition to True and deletes the jobs of the dry runs
+func (rc *ReconciliationContext) setValidatedConfig(configHash string) result.ReconcileResult {
+	dc := rc.Datacenter
+	if err := rc.deleteConfigValidationJobs(nil); err != nil {
+		return result.Error(err)
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.ValidatedConfigHash = configHash
+	rc.setCondition(api.NewDatacenterCondition(api.DatacenterConfigValid, corev1.ConditionTrue))
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status for config validation")
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+// deleteConfigValidationJobs deletes the jobs of the dry runs of the config
+// builder of the datacenter but the ones to keep, with their pods
+func (rc *ReconciliationContext) deleteConfigValidationJobs(keep map[string]bool) error {
+	dc := rc.Datacenter
+	jobList := &batchv1.JobList{}
+	listOptions := []client.ListOption{
+		client.InNamespace(dc.Namespace),
+		client.MatchingLabels{api.ConfigValidationLabel: dc.Name},
+	}
+	if err := rc.Client.List(rc.Ctx, jobList, listOptions...); err != nil {
+		return err
+	}
+	for idx := range jobList.Items {
+		job := &jobList.Items[idx]
+		if keep[job.Name] {
+			continue
+		}
+		rc.ReqLogger.Info("Deleting a config validation job", "job", job.Name)
+		if err := rc.Client.Delete(rc.Ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func desiredConfigValidationHash(t *testing.T, dc *api.CassandraDatacenter) string {
+	validations, err := buildConfigValidations(dc)
+	assert.NoError(t, err)
+	return datacenterConfigValidationHash(validations)
+}
+
+func desiredConfigValidationJobKeys(t *testing.T, dc *api.CassandraDatacenter) []types.NamespacedName {
+	validations, err := buildConfigValidations(dc)
+	assert.NoError(t, err)
+	var keys []types.NamespacedName
+	for _, validation := range validations {
+		keys = append(keys, types.NamespacedName{Name: dc.GetConfigValidationJobName(validation.hash), Namespace: dc.Namespace})
+	}
+	return keys
+}
+
+func succeedConfigValidationJob(t *testing.T, rc *ReconciliationContext, jobKey types.NamespacedName) {
+	job := &batchv1.Job{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, jobKey, job))
+	job.Status.Succeeded = 1
+	assert.NoError(t, rc.Client.Update(rc.Ctx, job))
+}
+
+func TestCheckConfigValidation(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	assert.False(t, rc.CheckConfigValidation().Completed())
+	assert.Empty(t, dc.Status.ValidatedConfigHash, "the config should only be validated on a live datacenter")
+
+	// The config the nodes run with is rendered in a dry run too
+	dc.SetCondition(*api.NewDatacenterCondition(api.DatacenterInitialized, corev1.ConditionTrue))
+	assert.True(t, rc.CheckConfigValidation().Completed())
+	assert.Empty(t, dc.Status.ValidatedConfigHash)
+	firstJobKeys := desiredConfigValidationJobKeys(t, dc)
+	assert.Len(t, firstJobKeys, 1)
+	succeedConfigValidationJob(t, rc, firstJobKeys[0])
+	assert.False(t, rc.CheckConfigValidation().Completed())
+	firstHash := desiredConfigValidationHash(t, dc)
+	assert.Equal(t, firstHash, dc.Status.ValidatedConfigHash)
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterConfigValid))
+
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"num_tokens":16}}`)
+	secondHash := desiredConfigValidationHash(t, dc)
+	assert.True(t, rc.CheckConfigValidation().Completed(), "the rollout should wait for the dry run")
+	job := &batchv1.Job{}
+	assert.NotEqual(t, firstHash, secondHash)
+	jobKey := desiredConfigValidationJobKeys(t, dc)[0]
+	assert.NoError(t, rc.Client.Get(rc.Ctx, jobKey, job))
+	assert.NotContains(t, job.Spec.Template.Labels, api.DatacenterLabel)
+	assert.Equal(t, corev1.RestartPolicyNever, job.Spec.Template.Spec.RestartPolicy)
+	assert.Equal(t, int32(configValidationBackoffLimit), *job.Spec.BackoffLimit)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, ServerConfigContainerName, container.Name)
+	assert.Equal(t, []corev1.VolumeMount{{Name: "server-config", MountPath: "/config"}}, container.VolumeMounts)
+	configData := ""
+	for _, envVar := range container.Env {
+		if envVar.Name == "CONFIG_FILE_DATA" {
+			configData = envVar.Value
+		}
+	}
+	assert.Contains(t, configData, `"num_tokens":16`)
+
+	assert.True(t, rc.CheckConfigValidation().Completed())
+	assert.Equal(t, firstHash, dc.Status.ValidatedConfigHash)
+
+	// A failed pod is retried by the job
+	job.Status.Failed = 1
+	assert.NoError(t, rc.Client.Update(rc.Ctx, job))
+	assert.True(t, rc.CheckConfigValidation().Completed())
+	assert.NotEqual(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterConfigValid))
+
+	// A failed rendering holds back the rollout with the output of the
+	// config builder
+	assert.NoError(t, rc.Client.Get(rc.Ctx, jobKey, job))
+	job.Status.Failed = configValidationBackoffLimit + 1
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	assert.NoError(t, rc.Client.Update(rc.Ctx, job))
+	assert.NoError(t, rc.Client.Create(rc.Ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-abcde",
+			Namespace: dc.Namespace,
+			Labels:    map[string]string{"job-name": job.Name},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: ServerConfigContainerName,
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "invalid num_tokens\n"},
+				},
+			}},
+		},
+	}))
+	assert.True(t, rc.CheckConfigValidation().Completed())
+	cond, ok := dc.GetCondition(api.DatacenterConfigValid)
+	assert.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, "ConfigRenderingFailed", cond.Reason)
+	assert.Equal(t, "The config builder failed to render the config in job "+job.Name+": invalid num_tokens", cond.Message)
+	assert.Equal(t, firstHash, dc.Status.ValidatedConfigHash)
+
+	// A new config is rendered in a new job
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"num_tokens":32}}`)
+	thirdHash := desiredConfigValidationHash(t, dc)
+	assert.True(t, rc.CheckConfigValidation().Completed())
+	assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, jobKey, &batchv1.Job{})))
+	jobKey = desiredConfigValidationJobKeys(t, dc)[0]
+	succeedConfigValidationJob(t, rc, jobKey)
+	assert.False(t, rc.CheckConfigValidation().Completed())
+	assert.Equal(t, thirdHash, dc.Status.ValidatedConfigHash)
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterConfigValid))
+	assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, jobKey, &batchv1.Job{})))
+}
+
+func TestCheckConfigValidation_ResourcesNotGuaranteed(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.SetCondition(*api.NewDatacenterCondition(api.DatacenterInitialized, corev1.ConditionTrue))
+	assert.True(t, rc.CheckConfigValidation().Completed())
+	succeedConfigValidationJob(t, rc, desiredConfigValidationJobKeys(t, dc)[0])
+	assert.False(t, rc.CheckConfigValidation().Completed())
+
+	// The cassandra container has no resources the pods could be guaranteed
+	dc.Spec.AllowMultipleNodesPerWorker = true
+	dc.Spec.MultipleNodesPerWorker = &api.MultipleNodesPerWorkerConfig{DedicatedCpus: true}
+	assert.True(t, rc.CheckConfigValidation().Completed())
+	cond, ok := dc.GetCondition(api.DatacenterConfigValid)
+	assert.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, "ResourcesNotGuaranteed", cond.Reason)
+	assert.Contains(t, cond.Message, "the cpu of container cassandra")
+
+	dc.Spec.Resources = corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("2Gi"),
+		},
+	}
+	assert.False(t, rc.CheckConfigValidation().Completed())
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterConfigValid))
+}
+
+func TestCheckConfigValidation_EveryRack(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Racks = []api.Rack{{Name: "rack1"}, {Name: "rack2"}}
+	dc.SetCondition(*api.NewDatacenterCondition(api.DatacenterInitialized, corev1.ConditionTrue))
+	assert.True(t, rc.CheckConfigValidation().Completed())
+
+	jobKeys := desiredConfigValidationJobKeys(t, dc)
+	assert.Len(t, jobKeys, 2)
+	assert.NotEqual(t, jobKeys[0], jobKeys[1], "each rack should render its own config")
+	for _, jobKey := range jobKeys {
+		assert.NoError(t, rc.Client.Get(rc.Ctx, jobKey, &batchv1.Job{}))
+	}
+
+	// The config is only validated once every rack rendered it
+	succeedConfigValidationJob(t, rc, jobKeys[0])
+	assert.True(t, rc.CheckConfigValidation().Completed())
+	assert.Empty(t, dc.Status.ValidatedConfigHash)
+
+	succeedConfigValidationJob(t, rc, jobKeys[1])
+	assert.False(t, rc.CheckConfigValidation().Completed())
+	assert.Equal(t, desiredConfigValidationHash(t, dc), dc.Status.ValidatedConfigHash)
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterConfigValid))
+	for _, jobKey := range jobKeys {
+		assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, jobKey, &batchv1.Job{})))
+	}
+}
@@ -4,12 +4,19 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/k8ssandra/cass-operator/operator/internal/result"
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/serverconfig"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/kubernetes/pkg/util/hash"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -19,29 +26,63 @@ import (
 // specified secret and add to the datacenter configuration secret. The datacenter
 // configuration is created by cass-operator. A second secret is used because cass-operator
 // adds additional properties to the configuration, and we do not want to write that
-// updated configuration back to the user's secret since we do not own it.
+// updated configuration back to the user's secret since we do not own it. The configs of
+// the secrets of ConfigSecrets are merged over the one of ConfigSecret.
 func (rc *ReconciliationContext) CheckConfigSecret() result.ReconcileResult {
 	rc.ReqLogger.Info("reconcile_racks::CheckConfigSecret")
 
-	if len(rc.Datacenter.Spec.ConfigSecret) == 0 {
+	if !rc.Datacenter.UsesConfigSecret() {
 		return result.Continue()
 	}
 
-	key := types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: rc.Datacenter.Spec.ConfigSecret}
-	secret, err := rc.retrieveSecret(key)
+	// A secret of Vault is named after its path in the logs
+	vaultPath := rc.Datacenter.GetVaultConfigSecretPath()
+	names := rc.Datacenter.GetConfigSecretNames()
+	if vaultPath != "" {
+		names = []string{vaultPath}
+	}
 
+	provider, err := rc.secretProvider(vaultPath)
 	if err != nil {
-		rc.ReqLogger.Error(err, "failed to get config secret", "ConfigSecret", key.Name)
 		return result.Error(err)
 	}
 
-	if err := rc.checkDatacenterNameAnnotation(secret); err != nil {
-		rc.ReqLogger.Error(err, "annotation check for config secret failed", "ConfigSecret", secret.Name)
+	var sources []configSource
+	if name := rc.Datacenter.Spec.ConfigConfigMap; name != "" {
+		configMap := &corev1.ConfigMap{}
+		if err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: name}, configMap); err != nil {
+			rc.ReqLogger.Error(err, "failed to get config ConfigMap", "ConfigMap", name)
+			return result.Error(err)
+		}
+		if err := rc.checkDatacenterNameAnnotation(configMap); err != nil {
+			rc.ReqLogger.Error(err, "annotation check for config ConfigMap failed", "ConfigMap", name)
+		}
+		config, found := configMap.Data["config"]
+		sources = append(sources, configSource{kind: "ConfigMap", name: name, config: []byte(config), found: found})
+	}
+
+	for _, name := range names {
+		key := types.NamespacedName{Namespace: rc.Datacenter.Namespace, Name: name}
+		secret, err := provider.GetSecret(rc.Ctx, key)
+		if err != nil {
+			rc.ReqLogger.Error(err, "failed to get config secret", "ConfigSecret", key.Name)
+			return result.Error(err)
+		}
+
+		// The annotation maps the changes of the secret to the datacenter,
+		// while the secrets of Vault are read again periodically
+		if vaultPath == "" {
+			if err := rc.checkDatacenterNameAnnotation(secret); err != nil {
+				rc.ReqLogger.Error(err, "annotation check for config secret failed", "ConfigSecret", secret.Name)
+			}
+		}
+		config, found := secret.Data["config"]
+		sources = append(sources, configSource{kind: "secret", name: secret.Name, config: config, found: found})
 	}
 
-	config, err := getConfigFromConfigSecret(rc.Datacenter, secret)
+	config, err := getConfigFromSources(rc.Datacenter, sources)
 	if err != nil {
-		rc.ReqLogger.Error(err, "failed to get json config from secret", "ConfigSecret", rc.Datacenter.Spec.ConfigSecret)
+		rc.ReqLogger.Error(err, "failed to get json config from secrets", "ConfigSecrets", names)
 		return result.Error(err)
 	}
 
@@ -67,8 +108,7 @@ func (rc *ReconciliationContext) CheckConfigSecret() result.ReconcileResult {
 				rc.ReqLogger.Error(err,"failed to update datacenter config secret", "ConfigSecret", dcConfigSecret.Name)
 				return result.Error(err)
 			}
-		}
-		if err := rc.Client.Create(rc.Ctx, dcConfigSecret); err != nil {
+		} else if err := rc.createResource(dcConfigSecret); err != nil {
 			rc.ReqLogger.Error(err, "failed to create datacenter config secret", "ConfigSecret", dcConfigSecret.Name)
 			return result.Error(err)
 		}
@@ -77,17 +117,33 @@ func (rc *ReconciliationContext) CheckConfigSecret() result.ReconcileResult {
 	return result.Continue()
 }
 
-// checkDatacenterNameAnnotation Checks to see if the secret has the datacenter annotation.
-// If the secret does not have the annotation, it is added, and the secret is patched. The
-// secret should be the one specifiied by ConfigSecret.
-func (rc *ReconciliationContext) checkDatacenterNameAnnotation(secret *corev1.Secret) error {
-	if v, ok := secret.Annotations[api.DatacenterAnnotation]; ok &&  v == rc.Datacenter.Name {
-		return nil
+// checkDatacenterNameAnnotation Checks to see if the datacenter annotation of the secret lists
+// the datacenter. If it does not, the datacenter is added to the ones of the annotation, so
+// that every datacenter sharing the secret is reconciled when it changes, and the secret is
+// patched. The secret should be the one specifiied by ConfigSecret, or the ConfigMap of
+// ConfigConfigMap.
+func (rc *ReconciliationContext) checkDatacenterNameAnnotation(obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	names := api.AnnotatedDatacenters(accessor.GetAnnotations())
+	for _, name := range names {
+		if name == rc.Datacenter.Name {
+			return nil
+		}
 	}
 
-	patch := client.MergeFrom(secret.DeepCopy())
-	secret.Annotations[api.DatacenterAnnotation] = rc.Datacenter.Name
-	return rc.Client.Patch(rc.Ctx, secret, patch)
+	patch := client.MergeFrom(obj.DeepCopyObject())
+	annotations := accessor.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	names = append(names, rc.Datacenter.Name)
+	sort.Strings(names)
+	annotations[api.DatacenterAnnotation] = strings.Join(names, ",")
+	accessor.SetAnnotations(annotations)
+	return rc.Client.Patch(rc.Ctx, obj, patch)
 }
 
 // updateConfigHashAnnotation Adds the config hash annotation to the datacenter. The value
@@ -108,18 +164,38 @@ func (rc *ReconciliationContext) updateConfigHashAnnotation(secret *corev1.Secre
 	return rc.Client.Patch(rc.Ctx, rc.Datacenter, patch)
 }
 
-// getConfigFromConfigSecret Generates the JSON with properties added by cass-operator.
-func getConfigFromConfigSecret(dc *api.CassandraDatacenter, secret *corev1.Secret) ([]byte, error) {
-	if b, found := secret.Data["config"]; found {
-		jsonConfig, err := dc.GetConfigAsJSON(b)
-		if err == nil {
-			return []byte(jsonConfig), nil
-		} else {
-			return nil, err
+// configSource is the config property of a secret or ConfigMap of the config
+type configSource struct {
+	kind   string
+	name   string
+	config []byte
+	found  bool
+}
+
+// getConfigFromSources Generates the JSON with properties added by cass-operator,
+// from the configs of the secrets and ConfigMap merged in order.
+func getConfigFromSources(dc *api.CassandraDatacenter, sources []configSource) ([]byte, error) {
+	merged := map[string]interface{}{}
+	for _, source := range sources {
+		if !source.found {
+			return nil, fmt.Errorf("invalid config %s %s: config property is required", source.kind, source.name)
 		}
-	} else {
-		return nil, fmt.Errorf("invalid config secret %s: config property is required", dc.Spec.ConfigSecret)
+		config := map[string]interface{}{}
+		if err := json.Unmarshal(source.config, &config); err != nil {
+			return nil, fmt.Errorf("invalid config %s %s: %s", source.kind, source.name, err)
+		}
+		serverconfig.MergeConfig(merged, config)
+	}
+
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	jsonConfig, err := dc.GetConfigAsJSON(b)
+	if err != nil {
+		return nil, err
 	}
+	return []byte(jsonConfig), nil
 }
 
 // getDatacenterConfigSecretName The format is clusterName-dcName-config
@@ -0,0 +1,110 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestCheckConfigSecret_ConfigSecrets(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Annotations = map[string]string{}
+	dc.Spec.ConfigSecret = "config-defaults"
+	dc.Spec.ConfigSecrets = []string{"config-overrides"}
+
+	for name, config := range map[string]string{
+		"config-defaults":  `{"cassandra-yaml":{"read_request_timeout_in_ms":10000,"concurrent_reads":32}}`,
+		"config-overrides": `{"cassandra-yaml":{"read_request_timeout_in_ms":5000}}`,
+	} {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: dc.Namespace, Annotations: map[string]string{}},
+			Data:       map[string][]byte{"config": []byte(config)},
+		}
+		assert.NoError(t, rc.Client.Create(rc.Ctx, secret))
+	}
+
+	assert.False(t, rc.CheckConfigSecret().Completed())
+
+	secret := &corev1.Secret{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: getDatacenterConfigSecretName(dc), Namespace: dc.Namespace}, secret)
+	assert.NoError(t, err)
+	assert.Contains(t, string(secret.Data["config"]), `"read_request_timeout_in_ms":5000`)
+	assert.Contains(t, string(secret.Data["config"]), `"concurrent_reads":32`)
+
+	// Both secrets are watched
+	for _, name := range dc.GetConfigSecretNames() {
+		assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: name, Namespace: dc.Namespace}, secret))
+		assert.Equal(t, dc.Name, secret.Annotations[api.DatacenterAnnotation])
+	}
+}
+
+func TestCheckConfigSecret_ConfigConfigMap(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Annotations = map[string]string{}
+	dc.Spec.ConfigConfigMap = "config-tuning"
+	dc.Spec.ConfigSecret = "config-secret"
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config-tuning", Namespace: dc.Namespace},
+		Data:       map[string]string{"config": `{"cassandra-yaml":{"concurrent_reads":32,"authenticator":"AllowAllAuthenticator"}}`},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, configMap))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "config-secret", Namespace: dc.Namespace},
+		Data:       map[string][]byte{"config": []byte(`{"cassandra-yaml":{"authenticator":"PasswordAuthenticator"}}`)},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, secret))
+
+	assert.False(t, rc.CheckConfigSecret().Completed())
+
+	dcConfigSecret := &corev1.Secret{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: getDatacenterConfigSecretName(dc), Namespace: dc.Namespace}, dcConfigSecret)
+	assert.NoError(t, err)
+	assert.Contains(t, string(dcConfigSecret.Data["config"]), `"concurrent_reads":32`)
+	assert.Contains(t, string(dcConfigSecret.Data["config"]), `"authenticator":"PasswordAuthenticator"`, "the secret should take precedence")
+
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: configMap.Name, Namespace: dc.Namespace}, configMap))
+	assert.Equal(t, dc.Name, configMap.Annotations[api.DatacenterAnnotation], "the ConfigMap should be watched")
+
+	dc.Spec.ConfigSecret = ""
+	configMap.Data = map[string]string{}
+	assert.NoError(t, rc.Client.Update(rc.Ctx, configMap))
+	assert.True(t, rc.CheckConfigSecret().Completed(), "the ConfigMap should have a config property")
+}
+
+func TestCheckDatacenterNameAnnotation_SharedSecret(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "config-defaults",
+			Namespace:   dc.Namespace,
+			Annotations: map[string]string{api.DatacenterAnnotation: "aaa-dc"},
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, secret))
+
+	assert.NoError(t, rc.checkDatacenterNameAnnotation(secret))
+	assert.NoError(t, rc.checkDatacenterNameAnnotation(secret), "the datacenter is only added once")
+
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: secret.Name, Namespace: dc.Namespace}, secret))
+	assert.Equal(t, "aaa-dc,"+dc.Name, secret.Annotations[api.DatacenterAnnotation],
+		"the other datacenter sharing the secret should still be watched")
+	assert.Equal(t, []string{"aaa-dc", dc.Name}, api.AnnotatedDatacenters(secret.Annotations))
+}
@@ -4,6 +4,11 @@
 package reconciliation
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/k8ssandra/cass-operator/operator/internal/result"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -13,7 +18,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
-	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
 )
 
 // ProcessDeletion ...
@@ -22,11 +28,25 @@ func (rc *ReconciliationContext) ProcessDeletion() result.ReconcileResult {
 		return result.Continue()
 	}
 
+	if !hasFinalizer(rc.Datacenter) {
+		// Already cleaned up, the datacenter waits for the finalizers of
+		// other controllers
+		return result.Done()
+	}
+
+	if recResult := rc.checkDeletionConfirmed(); recResult.Completed() {
+		return recResult
+	}
+
 	// set the label here but no need to remove since we're deleting the CassandraDatacenter
 	if err := setOperatorProgressStatus(rc, api.ProgressUpdating); err != nil {
 		return result.Error(err)
 	}
 
+	if recResult := rc.decommissionDatacenter(); recResult.Completed() {
+		return recResult
+	}
+
 	// Clean up annotation litter on the user Secrets
 	err := rc.SecretWatches.RemoveWatcher(types.NamespacedName{
 		Name: rc.Datacenter.GetName(), Namespace: rc.Datacenter.GetNamespace()})
@@ -35,19 +55,21 @@ func (rc *ReconciliationContext) ProcessDeletion() result.ReconcileResult {
 		rc.ReqLogger.Error(err, "Failed to remove dynamic secret watches for CassandraDatacenter")
 	}
 
-	if err := rc.deletePVCs(); err != nil {
-		rc.ReqLogger.Error(err, "Failed to delete PVCs for CassandraDatacenter")
-		return result.Error(err)
+	if rc.Datacenter.DeletePVCsWhenDeleted() {
+		if err := rc.deletePVCs(); err != nil {
+			rc.ReqLogger.Error(err, "Failed to delete PVCs for CassandraDatacenter")
+			return result.Error(err)
+		}
+	} else {
+		rc.ReqLogger.Info("Keeping the PVCs of the CassandraDatacenter as requested by its persistentVolumeClaimRetentionPolicy")
 	}
 
-	if utils.IsPSPEnabled() {
-		rc.RemoveDcFromNodeToDcMap(types.NamespacedName{
-			Name:      rc.Datacenter.GetName(),
-			Namespace: rc.Datacenter.GetNamespace()})
-	}
+	rc.RemoveDcFromNodeToDcMap(types.NamespacedName{
+		Name:      rc.Datacenter.GetName(),
+		Namespace: rc.Datacenter.GetNamespace()})
 
 	// Update finalizer to allow delete of CassandraDatacenter
-	rc.Datacenter.SetFinalizers(nil)
+	removeFinalizer(rc.Datacenter)
 
 	// Update CassandraDatacenter
 	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
@@ -58,6 +80,167 @@ func (rc *ReconciliationContext) ProcessDeletion() result.ReconcileResult {
 	return result.Done()
 }
 
+// decommissionDatacenter removes the nodes of a datacenter that is being
+// deleted from the cluster when decommissionOnDelete is set, one node at a
+// time in the reverse order of their ordinals. It returns Continue once every
+// node has left the ring, so the rest of the datacenter can be deleted.
+func (rc *ReconciliationContext) decommissionDatacenter() result.ReconcileResult {
+	dc := rc.Datacenter
+	logger := rc.ReqLogger
+
+	if !dc.Spec.DecommissionOnDelete {
+		return result.Continue()
+	}
+
+	podList, err := rc.listPods(dc.GetClusterLabels())
+	if err != nil {
+		logger.Error(err, "error listing all pods in the cluster")
+		return result.Error(err)
+	}
+
+	rc.clusterPods = PodPtrsFromPodList(podList)
+	rc.dcPods = FilterPodListByLabels(rc.clusterPods, dc.GetDatacenterLabels())
+
+	var otherPods []*corev1.Pod
+	for _, pod := range rc.clusterPods {
+		if pod.Labels[api.DatacenterLabel] != dc.Name {
+			otherPods = append(otherPods, pod)
+		}
+	}
+
+	if len(otherPods) == 0 {
+		// This is the last datacenter of the cluster, so there is no ring
+		// left for the nodes to leave
+		logger.Info("No other datacenters in the cluster, skipping decommission")
+		return result.Continue()
+	}
+
+	// Ask the other datacenters, as they keep seeing the nodes of this one
+	// after they have left
+	epData := rc.getCassMetadataEndpointsFrom(otherPods)
+	if len(epData.Entity) == 0 {
+		logger.Info("Waiting for a node of another datacenter to report the ring before decommissioning")
+		return result.RequeueSoon(10)
+	}
+
+	// The keyspaces must not be replicated to the datacenter anymore for its
+	// nodes to be decommissioned, whether the operator manages them or not
+	otherPod := findReadyPod(otherPods)
+	if otherPod == nil {
+		logger.Info("Waiting for a node of another datacenter to be ready to remove the datacenter from the replication of the keyspaces")
+		return result.RequeueSoon(10)
+	}
+	if err := rc.removeDatacenterReplication(otherPod, dc.Name); err != nil {
+		logger.Error(err, "error removing the datacenter from the replication of the keyspaces")
+		return result.Error(err)
+	}
+
+	pods := make([]*corev1.Pod, len(rc.dcPods))
+	copy(pods, rc.dcPods)
+	sort.Slice(pods, func(i, j int) bool {
+		iOrdinal, jOrdinal := podOrdinal(pods[i]), podOrdinal(pods[j])
+		if iOrdinal != jOrdinal {
+			return iOrdinal > jOrdinal
+		}
+		return pods[i].Name > pods[j].Name
+	})
+
+	for _, pod := range pods {
+		if IsDoneDecommissioning(pod, epData) {
+			continue
+		}
+
+		if pod.Labels[api.CassNodeState] == stateDecommissioning {
+			if !HasStartedDecommissioning(pod, epData) {
+				logger.Info("Decommission has not started trying again", "pod", pod.Name)
+				if err := rc.NodeMgmtClient.CallDecommissionNodeEndpoint(pod); err != nil {
+					logger.Info(fmt.Sprintf("Error from decommission attempt. This is only an attempt and can fail. Error: %v", err))
+				}
+			} else {
+				logger.Info("Node decommissioning, reconciling again soon", "pod", pod.Name)
+			}
+			return result.RequeueSoon(10)
+		}
+
+		if !isMgmtApiRunning(pod) {
+			logger.Info("Waiting for the Management API to be up on the node to decommission", "pod", pod.Name)
+			return result.RequeueSoon(10)
+		}
+
+		if err := rc.markDatacenterDecommissioning(); err != nil {
+			return result.Error(err)
+		}
+
+		if err := rc.NodeMgmtClient.CallDecommissionNodeEndpoint(pod); err != nil {
+			logger.Info(fmt.Sprintf("Error from decommission attempt. This is only an attempt and can"+
+				" fail it will be retried later if decomission has not started. Error: %v", err))
+		}
+
+		logger.Info("Marking node as decommissioning", "pod", pod.Name)
+		patch := client.MergeFrom(pod.DeepCopy())
+		pod.Labels[api.CassNodeState] = stateDecommissioning
+		if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
+			return result.Error(err)
+		}
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.LabeledPodAsDecommissioning,
+			"Labeled node as decommissioning %s", pod.Name)
+
+		return result.RequeueSoon(10)
+	}
+
+	logger.Info("All nodes of the datacenter have been decommissioned")
+	return result.Continue()
+}
+
+func (rc *ReconciliationContext) markDatacenterDecommissioning() error {
+	dc := rc.Datacenter
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	decommissioning := rc.conditionTransitions(api.DatacenterDecommissioning, corev1.ConditionTrue)
+	if !rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterDecommissioning, corev1.ConditionTrue,
+		"DecommissioningDatacenter", "Decommissioning the nodes before deleting the datacenter")) {
+		return nil
+	}
+
+	if decommissioning {
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.DecommissioningDatacenter,
+			"Decommissioning %d nodes before deleting the datacenter", len(rc.dcPods))
+	}
+
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status for decommissioning")
+		return err
+	}
+	return nil
+}
+
+// getCassMetadataEndpointsFrom returns the endpoints reported by the first of
+// the given pods that answers
+func (rc *ReconciliationContext) getCassMetadataEndpointsFrom(pods []*corev1.Pod) httphelper.CassMetadataEndpoints {
+	var metadata httphelper.CassMetadataEndpoints
+	for _, pod := range pods {
+		if !isServerReady(pod) {
+			continue
+		}
+
+		metadata, _ = rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(pod)
+		if len(metadata.Entity) > 0 {
+			break
+		}
+	}
+	return metadata
+}
+
+// podOrdinal returns the ordinal of a statefulset pod, or -1 if its name
+// does not end with one
+func podOrdinal(pod *corev1.Pod) int {
+	ordinal, err := strconv.Atoi(pod.Name[strings.LastIndex(pod.Name, "-")+1:])
+	if err != nil {
+		return -1
+	}
+	return ordinal
+}
+
 func (rc *ReconciliationContext) deletePVCs() error {
 	rc.ReqLogger.Info("reconciler::deletePVCs")
 	logger := rc.ReqLogger.WithValues(
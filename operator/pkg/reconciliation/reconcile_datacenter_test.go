@@ -5,7 +5,11 @@ package reconciliation
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,7 +17,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
 	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
 )
 
@@ -117,3 +124,134 @@ func TestDeletePVCs_FailedToDelete(t *testing.T) {
 
 	assert.EqualError(t, err, "failed to delete")
 }
+
+// setupDecommissionTest creates two pods in the datacenter and one in another
+// datacenter of the cluster, with the given ring statuses for sts-0 and sts-1.
+// The pods that decommission was called on are recorded in the returned slice.
+func setupDecommissionTest(t *testing.T, rc *ReconciliationContext, sts0Status, sts1Status string) *[]string {
+	dc := rc.Datacenter
+	dc.Spec.DecommissionOnDelete = true
+
+	started := metav1.NewTime(time.Now().Add(-time.Minute))
+	makePod := func(name, ip string, labels map[string]string) {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: dc.Namespace, Labels: labels},
+			Status: v1.PodStatus{
+				PodIP: ip,
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:  "cassandra",
+					Ready: true,
+					State: v1.ContainerState{Running: &v1.ContainerStateRunning{StartedAt: started}},
+				}},
+			},
+		}
+		if err := rc.Client.Create(rc.Ctx, pod); err != nil {
+			t.Fatalf("failed to create pod: %s", err)
+		}
+	}
+
+	makePod("cluster-dc1-r1-sts-0", "10.0.0.0", dc.GetDatacenterLabels())
+	makePod("cluster-dc1-r1-sts-1", "10.0.0.1", dc.GetDatacenterLabels())
+	otherLabels := dc.GetClusterLabels()
+	otherLabels[api.DatacenterLabel] = "dc2"
+	makePod("cluster-dc2-r1-sts-0", "10.0.1.0", otherLabels)
+
+	endpoints := fmt.Sprintf(`{"entity": [
+		{"RPC_ADDRESS": "10.0.0.0", "IS_ALIVE": "true", "STATUS": "%s"},
+		{"RPC_ADDRESS": "10.0.0.1", "IS_ALIVE": "true", "STATUS": "%s"},
+		{"RPC_ADDRESS": "10.0.1.0", "IS_ALIVE": "true", "STATUS": "NORMAL,3"}
+	]}`, sts0Status, sts1Status)
+
+	var decommissioned []string
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil
+			})).
+		Return(func(req *http.Request) *http.Response {
+			body := "OK"
+			if req.URL.Path == "/api/v0/metadata/endpoints" {
+				body = endpoints
+			} else if req.URL.Path == "/api/v0/ops/keyspace" {
+				body = "[]"
+			} else if req.URL.Path == "/api/v0/ops/node/decommission" {
+				decommissioned = append(decommissioned, req.URL.Host)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}
+		}, nil)
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	return &decommissioned
+}
+
+func TestDecommissionDatacenter_Disabled(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	recResult := rc.decommissionDatacenter()
+	assert.False(t, recResult.Completed())
+}
+
+func TestDecommissionDatacenter_LastDatacenter(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.DecommissionOnDelete = true
+
+	recResult := rc.decommissionDatacenter()
+	assert.False(t, recResult.Completed(), "the last datacenter has no ring to leave")
+}
+
+func TestDecommissionDatacenter_HighestOrdinalFirst(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	decommissioned := setupDecommissionTest(t, rc, "NORMAL,1", "NORMAL,2")
+
+	recResult := rc.decommissionDatacenter()
+	assert.True(t, recResult.Completed())
+	assert.Len(t, *decommissioned, 1)
+	assert.Contains(t, (*decommissioned)[0], "10.0.0.1")
+
+	pod := &v1.Pod{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: "cluster-dc1-r1-sts-1", Namespace: rc.Datacenter.Namespace}, pod)
+	assert.NoError(t, err)
+	assert.Equal(t, stateDecommissioning, pod.Labels[api.CassNodeState])
+	assert.Equal(t, v1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterDecommissioning))
+}
+
+func TestDecommissionDatacenter_WaitsForLeavingNode(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	decommissioned := setupDecommissionTest(t, rc, "NORMAL,1", "LEAVING,2")
+
+	pod := &v1.Pod{}
+	key := types.NamespacedName{Name: "cluster-dc1-r1-sts-1", Namespace: rc.Datacenter.Namespace}
+	if err := rc.Client.Get(rc.Ctx, key, pod); err != nil {
+		t.Fatalf("failed to get pod: %s", err)
+	}
+	pod.Labels[api.CassNodeState] = stateDecommissioning
+	if err := rc.Client.Update(rc.Ctx, pod); err != nil {
+		t.Fatalf("failed to label pod: %s", err)
+	}
+
+	recResult := rc.decommissionDatacenter()
+	assert.True(t, recResult.Completed())
+	assert.Empty(t, *decommissioned, "sts-0 should wait for sts-1 to leave")
+}
+
+func TestDecommissionDatacenter_AllNodesLeft(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	decommissioned := setupDecommissionTest(t, rc, "LEFT,1", "LEFT,2")
+
+	recResult := rc.decommissionDatacenter()
+	assert.False(t, recResult.Completed())
+	assert.Empty(t, *decommissioned)
+}
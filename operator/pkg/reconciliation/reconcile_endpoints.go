@@ -4,19 +4,27 @@
 package reconciliation
 
 import (
+	"sort"
+
 	"github.com/k8ssandra/cass-operator/operator/internal/result"
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
 )
 
+// How often the additional seed endpoints are refreshed
+const additionalSeedsRefreshSeconds = 60
+
 func (rc *ReconciliationContext) CreateEndpointsForAdditionalSeedService() result.ReconcileResult {
 	// unpacking
 	logger := rc.ReqLogger
-	client := rc.Client
 	endpoints := rc.Endpoints
 
 	logger.Info(
@@ -28,7 +36,7 @@ func (rc *ReconciliationContext) CreateEndpointsForAdditionalSeedService() resul
 		return result.Error(err)
 	}
 
-	if err := client.Create(rc.Ctx, endpoints); err != nil {
+	if err := rc.createResource(endpoints); err != nil {
 		logger.Error(err, "Could not create endpoints for additional seed service")
 
 		return result.Error(err)
@@ -47,11 +55,23 @@ func (rc *ReconciliationContext) CheckAdditionalSeedEndpoints() result.Reconcile
 
 	logger.Info("reconcile_endpoints::CheckAdditionalSeedEndpoints")
 
-	if len(dc.Spec.AdditionalSeeds) == 0 {
-		return result.Continue()
+	if !dc.HasAdditionalSeeds() {
+		return rc.deleteAdditionalSeedService()
+	}
+
+	seedDatacenterAddresses, err := rc.additionalSeedDatacenterAddresses()
+	if err != nil {
+		logger.Error(err, "Could not get the seeds of the additional seed datacenters")
+		return result.Error(err)
 	}
 
-	desiredEndpoints, err := newEndpointsForAdditionalSeeds(dc)
+	clusterSeeds, err := rc.clusterSeedAddresses()
+	if err != nil {
+		logger.Error(err, "Could not get the cluster seeds")
+		return result.Error(err)
+	}
+
+	desiredEndpoints, err := newEndpointsForAdditionalSeeds(dc, append(clusterSeeds, seedDatacenterAddresses...))
 	if err != nil {
 		logger.Error(err, "Could not set additional seeds for endpoints for additional seed service")
 		return result.Error(err)
@@ -85,22 +105,13 @@ func (rc *ReconciliationContext) CheckAdditionalSeedEndpoints() result.Reconcile
 	} else {
 		// if we found the endpoints already, check if it needs updating
 		if !utils.ResourcesHaveSameHash(currentEndpoints, desiredEndpoints) {
-			resourceVersion := currentEndpoints.GetResourceVersion()
-			// preserve any labels and annotations that were added to the Endpoints post-creation
-			desiredEndpoints.Labels = utils.MergeMap(map[string]string{}, currentEndpoints.Labels, desiredEndpoints.Labels)
-			desiredEndpoints.Annotations = utils.MergeMap(map[string]string{}, currentEndpoints.Annotations, desiredEndpoints.Annotations)
-
 			logger.Info("Updating endpoints for additional seed service",
 				"endpoints", currentEndpoints,
 				"desired", desiredEndpoints)
 
-			desiredEndpoints.DeepCopyInto(currentEndpoints)
-
-			currentEndpoints.SetResourceVersion(resourceVersion)
-
-			if err := client.Update(rc.Ctx, currentEndpoints); err != nil {
+			if err := rc.updateResource(desiredEndpoints, currentEndpoints); err != nil {
 				logger.Error(err, "Unable to update endpoints for additional seed service",
-					"endpoints", currentEndpoints)
+					"endpoints", nsName)
 				return result.Error(err)
 			}
 		}
@@ -113,3 +124,73 @@ func (rc *ReconciliationContext) CheckAdditionalSeedEndpoints() result.Reconcile
 
 	return result.Continue()
 }
+
+// additionalSeedDatacenterAddresses returns the sorted IP addresses the seed
+// pods of the additional seed datacenters broadcast, according to the address
+// type of their datacenter. Datacenters that do not exist (yet) are skipped.
+func (rc *ReconciliationContext) additionalSeedDatacenterAddresses() ([]string, error) {
+	var addresses []string
+	for _, key := range rc.Datacenter.GetAdditionalSeedDatacenterKeys() {
+		seedDc := &api.CassandraDatacenter{}
+		if err := rc.Client.Get(rc.Ctx, key, seedDc); err != nil {
+			if errors.IsNotFound(err) {
+				rc.ReqLogger.Info("Additional seed datacenter not found", "datacenter", key)
+				continue
+			}
+			return nil, err
+		}
+
+		selector := seedDc.GetDatacenterLabels()
+		selector[api.SeedNodeLabel] = "true"
+
+		podList := &corev1.PodList{}
+		listOptions := []client.ListOption{
+			client.InNamespace(seedDc.Namespace),
+			client.MatchingLabels(selector),
+		}
+		if err := rc.Client.List(rc.Ctx, podList, listOptions...); err != nil {
+			return nil, err
+		}
+
+		for idx := range podList.Items {
+			if address := getBroadcastAddress(seedDc, &podList.Items[idx]); address != "" {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+
+	// The pods are not listed in any particular order, which would otherwise
+	// change the hash of the endpoints
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// deleteAdditionalSeedService removes the additional seed service and its
+// endpoints once the additional seeds are removed from the spec. A service or
+// endpoints of the same name without the managed-by label of the operator
+// were not created by it, and are left alone.
+func (rc *ReconciliationContext) deleteAdditionalSeedService() result.ReconcileResult {
+	dc := rc.Datacenter
+	nsName := types.NamespacedName{Name: dc.GetAdditionalSeedsServiceName(), Namespace: dc.Namespace}
+
+	for _, obj := range []runtime.Object{&corev1.Endpoints{}, &corev1.Service{}} {
+		err := rc.Client.Get(rc.Ctx, nsName, obj)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			rc.ReqLogger.Error(err, "Could not get additional seed service resource", "name", nsName)
+			return result.Error(err)
+		}
+		if !oplabels.HasManagedByCassandraOperatorLabel(obj.(metav1.Object).GetLabels()) {
+			continue
+		}
+
+		rc.ReqLogger.Info("Deleting additional seed service resource", "name", nsName)
+		if err := rc.Client.Delete(rc.Ctx, obj); err != nil && !errors.IsNotFound(err) {
+			rc.ReqLogger.Error(err, "Could not delete additional seed service resource", "name", nsName)
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
@@ -0,0 +1,160 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+)
+
+func TestNewEndpointsForAdditionalSeeds(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:     "bob",
+			AdditionalSeeds: []string{"192.168.1.1"},
+		},
+	}
+
+	endpoints, err := newEndpointsForAdditionalSeeds(dc, []string{"10.0.0.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "bob-dc1-additional-seed-service", endpoints.Name)
+	assert.Equal(t, []corev1.EndpointSubset{{
+		Addresses: []corev1.EndpointAddress{{IP: "192.168.1.1"}, {IP: "10.0.0.1"}},
+	}}, endpoints.Subsets)
+
+	// The seed datacenters may not have any seeds yet
+	dc.Spec.AdditionalSeeds = nil
+	endpoints, err = newEndpointsForAdditionalSeeds(dc, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, endpoints.Subsets)
+}
+
+func TestCheckAdditionalSeedEndpoints_SeedDatacenters(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	seedDc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc2", Namespace: "other"},
+		Spec:       api.CassandraDatacenterSpec{ClusterName: rc.Datacenter.Spec.ClusterName},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, seedDc))
+
+	for _, pod := range []struct {
+		name, ip, hostIP string
+		seed             bool
+	}{
+		{"dc2-pod-0", "10.0.0.2", "192.168.0.2", true},
+		{"dc2-pod-1", "10.0.0.1", "192.168.0.1", true},
+		{"dc2-pod-2", "10.0.0.3", "192.168.0.3", false},
+	} {
+		labels := seedDc.GetDatacenterLabels()
+		if pod.seed {
+			labels[api.SeedNodeLabel] = "true"
+		}
+		assert.NoError(t, rc.Client.Create(rc.Ctx, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: pod.name, Namespace: seedDc.Namespace, Labels: labels},
+			Status:     corev1.PodStatus{PodIP: pod.ip, HostIP: pod.hostIP},
+		}))
+	}
+
+	rc.Datacenter.Spec.AdditionalSeedDatacenters = []corev1.ObjectReference{
+		{Name: "dc2", Namespace: "other"},
+		{Name: "missing"},
+	}
+
+	recResult := rc.CheckAdditionalSeedEndpoints()
+	assert.False(t, recResult.Completed())
+
+	endpoints := &corev1.Endpoints{}
+	nsName := types.NamespacedName{Name: rc.Datacenter.GetAdditionalSeedsServiceName(), Namespace: rc.Datacenter.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, nsName, endpoints))
+	assert.Equal(t, []corev1.EndpointSubset{{
+		Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+	}}, endpoints.Subsets)
+
+	// The seeds of datacenters broadcasting the IPs of their workers are
+	// reached at them
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: "dc2", Namespace: "other"}, seedDc))
+	seedDc.Spec.Networking = &api.NetworkingConfig{AddressType: api.AddressTypeHostIP}
+	assert.NoError(t, rc.Client.Update(rc.Ctx, seedDc))
+	assert.False(t, rc.CheckAdditionalSeedEndpoints().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, nsName, endpoints))
+	assert.Equal(t, []corev1.EndpointSubset{{
+		Addresses: []corev1.EndpointAddress{{IP: "192.168.0.1"}, {IP: "192.168.0.2"}},
+	}}, endpoints.Subsets)
+}
+
+func TestCheckAdditionalSeedEndpoints_ClusterSeeds(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ClusterSeeds = []api.ClusterSeedsSource{
+		{Addresses: []string{"192.168.1.1"}},
+		{ConfigMapName: "remote-seeds"},
+		{ConfigMapName: "other-cluster-seeds"},
+		{ConfigMapName: "missing"},
+	}
+	remote := newSeedsConfigMapForCassandraDatacenter(dc, []string{"192.168.2.1", "192.168.2.2"})
+	remote.Name = "remote-seeds"
+	assert.NoError(t, rc.Client.Create(rc.Ctx, remote))
+	otherCluster := newSeedsConfigMapForCassandraDatacenter(dc, []string{"192.168.3.1"})
+	otherCluster.Name = "other-cluster-seeds"
+	otherCluster.Data["cluster"] = "other"
+	assert.NoError(t, rc.Client.Create(rc.Ctx, otherCluster))
+
+	assert.False(t, rc.CheckAdditionalSeedEndpoints().Completed())
+
+	endpoints := &corev1.Endpoints{}
+	nsName := types.NamespacedName{Name: dc.GetAdditionalSeedsServiceName(), Namespace: dc.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, nsName, endpoints))
+	assert.Equal(t, []corev1.EndpointSubset{{
+		Addresses: []corev1.EndpointAddress{{IP: "192.168.1.1"}, {IP: "192.168.2.1"}, {IP: "192.168.2.2"}},
+	}}, endpoints.Subsets)
+}
+
+func TestCheckAdditionalSeedEndpoints_DeletesWhenRemoved(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.AdditionalSeeds = []string{"192.168.1.1"}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, newAdditionalSeedServiceForCassandraDatacenter(rc.Datacenter)))
+	recResult := rc.CheckAdditionalSeedEndpoints()
+	assert.False(t, recResult.Completed())
+
+	rc.Datacenter.Spec.AdditionalSeeds = nil
+	recResult = rc.CheckAdditionalSeedEndpoints()
+	assert.False(t, recResult.Completed())
+
+	nsName := types.NamespacedName{Name: rc.Datacenter.GetAdditionalSeedsServiceName(), Namespace: rc.Datacenter.Namespace}
+	err := rc.Client.Get(rc.Ctx, nsName, &corev1.Endpoints{})
+	assert.True(t, errors.IsNotFound(err), "the endpoints should be deleted")
+	err = rc.Client.Get(rc.Ctx, nsName, &corev1.Service{})
+	assert.True(t, errors.IsNotFound(err), "the service should be deleted")
+}
+
+func TestCheckAdditionalSeedEndpoints_KeepsUnmanagedService(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	service := newAdditionalSeedServiceForCassandraDatacenter(rc.Datacenter)
+	delete(service.Labels, oplabels.ManagedByLabel)
+	assert.NoError(t, rc.Client.Create(rc.Ctx, service))
+
+	recResult := rc.CheckAdditionalSeedEndpoints()
+	assert.False(t, recResult.Completed())
+
+	nsName := types.NamespacedName{Name: rc.Datacenter.GetAdditionalSeedsServiceName(), Namespace: rc.Datacenter.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, nsName, &corev1.Service{}),
+		"a service the operator did not create should not be deleted")
+}
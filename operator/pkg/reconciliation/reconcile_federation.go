@@ -0,0 +1,235 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+)
+
+// Keys of the seeds ConfigMap a datacenter publishes with spec.federation,
+// which the datacenters of the other Kubernetes clusters read from
+// spec.clusterSeeds
+const (
+	seedsConfigMapClusterKey    = "cluster"
+	seedsConfigMapDatacenterKey = "datacenter"
+	seedsConfigMapSeedsKey      = "seeds"
+)
+
+// newSeedsConfigMapForCassandraDatacenter creates the ConfigMap the seeds of
+// the datacenter are published to, one address per line
+func newSeedsConfigMapForCassandraDatacenter(dc *api.CassandraDatacenter, seeds []string) *corev1.ConfigMap {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(labels)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dc.GetSeedsConfigMapName(),
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			seedsConfigMapClusterKey:    dc.Spec.ClusterName,
+			seedsConfigMapDatacenterKey: dc.Name,
+			seedsConfigMapSeedsKey:      strings.Join(seeds, "\n"),
+		},
+	}
+
+	addAdditionalMetadata(dc, configMap)
+
+	return configMap
+}
+
+// newFederationSecretForCassandraDatacenter creates the secret the superuser
+// credentials and the internode CA of the datacenter are exported to. Its keys
+// are the ones of a superuser secret and of the CA the operator generates, so
+// that the datacenters of the other Kubernetes clusters import it as is.
+func newFederationSecretForCassandraDatacenter(dc *api.CassandraDatacenter, superuser, ca *corev1.Secret) *corev1.Secret {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(labels)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dc.GetFederationSecretName(),
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string][]byte{
+			"username": superuser.Data["username"],
+			"password": superuser.Data["password"],
+			"key":      ca.Data["key"],
+			"cert":     ca.Data["cert"],
+		},
+	}
+
+	addAdditionalMetadata(dc, secret)
+
+	return secret
+}
+
+// publishedSeeds returns the sorted addresses the seed pods of the datacenter
+// broadcast
+func (rc *ReconciliationContext) publishedSeeds() []string {
+	var seeds []string
+	for _, pod := range rc.dcPods {
+		if pod.Labels[api.SeedNodeLabel] != "true" {
+			continue
+		}
+		if address := getBroadcastAddress(rc.Datacenter, pod); address != "" {
+			seeds = append(seeds, address)
+		}
+	}
+	sort.Strings(seeds)
+	return seeds
+}
+
+// CheckFederation publishes the seeds of the datacenter and exports its
+// secrets with spec.federation.publish, or deletes them without it
+func (rc *ReconciliationContext) CheckFederation() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.IsFederationPublished() {
+		if err := rc.deleteFederationResources(); err != nil {
+			return result.Error(err)
+		}
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_federation::CheckFederation")
+
+	desiredConfigMap := newSeedsConfigMapForCassandraDatacenter(dc, rc.publishedSeeds())
+	if err := rc.upsertFederationResource(desiredConfigMap, &corev1.ConfigMap{}, func(current runtime.Object) bool {
+		return reflect.DeepEqual(current.(*corev1.ConfigMap).Data, desiredConfigMap.Data)
+	}); err != nil {
+		return result.Error(err)
+	}
+
+	superuser, err := rc.retrieveSuperuserSecret()
+	if err != nil {
+		rc.ReqLogger.Error(err, "failed to get the superuser secret to export")
+		return result.Error(err)
+	}
+	ca, err := rc.retrieveSecret(rc.keystoreCASecret())
+	if err != nil {
+		rc.ReqLogger.Error(err, "failed to get the internode CA to export")
+		return result.Error(err)
+	}
+	desiredSecret := newFederationSecretForCassandraDatacenter(dc, superuser, ca)
+	if err := rc.upsertFederationResource(desiredSecret, &corev1.Secret{}, func(current runtime.Object) bool {
+		return reflect.DeepEqual(current.(*corev1.Secret).Data, desiredSecret.Data)
+	}); err != nil {
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+// upsertFederationResource creates the desired resource, or updates the
+// current one when its data is not the same
+func (rc *ReconciliationContext) upsertFederationResource(desired, current runtime.Object, sameData func(runtime.Object) bool) error {
+	desiredMeta := desired.(metav1.Object)
+	if err := setControllerReference(rc.Datacenter, desiredMeta, rc.Scheme); err != nil {
+		return err
+	}
+
+	key := types.NamespacedName{Name: desiredMeta.GetName(), Namespace: desiredMeta.GetNamespace()}
+	err := rc.Client.Get(rc.Ctx, key, current)
+	if errors.IsNotFound(err) {
+		rc.ReqLogger.Info("Creating a federation resource", "name", key.Name)
+		if err := rc.createResource(desired); err != nil {
+			return err
+		}
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.CreatedResource,
+			"Created %s", key.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	currentMeta := current.(metav1.Object)
+	if sameData(current) && containsAll(currentMeta.GetLabels(), desiredMeta.GetLabels()) &&
+		containsAll(currentMeta.GetAnnotations(), desiredMeta.GetAnnotations()) {
+		return nil
+	}
+
+	rc.ReqLogger.Info("Updating a federation resource", "name", key.Name)
+	return rc.updateResource(desired, current)
+}
+
+// deleteFederationResources deletes the seeds ConfigMap and the federation
+// secret the operator published for the datacenter, if any
+func (rc *ReconciliationContext) deleteFederationResources() error {
+	dc := rc.Datacenter
+	resources := []struct {
+		name string
+		obj  runtime.Object
+	}{
+		{dc.GetSeedsConfigMapName(), &corev1.ConfigMap{}},
+		{dc.GetFederationSecretName(), &corev1.Secret{}},
+	}
+	for _, resource := range resources {
+		key := types.NamespacedName{Name: resource.name, Namespace: dc.Namespace}
+		if err := rc.Client.Get(rc.Ctx, key, resource.obj); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !oplabels.HasManagedByCassandraOperatorLabel(resource.obj.(metav1.Object).GetLabels()) {
+			continue
+		}
+		rc.ReqLogger.Info("Deleting a federation resource", "name", resource.name)
+		if err := rc.Client.Delete(rc.Ctx, resource.obj); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// clusterSeedAddresses returns the seeds of spec.clusterSeeds, from their
+// addresses and from the seeds ConfigMaps published by the other Kubernetes
+// clusters. ConfigMaps that do not exist (yet), or are published for another
+// cluster, are skipped.
+func (rc *ReconciliationContext) clusterSeedAddresses() ([]string, error) {
+	dc := rc.Datacenter
+	var addresses []string
+	for _, source := range dc.Spec.ClusterSeeds {
+		addresses = append(addresses, source.Addresses...)
+		if source.ConfigMapName == "" {
+			continue
+		}
+
+		configMap := &corev1.ConfigMap{}
+		key := types.NamespacedName{Name: source.ConfigMapName, Namespace: dc.Namespace}
+		if err := rc.Client.Get(rc.Ctx, key, configMap); err != nil {
+			if errors.IsNotFound(err) {
+				rc.ReqLogger.Info("Cluster seeds ConfigMap not found", "configMap", key.Name)
+				continue
+			}
+			return nil, err
+		}
+		if cluster := configMap.Data[seedsConfigMapClusterKey]; cluster != dc.Spec.ClusterName {
+			rc.ReqLogger.Info("Skipping the seeds ConfigMap of another cluster", "configMap", key.Name, "cluster", cluster)
+			continue
+		}
+		for _, seed := range strings.Split(configMap.Data[seedsConfigMapSeedsKey], "\n") {
+			if seed = strings.TrimSpace(seed); seed != "" {
+				addresses = append(addresses, seed)
+			}
+		}
+	}
+	return addresses, nil
+}
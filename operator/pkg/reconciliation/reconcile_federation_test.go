@@ -0,0 +1,78 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestCheckFederation(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Federation = &api.FederationConfig{Publish: true}
+
+	superuser, err := rc.retrieveSuperuserSecretOrCreateDefault()
+	assert.NoError(t, err)
+	_, err = rc.retrieveInternodeCredentialSecretOrCreateDefault()
+	assert.NoError(t, err)
+
+	seedLabels := dc.GetDatacenterLabels()
+	seedLabels[api.SeedNodeLabel] = "true"
+	rc.dcPods = []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: dc.Namespace, Labels: seedLabels},
+			Status:     corev1.PodStatus{PodIP: "10.0.0.2"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: dc.Namespace, Labels: dc.GetDatacenterLabels()},
+			Status:     corev1.PodStatus{PodIP: "10.0.0.3"},
+		},
+	}
+
+	assert.False(t, rc.CheckFederation().Completed())
+	configMap := &corev1.ConfigMap{}
+	configMapKey := types.NamespacedName{Name: dc.GetSeedsConfigMapName(), Namespace: dc.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, configMapKey, configMap))
+	assert.Equal(t, dc.Spec.ClusterName, configMap.Data["cluster"])
+	assert.Equal(t, "10.0.0.2", configMap.Data["seeds"])
+
+	secret := &corev1.Secret{}
+	secretKey := types.NamespacedName{Name: dc.GetFederationSecretName(), Namespace: dc.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, secretKey, secret))
+	assert.Equal(t, superuser.Data["password"], secret.Data["password"])
+	assert.NotEmpty(t, secret.Data["cert"])
+
+	// The published seeds follow the seed pods
+	rc.dcPods[1].Labels = seedLabels
+	assert.False(t, rc.CheckFederation().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, configMapKey, configMap))
+	assert.Equal(t, "10.0.0.2\n10.0.0.3", configMap.Data["seeds"])
+
+	// The federation secret is imported as is by another datacenter
+	other := dc.DeepCopy()
+	other.Name = "dc2"
+	other.Spec.Federation = &api.FederationConfig{ImportSecret: secret.Name}
+	rc.Datacenter = other
+	assert.Equal(t, secret.Name, other.GetSuperuserSecretNamespacedName().Name)
+	ca, err := rc.retrieveInternodeCredentialSecretOrCreateDefault()
+	assert.NoError(t, err)
+	assert.Equal(t, secret.Name, ca.Name)
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: "dc2-keystore", Namespace: dc.Namespace}, &corev1.Secret{}))
+
+	rc.Datacenter = dc
+	dc.Spec.Federation = nil
+	assert.False(t, rc.CheckFederation().Completed())
+	assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, configMapKey, &corev1.ConfigMap{})))
+	assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, secretKey, &corev1.Secret{})))
+}
@@ -0,0 +1,183 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+// Keys of the secret of the keystores the operator generates for the nodes,
+// along with a <pod name>.jks keystore for each node
+const (
+	internodeKeystorePasswordKey = "password"
+	internodeTruststoreKey       = "truststore.jks"
+)
+
+// internodeKeystorePodNames returns the names of the pods of the racks, which
+// need a keystore before they start
+func (rc *ReconciliationContext) internodeKeystorePodNames() []string {
+	var names []string
+	for _, rackInfo := range rc.desiredRackInformation {
+		statefulSetName := newNamespacedNameForStatefulSet(rc.Datacenter, rackInfo.RackName).Name
+		for i := 0; i < rackInfo.NodeCount; i++ {
+			names = append(names, fmt.Sprintf("%s-%d", statefulSetName, i))
+		}
+	}
+	return names
+}
+
+// CheckInternodeKeystores generates the keystores of the nodes that do not
+// have one yet, signed by the CA of the datacenter, when the operator sets up
+// internode encryption without a secret of the user
+func (rc *ReconciliationContext) CheckInternodeKeystores() result.ReconcileResult {
+	dc := rc.Datacenter
+	config := dc.GetInternodeEncryption()
+	if config == nil {
+		return result.Continue()
+	}
+	if config.SecretName != "" {
+		if err := rc.checkInternodeKeystoresHash(config.SecretName); err != nil {
+			return result.Error(err)
+		}
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_internode_encryption::CheckInternodeKeystores")
+
+	// The CA is created by CheckInternodeCredentialCreation
+	ca, err := rc.retrieveSecret(rc.keystoreCASecret())
+	if err != nil {
+		return result.Error(err)
+	}
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: dc.GetInternodeKeystoresSecretName(), Namespace: dc.Namespace}
+	err = rc.Client.Get(rc.Ctx, key, secret)
+	exists := err == nil
+	if err != nil && !errors.IsNotFound(err) {
+		return result.Error(err)
+	}
+
+	if !exists {
+		labels := dc.GetDatacenterLabels()
+		oplabels.AddManagedByLabel(labels)
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    labels,
+			},
+			Data: map[string][]byte{},
+		}
+		addAdditionalMetadata(dc, secret)
+		if err := rc.SetDatacenterAsOwner(secret); err != nil {
+			return result.Error(err)
+		}
+	}
+	patch := client.MergeFrom(secret.DeepCopy())
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+
+	updated := updateAdditionalMetadata(dc, secret)
+	password := string(secret.Data[internodeKeystorePasswordKey])
+	if password == "" {
+		if password, err = generateUtf8Password(); err != nil {
+			return result.Error(err)
+		}
+		secret.Data[internodeKeystorePasswordKey] = []byte(password)
+		updated = true
+	}
+	if _, ok := secret.Data[internodeTruststoreKey]; !ok {
+		truststore, err := utils.GenerateTruststore(ca, password)
+		if err != nil {
+			return result.Error(err)
+		}
+		secret.Data[internodeTruststoreKey] = truststore
+		updated = true
+	}
+	for _, podName := range rc.internodeKeystorePodNames() {
+		if _, ok := secret.Data[podName+".jks"]; ok {
+			continue
+		}
+		rc.ReqLogger.Info("generating the internode keystore of a node", "pod", podName)
+		keystore, err := utils.GenerateJKS(ca, podName, password)
+		if err != nil {
+			return result.Error(err)
+		}
+		secret.Data[podName+".jks"] = keystore
+		updated = true
+	}
+
+	if !exists {
+		err = rc.createResource(secret)
+	} else if updated {
+		err = rc.Client.Patch(rc.Ctx, secret, patch)
+	}
+	if err != nil {
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
+
+// checkInternodeKeystoresHash records the hash of the keystores of the secret
+// of the spec in the datacenter, so that the nodes are restarted or reload
+// them when they change
+func (rc *ReconciliationContext) checkInternodeKeystoresHash(secretName string) error {
+	dc := rc.Datacenter
+	secret, err := rc.retrieveSecret(types.NamespacedName{Name: secretName, Namespace: dc.Namespace})
+	if err != nil {
+		return err
+	}
+
+	keystoresHash := fmt.Sprintf("%x", sha256.Sum256(append(append([]byte{},
+		secret.Data["keystore.jks"]...), secret.Data[internodeTruststoreKey]...)))
+	if dc.Annotations[api.InternodeKeystoresHashAnnotation] == keystoresHash {
+		return nil
+	}
+
+	patch := client.MergeFrom(dc.DeepCopy())
+	if dc.Annotations == nil {
+		dc.Annotations = map[string]string{}
+	}
+	dc.Annotations[api.InternodeKeystoresHashAnnotation] = keystoresHash
+	return rc.Client.Patch(rc.Ctx, dc, patch)
+}
+
+// CheckInternodeEncryptionRollout records the mode of internode encryption
+// once all the nodes run with it, so that the next mode towards the one of
+// the spec can be rolled out
+func (rc *ReconciliationContext) CheckInternodeEncryptionRollout() result.ReconcileResult {
+	dc := rc.Datacenter
+	mode := dc.GetInternodeEncryptionMode()
+	if dc.Status.InternodeEncryptionMode == mode {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("all the nodes run with the internode encryption mode", "mode", mode)
+	patch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.InternodeEncryptionMode = mode
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, patch); err != nil {
+		return result.Error(err)
+	}
+
+	if dc.GetInternodeEncryptionMode() != mode {
+		// Roll out the next mode
+		return result.RequeueSoon(2)
+	}
+	return result.Continue()
+}
@@ -0,0 +1,103 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestCheckInternodeKeystores(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Encryption = &api.EncryptionConfig{
+		Internode: &api.InternodeEncryptionConfig{Mode: api.InternodeEncryptionRequired},
+	}
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 2}}
+
+	assert.False(t, rc.CheckInternodeCredentialCreation().Completed())
+	assert.False(t, rc.CheckInternodeKeystores().Completed())
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: rc.Datacenter.GetInternodeKeystoresSecretName(), Namespace: rc.Datacenter.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, secret))
+	assert.NotEmpty(t, secret.Data["password"])
+	assert.NotEmpty(t, secret.Data["truststore.jks"])
+	assert.NotEmpty(t, secret.Data["cassandradatacenter-example-cluster-cassandradatacenter-example-default-sts-0.jks"])
+	assert.NotEmpty(t, secret.Data["cassandradatacenter-example-cluster-cassandradatacenter-example-default-sts-1.jks"])
+
+	// Scaling up only adds the keystores of the new nodes
+	password := secret.Data["password"]
+	keystore := secret.Data["cassandradatacenter-example-cluster-cassandradatacenter-example-default-sts-0.jks"]
+	rc.desiredRackInformation[0].NodeCount = 3
+	assert.False(t, rc.CheckInternodeKeystores().Completed())
+
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, secret))
+	assert.Equal(t, password, secret.Data["password"])
+	assert.Equal(t, keystore, secret.Data["cassandradatacenter-example-cluster-cassandradatacenter-example-default-sts-0.jks"])
+	assert.NotEmpty(t, secret.Data["cassandradatacenter-example-cluster-cassandradatacenter-example-default-sts-2.jks"])
+}
+
+func TestCheckInternodeEncryptionRollout(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.ServerType = "cassandra"
+	rc.Datacenter.Spec.ServerVersion = "4.0.1"
+	rc.Datacenter.Spec.Encryption = &api.EncryptionConfig{
+		Internode: &api.InternodeEncryptionConfig{Mode: api.InternodeEncryptionRequired},
+	}
+	rc.Datacenter.SetCondition(*api.NewDatacenterCondition(api.DatacenterInitialized, corev1.ConditionTrue))
+	rc.Datacenter.Status.InternodeEncryptionMode = api.InternodeEncryptionNone
+
+	// Optional is rolled out, Required is next
+	recResult := rc.CheckInternodeEncryptionRollout()
+	assert.True(t, recResult.Completed())
+	assert.Equal(t, api.InternodeEncryptionOptional, rc.Datacenter.Status.InternodeEncryptionMode)
+
+	recResult = rc.CheckInternodeEncryptionRollout()
+	assert.False(t, recResult.Completed())
+	assert.Equal(t, api.InternodeEncryptionRequired, rc.Datacenter.Status.InternodeEncryptionMode)
+
+	assert.False(t, rc.CheckInternodeEncryptionRollout().Completed())
+}
+
+func TestCheckInternodeEncryptionRollout_WithoutOptional(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Encryption = &api.EncryptionConfig{
+		Internode: &api.InternodeEncryptionConfig{Mode: api.InternodeEncryptionRequired},
+	}
+	rc.Datacenter.SetCondition(*api.NewDatacenterCondition(api.DatacenterInitialized, corev1.ConditionTrue))
+
+	// The keystores are set up first with None, then DSE goes to Required
+	recResult := rc.CheckInternodeEncryptionRollout()
+	assert.True(t, recResult.Completed())
+	assert.Equal(t, api.InternodeEncryptionNone, rc.Datacenter.Status.InternodeEncryptionMode)
+
+	recResult = rc.CheckInternodeEncryptionRollout()
+	assert.False(t, recResult.Completed())
+	assert.Equal(t, api.InternodeEncryptionRequired, rc.Datacenter.Status.InternodeEncryptionMode)
+}
+
+func TestCheckConditionInitializedAndReady_InternodeEncryptionMode(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Encryption = &api.EncryptionConfig{
+		Internode: &api.InternodeEncryptionConfig{Mode: api.InternodeEncryptionRequired},
+	}
+
+	// The new nodes started with Required, which is not rolled out again
+	rc.CheckConditionInitializedAndReady()
+	assert.Equal(t, api.InternodeEncryptionRequired, rc.Datacenter.Status.InternodeEncryptionMode)
+	assert.Equal(t, api.InternodeEncryptionRequired, rc.Datacenter.GetInternodeEncryptionMode())
+}
@@ -0,0 +1,59 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+// legacyJmxCredentialsHashAnnotation held a hash of the credentials of remote
+// JMX, which older versions of the operator set on the datacenter
+const legacyJmxCredentialsHashAnnotation = "cassandra.datastax.com/jmx-credentials-hash"
+
+// CheckJmxCredentials checks the credentials secret of remote JMX before the
+// pods need it, and records its UID and resource version in the datacenter so
+// that the nodes are restarted with the new credentials when it changes. Any
+// update of the secret restarts them, as nothing derived from the credentials
+// is kept.
+func (rc *ReconciliationContext) CheckJmxCredentials() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.IsRemoteJmxEnabled() {
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_jmx::CheckJmxCredentials")
+
+	secret, err := rc.retrieveSecret(types.NamespacedName{Name: dc.Spec.Jmx.CredentialsSecret, Namespace: dc.Namespace})
+	if err != nil {
+		rc.ReqLogger.Error(err, "failed to get the credentials secret of remote JMX", "secret", dc.Spec.Jmx.CredentialsSecret)
+		return result.Error(err)
+	}
+	if errs := validateCassandraUserSecretContent(dc, secret); len(errs) > 0 {
+		return result.Error(errs[0])
+	}
+
+	credentialsVersion := fmt.Sprintf("%s/%s", secret.UID, secret.ResourceVersion)
+	_, hasLegacyHash := dc.Annotations[legacyJmxCredentialsHashAnnotation]
+	if dc.Annotations[api.JmxCredentialsVersionAnnotation] == credentialsVersion && !hasLegacyHash {
+		return result.Continue()
+	}
+
+	patch := client.MergeFrom(dc.DeepCopy())
+	if dc.Annotations == nil {
+		dc.Annotations = map[string]string{}
+	}
+	dc.Annotations[api.JmxCredentialsVersionAnnotation] = credentialsVersion
+	delete(dc.Annotations, legacyJmxCredentialsHashAnnotation)
+	if err := rc.Client.Patch(rc.Ctx, dc, patch); err != nil {
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
@@ -0,0 +1,51 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestCheckJmxCredentials(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Jmx = &api.JmxConfig{CredentialsSecret: "jmx-credentials"}
+
+	// The pods cannot start without the secret
+	assert.True(t, rc.CheckJmxCredentials().Completed())
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "jmx-credentials", Namespace: dc.Namespace},
+		Data: map[string][]byte{
+			"username": []byte("reaper"),
+			"password": []byte("secret"),
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, secret))
+
+	// The hash of older versions is dropped
+	dc.Annotations = map[string]string{legacyJmxCredentialsHashAnnotation: "former-hash"}
+	assert.False(t, rc.CheckJmxCredentials().Completed())
+	firstVersion := dc.Annotations[api.JmxCredentialsVersionAnnotation]
+	assert.Equal(t, "/"+secret.ResourceVersion, firstVersion)
+	assert.NotContains(t, dc.Annotations, legacyJmxCredentialsHashAnnotation)
+
+	// A new password restarts the nodes
+	secret.Data["password"] = []byte("changed")
+	assert.NoError(t, rc.Client.Update(rc.Ctx, secret))
+	assert.False(t, rc.CheckJmxCredentials().Completed())
+	assert.NotEqual(t, firstVersion, dc.Annotations[api.JmxCredentialsVersionAnnotation])
+
+	delete(secret.Data, "password")
+	assert.NoError(t, rc.Client.Update(rc.Ctx, secret))
+	assert.True(t, rc.CheckJmxCredentials().Completed())
+}
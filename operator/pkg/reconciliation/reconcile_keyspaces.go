@@ -0,0 +1,416 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+)
+
+// The keyspaces spec.manageSystemKeyspaces replicates to the datacenter
+var systemKeyspaces = []string{"system_auth", "system_distributed", "system_traces"}
+
+// The most replicas of the system keyspaces in a datacenter
+const maxSystemKeyspaceReplicas = 3
+
+const (
+	networkTopologyStrategy = "org.apache.cassandra.locator.NetworkTopologyStrategy"
+	simpleStrategy          = "org.apache.cassandra.locator.SimpleStrategy"
+)
+
+// managedKeyspaces returns the keyspaces whose replication the operator
+// manages, with the replication they should have. Each datacenter only
+// manages its own replicas, so the operators of the other datacenters never
+// undo its changes, nor it theirs.
+func (rc *ReconciliationContext) managedKeyspaces(current map[string]map[string]int32, nts map[string]bool) []api.KeyspaceReplication {
+	dc := rc.Datacenter
+	var keyspaces []api.KeyspaceReplication
+	for _, keyspace := range dc.Spec.Keyspaces {
+		factor, replicated := keyspace.Replication[dc.Name]
+		replication := ownReplication(dc.Name, current[keyspace.Name], nts[keyspace.Name], keyspace.Replication, factor, replicated)
+		if len(replication) > 0 {
+			keyspaces = append(keyspaces, api.KeyspaceReplication{Name: keyspace.Name, Replication: replication})
+		}
+	}
+	if !dc.Spec.ManageSystemKeyspaces {
+		return keyspaces
+	}
+
+	replicas := rc.systemKeyspaceReplicas()
+	for _, name := range systemKeyspaces {
+		replication := ownReplication(dc.Name, current[name], nts[name], nil, replicas, true)
+		keyspaces = append(keyspaces, api.KeyspaceReplication{Name: name, Replication: replication})
+	}
+	return keyspaces
+}
+
+// ownReplication returns the current replication of a keyspace with the
+// factor of the datacenter dcName, which is left out unless replicated. The
+// factors of the other datacenters are the current ones. When the keyspace
+// does not use NetworkTopologyStrategy yet, they are the replicas it keeps in
+// the other datacenters, see simpleStrategyReplicas, and the declared ones
+// take precedence.
+func ownReplication(dcName string, current map[string]int32, nts bool, declared map[string]int32, factor int32, replicated bool) map[string]int32 {
+	replication := map[string]int32{}
+	for name, f := range current {
+		if name != dcName {
+			replication[name] = f
+		}
+	}
+	if !nts {
+		for name, f := range declared {
+			if name != dcName {
+				replication[name] = f
+			}
+		}
+	}
+	if replicated {
+		replication[dcName] = factor
+	}
+	return replication
+}
+
+// systemKeyspaceReplicas returns the replication factor of the system
+// keyspaces in the datacenter, which follows its size up to 3 replicas
+func (rc *ReconciliationContext) systemKeyspaceReplicas() int32 {
+	replicas := int32(maxSystemKeyspaceReplicas)
+	if size := rc.Datacenter.Spec.Size; size < replicas {
+		replicas = size
+	}
+	return replicas
+}
+
+// simpleStrategyReplicas returns the replicas a keyspace with a
+// SimpleStrategy keeps in each datacenter of the ring other than dcName once
+// it moves to NetworkTopologyStrategy: as many as its replication factor, or
+// as the datacenter has nodes. SimpleStrategy spreads the replicas over the
+// ring of the whole cluster, so every datacenter has some of them, and one
+// without an operator managing the keyspace would otherwise lose them.
+func simpleStrategyReplicas(dcName string, replication map[string]string, ringNodes map[string]int32) map[string]int32 {
+	replicas := map[string]int32{}
+	if replication["class"] != simpleStrategy {
+		return replicas
+	}
+	factor, err := strconv.Atoi(replication["replication_factor"])
+	if err != nil {
+		return replicas
+	}
+	for name, nodes := range ringNodes {
+		if name == dcName {
+			continue
+		}
+		replicas[name] = int32(factor)
+		if nodes < replicas[name] {
+			replicas[name] = nodes
+		}
+	}
+	return replicas
+}
+
+// ringNodesByDatacenter counts the nodes of each datacenter in the ring the
+// node of pod sees
+func (rc *ReconciliationContext) ringNodesByDatacenter(pod *corev1.Pod) (map[string]int32, error) {
+	endpoints, err := rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(pod)
+	if err != nil {
+		return nil, err
+	}
+	nodes := map[string]int32{}
+	for _, endpoint := range endpoints.Entity {
+		if endpoint.Datacenter != "" {
+			nodes[endpoint.Datacenter]++
+		}
+	}
+	return nodes, nil
+}
+
+func findReadyPod(pods []*corev1.Pod) *corev1.Pod {
+	for _, pod := range pods {
+		if isServerReady(pod) {
+			return pod
+		}
+	}
+	return nil
+}
+
+// parseReplication returns the replication factor of each datacenter of a
+// keyspace, and false if it does not use NetworkTopologyStrategy
+func parseReplication(replication map[string]string) (map[string]int32, bool) {
+	factors := map[string]int32{}
+	if replication["class"] != networkTopologyStrategy {
+		return factors, false
+	}
+	for key, value := range replication {
+		if key == "class" {
+			continue
+		}
+		factor, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		factors[key] = int32(factor)
+	}
+	return factors, true
+}
+
+func replicationSettings(replication map[string]int32) []map[string]string {
+	dcNames := make([]string, 0, len(replication))
+	for dcName := range replication {
+		dcNames = append(dcNames, dcName)
+	}
+	sort.Strings(dcNames)
+
+	settings := make([]map[string]string, 0, len(dcNames))
+	for _, dcName := range dcNames {
+		settings = append(settings, map[string]string{
+			"dc_name":            dcName,
+			"replication_factor": strconv.Itoa(int(replication[dcName])),
+		})
+	}
+	return settings
+}
+
+// alterKeyspace alters the replication of a keyspace with the node of pod,
+// unless it already is the desired one. It returns whether the keyspace was
+// altered.
+func (rc *ReconciliationContext) alterKeyspace(pod *corev1.Pod, keyspace api.KeyspaceReplication, current map[string]int32, nts bool) (bool, error) {
+	if nts && reflect.DeepEqual(current, keyspace.Replication) {
+		return false, nil
+	}
+
+	rc.ReqLogger.Info("altering the replication of a keyspace",
+		"keyspace", keyspace.Name, "replication", keyspace.Replication, "pod", pod.Name)
+	if err := rc.NodeMgmtClient.AlterKeyspace(pod, keyspace.Name, replicationSettings(keyspace.Replication)); err != nil {
+		return false, err
+	}
+
+	rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.AlteredKeyspaceReplication,
+		"Altered the replication of keyspace %s to %v", keyspace.Name, keyspace.Replication)
+	return true, nil
+}
+
+// keyspaceRepair is the repair of the datacenter that follows a change to the
+// replication of a keyspace
+func keyspaceRepair(keyspaceName string) api.RepairSchedule {
+	return api.RepairSchedule{
+		Name:      fmt.Sprintf("%s replication", keyspaceName),
+		Type:      api.RepairTypeFull,
+		Keyspaces: []string{keyspaceName},
+	}
+}
+
+// CheckKeyspaceReplication alters the managed keyspaces whose replication
+// factor in the datacenter differs from spec.keyspaces, or for
+// spec.manageSystemKeyspaces from the replicas the system keyspaces should
+// have in the datacenter. When the
+// replication factor of the datacenter grows the nodes of the datacenter are
+// repaired one at a time, so they get the data they are now replicas for.
+// The keyspaces must exist, the ones that cannot be read or altered are
+// skipped until the next reconcile.
+func (rc *ReconciliationContext) CheckKeyspaceReplication() result.ReconcileResult {
+	dc := rc.Datacenter
+	logger := rc.ReqLogger
+
+	if len(dc.Spec.Keyspaces) == 0 && !dc.Spec.ManageSystemKeyspaces && len(dc.Status.KeyspaceRepairs) == 0 {
+		return result.Continue()
+	}
+
+	pod := findReadyPod(rc.dcPods)
+	if pod == nil {
+		return result.Continue()
+	}
+
+	// The replication of the keyspaces that are not there is left alone
+	current := map[string]map[string]int32{}
+	nts := map[string]bool{}
+	var ringNodes map[string]int32
+	names := []string{}
+	for _, keyspace := range dc.Spec.Keyspaces {
+		names = append(names, keyspace.Name)
+	}
+	if dc.Spec.ManageSystemKeyspaces {
+		names = append(names, systemKeyspaces...)
+	}
+	for _, name := range names {
+		replication, err := rc.NodeMgmtClient.CallGetKeyspaceReplicationEndpoint(pod, name)
+		if err != nil {
+			logger.Error(err, "error reading the replication of a keyspace", "keyspace", name)
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.FailedKeyspaceReplication,
+				"Could not read the replication of keyspace %s: %s", name, err.Error())
+			continue
+		}
+
+		factors, isNTS := parseReplication(replication)
+		if !isNTS {
+			// The other datacenters keep their replicas of a keyspace that
+			// moves to NetworkTopologyStrategy, which the ring tells
+			if ringNodes == nil {
+				if ringNodes, err = rc.ringNodesByDatacenter(pod); err != nil {
+					logger.Error(err, "error reading the ring before changing the strategy of a keyspace", "keyspace", name)
+					rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.FailedKeyspaceReplication,
+						"Could not read the ring to change the strategy of keyspace %s: %s", name, err.Error())
+					ringNodes = nil
+					continue
+				}
+			}
+			factors = simpleStrategyReplicas(dc.Name, replication, ringNodes)
+		}
+		current[name], nts[name] = factors, isNTS
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	now := metav1.NewTime(time.Now())
+
+	previous := make(map[string]api.RepairScheduleStatus)
+	for _, status := range dc.Status.KeyspaceRepairs {
+		previous[status.Name] = status
+	}
+
+	// Statuses of keyspaces that are no longer managed are dropped, and only
+	// the keyspaces that were repaired have one
+	var statuses []api.RepairScheduleStatus
+	for _, keyspace := range rc.managedKeyspaces(current, nts) {
+		status, ok := previous[keyspace.Name]
+		if !ok {
+			status = api.RepairScheduleStatus{Name: keyspace.Name}
+		}
+
+		if replication, ok := current[keyspace.Name]; ok {
+			altered, err := rc.alterKeyspace(pod, keyspace, replication, nts[keyspace.Name])
+			if err != nil {
+				logger.Error(err, "error altering the replication of a keyspace", "keyspace", keyspace.Name)
+				rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.FailedKeyspaceReplication,
+					"Could not alter the replication of keyspace %s: %s", keyspace.Name, err.Error())
+			} else if altered && keyspace.Replication[dc.Name] > replication[dc.Name] {
+				status.LastRepairStart = &now
+				status.LastError = ""
+				status.PendingPods = sortedPodNames(rc.dcPods)
+				rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.StartedRepair,
+					"Starting %s repair on %d pods", keyspaceRepair(keyspace.Name).Name, len(status.PendingPods))
+			}
+		}
+
+		if status.LastRepairStart != nil {
+			statuses = append(statuses, status)
+		}
+	}
+
+	// A single keyspace is repaired at a time
+	repairing := false
+	for i := range statuses {
+		status := &statuses[i]
+		if len(status.PendingPods) == 0 {
+			continue
+		}
+
+		repair := keyspaceRepair(status.Name)
+		if rc.repairPendingPod(repair, status) && len(status.PendingPods) == 0 {
+			status.LastRepairTime = &now
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CompletedRepair,
+				"Completed %s repair", repair.Name)
+		}
+		repairing = len(status.PendingPods) > 0
+		break
+	}
+
+	if !reflect.DeepEqual(dc.Status.KeyspaceRepairs, statuses) {
+		dc.Status.KeyspaceRepairs = statuses
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			logger.Error(err, "error patching datacenter status for keyspace repairs")
+			return result.Error(err)
+		}
+	}
+
+	// The repairs poll their nodes without holding back the steps after this
+	// one
+	if repairing {
+		rc.requeueAfter(repairPollSeconds)
+	}
+	return result.Continue()
+}
+
+// removeDatacenterReplication removes the datacenter dcName from the
+// replication of every keyspace of the cluster that names it, with the node
+// of pod, before the datacenter leaves the cluster. The node of pod must be
+// in another datacenter. The keyspaces only replicated to dcName are left
+// alone, as they have nowhere else to go.
+func (rc *ReconciliationContext) removeDatacenterReplication(pod *corev1.Pod, dcName string) error {
+	names, err := rc.NodeMgmtClient.CallListKeyspacesEndpoint(pod)
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		replication, err := rc.NodeMgmtClient.CallGetKeyspaceReplicationEndpoint(pod, name)
+		if err != nil {
+			return err
+		}
+
+		current, nts := parseReplication(replication)
+		if _, ok := current[dcName]; !nts || !ok {
+			continue
+		}
+
+		desired := map[string]int32{}
+		for other, factor := range current {
+			if other != dcName {
+				desired[other] = factor
+			}
+		}
+		if len(desired) == 0 {
+			continue
+		}
+
+		if _, err := rc.alterKeyspace(pod, api.KeyspaceReplication{Name: name, Replication: desired}, current, nts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lowerSystemKeyspaceReplication lowers the replication factor of the system
+// keyspaces in the datacenter before it is scaled down, as nodes cannot be
+// decommissioned while there would be fewer nodes left than replicas
+func (rc *ReconciliationContext) lowerSystemKeyspaceReplication() error {
+	dc := rc.Datacenter
+	pod := findReadyPod(rc.dcPods)
+	if pod == nil {
+		return fmt.Errorf("no ready node to alter the system keyspaces on")
+	}
+
+	replicas := rc.systemKeyspaceReplicas()
+	for _, name := range systemKeyspaces {
+		replication, err := rc.NodeMgmtClient.CallGetKeyspaceReplicationEndpoint(pod, name)
+		if err != nil {
+			return err
+		}
+
+		current, nts := parseReplication(replication)
+		if !nts || current[dc.Name] <= replicas {
+			continue
+		}
+
+		desired := map[string]int32{}
+		for dcName, factor := range current {
+			desired[dcName] = factor
+		}
+		desired[dc.Name] = replicas
+
+		if _, err := rc.alterKeyspace(pod, api.KeyspaceReplication{Name: name, Replication: desired}, current, nts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+// setupKeyspacesTest has the management API list the keyspaces in
+// replications and return their replication, which are not found otherwise,
+// and accept every other request
+func setupKeyspacesTest(rc *ReconciliationContext, replications map[string]string) *mocks.HttpClient {
+	return setupKeyspacesTestWithRing(rc, replications, map[string]int{rc.Datacenter.Name: 1})
+}
+
+// setupKeyspacesTestWithRing is setupKeyspacesTest with a ring of the given
+// number of nodes in each datacenter
+func setupKeyspacesTestWithRing(rc *ReconciliationContext, replications map[string]string, ring map[string]int) *mocks.HttpClient {
+	var endpoints []string
+	for dcName, nodes := range ring {
+		for i := 0; i < nodes; i++ {
+			endpoints = append(endpoints, fmt.Sprintf(`{"DC": "%s", "RPC_ADDRESS": "10.0.%d.%d", "STATUS": "NORMAL,1"}`, dcName, len(endpoints), i))
+		}
+	}
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/keyspace/replication"
+			})).
+		Return(func(req *http.Request) *http.Response {
+			replication, ok := replications[req.URL.Query().Get("keyspaceName")]
+			if !ok {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(replication)),
+			}
+		}, nil)
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path != "/api/v0/ops/keyspace/replication"
+			})).
+		Return(func(req *http.Request) *http.Response {
+			body := "OK"
+			if req.URL.Path == "/api/v0/ops/keyspace" {
+				var names []string
+				for name := range replications {
+					names = append(names, `"`+name+`"`)
+				}
+				body = "[" + strings.Join(names, ", ") + "]"
+			} else if req.URL.Path == "/api/v0/metadata/endpoints" {
+				body = `{"entity": [` + strings.Join(endpoints, ", ") + `]}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}
+		}, nil)
+
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+	return mockHttpClient
+}
+
+// alteredKeyspaces returns the bodies of the requests that altered keyspaces
+func alteredKeyspaces(t *testing.T, mockHttpClient *mocks.HttpClient) []string {
+	var bodies []string
+	for _, call := range mockHttpClient.Calls {
+		req := call.Arguments.Get(0).(*http.Request)
+		if req.URL.Path != "/api/v0/ops/keyspace/alter" {
+			continue
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		assert.NoError(t, err)
+		bodies = append(bodies, string(body))
+	}
+	return bodies
+}
+
+func TestCheckKeyspaceReplication_RepairsWhenReplicationGrows(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	replications := map[string]string{
+		"ks": `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "1", "dc2": "3"}`,
+	}
+	mockHttpClient := setupKeyspacesTest(rc, replications)
+	dc.Spec.Keyspaces = []api.KeyspaceReplication{
+		{Name: "ks", Replication: map[string]int32{dc.Name: 3, "dc2": 3}},
+	}
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-b"), makeReadyPod("pod-a")}
+
+	assert.False(t, rc.CheckKeyspaceReplication().Completed())
+	assert.Equal(t, repairPollSeconds, rc.requeueSeconds)
+	altered := alteredKeyspaces(t, mockHttpClient)
+	assert.Len(t, altered, 1)
+	assert.JSONEq(t, `{"keyspace_name": "ks", "replication_settings": [
+		{"dc_name": "`+dc.Name+`", "replication_factor": "3"},
+		{"dc_name": "dc2", "replication_factor": "3"}]}`, altered[0])
+	assert.Len(t, dc.Status.KeyspaceRepairs, 1)
+	assert.Equal(t, []string{"pod-a", "pod-b"}, dc.Status.KeyspaceRepairs[0].PendingPods)
+
+	replications["ks"] = `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "3", "dc2": "3"}`
+	waitForRepair(t, repairKey(dc, "ks replication", "pod-a"))
+
+	assert.False(t, rc.CheckKeyspaceReplication().Completed())
+	assert.Equal(t, repairPollSeconds, rc.requeueSeconds)
+	assert.Equal(t, []string{"pod-b"}, dc.Status.KeyspaceRepairs[0].PendingPods)
+
+	waitForRepair(t, repairKey(dc, "ks replication", "pod-b"))
+
+	assert.False(t, rc.CheckKeyspaceReplication().Completed())
+	assert.Empty(t, dc.Status.KeyspaceRepairs[0].PendingPods)
+	assert.NotNil(t, dc.Status.KeyspaceRepairs[0].LastRepairTime)
+	assert.Len(t, alteredKeyspaces(t, mockHttpClient), 1, "the keyspace is only altered once")
+}
+
+func TestCheckKeyspaceReplication_OtherDatacenters(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	mockHttpClient := setupKeyspacesTest(rc, map[string]string{
+		"ks":     `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "3", "dc2": "5"}`,
+		"new_ks": `{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "1"}`,
+	})
+	dc.Spec.Keyspaces = []api.KeyspaceReplication{
+		{Name: "ks", Replication: map[string]int32{dc.Name: 3, "dc2": 3}},
+		{Name: "new_ks", Replication: map[string]int32{dc.Name: 1, "dc2": 3}},
+	}
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-a")}
+
+	// The factor of dc2 is left to its own operator, and the declared one is
+	// only used to switch a keyspace to NetworkTopologyStrategy
+	assert.False(t, rc.CheckKeyspaceReplication().Completed())
+	assert.Equal(t, repairPollSeconds, rc.requeueSeconds)
+	altered := alteredKeyspaces(t, mockHttpClient)
+	assert.Len(t, altered, 1)
+	assert.JSONEq(t, `{"keyspace_name": "new_ks", "replication_settings": [
+		{"dc_name": "`+dc.Name+`", "replication_factor": "1"},
+		{"dc_name": "dc2", "replication_factor": "3"}]}`, altered[0])
+
+	waitForRepair(t, repairKey(dc, "new_ks replication", "pod-a"))
+}
+
+func TestCheckKeyspaceReplication_SystemKeyspaces(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Size = 5
+	dc.Spec.ManageSystemKeyspaces = true
+	mockHttpClient := setupKeyspacesTest(rc, map[string]string{
+		"system_auth":        `{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "1"}`,
+		"system_distributed": `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc2": "3", "` + dc.Name + `": "3"}`,
+	})
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-a")}
+
+	assert.False(t, rc.CheckKeyspaceReplication().Completed())
+	assert.Equal(t, repairPollSeconds, rc.requeueSeconds)
+	altered := alteredKeyspaces(t, mockHttpClient)
+	assert.Len(t, altered, 1)
+	assert.JSONEq(t, `{"keyspace_name": "system_auth", "replication_settings": [
+		{"dc_name": "`+dc.Name+`", "replication_factor": "3"}]}`, altered[0])
+	assert.Len(t, dc.Status.KeyspaceRepairs, 1)
+	assert.Equal(t, "system_auth", dc.Status.KeyspaceRepairs[0].Name)
+
+	waitForRepair(t, repairKey(dc, "system_auth replication", "pod-a"))
+}
+
+func TestCheckKeyspaceReplication_SimpleStrategyKeepsOtherDatacenters(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Size = 3
+	dc.Spec.ManageSystemKeyspaces = true
+	mockHttpClient := setupKeyspacesTestWithRing(rc, map[string]string{
+		"system_auth": `{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "3"}`,
+	}, map[string]int{dc.Name: 3, "dc2": 5, "dc3": 2})
+	dc.Spec.Keyspaces = []api.KeyspaceReplication{}
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-a")}
+
+	// The other datacenters keep as many replicas as SimpleStrategy had, up
+	// to their number of nodes
+	assert.False(t, rc.CheckKeyspaceReplication().Completed())
+	assert.Equal(t, repairPollSeconds, rc.requeueSeconds)
+	altered := alteredKeyspaces(t, mockHttpClient)
+	assert.Len(t, altered, 1)
+	assert.JSONEq(t, `{"keyspace_name": "system_auth", "replication_settings": [
+		{"dc_name": "`+dc.Name+`", "replication_factor": "3"},
+		{"dc_name": "dc2", "replication_factor": "3"},
+		{"dc_name": "dc3", "replication_factor": "2"}]}`, altered[0])
+
+	waitForRepair(t, repairKey(dc, "system_auth replication", "pod-a"))
+}
+
+func TestRemoveDatacenterReplication(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Keyspaces = []api.KeyspaceReplication{
+		{Name: "ks", Replication: map[string]int32{dc.Name: 3, "dc2": 3}},
+		{Name: "local", Replication: map[string]int32{dc.Name: 3}},
+	}
+	mockHttpClient := setupKeyspacesTest(rc, map[string]string{
+		"ks":     `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc2": "3", "` + dc.Name + `": "3"}`,
+		"local":  `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "3"}`,
+		"app":    `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc2": "1", "` + dc.Name + `": "2"}`,
+		"other":  `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc2": "3"}`,
+		"system": `{"class": "org.apache.cassandra.locator.LocalStrategy"}`,
+	})
+
+	assert.NoError(t, rc.removeDatacenterReplication(makeReadyPod("dc2-pod"), dc.Name))
+	altered := alteredKeyspaces(t, mockHttpClient)
+	assert.Len(t, altered, 2, "a keyspace only replicated to the datacenter is left alone")
+	assert.JSONEq(t, `{"keyspace_name": "app", "replication_settings": [
+		{"dc_name": "dc2", "replication_factor": "1"}]}`, altered[0], "keyspaces the operator does not manage are altered too")
+	assert.JSONEq(t, `{"keyspace_name": "ks", "replication_settings": [
+		{"dc_name": "dc2", "replication_factor": "3"}]}`, altered[1])
+}
+
+func TestLowerSystemKeyspaceReplication(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Size = 2
+	dc.Spec.ManageSystemKeyspaces = true
+	mockHttpClient := setupKeyspacesTest(rc, map[string]string{
+		"system_auth":        `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc2": "3", "` + dc.Name + `": "3"}`,
+		"system_distributed": `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "1"}`,
+		"system_traces":      `{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "3"}`,
+	})
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-a")}
+
+	assert.NoError(t, rc.lowerSystemKeyspaceReplication())
+	altered := alteredKeyspaces(t, mockHttpClient)
+	assert.Len(t, altered, 1)
+	assert.JSONEq(t, `{"keyspace_name": "system_auth", "replication_settings": [
+		{"dc_name": "`+dc.Name+`", "replication_factor": "2"},
+		{"dc_name": "dc2", "replication_factor": "3"}]}`, altered[0])
+
+	rc.dcPods = nil
+	assert.Error(t, rc.lowerSystemKeyspaceReplication(), "there is no node to alter the keyspaces on")
+}
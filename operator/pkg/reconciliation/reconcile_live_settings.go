@@ -0,0 +1,214 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync/atomic"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+	"github.com/k8ssandra/cass-operator/operator/pkg/serverconfig"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+// isLiveSettingsChange returns whether the desired statefulset only differs
+// from the current one in the live settings of the config its
+// server-config-init container renders, which CheckLiveSettings sets on the
+// running nodes instead of restarting them
+func isLiveSettingsChange(current, desired *appsv1.StatefulSet) bool {
+	currentContainer := findServerConfigContainer(&current.Spec.Template)
+	currentConfig := ""
+	if currentContainer != nil {
+		currentConfig = envVarValue(currentContainer, "CONFIG_FILE_DATA")
+	}
+	substituted := desired.DeepCopy()
+	desiredContainer := findServerConfigContainer(&substituted.Spec.Template)
+	if currentConfig == "" || desiredContainer == nil {
+		return false
+	}
+	if !serverconfig.IsLiveSettingsChange(currentConfig, envVarValue(desiredContainer, "CONFIG_FILE_DATA")) {
+		return false
+	}
+
+	// The rest of the statefulset must be the same
+	for i := range desiredContainer.Env {
+		if desiredContainer.Env[i].Name == "CONFIG_FILE_DATA" {
+			desiredContainer.Env[i].Value = currentConfig
+		}
+	}
+	utils.UpdateHashAnnotation(substituted)
+	return utils.ResourcesHaveSameHash(current, substituted)
+}
+
+// liveSettingsOfRack returns the values of the live settings of the config
+// of the rack, which overrides the one of the datacenter
+func liveSettingsOfRack(dc *api.CassandraDatacenter, rackName string) (map[string]int64, error) {
+	rackConfig, err := dc.GetRackConfig(rackName)
+	if err != nil {
+		return nil, err
+	}
+	config, err := dc.GetConfigAsJSON(rackConfig)
+	if err != nil {
+		return nil, err
+	}
+	return serverconfig.LiveSettingValues(config)
+}
+
+// liveSettingsApplied returns whether every pod of the rack records the live
+// settings of the config of the rack as set, so that a change of the config
+// that only touches them does not need to restart the pods
+func (rc *ReconciliationContext) liveSettingsApplied(rackName string) bool {
+	values, err := liveSettingsOfRack(rc.Datacenter, rackName)
+	if err != nil {
+		return false
+	}
+	valuesJSON, err := json.Marshal(values)
+	if err != nil {
+		return false
+	}
+	for _, pod := range rc.dcPods {
+		if pod.Labels[api.RackLabel] == rackName && pod.Annotations[api.LiveSettingsAnnotation] != string(valuesJSON) {
+			return false
+		}
+	}
+	return true
+}
+
+// podConfigLiveSettings returns the values of the live settings of the config
+// the pod was created with, and false when they cannot be known
+func podConfigLiveSettings(pod *corev1.Pod) (map[string]int64, bool) {
+	for i := range pod.Spec.InitContainers {
+		container := &pod.Spec.InitContainers[i]
+		if container.Name != ServerConfigContainerName {
+			continue
+		}
+		config := envVarValue(container, "CONFIG_FILE_DATA")
+		if config == "" {
+			return nil, false
+		}
+		values, err := serverconfig.LiveSettingValues(config)
+		return values, err == nil
+	}
+	return nil, false
+}
+
+// CheckLiveSettings sets the live settings of the config, such as the
+// compaction throughput, on the ready nodes whose pods do not record them
+// yet, through the management API. Changes of the config that only touch
+// them do not restart the pods once every pod of the rack records them, see
+// isLiveSettingsChange and liveSettingsApplied. A node whose management API
+// cannot change the settings is left unrecorded, so its rack rolls out the
+// config as any other change.
+func (rc *ReconciliationContext) CheckLiveSettings() result.ReconcileResult {
+	logger := rc.ReqLogger
+	dc := rc.Datacenter
+	if dc.UsesConfigSecret() {
+		return result.Continue()
+	}
+
+	rackValues := map[string]map[string]int64{}
+	for _, rack := range dc.GetRacks() {
+		values, err := liveSettingsOfRack(dc, rack.Name)
+		if err != nil {
+			return result.Error(err)
+		}
+		if len(values) > 0 {
+			rackValues[rack.Name] = values
+		}
+	}
+	if len(rackValues) == 0 {
+		return result.Continue()
+	}
+	logger.Info("reconcile_live_settings::CheckLiveSettings")
+
+	rackValuesJSON := map[string]string{}
+	for rackName, values := range rackValues {
+		valuesJSON, err := json.Marshal(values)
+		if err != nil {
+			return result.Error(err)
+		}
+		rackValuesJSON[rackName] = string(valuesJSON)
+	}
+
+	var pods []*corev1.Pod
+	for _, pod := range rc.dcPods {
+		valuesJSON, ok := rackValuesJSON[pod.Labels[api.RackLabel]]
+		if ok && isServerReady(pod) && pod.Annotations[api.LiveSettingsAnnotation] != valuesJSON {
+			pods = append(pods, pod)
+		}
+	}
+
+	var failed int32
+	rc.NodeMgmtClient.ForEachPod(pods, func(pod *corev1.Pod) {
+		values := rackValues[pod.Labels[api.RackLabel]]
+		valuesJSON := rackValuesJSON[pod.Labels[api.RackLabel]]
+
+		value, recorded := pod.Annotations[api.LiveSettingsAnnotation]
+		applied := map[string]int64{}
+		if recorded {
+			if err := json.Unmarshal([]byte(value), &applied); err != nil {
+				logger.Error(err, "Could not read the live settings set on the pod", "pod", pod.Name)
+				applied = map[string]int64{}
+			}
+		}
+
+		// A node that started with the settings already runs with them
+		if configValues, ok := podConfigLiveSettings(pod); recorded || !ok || !reflect.DeepEqual(configValues, values) {
+			if err := rc.setLiveSettings(pod, applied, values); err != nil {
+				if mgmtapi.IsNotSupported(err) {
+					logger.Info("The management API of the pod cannot change settings, the rack will restart for them", "pod", pod.Name)
+				} else {
+					logger.Error(err, "Could not set the live settings", "pod", pod.Name)
+					atomic.StoreInt32(&failed, 1)
+				}
+				return
+			}
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.UpdatedLiveSettings,
+				"Set the live settings on pod %s", pod.Name)
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		metav1.SetMetaDataAnnotation(&pod.ObjectMeta, api.LiveSettingsAnnotation, valuesJSON)
+		if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
+			logger.Error(err, "Could not record the live settings set on the pod", "pod", pod.Name)
+			atomic.StoreInt32(&failed, 1)
+			return
+		}
+	})
+
+	if atomic.LoadInt32(&failed) != 0 {
+		return result.RequeueSoon(10)
+	}
+	return result.Continue()
+}
+
+// setLiveSettings sets the live settings of the node whose value is not the
+// applied one
+func (rc *ReconciliationContext) setLiveSettings(pod *corev1.Pod, applied, values map[string]int64) error {
+	options := make([]string, 0, len(values))
+	for option := range values {
+		options = append(options, option)
+	}
+	sort.Strings(options)
+
+	for _, option := range options {
+		if current, ok := applied[option]; ok && current == values[option] {
+			continue
+		}
+		if err := rc.NodeMgmtClient.CallSetSettingEndpoint(pod, serverconfig.LiveSettings[option], values[option]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
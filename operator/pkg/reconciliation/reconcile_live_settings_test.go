@@ -0,0 +1,180 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+func TestCheckLiveSettings(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	updates := setupQueryLoggingTest(rc, false)
+	pod := rc.dcPods[0]
+	pod.Namespace = dc.Namespace
+	pod.Labels = map[string]string{api.RackLabel: "default"}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+
+	assert.False(t, rc.CheckLiveSettings().Completed())
+	assert.Empty(t, *updates, "no setting should be set without live settings in the config")
+
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"compaction_throughput_mb_per_sec":64,"concurrent_compactors":2}}`)
+	assert.False(t, rc.CheckLiveSettings().Completed())
+	assert.Equal(t, []string{
+		"/api/v0/ops/node/compactionthroughput?value=64",
+		"/api/v0/ops/node/concurrentcompactors?value=2",
+	}, *updates)
+
+	current := &corev1.Pod{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, current))
+	assert.Equal(t, `{"compaction_throughput_mb_per_sec":64,"concurrent_compactors":2}`,
+		current.Annotations[api.LiveSettingsAnnotation])
+
+	// The settings already set are not set again
+	*updates = nil
+	assert.False(t, rc.CheckLiveSettings().Completed())
+	assert.Empty(t, *updates)
+
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"compaction_throughput_mb_per_sec":32,"concurrent_compactors":2}}`)
+	assert.False(t, rc.CheckLiveSettings().Completed())
+	assert.Equal(t, []string{"/api/v0/ops/node/compactionthroughput?value=32"}, *updates)
+}
+
+func TestCheckLiveSettings_RackConfig(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	updates := setupQueryLoggingTest(rc, false)
+	pod := rc.dcPods[0]
+	pod.Namespace = dc.Namespace
+	pod.Labels = map[string]string{api.RackLabel: "rack2"}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"concurrent_compactors":2}}`)
+	dc.Spec.Racks = []api.Rack{
+		{Name: "rack1"},
+		{Name: "rack2", Config: []byte(`{"cassandra-yaml":{"concurrent_compactors":4}}`)},
+	}
+	assert.False(t, rc.CheckLiveSettings().Completed())
+	assert.Equal(t, []string{"/api/v0/ops/node/concurrentcompactors?value=4"}, *updates, "the config of the rack should take precedence")
+}
+
+func TestCheckRackPodTemplate_LiveSettings(t *testing.T) {
+	rc, _, cleanpMockSrc := setupTest()
+	defer cleanpMockSrc()
+
+	rc.Datacenter.Spec.ServerVersion = "6.8.2"
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1", Zone: "zone-1"},
+	}
+	rc.Datacenter.Spec.Config = []byte(`{"cassandra-yaml":{"concurrent_compactors":4}}`)
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
+		t.Fatalf("failed to add rack to cassandradatacenter: %s", err)
+	}
+
+	configData := func() string {
+		return envVarValue(findServerConfigContainer(&rc.statefulSets[0].Spec.Template), "CONFIG_FILE_DATA")
+	}
+	previousConfig := configData()
+
+	rc.Datacenter.Spec.Config = []byte(`{"cassandra-yaml":{"concurrent_compactors":2}}`)
+	result = rc.CheckRackPodTemplate()
+	assert.False(t, result.Completed(), "a change of live settings should not restart the pods")
+	assert.Equal(t, previousConfig, configData())
+
+	rc.Datacenter.Spec.Config = []byte(`{"cassandra-yaml":{"concurrent_compactors":2,"num_tokens":32}}`)
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Contains(t, configData(), `"concurrent_compactors":2`)
+}
+
+func TestCheckRackPodTemplate_LiveSettingsNotApplied(t *testing.T) {
+	rc, _, cleanpMockSrc := setupTest()
+	defer cleanpMockSrc()
+
+	rc.Datacenter.Spec.ServerVersion = "6.8.2"
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1", Zone: "zone-1"},
+	}
+	rc.Datacenter.Spec.Config = []byte(`{"cassandra-yaml":{"concurrent_compactors":4}}`)
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+	assert.False(t, rc.CheckRackCreation().Completed())
+	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
+		t.Fatalf("failed to add rack to cassandradatacenter: %s", err)
+	}
+
+	// A pod that did not take the settings restarts for them
+	pod := makeReadyPod("pod-0")
+	pod.Labels = map[string]string{api.RackLabel: "rack1"}
+	rc.dcPods = []*corev1.Pod{pod}
+	rc.Datacenter.Spec.Config = []byte(`{"cassandra-yaml":{"concurrent_compactors":2}}`)
+	assert.True(t, rc.CheckRackPodTemplate().Completed())
+	config := envVarValue(findServerConfigContainer(&rc.statefulSets[0].Spec.Template), "CONFIG_FILE_DATA")
+	assert.Contains(t, config, `"concurrent_compactors":2`)
+}
+
+func TestCheckLiveSettings_NotSupported(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	pod := makeReadyPod("pod-0")
+	pod.Namespace = dc.Namespace
+	pod.Labels = map[string]string{api.RackLabel: "default"}
+	pod.Status.PodIP = "10.0.0.1"
+	rc.dcPods = []*corev1.Pod{pod}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do", mock.Anything).
+		Return(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}
+		}, nil)
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"concurrent_compactors":2}}`)
+	assert.False(t, rc.CheckLiveSettings().Completed(), "the pods restart for the settings instead")
+	assert.NotContains(t, pod.Annotations, api.LiveSettingsAnnotation)
+	assert.False(t, rc.liveSettingsApplied("default"))
+
+	// A node that started with the settings is not called
+	pod.Spec.InitContainers = []corev1.Container{{
+		Name: ServerConfigContainerName,
+		Env:  []corev1.EnvVar{{Name: "CONFIG_FILE_DATA", Value: `{"cassandra-yaml":{"concurrent_compactors":2}}`}},
+	}}
+	calls := len(mockHttpClient.Calls)
+	assert.False(t, rc.CheckLiveSettings().Completed())
+	assert.Len(t, mockHttpClient.Calls, calls)
+	assert.Equal(t, `{"concurrent_compactors":2}`, pod.Annotations[api.LiveSettingsAnnotation])
+	assert.True(t, rc.liveSettingsApplied("default"))
+}
@@ -0,0 +1,191 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+// newLogbackConfigMap renders the logging levels of the spec into the
+// ConfigMap the generated logback.xml of the server containers includes
+func newLogbackConfigMap(dc *api.CassandraDatacenter) *corev1.ConfigMap {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(labels)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dc.GetLogbackConfigMapName(),
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			api.LogbackLevelsFile: newLogbackLevelsXML(dc),
+		},
+	}
+
+	addAdditionalMetadata(dc, configMap)
+
+	utils.AddHashAnnotation(configMap)
+
+	return configMap
+}
+
+// CheckLogbackConfig creates or updates the ConfigMap of the logging levels,
+// before the pods that mount it. Logback rescans it once the kubelet updated
+// it in the pods, which do not restart.
+func (rc *ReconciliationContext) CheckLogbackConfig() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.UsesGeneratedLogback() {
+		return result.Continue()
+	}
+
+	desiredConfigMap := newLogbackConfigMap(dc)
+	if err := setControllerReference(dc, desiredConfigMap, rc.Scheme); err != nil {
+		return result.Error(err)
+	}
+
+	currentConfigMap := &corev1.ConfigMap{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: desiredConfigMap.Name, Namespace: desiredConfigMap.Namespace}, currentConfigMap)
+	if err != nil && errors.IsNotFound(err) {
+		rc.ReqLogger.Info("Creating the ConfigMap of the logging levels", "ConfigMap", desiredConfigMap.Name)
+		if err := rc.createResource(desiredConfigMap); err != nil {
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created ConfigMap %s", desiredConfigMap.Name)
+		return result.Continue()
+	} else if err != nil {
+		return result.Error(err)
+	}
+
+	if !utils.ResourcesHaveSameHash(currentConfigMap, desiredConfigMap) {
+		rc.ReqLogger.Info("Updating the ConfigMap of the logging levels", "ConfigMap", desiredConfigMap.Name)
+		if err := rc.updateResource(desiredConfigMap, currentConfigMap); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
+
+// CheckLoggingLevels sets the logging levels of the spec and of the
+// set-log-level annotation on every ready node through the management API,
+// without waiting for logback to rescan the ConfigMap. The levels set on a pod
+// are recorded in an annotation, so that only the ones that changed are set,
+// and the loggers that were removed are reset. Once they are set on all the
+// ready nodes, they are reported in the status.
+func (rc *ReconciliationContext) CheckLoggingLevels() result.ReconcileResult {
+	logger := rc.ReqLogger
+	dc := rc.Datacenter
+	levels := dc.GetLoggingLevels()
+	if len(levels) == 0 && len(dc.Status.LoggingLevels) == 0 {
+		return result.Continue()
+	}
+	logger.Info("reconcile_logging::CheckLoggingLevels")
+
+	levelsJSON, err := json.Marshal(levels)
+	if err != nil {
+		return result.Error(err)
+	}
+
+	var readyPods []*corev1.Pod
+	for _, pod := range rc.dcPods {
+		if isServerReady(pod) {
+			readyPods = append(readyPods, pod)
+		}
+	}
+
+	var failed int32
+	rc.NodeMgmtClient.ForEachPod(readyPods, func(pod *corev1.Pod) {
+		applied := map[string]string{}
+		if value, ok := pod.Annotations[api.LoggingLevelsAnnotation]; ok {
+			if err := json.Unmarshal([]byte(value), &applied); err != nil {
+				logger.Error(err, "Could not read the logging levels set on the pod", "pod", pod.Name)
+				applied = map[string]string{}
+			}
+		}
+		if sameLoggingLevels(applied, levels) {
+			return
+		}
+
+		if err := rc.setLoggingLevels(pod, applied, levels); err != nil {
+			if !mgmtapi.IsNotSupported(err) {
+				logger.Error(err, "Could not set the logging levels", "pod", pod.Name)
+				atomic.StoreInt32(&failed, 1)
+				return
+			}
+			// Logback applies the levels when it rescans the ConfigMap
+			logger.Info("The management API of the pod cannot set logging levels", "pod", pod.Name)
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		metav1.SetMetaDataAnnotation(&pod.ObjectMeta, api.LoggingLevelsAnnotation, string(levelsJSON))
+		if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
+			logger.Error(err, "Could not record the logging levels set on the pod", "pod", pod.Name)
+			atomic.StoreInt32(&failed, 1)
+			return
+		}
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.UpdatedLoggingLevels,
+			"Set the logging levels on pod %s", pod.Name)
+	})
+
+	if failed != 0 {
+		return result.RequeueSoon(10)
+	}
+
+	if !sameLoggingLevels(dc.Status.LoggingLevels, levels) {
+		patch := client.MergeFrom(dc.DeepCopy())
+		dc.Status.LoggingLevels = levels
+		if len(levels) == 0 {
+			dc.Status.LoggingLevels = nil
+		}
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, patch); err != nil {
+			return result.Error(err)
+		}
+	}
+	return result.Continue()
+}
+
+// sameLoggingLevels returns whether the levels of the loggers are the same,
+// an empty map being the same as none
+func sameLoggingLevels(a, b map[string]string) bool {
+	return len(a) == len(b) && containsAll(a, b)
+}
+
+// setLoggingLevels sets the loggers of the node whose level is not the
+// applied one, and resets the applied loggers that are no longer in levels
+func (rc *ReconciliationContext) setLoggingLevels(pod *corev1.Pod, applied, levels map[string]string) error {
+	for name, level := range levels {
+		if current, ok := applied[name]; ok && current == level {
+			continue
+		}
+		if err := rc.NodeMgmtClient.CallSetLoggingLevelEndpoint(pod, name, level); err != nil {
+			return err
+		}
+	}
+	for name := range applied {
+		if _, ok := levels[name]; ok {
+			continue
+		}
+		if err := rc.NodeMgmtClient.CallSetLoggingLevelEndpoint(pod, name, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestCheckLogbackConfig(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	assert.False(t, rc.CheckLogbackConfig().Completed())
+	key := types.NamespacedName{Name: dc.GetLogbackConfigMapName(), Namespace: dc.Namespace}
+	assert.Error(t, rc.Client.Get(rc.Ctx, key, &corev1.ConfigMap{}), "the ConfigMap should only exist with a generated logback.xml")
+
+	dc.Spec.LoggingConfig = &api.LoggingConfig{RootLevel: "WARN"}
+	assert.False(t, rc.CheckLogbackConfig().Completed())
+	configMap := &corev1.ConfigMap{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, configMap))
+	assert.Contains(t, configMap.Data[api.LogbackLevelsFile], `<root level="WARN" />`)
+
+	dc.Spec.LoggingConfig.Loggers = map[string]string{"org.apache.cassandra.gms": "TRACE"}
+	assert.False(t, rc.CheckLogbackConfig().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, configMap))
+	assert.Contains(t, configMap.Data[api.LogbackLevelsFile], `<logger name="org.apache.cassandra.gms" level="TRACE" />`)
+}
+
+func TestCheckLoggingLevels(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	updates := setupQueryLoggingTest(rc, false)
+	pod := rc.dcPods[0]
+	pod.Namespace = dc.Namespace
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+
+	assert.False(t, rc.CheckLoggingLevels().Completed())
+	assert.Empty(t, *updates, "the levels should be left alone without a generated logback.xml")
+
+	dc.Spec.LoggingConfig = &api.LoggingConfig{
+		RootLevel: "WARN",
+		Loggers:   map[string]string{"org.apache.cassandra.gms": "TRACE"},
+	}
+	assert.False(t, rc.CheckLoggingLevels().Completed())
+	assert.ElementsMatch(t, []string{
+		"/api/v0/ops/node/logging?rawLevel=WARN&target=ROOT",
+		"/api/v0/ops/node/logging?rawLevel=DEBUG&target=org.apache.cassandra",
+		"/api/v0/ops/node/logging?rawLevel=TRACE&target=org.apache.cassandra.gms",
+	}, *updates)
+
+	current := &corev1.Pod{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, current))
+	assert.Equal(t, `{"ROOT":"WARN","org.apache.cassandra":"DEBUG","org.apache.cassandra.gms":"TRACE"}`,
+		current.Annotations[api.LoggingLevelsAnnotation])
+
+	// The levels already set are not set again
+	*updates = nil
+	assert.False(t, rc.CheckLoggingLevels().Completed())
+	assert.Empty(t, *updates)
+
+	// Only the changed levels are set, and the removed loggers are reset
+	dc.Spec.LoggingConfig = &api.LoggingConfig{
+		RootLevel: "WARN",
+		Loggers:   map[string]string{"org.apache.cassandra.db": "TRACE"},
+	}
+	assert.False(t, rc.CheckLoggingLevels().Completed())
+	assert.ElementsMatch(t, []string{
+		"/api/v0/ops/node/logging?rawLevel=TRACE&target=org.apache.cassandra.db",
+		"/api/v0/ops/node/logging?rawLevel=&target=org.apache.cassandra.gms",
+	}, *updates)
+	assert.Equal(t, map[string]string{
+		"ROOT":                    "WARN",
+		"org.apache.cassandra":    "DEBUG",
+		"org.apache.cassandra.db": "TRACE",
+	}, dc.Status.LoggingLevels)
+}
+
+func TestCheckLoggingLevels_Annotation(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	updates := setupQueryLoggingTest(rc, false)
+	pod := rc.dcPods[0]
+	pod.Namespace = dc.Namespace
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+
+	// Without the generated logback.xml, only the loggers of the annotation
+	// are set
+	dc.Annotations = map[string]string{api.SetLogLevelAnnotation: "org.apache.cassandra.db=debug"}
+	assert.False(t, rc.CheckLoggingLevels().Completed())
+	assert.Equal(t, []string{"/api/v0/ops/node/logging?rawLevel=DEBUG&target=org.apache.cassandra.db"}, *updates)
+	assert.Equal(t, map[string]string{"org.apache.cassandra.db": "DEBUG"}, dc.Status.LoggingLevels)
+
+	// The annotation takes precedence over the spec
+	*updates = nil
+	dc.Spec.LoggingConfig = &api.LoggingConfig{RootLevel: "WARN", Loggers: map[string]string{"org.apache.cassandra.db": "TRACE"}}
+	dc.Annotations[api.SetLogLevelAnnotation] = "org.apache.cassandra.db=DEBUG,ROOT=ERROR"
+	assert.False(t, rc.CheckLoggingLevels().Completed())
+	assert.ElementsMatch(t, []string{
+		"/api/v0/ops/node/logging?rawLevel=ERROR&target=ROOT",
+		"/api/v0/ops/node/logging?rawLevel=DEBUG&target=org.apache.cassandra",
+	}, *updates)
+
+	// Removing the annotation and the spec resets the loggers
+	*updates = nil
+	dc.Spec.LoggingConfig = nil
+	delete(dc.Annotations, api.SetLogLevelAnnotation)
+	assert.False(t, rc.CheckLoggingLevels().Completed())
+	assert.ElementsMatch(t, []string{
+		"/api/v0/ops/node/logging?rawLevel=&target=ROOT",
+		"/api/v0/ops/node/logging?rawLevel=&target=org.apache.cassandra",
+		"/api/v0/ops/node/logging?rawLevel=&target=org.apache.cassandra.db",
+	}, *updates)
+	assert.Nil(t, dc.Status.LoggingLevels)
+
+	*updates = nil
+	assert.False(t, rc.CheckLoggingLevels().Completed())
+	assert.Empty(t, *updates)
+}
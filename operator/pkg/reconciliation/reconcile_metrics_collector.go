@@ -0,0 +1,78 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+// newMetricsCollectorConfigMap renders the configuration of the MCAC agent of
+// the spec into the ConfigMap mounted into the server containers
+func newMetricsCollectorConfigMap(dc *api.CassandraDatacenter) *corev1.ConfigMap {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(labels)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dc.GetMetricsCollectorConfigMapName(),
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			api.MetricsCollectorConfigFile: dc.Spec.MetricsCollector.Config,
+		},
+	}
+
+	addAdditionalMetadata(dc, configMap)
+
+	utils.AddHashAnnotation(configMap)
+
+	return configMap
+}
+
+// CheckMetricsCollectorConfig creates or updates the ConfigMap of the
+// configuration of the MCAC agent, before the pods that mount it
+func (rc *ReconciliationContext) CheckMetricsCollectorConfig() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.Spec.MetricsCollector == nil || dc.Spec.MetricsCollector.Config == "" || !dc.IsMetricsCollectorEnabled() {
+		return result.Continue()
+	}
+
+	desiredConfigMap := newMetricsCollectorConfigMap(dc)
+	if err := setControllerReference(dc, desiredConfigMap, rc.Scheme); err != nil {
+		return result.Error(err)
+	}
+
+	currentConfigMap := &corev1.ConfigMap{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: desiredConfigMap.Name, Namespace: desiredConfigMap.Namespace}, currentConfigMap)
+	if err != nil && errors.IsNotFound(err) {
+		rc.ReqLogger.Info("Creating the ConfigMap of the metrics collector", "ConfigMap", desiredConfigMap.Name)
+		if err := rc.createResource(desiredConfigMap); err != nil {
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created ConfigMap %s", desiredConfigMap.Name)
+		return result.Continue()
+	} else if err != nil {
+		return result.Error(err)
+	}
+
+	if !utils.ResourcesHaveSameHash(currentConfigMap, desiredConfigMap) {
+		rc.ReqLogger.Info("Updating the ConfigMap of the metrics collector", "ConfigMap", desiredConfigMap.Name)
+		if err := rc.updateResource(desiredConfigMap, currentConfigMap); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
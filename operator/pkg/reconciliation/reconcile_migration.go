@@ -0,0 +1,419 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/jobtracker"
+)
+
+// migrationRebuildKey names the rebuild of a migration in the job annotations
+// of the pods
+const migrationRebuildKey = "rebuild-for-migration"
+
+// How often a running migration is checked on
+const migrationPollSeconds = 30
+
+// CheckMigration migrates the datacenter of spec.migrateFrom to this one once
+// it is ready, phase by phase: the nodes are rebuilt from the source
+// datacenter one at a time, its client service is switched over to the pods
+// of this datacenter, and it is then decommissioned and deleted. The phase is
+// kept in the status and in the Migrating condition. Removing migrateFrom
+// before the migration completes cancels it.
+func (rc *ReconciliationContext) CheckMigration() result.ReconcileResult {
+	dc := rc.Datacenter
+	logger := rc.ReqLogger
+	config := dc.Spec.MigrateFrom
+	progress := dc.Status.Migration
+
+	if config == nil {
+		if progress == nil || progress.CompletionTime != nil {
+			return result.Continue()
+		}
+		if progress.Phase == api.MigrationPhaseClientsSwitched || progress.Phase == api.MigrationPhaseDecommissioning {
+			if err := rc.handBackClientService(progress.SourceDatacenter); err != nil {
+				logger.Error(err, "error handing the client service back to the datacenter migrated from",
+					"datacenter", progress.SourceDatacenter)
+				return result.Error(err)
+			}
+		}
+		dcPatch := client.MergeFrom(dc.DeepCopy())
+		dc.Status.Migration = nil
+		rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterMigrating, corev1.ConditionFalse,
+			"Canceled", fmt.Sprintf("The migration from datacenter %s was canceled", progress.SourceDatacenter)))
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			logger.Error(err, "error patching datacenter status for the canceled migration")
+			return result.Error(err)
+		}
+		return result.Continue()
+	}
+
+	if progress != nil && progress.SourceDatacenter == config.Datacenter && progress.CompletionTime != nil {
+		return result.Continue()
+	}
+
+	source := &api.CassandraDatacenter{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: config.Datacenter, Namespace: dc.Namespace}, source)
+	if err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "error getting the datacenter to migrate from", "datacenter", config.Datacenter)
+		return result.Error(err)
+	}
+	sourceFound := err == nil
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	if progress == nil || progress.SourceDatacenter != config.Datacenter {
+		if !sourceFound {
+			logger.Info("Waiting for the datacenter to migrate from to exist", "datacenter", config.Datacenter)
+			rc.requeueAfter(migrationPollSeconds)
+			return result.Continue()
+		}
+		if source.Spec.ClusterName != dc.Spec.ClusterName {
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.FailedMigration,
+				"Cannot migrate from datacenter %s of cluster %s", source.Name, source.Spec.ClusterName)
+			return result.Continue()
+		}
+
+		if ok, err := rc.checkMigrationReplication(source.Name); err != nil || !ok {
+			return rc.refuseMigration(dcPatch, err)
+		}
+
+		progress = &api.MigrationProgress{
+			SourceDatacenter: source.Name,
+			Phase:            api.MigrationPhaseRebuilding,
+			StartTime:        metav1.Now(),
+			PendingPods:      rc.podNamesByRack(),
+		}
+		dc.Status.Migration = progress
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.StartedMigration,
+			"Migrating from datacenter %s, rebuilding %d pods", source.Name, len(progress.PendingPods))
+	}
+
+	switching := false
+	if progress.Phase == api.MigrationPhaseRebuilding {
+		rc.progressMigrationRebuild(progress)
+		if len(progress.PendingPods) == 0 {
+			if len(progress.FailedPods) > 0 {
+				progress.Phase = api.MigrationPhaseRebuildFailed
+				rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.FailedMigration,
+					"Failed to rebuild pods %s from datacenter %s", strings.Join(progress.FailedPods, ", "), progress.SourceDatacenter)
+			} else {
+				progress.Phase = api.MigrationPhaseClientsSwitched
+				switching = true
+			}
+		}
+	}
+
+	switchedBack := false
+
+	if progress.Phase == api.MigrationPhaseClientsSwitched || progress.Phase == api.MigrationPhaseDecommissioning {
+		if !sourceFound {
+			now := metav1.Now()
+			progress.Phase = api.MigrationPhaseCompleted
+			progress.CompletionTime = &now
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CompletedMigration,
+				"Migrated from datacenter %s", progress.SourceDatacenter)
+		} else {
+			if switching {
+				// The annotation keeps the reconciliation of the source
+				// datacenter from reverting the service. It is only set once,
+				// so that removing it switches the clients back.
+				patch := client.MergeFrom(source.DeepCopy())
+				metav1.SetMetaDataAnnotation(&source.ObjectMeta, api.MigratingToAnnotation, dc.Name)
+				if err := rc.Client.Patch(rc.Ctx, source, patch); err != nil {
+					logger.Error(err, "error annotating the datacenter migrated from", "datacenter", source.Name)
+					return result.Error(err)
+				}
+			}
+
+			if source.Annotations[api.MigratingToAnnotation] == dc.Name {
+				switched, err := rc.takeOverClientService(source)
+				if err != nil {
+					logger.Error(err, "error switching the clients of the datacenter migrated from", "datacenter", source.Name)
+					return result.Error(err)
+				}
+				if switched {
+					rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.SwitchedClients,
+						"Switched the service %s of datacenter %s over to the pods of this datacenter",
+						source.GetDatacenterServiceName(), source.Name)
+				}
+			} else if source.GetDeletionTimestamp() == nil {
+				// The clients were switched back, and the source datacenter
+				// serves them again until migrateFrom is removed
+				switchedBack = true
+				progress.Phase = api.MigrationPhaseClientsSwitched
+			}
+
+			if progress.Phase == api.MigrationPhaseClientsSwitched && !config.HoldDecommission && !switchedBack {
+				progress.Phase = api.MigrationPhaseDecommissioning
+			}
+			if progress.Phase == api.MigrationPhaseDecommissioning && source.GetDeletionTimestamp() == nil {
+				// The keyspaces might have changed since the rebuild
+				if ok, err := rc.checkMigrationReplication(source.Name); err != nil || !ok {
+					progress.Phase = api.MigrationPhaseClientsSwitched
+					return rc.refuseMigration(dcPatch, err)
+				}
+			}
+			if progress.Phase == api.MigrationPhaseDecommissioning {
+				if err := rc.deleteMigratedDatacenter(source); err != nil {
+					logger.Error(err, "error deleting the datacenter migrated from", "datacenter", source.Name)
+					return result.Error(err)
+				}
+			}
+		}
+	}
+
+	if switchedBack {
+		rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterMigrating, corev1.ConditionFalse, "ClientsSwitchedBack",
+			fmt.Sprintf("The clients were switched back to datacenter %s, holding its decommission", progress.SourceDatacenter)))
+	} else {
+		status, message := migrationConditionMessage(progress)
+		rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterMigrating, status, string(progress.Phase), message))
+	}
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		logger.Error(err, "error patching datacenter status for the migration")
+		return result.Error(err)
+	}
+
+	// The rebuilds and the decommission take hours, during which the rest of
+	// the datacenter is still reconciled
+	switch progress.Phase {
+	case api.MigrationPhaseRebuilding, api.MigrationPhaseDecommissioning:
+		rc.requeueAfter(migrationPollSeconds)
+	}
+	return result.Continue()
+}
+
+// unreplicatedMigrationReason is the reason of the Migrating condition while
+// keyspaces would be lost with the datacenter migrated from
+const unreplicatedMigrationReason = "KeyspacesNotReplicated"
+
+// checkMigrationReplication returns whether every keyspace with replicas in
+// the source datacenter also has replicas in this one. A rebuild streams
+// nothing of the other keyspaces, whose data would be lost once the source
+// datacenter is decommissioned, so the migration does not go on while there
+// are any: the Migrating condition is set to False and a warning is recorded
+// instead.
+func (rc *ReconciliationContext) checkMigrationReplication(source string) (bool, error) {
+	dc := rc.Datacenter
+	pod := findReadyPod(rc.dcPods)
+	if pod == nil {
+		return false, fmt.Errorf("no ready node to read the schema from")
+	}
+
+	keyspaces, err := rc.NodeMgmtClient.CallListKeyspacesEndpoint(pod)
+	if err != nil {
+		return false, err
+	}
+	sort.Strings(keyspaces)
+
+	var unreplicated []string
+	for _, name := range keyspaces {
+		replication, err := rc.NodeMgmtClient.CallGetKeyspaceReplicationEndpoint(pod, name)
+		if err != nil {
+			return false, err
+		}
+		// Only NetworkTopologyStrategy places replicas by datacenter
+		factors, ok := parseReplication(replication)
+		if ok && factors[source] > 0 && factors[dc.Name] == 0 {
+			unreplicated = append(unreplicated, name)
+		}
+	}
+	if len(unreplicated) == 0 {
+		return true, nil
+	}
+
+	message := fmt.Sprintf("Keyspaces %s replicate to datacenter %s but not to this one",
+		strings.Join(unreplicated, ", "), source)
+	current, _ := dc.GetCondition(api.DatacenterMigrating)
+	rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterMigrating, corev1.ConditionFalse,
+		unreplicatedMigrationReason, message))
+	if current.Reason != unreplicatedMigrationReason || current.Message != message {
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.FailedMigration,
+			"Not migrating from datacenter %s: %s", source, message)
+	}
+	return false, nil
+}
+
+// refuseMigration saves the status of a migration that cannot go on, and
+// checks on it again later, once the rest of the datacenter is reconciled
+func (rc *ReconciliationContext) refuseMigration(dcPatch client.Patch, err error) result.ReconcileResult {
+	if err != nil {
+		rc.ReqLogger.Error(err, "error checking the replication of the keyspaces before migrating")
+		return result.Error(err)
+	}
+	if err := rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status for the migration")
+		return result.Error(err)
+	}
+	rc.requeueAfter(migrationPollSeconds)
+	return result.Continue()
+}
+
+func migrationConditionMessage(progress *api.MigrationProgress) (corev1.ConditionStatus, string) {
+	source := progress.SourceDatacenter
+	switch progress.Phase {
+	case api.MigrationPhaseRebuilding:
+		return corev1.ConditionTrue, fmt.Sprintf("Rebuilding %d pods from datacenter %s", len(progress.PendingPods), source)
+	case api.MigrationPhaseRebuildFailed:
+		return corev1.ConditionFalse, fmt.Sprintf("Failed to rebuild pods %s from datacenter %s",
+			strings.Join(progress.FailedPods, ", "), source)
+	case api.MigrationPhaseClientsSwitched:
+		return corev1.ConditionTrue, fmt.Sprintf("Switched the clients of datacenter %s, holding its decommission", source)
+	case api.MigrationPhaseDecommissioning:
+		return corev1.ConditionTrue, fmt.Sprintf("Decommissioning datacenter %s", source)
+	}
+	return corev1.ConditionFalse, fmt.Sprintf("Migrated from datacenter %s", source)
+}
+
+// progressMigrationRebuild rebuilds the first pending pod from the source
+// datacenter, or checks on the job it is running as, and removes it from the
+// pending ones once it is done
+func (rc *ReconciliationContext) progressMigrationRebuild(progress *api.MigrationProgress) {
+	tracker := &jobtracker.Tracker{Client: rc.Client, MgmtClient: &rc.NodeMgmtClient}
+
+	for len(progress.PendingPods) > 0 {
+		podName := progress.PendingPods[0]
+		pod := findPod(rc.dcPods, podName)
+		if pod == nil {
+			if rc.isPodScaledDown(podName) {
+				// The pods scaled down have no data to rebuild
+				progress.PendingPods = progress.PendingPods[1:]
+				continue
+			}
+			// The pod comes back, and is rebuilt then
+			rc.ReqLogger.Info("Waiting for the pod to rebuild to come back", "pod", podName)
+			return
+		}
+
+		done, err := tracker.Poll(rc.Ctx, pod, jobtracker.Operation{
+			Key: migrationRebuildKey,
+			Submit: func() (string, error) {
+				return rc.NodeMgmtClient.CallRebuildAsyncEndpoint(pod, progress.SourceDatacenter)
+			},
+			Run: func() error {
+				return rc.NodeMgmtClient.CallRebuildEndpoint(pod, progress.SourceDatacenter)
+			},
+		})
+		if !done {
+			if err != nil {
+				rc.ReqLogger.Error(err, "error checking on the rebuild", "pod", podName)
+			}
+			return
+		}
+		if err != nil {
+			rc.ReqLogger.Error(err, "error rebuilding from the datacenter migrated from", "pod", podName)
+			progress.FailedPods = append(progress.FailedPods, podName)
+		}
+		progress.PendingPods = progress.PendingPods[1:]
+		return
+	}
+}
+
+// takeOverClientService points the client service of the source datacenter at
+// the pods of this datacenter, and makes this datacenter its owner, so that it
+// outlives the source datacenter. It returns whether the service changed.
+func (rc *ReconciliationContext) takeOverClientService(source *api.CassandraDatacenter) (bool, error) {
+	return rc.pointClientService(source.GetDatacenterServiceName(), rc.Datacenter)
+}
+
+// handBackClientService points the client service of the source datacenter of
+// a canceled migration at its own pods again, and makes it its owner again.
+// The service is left alone once the source datacenter is being deleted, or
+// if its clients were already switched back.
+func (rc *ReconciliationContext) handBackClientService(sourceName string) error {
+	dc := rc.Datacenter
+	source := &api.CassandraDatacenter{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: sourceName, Namespace: dc.Namespace}, source)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if source.GetDeletionTimestamp() != nil || source.Annotations[api.MigratingToAnnotation] != dc.Name {
+		return nil
+	}
+
+	if _, err := rc.pointClientService(source.GetDatacenterServiceName(), source); err != nil {
+		return err
+	}
+	patch := client.MergeFrom(source.DeepCopy())
+	delete(source.Annotations, api.MigratingToAnnotation)
+	if err := rc.Client.Patch(rc.Ctx, source, patch); err != nil {
+		return err
+	}
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.SwitchedClients,
+		"Switched the service %s back to the pods of datacenter %s", source.GetDatacenterServiceName(), source.Name)
+	return nil
+}
+
+// pointClientService has the client service of name select the pods of owner,
+// which it makes its owner. It returns whether the service changed.
+func (rc *ReconciliationContext) pointClientService(name string, owner *api.CassandraDatacenter) (bool, error) {
+	desiredSvc := newServiceForCassandraDatacenter(owner)
+	desiredSvc.Name = name
+	if err := setControllerReference(owner, desiredSvc, rc.Scheme); err != nil {
+		return false, err
+	}
+
+	currentService := &corev1.Service{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: desiredSvc.Name, Namespace: owner.Namespace}, currentService)
+	if errors.IsNotFound(err) {
+		return true, rc.createResource(desiredSvc)
+	} else if err != nil {
+		return false, err
+	}
+
+	if reflect.DeepEqual(currentService.Spec.Selector, desiredSvc.Spec.Selector) &&
+		reflect.DeepEqual(currentService.OwnerReferences, desiredSvc.OwnerReferences) {
+		return false, nil
+	}
+
+	desiredSvc.Spec.ClusterIP = currentService.Spec.ClusterIP
+	return true, rc.updateResource(desiredSvc, currentService)
+}
+
+// deleteMigratedDatacenter deletes the source datacenter of a migration, with
+// decommissionOnDelete so that its nodes leave the cluster first. The source
+// datacenter is removed from the replication of the keyspaces beforehand, as
+// its nodes cannot be decommissioned while they own replicas.
+func (rc *ReconciliationContext) deleteMigratedDatacenter(source *api.CassandraDatacenter) error {
+	if source.GetDeletionTimestamp() != nil {
+		return nil
+	}
+
+	pod := findReadyPod(rc.dcPods)
+	if pod == nil {
+		return fmt.Errorf("no ready node to remove datacenter %s from the replication of the keyspaces with", source.Name)
+	}
+	if err := rc.removeDatacenterReplication(pod, source.Name); err != nil {
+		return err
+	}
+
+	if !source.Spec.DecommissionOnDelete {
+		patch := client.MergeFrom(source.DeepCopy())
+		source.Spec.DecommissionOnDelete = true
+		if err := rc.Client.Patch(rc.Ctx, source, patch); err != nil {
+			return err
+		}
+	}
+
+	rc.ReqLogger.Info("Deleting the datacenter migrated from", "datacenter", source.Name)
+	if err := rc.Client.Delete(rc.Ctx, source); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
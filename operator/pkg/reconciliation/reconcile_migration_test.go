@@ -0,0 +1,320 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+// setupMigrationTest creates the source datacenter of the migration with its
+// client service, and gives the datacenter two pods whose management API
+// rebuilds them synchronously. The keyspaces of the cluster have the
+// replications given, or one replicating to both datacenters when nil.
+func setupMigrationTest(t *testing.T, rc *ReconciliationContext, replications map[string]string) *api.CassandraDatacenter {
+	dc := rc.Datacenter
+	source := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc-old", Namespace: dc.Namespace},
+		Spec:       api.CassandraDatacenterSpec{ClusterName: dc.Spec.ClusterName},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, source))
+	assert.NoError(t, rc.Client.Create(rc.Ctx, newServiceForCassandraDatacenter(source)))
+	dc.Spec.MigrateFrom = &api.MigrationConfig{Datacenter: source.Name}
+
+	for _, name := range []string{"default-1", "default-0"} {
+		pod := makeReadyPod(name)
+		pod.Labels = dc.GetRackLabels("default")
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+
+	if replications == nil {
+		replications = map[string]string{
+			"app": `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc-old": "3", "` + dc.Name + `": "3"}`,
+		}
+	}
+	replications["system"] = `{"class": "org.apache.cassandra.locator.LocalStrategy"}`
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/keyspace"
+			})).
+		Return(func(req *http.Request) *http.Response {
+			var names []string
+			for name := range replications {
+				names = append(names, `"`+name+`"`)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("[" + strings.Join(names, ", ") + "]")),
+			}
+		}, nil)
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/keyspace/replication"
+			})).
+		Return(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(replications[req.URL.Query().Get("keyspaceName")])),
+			}
+		}, nil)
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/keyspace/alter"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil)
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v1/ops/node/rebuild"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil)
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/node/rebuild" &&
+					req.URL.Query().Get("src_dc") == "dc-old"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil)
+
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+	return source
+}
+
+func TestCheckMigration(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	source := setupMigrationTest(t, rc, nil)
+	dc.Spec.MigrateFrom.HoldDecommission = true
+
+	// The pods are rebuilt one at a time, while the rest of the datacenter
+	// is still reconciled
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, migrationPollSeconds, rc.requeueSeconds)
+	progress := dc.Status.Migration
+	assert.NotNil(t, progress)
+	assert.Equal(t, api.MigrationPhaseRebuilding, progress.Phase)
+	assert.Equal(t, []string{"default-1"}, progress.PendingPods)
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterMigrating))
+
+	// Then the clients are switched over, and the decommission is held
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, api.MigrationPhaseClientsSwitched, progress.Phase)
+	service := &corev1.Service{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: source.GetDatacenterServiceName(), Namespace: dc.Namespace}, service))
+	assert.Equal(t, dc.GetDatacenterLabels(), service.Spec.Selector)
+	assert.Equal(t, dc.Name, service.Labels[api.DatacenterLabel])
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: source.Name, Namespace: dc.Namespace}, source))
+	assert.Equal(t, dc.Name, source.Annotations[api.MigratingToAnnotation])
+
+	// Releasing the hold deletes the source datacenter, once decommissioned
+	dc.Spec.MigrateFrom.HoldDecommission = false
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, api.MigrationPhaseDecommissioning, progress.Phase)
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: source.Name, Namespace: dc.Namespace}, source)
+	assert.True(t, errors.IsNotFound(err), "the source datacenter is deleted")
+	altered := alteredKeyspaces(t, rc.NodeMgmtClient.Client.(*mocks.HttpClient))
+	assert.Len(t, altered, 1, "the source datacenter is removed from the replication first")
+	assert.JSONEq(t, `{"keyspace_name": "app", "replication_settings": [
+		{"dc_name": "`+dc.Name+`", "replication_factor": "3"}]}`, altered[0])
+
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, api.MigrationPhaseCompleted, progress.Phase)
+	assert.NotNil(t, progress.CompletionTime)
+	assert.Equal(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterMigrating))
+}
+
+func TestCheckMigration_MissingPod(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	setupMigrationTest(t, rc, nil)
+	dc.Spec.MigrateFrom.HoldDecommission = true
+	replicas := int32(2)
+	rc.statefulSets = []*appsv1.StatefulSet{{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: dc.Namespace},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	}}
+
+	assert.False(t, rc.CheckMigration().Completed())
+	progress := dc.Status.Migration
+	assert.Equal(t, []string{"default-1"}, progress.PendingPods)
+
+	// A pod that restarts is rebuilt once it is back
+	rc.dcPods = rc.dcPods[1:]
+	assert.Equal(t, "default-0", rc.dcPods[0].Name)
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, api.MigrationPhaseRebuilding, progress.Phase)
+	assert.Equal(t, []string{"default-1"}, progress.PendingPods)
+
+	// while a pod scaled down is not
+	replicas = 1
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, api.MigrationPhaseClientsSwitched, progress.Phase)
+	assert.Empty(t, progress.FailedPods)
+}
+
+func TestCheckMigration_Cancel(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	setupMigrationTest(t, rc, nil)
+
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.NotNil(t, dc.Status.Migration)
+
+	dc.Spec.MigrateFrom = nil
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Nil(t, dc.Status.Migration)
+	condition, _ := dc.GetCondition(api.DatacenterMigrating)
+	assert.Equal(t, "Canceled", condition.Reason)
+}
+
+func TestCheckMigration_SwitchBack(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	source := setupMigrationTest(t, rc, nil)
+	dc.Spec.MigrateFrom.HoldDecommission = true
+	sourceKey := types.NamespacedName{Name: source.Name, Namespace: dc.Namespace}
+
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, api.MigrationPhaseClientsSwitched, dc.Status.Migration.Phase)
+
+	// Removing the annotation from the source datacenter switches the
+	// clients back, and it is not added again
+	assert.NoError(t, rc.Client.Get(rc.Ctx, sourceKey, source))
+	delete(source.Annotations, api.MigratingToAnnotation)
+	assert.NoError(t, rc.Client.Update(rc.Ctx, source))
+
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, sourceKey, source))
+	assert.Empty(t, source.Annotations[api.MigratingToAnnotation])
+	condition, _ := dc.GetCondition(api.DatacenterMigrating)
+	assert.Equal(t, "ClientsSwitchedBack", condition.Reason)
+
+	// and the source datacenter is not decommissioned once the hold is released
+	dc.Spec.MigrateFrom.HoldDecommission = false
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, api.MigrationPhaseClientsSwitched, dc.Status.Migration.Phase)
+	assert.NoError(t, rc.Client.Get(rc.Ctx, sourceKey, source))
+	assert.Nil(t, source.GetDeletionTimestamp())
+}
+
+func TestCheckMigration_CancelAfterSwitch(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	source := setupMigrationTest(t, rc, nil)
+	dc.Spec.MigrateFrom.HoldDecommission = true
+	sourceKey := types.NamespacedName{Name: source.Name, Namespace: dc.Namespace}
+	serviceKey := types.NamespacedName{Name: source.GetDatacenterServiceName(), Namespace: dc.Namespace}
+
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, api.MigrationPhaseClientsSwitched, dc.Status.Migration.Phase)
+
+	// Canceling hands the service back to the source datacenter
+	dc.Spec.MigrateFrom = nil
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Nil(t, dc.Status.Migration)
+
+	service := &corev1.Service{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, serviceKey, service))
+	assert.Equal(t, source.GetDatacenterLabels(), service.Spec.Selector)
+	assert.Equal(t, source.Name, service.Labels[api.DatacenterLabel])
+	assert.NoError(t, rc.Client.Get(rc.Ctx, sourceKey, source))
+	assert.Empty(t, source.Annotations[api.MigratingToAnnotation])
+}
+
+func TestCheckMigration_OtherCluster(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	source := setupMigrationTest(t, rc, nil)
+	source.Spec.ClusterName = "other-cluster"
+	assert.NoError(t, rc.Client.Update(rc.Ctx, source))
+
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Nil(t, dc.Status.Migration)
+}
+
+func TestCheckMigration_UnreplicatedKeyspace(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	replications := map[string]string{
+		"app":    `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc-old": "3", "` + dc.Name + `": "3"}`,
+		"orders": `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc-old": "3"}`,
+	}
+	source := setupMigrationTest(t, rc, replications)
+	dc.Spec.MigrateFrom.HoldDecommission = true
+
+	// Nothing is rebuilt while a keyspace would be lost with the source
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, migrationPollSeconds, rc.requeueSeconds)
+	assert.Nil(t, dc.Status.Migration)
+	condition, _ := dc.GetCondition(api.DatacenterMigrating)
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, unreplicatedMigrationReason, condition.Reason)
+	assert.Contains(t, condition.Message, "orders")
+
+	replications["orders"] = `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc-old": "3", "` + dc.Name + `": "3"}`
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, api.MigrationPhaseRebuilding, dc.Status.Migration.Phase)
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterMigrating))
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, api.MigrationPhaseClientsSwitched, dc.Status.Migration.Phase)
+
+	// A keyspace created since the rebuild holds the decommission
+	replications["events"] = `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "dc-old": "1"}`
+	dc.Spec.MigrateFrom.HoldDecommission = false
+	assert.False(t, rc.CheckMigration().Completed())
+	assert.Equal(t, api.MigrationPhaseClientsSwitched, dc.Status.Migration.Phase)
+	condition, _ = dc.GetCondition(api.DatacenterMigrating)
+	assert.Equal(t, unreplicatedMigrationReason, condition.Reason)
+	assert.Contains(t, condition.Message, "events")
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: source.Name, Namespace: dc.Namespace}, source)
+	assert.NoError(t, err, "the source datacenter is not deleted")
+}
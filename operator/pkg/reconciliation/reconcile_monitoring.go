@@ -0,0 +1,233 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+// The monitors are resources of the Prometheus operator, whose types are not
+// compiled in, so they are handled as unstructured objects
+const monitoringAPIVersion = "monitoring.coreos.com/v1"
+
+func monitorName(dc *api.CassandraDatacenter) string {
+	return dc.Spec.ClusterName + "-" + dc.Name + "-monitor"
+}
+
+func emptyMonitor(dc *api.CassandraDatacenter, kind string) *unstructured.Unstructured {
+	monitor := &unstructured.Unstructured{}
+	monitor.SetAPIVersion(monitoringAPIVersion)
+	monitor.SetKind(kind)
+	monitor.SetNamespace(dc.Namespace)
+	monitor.SetName(monitorName(dc))
+	return monitor
+}
+
+// newMonitorForCassandraDatacenter creates the ServiceMonitor or PodMonitor
+// that scrapes the metrics of the nodes of the datacenter
+func newMonitorForCassandraDatacenter(dc *api.CassandraDatacenter) *unstructured.Unstructured {
+	config := dc.Spec.Monitoring
+	kind := dc.GetMonitoringKind()
+	monitor := emptyMonitor(dc, kind)
+
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(labels)
+	labels = utils.MergeMap(map[string]string{}, config.Labels, labels)
+	monitor.SetLabels(labels)
+
+	endpoint := map[string]interface{}{
+		"port": "prometheus",
+		"path": "/metrics",
+	}
+	if config.Interval != "" {
+		endpoint["interval"] = config.Interval
+	}
+	if len(config.Relabelings) > 0 {
+		endpoint["relabelings"] = relabelingsToUnstructured(config.Relabelings)
+	}
+
+	var selectorLabels map[string]string
+	endpointsField := "endpoints"
+	if kind == api.PodMonitorKind {
+		selectorLabels = dc.GetDatacenterLabels()
+		endpointsField = "podMetricsEndpoints"
+	} else {
+		selectorLabels = dc.GetDatacenterLabels()
+		selectorLabels[api.PromMetricsLabel] = "true"
+	}
+	matchLabels := map[string]interface{}{}
+	for key, value := range selectorLabels {
+		matchLabels[key] = value
+	}
+
+	monitor.Object["spec"] = map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": matchLabels,
+		},
+		"namespaceSelector": map[string]interface{}{
+			"matchNames": []interface{}{dc.Namespace},
+		},
+		endpointsField: []interface{}{endpoint},
+	}
+
+	addAdditionalMetadata(dc, monitor)
+
+	utils.AddHashAnnotation(monitor)
+
+	return monitor
+}
+
+func relabelingsToUnstructured(relabelings []api.RelabelConfig) []interface{} {
+	var result []interface{}
+	for _, relabeling := range relabelings {
+		fields := map[string]interface{}{}
+		if len(relabeling.SourceLabels) > 0 {
+			sourceLabels := []interface{}{}
+			for _, label := range relabeling.SourceLabels {
+				sourceLabels = append(sourceLabels, label)
+			}
+			fields["sourceLabels"] = sourceLabels
+		}
+		for name, value := range map[string]string{
+			"separator":   relabeling.Separator,
+			"targetLabel": relabeling.TargetLabel,
+			"regex":       relabeling.Regex,
+			"replacement": relabeling.Replacement,
+			"action":      relabeling.Action,
+		} {
+			if value != "" {
+				fields[name] = value
+			}
+		}
+		if relabeling.Modulus > 0 {
+			fields["modulus"] = int64(relabeling.Modulus)
+		}
+		result = append(result, fields)
+	}
+	return result
+}
+
+// The reasons of the MonitoringUnavailable condition
+const (
+	metricsCollectorDisabledReason  = "MetricsCollectorDisabled"
+	prometheusOperatorMissingReason = "PrometheusOperatorNotInstalled"
+)
+
+// CheckMonitoring creates, updates or deletes the monitor of the datacenter
+// according to spec.monitoring. The monitor is only created while the MCAC
+// agent serves the metrics of the nodes.
+func (rc *ReconciliationContext) CheckMonitoring() result.ReconcileResult {
+	dc := rc.Datacenter
+	kind := dc.GetMonitoringKind()
+	var unavailableMessage string
+	if kind != "" && !dc.IsMetricsCollectorEnabled() {
+		unavailableMessage = fmt.Sprintf("Cannot create a %s since the metrics collector is disabled", kind)
+		kind = ""
+	}
+
+	for _, otherKind := range []string{api.ServiceMonitorKind, api.PodMonitorKind} {
+		if otherKind != kind {
+			if err := rc.deleteMonitor(otherKind); err != nil {
+				return result.Error(err)
+			}
+		}
+	}
+	if kind == "" {
+		return rc.setMonitoringUnavailable(metricsCollectorDisabledReason, unavailableMessage)
+	}
+
+	desiredMonitor := newMonitorForCassandraDatacenter(dc)
+	if err := setControllerReference(dc, desiredMonitor, rc.Scheme); err != nil {
+		return result.Error(err)
+	}
+
+	currentMonitor := emptyMonitor(dc, kind)
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: currentMonitor.GetName(), Namespace: currentMonitor.GetNamespace()}, currentMonitor)
+	if meta.IsNoMatchError(err) {
+		rc.ReqLogger.Info("The Prometheus operator is not installed, skipping the monitor", "kind", kind)
+		return rc.setMonitoringUnavailable(prometheusOperatorMissingReason,
+			fmt.Sprintf("Cannot create a %s since the Prometheus operator is not installed", kind))
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return result.Error(err)
+	}
+
+	if errors.IsNotFound(err) {
+		rc.ReqLogger.Info("Creating a monitor", "kind", kind, "name", desiredMonitor.GetName())
+		if err := rc.createResource(desiredMonitor); err != nil {
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created %s %s", kind, desiredMonitor.GetName())
+	} else if !utils.ResourcesHaveSameHash(currentMonitor, desiredMonitor) {
+		rc.ReqLogger.Info("Updating a monitor", "kind", kind, "name", desiredMonitor.GetName())
+		if err := rc.updateResource(desiredMonitor, currentMonitor); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	return rc.setMonitoringUnavailable("", "")
+}
+
+// setMonitoringUnavailable sets the MonitoringUnavailable condition, True
+// with the reason and the message when the message is not empty. The warning
+// event is only recorded when the condition becomes True, rather than on
+// every reconciliation.
+func (rc *ReconciliationContext) setMonitoringUnavailable(reason, message string) result.ReconcileResult {
+	dc := rc.Datacenter
+	if message == "" && dc.GetConditionStatus(api.DatacenterMonitoringUnavailable) != corev1.ConditionTrue {
+		return result.Continue()
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	unavailable := message != "" && rc.conditionTransitions(api.DatacenterMonitoringUnavailable, corev1.ConditionTrue)
+	if message == "" {
+		rc.setCondition(api.NewDatacenterCondition(api.DatacenterMonitoringUnavailable, corev1.ConditionFalse))
+	} else if !rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterMonitoringUnavailable, corev1.ConditionTrue, reason, message)) {
+		return result.Continue()
+	}
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status for monitoring")
+		return result.Error(err)
+	}
+	if unavailable {
+		rc.Recorder.Event(dc, corev1.EventTypeWarning, events.MonitoringUnavailable, message)
+	}
+	return result.Continue()
+}
+
+// deleteMonitor deletes the monitor of the given kind the operator created
+// for the datacenter, if any
+func (rc *ReconciliationContext) deleteMonitor(kind string) error {
+	monitor := emptyMonitor(rc.Datacenter, kind)
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: monitor.GetName(), Namespace: monitor.GetNamespace()}, monitor)
+	if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !oplabels.HasManagedByCassandraOperatorLabel(monitor.GetLabels()) {
+		return nil
+	}
+
+	rc.ReqLogger.Info("Deleting a monitor", "kind", kind, "name", monitor.GetName())
+	if err := rc.Client.Delete(rc.Ctx, monitor); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+)
+
+func TestNewMonitorForCassandraDatacenter(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "cluster1",
+			Monitoring: &api.MonitoringConfig{
+				Enabled:  true,
+				Interval: "30s",
+				Labels:   map[string]string{"release": "prometheus"},
+				Relabelings: []api.RelabelConfig{{
+					SourceLabels: []string{"__meta_kubernetes_pod_name"},
+					TargetLabel:  "pod",
+				}},
+			},
+		},
+	}
+
+	monitor := newMonitorForCassandraDatacenter(dc)
+	assert.Equal(t, api.ServiceMonitorKind, monitor.GetKind())
+	assert.Equal(t, "cluster1-dc1-monitor", monitor.GetName())
+	assert.Equal(t, "prometheus", monitor.GetLabels()["release"])
+	assert.True(t, oplabels.HasManagedByCassandraOperatorLabel(monitor.GetLabels()))
+
+	matchLabels, _, _ := unstructured.NestedStringMap(monitor.Object, "spec", "selector", "matchLabels")
+	assert.Equal(t, "true", matchLabels[api.PromMetricsLabel])
+	assert.Equal(t, "dc1", matchLabels[api.DatacenterLabel])
+
+	endpoints, _, _ := unstructured.NestedSlice(monitor.Object, "spec", "endpoints")
+	assert.Len(t, endpoints, 1)
+	endpoint := endpoints[0].(map[string]interface{})
+	assert.Equal(t, "prometheus", endpoint["port"])
+	assert.Equal(t, "30s", endpoint["interval"])
+	relabelings := endpoint["relabelings"].([]interface{})
+	assert.Equal(t, "pod", relabelings[0].(map[string]interface{})["targetLabel"])
+
+	dc.Spec.Monitoring.Kind = api.PodMonitorKind
+	monitor = newMonitorForCassandraDatacenter(dc)
+	assert.Equal(t, api.PodMonitorKind, monitor.GetKind())
+	matchLabels, _, _ = unstructured.NestedStringMap(monitor.Object, "spec", "selector", "matchLabels")
+	assert.NotContains(t, matchLabels, api.PromMetricsLabel)
+	endpoints, _, _ = unstructured.NestedSlice(monitor.Object, "spec", "podMetricsEndpoints")
+	assert.Len(t, endpoints, 1)
+}
+
+func TestCheckMonitoring(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	monitorKey := types.NamespacedName{Name: monitorName(dc), Namespace: dc.Namespace}
+	getMonitor := func() error {
+		return rc.Client.Get(rc.Ctx, monitorKey, emptyMonitor(dc, api.ServiceMonitorKind))
+	}
+
+	// Without the MCAC agent there are no metrics to scrape
+	disabled := false
+	dc.Spec.Monitoring = &api.MonitoringConfig{Enabled: true}
+	dc.Spec.MetricsCollector = &api.MetricsCollectorConfig{Enabled: &disabled}
+	assert.False(t, rc.CheckMonitoring().Completed())
+	assert.True(t, errors.IsNotFound(getMonitor()), "the monitor should not be created")
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterMonitoringUnavailable))
+	assert.True(t, hasEvent(recordedEvents(rc), events.MonitoringUnavailable))
+
+	assert.False(t, rc.CheckMonitoring().Completed())
+	assert.False(t, hasEvent(recordedEvents(rc), events.MonitoringUnavailable), "the warning should only be recorded once")
+
+	dc.Spec.MetricsCollector = nil
+	assert.False(t, rc.CheckMonitoring().Completed())
+	assert.NoError(t, getMonitor())
+	assert.Equal(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterMonitoringUnavailable))
+
+	// Removing spec.monitoring deletes the monitor
+	dc.Spec.Monitoring = nil
+	assert.False(t, rc.CheckMonitoring().Completed())
+	assert.True(t, errors.IsNotFound(getMonitor()), "the monitor should be deleted")
+}
@@ -0,0 +1,68 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+// How often the status of a paused datacenter is refreshed
+const pausedStatusRefreshSeconds = 60
+
+// UpdateStatusWhilePaused keeps the status of a datacenter paused with the
+// PausedAnnotation up to date without changing anything else: it sets the
+// ReconciliationPaused condition and refreshes the node statuses.
+func (rc *ReconciliationContext) UpdateStatusWhilePaused() result.ReconcileResult {
+	dc := rc.Datacenter
+	rc.ReqLogger.Info("reconcile_pause::UpdateStatusWhilePaused")
+
+	podList, err := rc.listPods(dc.GetDatacenterLabels())
+	if err != nil {
+		rc.ReqLogger.Error(err, "error listing the pods of the paused datacenter")
+		return result.Error(err)
+	}
+	rc.dcPods = PodPtrsFromPodList(podList)
+
+	oldDc := dc.DeepCopy()
+
+	rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterReconciliationPaused, corev1.ConditionTrue,
+		"PausedAnnotation", "Reconciliation is paused with the "+api.PausedAnnotation+" annotation"))
+
+	if err := rc.UpdateCassandraNodeStatus(); err != nil {
+		return result.Error(err)
+	}
+
+	if !reflect.DeepEqual(dc.Status, oldDc.Status) {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, client.MergeFrom(oldDc)); err != nil {
+			rc.ReqLogger.Error(err, "error updating the status of the paused datacenter")
+			return result.Error(err)
+		}
+	}
+
+	return result.RequeueSoon(pausedStatusRefreshSeconds)
+}
+
+// CheckReconciliationResumed clears the ReconciliationPaused condition once
+// the PausedAnnotation is removed
+func (rc *ReconciliationContext) CheckReconciliationResumed() result.ReconcileResult {
+	dc := rc.Datacenter
+	if dc.GetConditionStatus(api.DatacenterReconciliationPaused) != corev1.ConditionTrue {
+		return result.Continue()
+	}
+
+	patch := client.MergeFrom(dc.DeepCopy())
+	rc.setCondition(api.NewDatacenterCondition(api.DatacenterReconciliationPaused, corev1.ConditionFalse))
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, patch); err != nil {
+		rc.ReqLogger.Error(err, "error updating the ReconciliationPaused condition")
+		return result.Error(err)
+	}
+
+	return result.Continue()
+}
@@ -0,0 +1,58 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestUpdateStatusWhilePaused(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Annotations = map[string]string{api.PausedAnnotation: "true"}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-0",
+			Namespace: rc.Datacenter.Namespace,
+			Labels:    rc.Datacenter.GetDatacenterLabels(),
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+
+	recResult := rc.UpdateStatusWhilePaused()
+	assert.True(t, recResult.Completed())
+	res, err := recResult.Output()
+	assert.NoError(t, err)
+	assert.True(t, res.RequeueAfter > 0, "the status of a paused datacenter should be refreshed periodically")
+
+	dc := &api.CassandraDatacenter{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: rc.Datacenter.Name, Namespace: rc.Datacenter.Namespace}, dc))
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterReconciliationPaused))
+	assert.Contains(t, dc.Status.NodeStatuses, "pod-0")
+
+	// Resuming clears the condition
+	rc.Datacenter = dc
+	delete(rc.Datacenter.Annotations, api.PausedAnnotation)
+	recResult = rc.CheckReconciliationResumed()
+	assert.False(t, recResult.Completed())
+	assert.Equal(t, corev1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterReconciliationPaused))
+}
+
+func TestCheckReconciliationResumed_NeverPaused(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	recResult := rc.CheckReconciliationResumed()
+	assert.False(t, recResult.Completed())
+	_, hasCondition := rc.Datacenter.GetCondition(api.DatacenterReconciliationPaused)
+	assert.False(t, hasCondition)
+}
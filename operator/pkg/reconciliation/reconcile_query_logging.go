@@ -0,0 +1,74 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"sync/atomic"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+)
+
+// CheckQueryLogging turns the full query log and the audit log of every ready
+// node on or off to match the spec. Only the log directories are part of the
+// pod template, so this is done at runtime without restarting the pods. A node
+// that restarts comes back with its logs off, and is caught up here.
+func (rc *ReconciliationContext) CheckQueryLogging() result.ReconcileResult {
+	logger := rc.ReqLogger
+	dc := rc.Datacenter
+	logger.Info("reconcile_query_logging::CheckQueryLogging")
+
+	logs := []struct {
+		name     string
+		endpoint string
+		config   *api.QueryLoggingConfig
+	}{
+		{"full query logging", httphelper.FullQueryLoggingEndpoint, dc.Spec.FullQueryLogging},
+		{"audit logging", httphelper.AuditLoggingEndpoint, dc.Spec.AuditLogging},
+	}
+
+	var readyPods []*corev1.Pod
+	for _, pod := range rc.dcPods {
+		if isServerReady(pod) {
+			readyPods = append(readyPods, pod)
+		}
+	}
+
+	var failed int32
+	rc.NodeMgmtClient.ForEachPod(readyPods, func(pod *corev1.Pod) {
+		for _, queryLog := range logs {
+			if queryLog.config == nil {
+				continue
+			}
+
+			enabled, err := rc.NodeMgmtClient.CallGetQueryLoggingEndpoint(pod, queryLog.endpoint)
+			if err != nil {
+				logger.Error(err, "Could not get the state of "+queryLog.name, "pod", pod.Name)
+				atomic.StoreInt32(&failed, 1)
+				continue
+			}
+			if enabled == queryLog.config.Enabled {
+				continue
+			}
+
+			if err := rc.NodeMgmtClient.CallSetQueryLoggingEndpoint(pod, queryLog.endpoint, queryLog.config.Enabled); err != nil {
+				logger.Error(err, "Could not update "+queryLog.name, "pod", pod.Name)
+				atomic.StoreInt32(&failed, 1)
+				continue
+			}
+
+			rc.Recorder.Eventf(dc, "Normal", events.UpdatedQueryLogging,
+				"Set %s to enabled=%t on pod %s", queryLog.name, queryLog.config.Enabled, pod.Name)
+		}
+	})
+
+	if atomic.LoadInt32(&failed) == 1 {
+		return result.RequeueSoon(10)
+	}
+	return result.Continue()
+}
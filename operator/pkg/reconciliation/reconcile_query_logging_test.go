@@ -0,0 +1,92 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+// setupQueryLoggingTest makes the management API of every node report full
+// query logging as fullQueryLogging and audit logging as off. The requests
+// that change a log are recorded in the returned slice.
+func setupQueryLoggingTest(rc *ReconciliationContext, fullQueryLogging bool) *[]string {
+	pod := makeReadyPod("pod-0")
+	pod.Status.PodIP = "10.0.0.1"
+	rc.dcPods = []*corev1.Pod{pod}
+
+	var updates []string
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil
+			})).
+		Return(func(req *http.Request) *http.Response {
+			body := `{"entity": false}`
+			if req.Method == http.MethodPost {
+				updates = append(updates, req.URL.RequestURI())
+				body = "OK"
+			} else if req.URL.Path == httphelper.FullQueryLoggingEndpoint && fullQueryLogging {
+				body = `{"entity": true}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}
+		}, nil)
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	return &updates
+}
+
+func TestCheckQueryLogging_NotConfigured(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	updates := setupQueryLoggingTest(rc, true)
+
+	recResult := rc.CheckQueryLogging()
+	assert.False(t, recResult.Completed())
+	assert.Empty(t, *updates, "logs that are not configured should be left alone")
+}
+
+func TestCheckQueryLogging_UpdatesNodes(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.FullQueryLogging = &api.QueryLoggingConfig{Enabled: false}
+	rc.Datacenter.Spec.AuditLogging = &api.QueryLoggingConfig{Enabled: true}
+	updates := setupQueryLoggingTest(rc, true)
+
+	recResult := rc.CheckQueryLogging()
+	assert.False(t, recResult.Completed())
+	assert.Equal(t, []string{
+		httphelper.FullQueryLoggingEndpoint + "?enabled=false",
+		httphelper.AuditLoggingEndpoint + "?enabled=true",
+	}, *updates)
+}
+
+func TestCheckQueryLogging_UpToDate(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.FullQueryLogging = &api.QueryLoggingConfig{Enabled: true}
+	rc.Datacenter.Spec.AuditLogging = &api.QueryLoggingConfig{Enabled: false}
+	updates := setupQueryLoggingTest(rc, true)
+
+	recResult := rc.CheckQueryLogging()
+	assert.False(t, recResult.Completed())
+	assert.Empty(t, *updates)
+}
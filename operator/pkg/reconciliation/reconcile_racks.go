@@ -8,11 +8,14 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -23,10 +26,12 @@ import (
 
 	"github.com/k8ssandra/cass-operator/operator/internal/result"
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/emm"
 	"github.com/k8ssandra/cass-operator/operator/pkg/events"
 	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/jobtracker"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mgmtapi"
 	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
-	"github.com/k8ssandra/cass-operator/operator/pkg/psp"
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
 )
 
@@ -62,6 +67,8 @@ func (rc *ReconciliationContext) CalculateRackInformation() error {
 
 	if rc.Datacenter.Spec.Stopped {
 		nodeCount = 0
+	} else if rc.Datacenter.IsHibernated() {
+		nodeCount = rc.Datacenter.GetHibernatedNodeCount()
 	}
 
 	// 3 seeds per datacenter (this could be two, but we would like three seeds per cluster
@@ -105,6 +112,10 @@ func (rc *ReconciliationContext) CheckSuperuserSecretCreation() result.Reconcile
 	rc.ReqLogger.Info("reconcile_racks::CheckSuperuserSecretCreation")
 
 	_, err := rc.retrieveSuperuserSecretOrCreateDefault()
+	if errors.IsNotFound(err) && rc.Datacenter.GetVaultSecretProviderClass() != "" {
+		rc.ReqLogger.Info("waiting for the CSI driver to sync the superuser secret from vault")
+		return result.Continue()
+	}
 	if err != nil {
 		rc.ReqLogger.Error(err, "error retrieving SuperuserSecret for CassandraDatacenter.")
 		return result.Error(err)
@@ -138,6 +149,12 @@ func (rc *ReconciliationContext) CheckRackCreation() result.ReconcileResult {
 				err,
 				"Could not locate statefulSet for",
 				"Rack", rackInfo.RackName)
+			// The pod template of a new datacenter is not validated yet
+			if _, ok := err.(*resourcesNotGuaranteedError); ok {
+				if res := rc.rejectConfig(resourcesNotGuaranteedReason, err.Error()); res.Completed() {
+					return res
+				}
+			}
 			return result.Error(err)
 		}
 
@@ -161,47 +178,24 @@ func (rc *ReconciliationContext) CheckRackCreation() result.ReconcileResult {
 	return result.Continue()
 }
 
-func (rc *ReconciliationContext) desiredStatefulSetForExistingStatefulSet(sts *appsv1.StatefulSet, rackName string) (desiredSts *appsv1.StatefulSet, err error) {
-	dc := rc.Datacenter
-
-	// have to use zero here, because each statefulset is created with no replicas
-	// in GetStatefulSetForRack()
-	replicas := 0
-
-	// when Cass Operator was released, we accidentally used the incorrect managed-by
-	// label of "cassandra-operator" we have since fixed this to be "cass-operator",
-	// but unfortunately, we cannot modify the labels in the volumeClaimTemplates of a
-	// StatefulSet. Consequently, we must preserve the old labels in this case.
-	usesDefunct := usesDefunctPvcManagedByLabel(sts)
-
-	if usesDefunct {
-		desiredSts, err = newStatefulSetForCassandraDatacenterWithDefunctPvcManagedBy(rackName, dc, replicas)
-	} else {
-		desiredSts, err = newStatefulSetForCassandraDatacenter(rackName, dc, replicas)
-	}
-
-	return
-}
-
 func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 	logger := rc.ReqLogger
 	dc := rc.Datacenter
 	logger.Info("starting CheckRackPodTemplate()")
 
+	// The statefulsets whose canary pods are upgraded and waiting for approval
+	var canaries []*appsv1.StatefulSet
+
 	for idx := range rc.desiredRackInformation {
 		rackName := rc.desiredRackInformation[idx].RackName
-		if dc.Spec.CanaryUpgrade && idx > 0 {
-			logger.
-				WithValues("rackName", rackName).
-				Info("Skipping rack because CanaryUpgrade is turned on")
-			return result.Continue()
-		}
 		statefulSet := rc.statefulSets[idx]
 
-		desiredSts, err := rc.desiredStatefulSetForExistingStatefulSet(statefulSet, rackName)
+		// have to use zero here, because each statefulset is created with no replicas
+		// in GetStatefulSetForRack()
+		desiredSts, err := newStatefulSetForCassandraDatacenter(rackName, dc, 0)
 
 		if err != nil {
-			logger.Error(err, "error calling desiredStatefulSetForExistingStatefulSet")
+			logger.Error(err, "error calling newStatefulSetForCassandraDatacenter")
 			return result.Error(err)
 		}
 
@@ -217,26 +211,68 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 		}
 
 		needsUpdate := false
+		configChanged := false
+		// The annotations the operator records with patches that the update
+		// resets
+		var droppedAnnotations []string
 
-		if !utils.ResourcesHaveSameHash(statefulSet, desiredSts) {
+		if utils.ResourcesHaveSameHash(statefulSet, desiredSts) && dc.Spec.RevertStatefulSetDrift {
+			drifted, err := rc.checkStatefulSetDrift(statefulSet)
+			if err != nil {
+				return result.Error(err)
+			}
+			if drifted {
+				logger.
+					WithValues("rackName", rackName).
+					Info("statefulset was edited outside of the operator, reverting it")
+
+				rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeWarning, events.RevertedStatefulSetDrift,
+					"Reverting the edits of StatefulSet %s", statefulSet.Name)
+
+				needsUpdate = true
+
+				desiredSts.Spec.Replicas = statefulSet.Spec.Replicas
+				desiredSts.Spec.UpdateStrategy = statefulSet.Spec.UpdateStrategy
+				if canary, ok := statefulSet.Annotations[api.ConfigCanaryAnnotation]; ok {
+					desiredSts.Annotations[api.ConfigCanaryAnnotation] = canary
+				}
+				droppedAnnotations = []string{api.StatefulSetSpecHashAnnotation}
+			}
+		} else if !utils.ResourcesHaveSameHash(statefulSet, desiredSts) {
 			logger.
 				WithValues("rackName", rackName).
 				Info("statefulset needs an update")
 
+			// The API server would reject the update, see CheckRackForceUpgrade
+			if immutableStatefulSetFieldsChanged(statefulSet, desiredSts) {
+				rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeWarning, events.ImmutableStatefulSetChange,
+					"Rack %s changes fields of StatefulSet %s that cannot be updated, add it to forceUpgradeRacks to recreate the StatefulSet",
+					rackName, statefulSet.Name)
+				return result.Error(fmt.Errorf("cannot update the immutable fields of statefulset %s without forceUpgradeRacks", statefulSet.Name))
+			}
+
+			// The live settings are set on the nodes by CheckLiveSettings, and
+			// reach the pod template with the next change that restarts them.
+			// Racks with nodes that did not take them roll out the config.
+			if isLiveSettingsChange(statefulSet, desiredSts) && rc.liveSettingsApplied(rackName) {
+				logger.
+					WithValues("rackName", rackName).
+					Info("only live settings of the config changed, not restarting the rack")
+				continue
+			}
+
 			needsUpdate = true
+			configChanged = serverConfigChanged(&statefulSet.Spec.Template, &desiredSts.Spec.Template)
 
-			// "fix" the replica count, and maintain labels and annotations the k8s admin may have set
+			// "fix" the replica count
 			desiredSts.Spec.Replicas = statefulSet.Spec.Replicas
-			desiredSts.Labels = utils.MergeMap(map[string]string{}, statefulSet.Labels, desiredSts.Labels)
-			desiredSts.Annotations = utils.MergeMap(map[string]string{}, statefulSet.Annotations, desiredSts.Annotations)
+			// The spec hash is recorded again once the update is done
+			droppedAnnotations = []string{api.StatefulSetSpecHashAnnotation, api.ConfigCanaryAnnotation}
 
-			if dc.Spec.CanaryUpgrade {
-				var partition int32
-				if dc.Spec.CanaryUpgradeCount == 0 || dc.Spec.CanaryUpgradeCount > int32(rc.desiredRackInformation[idx].NodeCount) {
-					partition = int32(rc.desiredRackInformation[idx].NodeCount)
-				} else {
-					partition = int32(rc.desiredRackInformation[idx].NodeCount) - dc.Spec.CanaryUpgradeCount
-				}
+			// Without canaryUpgradeAllRacks, only the first rack gets canary pods. The
+			// other racks are only updated once the canary upgrade is approved.
+			if dc.Spec.CanaryUpgrade && (dc.Spec.CanaryUpgradeAllRacks || idx == 0) {
+				partition := canaryUpgradePartition(dc, rc.desiredRackInformation[idx].NodeCount)
 				strategy := appsv1.StatefulSetUpdateStrategy{
 					Type: appsv1.RollingUpdateStatefulSetStrategyType,
 					RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
@@ -244,18 +280,49 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 					},
 				}
 				desiredSts.Spec.UpdateStrategy = strategy
+			} else if podResourcesChanged(&statefulSet.Spec.Template, &desiredSts.Spec.Template) {
+				// The operator replaces the pods itself, see replaceOutdatedPods
+				logger.
+					WithValues("rackName", rackName).
+					Info("resources changed, pods will be drained before they are replaced")
+				desiredSts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+					Type: appsv1.OnDeleteStatefulSetStrategyType,
+				}
+			} else if dc.Spec.ConfigRolloutStrategy != nil && serverConfigChanged(&statefulSet.Spec.Template, &desiredSts.Spec.Template) {
+				// The rest of the rack is updated once the canary pods are healthy,
+				// see areConfigCanariesHealthy
+				if partition := configCanaryPartition(dc, rc.desiredRackInformation[idx].NodeCount); partition > 0 {
+					logger.
+						WithValues("rackName", rackName).
+						Info("config changed, rolling it out to the canary pods first", "partition", partition)
+					desiredSts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+						Type: appsv1.RollingUpdateStatefulSetStrategyType,
+						RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+							Partition: &partition,
+						},
+					}
+					desiredSts.Annotations[api.ConfigCanaryAnnotation] = "true"
+				}
 			}
-
-			desiredSts.DeepCopyInto(statefulSet)
 		}
 
 		if needsUpdate {
-			rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.UpdatingRack,
+			// The racks are updated one at a time, so the next ones wait too
+			if wait, err := rc.waitForMaintenanceWindow("update of rack " + rackName); err != nil {
+				return result.Error(err)
+			} else if wait {
+				return result.Continue()
+			}
+
+			rc.recordRackEventf(rackName, corev1.EventTypeNormal, events.UpdatingRack,
 				"Updating rack %s", rackName)
+			if configChanged {
+				rc.recordRackEventf(rackName, corev1.EventTypeNormal, events.ConfigRolloutStarted,
+					"Rolling out the changed config to rack %s", rackName)
+			}
 
 			dcPatch := client.MergeFrom(dc.DeepCopy())
-			updated := rc.setCondition(
-				api.NewDatacenterCondition(api.DatacenterUpdating, corev1.ConditionTrue))
+			updated := rc.setUpdatingCondition(configChanged)
 
 			if updated {
 				err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch)
@@ -270,10 +337,10 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 			}
 
 			logger.Info("Updating statefulset pod specs",
-				"statefulSet", statefulSet,
+				"statefulSet", desiredSts,
 			)
 
-			err = rc.Client.Update(rc.Ctx, statefulSet)
+			err = rc.updateResource(desiredSts, statefulSet, droppedAnnotations...)
 			if err != nil {
 				logger.Error(
 					err,
@@ -281,6 +348,7 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 					"statefulSet", statefulSet)
 				return result.Error(err)
 			}
+			desiredSts.DeepCopyInto(statefulSet)
 
 			if err := rc.enableQuietPeriod(20); err != nil {
 				logger.Error(
@@ -292,6 +360,53 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 			// we just updated k8s and pods will be knocked out of ready state, so let k8s
 			// call us back when these changes are done and the new pods are back to ready
 			return result.Done()
+		} else if statefulSet.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+			if recResult := rc.replaceOutdatedPods(statefulSet); recResult.Completed() {
+				return recResult
+			}
+		} else if partition := statefulSetPartition(statefulSet); partition > 0 {
+			if !dc.Spec.CanaryUpgrade && dc.Spec.ConfigRolloutStrategy != nil &&
+				statefulSet.Annotations[api.ConfigCanaryAnnotation] == "true" {
+				if !isCanaryUpgradeDone(statefulSet, partition) || !rc.areConfigCanariesHealthy(rackName, partition) {
+					logger.Info(
+						"waiting for the canary pods of the config to be healthy",
+						"statefulset", statefulSet.Name,
+						"partition", partition,
+					)
+					return result.RequeueSoon(10)
+				}
+
+				// The next rack only gets the config once this one is ready
+				// again, like any other update of the racks
+				return rc.releaseConfigCanaries(rackName, statefulSet)
+			}
+
+			if !dc.Spec.CanaryUpgrade {
+				// Canary upgrades were turned off, so there is nothing to wait for
+				// before upgrading the rest of the rack
+				logger.Info("Canary upgrade turned off, upgrading the rest of the rack",
+					"statefulset", statefulSet.Name)
+				if err := rc.releaseCanaryPartition(statefulSet); err != nil {
+					return result.Error(err)
+				}
+				return result.Done()
+			}
+
+			if !isCanaryUpgradeDone(statefulSet, partition) {
+				logger.Info(
+					"waiting for canary upgrade to finish on statefulset",
+					"statefulset", statefulSet.Name,
+					"partition", partition,
+					"readyReplicas", statefulSet.Status.ReadyReplicas,
+					"updatedReplicas", statefulSet.Status.UpdatedReplicas,
+				)
+				return result.RequeueSoon(10)
+			}
+
+			canaries = append(canaries, statefulSet)
+			if !dc.Spec.CanaryUpgradeAllRacks {
+				break
+			}
 		} else {
 
 			// the pod template is right, but if any pods don't match it,
@@ -318,10 +433,178 @@ func (rc *ReconciliationContext) CheckRackPodTemplate() result.ReconcileResult {
 		}
 	}
 
+	if len(canaries) > 0 {
+		return rc.checkCanaryUpgradeApproval(canaries)
+	}
+
+	if dc.Spec.CanaryUpgradeApproved {
+		// There is no canary upgrade waiting, so do not let the approval apply
+		// to the next one
+		dcPatch := client.MergeFrom(dc.DeepCopy())
+		dc.Spec.CanaryUpgradeApproved = false
+		if err := rc.Client.Patch(rc.Ctx, dc, dcPatch); err != nil {
+			logger.Error(err, "error patching datacenter to reset canaryUpgradeApproved")
+			return result.Error(err)
+		}
+	}
+
 	logger.Info("done CheckRackPodTemplate()")
 	return result.Continue()
 }
 
+// canaryUpgradePartition returns the partition of a rack of nodeCount pods
+// that leaves canaryUpgradeCount pods to upgrade, a single one when it is not
+// set, or 0 when the rack has no more pods than that
+func canaryUpgradePartition(dc *api.CassandraDatacenter, nodeCount int) int32 {
+	count := dc.Spec.CanaryUpgradeCount
+	if count == 0 {
+		count = 1
+	}
+	partition := int32(nodeCount) - count
+	if partition < 0 {
+		return 0
+	}
+	return partition
+}
+
+// checkCanaryUpgradeApproval pauses the upgrade until the user approves it by
+// setting canaryUpgradeApproved, and then upgrades the rest of the pods of the
+// given statefulsets.
+func (rc *ReconciliationContext) checkCanaryUpgradeApproval(canaries []*appsv1.StatefulSet) result.ReconcileResult {
+	logger := rc.ReqLogger
+	dc := rc.Datacenter
+
+	if !dc.Spec.CanaryUpgradeApproved {
+		dcPatch := client.MergeFrom(dc.DeepCopy())
+		paused := rc.conditionTransitions(api.DatacenterCanaryUpgradePaused, corev1.ConditionTrue)
+		updated := rc.setCondition(
+			api.NewDatacenterConditionWithReason(api.DatacenterCanaryUpgradePaused, corev1.ConditionTrue,
+				"AwaitingApproval", "Set canaryUpgradeApproved to true to upgrade the remaining pods"))
+
+		if updated {
+			err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch)
+			if err != nil {
+				logger.Error(err, "error patching datacenter status for canary upgrade paused")
+				return result.Error(err)
+			}
+		}
+
+		if paused {
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CanaryUpgradePaused,
+				"Canary pods upgraded on %d racks, waiting for approval", len(canaries))
+		}
+
+		logger.Info("Canary upgrade is waiting for approval")
+		return result.Done()
+	}
+
+	if wait, err := rc.waitForMaintenanceWindow("approved canary upgrade"); err != nil {
+		return result.Error(err)
+	} else if wait {
+		return result.Continue()
+	}
+
+	for _, statefulSet := range canaries {
+		if err := rc.releaseCanaryPartition(statefulSet); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CanaryUpgradeApproved,
+		"Canary upgrade approved, upgrading the remaining pods")
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	if rc.setCondition(api.NewDatacenterCondition(api.DatacenterCanaryUpgradePaused, corev1.ConditionFalse)) {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			logger.Error(err, "error patching datacenter status for canary upgrade approved")
+			return result.Error(err)
+		}
+	}
+
+	dcPatch = client.MergeFrom(dc.DeepCopy())
+	dc.Spec.CanaryUpgradeApproved = false
+	if err := rc.Client.Patch(rc.Ctx, dc, dcPatch); err != nil {
+		logger.Error(err, "error patching datacenter to reset canaryUpgradeApproved")
+		return result.Error(err)
+	}
+
+	return result.Done()
+}
+
+// immutableStatefulSetFieldsChanged tells whether the desired statefulset
+// changes fields of the current one that the API server does not allow to
+// update. The fields the API server set the defaults of in the volume claim
+// templates of the current statefulset are not compared.
+func immutableStatefulSetFieldsChanged(current *appsv1.StatefulSet, desired *appsv1.StatefulSet) bool {
+	return current.Spec.ServiceName != desired.Spec.ServiceName ||
+		current.Spec.PodManagementPolicy != desired.Spec.PodManagementPolicy ||
+		!equality.Semantic.DeepEqual(current.Spec.Selector, desired.Spec.Selector) ||
+		len(current.Spec.VolumeClaimTemplates) != len(desired.Spec.VolumeClaimTemplates) ||
+		!equality.Semantic.DeepDerivative(desired.Spec.VolumeClaimTemplates, current.Spec.VolumeClaimTemplates)
+}
+
+// checkStatefulSetDrift tells whether the spec of a statefulset changed since
+// the operator last updated it. The hash of the spec is recorded the first
+// time the statefulset is checked after an update, once the API server has set
+// the defaults of its fields. The replicas and the update strategy are left
+// out, since the operator changes them without a full update.
+func (rc *ReconciliationContext) checkStatefulSetDrift(statefulSet *appsv1.StatefulSet) (bool, error) {
+	spec := statefulSet.Spec.DeepCopy()
+	spec.Replicas = nil
+	spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{}
+	specHash := utils.DeepHash(spec)
+
+	if recordedHash, ok := statefulSet.Annotations[api.StatefulSetSpecHashAnnotation]; ok {
+		return recordedHash != specHash, nil
+	}
+
+	patch := client.MergeFrom(statefulSet.DeepCopy())
+	metav1.SetMetaDataAnnotation(&statefulSet.ObjectMeta, api.StatefulSetSpecHashAnnotation, specHash)
+	if err := rc.Client.Patch(rc.Ctx, statefulSet, patch); err != nil {
+		rc.ReqLogger.Error(err, "error recording the spec hash of statefulset",
+			"statefulset", statefulSet.Name)
+		return false, err
+	}
+	return false, nil
+}
+
+// releaseCanaryPartition removes the partition of a canary upgrade from the
+// statefulset, so the rest of its pods get upgraded
+func (rc *ReconciliationContext) releaseCanaryPartition(statefulSet *appsv1.StatefulSet) error {
+	patch := client.MergeFrom(statefulSet.DeepCopy())
+	statefulSet.Spec.UpdateStrategy.RollingUpdate = nil
+	delete(statefulSet.Annotations, api.ConfigCanaryAnnotation)
+	if err := rc.Client.Patch(rc.Ctx, statefulSet, patch); err != nil {
+		rc.ReqLogger.Error(err, "error removing canary partition from statefulset",
+			"statefulset", statefulSet.Name)
+		return err
+	}
+	return nil
+}
+
+func statefulSetPartition(statefulSet *appsv1.StatefulSet) int32 {
+	rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate
+	if rollingUpdate == nil || rollingUpdate.Partition == nil {
+		return 0
+	}
+	return *rollingUpdate.Partition
+}
+
+// isCanaryUpgradeDone reports whether the pods above the partition of the
+// statefulset have been upgraded and every pod is ready
+func isCanaryUpgradeDone(statefulSet *appsv1.StatefulSet, partition int32) bool {
+	status := statefulSet.Status
+
+	var canaries int32
+	if statefulSet.Spec.Replicas != nil && *statefulSet.Spec.Replicas > partition {
+		canaries = *statefulSet.Spec.Replicas - partition
+	}
+
+	return statefulSet.Generation == status.ObservedGeneration &&
+		status.UpdatedReplicas >= canaries &&
+		status.ReadyReplicas == status.Replicas
+}
+
 func (rc *ReconciliationContext) CheckRackForceUpgrade() result.ReconcileResult {
 	// This code is *very* similar to CheckRackPodTemplate(), but it's not an exact
 	// copy. Some 3 to 5 line parts could maybe be extracted into functions.
@@ -359,12 +642,29 @@ func (rc *ReconciliationContext) CheckRackForceUpgrade() result.ReconcileResult
 				return result.Error(err)
 			}
 
-			// "fix" the replica count, and maintain labels and annotations the k8s admin may have set
-			desiredSts.Spec.Replicas = statefulSet.Spec.Replicas
-			desiredSts.Labels = utils.MergeMap(map[string]string{}, statefulSet.Labels, desiredSts.Labels)
-			desiredSts.Annotations = utils.MergeMap(map[string]string{}, statefulSet.Annotations, desiredSts.Annotations)
+			// The statefulset is deleted without its pods, and CheckRackCreation
+			// recreates it with the new fields and adopts the pods. The rack stays
+			// in forceUpgradeRacks until the recreated statefulset is updated.
+			if immutableStatefulSetFieldsChanged(statefulSet, desiredSts) {
+				if statefulSet.GetDeletionTimestamp() != nil {
+					return result.RequeueSoon(2)
+				}
 
-			desiredSts.DeepCopyInto(statefulSet)
+				rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.RecreatingStatefulSet,
+					"Recreating StatefulSet %s of rack %s to change its immutable fields", statefulSet.Name, rackName)
+
+				logger.Info("Deleting the statefulset without its pods to recreate it",
+					"statefulSet", statefulSet.Name)
+				if err := rc.Client.Delete(rc.Ctx, statefulSet, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil {
+					logger.Error(err, "Failed to delete the statefulset", "statefulSet", statefulSet.Name)
+					return result.Error(err)
+				}
+
+				return result.RequeueSoon(2)
+			}
+
+			// "fix" the replica count
+			desiredSts.Spec.Replicas = statefulSet.Spec.Replicas
 
 			rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.UpdatingRack,
 				"Force updating rack %s", rackName)
@@ -382,16 +682,17 @@ func (rc *ReconciliationContext) CheckRackForceUpgrade() result.ReconcileResult
 			}
 
 			logger.Info("Force updating statefulset pod specs",
-				"statefulSet", statefulSet,
+				"statefulSet", desiredSts,
 			)
 
-			if err := rc.Client.Update(rc.Ctx, statefulSet); err != nil {
+			if err := rc.updateResource(desiredSts, statefulSet, api.StatefulSetSpecHashAnnotation); err != nil {
 				logger.Error(
 					err,
 					"Unable to perform update on statefulset for force update config",
 					"statefulSet", statefulSet)
 				return result.Error(err)
 			}
+			desiredSts.DeepCopyInto(statefulSet)
 
 		}
 	}
@@ -448,7 +749,16 @@ func (rc *ReconciliationContext) CheckRackStoppedState() result.ReconcileResult
 	logger := rc.ReqLogger
 	dc := rc.Datacenter
 
+	// The budget of the running datacenter would never allow a disruption of
+	// the pods left
+	if dc.Spec.Stopped || dc.IsHibernated() {
+		if recResult := rc.CheckDcPodDisruptionBudget(); recResult.Completed() {
+			return recResult
+		}
+	}
+
 	emittedStoppingEvent := false
+	emittedHibernatingEvent := false
 	racksUpdated := false
 	for idx := range rc.desiredRackInformation {
 		rackInfo := rc.desiredRackInformation[idx]
@@ -485,33 +795,41 @@ func (rc *ReconciliationContext) CheckRackStoppedState() result.ReconcileResult
 				emittedStoppingEvent = true
 			}
 
-			rackPods := FilterPodListByLabels(rc.dcPods, rc.Datacenter.GetRackLabels(rackInfo.RackName))
+			if !dc.SkipsDrainOnStop() {
+				rc.drainRackPods(statefulSet, rackInfo.RackName, 0)
+			}
 
-			nodesDrained := 0
-			nodeDrainErrors := 0
+			err := rc.UpdateRackNodeCount(statefulSet, 0)
+			if err != nil {
+				return result.Error(err)
+			}
+			racksUpdated = true
+		} else if dc.IsHibernated() && currentPodCount > int32(rackInfo.NodeCount) {
+			logger.Info(
+				"CassandraDatacenter is hibernated, scaling rack down",
+				"rack", rackInfo.RackName,
+				"currentSize", currentPodCount,
+				"desiredSize", rackInfo.NodeCount,
+			)
 
-			for _, pod := range rackPods {
-				if isMgmtApiRunning(pod) {
-					nodesDrained++
-					err := rc.NodeMgmtClient.CallDrainEndpoint(pod)
-					// if we got an error during drain, just log it and count it
-					// and then keep going, because we don't want to try restarting
-					// the server just to bring it down
+			if !emittedHibernatingEvent {
+				dcPatch := client.MergeFrom(dc.DeepCopy())
+				if rc.setCondition(api.NewDatacenterCondition(api.DatacenterHibernated, corev1.ConditionTrue)) {
+					err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch)
 					if err != nil {
-						logger.Error(err, "error during node drain",
-							"pod", pod.Name)
-						nodeDrainErrors++
+						logger.Error(err, "error patching datacenter status for hibernating")
+						return result.Error(err)
 					}
 				}
+
+				rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.HibernatingDatacenter,
+					"Hibernating datacenter")
+				emittedHibernatingEvent = true
 			}
 
-			logger.Info("rack drains done",
-				"rack", rackInfo.RackName,
-				"nodesDrained", nodesDrained,
-				"nodeDrainErrors", nodeDrainErrors,
-			)
+			rc.drainRackPods(statefulSet, rackInfo.RackName, int32(rackInfo.NodeCount))
 
-			err := rc.UpdateRackNodeCount(statefulSet, 0)
+			err := rc.UpdateRackNodeCount(statefulSet, int32(rackInfo.NodeCount))
 			if err != nil {
 				return result.Error(err)
 			}
@@ -525,6 +843,41 @@ func (rc *ReconciliationContext) CheckRackStoppedState() result.ReconcileResult
 	return result.Continue()
 }
 
+// drainRackPods drains the nodes of the pods of the rack whose ordinal is at
+// least firstOrdinal, before the StatefulSet is scaled down to firstOrdinal
+func (rc *ReconciliationContext) drainRackPods(statefulSet *appsv1.StatefulSet, rackName string, firstOrdinal int32) {
+	logger := rc.ReqLogger
+	rackPods := FilterPodListByLabels(rc.dcPods, rc.Datacenter.GetRackLabels(rackName))
+
+	nodesDrained := 0
+	nodeDrainErrors := 0
+
+	for idx := firstOrdinal; idx < *statefulSet.Spec.Replicas; idx++ {
+		podName := getStatefulSetPodNameForIdx(statefulSet, idx)
+		for _, pod := range rackPods {
+			if pod.Name != podName || !isMgmtApiRunning(pod) {
+				continue
+			}
+			nodesDrained++
+			err := rc.NodeMgmtClient.CallDrainEndpoint(pod)
+			// if we got an error during drain, just log it and count it
+			// and then keep going, because we don't want to try restarting
+			// the server just to bring it down
+			if err != nil {
+				logger.Error(err, "error during node drain",
+					"pod", pod.Name)
+				nodeDrainErrors++
+			}
+		}
+	}
+
+	logger.Info("rack drains done",
+		"rack", rackName,
+		"nodesDrained", nodesDrained,
+		"nodeDrainErrors", nodeDrainErrors,
+	)
+}
+
 // checkSeedLabels loops over all racks and makes sure that the proper pods are labelled as seeds.
 func (rc *ReconciliationContext) checkSeedLabels() (int, error) {
 	rc.ReqLogger.Info("reconcile_racks::CheckSeedLabels")
@@ -586,6 +939,22 @@ func (rc *ReconciliationContext) CheckPodsReady(endpointData httphelper.CassMeta
 		return result.Error(err)
 	}
 
+	// Resuming nodes that already joined the cluster can start in parallel, once
+	// a node of the datacenter is ready to be their seed
+
+	if rc.Datacenter.Spec.ResumeParallelism > 1 &&
+		rc.Datacenter.GetConditionStatus(api.DatacenterResuming) == corev1.ConditionTrue &&
+		countReadyServers(rc.dcPods) > 0 {
+
+		nodesStarting, err := rc.startResumingNodes(endpointData)
+		if err != nil {
+			return result.Error(err)
+		}
+		if nodesStarting {
+			return result.RequeueSoon(2)
+		}
+	}
+
 	// step 1 - see if any nodes are already coming up
 
 	nodeIsStarting, _, err := rc.findStartingNodes()
@@ -610,7 +979,9 @@ func (rc *ReconciliationContext) CheckPodsReady(endpointData httphelper.CassMeta
 
 	// step 3 - get all nodes up
 	// if the cluster isn't healthy, that's ok, but go back to step 1
-	if !rc.isClusterHealthy() {
+	// A hibernated datacenter cannot be healthy, since most of its nodes are
+	// down on purpose, but it only runs the nodes started by step 2
+	if !rc.Datacenter.IsHibernated() && !rc.isClusterHealthy() {
 		rc.ReqLogger.Info(
 			"cluster isn't healthy",
 		)
@@ -634,7 +1005,10 @@ func (rc *ReconciliationContext) CheckPodsReady(endpointData httphelper.CassMeta
 	// step 5 sanity check that all pods are labelled as started and are ready
 
 	readyPodCount, startedLabelCount := rc.countReadyAndStarted()
-	desiredSize := int(rc.Datacenter.Spec.Size)
+	desiredSize := 0
+	for _, rackInfo := range rc.desiredRackInformation {
+		desiredSize += rackInfo.NodeCount
+	}
 
 	if desiredSize <= readyPodCount && desiredSize <= startedLabelCount {
 		return result.Continue()
@@ -770,12 +1144,17 @@ func (rc *ReconciliationContext) CheckRackScale() result.ReconcileResult {
 			dcPatch := client.MergeFrom(dc.DeepCopy())
 			updated := false
 
-			// Check to see if we are resuming from stopped and update conditions appropriately
-			if dc.GetConditionStatus(api.DatacenterStopped) == corev1.ConditionTrue {
+			// Check to see if we are resuming from stopped or hibernated and update conditions appropriately
+			if dc.GetConditionStatus(api.DatacenterStopped) == corev1.ConditionTrue ||
+				dc.GetConditionStatus(api.DatacenterHibernated) == corev1.ConditionTrue {
 				updated = rc.setCondition(
 					api.NewDatacenterCondition(
 						api.DatacenterStopped, corev1.ConditionFalse)) || updated
 
+				updated = rc.setCondition(
+					api.NewDatacenterCondition(
+						api.DatacenterHibernated, corev1.ConditionFalse)) || updated
+
 				updated = rc.setCondition(
 					api.NewDatacenterCondition(
 						api.DatacenterResuming, corev1.ConditionTrue)) || updated
@@ -803,7 +1182,7 @@ func (rc *ReconciliationContext) CheckRackScale() result.ReconcileResult {
 				"desiredSize", desiredNodeCount,
 			)
 
-			rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.ScalingUpRack,
+			rc.recordRackEventf(rackInfo.RackName, corev1.EventTypeNormal, events.ScalingUpRack,
 				"Scaling up rack %s", rackInfo.RackName)
 
 			err := rc.UpdateRackNodeCount(statefulSet, desiredNodeCount)
@@ -837,7 +1216,9 @@ func shouldUpsertUsers(dc api.CassandraDatacenter) bool {
 	return time.Now().After(lastCreated.Add(time.Minute * 4))
 }
 
-func (rc *ReconciliationContext) upsertUser(user api.CassandraUser) error {
+// upsertUser creates the role of the user, or updates its password, and
+// returns its username
+func (rc *ReconciliationContext) upsertUser(user api.CassandraUser) (string, error) {
 	dc := rc.Datacenter
 	namespace := dc.ObjectMeta.Namespace
 
@@ -846,21 +1227,101 @@ func (rc *ReconciliationContext) upsertUser(user api.CassandraUser) error {
 		Namespace: namespace,
 	}
 
-	secret, err := rc.retrieveSecret(namespacedName)
+	var secret *corev1.Secret
+	var err error
+	if namespacedName == dc.GetSuperuserSecretNamespacedName() {
+		// It might come from Vault
+		secret, err = rc.retrieveSuperuserSecret()
+	} else {
+		secret, err = rc.retrieveSecret(namespacedName)
+	}
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// We will call mgmt API on the first pod
 	pod := rc.dcPods[0]
 
+	username := string(secret.Data["username"])
 	err = rc.NodeMgmtClient.CallCreateRoleEndpoint(
 		pod,
-		string(secret.Data["username"]),
+		username,
 		string(secret.Data["password"]),
 		user.Superuser)
 
-	return err
+	return username, err
+}
+
+// dropRemovedUsers drops the roles of the status that are no longer created
+// from the users of the spec
+func (rc *ReconciliationContext) dropRemovedUsers(upserted map[string]bool) error {
+	dc := rc.Datacenter
+	pod := rc.dcPods[0]
+
+	for _, user := range dc.Status.Users {
+		if upserted[user.Username] {
+			continue
+		}
+
+		rc.ReqLogger.Info("dropping the role of a removed user", "username", user.Username)
+		err := rc.NodeMgmtClient.CallDropRoleEndpoint(pod, user.Username)
+		if mgmtapi.IsNotSupported(err) {
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.DroppedUser,
+				"The management API cannot drop role %s, it must be dropped manually", user.Username)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.DroppedUser,
+			"Dropped role %s", user.Username)
+	}
+	return nil
+}
+
+// superuserSecretVersion identifies the revision of the superuser secret by
+// its UID and resource version, to detect its changes without keeping
+// anything derived from the credentials in the status. Other changes of the
+// secret, such as to its labels, only set the same password again.
+func superuserSecretVersion(secret *corev1.Secret) string {
+	return fmt.Sprintf("%s/%s", secret.UID, secret.ResourceVersion)
+}
+
+// rotateSuperuserPassword changes the password of the superuser role when
+// the superuser secret changed since it was last applied, with the Online
+// rotation policy. It returns the version of the secret to record in the
+// status, and whether the password was changed.
+func (rc *ReconciliationContext) rotateSuperuserPassword() (string, bool, error) {
+	dc := rc.Datacenter
+	if dc.Spec.SuperuserSecretRotationPolicy != api.SuperuserSecretRotationOnline {
+		return "", false, nil
+	}
+
+	secret, err := rc.retrieveSuperuserSecret()
+	if err != nil {
+		return "", false, err
+	}
+
+	version := superuserSecretVersion(secret)
+	if dc.Status.SuperuserSecretVersion == "" || dc.Status.SuperuserSecretVersion == version {
+		// The superuser was just created from this secret
+		return version, false, nil
+	}
+
+	username := string(secret.Data["username"])
+	rc.ReqLogger.Info("rotating the password of the superuser", "username", username)
+	err = rc.NodeMgmtClient.CallChangeRolePasswordEndpoint(rc.dcPods[0], username, string(secret.Data["password"]))
+	if mgmtapi.IsNotSupported(err) {
+		// The older management APIs change the password of existing roles
+		// when they are created again, which upsertUser just did
+		rc.ReqLogger.Info("management API cannot change passwords, relying on the upsert of the superuser")
+		err = nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return version, true, nil
 }
 
 func (rc *ReconciliationContext) GetUsers() []api.CassandraUser {
@@ -883,6 +1344,24 @@ func (rc *ReconciliationContext) UpdateSecretWatches() error {
 		name := types.NamespacedName{Name: user.SecretName, Namespace: dc.Namespace}
 		names = append(names, name)
 	}
+	// The renewals of the certificate of the client encryption are
+	// converted to keystores
+	if dc.GetClientEncryption() != nil {
+		names = append(names, types.NamespacedName{Name: dc.GetClientCertificateName(), Namespace: dc.Namespace})
+	}
+	// The nodes are restarted or reload the keystores of the internode
+	// encryption when they change
+	if config := dc.GetInternodeEncryption(); config != nil && config.SecretName != "" {
+		names = append(names, types.NamespacedName{Name: config.SecretName, Namespace: dc.Namespace})
+	}
+	// The nodes are restarted when the credentials of remote JMX change
+	if dc.IsRemoteJmxEnabled() {
+		names = append(names, types.NamespacedName{Name: dc.Spec.Jmx.CredentialsSecret, Namespace: dc.Namespace})
+	}
+	// The configuration of the backup agent is rendered again when it changes
+	if config := dc.Spec.BackupAgent; config != nil {
+		names = append(names, types.NamespacedName{Name: config.ConfigSecret, Namespace: dc.Namespace})
+	}
 	dcNamespacedName := types.NamespacedName{Name: dc.Name, Namespace: dc.Namespace}
 	err := rc.SecretWatches.UpdateWatch(dcNamespacedName, names)
 
@@ -909,17 +1388,47 @@ func (rc *ReconciliationContext) CreateUsers() result.ReconcileResult {
 
 	users := rc.GetUsers()
 
-	for _, user := range users {
-		err := rc.upsertUser(user)
+	// The roles of the users of the spec are tracked in the status, unlike
+	// the superuser of the datacenter, which is never dropped
+	var userStatuses []api.CassandraUserStatus
+	upserted := map[string]bool{}
+	for i, user := range users {
+		username, err := rc.upsertUser(user)
 		if err != nil {
 			rc.ReqLogger.Error(err, "error updating user", "secretName", user.SecretName)
 			return result.Error(err)
 		}
+		upserted[username] = true
+
+		if i < len(dc.Spec.Users) {
+			userStatuses = append(userStatuses, api.CassandraUserStatus{
+				Username:   username,
+				SecretName: user.SecretName,
+				Superuser:  user.Superuser,
+				Upserted:   metav1.Now(),
+			})
+		}
+	}
+
+	if err := rc.dropRemovedUsers(upserted); err != nil {
+		rc.ReqLogger.Error(err, "error dropping the roles of removed users")
+		return result.Error(err)
+	}
+
+	secretVersion, rotated, err := rc.rotateSuperuserPassword()
+	if err != nil {
+		rc.ReqLogger.Error(err, "error rotating the password of the superuser")
+		return result.Error(err)
 	}
 
 	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedUsers,
 		"Created users")
 
+	if rotated {
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.RotatedSuperuserPassword,
+			"Changed the password of the superuser")
+	}
+
 	// For backwards compatibility
 	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedSuperuser,
 		"Created superuser")
@@ -929,6 +1438,11 @@ func (rc *ReconciliationContext) CreateUsers() result.ReconcileResult {
 
 	// For backwards compatibility
 	rc.Datacenter.Status.SuperUserUpserted = metav1.Now()
+	rc.Datacenter.Status.Users = userStatuses
+	rc.Datacenter.Status.SuperuserSecretVersion = secretVersion
+	if rotated {
+		rc.Datacenter.Status.SuperuserPasswordRotated = metav1.Now()
+	}
 
 	if err = rc.Client.Status().Patch(rc.Ctx, rc.Datacenter, patch); err != nil {
 		rc.ReqLogger.Error(err, "error updating the users upsert timestamp")
@@ -938,17 +1452,25 @@ func (rc *ReconciliationContext) CreateUsers() result.ReconcileResult {
 	return result.Continue()
 }
 
-func findHostIdForIpFromEndpointsData(endpointsData []httphelper.EndpointState, ip string) string {
-	for _, data := range endpointsData {
-		if data.GetRpcAddress() == ip {
-			return data.HostID
-		}
+// getBroadcastAddress returns the IP the node of the pod broadcasts to the
+// other nodes. The DNS name of a pod resolves to its IP.
+func getBroadcastAddress(dc *api.CassandraDatacenter, pod *corev1.Pod) string {
+	if dc.GetAddressType() == api.AddressTypeHostIP {
+		return pod.Status.HostIP
 	}
-	return ""
+	return pod.Status.PodIP
 }
 
 func getRpcAddress(dc *api.CassandraDatacenter, pod *corev1.Pod) string {
+	if dc.IsLoadBalancerPerPodEnabled() {
+		if address := pod.Annotations[api.BroadcastRpcAddressAnnotation]; address != "" {
+			return address
+		}
+	}
 	nc := dc.Spec.Networking
+	if nc != nil && nc.AddressType != "" {
+		return getBroadcastAddress(dc, pod)
+	}
 	if nc != nil {
 		if nc.HostNetwork {
 			return pod.Status.HostIP
@@ -963,6 +1485,32 @@ func getRpcAddress(dc *api.CassandraDatacenter, pod *corev1.Pod) string {
 	return pod.Status.PodIP
 }
 
+func findEndpointStateForIp(endpointsData []httphelper.EndpointState, ip string) (httphelper.EndpointState, bool) {
+	for _, data := range endpointsData {
+		if data.GetRpcAddress() == ip {
+			return data, true
+		}
+	}
+	return httphelper.EndpointState{}, false
+}
+
+// getEndpointsData returns the gossip state of the nodes of the cluster, as
+// seen by the first node that answers
+func (rc *ReconciliationContext) getEndpointsData() []httphelper.EndpointState {
+	for _, pod := range rc.dcPods {
+		if pod.Status.PodIP == "" || !isMgmtApiRunning(pod) {
+			continue
+		}
+		endpointsResponse, err := rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(pod)
+		if err != nil {
+			rc.ReqLogger.Error(err, "Could not get endpoints data", "pod", pod.Name)
+			continue
+		}
+		return endpointsResponse.Entity
+	}
+	return nil
+}
+
 func (rc *ReconciliationContext) UpdateCassandraNodeStatus() error {
 	logger := rc.ReqLogger
 	dc := rc.Datacenter
@@ -971,29 +1519,38 @@ func (rc *ReconciliationContext) UpdateCassandraNodeStatus() error {
 		dc.Status.NodeStatuses = map[string]api.CassandraNodeStatus{}
 	}
 
+	// A single call returns the state of every node, so the statuses are
+	// refreshed on each reconcile
+	endpointsData := rc.getEndpointsData()
+
 	for _, pod := range rc.dcPods {
 		nodeStatus, ok := dc.Status.NodeStatuses[pod.Name]
 		if !ok {
 			nodeStatus = api.CassandraNodeStatus{}
 		}
+		nodeStatus.SniHostname = dc.GetSniHostname(pod.Name)
 
-		if pod.Status.PodIP != "" && isMgmtApiRunning(pod) {
-			// Getting the HostID requires a call to the node management API which is
-			// moderately expensive, so if we already have a HostID, don't bother. This
-			// would only change if something has gone horribly horribly wrong.
-			if nodeStatus.HostID == "" {
-				endpointsResponse, err := rc.NodeMgmtClient.CallMetadataEndpointsEndpoint(pod)
-				if err == nil {
-					ip := getRpcAddress(dc, pod)
-					nodeStatus.HostID = findHostIdForIpFromEndpointsData(
-						endpointsResponse.Entity, ip)
-					if nodeStatus.HostID == "" {
-						logger.Info("Failed to find host ID", "pod", pod.Name)
-					}
+		if pod.Status.PodIP != "" && endpointsData != nil {
+			ip := getRpcAddress(dc, pod)
+			endpointState, found := findEndpointStateForIp(endpointsData, ip)
+			if found {
+				// The host ID of a node only changes when it is replaced, and
+				// the replacement has a new pod, so the first one is kept.
+				if nodeStatus.HostID == "" {
+					nodeStatus.HostID = endpointState.HostID
+				}
+				nodeStatus.Rack = endpointState.Rack
+				nodeStatus.OperationMode = endpointState.GetOperationMode()
+				nodeStatus.SchemaVersion = endpointState.Schema
+				if load, err := endpointState.GetLoad(); err == nil {
+					nodeStatus.Load = load
 				} else {
-					rc.ReqLogger.Error(err, "Could not get endpoints data")
+					logger.Info("Failed to parse the load of the node", "pod", pod.Name, "load", endpointState.Load)
 				}
 			}
+			if nodeStatus.HostID == "" {
+				logger.Info("Failed to find host ID", "pod", pod.Name)
+			}
 		}
 
 		dc.Status.NodeStatuses[pod.Name] = nodeStatus
@@ -1044,7 +1601,7 @@ func (rc *ReconciliationContext) updateCurrentReplacePodsProgress() error {
 					if replacingForOver30min || timeStartedReplacing.Before(&timeCreated) || timeStartedReplacing.Equal(&timeCreated) {
 						logger.Info("Finished replacing pod", "pod", pod.Name)
 
-						rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.FinishedReplaceNode,
+						rc.recordPodEventf(pod, corev1.EventTypeNormal, events.FinishedReplaceNode,
 							"Finished replacing pod %s", pod.Name)
 
 						dc.Status.NodeReplacements = utils.RemoveValueFromStringArray(dc.Status.NodeReplacements, pod.Name)
@@ -1114,6 +1671,10 @@ func (rc *ReconciliationContext) UpdateStatus() result.ReconcileResult {
 		return result.Error(err)
 	}
 
+	dc.Status.ReadyNodes = countReadyServers(rc.dcPods)
+	rc.updateDerivedConditions()
+	dc.Status.Phase = dc.Status.ComputePhase()
+
 	status = &api.CassandraDatacenterStatus{}
 	dc.Status.DeepCopyInto(status)
 	oldDc.Status.DeepCopyInto(&dc.Status)
@@ -1274,15 +1835,21 @@ func (rc *ReconciliationContext) deleteStuckNodes() (bool, error) {
 func (rc *ReconciliationContext) isClusterHealthy() bool {
 	pods := FilterPodListByCassNodeState(rc.clusterPods, stateStarted)
 
+	// Every started node of the cluster is probed, a few at a time, until one
+	// of them reports the cluster unhealthy
 	numRacks := len(rc.Datacenter.GetRacks())
-	for _, pod := range pods {
+	var unhealthy int32
+	rc.NodeMgmtClient.ForEachPod(pods, func(pod *corev1.Pod) {
+		if atomic.LoadInt32(&unhealthy) != 0 {
+			return
+		}
 		err := rc.NodeMgmtClient.CallProbeClusterEndpoint(pod, "LOCAL_QUORUM", numRacks)
 		if err != nil {
-			return false
+			atomic.StoreInt32(&unhealthy, 1)
 		}
-	}
+	})
 
-	return true
+	return atomic.LoadInt32(&unhealthy) == 0
 }
 
 // labelSeedPods iterates over all pods for a statefulset and makes sure the right number of
@@ -1377,6 +1944,13 @@ func (rc *ReconciliationContext) GetStatefulSetForRack(
 		return nil, false, err
 	}
 
+	// The statefulset of a rack whose pods are still there was deleted without
+	// them, for example to expand its volumes. It is recreated with enough
+	// replicas to adopt the pods, rather than scaling them down.
+	if podCount := rc.countRackPods(nextRack.RackName); podCount > 0 {
+		desiredStatefulSet.Spec.Replicas = &podCount
+	}
+
 	// Set the CassandraDatacenter as the owner and controller
 	err = setControllerReference(
 		rc.Datacenter,
@@ -1389,6 +1963,17 @@ func (rc *ReconciliationContext) GetStatefulSetForRack(
 	return desiredStatefulSet, false, nil
 }
 
+// countRackPods returns the number of pods of the rack that are not being deleted
+func (rc *ReconciliationContext) countRackPods(rackName string) int32 {
+	var count int32
+	for _, pod := range rc.dcPods {
+		if pod.Labels[api.RackLabel] == rackName && pod.GetDeletionTimestamp() == nil {
+			count++
+		}
+	}
+	return count
+}
+
 // ReconcileNextRack ensures that the resources for a rack have been properly created
 func (rc *ReconciliationContext) ReconcileNextRack(statefulSet *appsv1.StatefulSet) error {
 
@@ -1404,7 +1989,7 @@ func (rc *ReconciliationContext) ReconcileNextRack(statefulSet *appsv1.StatefulS
 		"Creating a new StatefulSet.",
 		"statefulSetNamespace", statefulSet.Namespace,
 		"statefulSetName", statefulSet.Name)
-	if err := rc.Client.Create(rc.Ctx, statefulSet); err != nil {
+	if err := rc.createResource(statefulSet); err != nil {
 		return err
 	}
 
@@ -1415,14 +2000,54 @@ func (rc *ReconciliationContext) ReconcileNextRack(statefulSet *appsv1.StatefulS
 }
 
 func (rc *ReconciliationContext) CheckDcPodDisruptionBudget() result.ReconcileResult {
-	// Create a PodDisruptionBudget for the CassandraDatacenter
+	// Create the PodDisruptionBudgets of the CassandraDatacenter
+	dc := rc.Datacenter
+	desiredBudgets := newPodDisruptionBudgetsForDatacenter(dc)
+
+	desiredNames := map[string]bool{}
+	for _, desiredBudget := range desiredBudgets {
+		desiredNames[desiredBudget.Name] = true
+		if err := rc.checkPodDisruptionBudget(desiredBudget); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	// Remove the budgets left over from switching between a budget for the
+	// datacenter and budgets per rack, or from removed racks
+	selector := dc.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(selector)
+	budgetList := &policyv1beta1.PodDisruptionBudgetList{}
+	listOptions := []client.ListOption{
+		client.InNamespace(dc.Namespace),
+		client.MatchingLabels(selector),
+	}
+	if err := rc.Client.List(rc.Ctx, budgetList, listOptions...); err != nil {
+		return result.Error(err)
+	}
+	for idx := range budgetList.Items {
+		budget := &budgetList.Items[idx]
+		if desiredNames[budget.Name] {
+			continue
+		}
+		rc.ReqLogger.Info(
+			"Deleting a PodDisruptionBudget that is no longer needed",
+			"pdbNamespace", budget.Namespace,
+			"pdbName", budget.Name)
+		if err := rc.Client.Delete(rc.Ctx, budget); err != nil && !errors.IsNotFound(err) {
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
+
+func (rc *ReconciliationContext) checkPodDisruptionBudget(desiredBudget *policyv1beta1.PodDisruptionBudget) error {
 	dc := rc.Datacenter
 	ctx := rc.Ctx
-	desiredBudget := newPodDisruptionBudgetForDatacenter(dc)
 
 	// Set CassandraDatacenter as the owner and controller
 	if err := setControllerReference(dc, desiredBudget, rc.Scheme); err != nil {
-		return result.Error(err)
+		return err
 	}
 
 	// Check if the budget already exists
@@ -1435,13 +2060,13 @@ func (rc *ReconciliationContext) CheckDcPodDisruptionBudget() result.ReconcileRe
 		currentBudget)
 
 	if err != nil && !errors.IsNotFound(err) {
-		return result.Error(err)
+		return err
 	}
 
 	found := err == nil
 
 	if found && utils.ResourcesHaveSameHash(currentBudget, desiredBudget) {
-		return result.Continue()
+		return nil
 	}
 
 	// it's not possible to update a PodDisruptionBudget, so we need to delete this one and remake it
@@ -1455,7 +2080,7 @@ func (rc *ReconciliationContext) CheckDcPodDisruptionBudget() result.ReconcileRe
 		)
 		err = rc.Client.Delete(ctx, currentBudget)
 		if err != nil {
-			return result.Error(err)
+			return err
 		}
 	}
 
@@ -1465,15 +2090,15 @@ func (rc *ReconciliationContext) CheckDcPodDisruptionBudget() result.ReconcileRe
 		"pdbNamespace", desiredBudget.Namespace,
 		"pdbName", desiredBudget.Name)
 
-	err = rc.Client.Create(ctx, desiredBudget)
+	err = rc.createResource(desiredBudget)
 	if err != nil {
-		return result.Error(err)
+		return err
 	}
 
 	rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.CreatedResource,
 		"Created PodDisruptionBudget %s", desiredBudget.Name)
 
-	return result.Continue()
+	return nil
 }
 
 // Updates the node count on a rack (statefulset)
@@ -1692,7 +2317,7 @@ func (rc *ReconciliationContext) findStartingNodes() (bool, bool, error) {
 	for _, pod := range rc.clusterPods {
 		if pod.Labels[api.CassNodeState] == stateStarting {
 			if isServerReady(pod) {
-				rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.StartedCassandra,
+				rc.recordPodEventf(pod, corev1.EventTypeNormal, events.StartedCassandra,
 					"Started Cassandra for pod %s", pod.Name)
 				if err := rc.labelServerPodStarted(pod); err != nil {
 					return false, false, err
@@ -1793,8 +2418,10 @@ func (rc *ReconciliationContext) startOneNodePerRack(endpointData httphelper.Cas
 	rc.ReqLogger.Info("reconcile_racks::startOneNodePerRack")
 
 	rackReadyCount := map[string]int{}
+	rackNodeCounts := map[string]int{}
 	for _, rackInfo := range rc.desiredRackInformation {
 		rackReadyCount[rackInfo.RackName] = 0
+		rackNodeCounts[rackInfo.RackName] = rackInfo.NodeCount
 	}
 
 	for _, pod := range rc.dcPods {
@@ -1806,11 +2433,11 @@ func (rc *ReconciliationContext) startOneNodePerRack(endpointData httphelper.Cas
 
 	// if the DC has no ready seeds, label a pod as a seed before we start Cassandra on it
 	// and also consider additional seeds
-	labelSeedBeforeStart := readySeeds == 0 && len(rc.Datacenter.Spec.AdditionalSeeds) == 0
+	labelSeedBeforeStart := readySeeds == 0 && !rc.Datacenter.HasAdditionalSeeds()
 
 	rackThatNeedsNode := ""
 	for rackName, readyCount := range rackReadyCount {
-		if readyCount > 0 {
+		if readyCount > 0 || rackNodeCounts[rackName] == 0 {
 			continue
 		}
 		rackThatNeedsNode = rackName
@@ -1874,6 +2501,50 @@ func (rc *ReconciliationContext) startAllNodes(endpointData httphelper.CassMetad
 	return false, nil
 }
 
+// startResumingNodes starts the nodes of a resuming datacenter that already
+// joined the cluster, without waiting for the nodes that are starting to be
+// ready as long as fewer than resumeParallelism are. It returns whether nodes
+// are still starting.
+func (rc *ReconciliationContext) startResumingNodes(endpointData httphelper.CassMetadataEndpoints) (bool, error) {
+	rc.ReqLogger.Info("reconcile_racks::startResumingNodes")
+	dc := rc.Datacenter
+
+	var starting int32
+	for _, pod := range rc.dcPods {
+		if !isServerStarting(pod) {
+			continue
+		}
+		if isServerReady(pod) {
+			rc.recordPodEventf(pod, corev1.EventTypeNormal, events.StartedCassandra,
+				"Started Cassandra for pod %s", pod.Name)
+			if err := rc.labelServerPodStarted(pod); err != nil {
+				return false, err
+			}
+		} else {
+			starting++
+		}
+	}
+
+	for _, pod := range rc.dcPods {
+		if starting >= dc.Spec.ResumeParallelism {
+			break
+		}
+		// The nodes being replaced, or that never joined, are started one at
+		// a time afterwards
+		if !isMgmtApiRunning(pod) || !isServerReadyToStart(pod) ||
+			dc.Status.NodeStatuses[pod.Name].HostID == "" ||
+			utils.IndexOfString(dc.Status.NodeReplacements, pod.Name) > -1 {
+			continue
+		}
+		if err := rc.startCassandra(endpointData, pod); err != nil {
+			return false, err
+		}
+		starting++
+	}
+
+	return starting > 0, nil
+}
+
 func (rc *ReconciliationContext) countReadyAndStarted() (int, int) {
 	ready := 0
 	started := 0
@@ -1938,6 +2609,16 @@ func isServerReadyToStart(pod *corev1.Pod) bool {
 	return pod.Labels[api.CassNodeState] == stateReadyToStart
 }
 
+func countReadyServers(pods []*corev1.Pod) int32 {
+	var count int32
+	for _, pod := range pods {
+		if isServerReady(pod) {
+			count++
+		}
+	}
+	return count
+}
+
 func didServerLoseReadiness(pod *corev1.Pod) bool {
 	if pod.Labels[api.CassNodeState] == stateStarted {
 		return !isServerReady(pod)
@@ -1966,13 +2647,19 @@ func (rc *ReconciliationContext) refreshSeeds() error {
 
 	startedPods := FilterPodListByCassNodeState(rc.clusterPods, stateStarted)
 
-	for _, pod := range startedPods {
+	var mu sync.Mutex
+	var reloadErr error
+	rc.NodeMgmtClient.ForEachPod(startedPods, func(pod *corev1.Pod) {
 		if err := rc.NodeMgmtClient.CallReloadSeedsEndpoint(pod); err != nil {
-			return err
+			mu.Lock()
+			defer mu.Unlock()
+			if reloadErr == nil {
+				reloadErr = err
+			}
 		}
-	}
+	})
 
-	return nil
+	return reloadErr
 }
 
 func (rc *ReconciliationContext) listPods(selector map[string]string) (*corev1.PodList, error) {
@@ -2000,6 +2687,7 @@ func (rc *ReconciliationContext) CheckRollingRestart() result.ReconcileResult {
 	if dc.Spec.RollingRestartRequested {
 		dcPatch := client.MergeFrom(dc.DeepCopy())
 		dc.Status.LastRollingRestart = metav1.Now()
+		dc.Status.RollingRestartScope = dc.Spec.RollingRestart.DeepCopy()
 		_ = rc.setCondition(
 			api.NewDatacenterCondition(api.DatacenterRollingRestart, corev1.ConditionTrue))
 		err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch)
@@ -2017,41 +2705,105 @@ func (rc *ReconciliationContext) CheckRollingRestart() result.ReconcileResult {
 		}
 	}
 
+	pods, err := rc.podsToRestart()
+	if err != nil {
+		logger.Error(err, "error finding pods for rolling restart")
+		return result.Error(err)
+	}
+
+	if len(pods) == 0 {
+		return result.Continue()
+	}
+
+	if wait, err := rc.waitForMaintenanceWindow("rolling restart"); err != nil {
+		return result.Error(err)
+	} else if wait {
+		return result.Continue()
+	}
+
+	// Racks are restarted one after the other, and at most maxUnavailablePerRack
+	// pods of the rack are restarted at the same time
+	rackName := pods[0].Labels[api.RackLabel]
+	maxUnavailable := dc.Status.RollingRestartScope.GetMaxUnavailablePerRack()
+	for i, pod := range pods {
+		if i >= maxUnavailable || pod.Labels[api.RackLabel] != rackName {
+			break
+		}
+
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.RestartingCassandra,
+			"Restarting Cassandra for pod %s", pod.Name)
+
+		// drain the node
+		err := rc.NodeMgmtClient.CallDrainEndpoint(pod)
+		if err != nil {
+			logger.Error(err, "error during drain during rolling restart",
+				"pod", pod.Name)
+		}
+		// get a fresh pod
+		// TODO should we keep the pod and cycle the DB with mgmt api?
+		err = rc.Client.Delete(rc.Ctx, pod)
+		if err != nil {
+			return result.Error(err)
+		}
+	}
+
+	return result.Done()
+}
+
+// podsToRestart returns the pods that were created before the last rolling
+// restart was requested and are within its scope, ordered by rack and then by
+// name.
+func (rc *ReconciliationContext) podsToRestart() ([]*corev1.Pod, error) {
+	dc := rc.Datacenter
+	scope := dc.Status.RollingRestartScope
+
+	selector := labels.Everything()
+	if scope != nil && scope.PodSelector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(scope.PodSelector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	cutoff := &dc.Status.LastRollingRestart
+	var pods []*corev1.Pod
 	for _, pod := range rc.dcPods {
 		podStartTime := pod.GetCreationTimestamp()
-		if podStartTime.Before(cutoff) {
-			rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.RestartingCassandra,
-				"Restarting Cassandra for pod %s", pod.Name)
+		if !podStartTime.Before(cutoff) {
+			continue
+		}
 
-			// drain the node
-			err := rc.NodeMgmtClient.CallDrainEndpoint(pod)
-			if err != nil {
-				logger.Error(err, "error during drain during rolling restart",
-					"pod", pod.Name)
+		if scope != nil {
+			if len(scope.Racks) > 0 && utils.IndexOfString(scope.Racks, pod.Labels[api.RackLabel]) < 0 {
+				continue
 			}
-			// get a fresh pod
-			// TODO should we keep the pod and cycle the DB with mgmt api?
-			err = rc.Client.Delete(rc.Ctx, pod)
-			if err != nil {
-				return result.Error(err)
+			if len(scope.Pods) > 0 && utils.IndexOfString(scope.Pods, pod.Name) < 0 {
+				continue
+			}
+			if !selector.Matches(labels.Set(pod.Labels)) {
+				continue
 			}
-			return result.Done()
 		}
-	}
 
-	return result.Continue()
-}
+		pods = append(pods, pod)
+	}
 
-func (rc *ReconciliationContext) setCondition(condition *api.DatacenterCondition) bool {
-	dc := rc.Datacenter
-	if dc.GetConditionStatus(condition.Type) != condition.Status {
-		// We are changing the status, so record the transition time
-		condition.LastTransitionTime = metav1.Now()
-		dc.SetCondition(*condition)
-		return true
+	rackOrder := make(map[string]int)
+	for i, rack := range dc.GetRacks() {
+		rackOrder[rack.Name] = i
 	}
-	return false
+
+	sort.SliceStable(pods, func(i, j int) bool {
+		rackI := rackOrder[pods[i].Labels[api.RackLabel]]
+		rackJ := rackOrder[pods[j].Labels[api.RackLabel]]
+		if rackI != rackJ {
+			return rackI < rackJ
+		}
+		return pods[i].Name < pods[j].Name
+	})
+
+	return pods, nil
 }
 
 func (rc *ReconciliationContext) CheckConditionInitializedAndReady() result.ReconcileResult {
@@ -2059,13 +2811,27 @@ func (rc *ReconciliationContext) CheckConditionInitializedAndReady() result.Reco
 	dcPatch := client.MergeFrom(dc.DeepCopy())
 	logger := rc.ReqLogger
 
+	// The nodes were set up with the internode encryption of the spec, and
+	// go from it one step at a time from now on
+	if dc.GetConditionStatus(api.DatacenterInitialized) != corev1.ConditionTrue {
+		dc.Status.InternodeEncryptionMode = dc.GetInternodeEncryptionMode()
+	}
+
 	updated := false
 	updated = rc.setCondition(
 		api.NewDatacenterCondition(api.DatacenterInitialized, corev1.ConditionTrue)) || updated
 
 	if dc.GetConditionStatus(api.DatacenterStopped) == corev1.ConditionFalse {
-		updated = rc.setCondition(
-			api.NewDatacenterCondition(api.DatacenterReady, corev1.ConditionTrue)) || updated
+		_, wasReady := dc.GetCondition(api.DatacenterReady)
+		if rc.setCondition(api.NewDatacenterCondition(api.DatacenterReady, corev1.ConditionTrue)) {
+			updated = true
+			// The first time only, later the datacenter gets ready again after
+			// it is stopped or a node restarts
+			if !wasReady {
+				rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.BootstrapComplete,
+					"Bootstrapped the datacenter, all %d nodes are ready", dc.Spec.Size)
+			}
+		}
 	}
 
 	if updated {
@@ -2083,16 +2849,45 @@ func (rc *ReconciliationContext) CheckConditionInitializedAndReady() result.Reco
 	return result.Continue()
 }
 
-func (rc *ReconciliationContext) cleanupAfterScaling() error {
-	var err error
+// cleanupAfterScalingKey names the cleanup that follows scaling up in the job
+// annotations of the pods
+const cleanupAfterScalingKey = "cleanup-after-scaling"
+
+// How often a running cleanup is checked on
+const cleanupPollSeconds = 5
+
+// cleanupAfterScaling runs a cleanup as a job of the management API on the
+// first pod that accepts it, and returns true once the cleanup is done
+func (rc *ReconciliationContext) cleanupAfterScaling() (bool, error) {
+	tracker := &jobtracker.Tracker{Client: rc.Client, MgmtClient: &rc.NodeMgmtClient}
 
-	for idx := range rc.dcPods {
-		err = rc.NodeMgmtClient.CallKeyspaceCleanupEndpoint(rc.dcPods[idx], -1, "", nil)
-		if err == nil {
+	// A cleanup that is already running is followed until it is done
+	pods := rc.dcPods
+	for _, pod := range rc.dcPods {
+		if jobtracker.JobID(pod, cleanupAfterScalingKey) != "" {
+			pods = []*corev1.Pod{pod}
 			break
 		}
 	}
-	return err
+
+	var err error
+	for _, pod := range pods {
+		pod := pod
+		var done bool
+		done, err = tracker.Poll(rc.Ctx, pod, jobtracker.Operation{
+			Key: cleanupAfterScalingKey,
+			Submit: func() (string, error) {
+				return rc.NodeMgmtClient.CallKeyspaceCleanupAsyncEndpoint(pod, -1, "", nil)
+			},
+			Run: func() error {
+				return rc.NodeMgmtClient.CallKeyspaceCleanupEndpoint(pod, -1, "", nil)
+			},
+		})
+		if !done || err == nil {
+			return done, err
+		}
+	}
+	return true, err
 }
 
 func (rc *ReconciliationContext) CheckCassandraNodeStatuses() result.ReconcileResult {
@@ -2124,6 +2919,8 @@ func (rc *ReconciliationContext) CheckClearActionConditions() result.ReconcileRe
 		api.DatacenterRollingRestart,
 		api.DatacenterResuming,
 		api.DatacenterScalingDown,
+		api.DatacenterCanaryUpgradePaused,
+		api.DatacenterMaintenancePending,
 	}
 	conditionsThatShouldBeTrue := []api.DatacenterConditionType{
 		api.DatacenterValid,
@@ -2132,14 +2929,20 @@ func (rc *ReconciliationContext) CheckClearActionConditions() result.ReconcileRe
 
 	// Explicitly handle scaling up here because we want to run a cleanup afterwards
 	if dc.GetConditionStatus(api.DatacenterScalingUp) == corev1.ConditionTrue {
-		err := rc.cleanupAfterScaling()
+		done, err := rc.cleanupAfterScaling()
 		if err != nil {
 			logger.Error(err, "error cleaning up after scaling datacenter")
 			return result.Error(err)
 		}
+		if !done {
+			logger.Info("waiting for the cleanup after scaling up the datacenter")
+			return result.RequeueSoon(cleanupPollSeconds)
+		}
 
 		updated = rc.setCondition(
 			api.NewDatacenterCondition(api.DatacenterScalingUp, corev1.ConditionFalse)) || updated
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.ScaledUpDatacenter,
+			"Scaled up the datacenter to %d nodes", dc.Spec.Size)
 	}
 
 	// Make sure that the stopped condition matches the spec, because logically
@@ -2153,6 +2956,17 @@ func (rc *ReconciliationContext) CheckClearActionConditions() result.ReconcileRe
 			api.NewDatacenterCondition(api.DatacenterStopped, corev1.ConditionFalse)) || updated
 	}
 
+	if dc.IsHibernated() {
+		updated = rc.setCondition(
+			api.NewDatacenterCondition(api.DatacenterHibernated, corev1.ConditionTrue)) || updated
+	} else if dc.GetConditionStatus(api.DatacenterHibernated) == corev1.ConditionTrue {
+		updated = rc.setCondition(
+			api.NewDatacenterCondition(api.DatacenterHibernated, corev1.ConditionFalse)) || updated
+	}
+
+	updatingCondition, _ := dc.GetCondition(api.DatacenterUpdating)
+	rolledOutConfig := updatingCondition.Status == corev1.ConditionTrue && updatingCondition.Reason == configRolloutReason
+
 	for _, conditionType := range conditionsThatShouldBeFalse {
 		updated = rc.setCondition(
 			api.NewDatacenterCondition(conditionType, corev1.ConditionFalse)) || updated
@@ -2170,6 +2984,13 @@ func (rc *ReconciliationContext) CheckClearActionConditions() result.ReconcileRe
 			return result.Error(err)
 		}
 
+		// The rollout is only over once its condition is cleared, otherwise the
+		// next reconciliation finishes it again
+		if rolledOutConfig {
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.ConfigRolloutFinished,
+				"Rolled out the changed config to every rack")
+		}
+
 		// There may have been changes to the CassandraDatacenter resource that we ignored
 		// while executing some action on the cluster. For example, a user may have
 		// requested to scale up the node count while we were in the middle of a rolling
@@ -2184,7 +3005,9 @@ func (rc *ReconciliationContext) CheckClearActionConditions() result.ReconcileRe
 
 func (rc *ReconciliationContext) CheckForInvalidState() result.ReconcileResult {
 	cond, isSet := rc.Datacenter.GetCondition(api.DatacenterValid)
-	if isSet && cond.Status == corev1.ConditionFalse {
+	// CheckVolumeClaimSizes checks a refused volume expansion again, and marks
+	// the datacenter valid once it is undone
+	if isSet && cond.Status == corev1.ConditionFalse && cond.Reason != storageClassNotExpandableReason {
 		err := fmt.Errorf("Datacenter %s is not in a valid state: %s", rc.Datacenter.Name, cond.Message)
 		return result.Error(err)
 	}
@@ -2276,18 +3099,70 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckInternodeKeystores(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckClientEncryption(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckJmxCredentials(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckBackupAgentConfig(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckConfigSecret(); recResult.Completed() {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckMetricsCollectorConfig(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckLogbackConfig(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckRenderedConfig(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckAdoption(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckRackCreation(); recResult.Completed() {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckManagedByLabelMigration(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.UpdateRackStatuses(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckRackLabels(); recResult.Completed() {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckVolumeClaimSizes(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckVolumeFailures(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckUnhealthyPods(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckDecommissioningNodes(endpointData); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2300,20 +3175,36 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
-	if utils.IsPSPEnabled() {
-		if recResult := psp.CheckEMM(rc); recResult.Completed() {
+	if emm.IsEnabled(rc.Datacenter) {
+		if recResult := emm.CheckEMM(rc, rc.Datacenter.Spec.NodeMaintenancePolicy); recResult.Completed() {
 			return recResult.Output()
 		}
 
-		// if recResult := psp.CheckPVCHealth(rc); recResult.Completed() {
+		// if recResult := emm.CheckPVCHealth(rc); recResult.Completed() {
 		// 	return recResult.Output()
 		// }
 	}
 
+	if recResult := rc.CheckSchemaAgreement(endpointData); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckRackScale(); recResult.Completed() {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckPodLoadBalancers(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckSniIngress(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckFederation(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckPodsReady(endpointData); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2331,10 +3222,22 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckMonitoring(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.DecommissionNodes(endpointData); recResult.Completed() {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckConfigValidation(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckLiveSettings(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckRackPodTemplate(); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2347,6 +3250,14 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckQueryLogging(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckLoggingLevels(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if recResult := rc.CheckClearActionConditions(); recResult.Completed() {
 		return recResult.Output()
 	}
@@ -2355,6 +3266,14 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 		return recResult.Output()
 	}
 
+	if recResult := rc.CheckInternodeEncryptionRollout(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckCertificatesReload(); recResult.Completed() {
+		return recResult.Output()
+	}
+
 	if err := setOperatorProgressStatus(rc, api.ProgressReady); err != nil {
 		return result.Error(err).Output()
 	}
@@ -2368,5 +3287,54 @@ func (rc *ReconciliationContext) ReconcileAllRacks() (reconcile.Result, error) {
 
 	rc.ReqLogger.Info("All StatefulSets should now be reconciled.")
 
+	// The generation is observed once the StatefulSets follow it. The steps
+	// below follow operations that run for hours, such as upgrades of the
+	// SSTables or repairs, which must not keep it behind.
+	if err := setObservedGeneration(rc); err != nil {
+		return result.Error(err).Output()
+	}
+
+	if recResult := rc.CheckSSTablesUpgrade(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckReplicationFactor(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckKeyspaceReplication(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckMigration(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckRepairs(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	if recResult := rc.CheckReaper(); recResult.Completed() {
+		return recResult.Output()
+	}
+
+	// Nothing notifies the operator of changes to the secrets of Vault, so
+	// read them again in a while
+	if refresh := rc.Datacenter.GetVaultRefreshInterval(); refresh > 0 {
+		rc.requeueAfter(int(refresh.Seconds()))
+	}
+
+	// Nothing is watched for changes to the additional seeds, so check on
+	// them again in a while to follow hostnames and seed datacenters
+	if rc.Datacenter.HasAdditionalSeeds() {
+		rc.requeueAfter(additionalSeedsRefreshSeconds)
+	}
+
+	// The steps that wait on long-running operations, such as repairs, are
+	// checked again once the soonest of them asked to be
+	if rc.requeueSeconds > 0 {
+		return result.RequeueSoon(rc.requeueSeconds).Output()
+	}
+
 	return result.Done().Output()
 }
@@ -4,6 +4,8 @@ package reconciliation
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
@@ -53,6 +55,27 @@ func ListAllStartedPods(pods []*corev1.Pod) []*corev1.Pod {
 	return FilterPodListByCassNodeState(pods, stateStarted)
 }
 
+// isPodScaledDown returns whether a pod of the datacenter is gone for good,
+// its ordinal being no longer below the replicas of the StatefulSet of its
+// rack. A pod that is only missing for a while, as it restarts or is
+// rescheduled, is not.
+func (rc *ReconciliationContext) isPodScaledDown(podName string) bool {
+	idx := strings.LastIndex(podName, "-")
+	if idx < 0 {
+		return false
+	}
+	ordinal, err := strconv.Atoi(podName[idx+1:])
+	if err != nil {
+		return false
+	}
+	for _, statefulSet := range rc.statefulSets {
+		if statefulSet != nil && statefulSet.Name == podName[:idx] {
+			return statefulSet.Spec.Replicas != nil && int32(ordinal) >= *statefulSet.Spec.Replicas
+		}
+	}
+	return false
+}
+
 func FindIpForHostId(endpointData httphelper.CassMetadataEndpoints, hostId string) (string, error) {
 	// If there are no nodes to ask, then of course we will not find an IP. We
 	// treat this as an error since we have not way to determine the mapping.
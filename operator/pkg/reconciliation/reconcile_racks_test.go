@@ -15,6 +15,7 @@ import (
 
 	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/jobtracker"
 	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
 	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
@@ -23,9 +24,12 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -284,6 +288,261 @@ func TestCheckRackPodTemplate_CanaryUpgrade(t *testing.T) {
 	assert.True(t, result.Completed())
 }
 
+func TestCanaryUpgradePartition(t *testing.T) {
+	dc := &api.CassandraDatacenter{}
+	assert.Equal(t, int32(2), canaryUpgradePartition(dc, 3), "a single pod is a canary by default")
+
+	dc.Spec.CanaryUpgradeCount = 2
+	assert.Equal(t, int32(1), canaryUpgradePartition(dc, 3))
+	assert.Equal(t, int32(0), canaryUpgradePartition(dc, 2))
+	assert.Equal(t, int32(0), canaryUpgradePartition(dc, 1))
+}
+
+func TestCheckRackPodTemplate_CanaryUpgradeApproval(t *testing.T) {
+	rc, _, cleanpMockSrc := setupTest()
+	defer cleanpMockSrc()
+
+	rc.Datacenter.Spec.ServerVersion = "6.8.2"
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1", Zone: "zone-1"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
+		t.Fatalf("failed to add rack to cassandradatacenter: %s", err)
+	}
+
+	rc.Datacenter.Spec.CanaryUpgrade = true
+	rc.Datacenter.Spec.CanaryUpgradeCount = 1
+	rc.Datacenter.Spec.ServerVersion = "6.8.3"
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+
+	rc.statefulSets[0].Status.Replicas = 2
+	rc.statefulSets[0].Status.ReadyReplicas = 1
+	rc.statefulSets[0].Status.CurrentReplicas = 1
+	rc.statefulSets[0].Status.UpdatedReplicas = 1
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed(), "should wait for the canary pod to be ready")
+	assert.NotEqual(t, corev1.ConditionTrue,
+		rc.Datacenter.Status.GetConditionStatus(api.DatacenterCanaryUpgradePaused))
+
+	rc.statefulSets[0].Status.ReadyReplicas = 2
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed(), "should pause for approval")
+	assert.Equal(t, corev1.ConditionTrue,
+		rc.Datacenter.Status.GetConditionStatus(api.DatacenterCanaryUpgradePaused))
+	assert.Equal(t, int32(1), statefulSetPartition(rc.statefulSets[0]))
+
+	rc.Datacenter.Spec.CanaryUpgradeApproved = true
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Equal(t, corev1.ConditionFalse,
+		rc.Datacenter.Status.GetConditionStatus(api.DatacenterCanaryUpgradePaused))
+	assert.False(t, rc.Datacenter.Spec.CanaryUpgradeApproved, "the approval should be reset")
+	assert.Nil(t, rc.statefulSets[0].Spec.UpdateStrategy.RollingUpdate)
+}
+
+func TestCheckRackPodTemplate_CanaryUpgradeAllRacks(t *testing.T) {
+	rc, _, cleanpMockSrc := setupTest()
+	defer cleanpMockSrc()
+
+	rc.Datacenter.Spec.ServerVersion = "6.8.2"
+	rc.Datacenter.Spec.Size = 4
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1", Zone: "zone-1"},
+		{Name: "rack2", Zone: "zone-2"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
+		t.Fatalf("failed to add rack to cassandradatacenter: %s", err)
+	}
+
+	rc.Datacenter.Spec.CanaryUpgrade = true
+	rc.Datacenter.Spec.CanaryUpgradeAllRacks = true
+	rc.Datacenter.Spec.CanaryUpgradeCount = 1
+	rc.Datacenter.Spec.ServerVersion = "6.8.3"
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Equal(t, int32(1), statefulSetPartition(rc.statefulSets[0]))
+
+	rc.statefulSets[0].Status.Replicas = 2
+	rc.statefulSets[0].Status.ReadyReplicas = 2
+	rc.statefulSets[0].Status.UpdatedReplicas = 1
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Equal(t, int32(1), statefulSetPartition(rc.statefulSets[1]),
+		"the second rack should get canary pods too")
+	assert.NotEqual(t, corev1.ConditionTrue,
+		rc.Datacenter.Status.GetConditionStatus(api.DatacenterCanaryUpgradePaused))
+
+	rc.statefulSets[1].Status.Replicas = 2
+	rc.statefulSets[1].Status.ReadyReplicas = 2
+	rc.statefulSets[1].Status.UpdatedReplicas = 1
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Equal(t, corev1.ConditionTrue,
+		rc.Datacenter.Status.GetConditionStatus(api.DatacenterCanaryUpgradePaused))
+}
+
+func TestCheckRackPodTemplate_CanaryUpgradeTurnedOff(t *testing.T) {
+	rc, _, cleanpMockSrc := setupTest()
+	defer cleanpMockSrc()
+
+	rc.Datacenter.Spec.ServerVersion = "6.8.2"
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1", Zone: "zone-1"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	if err := rc.Client.Update(rc.Ctx, rc.Datacenter); err != nil {
+		t.Fatalf("failed to add rack to cassandradatacenter: %s", err)
+	}
+
+	rc.Datacenter.Spec.CanaryUpgrade = true
+	rc.Datacenter.Spec.CanaryUpgradeCount = 1
+	rc.Datacenter.Spec.ServerVersion = "6.8.3"
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+
+	rc.Datacenter.Spec.CanaryUpgrade = false
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Nil(t, rc.statefulSets[0].Spec.UpdateStrategy.RollingUpdate)
+}
+
+func TestCheckRackPodTemplate_RevertStatefulSetDrift(t *testing.T) {
+	rc, _, cleanpMockSrc := setupTest()
+	defer cleanpMockSrc()
+
+	rc.Datacenter.Spec.ServerVersion = "6.8.2"
+	rc.Datacenter.Spec.RevertStatefulSetDrift = true
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1", Zone: "zone-1"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	// The spec hash is recorded the first time
+	result = rc.CheckRackPodTemplate()
+	assert.False(t, result.Completed())
+	assert.Contains(t, rc.statefulSets[0].Annotations, api.StatefulSetSpecHashAnnotation)
+
+	image := rc.statefulSets[0].Spec.Template.Spec.Containers[0].Image
+	rc.statefulSets[0].Spec.Template.Spec.Containers[0].Image = "cassandra:edited"
+	if err := rc.Client.Update(rc.Ctx, rc.statefulSets[0]); err != nil {
+		t.Fatalf("failed to edit statefulset: %s", err)
+	}
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	assert.Equal(t, image, rc.statefulSets[0].Spec.Template.Spec.Containers[0].Image)
+	assert.NotContains(t, rc.statefulSets[0].Annotations, api.StatefulSetSpecHashAnnotation)
+
+	// Scaling the rack is not an edit
+	result = rc.CheckRackPodTemplate()
+	assert.False(t, result.Completed())
+	assert.NoError(t, rc.UpdateRackNodeCount(rc.statefulSets[0], 2))
+	result = rc.CheckRackPodTemplate()
+	assert.False(t, result.Completed())
+}
+
+func TestCheckRackPodTemplate_ImmutableFields(t *testing.T) {
+	rc, _, cleanpMockSrc := setupTest()
+	defer cleanpMockSrc()
+
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1", Zone: "zone-1"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	storageClass := "other-storage-class"
+	rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.StorageClassName = &storageClass
+
+	result = rc.CheckRackPodTemplate()
+	assert.True(t, result.Completed())
+	_, err := result.Output()
+	assert.Error(t, err)
+	assert.NotEqual(t, storageClass, *rc.statefulSets[0].Spec.VolumeClaimTemplates[0].Spec.StorageClassName)
+}
+
+func TestCheckRackForceUpgrade_ImmutableFields(t *testing.T) {
+	rc, _, cleanpMockSrc := setupTest()
+	defer cleanpMockSrc()
+
+	rc.Datacenter.Spec.Racks = []api.Rack{
+		{Name: "rack1", Zone: "zone-1"},
+	}
+
+	if err := rc.CalculateRackInformation(); err != nil {
+		t.Fatalf("failed to calculate rack information: %s", err)
+	}
+
+	result := rc.CheckRackCreation()
+	assert.False(t, result.Completed(), "CheckRackCreation did not complete as expected")
+
+	storageClass := "other-storage-class"
+	rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.StorageClassName = &storageClass
+	rc.Datacenter.Spec.ForceUpgradeRacks = []string{"rack1"}
+
+	// The statefulset is deleted without its pods, to be recreated
+	result = rc.CheckRackForceUpgrade()
+	assert.True(t, result.Completed())
+	_, err := result.Output()
+	assert.NoError(t, err)
+	key := types.NamespacedName{Name: rc.statefulSets[0].Name, Namespace: rc.statefulSets[0].Namespace}
+	err = rc.Client.Get(rc.Ctx, key, &appsv1.StatefulSet{})
+	assert.True(t, errors.IsNotFound(err), "the statefulset should be deleted to be recreated")
+	assert.Equal(t, []string{"rack1"}, rc.Datacenter.Spec.ForceUpgradeRacks)
+
+	result = rc.CheckRackCreation()
+	assert.False(t, result.Completed())
+	assert.Equal(t, storageClass, *rc.statefulSets[0].Spec.VolumeClaimTemplates[0].Spec.StorageClassName)
+
+	result = rc.CheckRackForceUpgrade()
+	assert.True(t, result.Completed())
+	assert.Nil(t, rc.Datacenter.Spec.ForceUpgradeRacks)
+}
+
 func TestReconcilePods(t *testing.T) {
 	t.Skip()
 	rc, _, cleanupMockScr := setupTest()
@@ -488,6 +747,45 @@ func TestCalculateRackInformation_MultiRack(t *testing.T) {
 	// TODO add more RackInformation validation
 }
 
+func TestCalculateRackInformation_Hibernated(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Racks = []api.Rack{{
+		Name: "rack0",
+	}, {
+		Name: "rack1",
+	}, {
+		Name: "rack2",
+	}}
+	rc.Datacenter.Spec.Size = 6
+	rc.Datacenter.Spec.Hibernate = &api.HibernateConfig{Enabled: true}
+
+	nodeCounts := func() []int {
+		var counts []int
+		for _, rackInfo := range rc.desiredRackInformation {
+			counts = append(counts, rackInfo.NodeCount)
+		}
+		return counts
+	}
+
+	err := rc.CalculateRackInformation()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 1, 1}, nodeCounts())
+
+	rc.Datacenter.Spec.Hibernate.SingleNode = true
+	err = rc.CalculateRackInformation()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 0, 0}, nodeCounts())
+	assert.Equal(t, 1, rc.desiredRackInformation[0].SeedCount)
+
+	// Stopped takes precedence
+	rc.Datacenter.Spec.Stopped = true
+	err = rc.CalculateRackInformation()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 0, 0}, nodeCounts())
+}
+
 func TestReconcileRacks(t *testing.T) {
 	rc, _, cleanupMockScr := setupTest()
 	defer cleanupMockScr()
@@ -1382,3 +1680,515 @@ func Test_callPodEndpoint_RequestFail(t *testing.T) {
 		assert.Fail(t, "Should have returned error")
 	}
 }
+
+func makeRestartTestPod(name, rackName string, created time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels: map[string]string{
+				api.RackLabel: rackName,
+				"team":        "blue",
+			},
+		},
+	}
+}
+
+func setupRollingRestartTest() (*ReconciliationContext, func()) {
+	rc, _, cleanupMockScr := setupTest()
+
+	rc.Datacenter.Spec.Racks = []api.Rack{{Name: "rack1"}, {Name: "rack2"}}
+
+	old := time.Now().Add(-time.Hour)
+	rc.dcPods = []*corev1.Pod{
+		makeRestartTestPod("rack2-pod-0", "rack2", old),
+		makeRestartTestPod("rack1-pod-1", "rack1", old),
+		makeRestartTestPod("rack1-pod-0", "rack1", old),
+		makeRestartTestPod("rack2-pod-1", "rack2", old),
+	}
+	rc.dcPods[3].Labels["team"] = "red"
+
+	trackObjects := []runtime.Object{rc.Datacenter}
+	for _, pod := range rc.dcPods {
+		trackObjects = append(trackObjects, pod)
+	}
+	rc.Client = fake.NewFakeClient(trackObjects...)
+
+	return rc, cleanupMockScr
+}
+
+func podNames(pods []*corev1.Pod) []string {
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func TestPodsToRestart(t *testing.T) {
+	rc, cleanupMockScr := setupRollingRestartTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Status.LastRollingRestart = metav1.Now()
+
+	tests := []struct {
+		name  string
+		scope *api.RollingRestartConfig
+		want  []string
+	}{
+		{
+			name:  "all pods",
+			scope: nil,
+			want:  []string{"rack1-pod-0", "rack1-pod-1", "rack2-pod-0", "rack2-pod-1"},
+		},
+		{
+			name:  "single rack",
+			scope: &api.RollingRestartConfig{Racks: []string{"rack2"}},
+			want:  []string{"rack2-pod-0", "rack2-pod-1"},
+		},
+		{
+			name:  "single pod",
+			scope: &api.RollingRestartConfig{Pods: []string{"rack1-pod-1"}},
+			want:  []string{"rack1-pod-1"},
+		},
+		{
+			name: "label selector",
+			scope: &api.RollingRestartConfig{
+				PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "red"}},
+			},
+			want: []string{"rack2-pod-1"},
+		},
+		{
+			name: "rack and label selector",
+			scope: &api.RollingRestartConfig{
+				Racks:       []string{"rack1"},
+				PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "red"}},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc.Datacenter.Status.RollingRestartScope = tt.scope
+			pods, err := rc.podsToRestart()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, podNames(pods))
+		})
+	}
+}
+
+func TestPodsToRestart_SkipsNewPods(t *testing.T) {
+	rc, cleanupMockScr := setupRollingRestartTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Status.LastRollingRestart = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+
+	pods, err := rc.podsToRestart()
+	assert.NoError(t, err)
+	assert.Empty(t, pods)
+}
+
+func TestCheckRollingRestart_MaxUnavailablePerRack(t *testing.T) {
+	rc, cleanupMockScr := setupRollingRestartTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.RollingRestartRequested = true
+	rc.Datacenter.Spec.RollingRestart = &api.RollingRestartConfig{MaxUnavailablePerRack: 3}
+
+	recResult := rc.CheckRollingRestart()
+	assert.True(t, recResult.Completed())
+	assert.False(t, rc.Datacenter.Spec.RollingRestartRequested)
+	assert.Equal(t, 3, rc.Datacenter.Status.RollingRestartScope.MaxUnavailablePerRack)
+
+	// Both pods of the first rack are restarted together, the second rack waits
+	deleted := func(name string) bool {
+		pod := &corev1.Pod{}
+		err := rc.Client.Get(rc.Ctx, types.NamespacedName{Namespace: "default", Name: name}, pod)
+		return err != nil
+	}
+	assert.True(t, deleted("rack1-pod-0"))
+	assert.True(t, deleted("rack1-pod-1"))
+	assert.False(t, deleted("rack2-pod-0"))
+	assert.False(t, deleted("rack2-pod-1"))
+}
+
+func TestCheckRollingRestart_WaitsForMaintenanceWindow(t *testing.T) {
+	rc, cleanupMockScr := setupRollingRestartTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.RollingRestartRequested = true
+	rc.Datacenter.Spec.MaintenanceWindow = &api.MaintenanceWindow{
+		Schedule: "0 0 1 1 *",
+		Duration: metav1.Duration{Duration: time.Minute},
+	}
+
+	// Only the restart waits, the steps after it go on
+	recResult := rc.CheckRollingRestart()
+	assert.False(t, recResult.Completed())
+	assert.True(t, rc.requeueSeconds > 0)
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterRollingRestart))
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterMaintenancePending))
+
+	// The restart is recorded, but no pod is restarted outside the window
+	pods := &corev1.PodList{}
+	assert.NoError(t, rc.Client.List(rc.Ctx, pods))
+	assert.Len(t, pods.Items, 4)
+}
+
+func TestCheckRollingRestart_OnePodAtATimeByDefault(t *testing.T) {
+	rc, cleanupMockScr := setupRollingRestartTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.RollingRestartRequested = true
+
+	recResult := rc.CheckRollingRestart()
+	assert.True(t, recResult.Completed())
+	assert.Nil(t, rc.Datacenter.Status.RollingRestartScope)
+
+	pods := &corev1.PodList{}
+	assert.NoError(t, rc.Client.List(rc.Ctx, pods))
+	assert.Len(t, pods.Items, 3)
+}
+
+func TestCleanupAfterScaling_FollowsJob(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v1/ops/keyspace/cleanup"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusAccepted,
+			Body:       ioutil.NopCloser(strings.NewReader("job-1")),
+		}, nil).
+		Once()
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/executor/job" && req.URL.Query().Get("job_id") == "job-1"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id": "job-1", "status": "COMPLETED"}`)),
+		}, nil).
+		Once()
+
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+
+	pod := makeReloadTestPod()
+	pod.Status.PodIP = "1.2.3.4"
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+	rc.dcPods = []*corev1.Pod{pod}
+
+	done, err := rc.cleanupAfterScaling()
+	assert.NoError(t, err)
+	assert.False(t, done, "the cleanup should still be running")
+	assert.Equal(t, "job-1", jobtracker.JobID(pod, cleanupAfterScalingKey))
+
+	done, err = rc.cleanupAfterScaling()
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Empty(t, jobtracker.JobID(pod, cleanupAfterScalingKey))
+	mockHttpClient.AssertExpectations(t)
+}
+
+func TestCheckDcPodDisruptionBudget_PerRack(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dcBudget := newPodDisruptionBudgetForDatacenter(rc.Datacenter)
+	assert.NoError(t, rc.Client.Create(rc.Ctx, dcBudget))
+
+	rc.Datacenter.Spec.Racks = []api.Rack{{Name: "r1"}, {Name: "r2"}}
+	rc.Datacenter.Spec.PodDisruptionBudget = &api.PodDisruptionBudgetConfig{PerRack: true}
+
+	recResult := rc.CheckDcPodDisruptionBudget()
+	assert.False(t, recResult.Completed())
+
+	budgets := &policyv1beta1.PodDisruptionBudgetList{}
+	assert.NoError(t, rc.Client.List(rc.Ctx, budgets, client.InNamespace(rc.Datacenter.Namespace)))
+	var names []string
+	for _, budget := range budgets.Items {
+		names = append(names, budget.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		rc.Datacenter.Name + "-r1-pdb",
+		rc.Datacenter.Name + "-r2-pdb",
+	}, names, "the budget of the datacenter should be replaced by budgets per rack")
+}
+
+func TestUpdateCassandraNodeStatus(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/metadata/endpoints"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body: ioutil.NopCloser(strings.NewReader(`{"entity": [
+				{"HOST_ID": "host-0", "RPC_ADDRESS": "10.0.0.0", "STATUS": "NORMAL,1", "LOAD": "2048.0", "RACK": "r1", "SCHEMA": "schema-1"},
+				{"HOST_ID": "host-1", "RPC_ADDRESS": "10.0.0.1", "STATUS": "LEAVING,2", "LOAD": "1024.0", "RACK": "r2", "SCHEMA": "schema-1"}
+			]}`)),
+		}, nil).
+		Once()
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	started := metav1.NewTime(time.Now().Add(-time.Minute))
+	for i := 0; i < 2; i++ {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: rc.Datacenter.Namespace},
+			Status: corev1.PodStatus{
+				PodIP: fmt.Sprintf("10.0.0.%d", i),
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "cassandra",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: started}},
+				}},
+			},
+		}
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+
+	assert.NoError(t, rc.UpdateCassandraNodeStatus())
+	assert.Equal(t, api.CassandraNodeStatus{
+		HostID:        "host-0",
+		Rack:          "r1",
+		OperationMode: "NORMAL",
+		Load:          2048,
+		SchemaVersion: "schema-1",
+	}, rc.Datacenter.Status.NodeStatuses["pod-0"])
+	assert.Equal(t, "LEAVING", rc.Datacenter.Status.NodeStatuses["pod-1"].OperationMode)
+	mockHttpClient.AssertExpectations(t)
+}
+
+func TestDropRemovedUsers(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.Method == http.MethodDelete &&
+					req.URL.Path == "/api/v0/ops/auth/role" && req.URL.Query().Get("username") == "old"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("OK")),
+		}, nil).
+		Once()
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	rc.dcPods = []*corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: rc.Datacenter.Namespace},
+		Status:     corev1.PodStatus{PodIP: "10.0.0.0"},
+	}}
+	rc.Datacenter.Status.Users = []api.CassandraUserStatus{
+		{Username: "app", SecretName: "app-secret"},
+		{Username: "old", SecretName: "old-secret"},
+	}
+
+	err := rc.dropRemovedUsers(map[string]bool{"app": true})
+	assert.NoError(t, err)
+	mockHttpClient.AssertExpectations(t)
+}
+
+func TestRotateSuperuserPassword(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/auth/role/password" &&
+					req.URL.Query().Get("password") == "new-password"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("OK")),
+		}, nil).
+		Once()
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	rc.dcPods = []*corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: rc.Datacenter.Namespace},
+		Status:     corev1.PodStatus{PodIP: "10.0.0.0"},
+	}}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rc.Datacenter.GetSuperuserSecretNamespacedName().Name,
+			Namespace: rc.Datacenter.Namespace,
+		},
+		Data: map[string][]byte{
+			"username": []byte("superuser"),
+			"password": []byte("new-password"),
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, secret))
+
+	// The policy defaults to Manual
+	version, rotated, err := rc.rotateSuperuserPassword()
+	assert.NoError(t, err)
+	assert.False(t, rotated)
+	assert.Empty(t, version)
+
+	rc.Datacenter.Spec.SuperuserSecretRotationPolicy = api.SuperuserSecretRotationOnline
+	rc.Datacenter.Status.SuperuserSecretVersion = "former-uid/1"
+	version, rotated, err = rc.rotateSuperuserPassword()
+	assert.NoError(t, err)
+	assert.True(t, rotated)
+	assert.Equal(t, superuserSecretVersion(secret), version)
+	assert.NotContains(t, version, "new-password")
+
+	// The same secret is not applied again
+	rc.Datacenter.Status.SuperuserSecretVersion = version
+	_, rotated, err = rc.rotateSuperuserPassword()
+	assert.NoError(t, err)
+	assert.False(t, rotated)
+	mockHttpClient.AssertExpectations(t)
+}
+
+func TestStartResumingNodes(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/lifecycle/start"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusCreated,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil).
+		Twice()
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	rc.Datacenter.Spec.ResumeParallelism = 3
+	rc.Datacenter.Status.NodeStatuses = api.CassandraStatusMap{}
+
+	started := metav1.NewTime(time.Now().Add(-time.Minute))
+	for i := 0; i < 5; i++ {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: rc.Datacenter.Namespace,
+				Labels:    map[string]string{api.CassNodeState: stateReadyToStart},
+			},
+			Status: corev1.PodStatus{
+				PodIP: fmt.Sprintf("10.0.0.%d", i),
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "cassandra",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: started}},
+				}},
+			},
+		}
+		// The last pod never joined the cluster
+		if i < 4 {
+			rc.Datacenter.Status.NodeStatuses[pod.Name] = api.CassandraNodeStatus{HostID: fmt.Sprintf("host-%d", i)}
+		}
+		assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+	rc.dcPods[0].Labels[api.CassNodeState] = stateStarted
+	rc.dcPods[0].Status.ContainerStatuses[0].Ready = true
+	rc.dcPods[1].Labels[api.CassNodeState] = stateStarting
+
+	// pod-1 is still starting, so only two more nodes can start
+	nodesStarting, err := rc.startResumingNodes(httphelper.CassMetadataEndpoints{})
+	assert.NoError(t, err)
+	assert.True(t, nodesStarting)
+	assert.Equal(t, stateStarting, rc.dcPods[2].Labels[api.CassNodeState])
+	assert.Equal(t, stateStarting, rc.dcPods[3].Labels[api.CassNodeState])
+	assert.Equal(t, stateReadyToStart, rc.dcPods[4].Labels[api.CassNodeState])
+	mockHttpClient.AssertExpectations(t)
+
+	// Ready nodes are labeled as started
+	for _, pod := range rc.dcPods[1:4] {
+		pod.Status.ContainerStatuses[0].Ready = true
+	}
+	nodesStarting, err = rc.startResumingNodes(httphelper.CassMetadataEndpoints{})
+	assert.NoError(t, err)
+	assert.False(t, nodesStarting)
+	assert.Equal(t, stateStarted, rc.dcPods[1].Labels[api.CassNodeState])
+	assert.Equal(t, stateReadyToStart, rc.dcPods[4].Labels[api.CassNodeState])
+}
+
+func TestCheckRackStoppedState_Hibernated(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	reconcileRack := func() {
+		err := rc.CalculateRackInformation()
+		assert.NoError(t, err)
+		result := rc.CheckRackCreation()
+		assert.False(t, result.Completed())
+	}
+
+	reconcileRack()
+	result := rc.CheckRackScale()
+	assert.False(t, result.Completed())
+	assert.Equal(t, int32(2), *rc.statefulSets[0].Spec.Replicas)
+
+	// Hibernating keeps the first node of the rack
+	dc.Spec.Hibernate = &api.HibernateConfig{Enabled: true}
+	reconcileRack()
+	result = rc.CheckRackStoppedState()
+	assert.True(t, result.Completed())
+	assert.Equal(t, int32(1), *rc.statefulSets[0].Spec.Replicas)
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterHibernated))
+
+	reconcileRack()
+	result = rc.CheckRackStoppedState()
+	assert.False(t, result.Completed())
+
+	// Waking up resumes the other nodes
+	dc.Spec.Hibernate.Enabled = false
+	reconcileRack()
+	result = rc.CheckRackStoppedState()
+	assert.False(t, result.Completed())
+	result = rc.CheckRackScale()
+	assert.False(t, result.Completed())
+	assert.Equal(t, int32(2), *rc.statefulSets[0].Spec.Replicas)
+	assert.Equal(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterHibernated))
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterResuming))
+}
+
+func TestIsClusterHealthy_StopsAtUnhealthyNode(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do", mock.Anything).
+		Return(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusInternalServerError,
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}
+		}, nil)
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http", Workers: 1}
+
+	for _, name := range []string{"pod-a", "pod-b", "pod-c"} {
+		pod := makeReadyPod(name)
+		pod.Labels = map[string]string{api.CassNodeState: stateStarted}
+		rc.clusterPods = append(rc.clusterPods, pod)
+	}
+
+	assert.False(t, rc.isClusterHealthy())
+	mockHttpClient.AssertNumberOfCalls(t, "Do", 1)
+}
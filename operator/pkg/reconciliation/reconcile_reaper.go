@@ -0,0 +1,322 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/images"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/reaper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+const ReaperContainerName = "reaper"
+
+// reaperKeyspaceReplicationFactor is the replication factor of the keyspace
+// of Reaper, lowered for the datacenters with fewer nodes
+const reaperKeyspaceReplicationFactor = 3
+
+func reaperEnvFromSecret(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+// newReaperDeploymentForCassandraDatacenter creates the deployment of Reaper,
+// which stores its state in the cluster and connects to the nodes through
+// remote JMX
+func newReaperDeploymentForCassandraDatacenter(dc *api.CassandraDatacenter) *appsv1.Deployment {
+	config := dc.Spec.Reaper
+	name := dc.GetReaperDeploymentName()
+
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(labels)
+	podLabels := map[string]string{api.ReaperLabel: name}
+	oplabels.AddManagedByLabel(podLabels)
+
+	image := config.Image
+	if image == "" {
+		image = images.GetImage(images.Reaper)
+	}
+
+	superuserSecret := dc.GetSuperuserSecretNamespacedName().Name
+	env := []corev1.EnvVar{
+		{Name: "REAPER_STORAGE_TYPE", Value: "cassandra"},
+		{Name: "REAPER_CASS_CONTACT_POINTS", Value: fmt.Sprintf("[%s]", dc.GetDatacenterServiceName())},
+		{Name: "REAPER_CASS_CLUSTER_NAME", Value: dc.Spec.ClusterName},
+		{Name: "REAPER_CASS_KEYSPACE", Value: api.ReaperKeyspace},
+		{Name: "REAPER_CASS_LOCAL_DC", Value: dc.Name},
+		{Name: "REAPER_DATACENTER_AVAILABILITY", Value: "LOCAL"},
+		{Name: "REAPER_CASS_AUTH_ENABLED", Value: "true"},
+		reaperEnvFromSecret("REAPER_CASS_AUTH_USERNAME", superuserSecret, "username"),
+		reaperEnvFromSecret("REAPER_CASS_AUTH_PASSWORD", superuserSecret, "password"),
+		reaperEnvFromSecret("REAPER_JMX_AUTH_USERNAME", dc.Spec.Jmx.CredentialsSecret, "username"),
+		reaperEnvFromSecret("REAPER_JMX_AUTH_PASSWORD", dc.Spec.Jmx.CredentialsSecret, "password"),
+		{Name: "REAPER_AUTH_ENABLED", Value: "false"},
+	}
+
+	probe := &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: "/healthcheck",
+				Port: intstr.FromInt(api.ReaperAdminPort),
+			},
+		},
+		InitialDelaySeconds: 30,
+		PeriodSeconds:       15,
+	}
+
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{api.ReaperLabel: name},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: podLabels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:            ReaperContainerName,
+						Image:           image,
+						ImagePullPolicy: config.ImagePullPolicy,
+						Env:             env,
+						Ports: []corev1.ContainerPort{
+							{Name: "app", ContainerPort: api.ReaperPort},
+							{Name: "admin", ContainerPort: api.ReaperAdminPort},
+						},
+						ReadinessProbe: probe,
+						LivenessProbe:  probe,
+						Resources:      *getResourcesOrDefault(&config.Resources, &DefaultsReaperContainer),
+					}},
+					ImagePullSecrets: dc.Spec.ImagePullSecrets,
+				},
+			},
+		},
+	}
+
+	addAdditionalMetadata(dc, deployment)
+	addAdditionalTemplateMetadata(dc, &deployment.Spec.Template.ObjectMeta)
+
+	utils.AddHashAnnotation(deployment)
+
+	return deployment
+}
+
+// newReaperServiceForCassandraDatacenter creates the service of the REST API
+// of Reaper
+func newReaperServiceForCassandraDatacenter(dc *api.CassandraDatacenter) *corev1.Service {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(labels)
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dc.GetReaperServiceName(),
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{api.ReaperLabel: dc.GetReaperDeploymentName()},
+			Ports: []corev1.ServicePort{
+				namedServicePort("app", api.ReaperPort, api.ReaperPort),
+			},
+		},
+	}
+
+	addAdditionalMetadata(dc, service)
+
+	utils.AddHashAnnotation(service)
+
+	return service
+}
+
+// CheckReaper deploys the Reaper of spec.reaper and registers the cluster with
+// it once it is available, or deletes the Reaper the operator deployed when
+// spec.reaper no longer enables it
+func (rc *ReconciliationContext) CheckReaper() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.IsReaperEnabled() {
+		if err := rc.deleteReaper(); err != nil {
+			return result.Error(err)
+		}
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_reaper::CheckReaper")
+
+	desiredService := newReaperServiceForCassandraDatacenter(dc)
+	if err := rc.upsertReaperResource("Service", desiredService, &corev1.Service{}, nil); err != nil {
+		return result.Error(err)
+	}
+
+	desiredDeployment := newReaperDeploymentForCassandraDatacenter(dc)
+	currentDeployment := &appsv1.Deployment{}
+	// Reaper does not create its keyspace
+	if err := rc.upsertReaperResource("Deployment", desiredDeployment, currentDeployment, rc.createReaperKeyspace); err != nil {
+		return result.Error(err)
+	}
+
+	if dc.Status.ReaperClusterRegistered {
+		return result.Continue()
+	}
+	if currentDeployment.Status.AvailableReplicas < 1 {
+		rc.ReqLogger.Info("waiting for Reaper to be available", "deployment", desiredDeployment.Name)
+		return result.RequeueSoon(10)
+	}
+
+	reaperURL := fmt.Sprintf("http://%s.%s.svc:%d", desiredService.Name, dc.Namespace, api.ReaperPort)
+	if err := rc.reaperClient(reaperURL).AddCluster(rc.Ctx, dc.GetAllPodsServiceName(), dc.GetJmxPort()); err != nil {
+		rc.ReqLogger.Error(err, "failed to register the cluster with Reaper", "url", reaperURL)
+		return result.RequeueSoon(10)
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.RegisteredWithReaper,
+		"Registered cluster %s with Reaper %s", dc.Spec.ClusterName, desiredDeployment.Name)
+	if err := rc.setReaperClusterRegistered(true); err != nil {
+		return result.Error(err)
+	}
+	return result.Continue()
+}
+
+// upsertReaperResource creates the desired resource, after beforeCreate if
+// any, or updates the current one when their hashes differ. current is left
+// with the resource of the cluster.
+func (rc *ReconciliationContext) upsertReaperResource(kind string, desired, current runtime.Object, beforeCreate func() error) error {
+	desiredMeta := desired.(metav1.Object)
+	if err := setControllerReference(rc.Datacenter, desiredMeta, rc.Scheme); err != nil {
+		return err
+	}
+
+	key := types.NamespacedName{Name: desiredMeta.GetName(), Namespace: desiredMeta.GetNamespace()}
+	err := rc.Client.Get(rc.Ctx, key, current)
+	if errors.IsNotFound(err) {
+		if beforeCreate != nil {
+			if err := beforeCreate(); err != nil {
+				return err
+			}
+		}
+		rc.ReqLogger.Info("Creating a resource of Reaper", "kind", kind, "name", key.Name)
+		if err := rc.createResource(desired); err != nil {
+			return err
+		}
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.CreatedResource,
+			"Created %s %s", kind, key.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	currentMeta := current.(metav1.Object)
+	if utils.ResourcesHaveSameHash(currentMeta, desiredMeta) {
+		return nil
+	}
+	rc.ReqLogger.Info("Updating a resource of Reaper", "kind", kind, "name", key.Name)
+	if desiredService, ok := desired.(*corev1.Service); ok {
+		// The cluster IP of a service cannot change
+		desiredService.Spec.ClusterIP = current.(*corev1.Service).Spec.ClusterIP
+	}
+	return rc.updateResource(desired, current)
+}
+
+// createReaperKeyspace creates the keyspace Reaper stores its state in,
+// replicated in this datacenter. It is not dropped with spec.reaper.
+func (rc *ReconciliationContext) createReaperKeyspace() error {
+	dc := rc.Datacenter
+	replicationFactor := reaperKeyspaceReplicationFactor
+	if size := int(dc.Spec.Size); size < replicationFactor {
+		replicationFactor = size
+	}
+	replication := []map[string]string{{
+		"dc_name":            dc.Name,
+		"replication_factor": strconv.Itoa(replicationFactor),
+	}}
+
+	for _, pod := range rc.dcPods {
+		if !isServerReady(pod) {
+			continue
+		}
+		rc.ReqLogger.Info("creating the keyspace of Reaper", "keyspace", api.ReaperKeyspace, "pod", pod.Name)
+		return rc.NodeMgmtClient.CreateKeyspace(pod, api.ReaperKeyspace, replication)
+	}
+	return fmt.Errorf("no ready node to create keyspace %s on", api.ReaperKeyspace)
+}
+
+func (rc *ReconciliationContext) reaperClient(baseURL string) *reaper.Client {
+	httpClient := rc.ReaperHTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: reaper.DefaultTimeout}
+	}
+	return reaper.NewClient(httpClient, baseURL)
+}
+
+// deleteReaper deletes the deployment and the service of Reaper the operator
+// created for the datacenter, if any
+func (rc *ReconciliationContext) deleteReaper() error {
+	dc := rc.Datacenter
+	resources := []struct {
+		name string
+		obj  runtime.Object
+	}{
+		{dc.GetReaperDeploymentName(), &appsv1.Deployment{}},
+		{dc.GetReaperServiceName(), &corev1.Service{}},
+	}
+	for _, resource := range resources {
+		err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: resource.name, Namespace: dc.Namespace}, resource.obj)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !oplabels.HasManagedByCassandraOperatorLabel(resource.obj.(metav1.Object).GetLabels()) {
+			continue
+		}
+		rc.ReqLogger.Info("Deleting a resource of Reaper", "name", resource.name)
+		if err := rc.Client.Delete(rc.Ctx, resource.obj); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if dc.Status.ReaperClusterRegistered {
+		return rc.setReaperClusterRegistered(false)
+	}
+	return nil
+}
+
+func (rc *ReconciliationContext) setReaperClusterRegistered(registered bool) error {
+	dc := rc.Datacenter
+	patch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.ReaperClusterRegistered = registered
+	return rc.Client.Status().Patch(rc.Ctx, dc, patch)
+}
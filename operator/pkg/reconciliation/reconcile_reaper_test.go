@@ -0,0 +1,132 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+func TestNewReaperDeploymentForCassandraDatacenter(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "cluster1",
+			Reaper:      &api.ReaperConfig{Enabled: true, Image: "reaper:test"},
+			Jmx:         &api.JmxConfig{CredentialsSecret: "jmx-credentials"},
+		},
+	}
+
+	deployment := newReaperDeploymentForCassandraDatacenter(dc)
+	assert.Equal(t, "cluster1-dc1-reaper", deployment.Name)
+	assert.Equal(t, "dc1", deployment.Labels[api.DatacenterLabel])
+
+	// The services of the datacenter do not select the pods of Reaper
+	podLabels := deployment.Spec.Template.Labels
+	assert.Equal(t, "cluster1-dc1-reaper", podLabels[api.ReaperLabel])
+	assert.NotContains(t, podLabels, api.DatacenterLabel)
+	assert.NotContains(t, podLabels, api.ClusterLabel)
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "reaper:test", container.Image)
+	assert.Equal(t, DefaultsReaperContainer, container.Resources)
+
+	env := map[string]corev1.EnvVar{}
+	for _, envVar := range container.Env {
+		env[envVar.Name] = envVar
+	}
+	assert.Equal(t, "[cluster1-dc1-service]", env["REAPER_CASS_CONTACT_POINTS"].Value)
+	assert.Equal(t, api.ReaperKeyspace, env["REAPER_CASS_KEYSPACE"].Value)
+	assert.Equal(t, "dc1", env["REAPER_CASS_LOCAL_DC"].Value)
+	assert.Equal(t, "cluster1-superuser", env["REAPER_CASS_AUTH_PASSWORD"].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "jmx-credentials", env["REAPER_JMX_AUTH_USERNAME"].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "password", env["REAPER_JMX_AUTH_PASSWORD"].ValueFrom.SecretKeyRef.Key)
+}
+
+func TestCheckReaper(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/keyspace/create"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("OK")),
+		}, nil).
+		Once()
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	reaperHttpClient := &mocks.HttpClient{}
+	reaperHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.Method == http.MethodPost && req.URL.Path == "/cluster" &&
+					req.URL.Query().Get("seedHost") == rc.Datacenter.GetAllPodsServiceName() &&
+					req.URL.Query().Get("jmxPort") == "7199"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusCreated,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil).
+		Once()
+	rc.ReaperHTTPClient = reaperHttpClient
+
+	rc.dcPods = []*corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: rc.Datacenter.Namespace},
+		Status: corev1.PodStatus{
+			PodIP:             "10.0.0.0",
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "cassandra", Ready: true}},
+		},
+	}}
+
+	dc := rc.Datacenter
+	dc.Spec.Reaper = &api.ReaperConfig{Enabled: true}
+	dc.Spec.Jmx = &api.JmxConfig{CredentialsSecret: "jmx-credentials"}
+
+	// The cluster is registered once Reaper is available
+	assert.True(t, rc.CheckReaper().Completed())
+	mockHttpClient.AssertExpectations(t)
+	assert.False(t, dc.Status.ReaperClusterRegistered)
+
+	deploymentKey := types.NamespacedName{Name: dc.GetReaperDeploymentName(), Namespace: dc.Namespace}
+	deployment := &appsv1.Deployment{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, deploymentKey, deployment))
+	service := &corev1.Service{}
+	serviceKey := types.NamespacedName{Name: dc.GetReaperServiceName(), Namespace: dc.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, serviceKey, service))
+
+	deployment.Status.AvailableReplicas = 1
+	assert.NoError(t, rc.Client.Update(rc.Ctx, deployment))
+	assert.False(t, rc.CheckReaper().Completed())
+	assert.True(t, dc.Status.ReaperClusterRegistered)
+	reaperHttpClient.AssertExpectations(t)
+
+	// Registered clusters are not registered again
+	assert.False(t, rc.CheckReaper().Completed())
+
+	// Disabling Reaper deletes it
+	dc.Spec.Reaper.Enabled = false
+	assert.False(t, rc.CheckReaper().Completed())
+	assert.False(t, dc.Status.ReaperClusterRegistered)
+	assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, deploymentKey, &appsv1.Deployment{})))
+	assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, serviceKey, &corev1.Service{})))
+}
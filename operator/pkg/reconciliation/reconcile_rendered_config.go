@@ -0,0 +1,174 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/serverconfig"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+// renderServerConfig renders the config of the spec for the nodes of the
+// rack, in place of the config builder
+func renderServerConfig(dc *api.CassandraDatacenter, rackName string) (*serverconfig.RenderedConfig, error) {
+	rackConfig, err := dc.GetRackConfig(rackName)
+	if err != nil {
+		return nil, err
+	}
+	config, err := dc.GetConfigAsJSON(rackConfig)
+	if err != nil {
+		return nil, err
+	}
+	return serverconfig.RenderConfig(dc.Spec.ServerVersion, rackName, config)
+}
+
+// renderedConfigHash returns the hash of the files of the rendered config
+func renderedConfigHash(rendered *serverconfig.RenderedConfig) (string, error) {
+	filesJSON, err := json.Marshal(rendered.Files)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(filesJSON)), nil
+}
+
+// newRenderedConfigMap returns the ConfigMap of the files of the config
+// rendered for the nodes of the rack, which their server-config-init
+// container copies. It is named after the hash of the files, which never
+// change in place.
+func newRenderedConfigMap(dc *api.CassandraDatacenter, rackName string, rendered *serverconfig.RenderedConfig, configHash string) *corev1.ConfigMap {
+	labels := dc.GetRackLabels(rackName)
+	oplabels.AddManagedByLabel(labels)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dc.GetRenderedConfigMapName(rackName, configHash),
+			Namespace: dc.Namespace,
+			Labels:    labels,
+		},
+		Data: rendered.Files,
+	}
+
+	addAdditionalMetadata(dc, configMap)
+
+	utils.AddHashAnnotation(configMap)
+
+	return configMap
+}
+
+// CheckRenderedConfig creates the ConfigMap of the rendered config of every
+// rack, before the pods that mount it. The pods restart for the changes of
+// the config, as the name of the ConfigMap and the hash in their
+// server-config-init container change with it. The ConfigMaps of the previous
+// configs are deleted once no pod or pod template of the rack mounts them.
+func (rc *ReconciliationContext) CheckRenderedConfig() result.ReconcileResult {
+	dc := rc.Datacenter
+	if !dc.RendersConfigInOperator() {
+		return result.Continue()
+	}
+
+	for _, rack := range dc.GetRacks() {
+		rendered, err := renderServerConfig(dc, rack.Name)
+		if err != nil {
+			return result.Error(err)
+		}
+		configHash, err := renderedConfigHash(rendered)
+		if err != nil {
+			return result.Error(err)
+		}
+
+		desiredConfigMap := newRenderedConfigMap(dc, rack.Name, rendered, configHash)
+		if err := setControllerReference(dc, desiredConfigMap, rc.Scheme); err != nil {
+			return result.Error(err)
+		}
+
+		currentConfigMap := &corev1.ConfigMap{}
+		err = rc.Client.Get(rc.Ctx, types.NamespacedName{Name: desiredConfigMap.Name, Namespace: desiredConfigMap.Namespace}, currentConfigMap)
+		if err != nil && errors.IsNotFound(err) {
+			rc.ReqLogger.Info("Creating the ConfigMap of the rendered config", "ConfigMap", desiredConfigMap.Name)
+			if err := rc.createResource(desiredConfigMap); err != nil {
+				return result.Error(err)
+			}
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+				"Created ConfigMap %s", desiredConfigMap.Name)
+		} else if err != nil {
+			return result.Error(err)
+		} else if !utils.ResourcesHaveSameHash(currentConfigMap, desiredConfigMap) {
+			// Only the labels and annotations change, the files are the ones
+			// of the name
+			rc.ReqLogger.Info("Updating the ConfigMap of the rendered config", "ConfigMap", desiredConfigMap.Name)
+			if err := rc.updateResource(desiredConfigMap, currentConfigMap); err != nil {
+				return result.Error(err)
+			}
+		}
+
+		if err := rc.deleteUnusedRenderedConfigMaps(rack.Name, desiredConfigMap.Name); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
+
+// deleteUnusedRenderedConfigMaps deletes the ConfigMaps of the rendered
+// configs of the rack other than desired that neither the pods of the rack
+// nor its StatefulSet mount anymore
+func (rc *ReconciliationContext) deleteUnusedRenderedConfigMaps(rackName, desired string) error {
+	dc := rc.Datacenter
+	inUse := map[string]bool{desired: true}
+	addVolumes := func(volumes []corev1.Volume) {
+		for _, volume := range volumes {
+			if volume.ConfigMap != nil {
+				inUse[volume.ConfigMap.Name] = true
+			}
+		}
+	}
+	for _, pod := range rc.dcPods {
+		if pod.Labels[api.RackLabel] == rackName {
+			addVolumes(pod.Spec.Volumes)
+		}
+	}
+	statefulSet := &appsv1.StatefulSet{}
+	err := rc.Client.Get(rc.Ctx, newNamespacedNameForStatefulSet(dc, rackName), statefulSet)
+	if err == nil {
+		addVolumes(statefulSet.Spec.Template.Spec.Volumes)
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	if err := rc.Client.List(rc.Ctx, configMaps, client.InNamespace(dc.Namespace), client.MatchingLabels(dc.GetRackLabels(rackName))); err != nil {
+		return err
+	}
+	prefix := dc.GetRenderedConfigMapPrefix(rackName)
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+		// The ConfigMap of releases that updated it in place has no hash
+		if configMap.Name != prefix && !strings.HasPrefix(configMap.Name, prefix+"-") {
+			continue
+		}
+		if inUse[configMap.Name] {
+			continue
+		}
+		rc.ReqLogger.Info("Deleting the ConfigMap of a previous rendered config", "ConfigMap", configMap.Name)
+		if err := rc.Client.Delete(rc.Ctx, configMap); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
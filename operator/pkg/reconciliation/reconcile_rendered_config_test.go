@@ -0,0 +1,111 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/serverconfig"
+)
+
+// renderedConfigMapKey returns the key of the ConfigMap of the config the
+// rack renders
+func renderedConfigMapKey(t *testing.T, dc *api.CassandraDatacenter, rackName string) types.NamespacedName {
+	rendered, err := renderServerConfig(dc, rackName)
+	assert.NoError(t, err)
+	configHash, err := renderedConfigHash(rendered)
+	assert.NoError(t, err)
+	return types.NamespacedName{Name: dc.GetRenderedConfigMapName(rackName, configHash), Namespace: dc.Namespace}
+}
+
+func TestCheckRenderedConfig(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ServerType = "cassandra"
+	dc.Spec.ServerVersion = "4.0.1"
+	dc.Spec.Racks = []api.Rack{{Name: "rack1"}, {Name: "rack2"}}
+
+	assert.False(t, rc.CheckRenderedConfig().Completed())
+	key := renderedConfigMapKey(t, dc, "rack1")
+	assert.Error(t, rc.Client.Get(rc.Ctx, key, &corev1.ConfigMap{}), "the ConfigMap should only exist with the config rendered in the operator")
+
+	dc.Spec.ConfigRenderer = api.ConfigRendererOperator
+	assert.False(t, rc.CheckRenderedConfig().Completed())
+	configMap := &corev1.ConfigMap{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, renderedConfigMapKey(t, dc, "rack2"), configMap))
+	assert.Equal(t, "dc=cassandradatacenter-example\nrack=rack2\n", configMap.Data[serverconfig.RackDCFile])
+
+	// A pod still mounts the ConfigMap of the first config
+	rc.dcPods = []*corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Labels: dc.GetRackLabels("rack1")},
+		Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+			Name: RenderedConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: key.Name},
+			}},
+		}}},
+	}}
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"num_tokens":8}}`)
+	assert.False(t, rc.CheckRenderedConfig().Completed())
+	newKey := renderedConfigMapKey(t, dc, "rack1")
+	assert.NotEqual(t, key, newKey, "the ConfigMap of a new config has a name of its own")
+	assert.NoError(t, rc.Client.Get(rc.Ctx, newKey, configMap))
+	assert.Contains(t, configMap.Data[serverconfig.CassandraYamlFile], "num_tokens: 8")
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, configMap), "the ConfigMap of the pod is kept until it restarts")
+
+	rc.dcPods = nil
+	assert.False(t, rc.CheckRenderedConfig().Completed())
+	assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, key, configMap)))
+	assert.NoError(t, rc.Client.Get(rc.Ctx, newKey, configMap))
+}
+
+func TestBuildPodTemplateSpec_RenderedConfig(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName:    "cluster1",
+			ServerType:     "cassandra",
+			ServerVersion:  "4.0.1",
+			ConfigRenderer: api.ConfigRendererOperator,
+			Config:         []byte(`{"jvm-server-options":{"max_heap_size":"4G"}}`),
+		},
+	}
+
+	podTemplate, err := buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+
+	container := findServerConfigContainer(podTemplate)
+	assert.NotNil(t, container)
+	assert.NotEmpty(t, envVarValue(container, "CONFIG_HASH"))
+	assert.Empty(t, envVarValue(container, "CONFIG_FILE_DATA"), "the config builder should not run")
+
+	found := false
+	for _, volume := range podTemplate.Spec.Volumes {
+		if volume.Name == RenderedConfigVolumeName {
+			found = true
+			assert.Equal(t, dc.GetRenderedConfigMapName("rack1", envVarValue(container, "CONFIG_HASH")), volume.ConfigMap.Name)
+		}
+	}
+	assert.True(t, found, "the ConfigMap of the rack should be mounted")
+
+	for _, c := range podTemplate.Spec.Containers {
+		if c.Name == CassandraContainerName {
+			assert.Equal(t, "-Xmx4G", envVarValue(&c, "JVM_EXTRA_OPTS"))
+		}
+	}
+
+	// The pods restart when the rendered config changes
+	dc.Spec.Config = []byte(`{"cassandra-yaml":{"num_tokens":8}}`)
+	changed, err := buildPodTemplateSpec(dc, map[string]string{}, "rack1")
+	assert.NoError(t, err)
+	assert.NotEqual(t, envVarValue(container, "CONFIG_HASH"), envVarValue(findServerConfigContainer(changed), "CONFIG_HASH"))
+}
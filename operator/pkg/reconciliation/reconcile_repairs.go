@@ -0,0 +1,316 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+)
+
+// How often the progress of a running repair is checked
+const repairPollSeconds = 30
+
+// The management API only returns once a node is done repairing, which can
+// take hours. The coordinator runs the repair of a node in the background and
+// the reconcile loop polls it, so the rest of the datacenter keeps being
+// reconciled in the meantime. Its state is only kept in memory; if the
+// operator restarts, the pod at the head of the pending list in the status is
+// simply repaired again.
+type repairCoordinator struct {
+	mutex sync.Mutex
+	jobs  map[string]*repairJob
+}
+
+type repairJob struct {
+	datacenter string
+	repairName string
+	done       bool
+	err        error
+}
+
+var repairs = &repairCoordinator{jobs: make(map[string]*repairJob)}
+
+// start runs repair in the background, unless a repair of the datacenter is
+// already tracked: the nodes of a datacenter are repaired one at a time,
+// whichever schedule they are repaired for. It returns whether the repair of
+// key is the one tracked.
+func (c *repairCoordinator) start(datacenter, repairName, key string, repair func() error) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for trackedKey, job := range c.jobs {
+		if job.datacenter == datacenter {
+			return trackedKey == key
+		}
+	}
+
+	job := &repairJob{datacenter: datacenter, repairName: repairName}
+	c.jobs[key] = job
+
+	go func() {
+		err := repair()
+
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		job.done = true
+		job.err = err
+	}()
+	return true
+}
+
+// poll reports whether a repair is tracked for key and, once it is done, its
+// error. A finished repair is forgotten after it has been polled.
+func (c *repairCoordinator) poll(key string) (started bool, done bool, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	job, ok := c.jobs[key]
+	if !ok {
+		return false, false, nil
+	}
+
+	if job.done {
+		delete(c.jobs, key)
+	}
+	return true, job.done, job.err
+}
+
+// forgetRemovedSchedules forgets the finished repairs of the datacenter that
+// are not of the given schedules, which are never polled again and would keep
+// the other schedules from repairing
+func (c *repairCoordinator) forgetRemovedSchedules(datacenter string, schedules map[string]bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key, job := range c.jobs {
+		if job.datacenter == datacenter && job.done && !schedules[job.repairName] {
+			delete(c.jobs, key)
+		}
+	}
+}
+
+func repairDatacenter(dc *api.CassandraDatacenter) string {
+	return dc.Namespace + "/" + dc.Name
+}
+
+func repairKey(dc *api.CassandraDatacenter, repairName, podName string) string {
+	return fmt.Sprintf("%s/%s/%s", repairDatacenter(dc), repairName, podName)
+}
+
+// CheckRepairs starts the repair schedules that are due, and moves the
+// running ones along one node at a time. Repairing a single node of the
+// datacenter at a time, even when several schedules are running, keeps the
+// repairs from ever working on the same token ranges at once.
+func (rc *ReconciliationContext) CheckRepairs() result.ReconcileResult {
+	dc := rc.Datacenter
+	logger := rc.ReqLogger
+
+	if len(dc.Spec.Repairs) == 0 && len(dc.Status.Repairs) == 0 {
+		return result.Continue()
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	now := time.Now()
+
+	previous := make(map[string]api.RepairScheduleStatus)
+	for _, status := range dc.Status.Repairs {
+		previous[status.Name] = status
+	}
+
+	running := false
+	var nextDue *metav1.Time
+
+	schedules := make(map[string]bool, len(dc.Spec.Repairs))
+	for _, repair := range dc.Spec.Repairs {
+		schedules[repair.Name] = true
+	}
+	// The repairs after the replication of keyspaces grew poll theirs
+	for _, status := range dc.Status.KeyspaceRepairs {
+		schedules[keyspaceRepair(status.Name).Name] = true
+	}
+	repairs.forgetRemovedSchedules(repairDatacenter(dc), schedules)
+
+	// Statuses of schedules that were removed from the spec are dropped
+	var statuses []api.RepairScheduleStatus
+	for _, repair := range dc.Spec.Repairs {
+		schedule, err := cron.ParseStandard(repair.Schedule)
+		if err != nil {
+			logger.Error(err, "invalid repair schedule", "repair", repair.Name)
+			continue
+		}
+
+		status, ok := previous[repair.Name]
+		if !ok {
+			status = api.RepairScheduleStatus{Name: repair.Name}
+		}
+
+		rc.progressRepair(repair, schedule, &status, now)
+
+		if len(status.PendingPods) > 0 {
+			running = true
+		} else if nextDue == nil || status.NextRepairTime.Before(nextDue) {
+			nextDue = status.NextRepairTime
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	if !reflect.DeepEqual(dc.Status.Repairs, statuses) {
+		dc.Status.Repairs = statuses
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			logger.Error(err, "error patching datacenter status for repairs")
+			return result.Error(err)
+		}
+	}
+
+	// The repairs run for hours, and the next runs can be days away, which
+	// must not hold back the steps after this one
+	if running {
+		rc.requeueAfter(repairPollSeconds)
+	} else if nextDue != nil {
+		rc.requeueAfter(int(nextDue.Sub(now).Seconds()) + 1)
+	}
+
+	return result.Continue()
+}
+
+// progressRepair updates the status of a single repair schedule. It starts a
+// new run once the schedule is due, and otherwise repairs the next pending
+// pod of the current run.
+func (rc *ReconciliationContext) progressRepair(repair api.RepairSchedule, schedule cron.Schedule, status *api.RepairScheduleStatus, now time.Time) {
+	dc := rc.Datacenter
+
+	if len(status.PendingPods) == 0 {
+		// Basing the next run on the last one picks up changes to the schedule,
+		// and catches up on a run that was missed while the operator was down.
+		var next metav1.Time
+		if status.LastRepairTime != nil {
+			next = metav1.NewTime(schedule.Next(status.LastRepairTime.Time))
+		} else if status.NextRepairTime != nil {
+			next = *status.NextRepairTime
+		} else {
+			next = metav1.NewTime(schedule.Next(now))
+		}
+		status.NextRepairTime = &next
+
+		if now.Before(next.Time) {
+			return
+		}
+
+		start := metav1.NewTime(now)
+		status.LastRepairStart = &start
+		status.LastError = ""
+		status.PendingPods = sortedPodNames(rc.dcPods)
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.StartedRepair,
+			"Starting %s repair on %d pods", repair.Name, len(status.PendingPods))
+	}
+
+	if len(status.PendingPods) > 0 && !rc.repairPendingPod(repair, status) {
+		return
+	}
+
+	if len(status.PendingPods) == 0 {
+		finish := metav1.NewTime(now)
+		status.LastRepairTime = &finish
+		next := metav1.NewTime(schedule.Next(now))
+		status.NextRepairTime = &next
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CompletedRepair,
+			"Completed %s repair", repair.Name)
+	}
+}
+
+// repairPendingPod starts the repair of the first pending pod, or checks on
+// it if it is already running. It returns true once the pod is done and has
+// been removed from the pending list.
+func (rc *ReconciliationContext) repairPendingPod(repair api.RepairSchedule, status *api.RepairScheduleStatus) bool {
+	dc := rc.Datacenter
+	podName := status.PendingPods[0]
+	key := repairKey(dc, repair.Name, podName)
+
+	started, done, err := repairs.poll(key)
+	if !started {
+		pod := findPod(rc.dcPods, podName)
+		if pod == nil {
+			// The pod was removed from the datacenter since the run started
+			status.PendingPods = status.PendingPods[1:]
+			return true
+		}
+
+		if !isServerReady(pod) {
+			rc.ReqLogger.Info("Waiting for pod to be ready before repairing it",
+				"repair", repair.Name, "pod", podName)
+			return false
+		}
+
+		mgmtClient := rc.NodeMgmtClient
+		pod = pod.DeepCopy()
+		if !repairs.start(repairDatacenter(dc), repair.Name, key, func() error {
+			return repairNode(&mgmtClient, pod, repair)
+		}) {
+			rc.ReqLogger.Info("Waiting for the repair of another node of the datacenter",
+				"repair", repair.Name, "pod", podName)
+			return false
+		}
+		rc.ReqLogger.Info("Repairing pod", "repair", repair.Name, "pod", podName)
+		return false
+	}
+
+	if !done {
+		return false
+	}
+
+	if err != nil {
+		status.LastError = fmt.Sprintf("%s: %s", podName, err.Error())
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.FailedRepair,
+			"Repair %s failed on pod %s: %s", repair.Name, podName, err.Error())
+	}
+	status.PendingPods = status.PendingPods[1:]
+	return true
+}
+
+// repairNode repairs the primary ranges of the node, the ranges it owns the
+// first replica of. Every node of the datacenter is repaired in turn, so each
+// range is repaired once rather than once per replica.
+func repairNode(mgmtClient *httphelper.NodeMgmtClient, pod *corev1.Pod, repair api.RepairSchedule) error {
+	full := repair.Type != api.RepairTypeIncremental
+	for _, keyspace := range repair.Keyspaces {
+		if err := mgmtClient.CallRepairEndpoint(pod, keyspace, full, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedPodNames(pods []*corev1.Pod) []string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func findPod(pods []*corev1.Pod, name string) *corev1.Pod {
+	for _, pod := range pods {
+		if pod.Name == name {
+			return pod
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func makeReadyPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.PodStatus{
+			PodIP: "1.2.3.4",
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "cassandra", Ready: true},
+			},
+		},
+	}
+}
+
+func waitForRepair(t *testing.T, key string) {
+	for i := 0; i < 200; i++ {
+		repairs.mutex.Lock()
+		job, ok := repairs.jobs[key]
+		done := ok && job.done
+		repairs.mutex.Unlock()
+
+		if done {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("repair %s did not finish", key)
+}
+
+func TestRepairCoordinator(t *testing.T) {
+	coordinator := &repairCoordinator{jobs: make(map[string]*repairJob)}
+
+	started, _, _ := coordinator.poll("key")
+	assert.False(t, started)
+
+	release := make(chan struct{})
+	calls := 0
+	assert.True(t, coordinator.start("dc", "weekly", "key", func() error {
+		calls++
+		<-release
+		return fmt.Errorf("boom")
+	}))
+	assert.True(t, coordinator.start("dc", "weekly", "key", func() error {
+		t.Error("a second repair should not start while one is tracked")
+		return nil
+	}))
+	assert.False(t, coordinator.start("dc", "daily", "other-key", func() error {
+		t.Error("another node of the datacenter should not be repaired at the same time")
+		return nil
+	}))
+
+	started, done, _ := coordinator.poll("key")
+	assert.True(t, started)
+	assert.False(t, done)
+
+	close(release)
+	for i := 0; i < 200; i++ {
+		if _, done, _ = coordinator.poll("key"); done {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	started, _, _ = coordinator.poll("key")
+	assert.False(t, started, "a finished repair should be forgotten once polled")
+	assert.Equal(t, 1, calls)
+}
+
+func TestRepairCoordinator_ForgetsRemovedSchedules(t *testing.T) {
+	coordinator := &repairCoordinator{jobs: make(map[string]*repairJob)}
+	coordinator.jobs["key"] = &repairJob{datacenter: "dc", repairName: "removed", done: true}
+
+	coordinator.forgetRemovedSchedules("dc", map[string]bool{"weekly": true})
+	assert.True(t, coordinator.start("dc", "weekly", "other-key", func() error { return nil }),
+		"the repair of a removed schedule should not hold up the others")
+}
+
+func TestCheckRepairs_NoSchedules(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	recResult := rc.CheckRepairs()
+	assert.False(t, recResult.Completed())
+}
+
+func TestCheckRepairs_SchedulesFirstRun(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Repairs = []api.RepairSchedule{
+		{Name: "daily", Schedule: "@daily", Keyspaces: []string{"ks1"}},
+	}
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-a")}
+
+	recResult := rc.CheckRepairs()
+	assert.False(t, recResult.Completed(), "the steps after the repairs should still run")
+	assert.True(t, rc.requeueSeconds > 0, "should requeue for the next run")
+
+	assert.Len(t, rc.Datacenter.Status.Repairs, 1)
+	status := rc.Datacenter.Status.Repairs[0]
+	assert.Equal(t, "daily", status.Name)
+	assert.NotNil(t, status.NextRepairTime)
+	assert.True(t, status.NextRepairTime.After(time.Now()))
+	assert.Empty(t, status.PendingPods)
+	assert.Nil(t, status.LastRepairStart)
+}
+
+func TestCheckRepairs_RepairsOnePodAtATime(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	due := metav1.NewTime(time.Now().Add(-time.Minute))
+	rc.Datacenter.Spec.Repairs = []api.RepairSchedule{
+		{Name: "weekly", Schedule: "0 2 * * 0", Type: api.RepairTypeIncremental, Keyspaces: []string{"ks1", "ks2"}},
+	}
+	rc.Datacenter.Status.Repairs = []api.RepairScheduleStatus{
+		{Name: "weekly", NextRepairTime: &due},
+	}
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-b"), makeReadyPod("pod-a")}
+
+	rc.CheckRepairs()
+	status := rc.Datacenter.Status.Repairs[0]
+	assert.Equal(t, []string{"pod-a", "pod-b"}, status.PendingPods)
+	assert.NotNil(t, status.LastRepairStart)
+
+	waitForRepair(t, repairKey(rc.Datacenter, "weekly", "pod-a"))
+
+	rc.CheckRepairs()
+	status = rc.Datacenter.Status.Repairs[0]
+	assert.Equal(t, []string{"pod-b"}, status.PendingPods)
+	assert.Nil(t, status.LastRepairTime)
+
+	waitForRepair(t, repairKey(rc.Datacenter, "weekly", "pod-b"))
+
+	rc.CheckRepairs()
+	status = rc.Datacenter.Status.Repairs[0]
+	assert.Empty(t, status.PendingPods)
+	assert.NotNil(t, status.LastRepairTime)
+	assert.True(t, status.NextRepairTime.After(time.Now()))
+	assert.Empty(t, status.LastError)
+}
+
+func TestCheckRepairs_WaitsForReadyPod(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	pod := makeReadyPod("pod-a")
+	pod.Status.ContainerStatuses[0].Ready = false
+
+	rc.Datacenter.Spec.Repairs = []api.RepairSchedule{
+		{Name: "weekly", Schedule: "0 2 * * 0", Keyspaces: []string{"ks1"}},
+	}
+	rc.Datacenter.Status.Repairs = []api.RepairScheduleStatus{
+		{Name: "weekly", PendingPods: []string{"pod-a"}},
+	}
+	rc.dcPods = []*corev1.Pod{pod}
+
+	recResult := rc.CheckRepairs()
+	assert.False(t, recResult.Completed())
+	assert.Equal(t, repairPollSeconds, rc.requeueSeconds)
+
+	started, _, _ := repairs.poll(repairKey(rc.Datacenter, "weekly", "pod-a"))
+	assert.False(t, started, "should not repair a pod that is not ready")
+	assert.Equal(t, []string{"pod-a"}, rc.Datacenter.Status.Repairs[0].PendingPods)
+}
+
+func TestCheckRepairs_SkipsRemovedPods(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Repairs = []api.RepairSchedule{
+		{Name: "weekly", Schedule: "0 2 * * 0", Keyspaces: []string{"ks1"}},
+	}
+	rc.Datacenter.Status.Repairs = []api.RepairScheduleStatus{
+		{Name: "weekly", PendingPods: []string{"pod-gone"}},
+	}
+
+	rc.CheckRepairs()
+	status := rc.Datacenter.Status.Repairs[0]
+	assert.Empty(t, status.PendingPods)
+	assert.NotNil(t, status.LastRepairTime)
+}
+
+func TestCheckRepairs_DropsRemovedSchedules(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Status.Repairs = []api.RepairScheduleStatus{{Name: "removed"}}
+
+	rc.CheckRepairs()
+	assert.Empty(t, rc.Datacenter.Status.Repairs)
+}
+
+func TestCheckRepairs_RepairsOneNodeOfTheDatacenterAtATime(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	due := metav1.NewTime(time.Now().Add(-time.Minute))
+	rc.Datacenter.Spec.Repairs = []api.RepairSchedule{
+		{Name: "weekly", Schedule: "0 2 * * 0", Keyspaces: []string{"ks1"}},
+		{Name: "daily", Schedule: "@daily", Keyspaces: []string{"ks2"}},
+	}
+	rc.Datacenter.Status.Repairs = []api.RepairScheduleStatus{
+		{Name: "weekly", NextRepairTime: &due},
+		{Name: "daily", NextRepairTime: &due},
+	}
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-a")}
+
+	rc.CheckRepairs()
+	assert.Equal(t, []string{"pod-a"}, rc.Datacenter.Status.Repairs[0].PendingPods)
+	assert.Equal(t, []string{"pod-a"}, rc.Datacenter.Status.Repairs[1].PendingPods)
+	started, _, _ := repairs.poll(repairKey(rc.Datacenter, "daily", "pod-a"))
+	assert.False(t, started, "the daily repair should wait for the weekly one")
+
+	waitForRepair(t, repairKey(rc.Datacenter, "weekly", "pod-a"))
+
+	rc.CheckRepairs()
+	assert.Empty(t, rc.Datacenter.Status.Repairs[0].PendingPods)
+	assert.Equal(t, []string{"pod-a"}, rc.Datacenter.Status.Repairs[1].PendingPods)
+
+	waitForRepair(t, repairKey(rc.Datacenter, "daily", "pod-a"))
+
+	rc.CheckRepairs()
+	assert.Empty(t, rc.Datacenter.Status.Repairs[1].PendingPods)
+	assert.NotNil(t, rc.Datacenter.Status.Repairs[1].LastRepairTime)
+}
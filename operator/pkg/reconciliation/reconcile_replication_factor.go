@@ -0,0 +1,177 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+)
+
+// How long the replication factors read from the schema are relied on before
+// they are read again, except before scaling down
+const replicationFactorRefreshPeriod = 5 * time.Minute
+
+const (
+	sizeBelowReplicationFactorReason      = "SizeBelowReplicationFactor"
+	scaleDownBelowReplicationFactorReason = "ScaleDownBelowReplicationFactor"
+)
+
+// replicationFactorIn returns the replication factor of a keyspace in the
+// datacenter. Only NetworkTopologyStrategy places replicas in each
+// datacenter: the ones of a SimpleStrategy are spread over the ring of the
+// whole cluster, and keyspaces with a LocalStrategy, such as system, have
+// none.
+func replicationFactorIn(dcName string, replication map[string]string) int32 {
+	if replication["class"] != networkTopologyStrategy {
+		return 0
+	}
+	factors, _ := parseReplication(replication)
+	return factors[dcName]
+}
+
+// managesSystemKeyspace returns whether name is one of the system keyspaces
+// whose replication the operator keeps in step with the size of the
+// datacenter, which never makes a size unsafe: the operator lowers their
+// replication factor itself before scaling down.
+func (rc *ReconciliationContext) managesSystemKeyspace(name string) bool {
+	if !rc.Datacenter.Spec.ManageSystemKeyspaces {
+		return false
+	}
+	for _, systemKeyspace := range systemKeyspaces {
+		if name == systemKeyspace {
+			return true
+		}
+	}
+	return false
+}
+
+// readMaxReplicationFactor reads the keyspace with the highest replication
+// factor in the datacenter from the schema. The managed system keyspaces are
+// left out, see managesSystemKeyspace.
+func (rc *ReconciliationContext) readMaxReplicationFactor() (*api.KeyspaceReplicationFactor, error) {
+	dc := rc.Datacenter
+	pod := findReadyPod(rc.dcPods)
+	if pod == nil {
+		return nil, fmt.Errorf("no ready node to read the schema from")
+	}
+
+	keyspaces, err := rc.NodeMgmtClient.CallListKeyspacesEndpoint(pod)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keyspaces)
+
+	max := &api.KeyspaceReplicationFactor{LastCheckTime: metav1.Now()}
+	for _, name := range keyspaces {
+		if rc.managesSystemKeyspace(name) {
+			continue
+		}
+		replication, err := rc.NodeMgmtClient.CallGetKeyspaceReplicationEndpoint(pod, name)
+		if err != nil {
+			return nil, err
+		}
+		if factor := replicationFactorIn(dc.Name, replication); factor > max.ReplicationFactor {
+			max.Keyspace = name
+			max.ReplicationFactor = factor
+		}
+	}
+	return max, nil
+}
+
+// refreshMaxReplicationFactor updates status.maxReplicationFactor when it has
+// not been read within the refresh period, or when forced. It is also read
+// again when it is the one of a system keyspace the operator has started
+// managing since. The last one read is kept if the schema cannot be read.
+func (rc *ReconciliationContext) refreshMaxReplicationFactor(force bool) error {
+	dc := rc.Datacenter
+	if last := dc.Status.MaxReplicationFactor; !force && last != nil &&
+		!rc.managesSystemKeyspace(last.Keyspace) &&
+		time.Since(last.LastCheckTime.Time) < replicationFactorRefreshPeriod {
+		return nil
+	}
+
+	max, err := rc.readMaxReplicationFactor()
+	if err != nil {
+		rc.ReqLogger.Info("Could not read the replication factors of the keyspaces, relying on the last ones read",
+			"error", err.Error())
+		return nil
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.MaxReplicationFactor = max
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching datacenter status for the replication factor")
+		return err
+	}
+	return nil
+}
+
+// updateReplicationFactorCondition sets the ReplicationFactorUnsafe condition
+// with reason when size is below the highest replication factor of the
+// keyspaces, and clears it otherwise. It returns whether size is unsafe.
+func (rc *ReconciliationContext) updateReplicationFactorCondition(size int32, reason string) (bool, error) {
+	dc := rc.Datacenter
+	max := dc.Status.MaxReplicationFactor
+	unsafe := max != nil && size < max.ReplicationFactor
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	changed := false
+	if unsafe {
+		var message string
+		if reason == scaleDownBelowReplicationFactorReason {
+			message = fmt.Sprintf("Not scaling down to %d nodes, below the replication factor %d of keyspace %s",
+				size, max.ReplicationFactor, max.Keyspace)
+		} else {
+			message = fmt.Sprintf("The datacenter has %d nodes, below the replication factor %d of keyspace %s",
+				size, max.ReplicationFactor, max.Keyspace)
+		}
+
+		// The reason and the message change with the size while the condition
+		// stays True, which is warned about again
+		current, _ := dc.GetCondition(api.DatacenterReplicationFactorUnsafe)
+		changed = rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterReplicationFactorUnsafe,
+			corev1.ConditionTrue, reason, message))
+		if current.Status != corev1.ConditionTrue || current.Reason != reason || current.Message != message {
+			rc.Recorder.Event(dc, corev1.EventTypeWarning, events.ReplicationFactorUnsafe, message)
+		}
+	} else if dc.GetConditionStatus(api.DatacenterReplicationFactorUnsafe) == corev1.ConditionTrue {
+		changed = rc.setCondition(api.NewDatacenterCondition(api.DatacenterReplicationFactorUnsafe, corev1.ConditionFalse))
+	}
+
+	if changed {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			rc.ReqLogger.Error(err, "error patching datacenter status for the replication factor")
+			return unsafe, err
+		}
+	}
+	return unsafe, nil
+}
+
+// CheckReplicationFactor keeps status.maxReplicationFactor, which the webhook
+// validates scale downs against, up to date, and warns with the
+// ReplicationFactorUnsafe condition while the datacenter has fewer nodes than
+// the replication factor of one of its keyspaces
+func (rc *ReconciliationContext) CheckReplicationFactor() result.ReconcileResult {
+	if rc.IsStopped() {
+		return result.Continue()
+	}
+
+	if err := rc.refreshMaxReplicationFactor(false); err != nil {
+		return result.Error(err)
+	}
+
+	if _, err := rc.updateReplicationFactorCondition(rc.Datacenter.Spec.Size, sizeBelowReplicationFactorReason); err != nil {
+		return result.Error(err)
+	}
+	return result.Continue()
+}
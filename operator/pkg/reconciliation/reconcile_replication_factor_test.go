@@ -0,0 +1,118 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+)
+
+func TestReadMaxReplicationFactor(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.ManageSystemKeyspaces = true
+	setupKeyspacesTest(rc, map[string]string{
+		"system":      `{"class": "org.apache.cassandra.locator.LocalStrategy"}`,
+		"system_auth": `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "3"}`,
+		"ks":          `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "2", "dc2": "5"}`,
+		"simple":      `{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "1"}`,
+		"simple_wide": `{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "5"}`,
+	})
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-a")}
+
+	max, err := rc.readMaxReplicationFactor()
+	assert.NoError(t, err)
+	assert.Equal(t, "ks", max.Keyspace, "the managed system keyspaces, the other datacenters and SimpleStrategy do not count")
+	assert.Equal(t, int32(2), max.ReplicationFactor)
+}
+
+func TestCheckReplicationFactor(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Size = 2
+	setupKeyspacesTest(rc, map[string]string{
+		"ks": `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "3"}`,
+	})
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-a")}
+
+	assert.False(t, rc.CheckReplicationFactor().Completed())
+	assert.Equal(t, &api.KeyspaceReplicationFactor{
+		Keyspace:          "ks",
+		ReplicationFactor: 3,
+		LastCheckTime:     dc.Status.MaxReplicationFactor.LastCheckTime,
+	}, dc.Status.MaxReplicationFactor)
+	condition, _ := dc.GetCondition(api.DatacenterReplicationFactorUnsafe)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Equal(t, sizeBelowReplicationFactorReason, condition.Reason)
+
+	unsafe, err := rc.updateReplicationFactorCondition(1, scaleDownBelowReplicationFactorReason)
+	assert.NoError(t, err)
+	assert.True(t, unsafe)
+	condition, _ = dc.GetCondition(api.DatacenterReplicationFactorUnsafe)
+	assert.Equal(t, scaleDownBelowReplicationFactorReason, condition.Reason)
+	assert.Contains(t, condition.Message, "Not scaling down to 1 nodes")
+
+	// The replication factor is not read again within the refresh period
+	dc.Spec.Size = 3
+	rc.dcPods = nil
+	assert.False(t, rc.CheckReplicationFactor().Completed())
+	assert.Equal(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterReplicationFactorUnsafe))
+}
+
+func TestDecommissionNodes_BelowReplicationFactor(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Size = 2
+	dc.Spec.ManageSystemKeyspaces = true
+	mockHttpClient := setupKeyspacesTest(rc, map[string]string{
+		"system_auth": `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "3"}`,
+		"ks":          `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "3"}`,
+	})
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-a")}
+	replicas := int32(3)
+	rc.statefulSets = []*appsv1.StatefulSet{{Spec: appsv1.StatefulSetSpec{Replicas: &replicas}}}
+
+	// The system keyspaces are not lowered for a scale down that is refused
+	// and the steps after the scale down go on
+	assert.False(t, rc.DecommissionNodes(httphelper.CassMetadataEndpoints{}).Completed())
+	assert.Equal(t, 60, rc.requeueSeconds)
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterReplicationFactorUnsafe))
+	assert.Empty(t, alteredKeyspaces(t, mockHttpClient))
+}
+
+func TestCheckReplicationFactor_StartsManagingSystemKeyspaces(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Size = 2
+	setupKeyspacesTest(rc, map[string]string{
+		"system_traces": `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "3"}`,
+		"ks":            `{"class": "org.apache.cassandra.locator.NetworkTopologyStrategy", "` + dc.Name + `": "1"}`,
+	})
+	rc.dcPods = []*corev1.Pod{makeReadyPod("pod-a")}
+
+	assert.False(t, rc.CheckReplicationFactor().Completed())
+	assert.Equal(t, "system_traces", dc.Status.MaxReplicationFactor.Keyspace)
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterReplicationFactorUnsafe))
+
+	// The replication factor of a keyspace the operator now manages is not
+	// relied on, even within the refresh period
+	dc.Spec.ManageSystemKeyspaces = true
+	assert.False(t, rc.CheckReplicationFactor().Completed())
+	assert.Equal(t, "ks", dc.Status.MaxReplicationFactor.Keyspace)
+	assert.Equal(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterReplicationFactorUnsafe))
+}
@@ -0,0 +1,102 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+)
+
+const schemaDisagreementReason = "SchemaVersionsDiffer"
+
+// How often the schema versions are checked again while they disagree
+const schemaAgreementPollSeconds = 10
+
+// schemaVersions returns the schema versions of the live nodes of the
+// cluster, with the addresses of the nodes that have each of them
+func schemaVersions(endpointData httphelper.CassMetadataEndpoints) map[string][]string {
+	versions := map[string][]string{}
+	for _, state := range endpointData.Entity {
+		if state.IsAlive != "true" || state.Schema == "" {
+			continue
+		}
+		versions[state.Schema] = append(versions[state.Schema], state.GetRpcAddress())
+	}
+	return versions
+}
+
+// isTopologyChangePending tells whether the racks are about to be scaled up,
+// or the datacenter scaled down
+func (rc *ReconciliationContext) isTopologyChangePending() bool {
+	if rc.IsStopped() {
+		return false
+	}
+
+	var currentSize int32
+	for idx, rackInfo := range rc.desiredRackInformation {
+		if idx >= len(rc.statefulSets) || rc.statefulSets[idx] == nil {
+			continue
+		}
+		replicas := *rc.statefulSets[idx].Spec.Replicas
+		if replicas < int32(rackInfo.NodeCount) {
+			return true
+		}
+		currentSize += replicas
+	}
+	return currentSize > rc.Datacenter.Spec.Size
+}
+
+// CheckSchemaAgreement holds back the scaling of the datacenter while the live
+// nodes of the cluster disagree on the version of the schema, as nodes that
+// join or leave the ring then can end up with a stale schema. It sets the
+// SchemaDisagreement condition while the scaling waits.
+func (rc *ReconciliationContext) CheckSchemaAgreement(endpointData httphelper.CassMetadataEndpoints) result.ReconcileResult {
+	dc := rc.Datacenter
+	versions := schemaVersions(endpointData)
+
+	if len(versions) <= 1 || !rc.isTopologyChangePending() {
+		if dc.GetConditionStatus(api.DatacenterSchemaDisagreement) == corev1.ConditionTrue {
+			dcPatch := client.MergeFrom(dc.DeepCopy())
+			rc.setCondition(api.NewDatacenterCondition(api.DatacenterSchemaDisagreement, corev1.ConditionFalse))
+			if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+				rc.ReqLogger.Error(err, "error patching datacenter status for schema agreement")
+				return result.Error(err)
+			}
+		}
+		return result.Continue()
+	}
+
+	var described []string
+	for version, addresses := range versions {
+		sort.Strings(addresses)
+		described = append(described, fmt.Sprintf("%s on %s", version, strings.Join(addresses, ", ")))
+	}
+	sort.Strings(described)
+	message := "Waiting for the schema versions to agree before scaling: " + strings.Join(described, "; ")
+	rc.ReqLogger.Info(message)
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	disagreeing := rc.conditionTransitions(api.DatacenterSchemaDisagreement, corev1.ConditionTrue)
+	if rc.setCondition(api.NewDatacenterConditionWithReason(api.DatacenterSchemaDisagreement, corev1.ConditionTrue,
+		schemaDisagreementReason, message)) {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			rc.ReqLogger.Error(err, "error patching datacenter status for schema agreement")
+			return result.Error(err)
+		}
+	}
+	if disagreeing {
+		rc.Recorder.Event(dc, corev1.EventTypeWarning, events.WaitingForSchemaAgreement, message)
+	}
+
+	return result.RequeueSoon(schemaAgreementPollSeconds)
+}
@@ -0,0 +1,51 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+)
+
+func setupSchemaAgreementTest(rc *ReconciliationContext, replicas int32, desired int) {
+	rc.Datacenter.Spec.Size = int32(desired)
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: desired}}
+	rc.statefulSets = []*appsv1.StatefulSet{{Spec: appsv1.StatefulSetSpec{Replicas: &replicas}}}
+}
+
+func TestCheckSchemaAgreement(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	endpointData := httphelper.CassMetadataEndpoints{
+		Entity: []httphelper.EndpointState{
+			{RpcAddress: "10.0.0.1", IsAlive: "true", Schema: "schema-1"},
+			{RpcAddress: "10.0.0.2", IsAlive: "true", Schema: "schema-2"},
+		},
+	}
+
+	// Nothing waits without a topology change
+	setupSchemaAgreementTest(rc, 2, 2)
+	assert.False(t, rc.CheckSchemaAgreement(endpointData).Completed())
+
+	setupSchemaAgreementTest(rc, 2, 3)
+	assert.True(t, rc.CheckSchemaAgreement(endpointData).Completed(), "scaling up should wait")
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterSchemaDisagreement))
+	condition, _ := rc.Datacenter.GetCondition(api.DatacenterSchemaDisagreement)
+	assert.Contains(t, condition.Message, "schema-2 on 10.0.0.2")
+
+	setupSchemaAgreementTest(rc, 3, 2)
+	assert.True(t, rc.CheckSchemaAgreement(endpointData).Completed(), "scaling down should wait")
+
+	// The nodes that are down do not count
+	endpointData.Entity[1].IsAlive = "false"
+	assert.False(t, rc.CheckSchemaAgreement(endpointData).Completed())
+	assert.Equal(t, corev1.ConditionFalse, rc.Datacenter.GetConditionStatus(api.DatacenterSchemaDisagreement))
+}
@@ -9,6 +9,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
 )
@@ -16,7 +17,6 @@ import (
 func (rc *ReconciliationContext) CreateHeadlessServices() result.ReconcileResult {
 	// unpacking
 	logger := rc.ReqLogger
-	client := rc.Client
 
 	for idx := range rc.Services {
 		service := rc.Services[idx]
@@ -30,7 +30,7 @@ func (rc *ReconciliationContext) CreateHeadlessServices() result.ReconcileResult
 			return result.Error(err)
 		}
 
-		if err := client.Create(rc.Ctx, service); err != nil {
+		if err := rc.createResource(service); err != nil {
 			logger.Error(err, "Could not create headless service")
 
 			return result.Error(err)
@@ -61,7 +61,7 @@ func (rc *ReconciliationContext) CheckHeadlessServices() result.ReconcileResult
 
 	services := []*corev1.Service{cqlService, seedService, allPodsService}
 
-	if len(dc.Spec.AdditionalSeeds) > 0 {
+	if dc.HasAdditionalSeeds() {
 		additionalSeedService := newAdditionalSeedServiceForCassandraDatacenter(dc)
 		services = append(services, additionalSeedService)
 	}
@@ -76,6 +76,12 @@ func (rc *ReconciliationContext) CheckHeadlessServices() result.ReconcileResult
 	for idx := range services {
 		desiredSvc := services[idx]
 
+		// The datacenter that migrates the clients of this one owns its
+		// client service now
+		if dc.Annotations[api.MigratingToAnnotation] != "" && desiredSvc.Name == dc.GetDatacenterServiceName() {
+			continue
+		}
+
 		// Set CassandraDatacenter dc as the owner and controller
 		err := setControllerReference(dc, desiredSvc, rc.Scheme)
 		if err != nil {
@@ -102,10 +108,19 @@ func (rc *ReconciliationContext) CheckHeadlessServices() result.ReconcileResult
 		} else {
 			// if we found the service already, check if they need updating
 			if !utils.ResourcesHaveSameHash(currentService, desiredSvc) {
-				resourceVersion := currentService.GetResourceVersion()
-				// preserve any labels and annotations that were added to the service post-creation
-				desiredSvc.Labels = utils.MergeMap(map[string]string{}, currentService.Labels, desiredSvc.Labels)
-				desiredSvc.Annotations = utils.MergeMap(map[string]string{}, currentService.Annotations, desiredSvc.Annotations)
+				// The cluster IP of a service cannot change, so a service that
+				// becomes headless, or stops being so, is recreated
+				if (currentService.Spec.ClusterIP == corev1.ClusterIPNone) != (desiredSvc.Spec.ClusterIP == corev1.ClusterIPNone) {
+					logger.Info("Recreating service to change whether it is headless",
+						"service", nsName)
+					if err := client.Delete(rc.Ctx, currentService); err != nil && !errors.IsNotFound(err) {
+						logger.Error(err, "Unable to delete service",
+							"service", nsName)
+						return result.Error(err)
+					}
+					createNeeded = append(createNeeded, desiredSvc)
+					continue
+				}
 
 				// ClusterIP may have been updated for the NodePort service
 				// so we need to preserve it.  Copying should not break any of
@@ -116,13 +131,9 @@ func (rc *ReconciliationContext) CheckHeadlessServices() result.ReconcileResult
 					"service", currentService,
 					"desired", desiredSvc)
 
-				desiredSvc.DeepCopyInto(currentService)
-
-				currentService.SetResourceVersion(resourceVersion)
-
-				if err := client.Update(rc.Ctx, currentService); err != nil {
+				if err := rc.updateResource(desiredSvc, currentService); err != nil {
 					logger.Error(err, "Unable to update service",
-						"service", currentService)
+						"service", nsName)
 					return result.Error(err)
 				}
 			}
@@ -136,3 +147,115 @@ func (rc *ReconciliationContext) CheckHeadlessServices() result.ReconcileResult
 
 	return result.Continue()
 }
+
+// checkPodServices creates a service built by newService for each server pod
+// when enabled, labeled with the name of its pod, and deletes the services of
+// the label whose pods were scaled down. The service of a pod that is only
+// missing while it restarts is kept, so that a load balancer keeps its
+// address. It returns the services of the pods.
+func (rc *ReconciliationContext) checkPodServices(label string, enabled bool, newService func(*api.CassandraDatacenter, string) *corev1.Service) (map[string]*corev1.Service, error) {
+	logger := rc.ReqLogger
+	dc := rc.Datacenter
+
+	services := &corev1.ServiceList{}
+	err := rc.Client.List(rc.Ctx, services,
+		client.InNamespace(dc.Namespace),
+		client.MatchingLabels(dc.GetDatacenterLabels()))
+	if err != nil {
+		logger.Error(err, "error listing the services of the pods", "label", label)
+		return nil, err
+	}
+
+	podServices := map[string]*corev1.Service{}
+	for idx := range services.Items {
+		service := &services.Items[idx]
+		if podName, ok := service.Labels[label]; ok {
+			podServices[podName] = service
+		}
+	}
+
+	pods := map[string]bool{}
+	if enabled {
+		for _, pod := range rc.dcPods {
+			pods[pod.Name] = true
+		}
+	}
+
+	for podName, service := range podServices {
+		if pods[podName] || (enabled && !rc.isPodScaledDown(podName)) {
+			continue
+		}
+		logger.Info("deleting the service of a pod", "service", service.Name)
+		if err := rc.Client.Delete(rc.Ctx, service); err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		delete(podServices, podName)
+	}
+
+	for podName := range pods {
+		if _, ok := podServices[podName]; ok {
+			continue
+		}
+		service := newService(dc, podName)
+		if err := setControllerReference(dc, service, rc.Scheme); err != nil {
+			return nil, err
+		}
+		if err := rc.createResource(service); err != nil {
+			logger.Error(err, "could not create the service of a pod", "service", service.Name)
+			return nil, err
+		}
+		rc.Recorder.Eventf(dc, "Normal", "CreatedResource", "Created service %s", service.Name)
+		podServices[podName] = service
+	}
+
+	return podServices, nil
+}
+
+// CheckPodLoadBalancers creates the load balancer service of each server pod
+// with spec.networking.nodePort.loadBalancerPerPod, and deletes the ones of
+// the pods that are gone. Once a load balancer has an address, it is recorded
+// on its pod, whose init container waits for it to set the
+// broadcast_rpc_address of the node.
+func (rc *ReconciliationContext) CheckPodLoadBalancers() result.ReconcileResult {
+	logger := rc.ReqLogger
+	dc := rc.Datacenter
+
+	logger.Info("reconcile_services::CheckPodLoadBalancers")
+
+	podServices, err := rc.checkPodServices(api.LoadBalancerPodLabel, dc.IsLoadBalancerPerPodEnabled(), newLoadBalancerServiceForPod)
+	if err != nil {
+		return result.Error(err)
+	}
+
+	for _, pod := range rc.dcPods {
+		service, ok := podServices[pod.Name]
+		if !ok {
+			continue
+		}
+
+		address := ""
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if ingress.IP != "" {
+				address = ingress.IP
+			} else {
+				address = ingress.Hostname
+			}
+			break
+		}
+		if address == "" || pod.Annotations[api.BroadcastRpcAddressAnnotation] == address {
+			continue
+		}
+
+		logger.Info("recording the address of the load balancer of a pod", "pod", pod.Name, "address", address)
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[api.BroadcastRpcAddressAnnotation] = address
+		if err := rc.Client.Patch(rc.Ctx, pod, patch); err != nil {
+			return result.Error(err)
+		}
+	}
+
+	return result.Continue()
+}
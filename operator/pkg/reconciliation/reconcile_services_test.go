@@ -9,8 +9,13 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
 	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
 )
 
@@ -106,3 +111,69 @@ func TestCreateHeadlessService_ClientReturnsError(t *testing.T) {
 
 	mockClient.AssertExpectations(t)
 }
+
+func TestCheckPodLoadBalancers(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Networking = &api.NetworkingConfig{
+		NodePort: &api.NodePortConfig{Native: 30042, LoadBalancerPerPod: true},
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-0",
+			Namespace: dc.Namespace,
+			Labels:    dc.GetDatacenterLabels(),
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+	rc.dcPods = []*corev1.Pod{pod}
+
+	assert.False(t, rc.CheckPodLoadBalancers().Completed())
+	service := &corev1.Service{}
+	key := types.NamespacedName{Name: dc.GetPodLoadBalancerServiceName("pod-0"), Namespace: dc.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, service))
+	assert.NotContains(t, pod.Annotations, api.BroadcastRpcAddressAnnotation)
+
+	// The address of the load balancer is recorded on the pod
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}}
+	assert.NoError(t, rc.Client.Update(rc.Ctx, service))
+	assert.False(t, rc.CheckPodLoadBalancers().Completed())
+	assert.Equal(t, "203.0.113.10", pod.Annotations[api.BroadcastRpcAddressAnnotation])
+	assert.Equal(t, "203.0.113.10", getRpcAddress(dc, pod))
+
+	// The service of a pod that is recreated is kept
+	replicas := int32(1)
+	rc.statefulSets = []*appsv1.StatefulSet{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: dc.Namespace},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	}}
+	rc.dcPods = nil
+	assert.False(t, rc.CheckPodLoadBalancers().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, &corev1.Service{}))
+
+	// The services of the pods that were scaled down are deleted
+	replicas = 0
+	assert.False(t, rc.CheckPodLoadBalancers().Completed())
+	assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, key, &corev1.Service{})))
+}
+
+func TestReconcileHeadlessService_ChangeType(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	assert.False(t, rc.CheckHeadlessServices().Completed())
+	key := types.NamespacedName{Name: rc.Datacenter.GetDatacenterServiceName(), Namespace: rc.Datacenter.Namespace}
+	service := &corev1.Service{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, service))
+	assert.Equal(t, corev1.ClusterIPNone, service.Spec.ClusterIP)
+
+	// A headless service cannot be updated to a load balancer, it is recreated
+	rc.Datacenter.Spec.AdditionalServiceConfig.DatacenterService.Type = corev1.ServiceTypeLoadBalancer
+	assert.False(t, rc.CheckHeadlessServices().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, key, service))
+	assert.Equal(t, corev1.ServiceTypeLoadBalancer, service.Spec.Type)
+	assert.Equal(t, "", service.Spec.ClusterIP)
+}
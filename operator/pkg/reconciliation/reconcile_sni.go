@@ -0,0 +1,166 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/oplabels"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+const (
+	ingressClassAnnotation   = "kubernetes.io/ingress.class"
+	sslPassthroughAnnotation = "nginx.ingress.kubernetes.io/ssl-passthrough"
+)
+
+// newSniServiceForPod creates the ClusterIP service owned by the
+// CassandraDatacenter the SNI Ingress routes the host name of a pod to
+func newSniServiceForPod(dc *api.CassandraDatacenter, podName string) *corev1.Service {
+	service := makeGenericHeadlessService(dc)
+	service.ObjectMeta.Name = dc.GetPodSniServiceName(podName)
+	service.ObjectMeta.Labels[api.SniPodLabel] = podName
+	service.Spec.Selector["statefulset.kubernetes.io/pod-name"] = podName
+
+	service.Spec.Type = corev1.ServiceTypeClusterIP
+	service.Spec.ClusterIP = ""
+
+	nativePort := dc.GetNativePort()
+	if dc.IsNodePortEnabled() {
+		nativePort = dc.GetNodePortNativePort()
+	}
+	service.Spec.Ports = []corev1.ServicePort{
+		{
+			Name: "native", Port: int32(nativePort), TargetPort: intstr.FromInt(nativePort),
+		},
+	}
+
+	addAdditionalMetadata(dc, service)
+
+	return service
+}
+
+// newSniIngressForCassandraDatacenter creates the Ingress owned by the
+// CassandraDatacenter that routes the SNI host name of each pod to the service
+// of the pod. The ingress controller passes the TLS connections through, so
+// the nodes terminate them with the certificate of the client encryption.
+func newSniIngressForCassandraDatacenter(dc *api.CassandraDatacenter, podNames []string) *networkingv1beta1.Ingress {
+	labels := dc.GetDatacenterLabels()
+	oplabels.AddManagedByLabel(labels)
+
+	annotations := map[string]string{}
+	for key, value := range dc.Spec.Networking.SNI.Annotations {
+		annotations[key] = value
+	}
+	annotations[ingressClassAnnotation] = dc.GetSniIngressClass()
+	annotations[sslPassthroughAnnotation] = "true"
+
+	nativePort := dc.GetNativePort()
+	if dc.IsNodePortEnabled() {
+		nativePort = dc.GetNodePortNativePort()
+	}
+
+	var rules []networkingv1beta1.IngressRule
+	for _, podName := range podNames {
+		rules = append(rules, networkingv1beta1.IngressRule{
+			Host: dc.GetSniHostname(podName),
+			IngressRuleValue: networkingv1beta1.IngressRuleValue{
+				HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+					Paths: []networkingv1beta1.HTTPIngressPath{{
+						Backend: networkingv1beta1.IngressBackend{
+							ServiceName: dc.GetPodSniServiceName(podName),
+							ServicePort: intstr.FromInt(nativePort),
+						},
+					}},
+				},
+			},
+		})
+	}
+
+	ingress := &networkingv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        dc.GetSniIngressName(),
+			Namespace:   dc.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: networkingv1beta1.IngressSpec{Rules: rules},
+	}
+
+	addAdditionalMetadata(dc, ingress)
+
+	utils.AddHashAnnotation(ingress)
+
+	return ingress
+}
+
+// CheckSniIngress creates the services of the pods and the Ingress of their
+// SNI host names with spec.networking.sni, and keeps the rules of the Ingress
+// in line with the pods. Without it, they are deleted.
+func (rc *ReconciliationContext) CheckSniIngress() result.ReconcileResult {
+	dc := rc.Datacenter
+	if _, err := rc.checkPodServices(api.SniPodLabel, dc.IsSniEnabled(), newSniServiceForPod); err != nil {
+		return result.Error(err)
+	}
+
+	key := types.NamespacedName{Name: dc.GetSniIngressName(), Namespace: dc.Namespace}
+	currentIngress := &networkingv1beta1.Ingress{}
+	err := rc.Client.Get(rc.Ctx, key, currentIngress)
+	if err != nil && !errors.IsNotFound(err) {
+		return result.Error(err)
+	}
+	found := err == nil
+
+	if !dc.IsSniEnabled() {
+		if found && oplabels.HasManagedByCassandraOperatorLabel(currentIngress.Labels) {
+			rc.ReqLogger.Info("deleting the SNI ingress", "ingress", key.Name)
+			if err := rc.Client.Delete(rc.Ctx, currentIngress); err != nil && !errors.IsNotFound(err) {
+				return result.Error(err)
+			}
+		}
+		return result.Continue()
+	}
+
+	rc.ReqLogger.Info("reconcile_sni::CheckSniIngress")
+
+	var podNames []string
+	for _, pod := range rc.dcPods {
+		podNames = append(podNames, pod.Name)
+	}
+	sort.Strings(podNames)
+
+	desiredIngress := newSniIngressForCassandraDatacenter(dc, podNames)
+	if err := setControllerReference(dc, desiredIngress, rc.Scheme); err != nil {
+		return result.Error(err)
+	}
+
+	if !found {
+		rc.ReqLogger.Info("creating the SNI ingress", "ingress", key.Name)
+		if err := rc.createResource(desiredIngress); err != nil {
+			return result.Error(err)
+		}
+		rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CreatedResource,
+			"Created ingress %s", key.Name)
+		return result.Continue()
+	}
+
+	if utils.ResourcesHaveSameHash(currentIngress, desiredIngress) {
+		return result.Continue()
+	}
+	rc.ReqLogger.Info("updating the SNI ingress", "ingress", key.Name)
+	if err := rc.updateResource(desiredIngress, currentIngress); err != nil {
+		return result.Error(err)
+	}
+	return result.Continue()
+}
@@ -0,0 +1,91 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestNewSniIngressForCassandraDatacenter(t *testing.T) {
+	dc := &api.CassandraDatacenter{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc1", Namespace: "ns1"},
+		Spec: api.CassandraDatacenterSpec{
+			ClusterName: "cluster1",
+			Networking: &api.NetworkingConfig{
+				SNI: &api.SNIConfig{
+					Domain:       "cassandra.example.com",
+					IngressClass: "nginx-external",
+					Annotations:  map[string]string{"example.com/team": "data"},
+				},
+			},
+		},
+	}
+
+	ingress := newSniIngressForCassandraDatacenter(dc, []string{"cluster1-dc1-r1-sts-0"})
+	assert.Equal(t, "cluster1-dc1-sni-ingress", ingress.Name)
+	assert.Equal(t, "nginx-external", ingress.Annotations[ingressClassAnnotation])
+	assert.Equal(t, "true", ingress.Annotations[sslPassthroughAnnotation])
+	assert.Equal(t, "data", ingress.Annotations["example.com/team"])
+
+	assert.Len(t, ingress.Spec.Rules, 1)
+	rule := ingress.Spec.Rules[0]
+	assert.Equal(t, "cluster1-dc1-r1-sts-0.cassandra.example.com", rule.Host)
+	backend := rule.HTTP.Paths[0].Backend
+	assert.Equal(t, "cluster1-dc1-r1-sts-0-sni-service", backend.ServiceName)
+	assert.Equal(t, 9042, backend.ServicePort.IntValue())
+
+	service := newSniServiceForPod(dc, "cluster1-dc1-r1-sts-0")
+	assert.Equal(t, "cluster1-dc1-r1-sts-0-sni-service", service.Name)
+	assert.Equal(t, corev1.ServiceTypeClusterIP, service.Spec.Type)
+	assert.Equal(t, "", service.Spec.ClusterIP)
+	assert.Equal(t, "cluster1-dc1-r1-sts-0", service.Spec.Selector["statefulset.kubernetes.io/pod-name"])
+}
+
+func TestCheckSniIngress(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.Networking = &api.NetworkingConfig{
+		SNI: &api.SNIConfig{Domain: "cassandra.example.com"},
+	}
+	rc.dcPods = []*corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: dc.Namespace},
+	}}
+
+	assert.False(t, rc.CheckSniIngress().Completed())
+	serviceKey := types.NamespacedName{Name: dc.GetPodSniServiceName("pod-0"), Namespace: dc.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, serviceKey, &corev1.Service{}))
+	ingress := &networkingv1beta1.Ingress{}
+	ingressKey := types.NamespacedName{Name: dc.GetSniIngressName(), Namespace: dc.Namespace}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, ingressKey, ingress))
+	assert.Len(t, ingress.Spec.Rules, 1)
+
+	// The rules follow the pods
+	rc.dcPods = append(rc.dcPods, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: dc.Namespace},
+	})
+	assert.False(t, rc.CheckSniIngress().Completed())
+	assert.NoError(t, rc.Client.Get(rc.Ctx, ingressKey, ingress))
+	assert.Len(t, ingress.Spec.Rules, 2)
+	assert.Equal(t, "pod-1.cassandra.example.com", ingress.Spec.Rules[1].Host)
+
+	assert.NoError(t, rc.UpdateCassandraNodeStatus())
+	assert.Equal(t, "pod-1.cassandra.example.com", dc.Status.NodeStatuses["pod-1"].SniHostname)
+
+	// Without spec.networking.sni, the services and the ingress are deleted
+	dc.Spec.Networking = nil
+	assert.False(t, rc.CheckSniIngress().Completed())
+	assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, serviceKey, &corev1.Service{})))
+	assert.True(t, errors.IsNotFound(rc.Client.Get(rc.Ctx, ingressKey, &networkingv1beta1.Ingress{})))
+}
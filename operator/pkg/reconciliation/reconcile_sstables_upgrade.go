@@ -0,0 +1,208 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/jobtracker"
+)
+
+// sstablesUpgradeKey names the upgradesstables that follows a major upgrade in
+// the job annotations of the pods
+const sstablesUpgradeKey = "upgradesstables-after-upgrade"
+
+// How often a running upgradesstables is checked on
+const sstablesUpgradePollSeconds = 30
+
+// majorVersion returns the major version of a server version
+func majorVersion(version string) string {
+	return strings.SplitN(version, ".", 2)[0]
+}
+
+// areStatefulSetsUpdated returns whether every pod of the racks runs the
+// current template of its StatefulSet
+func (rc *ReconciliationContext) areStatefulSetsUpdated() bool {
+	for _, statefulSet := range rc.statefulSets {
+		if statefulSet == nil {
+			return false
+		}
+		status := statefulSet.Status
+		if statefulSet.Generation != status.ObservedGeneration ||
+			status.Replicas != status.UpdatedReplicas ||
+			status.CurrentRevision != status.UpdateRevision {
+			return false
+		}
+	}
+	return true
+}
+
+// podNamesByRack returns the names of the pods of the datacenter, rack by
+// rack, in the order the operations that go through them one rack at a time
+// run on them, such as the upgrade of their sstables
+func (rc *ReconciliationContext) podNamesByRack() []string {
+	var names []string
+	for _, rack := range rc.Datacenter.GetRacks() {
+		rackPods := FilterPodListByLabels(rc.dcPods, rc.Datacenter.GetRackLabels(rack.Name))
+		names = append(names, sortedPodNames(rackPods)...)
+	}
+	return names
+}
+
+// CheckSSTablesUpgrade records the server version once every node was upgraded
+// to it, and after a major upgrade runs upgradesstables on the nodes with
+// spec.upgradeSSTables. The nodes of a rack are done before the next rack, a
+// few at a time, and the progress is kept in the status. While it runs, the
+// reconciliation is requeued to check on it.
+func (rc *ReconciliationContext) CheckSSTablesUpgrade() result.ReconcileResult {
+	dc := rc.Datacenter
+	logger := rc.ReqLogger
+
+	if dc.Status.ServerVersion != dc.Spec.ServerVersion && rc.areStatefulSetsUpdated() {
+		dcPatch := client.MergeFrom(dc.DeepCopy())
+		fromVersion := dc.Status.ServerVersion
+		dc.Status.ServerVersion = dc.Spec.ServerVersion
+
+		if config := dc.Spec.UpgradeSSTables; config != nil && config.Enabled &&
+			fromVersion != "" && majorVersion(fromVersion) != majorVersion(dc.Spec.ServerVersion) {
+			dc.Status.SSTablesUpgrade = &api.SSTablesUpgradeProgress{
+				FromVersion: fromVersion,
+				ToVersion:   dc.Spec.ServerVersion,
+				StartTime:   metav1.Now(),
+				PendingPods: rc.podNamesByRack(),
+			}
+			rc.setCondition(api.NewDatacenterCondition(api.DatacenterUpgradingSSTables, corev1.ConditionTrue))
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.StartedSSTablesUpgrade,
+				"Upgrading the sstables of %d pods from %s to %s",
+				len(dc.Status.SSTablesUpgrade.PendingPods), fromVersion, dc.Spec.ServerVersion)
+		}
+
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			logger.Error(err, "error patching datacenter status for the server version")
+			return result.Error(err)
+		}
+	}
+
+	progress := dc.Status.SSTablesUpgrade
+	if progress == nil || progress.CompletionTime != nil {
+		return result.Continue()
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	rc.progressSSTablesUpgrade(progress)
+
+	if len(progress.PendingPods) == 0 {
+		now := metav1.Now()
+		progress.CompletionTime = &now
+		rc.setCondition(api.NewDatacenterCondition(api.DatacenterUpgradingSSTables, corev1.ConditionFalse))
+		if len(progress.FailedPods) > 0 {
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.FailedSSTablesUpgrade,
+				"Failed to upgrade the sstables of pods %s", strings.Join(progress.FailedPods, ", "))
+		} else {
+			rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.CompletedSSTablesUpgrade,
+				"Upgraded the sstables to %s", progress.ToVersion)
+		}
+	}
+
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		logger.Error(err, "error patching datacenter status for the sstables upgrade")
+		return result.Error(err)
+	}
+
+	// The upgrade can take hours, and its progress is kept in the status, so
+	// the steps after this one are not held back
+	if progress.CompletionTime == nil {
+		rc.requeueAfter(sstablesUpgradePollSeconds)
+	}
+	return result.Continue()
+}
+
+// rackOfPodName returns the rack of the StatefulSet the pod belongs to, empty
+// if there is none
+func (rc *ReconciliationContext) rackOfPodName(podName string) string {
+	idx := strings.LastIndex(podName, "-")
+	if idx < 0 {
+		return ""
+	}
+	for _, statefulSet := range rc.statefulSets {
+		if statefulSet != nil && statefulSet.Name == podName[:idx] {
+			return statefulSet.Labels[api.RackLabel]
+		}
+	}
+	return ""
+}
+
+// progressSSTablesUpgrade runs upgradesstables on the next pending pods of the
+// rack of the first one, or checks on the jobs it is running as, and removes
+// the pods that are done from the pending ones
+func (rc *ReconciliationContext) progressSSTablesUpgrade(progress *api.SSTablesUpgradeProgress) {
+	dc := rc.Datacenter
+	tracker := &jobtracker.Tracker{Client: rc.Client, MgmtClient: &rc.NodeMgmtClient}
+
+	jobs := -1
+	if dc.Spec.UpgradeSSTables != nil && dc.Spec.UpgradeSSTables.Jobs != nil {
+		jobs = *dc.Spec.UpgradeSSTables.Jobs
+	}
+	concurrency := 1
+	if dc.Spec.UpgradeSSTables != nil {
+		concurrency = dc.Spec.UpgradeSSTables.GetConcurrency()
+	}
+
+	rack := ""
+	var pending []string
+	started := 0
+	for _, podName := range progress.PendingPods {
+		pod := findPod(rc.dcPods, podName)
+		if pod == nil {
+			if rc.isPodScaledDown(podName) {
+				// The pods that were scaled down have no sstables to upgrade
+				continue
+			}
+			// A pod that restarts or is rescheduled is upgraded once it is
+			// back, still before the pods of the next racks
+			if rack == "" {
+				rack = rc.rackOfPodName(podName)
+			}
+			pending = append(pending, podName)
+			continue
+		}
+		if rack == "" {
+			rack = pod.Labels[api.RackLabel]
+		}
+		if pod.Labels[api.RackLabel] != rack || started >= concurrency {
+			pending = append(pending, podName)
+			continue
+		}
+		started++
+
+		done, err := tracker.Poll(rc.Ctx, pod, jobtracker.Operation{
+			Key: sstablesUpgradeKey,
+			Submit: func() (string, error) {
+				return rc.NodeMgmtClient.CallUpgradeSSTablesAsyncEndpoint(pod, jobs, "", nil)
+			},
+			Run: func() error {
+				return rc.NodeMgmtClient.CallUpgradeSSTablesEndpoint(pod, jobs, "", nil)
+			},
+		})
+		if !done {
+			if err != nil {
+				rc.ReqLogger.Error(err, "error checking on the sstables upgrade", "pod", podName)
+			}
+			pending = append(pending, podName)
+			continue
+		}
+		if err != nil {
+			rc.ReqLogger.Error(err, "error upgrading the sstables", "pod", podName)
+			progress.FailedPods = append(progress.FailedPods, podName)
+		}
+	}
+	progress.PendingPods = pending
+}
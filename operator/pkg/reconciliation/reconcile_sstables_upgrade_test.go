@@ -0,0 +1,160 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+// setupSSTablesUpgradeTest gives the datacenter two pods in rack r1 and one in
+// rack r2, whose management API runs upgradesstables synchronously
+func setupSSTablesUpgradeTest(rc *ReconciliationContext) *mocks.HttpClient {
+	dc := rc.Datacenter
+	dc.Spec.ServerType = "cassandra"
+	dc.Spec.Racks = []api.Rack{{Name: "r1"}, {Name: "r2"}}
+
+	for _, name := range []string{"r2-0", "r1-1", "r1-0"} {
+		pod := makeReadyPod(name)
+		pod.Labels = dc.GetRackLabels(name[:2])
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v1/ops/tables/sstables/upgrade"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil)
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil && req.URL.Path == "/api/v0/ops/tables/sstables/upgrade"
+			})).
+		Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil)
+
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{
+		Client:   mockHttpClient,
+		Log:      rc.ReqLogger,
+		Protocol: "http",
+	}
+	return mockHttpClient
+}
+
+func TestCheckSSTablesUpgrade_RackByRack(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	mockHttpClient := setupSSTablesUpgradeTest(rc)
+	dc.Spec.ServerVersion = "4.0.1"
+	dc.Status.ServerVersion = "3.11.7"
+	dc.Spec.UpgradeSSTables = &api.UpgradeSSTablesConfig{Enabled: true, Concurrency: 2}
+
+	// Both pods of r1 are done before the pod of r2
+	assert.False(t, rc.CheckSSTablesUpgrade().Completed())
+	assert.Equal(t, sstablesUpgradePollSeconds, rc.requeueSeconds)
+	assert.Equal(t, "4.0.1", dc.Status.ServerVersion)
+	progress := dc.Status.SSTablesUpgrade
+	assert.NotNil(t, progress)
+	assert.Equal(t, "3.11.7", progress.FromVersion)
+	assert.Equal(t, []string{"r2-0"}, progress.PendingPods)
+	assert.Nil(t, progress.CompletionTime)
+	assert.Equal(t, corev1.ConditionTrue, dc.GetConditionStatus(api.DatacenterUpgradingSSTables))
+
+	rc.requeueSeconds = 0
+	assert.False(t, rc.CheckSSTablesUpgrade().Completed())
+	assert.Empty(t, progress.PendingPods)
+	assert.Empty(t, progress.FailedPods)
+	assert.NotNil(t, progress.CompletionTime)
+	assert.Zero(t, rc.requeueSeconds, "a finished upgrade is not checked on")
+	assert.Equal(t, corev1.ConditionFalse, dc.GetConditionStatus(api.DatacenterUpgradingSSTables))
+
+	assert.False(t, rc.CheckSSTablesUpgrade().Completed(), "the upgrade should only run once")
+	mockHttpClient.AssertNumberOfCalls(t, "Do", 6)
+}
+
+func TestCheckSSTablesUpgrade_MinorUpgrade(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	mockHttpClient := setupSSTablesUpgradeTest(rc)
+	dc.Spec.ServerVersion = "3.11.7"
+	dc.Spec.UpgradeSSTables = &api.UpgradeSSTablesConfig{Enabled: true}
+
+	// The first version is only recorded
+	assert.False(t, rc.CheckSSTablesUpgrade().Completed())
+	assert.Equal(t, "3.11.7", dc.Status.ServerVersion)
+	assert.Nil(t, dc.Status.SSTablesUpgrade)
+
+	dc.Spec.ServerVersion = "3.11.10"
+	assert.False(t, rc.CheckSSTablesUpgrade().Completed())
+	assert.Equal(t, "3.11.10", dc.Status.ServerVersion)
+	assert.Nil(t, dc.Status.SSTablesUpgrade, "the sstables should only be upgraded after major upgrades")
+
+	dc.Spec.UpgradeSSTables.Enabled = false
+	dc.Spec.ServerVersion = "4.0.1"
+	assert.False(t, rc.CheckSSTablesUpgrade().Completed())
+	assert.Equal(t, "4.0.1", dc.Status.ServerVersion)
+	assert.Nil(t, dc.Status.SSTablesUpgrade)
+
+	mockHttpClient.AssertNotCalled(t, "Do", mock.Anything)
+}
+
+func TestCheckSSTablesUpgrade_MissingPod(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	setupSSTablesUpgradeTest(rc)
+	for rackName, size := range map[string]int32{"r1": 2, "r2": 1} {
+		replicas := size
+		rc.statefulSets = append(rc.statefulSets, &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: rackName, Labels: dc.GetRackLabels(rackName)},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		})
+	}
+	// r1-0 is being rescheduled, and r2-1 was scaled down
+	rc.dcPods = rc.dcPods[:2]
+	dc.Status.SSTablesUpgrade = &api.SSTablesUpgradeProgress{
+		PendingPods: []string{"r1-0", "r1-1", "r2-0", "r2-1"},
+	}
+
+	assert.False(t, rc.CheckSSTablesUpgrade().Completed())
+	progress := dc.Status.SSTablesUpgrade
+	assert.Equal(t, []string{"r1-0", "r2-0"}, progress.PendingPods,
+		"the next rack should wait for the missing pod, and the pod scaled down should be dropped")
+	assert.Nil(t, progress.CompletionTime)
+
+	pod := makeReadyPod("r1-0")
+	pod.Labels = dc.GetRackLabels("r1")
+	rc.dcPods = append(rc.dcPods, pod)
+	assert.False(t, rc.CheckSSTablesUpgrade().Completed())
+	assert.Equal(t, []string{"r2-0"}, progress.PendingPods)
+
+	assert.False(t, rc.CheckSSTablesUpgrade().Completed())
+	assert.Empty(t, progress.PendingPods)
+	assert.Empty(t, progress.FailedPods)
+	assert.NotNil(t, progress.CompletionTime)
+}
@@ -0,0 +1,142 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+)
+
+// When the resources of the pods change, the statefulset is switched to the
+// OnDelete update strategy and the operator replaces the pods itself. This
+// lets it drain each node before its pod is deleted, and wait for the new pod
+// to be back Up/Normal in the ring before moving on to the next one, rather
+// than relying on the preStop hook finishing within the grace period.
+//
+// podResourcesChanged reports whether the resources of any container differ
+// between the two pod templates.
+func podResourcesChanged(current, desired *corev1.PodTemplateSpec) bool {
+	return !reflect.DeepEqual(containerResources(current), containerResources(desired))
+}
+
+func containerResources(template *corev1.PodTemplateSpec) map[string]corev1.ResourceRequirements {
+	resources := make(map[string]corev1.ResourceRequirements)
+	for _, container := range template.Spec.InitContainers {
+		resources[container.Name] = container.Resources
+	}
+	for _, container := range template.Spec.Containers {
+		resources[container.Name] = container.Resources
+	}
+	return resources
+}
+
+// replaceOutdatedPods drains and deletes one pod of the statefulset that does
+// not run its current revision yet, once every node of the datacenter is up.
+// It returns Continue once all the pods of the statefulset are up to date.
+func (rc *ReconciliationContext) replaceOutdatedPods(statefulSet *appsv1.StatefulSet) result.ReconcileResult {
+	logger := rc.ReqLogger.WithValues("statefulSet", statefulSet.Name)
+	status := statefulSet.Status
+
+	if statefulSet.Generation != status.ObservedGeneration {
+		logger.Info("waiting for the statefulset controller to observe the new pod template")
+		return result.RequeueSoon(10)
+	}
+
+	rackName := statefulSet.Labels[api.RackLabel]
+	var outdated []*corev1.Pod
+	for _, pod := range rc.dcPods {
+		if pod.Labels[api.RackLabel] == rackName &&
+			pod.Labels[appsv1.StatefulSetRevisionLabel] != status.UpdateRevision {
+			outdated = append(outdated, pod)
+		}
+	}
+
+	if len(outdated) == 0 {
+		if status.UpdatedReplicas != status.Replicas || status.ReadyReplicas != status.Replicas {
+			logger.Info("waiting for the replaced pods to be ready")
+			return result.RequeueSoon(10)
+		}
+
+		// Every pod is on the new revision, so go back to the default strategy
+		logger.Info("All pods of the statefulset have been replaced")
+		patch := client.MergeFrom(statefulSet.DeepCopy())
+		statefulSet.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+			Type: appsv1.RollingUpdateStatefulSetStrategyType,
+		}
+		if err := rc.Client.Patch(rc.Ctx, statefulSet, patch); err != nil {
+			logger.Error(err, "error restoring the rolling update strategy of the statefulset")
+			return result.Error(err)
+		}
+		return result.Continue()
+	}
+
+	if !rc.allNodesUpAndNormal() {
+		logger.Info("waiting for every node to be Up/Normal before replacing the next pod")
+		return result.RequeueSoon(10)
+	}
+
+	if wait, err := rc.waitForMaintenanceWindow("replacement of the pods of rack " + rackName); err != nil {
+		return result.Error(err)
+	} else if wait {
+		return result.Continue()
+	}
+
+	// Replace the pods in the same order the statefulset controller would,
+	// from the highest ordinal down
+	sort.Slice(outdated, func(i, j int) bool {
+		if len(outdated[i].Name) != len(outdated[j].Name) {
+			return len(outdated[i].Name) > len(outdated[j].Name)
+		}
+		return outdated[i].Name > outdated[j].Name
+	})
+	pod := outdated[0]
+
+	rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.ReplacingPod,
+		"Draining and replacing pod %s to update its resources", pod.Name)
+
+	if err := rc.NodeMgmtClient.CallDrainEndpoint(pod); err != nil {
+		logger.Error(err, "error draining node before replacing its pod", "pod", pod.Name)
+		return result.RequeueSoon(10)
+	}
+
+	if err := rc.Client.Delete(rc.Ctx, pod); err != nil {
+		logger.Error(err, "error deleting pod after drain", "pod", pod.Name)
+		return result.Error(err)
+	}
+
+	return result.Done()
+}
+
+// allNodesUpAndNormal reports whether every pod of the datacenter is ready
+// and its node is seen as Up/Normal by the ring
+func (rc *ReconciliationContext) allNodesUpAndNormal() bool {
+	for _, pod := range rc.dcPods {
+		if !isServerReady(pod) {
+			return false
+		}
+	}
+
+	endpointStates := MapPodsToEndpointDataByName(rc.dcPods, rc.getCassMetadataEndpoints())
+	for _, pod := range rc.dcPods {
+		state, ok := endpointStates[pod.Name]
+		if !ok || !isUpAndNormal(state) {
+			return false
+		}
+	}
+	return true
+}
+
+func isUpAndNormal(state httphelper.EndpointState) bool {
+	return state.IsAlive == "true" && strings.HasPrefix(state.Status, "NORMAL")
+}
@@ -0,0 +1,164 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/httphelper"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+)
+
+func TestPodResourcesChanged(t *testing.T) {
+	template := func(cpu string) *corev1.PodTemplateSpec {
+		return &corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name: "cassandra",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+					},
+				}},
+			},
+		}
+	}
+
+	assert.False(t, podResourcesChanged(template("1"), template("1")))
+	assert.True(t, podResourcesChanged(template("1"), template("2")))
+
+	withImage := template("1")
+	withImage.Spec.Containers[0].Image = "cassandra:3.11.7"
+	assert.False(t, podResourcesChanged(template("1"), withImage))
+}
+
+// setupVerticalScalingTest returns a statefulset on the OnDelete strategy
+// with pod-0 on its update revision and pod-1 still outdated. The drained
+// pods are recorded in the returned slice.
+func setupVerticalScalingTest(t *testing.T, rc *ReconciliationContext, pod1Status string) (*appsv1.StatefulSet, *[]string) {
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sts-rack1",
+			Namespace: rc.Datacenter.Namespace,
+			Labels:    map[string]string{api.RackLabel: "rack1"},
+		},
+		Spec: appsv1.StatefulSetSpec{
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType},
+		},
+		Status: appsv1.StatefulSetStatus{
+			Replicas:        2,
+			ReadyReplicas:   2,
+			UpdatedReplicas: 1,
+			UpdateRevision:  "rev2",
+		},
+	}
+	if err := rc.Client.Create(rc.Ctx, statefulSet); err != nil {
+		t.Fatalf("failed to create statefulset: %s", err)
+	}
+
+	rc.dcPods = nil
+	for i, revision := range []string{"rev2", "rev1"} {
+		pod := makeReadyPod(fmt.Sprintf("pod-%d", i))
+		pod.Namespace = rc.Datacenter.Namespace
+		pod.Status.PodIP = fmt.Sprintf("10.0.0.%d", i)
+		pod.Labels = map[string]string{
+			api.RackLabel:                   "rack1",
+			appsv1.StatefulSetRevisionLabel: revision,
+		}
+		if err := rc.Client.Create(rc.Ctx, pod); err != nil {
+			t.Fatalf("failed to create pod: %s", err)
+		}
+		rc.dcPods = append(rc.dcPods, pod)
+	}
+	rc.clusterPods = rc.dcPods
+
+	endpoints := fmt.Sprintf(`{"entity": [
+		{"RPC_ADDRESS": "10.0.0.0", "IS_ALIVE": "true", "STATUS": "NORMAL,1"},
+		{"RPC_ADDRESS": "10.0.0.1", "IS_ALIVE": "true", "STATUS": "%s"}
+	]}`, pod1Status)
+
+	var drained []string
+	mockHttpClient := &mocks.HttpClient{}
+	mockHttpClient.On("Do",
+		mock.MatchedBy(
+			func(req *http.Request) bool {
+				return req != nil
+			})).
+		Return(func(req *http.Request) *http.Response {
+			body := "OK"
+			if req.URL.Path == "/api/v0/metadata/endpoints" {
+				body = endpoints
+			} else if req.URL.Path == "/api/v0/ops/node/drain" {
+				drained = append(drained, req.URL.Host)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}
+		}, nil)
+	rc.NodeMgmtClient = httphelper.NodeMgmtClient{Client: mockHttpClient, Log: rc.ReqLogger, Protocol: "http"}
+
+	return statefulSet, &drained
+}
+
+func TestReplaceOutdatedPods_DrainsAndDeletes(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	statefulSet, drained := setupVerticalScalingTest(t, rc, "NORMAL,2")
+
+	recResult := rc.replaceOutdatedPods(statefulSet)
+	assert.True(t, recResult.Completed())
+	assert.Len(t, *drained, 1)
+	assert.Contains(t, (*drained)[0], "10.0.0.1")
+
+	pod := &corev1.Pod{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: "pod-1", Namespace: rc.Datacenter.Namespace}, pod)
+	assert.True(t, errors.IsNotFound(err), "the outdated pod should be deleted")
+
+	err = rc.Client.Get(rc.Ctx, types.NamespacedName{Name: "pod-0", Namespace: rc.Datacenter.Namespace}, pod)
+	assert.NoError(t, err, "the up to date pod should be kept")
+}
+
+func TestReplaceOutdatedPods_WaitsForUpNormal(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	statefulSet, drained := setupVerticalScalingTest(t, rc, "JOINING,2")
+
+	recResult := rc.replaceOutdatedPods(statefulSet)
+	assert.True(t, recResult.Completed())
+	assert.Empty(t, *drained)
+
+	pod := &corev1.Pod{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: "pod-1", Namespace: rc.Datacenter.Namespace}, pod)
+	assert.NoError(t, err, "no pod should be deleted while a node is joining")
+}
+
+func TestReplaceOutdatedPods_RestoresRollingUpdate(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	statefulSet, drained := setupVerticalScalingTest(t, rc, "NORMAL,2")
+	rc.dcPods[1].Labels[appsv1.StatefulSetRevisionLabel] = "rev2"
+	statefulSet.Status.UpdatedReplicas = 2
+
+	recResult := rc.replaceOutdatedPods(statefulSet)
+	assert.False(t, recResult.Completed())
+	assert.Empty(t, *drained)
+	assert.Equal(t, appsv1.RollingUpdateStatefulSetStrategyType, statefulSet.Spec.UpdateStrategy.Type)
+}
@@ -0,0 +1,236 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+)
+
+// The reason of the Valid condition while a storage request cannot be
+// increased
+const storageClassNotExpandableReason = "storageClassNotExpandable"
+
+// CheckVolumeClaimSizes expands the persistent volume claims of a rack when the
+// storage requests of the storageConfig grow. The volumeClaimTemplates of a
+// StatefulSet cannot be updated, so once its claims are expanded the
+// StatefulSet is deleted without its pods, and CheckRackCreation recreates it
+// with the new templates and adopts the pods.
+func (rc *ReconciliationContext) CheckVolumeClaimSizes() result.ReconcileResult {
+	logger := rc.ReqLogger
+	logger.Info("reconcile_volumes::CheckVolumeClaimSizes")
+
+	for idx := range rc.desiredRackInformation {
+		rackName := rc.desiredRackInformation[idx].RackName
+		statefulSet := rc.statefulSets[idx]
+		if statefulSet == nil {
+			continue
+		}
+
+		if statefulSet.GetDeletionTimestamp() != nil {
+			logger.Info("Waiting for the statefulset to be deleted before it is recreated",
+				"statefulSet", statefulSet.Name)
+			return result.RequeueSoon(2)
+		}
+
+		desiredSts, err := newStatefulSetForCassandraDatacenter(rackName, rc.Datacenter, 0)
+		if err != nil {
+			logger.Error(err, "error calling newStatefulSetForCassandraDatacenter")
+			return result.Error(err)
+		}
+
+		expandedClaims := expandedVolumeClaimTemplates(statefulSet, desiredSts)
+		if len(expandedClaims) == 0 {
+			continue
+		}
+
+		for _, claim := range expandedClaims {
+			allowed, err := rc.checkStorageClassAllowsExpansion(claim)
+			if err != nil {
+				return result.Error(err)
+			}
+			if !allowed {
+				// The templates of the StatefulSet cannot change until the
+				// storage class allows the expansion, or the storage request
+				// is set back
+				if err := rc.refuseVolumeExpansion(statefulSet, claim); err != nil {
+					return result.Error(err)
+				}
+				return result.RequeueSoon(60)
+			}
+		}
+
+		rc.Recorder.Eventf(rc.Datacenter, corev1.EventTypeNormal, events.ExpandingVolumes,
+			"Expanding the volumes of rack %s", rackName)
+
+		for ordinal := int32(0); ordinal < *statefulSet.Spec.Replicas; ordinal++ {
+			for _, claim := range expandedClaims {
+				pvcName := fmt.Sprintf("%s-%s-%d", claim.Name, statefulSet.Name, ordinal)
+				if err := rc.expandVolumeClaim(pvcName, claim.Spec.Resources.Requests[corev1.ResourceStorage]); err != nil {
+					return result.Error(err)
+				}
+			}
+		}
+
+		logger.Info("Deleting the statefulset without its pods to recreate it with the expanded volumes",
+			"statefulSet", statefulSet.Name)
+		if err := rc.Client.Delete(rc.Ctx, statefulSet, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil {
+			logger.Error(err, "Failed to delete the statefulset", "statefulSet", statefulSet.Name)
+			return result.Error(err)
+		}
+
+		return result.RequeueSoon(2)
+	}
+
+	if err := rc.clearRefusedVolumeExpansion(); err != nil {
+		return result.Error(err)
+	}
+	return result.Continue()
+}
+
+// expandedVolumeClaimTemplates returns the volume claim templates of the
+// desired statefulset whose storage requests are larger than in the current one
+func expandedVolumeClaimTemplates(current *appsv1.StatefulSet, desired *appsv1.StatefulSet) []corev1.PersistentVolumeClaim {
+	var expanded []corev1.PersistentVolumeClaim
+	for _, desiredClaim := range desired.Spec.VolumeClaimTemplates {
+		for _, currentClaim := range current.Spec.VolumeClaimTemplates {
+			if currentClaim.Name != desiredClaim.Name {
+				continue
+			}
+			desiredRequest := desiredClaim.Spec.Resources.Requests[corev1.ResourceStorage]
+			currentRequest := currentClaim.Spec.Resources.Requests[corev1.ResourceStorage]
+			if desiredRequest.Cmp(currentRequest) > 0 {
+				expanded = append(expanded, desiredClaim)
+			}
+		}
+	}
+	return expanded
+}
+
+// checkStorageClassAllowsExpansion returns whether the storage class of the
+// claim allows its volumes to be expanded
+func (rc *ReconciliationContext) checkStorageClassAllowsExpansion(claim corev1.PersistentVolumeClaim) (bool, error) {
+	// Without a storage class name the default storage class is used, the
+	// API server rejects the expansion if it does not allow it
+	if claim.Spec.StorageClassName == nil {
+		return true, nil
+	}
+
+	storageClass := &storagev1.StorageClass{}
+	if err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: *claim.Spec.StorageClassName}, storageClass); err != nil {
+		rc.ReqLogger.Error(err, "Failed to get storage class", "storageClass", *claim.Spec.StorageClassName)
+		return false, err
+	}
+
+	return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+}
+
+// refuseVolumeExpansion marks the datacenter as invalid while the storage
+// class of the claim does not allow its volumes to be expanded, and records
+// the storage requests of the StatefulSet so that the webhook lets the
+// storageConfig be set back to them
+func (rc *ReconciliationContext) refuseVolumeExpansion(statefulSet *appsv1.StatefulSet, claim corev1.PersistentVolumeClaim) error {
+	dc := rc.Datacenter
+	msg := fmt.Sprintf("Storage class %s does not allow volume expansion, the storage request of %s cannot be increased",
+		*claim.Spec.StorageClassName, claim.Name)
+
+	requests := map[string]resource.Quantity{}
+	for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+		requests[template.Name] = template.Spec.Resources.Requests[corev1.ResourceStorage]
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	current, _ := dc.GetCondition(api.DatacenterValid)
+	refused := current.Status != corev1.ConditionFalse || current.Message != msg
+	updated := rc.setCondition(
+		api.NewDatacenterConditionWithReason(api.DatacenterValid,
+			corev1.ConditionFalse, storageClassNotExpandableReason, msg,
+		),
+	)
+	if !reflect.DeepEqual(dc.Status.VolumeClaimRequests, requests) {
+		dc.Status.VolumeClaimRequests = requests
+		updated = true
+		refused = true
+	}
+
+	if refused {
+		rc.ReqLogger.Info(msg)
+		rc.Recorder.Event(dc, corev1.EventTypeWarning, events.ExpandingVolumes, msg)
+	}
+	if updated {
+		if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+			rc.ReqLogger.Error(err, "error patching condition Valid for failed volume expansion")
+			return err
+		}
+	}
+	return nil
+}
+
+// clearRefusedVolumeExpansion marks the datacenter as valid again once no
+// volume expansion is refused, because the storage class allows it now or the
+// request was set back
+func (rc *ReconciliationContext) clearRefusedVolumeExpansion() error {
+	dc := rc.Datacenter
+	condition, _ := dc.GetCondition(api.DatacenterValid)
+	if len(dc.Status.VolumeClaimRequests) == 0 &&
+		(condition.Status != corev1.ConditionFalse || condition.Reason != storageClassNotExpandableReason) {
+		return nil
+	}
+
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.VolumeClaimRequests = nil
+	if condition.Reason == storageClassNotExpandableReason {
+		rc.setCondition(api.NewDatacenterCondition(api.DatacenterValid, corev1.ConditionTrue))
+	}
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		rc.ReqLogger.Error(err, "error patching condition Valid after the volume expansion")
+		return err
+	}
+	return nil
+}
+
+// expandVolumeClaim increases the storage request of a claim. Claims that do
+// not exist yet are skipped, the recreated statefulset creates them from the
+// new templates.
+func (rc *ReconciliationContext) expandVolumeClaim(pvcName string, request resource.Quantity) error {
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pvcName, Namespace: rc.Datacenter.Namespace}, pvc)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		rc.ReqLogger.Error(err, "Failed to get pod PVC", "Claim Name", pvcName)
+		return err
+	}
+
+	current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if current.Cmp(request) >= 0 {
+		return nil
+	}
+
+	rc.ReqLogger.Info("Expanding pod PVC", "Claim Name", pvcName, "from", current.String(), "to", request.String())
+	patch := client.MergeFrom(pvc.DeepCopy())
+	if pvc.Spec.Resources.Requests == nil {
+		pvc.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = request
+	if err := rc.Client.Patch(rc.Ctx, pvc, patch); err != nil {
+		rc.ReqLogger.Error(err, "Failed to expand pod PVC", "Claim Name", pvcName)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+// setupVolumeExpansionTest creates the statefulset of the default rack with
+// two pods and their claims, and then increases the storage request of the
+// datacenter
+func setupVolumeExpansionTest(t *testing.T, rc *ReconciliationContext, allowVolumeExpansion bool) *appsv1.StatefulSet {
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: *rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.StorageClassName},
+		Provisioner:          "kubernetes.io/no-provisioner",
+		AllowVolumeExpansion: &allowVolumeExpansion,
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, storageClass))
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("default", rc.Datacenter, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.Client.Create(rc.Ctx, statefulSet))
+
+	for ordinal := 0; ordinal < 2; ordinal++ {
+		assert.NoError(t, rc.Client.Create(rc.Ctx, &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s-%d", PvcName, statefulSet.Name, ordinal),
+				Namespace: rc.Datacenter.Namespace,
+			},
+			Spec: *rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.DeepCopy(),
+		}))
+	}
+
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 2}}
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+
+	requests := rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.Resources.Requests.DeepCopy()
+	requests[corev1.ResourceStorage] = resource.MustParse("2Gi")
+	rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.Resources.Requests = requests
+
+	return statefulSet
+}
+
+func TestCheckVolumeClaimSizes_Unchanged(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("default", rc.Datacenter, 2)
+	assert.NoError(t, err)
+	rc.desiredRackInformation = []*RackInformation{{RackName: "default", NodeCount: 2}}
+	rc.statefulSets = []*appsv1.StatefulSet{statefulSet}
+
+	recResult := rc.CheckVolumeClaimSizes()
+	assert.False(t, recResult.Completed())
+}
+
+func TestCheckVolumeClaimSizes_ExpandsClaims(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	statefulSet := setupVolumeExpansionTest(t, rc, true)
+
+	recResult := rc.CheckVolumeClaimSizes()
+	assert.True(t, recResult.Completed())
+
+	for ordinal := 0; ordinal < 2; ordinal++ {
+		pvc := &corev1.PersistentVolumeClaim{}
+		name := types.NamespacedName{
+			Name:      fmt.Sprintf("%s-%s-%d", PvcName, statefulSet.Name, ordinal),
+			Namespace: rc.Datacenter.Namespace,
+		}
+		assert.NoError(t, rc.Client.Get(rc.Ctx, name, pvc))
+		request := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		assert.Equal(t, "2Gi", request.String())
+	}
+
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: statefulSet.Name, Namespace: statefulSet.Namespace}, &appsv1.StatefulSet{})
+	assert.True(t, errors.IsNotFound(err), "the statefulset should be deleted to be recreated")
+}
+
+func TestCheckVolumeClaimSizes_StorageClassNotExpandable(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	statefulSet := setupVolumeExpansionTest(t, rc, false)
+	currentRequest := statefulSet.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+
+	recResult := rc.CheckVolumeClaimSizes()
+	assert.True(t, recResult.Completed())
+	_, err := recResult.Output()
+	assert.NoError(t, err, "a refused expansion is a condition, not an error")
+	condition, _ := rc.Datacenter.GetCondition(api.DatacenterValid)
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, storageClassNotExpandableReason, condition.Reason)
+	assert.Equal(t, map[string]resource.Quantity{PvcName: currentRequest}, rc.Datacenter.Status.VolumeClaimRequests)
+	assert.False(t, rc.CheckForInvalidState().Completed(), "the refused expansion is checked again")
+
+	err = rc.Client.Get(rc.Ctx, types.NamespacedName{Name: statefulSet.Name, Namespace: statefulSet.Namespace}, &appsv1.StatefulSet{})
+	assert.NoError(t, err, "the statefulset should be kept")
+
+	// Setting the request back to the one of the statefulset undoes it
+	rc.Datacenter.Spec.StorageConfig.CassandraDataVolumeClaimSpec.Resources.Requests[corev1.ResourceStorage] = currentRequest
+	assert.False(t, rc.CheckVolumeClaimSizes().Completed())
+	assert.Equal(t, corev1.ConditionTrue, rc.Datacenter.GetConditionStatus(api.DatacenterValid))
+	assert.Empty(t, rc.Datacenter.Status.VolumeClaimRequests)
+}
+
+func TestGetStatefulSetForRack_AdoptsPods(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.dcPods = []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Labels: map[string]string{api.RackLabel: "default"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Labels: map[string]string{api.RackLabel: "default"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod-2", Labels: map[string]string{api.RackLabel: "other"}}},
+	}
+
+	statefulSet, found, err := rc.GetStatefulSetForRack(&RackInformation{RackName: "default", NodeCount: 2})
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, int32(2), *statefulSet.Spec.Replicas)
+}
@@ -0,0 +1,188 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+)
+
+// The remediations recorded in the status count against the budget for an
+// hour
+const remediationBudgetPeriod = time.Hour
+
+// CheckUnhealthyPods remediates the pods that have been stuck for longer than
+// the grace period of spec.remediation. A stuck pod is restarted, or its node
+// replaced if it was already restarted within the hour and spec.remediation
+// allows it. The remediations of the last hour are kept in the status, and no
+// pod is remediated once they have used up the budget. Pods are remediated
+// one at a time, and not while nodes are being replaced.
+func (rc *ReconciliationContext) CheckUnhealthyPods() result.ReconcileResult {
+	dc := rc.Datacenter
+	config := dc.Spec.Remediation
+	if config == nil || !config.Enabled || rc.IsStopped() {
+		return result.Continue()
+	}
+
+	if len(dc.Spec.ReplaceNodes) > 0 || len(dc.Status.NodeReplacements) > 0 {
+		return result.Continue()
+	}
+
+	logger := rc.ReqLogger
+	now := time.Now()
+	recent := recentRemediations(dc.Status.Remediations, now)
+
+	var stuck []stuckPod
+	for _, pod := range rc.dcPods {
+		if pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		reason, gracePeriod := stuckPodReason(pod, dc.Status.NodeStatuses[pod.Name], config)
+		if reason == "" {
+			continue
+		}
+		since, notReady := notReadySince(pod)
+		if !notReady || now.Sub(since) < gracePeriod {
+			continue
+		}
+		stuck = append(stuck, stuckPod{pod: pod, reason: reason, since: since})
+	}
+	if len(stuck) == 0 {
+		return result.Continue()
+	}
+
+	pod, reason, since := stuck[0].pod, stuck[0].reason, stuck[0].since
+	if len(recent) >= config.GetMaxRemediationsPerHour() {
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.RemediationBudgetExhausted,
+			"Not remediating pod %s, which %s, as %d pods were already remediated in the last hour",
+			pod.Name, reason, len(recent))
+		return result.Continue()
+	}
+
+	action := api.RemediationRestart
+	if config.ReplaceNodes && wasRestarted(recent, pod.Name) {
+		// The data of the node is only thrown away when the node itself is at
+		// fault, not the rollout of a new pod template or config, nor a
+		// problem that affects several nodes
+		if blocker := rc.replaceBlocker(len(stuck), now); blocker != "" {
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.RemediatingPod,
+				"Not replacing the node of pod %s, which %s, as %s, restarting it instead", pod.Name, reason, blocker)
+		} else {
+			action = api.RemediationReplace
+		}
+	}
+
+	// The remediation is recorded first, so that it counts against the
+	// budget even if it fails
+	dcPatch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.Remediations = append(recent, api.PodRemediation{
+		PodName: pod.Name,
+		Action:  action,
+		Reason:  reason,
+		Time:    metav1.NewTime(now),
+	})
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, dcPatch); err != nil {
+		logger.Error(err, "error patching datacenter status for the remediation of a pod")
+		return result.Error(err)
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.RemediatingPod,
+		"%s pod %s, which %s since %s", action, pod.Name, reason, since.Format(time.RFC3339))
+
+	var err error
+	if action == api.RemediationReplace {
+		err = rc.StartNodeReplace(pod.Name)
+	} else {
+		err = rc.Client.Delete(rc.Ctx, pod)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to remediate the pod", "pod", pod.Name, "action", action)
+		return result.Error(err)
+	}
+
+	return result.RequeueSoon(10)
+}
+
+type stuckPod struct {
+	pod    *corev1.Pod
+	reason string
+	since  time.Time
+}
+
+// replaceBlocker tells why the node of a stuck pod must not be replaced, or
+// returns an empty string if it can be: more than one pod is stuck, or the
+// pod template or the config of the datacenter changed within the hour
+func (rc *ReconciliationContext) replaceBlocker(stuckPods int, now time.Time) string {
+	if stuckPods > 1 {
+		return fmt.Sprintf("%d pods are stuck", stuckPods)
+	}
+
+	updating, ok := rc.Datacenter.GetCondition(api.DatacenterUpdating)
+	if ok && (updating.Status == corev1.ConditionTrue || now.Sub(updating.LastTransitionTime.Time) < remediationBudgetPeriod) {
+		return "the pods of the datacenter were updated recently"
+	}
+	return ""
+}
+
+// stuckPodReason describes why the pod is stuck unhealthy, with how long it
+// has to be before it is remediated, or returns an empty string if it is not:
+// its server container is in CrashLoopBackOff, or its node is drained, or
+// JOINING the ring. A JOINING node may still be streaming its data for as long
+// as the bootstrap takes, so it gets the longer joining grace period.
+func stuckPodReason(pod *corev1.Pod, nodeStatus api.CassandraNodeStatus, config *api.RemediationConfig) (string, time.Duration) {
+	if status := getCassContainerStatus(pod); status != nil &&
+		status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+		return "has been in CrashLoopBackOff", config.GetGracePeriod()
+	}
+
+	switch nodeStatus.OperationMode {
+	case "JOINING":
+		return "has been joining the ring", config.GetJoiningGracePeriod()
+	case "SHUTDOWN":
+		if isServerStarted(pod) {
+			return "has been drained", config.GetGracePeriod()
+		}
+	}
+	return "", 0
+}
+
+// notReadySince returns since when the pod has not been ready, and false if
+// it is ready
+func notReadySince(pod *corev1.Pod) (time.Time, bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.LastTransitionTime.Time, condition.Status != corev1.ConditionTrue
+		}
+	}
+	return pod.CreationTimestamp.Time, true
+}
+
+// recentRemediations returns the remediations within the budget period
+func recentRemediations(remediations []api.PodRemediation, now time.Time) []api.PodRemediation {
+	recent := []api.PodRemediation{}
+	for _, remediation := range remediations {
+		if now.Sub(remediation.Time.Time) < remediationBudgetPeriod {
+			recent = append(recent, remediation)
+		}
+	}
+	return recent
+}
+
+func wasRestarted(remediations []api.PodRemediation, podName string) bool {
+	for _, remediation := range remediations {
+		if remediation.PodName == podName && remediation.Action == api.RemediationRestart {
+			return true
+		}
+	}
+	return false
+}
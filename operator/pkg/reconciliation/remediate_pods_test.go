@@ -0,0 +1,201 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+// makeCrashLoopingPod creates a pod whose server container has been in
+// CrashLoopBackOff for the given duration
+func makeCrashLoopingPod(t *testing.T, rc *ReconciliationContext, name string, notReadyFor time.Duration) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: rc.Datacenter.Namespace},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-notReadyFor)),
+			}},
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "cassandra",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}},
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+	return pod
+}
+
+func enableRemediation(rc *ReconciliationContext, maxPerHour int32) {
+	gracePeriod := int32(60)
+	rc.Datacenter.Spec.Remediation = &api.RemediationConfig{
+		Enabled:                true,
+		GracePeriodSeconds:     &gracePeriod,
+		MaxRemediationsPerHour: &maxPerHour,
+	}
+}
+
+func podExists(t *testing.T, rc *ReconciliationContext, name string) bool {
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: name, Namespace: rc.Datacenter.Namespace}, &corev1.Pod{})
+	if errors.IsNotFound(err) {
+		return false
+	}
+	assert.NoError(t, err)
+	return true
+}
+
+func TestStuckPodReason(t *testing.T) {
+	config := &api.RemediationConfig{}
+	started := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{api.CassNodeState: stateStartedNotReady}}}
+
+	reason, gracePeriod := stuckPodReason(started, api.CassandraNodeStatus{OperationMode: "JOINING"}, config)
+	assert.NotEmpty(t, reason)
+	assert.Equal(t, 24*time.Hour, gracePeriod, "a joining node may still be streaming")
+
+	reason, gracePeriod = stuckPodReason(started, api.CassandraNodeStatus{OperationMode: "SHUTDOWN"}, config)
+	assert.NotEmpty(t, reason)
+	assert.Equal(t, 30*time.Minute, gracePeriod)
+
+	reason, _ = stuckPodReason(started, api.CassandraNodeStatus{OperationMode: "NORMAL"}, config)
+	assert.Empty(t, reason)
+
+	// A pod that was never started is not drained
+	reason, _ = stuckPodReason(&corev1.Pod{}, api.CassandraNodeStatus{OperationMode: "SHUTDOWN"}, config)
+	assert.Empty(t, reason)
+}
+
+func TestCheckUnhealthyPods_RestartsWithinBudget(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	enableRemediation(rc, 1)
+	rc.dcPods = []*corev1.Pod{
+		makeCrashLoopingPod(t, rc, "pod-0", 30*time.Second),
+		makeCrashLoopingPod(t, rc, "pod-1", 2*time.Minute),
+		makeCrashLoopingPod(t, rc, "pod-2", 2*time.Minute),
+	}
+
+	assert.True(t, rc.CheckUnhealthyPods().Completed())
+	assert.True(t, podExists(t, rc, "pod-0"), "the pod is only remediated after the grace period")
+	assert.False(t, podExists(t, rc, "pod-1"))
+	assert.Len(t, rc.Datacenter.Status.Remediations, 1)
+	assert.Equal(t, "pod-1", rc.Datacenter.Status.Remediations[0].PodName)
+	assert.Equal(t, api.RemediationRestart, rc.Datacenter.Status.Remediations[0].Action)
+
+	rc.dcPods = rc.dcPods[2:]
+	assert.False(t, rc.CheckUnhealthyPods().Completed())
+	assert.True(t, podExists(t, rc, "pod-2"), "the budget is exhausted")
+
+	// The remediations older than an hour no longer count
+	rc.Datacenter.Status.Remediations[0].Time = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	assert.True(t, rc.CheckUnhealthyPods().Completed())
+	assert.False(t, podExists(t, rc, "pod-2"))
+	assert.Len(t, rc.Datacenter.Status.Remediations, 1)
+}
+
+func TestCheckUnhealthyPods_ReplacesAfterRestart(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	enableRemediation(rc, 2)
+	rc.Datacenter.Spec.Remediation.ReplaceNodes = true
+	rc.dcPods = []*corev1.Pod{makeCrashLoopingPod(t, rc, "pod-0", 2*time.Minute)}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "server-data-pod-0", Namespace: rc.Datacenter.Namespace},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pvc))
+
+	assert.True(t, rc.CheckUnhealthyPods().Completed())
+	assert.Empty(t, rc.Datacenter.Spec.ReplaceNodes, "the pod is restarted first")
+
+	// The pod that replaced it is stuck as well
+	rc.dcPods = []*corev1.Pod{makeCrashLoopingPod(t, rc, "pod-0", 2*time.Minute)}
+	assert.True(t, rc.CheckUnhealthyPods().Completed())
+	assert.Equal(t, []string{"pod-0"}, rc.Datacenter.Spec.ReplaceNodes)
+	assert.Equal(t, api.RemediationReplace, rc.Datacenter.Status.Remediations[1].Action)
+	assert.False(t, podExists(t, rc, "pod-0"))
+
+	// No pod is remediated while nodes are being replaced
+	rc.dcPods = []*corev1.Pod{makeCrashLoopingPod(t, rc, "pod-0", 2*time.Minute)}
+	assert.False(t, rc.CheckUnhealthyPods().Completed())
+}
+
+func TestCheckUnhealthyPods_DoesNotReplaceWhenSeveralPodsAreStuck(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	enableRemediation(rc, 3)
+	rc.Datacenter.Spec.Remediation.ReplaceNodes = true
+	rc.dcPods = []*corev1.Pod{makeCrashLoopingPod(t, rc, "pod-0", 2*time.Minute)}
+	assert.True(t, rc.CheckUnhealthyPods().Completed())
+
+	rc.dcPods = []*corev1.Pod{
+		makeCrashLoopingPod(t, rc, "pod-0", 2*time.Minute),
+		makeCrashLoopingPod(t, rc, "pod-1", 2*time.Minute),
+	}
+	assert.True(t, rc.CheckUnhealthyPods().Completed())
+	assert.Empty(t, rc.Datacenter.Spec.ReplaceNodes)
+	assert.Equal(t, api.RemediationRestart, rc.Datacenter.Status.Remediations[1].Action)
+	assert.False(t, podExists(t, rc, "pod-0"), "the pod is restarted instead")
+}
+
+func TestCheckUnhealthyPods_DoesNotReplaceAfterUpdate(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	enableRemediation(rc, 3)
+	rc.Datacenter.Spec.Remediation.ReplaceNodes = true
+	rc.dcPods = []*corev1.Pod{makeCrashLoopingPod(t, rc, "pod-0", 2*time.Minute)}
+	assert.True(t, rc.CheckUnhealthyPods().Completed())
+
+	updated := api.NewDatacenterCondition(api.DatacenterUpdating, corev1.ConditionFalse)
+	updated.LastTransitionTime = metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	rc.Datacenter.SetCondition(*updated)
+
+	rc.dcPods = []*corev1.Pod{makeCrashLoopingPod(t, rc, "pod-0", 2*time.Minute)}
+	assert.True(t, rc.CheckUnhealthyPods().Completed())
+	assert.Empty(t, rc.Datacenter.Spec.ReplaceNodes, "the new pod template may be at fault")
+	assert.Equal(t, api.RemediationRestart, rc.Datacenter.Status.Remediations[1].Action)
+}
+
+func TestCheckUnhealthyPods_JoiningGracePeriod(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	enableRemediation(rc, 1)
+	joiningGracePeriod := int32(3600)
+	rc.Datacenter.Spec.Remediation.JoiningGracePeriodSeconds = &joiningGracePeriod
+	rc.Datacenter.Status.NodeStatuses = api.CassandraStatusMap{"pod-0": {OperationMode: "JOINING"}}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: rc.Datacenter.Namespace},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-30 * time.Minute)),
+			}},
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+	rc.dcPods = []*corev1.Pod{pod}
+
+	assert.False(t, rc.CheckUnhealthyPods().Completed())
+	assert.True(t, podExists(t, rc, "pod-0"), "the node may still be bootstrapping")
+
+	pod.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	assert.True(t, rc.CheckUnhealthyPods().Completed())
+	assert.False(t, podExists(t, rc, "pod-0"))
+}
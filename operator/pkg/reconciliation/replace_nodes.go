@@ -0,0 +1,168 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+)
+
+// CheckVolumeFailures replaces the nodes whose volume has failed for longer
+// than the grace period of spec.automaticNodeReplacement. The time a failure
+// was first seen is kept in an annotation of the data PVC of the node, so it
+// survives restarts of the operator, and is removed if the volume recovers.
+// Nodes are replaced one at a time through the replaceNodes flow.
+func (rc *ReconciliationContext) CheckVolumeFailures() result.ReconcileResult {
+	dc := rc.Datacenter
+	config := dc.Spec.AutomaticNodeReplacement
+	if config == nil || !config.Enabled {
+		return result.Continue()
+	}
+
+	logger := rc.ReqLogger
+	logger.Info("replace_nodes::CheckVolumeFailures")
+
+	replacing := len(dc.Spec.ReplaceNodes) > 0 || len(dc.Status.NodeReplacements) > 0
+
+	for _, pod := range rc.dcPods {
+		if pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		pvc, err := rc.GetPodPVC(pod.Namespace, pod.Name)
+		if errors.IsNotFound(err) {
+			// fixMissingPVC takes care of pods without a PVC
+			continue
+		}
+		if err != nil {
+			return result.Error(err)
+		}
+
+		if dc.Status.NodeStatuses[pod.Name].HostID == "" {
+			// A node that never joined the ring has nothing to replace, and
+			// its volume may well still be provisioned
+			continue
+		}
+
+		failedSince, hasFailed := pvc.Annotations[api.VolumeFailureAnnotation]
+		reason := volumeFailureReason(pod, pvc)
+
+		if reason == "" {
+			if hasFailed {
+				rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.RecoveredVolumeFailure,
+					"The volume of pod %s recovered", pod.Name)
+				if err := rc.setVolumeFailureAnnotation(pvc, ""); err != nil {
+					return result.Error(err)
+				}
+			}
+			continue
+		}
+
+		if !hasFailed {
+			rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.DetectedVolumeFailure,
+				"Detected a failure of the volume of pod %s: %s. The node will be replaced unless it recovers within %s",
+				pod.Name, reason, config.GetGracePeriod())
+			if err := rc.setVolumeFailureAnnotation(pvc, time.Now().UTC().Format(time.RFC3339)); err != nil {
+				return result.Error(err)
+			}
+			continue
+		}
+
+		failureTime, err := time.Parse(time.RFC3339, failedSince)
+		if err != nil {
+			logger.Error(err, "Invalid volume failure annotation, resetting it", "Claim Name", pvc.Name)
+			if err := rc.setVolumeFailureAnnotation(pvc, time.Now().UTC().Format(time.RFC3339)); err != nil {
+				return result.Error(err)
+			}
+			continue
+		}
+
+		if replacing || time.Since(failureTime) < config.GetGracePeriod() {
+			continue
+		}
+
+		rc.Recorder.Eventf(dc, corev1.EventTypeWarning, events.ReplacingNode,
+			"Replacing the node of pod %s as its volume failed %s ago: %s",
+			pod.Name, time.Since(failureTime).Round(time.Second), reason)
+		if err := rc.StartNodeReplace(pod.Name); err != nil {
+			logger.Error(err, "Failed to start the replacement of the node", "pod", pod.Name)
+			return result.Error(err)
+		}
+
+		return result.RequeueSoon(2)
+	}
+
+	return result.Continue()
+}
+
+// volumeSchedulingFailures are the messages of the scheduler for the pods
+// that cannot be scheduled because of their volumes
+var volumeSchedulingFailures = []string{
+	"volume node affinity conflict",
+	"unbound immediate PersistentVolumeClaims",
+	"didn't find available persistent volumes to bind",
+}
+
+// volumeFailureReason describes why the volume of a pod has failed, or
+// returns an empty string if it has not. Besides a lost claim, the pod must be
+// Pending because the scheduler cannot place it with its volume, as a claim
+// that is Pending on its own may only be waiting for its volume to be
+// provisioned.
+func volumeFailureReason(pod *corev1.Pod, pvc *corev1.PersistentVolumeClaim) string {
+	if pvc.Status.Phase == corev1.ClaimLost {
+		return fmt.Sprintf("PersistentVolumeClaim %s lost its volume", pvc.Name)
+	}
+
+	if pod.Status.Phase != corev1.PodPending {
+		return ""
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != corev1.PodScheduled ||
+			condition.Status != corev1.ConditionFalse ||
+			condition.Reason != corev1.PodReasonUnschedulable {
+			continue
+		}
+		for _, failure := range volumeSchedulingFailures {
+			if !strings.Contains(condition.Message, failure) {
+				continue
+			}
+			if pvc.Status.Phase == corev1.ClaimPending {
+				return fmt.Sprintf("PersistentVolumeClaim %s is Pending and the pod cannot be scheduled: %s", pvc.Name, condition.Message)
+			}
+			return fmt.Sprintf("pod cannot be scheduled: %s", condition.Message)
+		}
+	}
+
+	return ""
+}
+
+// setVolumeFailureAnnotation records when the volume of a PVC failed, or
+// removes the record if failureTime is empty
+func (rc *ReconciliationContext) setVolumeFailureAnnotation(pvc *corev1.PersistentVolumeClaim, failureTime string) error {
+	patch := client.MergeFrom(pvc.DeepCopy())
+	if failureTime == "" {
+		delete(pvc.Annotations, api.VolumeFailureAnnotation)
+	} else {
+		if pvc.Annotations == nil {
+			pvc.Annotations = map[string]string{}
+		}
+		pvc.Annotations[api.VolumeFailureAnnotation] = failureTime
+	}
+
+	if err := rc.Client.Patch(rc.Ctx, pvc, patch); err != nil {
+		rc.ReqLogger.Error(err, "Failed to patch the volume failure annotation", "Claim Name", pvc.Name)
+		return err
+	}
+	return nil
+}
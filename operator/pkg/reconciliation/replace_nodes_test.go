@@ -0,0 +1,180 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func TestVolumeFailureReason(t *testing.T) {
+	unschedulable := corev1.PodCondition{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  corev1.PodReasonUnschedulable,
+		Message: "0/3 nodes are available: 3 node(s) had volume node affinity conflict.",
+	}
+	unboundClaim := corev1.PodCondition{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  corev1.PodReasonUnschedulable,
+		Message: "pod has unbound immediate PersistentVolumeClaims (repeated 2 times)",
+	}
+	insufficientCpu := corev1.PodCondition{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  corev1.PodReasonUnschedulable,
+		Message: "0/3 nodes are available: 3 Insufficient cpu.",
+	}
+
+	tests := []struct {
+		name      string
+		podPhase  corev1.PodPhase
+		condition *corev1.PodCondition
+		pvcPhase  corev1.PersistentVolumeClaimPhase
+		failed    bool
+	}{
+		{name: "Healthy", podPhase: corev1.PodRunning, pvcPhase: corev1.ClaimBound, failed: false},
+		{name: "Lost claim", podPhase: corev1.PodRunning, pvcPhase: corev1.ClaimLost, failed: true},
+		{name: "Pending claim", podPhase: corev1.PodPending, pvcPhase: corev1.ClaimPending, failed: false},
+		{name: "Pending claim that blocks scheduling", podPhase: corev1.PodPending, condition: &unboundClaim, pvcPhase: corev1.ClaimPending, failed: true},
+		{name: "Pending claim of a pod short of cpu", podPhase: corev1.PodPending, condition: &insufficientCpu, pvcPhase: corev1.ClaimPending, failed: false},
+		{name: "Pending claim of a running pod", podPhase: corev1.PodRunning, pvcPhase: corev1.ClaimPending, failed: false},
+		{name: "Volume node affinity conflict", podPhase: corev1.PodPending, condition: &unschedulable, pvcPhase: corev1.ClaimBound, failed: true},
+		{name: "Insufficient cpu", podPhase: corev1.PodPending, condition: &insufficientCpu, pvcPhase: corev1.ClaimBound, failed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{Status: corev1.PodStatus{Phase: tt.podPhase}}
+			if tt.condition != nil {
+				pod.Status.Conditions = []corev1.PodCondition{*tt.condition}
+			}
+			pvc := &corev1.PersistentVolumeClaim{Status: corev1.PersistentVolumeClaimStatus{Phase: tt.pvcPhase}}
+
+			reason := volumeFailureReason(pod, pvc)
+			assert.Equal(t, tt.failed, reason != "", reason)
+		})
+	}
+}
+
+func setupVolumeFailureTest(t *testing.T, rc *ReconciliationContext, failedSince string) (*corev1.Pod, *corev1.PersistentVolumeClaim) {
+	gracePeriod := int32(60)
+	rc.Datacenter.Spec.AutomaticNodeReplacement = &api.AutomaticNodeReplacementConfig{
+		Enabled:            true,
+		GracePeriodSeconds: &gracePeriod,
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: rc.Datacenter.Namespace},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pod))
+	rc.dcPods = []*corev1.Pod{pod}
+	rc.Datacenter.Status.NodeStatuses = api.CassandraStatusMap{pod.Name: api.CassandraNodeStatus{HostID: "host-0"}}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "server-data-pod-0", Namespace: rc.Datacenter.Namespace},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimLost},
+	}
+	if failedSince != "" {
+		pvc.Annotations = map[string]string{api.VolumeFailureAnnotation: failedSince}
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, pvc))
+
+	return pod, pvc
+}
+
+func TestCheckVolumeFailures_Disabled(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	setupVolumeFailureTest(t, rc, "")
+	rc.Datacenter.Spec.AutomaticNodeReplacement = nil
+
+	recResult := rc.CheckVolumeFailures()
+	assert.False(t, recResult.Completed())
+}
+
+func TestCheckVolumeFailures_RecordsFailure(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	_, pvc := setupVolumeFailureTest(t, rc, "")
+
+	recResult := rc.CheckVolumeFailures()
+	assert.False(t, recResult.Completed(), "the node is only replaced after the grace period")
+
+	updated := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, updated))
+	assert.Contains(t, updated.Annotations, api.VolumeFailureAnnotation)
+	assert.Empty(t, rc.Datacenter.Spec.ReplaceNodes)
+}
+
+func TestCheckVolumeFailures_ReplacesAfterGracePeriod(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	failedSince := time.Now().Add(-2 * time.Minute).UTC().Format(time.RFC3339)
+	pod, pvc := setupVolumeFailureTest(t, rc, failedSince)
+
+	recResult := rc.CheckVolumeFailures()
+	assert.True(t, recResult.Completed())
+	assert.Equal(t, []string{pod.Name}, rc.Datacenter.Spec.ReplaceNodes)
+
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, errors.IsNotFound(err), "the PVC should be deleted")
+	err = rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, &corev1.Pod{})
+	assert.True(t, errors.IsNotFound(err), "the pod should be deleted")
+}
+
+func TestCheckVolumeFailures_NodeNeverJoined(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	failedSince := time.Now().Add(-2 * time.Minute).UTC().Format(time.RFC3339)
+	setupVolumeFailureTest(t, rc, failedSince)
+	rc.Datacenter.Status.NodeStatuses = nil
+
+	recResult := rc.CheckVolumeFailures()
+	assert.False(t, recResult.Completed())
+	assert.Empty(t, rc.Datacenter.Spec.ReplaceNodes, "a node without a host ID cannot be replaced")
+}
+
+func TestCheckVolumeFailures_WaitsForOtherReplacements(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	failedSince := time.Now().Add(-2 * time.Minute).UTC().Format(time.RFC3339)
+	setupVolumeFailureTest(t, rc, failedSince)
+	rc.Datacenter.Status.NodeReplacements = []string{"pod-1"}
+
+	recResult := rc.CheckVolumeFailures()
+	assert.False(t, recResult.Completed())
+	assert.Empty(t, rc.Datacenter.Spec.ReplaceNodes)
+}
+
+func TestCheckVolumeFailures_Recovered(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	_, pvc := setupVolumeFailureTest(t, rc, time.Now().UTC().Format(time.RFC3339))
+	pvc.Status.Phase = corev1.ClaimBound
+	assert.NoError(t, rc.Client.Update(rc.Ctx, pvc))
+
+	recResult := rc.CheckVolumeFailures()
+	assert.False(t, recResult.Completed())
+
+	updated := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, updated))
+	assert.NotContains(t, updated.Annotations, api.VolumeFailureAnnotation)
+}
@@ -0,0 +1,87 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/k8ssandra/cass-operator/operator/pkg/vault"
+)
+
+// SecretProvider reads a secret the datacenter references
+type SecretProvider interface {
+	// GetSecret returns the secret, named after key whatever it is read from
+	GetSecret(ctx context.Context, key types.NamespacedName) (*corev1.Secret, error)
+}
+
+// kubernetesSecretProvider reads the secrets of the api server
+type kubernetesSecretProvider struct {
+	rc *ReconciliationContext
+}
+
+func (p *kubernetesSecretProvider) GetSecret(_ context.Context, key types.NamespacedName) (*corev1.Secret, error) {
+	return p.rc.retrieveSecret(key)
+}
+
+// vaultSecretProvider reads a KV secret of Vault, returned as a Secret so
+// that the reconciliation handles it like a kubernetes secret
+type vaultSecretProvider struct {
+	client *vault.Client
+	path   string
+}
+
+func (p *vaultSecretProvider) GetSecret(ctx context.Context, key types.NamespacedName) (*corev1.Secret, error) {
+	data, err := p.client.ReadSecret(ctx, p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %s of vault: %w", p.path, err)
+	}
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+		Data: map[string][]byte{},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret, nil
+}
+
+// secretProvider returns the provider of a secret of the datacenter: Vault
+// when the secret has a path there, the api server otherwise. The address of
+// Vault and the roles the datacenter may log in with come from the
+// environment of the operator, never from the datacenter, which would
+// otherwise get the token of the operator sent to any server.
+func (rc *ReconciliationContext) secretProvider(vaultPath string) (SecretProvider, error) {
+	if vaultPath == "" {
+		return &kubernetesSecretProvider{rc: rc}, nil
+	}
+
+	config := rc.Datacenter.Spec.Vault
+	address := vault.Address()
+	if address == "" {
+		return nil, fmt.Errorf("cannot read secret %s of vault without the %s environment variable of the operator", vaultPath, vault.AddressEnv)
+	}
+	role, err := vault.RoleFor(rc.Datacenter.Namespace, config.Role)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read secret %s of vault: %w", vaultPath, err)
+	}
+
+	client, err := vault.ClientFor(address, config.AuthPath, role)
+	if err != nil {
+		return nil, err
+	}
+	return &vaultSecretProvider{client: client, path: vaultPath}, nil
+}
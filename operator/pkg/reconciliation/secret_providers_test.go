@@ -0,0 +1,132 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/vault"
+)
+
+// newTestVault serves the KV secrets of version 2 of the KV secrets engine,
+// by path, to the operator logged in with the role cass-operator, which the
+// datacenters of every namespace are allowed
+func newTestVault(t *testing.T, secrets map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/kubernetes/login" {
+			_, _ = w.Write([]byte(`{"auth": {"client_token": "token", "lease_duration": 3600}}`))
+			return
+		}
+		secret, ok := secrets[r.URL.Path]
+		if !ok || r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"data": ` + secret + `, "metadata": {"version": 1}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, ioutil.WriteFile(tokenFile, []byte("jwt"), 0600))
+	defaultTokenFile := vault.DefaultTokenFile
+	vault.DefaultTokenFile = tokenFile
+	t.Cleanup(func() { vault.DefaultTokenFile = defaultTokenFile })
+
+	os.Setenv(vault.AddressEnv, server.URL)
+	os.Setenv(vault.RolesEnv, "*=cass-operator")
+	t.Cleanup(func() {
+		os.Unsetenv(vault.AddressEnv)
+		os.Unsetenv(vault.RolesEnv)
+	})
+
+	return server
+}
+
+func TestRetrieveSuperuserSecret_Vault(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	newTestVault(t, map[string]string{
+		"/v1/secret/data/cassandra/superuser": `{"username": "admin", "password": "secret"}`,
+	})
+	rc.Datacenter.Spec.Vault = &api.VaultConfig{
+		Role:                "cass-operator",
+		SuperuserSecretPath: "secret/data/cassandra/superuser",
+	}
+
+	secret, err := rc.retrieveSuperuserSecret()
+	assert.NoError(t, err)
+	assert.Equal(t, rc.Datacenter.GetSuperuserSecretNamespacedName().Name, secret.Name)
+	assert.Equal(t, []byte("admin"), secret.Data["username"])
+	assert.Equal(t, []byte("secret"), secret.Data["password"])
+	assert.Empty(t, rc.validateSuperuserSecret())
+
+	// The operator does not generate a superuser secret in place of the one
+	// of Vault
+	rc.Datacenter.Spec.Vault.SuperuserSecretPath = "secret/data/cassandra/missing"
+	assert.True(t, rc.CheckSuperuserSecretCreation().Completed())
+	assert.NotEmpty(t, rc.validateSuperuserSecret())
+}
+
+func TestSecretProvider_VaultFromOperatorConfig(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	newTestVault(t, map[string]string{})
+	rc.Datacenter.Spec.Vault = &api.VaultConfig{
+		Role:                "cass-operator",
+		SuperuserSecretPath: "secret/data/cassandra/superuser",
+	}
+	_, err := rc.secretProvider(rc.Datacenter.GetVaultSuperuserSecretPath())
+	assert.NoError(t, err)
+
+	rc.Datacenter.Spec.Vault.Role = "other-tenant"
+	_, err = rc.secretProvider(rc.Datacenter.GetVaultSuperuserSecretPath())
+	assert.Error(t, err, "the role is not allowed for the namespace")
+}
+
+func TestCheckSuperuserSecretCreation_SecretProviderClass(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.SuperuserSecretName = "vault-superuser"
+	rc.Datacenter.Spec.Vault = &api.VaultConfig{SecretProviderClass: "vault-cassandra"}
+
+	// The CSI driver syncs the secret once the pods start
+	assert.False(t, rc.CheckSuperuserSecretCreation().Completed())
+	assert.Empty(t, rc.validateSuperuserSecret())
+}
+
+func TestCheckConfigSecret_Vault(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	newTestVault(t, map[string]string{
+		"/v1/secret/data/cassandra/config": `{"config": {"cassandra-yaml": {"read_request_timeout_in_ms": 10000}}}`,
+	})
+	dc := rc.Datacenter
+	dc.Annotations = map[string]string{}
+	dc.Spec.Vault = &api.VaultConfig{
+		Role:             "cass-operator",
+		ConfigSecretPath: "secret/data/cassandra/config",
+	}
+
+	assert.False(t, rc.CheckConfigSecret().Completed())
+
+	secret := &corev1.Secret{}
+	err := rc.Client.Get(rc.Ctx, types.NamespacedName{Name: getDatacenterConfigSecretName(dc), Namespace: dc.Namespace}, secret)
+	assert.NoError(t, err)
+	assert.Contains(t, string(secret.Data["config"]), `"read_request_timeout_in_ms":10000`)
+	assert.NotEmpty(t, dc.Annotations[api.ConfigHashAnnotation])
+}
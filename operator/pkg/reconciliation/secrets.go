@@ -74,6 +74,7 @@ func buildDefaultSuperuserSecret(dc *api.CassandraDatacenter) (*corev1.Secret, e
 			"username": []byte(username),
 			"password": []byte(password),
 		}
+		addAdditionalMetadata(dc, secret)
 	}
 
 	return secret, nil
@@ -106,7 +107,11 @@ func (rc *ReconciliationContext) retrieveSecret(secretNamespacedName types.Names
 func (rc *ReconciliationContext) retrieveSuperuserSecret() (*corev1.Secret, error) {
 	dc := rc.Datacenter
 	secretNamespacedName := dc.GetSuperuserSecretNamespacedName()
-	return rc.retrieveSecret(secretNamespacedName)
+	provider, err := rc.secretProvider(dc.GetVaultSuperuserSecretPath())
+	if err != nil {
+		return nil, err
+	}
+	return provider.GetSecret(rc.Ctx, secretNamespacedName)
 }
 
 func (rc *ReconciliationContext) retrieveSuperuserSecretOrCreateDefault() (*corev1.Secret, error) {
@@ -122,7 +127,7 @@ func (rc *ReconciliationContext) retrieveSuperuserSecretOrCreateDefault() (*core
 			}
 
 			if err == nil {
-				err = rc.Client.Create(rc.Ctx, secret)
+				err = rc.createResource(secret)
 			}
 
 			if err != nil {
@@ -152,6 +157,7 @@ func (rc *ReconciliationContext) createInternodeCACredential() (*corev1.Secret,
 			"key":  []byte(keypem),
 			"cert": []byte(certpem),
 		}
+		addAdditionalMetadata(rc.Datacenter, secret)
 		return secret, nil
 	} else {
 		return nil, err
@@ -182,15 +188,23 @@ func (rc *ReconciliationContext) createCABootstrappingSecret(jksBlob []byte) err
 	secret.Data = map[string][]byte{
 		"node-keystore.jks": jksBlob,
 	}
+	addAdditionalMetadata(rc.Datacenter, secret)
 
-	return rc.Client.Create(rc.Ctx, secret)
+	return rc.createResource(secret)
 }
 
 func (rc *ReconciliationContext) keystoreCASecret() types.NamespacedName {
+	if importSecret := rc.Datacenter.GetFederationImportSecret(); importSecret != "" {
+		return types.NamespacedName{Name: importSecret, Namespace: rc.Datacenter.Namespace}
+	}
 	return types.NamespacedName{Name: fmt.Sprintf("%s-ca-keystore", rc.Datacenter.Name), Namespace: rc.Datacenter.Namespace}
 }
 
 func (rc *ReconciliationContext) retrieveInternodeCredentialSecretOrCreateDefault() (*corev1.Secret, error) {
+	if rc.Datacenter.GetFederationImportSecret() != "" {
+		return rc.retrieveImportedInternodeCredentialSecret()
+	}
+
 	secret, retrieveErr := rc.retrieveSecret(rc.keystoreCASecret())
 	if retrieveErr != nil {
 		if errors.IsNotFound(retrieveErr) {
@@ -201,7 +215,7 @@ func (rc *ReconciliationContext) retrieveInternodeCredentialSecretOrCreateDefaul
 			}
 
 			if err == nil {
-				err = rc.Client.Create(rc.Ctx, secret)
+				err = rc.createResource(secret)
 			}
 
 			if err == nil {
@@ -223,6 +237,33 @@ func (rc *ReconciliationContext) retrieveInternodeCredentialSecretOrCreateDefaul
 	return secret, nil
 }
 
+// retrieveImportedInternodeCredentialSecret returns the federation secret
+// imported from another Kubernetes cluster, whose CA signs the keystore of the
+// datacenter instead of a CA of its own
+func (rc *ReconciliationContext) retrieveImportedInternodeCredentialSecret() (*corev1.Secret, error) {
+	secret, err := rc.retrieveSecret(rc.keystoreCASecret())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the imported federation secret: %w", err)
+	}
+
+	_, err = rc.retrieveSecret(types.NamespacedName{
+		Name:      fmt.Sprintf("%s-keystore", rc.Datacenter.Name),
+		Namespace: rc.Datacenter.Namespace,
+	})
+	if errors.IsNotFound(err) {
+		var jksBlob []byte
+		jksBlob, err = utils.GenerateJKS(secret, rc.Datacenter.Name, rc.Datacenter.Name)
+		if err == nil {
+			err = rc.createCABootstrappingSecret(jksBlob)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
 // Helper function that is easier to test
 func validateCassandraUserSecretContent(dc *api.CassandraDatacenter, secret *corev1.Secret) []error {
 	var errs []error
@@ -254,7 +295,9 @@ func (rc *ReconciliationContext) validateSuperuserSecret() []error {
 	secret, err := rc.retrieveSuperuserSecret()
 	if err != nil {
 		if errors.IsNotFound(err) {
-			if dc.ShouldGenerateSuperuserSecret() {
+			// The CSI driver syncs the secret once the pods mount the
+			// SecretProviderClass
+			if dc.ShouldGenerateSuperuserSecret() || dc.GetVaultSecretProviderClass() != "" {
 				return []error{}
 			} else {
 				return []error{
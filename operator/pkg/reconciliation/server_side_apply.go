@@ -0,0 +1,185 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"encoding/json"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+// FieldManager is the manager of the fields the operator applies
+const FieldManager = "cass-operator"
+
+// legacyFieldManager returns the manager of the fields the operator sets with
+// creates, updates and patches, which the kube-apiserver names after the
+// user agent of the operator
+func legacyFieldManager() string {
+	return strings.Split(rest.DefaultKubernetesUserAgent(), "/")[0]
+}
+
+// applyResource sends the desired state of a resource with server-side apply.
+// The kube-apiserver merges it into the resource, creating it if needed, and
+// only changes the fields the operator manages. The fields other controllers
+// set, such as the annotations of a mesh injector, are left to them. The
+// operator takes over the fields it sets that another manager changed. The
+// current resource, when there is one, first has its fields handed over from
+// the legacy manager of the operator.
+func (rc *ReconciliationContext) applyResource(desired, current runtime.Object) error {
+	if current != nil {
+		if err := rc.handOverManagedFields(current); err != nil {
+			return err
+		}
+	}
+
+	gvk, err := apiutil.GVKForObject(desired, rc.Scheme)
+	if err != nil {
+		return err
+	}
+	// The kind is part of the applied object, unlike for the other requests
+	desired.GetObjectKind().SetGroupVersionKind(gvk)
+	return rc.Client.Patch(rc.Ctx, desired, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership)
+}
+
+// createResource creates a resource, with server-side apply when it is
+// enabled so that the operator manages its fields from the start
+func (rc *ReconciliationContext) createResource(desired runtime.Object) error {
+	if utils.IsServerSideApplyEnabled() {
+		return rc.applyResource(desired, nil)
+	}
+	return rc.Client.Create(rc.Ctx, desired)
+}
+
+// updateResource updates the current resource to the desired one, which gets
+// the updated resource. With server-side apply the fields other managers set
+// are theirs. Otherwise the labels and annotations others added to the
+// current resource are kept, but the dropped annotations, which the operator
+// records with patches and resets on updates, and the additional labels and
+// annotations of the datacenter it set before.
+func (rc *ReconciliationContext) updateResource(desired, current runtime.Object, droppedAnnotations ...string) error {
+	if utils.IsServerSideApplyEnabled() {
+		return rc.applyResource(desired, current)
+	}
+
+	desiredMeta, err := meta.Accessor(desired)
+	if err != nil {
+		return err
+	}
+	currentMeta, err := meta.Accessor(current)
+	if err != nil {
+		return err
+	}
+	// The additional labels and annotations the operator set are the ones of
+	// the desired resource, which no longer has those taken out of the spec
+	labels, annotations := withoutAdditionalMetadata(currentMeta.GetLabels(), currentMeta.GetAnnotations())
+	for _, key := range droppedAnnotations {
+		delete(annotations, key)
+	}
+	desiredMeta.SetLabels(utils.MergeMap(labels, desiredMeta.GetLabels()))
+	desiredMeta.SetAnnotations(utils.MergeMap(annotations, desiredMeta.GetAnnotations()))
+	desiredMeta.SetResourceVersion(currentMeta.GetResourceVersion())
+	return rc.Client.Update(rc.Ctx, desired)
+}
+
+// handOverManagedFields gives the fields of the resource the legacy manager
+// of the operator owns to the applied config of FieldManager, the way kubectl
+// upgrades client-side applied resources. The fields the operator stops
+// applying are then removed, rather than kept by the legacy manager. The
+// patches of the operator record their fields with the legacy manager, which
+// are handed over on the next apply.
+func (rc *ReconciliationContext) handOverManagedFields(current runtime.Object) error {
+	currentMeta, err := meta.Accessor(current)
+	if err != nil {
+		return err
+	}
+
+	legacyManager := legacyFieldManager()
+	var entries []metav1.ManagedFieldsEntry
+	legacyIdx, appliedIdx := -1, -1
+	for _, entry := range currentMeta.GetManagedFields() {
+		switch {
+		case entry.Manager == legacyManager && entry.Operation == metav1.ManagedFieldsOperationUpdate:
+			legacyIdx = len(entries)
+		case entry.Manager == FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply:
+			appliedIdx = len(entries)
+		}
+		entries = append(entries, entry)
+	}
+	if legacyIdx < 0 {
+		return nil
+	}
+
+	legacy := entries[legacyIdx]
+	if appliedIdx < 0 {
+		legacy.Manager = FieldManager
+		legacy.Operation = metav1.ManagedFieldsOperationApply
+		entries[legacyIdx] = legacy
+	} else {
+		applied := entries[appliedIdx]
+		if applied.APIVersion != legacy.APIVersion {
+			// The field sets of different versions cannot be merged
+			return nil
+		}
+		fields, err := mergeFieldsV1(applied.FieldsV1, legacy.FieldsV1)
+		if err != nil {
+			return err
+		}
+		applied.FieldsV1 = fields
+		entries[appliedIdx] = applied
+		entries = append(entries[:legacyIdx], entries[legacyIdx+1:]...)
+	}
+
+	rc.ReqLogger.Info("Handing the fields of the operator over to server-side apply",
+		"resource", currentMeta.GetName(), "manager", legacyManager)
+	patch := client.MergeFrom(current.DeepCopyObject())
+	currentMeta.SetManagedFields(entries)
+	return rc.Client.Patch(rc.Ctx, current, patch)
+}
+
+// mergeFieldsV1 returns the union of two field sets of managedFields, which
+// are trees of the field paths in JSON
+func mergeFieldsV1(into, from *metav1.FieldsV1) (*metav1.FieldsV1, error) {
+	if from == nil {
+		return into, nil
+	}
+	if into == nil {
+		return from, nil
+	}
+	intoSet := map[string]interface{}{}
+	if err := json.Unmarshal(into.Raw, &intoSet); err != nil {
+		return nil, err
+	}
+	fromSet := map[string]interface{}{}
+	if err := json.Unmarshal(from.Raw, &fromSet); err != nil {
+		return nil, err
+	}
+	mergeFieldSets(intoSet, fromSet)
+	raw, err := json.Marshal(intoSet)
+	if err != nil {
+		return nil, err
+	}
+	return &metav1.FieldsV1{Raw: raw}, nil
+}
+
+func mergeFieldSets(into, from map[string]interface{}) {
+	for key, fromValue := range from {
+		fromChildren, fromIsSet := fromValue.(map[string]interface{})
+		intoChildren, intoIsSet := into[key].(map[string]interface{})
+		if fromIsSet && intoIsSet {
+			mergeFieldSets(intoChildren, fromChildren)
+			continue
+		}
+		if _, ok := into[key]; !ok || fromIsSet {
+			into[key] = fromValue
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/mocks"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+func TestCreateResourceWithServerSideApply(t *testing.T) {
+	rc, service, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockClient := &mocks.Client{}
+	rc.Client = mockClient
+
+	os.Setenv(utils.ServerSideApplyEnv, "true")
+	defer os.Unsetenv(utils.ServerSideApplyEnv)
+
+	mockClient.On("Patch", mock.Anything, service, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership).
+		Return(nil).
+		Once()
+
+	err := rc.createResource(service)
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+	assert.Equal(t, "Service", service.Kind, "the applied object should have its kind")
+	assert.Equal(t, "v1", service.APIVersion)
+}
+
+func TestCreateResourceWithoutServerSideApply(t *testing.T) {
+	rc, service, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	mockClient := &mocks.Client{}
+	rc.Client = mockClient
+
+	k8sMockClientCreate(mockClient, nil)
+
+	err := rc.createResource(service)
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestUpdateResourceWithoutServerSideApply(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	current := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "config",
+			Namespace:   "default",
+			Labels:      map[string]string{"team": "a"},
+			Annotations: map[string]string{"injected": "true", "recorded": "old"},
+		},
+		Data: map[string]string{"key": "old"},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, current))
+
+	desired := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "config",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "cassandra"},
+		},
+		Data: map[string]string{"key": "new"},
+	}
+	assert.NoError(t, rc.updateResource(desired, current, "recorded"))
+
+	updated := &corev1.ConfigMap{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: "config", Namespace: "default"}, updated))
+	assert.Equal(t, map[string]string{"team": "a", "app": "cassandra"}, updated.Labels)
+	assert.Equal(t, map[string]string{"injected": "true"}, updated.Annotations, "the dropped annotation should be reset")
+	assert.Equal(t, "new", updated.Data["key"])
+}
+
+func TestUpdateResourceRemovesAdditionalMetadata(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	dc := rc.Datacenter
+	dc.Spec.AdditionalLabels = map[string]string{"example.com/team": "data", "example.com/tier": "gold"}
+	newConfigMap := func() *corev1.ConfigMap {
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"}}
+		addAdditionalMetadata(dc, configMap)
+		return configMap
+	}
+	current := newConfigMap()
+	current.Labels["injected"] = "true"
+	assert.NoError(t, rc.Client.Create(rc.Ctx, current))
+
+	delete(dc.Spec.AdditionalLabels, "example.com/tier")
+	assert.NoError(t, rc.updateResource(newConfigMap(), current))
+
+	updated := &corev1.ConfigMap{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: "config", Namespace: "default"}, updated))
+	assert.Equal(t, map[string]string{"example.com/team": "data", "injected": "true"}, updated.Labels,
+		"the label taken out of the spec should be removed, and the ones of others kept")
+	assert.Equal(t, "example.com/team", updated.Annotations[api.AdditionalLabelsAnnotation])
+}
+
+func TestHandOverManagedFields(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	fieldsOf := func(raw string) *metav1.FieldsV1 {
+		return &metav1.FieldsV1{Raw: []byte(raw)}
+	}
+	legacyEntry := metav1.ManagedFieldsEntry{
+		Manager:    legacyFieldManager(),
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   fieldsOf(`{"f:data":{"f:legacy":{}}}`),
+	}
+	otherEntry := metav1.ManagedFieldsEntry{
+		Manager:    "mesh-injector",
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   fieldsOf(`{"f:metadata":{"f:annotations":{"f:injected":{}}}}`),
+	}
+
+	// The fields of the legacy manager become the applied config
+	current := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:          "config",
+			Namespace:     "default",
+			ManagedFields: []metav1.ManagedFieldsEntry{legacyEntry, otherEntry},
+		},
+	}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, current))
+	assert.NoError(t, rc.handOverManagedFields(current))
+	updated := &corev1.ConfigMap{}
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: "config", Namespace: "default"}, updated))
+	if assert.Len(t, updated.ManagedFields, 2) {
+		assert.Equal(t, FieldManager, updated.ManagedFields[0].Manager)
+		assert.Equal(t, metav1.ManagedFieldsOperationApply, updated.ManagedFields[0].Operation)
+		assert.Equal(t, "mesh-injector", updated.ManagedFields[1].Manager)
+	}
+
+	// They are merged into an existing applied config
+	appliedEntry := metav1.ManagedFieldsEntry{
+		Manager:    FieldManager,
+		Operation:  metav1.ManagedFieldsOperationApply,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   fieldsOf(`{"f:data":{"f:applied":{}}}`),
+	}
+	updated.ManagedFields = []metav1.ManagedFieldsEntry{appliedEntry, otherEntry, legacyEntry}
+	assert.NoError(t, rc.Client.Update(rc.Ctx, updated))
+	assert.NoError(t, rc.handOverManagedFields(updated))
+	assert.NoError(t, rc.Client.Get(rc.Ctx, types.NamespacedName{Name: "config", Namespace: "default"}, updated))
+	if assert.Len(t, updated.ManagedFields, 2) {
+		assert.Equal(t, FieldManager, updated.ManagedFields[0].Manager)
+		fields := map[string]map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(updated.ManagedFields[0].FieldsV1.Raw, &fields))
+		assert.Contains(t, fields["f:data"], "f:applied")
+		assert.Contains(t, fields["f:data"], "f:legacy")
+		assert.Equal(t, "mesh-injector", updated.ManagedFields[1].Manager)
+	}
+
+	// Nothing is patched without fields of the legacy manager
+	mockClient := &mocks.Client{}
+	rc.Client = mockClient
+	assert.NoError(t, rc.handOverManagedFields(updated))
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,117 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8ssandra/cass-operator/operator/internal/result"
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+	"github.com/k8ssandra/cass-operator/operator/pkg/events"
+	"github.com/k8ssandra/cass-operator/operator/pkg/utils"
+)
+
+var invalidRackNameChars = regexp.MustCompile("[^a-z0-9-]")
+
+// CheckZoneRacks defines a rack for every zone of the k8s workers when
+// spec.zoneRacks is enabled and no racks are listed in the spec. The racks
+// are recorded in the status before the first statefulset is created, and are
+// never changed afterwards since the racks of a deployed datacenter cannot
+// change.
+func (rc *ReconciliationContext) CheckZoneRacks() result.ReconcileResult {
+	dc := rc.Datacenter
+	config := dc.Spec.ZoneRacks
+	if config == nil || !config.Enabled || len(dc.Spec.Racks) > 0 || len(dc.Status.ZoneRacks) > 0 {
+		return result.Continue()
+	}
+
+	logger := rc.ReqLogger
+	logger.Info("zone_racks::CheckZoneRacks")
+
+	// The datacenter was already deployed in the default rack
+	err := rc.Client.Get(rc.Ctx, newNamespacedNameForStatefulSet(dc, "default"), &appsv1.StatefulSet{})
+	if err == nil {
+		return result.Continue()
+	}
+	if !errors.IsNotFound(err) {
+		return result.Error(err)
+	}
+
+	zoneLabel := config.GetZoneLabel()
+	zones, err := rc.listZones(zoneLabel)
+	if err != nil {
+		logger.Error(err, "Failed to list the zones of the k8s workers")
+		return result.Error(err)
+	}
+
+	if len(zones) == 0 {
+		logger.Info("No k8s worker has a zone, using the default rack", "label", zoneLabel)
+		return result.Continue()
+	}
+
+	// A datacenter cannot have more racks than nodes
+	if len(zones) > int(dc.Spec.Size) {
+		zones = zones[:dc.Spec.Size]
+	}
+
+	racks := make([]api.Rack, 0, len(zones))
+	for _, zone := range zones {
+		racks = append(racks, api.Rack{
+			Name:               rackNameForZone(zone),
+			NodeAffinityLabels: map[string]string{zoneLabel: zone},
+		})
+	}
+
+	patch := client.MergeFrom(dc.DeepCopy())
+	dc.Status.ZoneRacks = racks
+	if err := rc.Client.Status().Patch(rc.Ctx, dc, patch); err != nil {
+		logger.Error(err, "Failed to record the zone racks in the status")
+		return result.Error(err)
+	}
+
+	rc.Recorder.Eventf(dc, corev1.EventTypeNormal, events.DefinedZoneRacks,
+		"Defined a rack for each of the zones %s", strings.Join(zones, ", "))
+
+	return result.Continue()
+}
+
+// listZones returns the sorted zones of the schedulable k8s workers that
+// match the node selector and the node affinity labels of the datacenter
+func (rc *ReconciliationContext) listZones(zoneLabel string) ([]string, error) {
+	dc := rc.Datacenter
+	nodeLabels := utils.MergeMap(map[string]string{}, dc.Spec.NodeSelector, dc.Spec.NodeAffinityLabels)
+
+	nodeList := &corev1.NodeList{}
+	if err := rc.Client.List(rc.Ctx, nodeList, client.MatchingLabels(nodeLabels)); err != nil {
+		return nil, err
+	}
+
+	zoneSet := utils.StringSet{}
+	for _, node := range nodeList.Items {
+		if zone := node.Labels[zoneLabel]; zone != "" && !node.Spec.Unschedulable {
+			zoneSet[zone] = true
+		}
+	}
+
+	zones := make([]string, 0, len(zoneSet))
+	for zone := range zoneSet {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	return zones, nil
+}
+
+// rackNameForZone turns a zone into a rack name that can be used in the names
+// of the statefulsets and pods
+func rackNameForZone(zone string) string {
+	return invalidRackNameChars.ReplaceAllString(strings.ToLower(zone), "-")
+}
@@ -0,0 +1,84 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package reconciliation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/k8ssandra/cass-operator/operator/pkg/apis/cassandra/v1beta1"
+)
+
+func createZoneNodes(t *testing.T, rc *ReconciliationContext, zones ...string) {
+	for i, zone := range zones {
+		assert.NoError(t, rc.Client.Create(rc.Ctx, &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   fmt.Sprintf("node-%d", i),
+				Labels: map[string]string{api.DefaultZoneLabel: zone},
+			},
+		}))
+	}
+}
+
+func TestCheckZoneRacks_DefinesRacks(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.Size = 3
+	rc.Datacenter.Spec.ZoneRacks = &api.ZoneRacksConfig{Enabled: true}
+	createZoneNodes(t, rc, "us-east1-b", "us-east1-c", "us-east1-b", "US_East1.A")
+
+	recResult := rc.CheckZoneRacks()
+	assert.False(t, recResult.Completed())
+
+	racks := rc.Datacenter.GetRacks()
+	assert.Equal(t, []api.Rack{
+		{Name: "us-east1-a", NodeAffinityLabels: map[string]string{api.DefaultZoneLabel: "US_East1.A"}},
+		{Name: "us-east1-b", NodeAffinityLabels: map[string]string{api.DefaultZoneLabel: "us-east1-b"}},
+		{Name: "us-east1-c", NodeAffinityLabels: map[string]string{api.DefaultZoneLabel: "us-east1-c"}},
+	}, racks)
+}
+
+func TestCheckZoneRacks_NoMoreRacksThanNodes(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.ZoneRacks = &api.ZoneRacksConfig{Enabled: true}
+	createZoneNodes(t, rc, "zone-a", "zone-b", "zone-c")
+
+	rc.CheckZoneRacks()
+	assert.Len(t, rc.Datacenter.Status.ZoneRacks, int(rc.Datacenter.Spec.Size))
+}
+
+func TestCheckZoneRacks_KeepsDeployedRacks(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.ZoneRacks = &api.ZoneRacksConfig{Enabled: true}
+	createZoneNodes(t, rc, "zone-a", "zone-b")
+
+	statefulSet, err := newStatefulSetForCassandraDatacenter("default", rc.Datacenter, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.Client.Create(rc.Ctx, statefulSet))
+
+	rc.CheckZoneRacks()
+	assert.Empty(t, rc.Datacenter.Status.ZoneRacks)
+	assert.Equal(t, "default", rc.Datacenter.GetRacks()[0].Name)
+}
+
+func TestCheckZoneRacks_NoZones(t *testing.T) {
+	rc, _, cleanupMockScr := setupTest()
+	defer cleanupMockScr()
+
+	rc.Datacenter.Spec.ZoneRacks = &api.ZoneRacksConfig{Enabled: true}
+	assert.NoError(t, rc.Client.Create(rc.Ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}}))
+
+	recResult := rc.CheckZoneRacks()
+	assert.False(t, recResult.Completed())
+	assert.Empty(t, rc.Datacenter.Status.ZoneRacks)
+}
@@ -0,0 +1,250 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package requeue decides when the reconciliations run again. It spreads the
+// requeues the reconcile loops ask for with some jitter, so that hundreds of
+// datacenters polling at the same interval do not hit the kube-apiserver in
+// lockstep, and it backs off the retries of the errors that are worth
+// retrying, depending on their class.
+package requeue
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var log = logf.Log.WithName("requeue")
+
+// The environment variables configuring the Policy. The delays are Go
+// durations, such as 500ms or 2m, and the jitter a fraction of the delay.
+const (
+	MinDelayEnv       = "REQUEUE_MIN_DELAY"
+	MaxDelayEnv       = "REQUEUE_MAX_DELAY"
+	ErrorBaseDelayEnv = "REQUEUE_ERROR_BASE_DELAY"
+	ErrorMaxDelayEnv  = "REQUEUE_ERROR_MAX_DELAY"
+	JitterEnv         = "REQUEUE_JITTER"
+)
+
+// Policy is how the requeues are spread and the retries backed off.
+type Policy struct {
+	// MinDelay is the shortest delay of a requeue, including the ones asked
+	// for without a delay. MaxDelay, when set, is the longest.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+
+	// ErrorBaseDelay is the delay of the first retry of an error, which
+	// doubles with each retry in a row up to ErrorMaxDelay.
+	ErrorBaseDelay time.Duration
+	ErrorMaxDelay  time.Duration
+
+	// Jitter adds up to this fraction of the delay at random to each requeue
+	Jitter float64
+}
+
+// DefaultPolicy keeps the delays the reconcile loops ask for, only adding a
+// tenth of jitter.
+func DefaultPolicy() Policy {
+	return Policy{
+		ErrorBaseDelay: time.Second,
+		ErrorMaxDelay:  5 * time.Minute,
+		Jitter:         0.1,
+	}
+}
+
+// FromEnv returns the DefaultPolicy overridden by the environment variables
+// that are set.
+func FromEnv() (Policy, error) {
+	policy := DefaultPolicy()
+	durations := []struct {
+		env   string
+		value *time.Duration
+	}{
+		{MinDelayEnv, &policy.MinDelay},
+		{MaxDelayEnv, &policy.MaxDelay},
+		{ErrorBaseDelayEnv, &policy.ErrorBaseDelay},
+		{ErrorMaxDelayEnv, &policy.ErrorMaxDelay},
+	}
+	for _, d := range durations {
+		value := os.Getenv(d.env)
+		if value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			return Policy{}, fmt.Errorf("invalid %s '%s', it must be a positive duration such as 30s", d.env, value)
+		}
+		*d.value = duration
+	}
+
+	if value := os.Getenv(JitterEnv); value != "" {
+		jitter, err := strconv.ParseFloat(value, 64)
+		if err != nil || jitter < 0 || jitter > 1 {
+			return Policy{}, fmt.Errorf("invalid %s '%s', it must be between 0 and 1", JitterEnv, value)
+		}
+		policy.Jitter = jitter
+	}
+
+	if policy.MaxDelay > 0 && policy.MaxDelay < policy.MinDelay {
+		return Policy{}, fmt.Errorf("%s must not be shorter than %s", MaxDelayEnv, MinDelayEnv)
+	}
+	if policy.ErrorMaxDelay < policy.ErrorBaseDelay {
+		return Policy{}, fmt.Errorf("%s must not be shorter than %s", ErrorMaxDelayEnv, ErrorBaseDelayEnv)
+	}
+	return policy, nil
+}
+
+// Class is the kind of an error, which tells how soon to retry it.
+type Class int
+
+const (
+	// Other errors are returned to controller-runtime, which retries them
+	// with its own rate limiting
+	Other Class = iota
+	// Conflict is an object that changed since it was read. Reading it again
+	// is enough, so it is retried after ErrorBaseDelay.
+	Conflict
+	// Throttled is the kube-apiserver asking for fewer requests. It is retried
+	// no sooner than the server suggests.
+	Throttled
+	// Unavailable is the kube-apiserver or a management API timing out or not
+	// answering. It is retried with a backoff.
+	Unavailable
+)
+
+func (c Class) String() string {
+	switch c {
+	case Conflict:
+		return "conflict"
+	case Throttled:
+		return "throttled"
+	case Unavailable:
+		return "unavailable"
+	}
+	return "other"
+}
+
+// Classify returns the class of an error
+func Classify(err error) Class {
+	switch {
+	case apierrors.IsConflict(err):
+		return Conflict
+	case apierrors.IsTooManyRequests(err):
+		return Throttled
+	case apierrors.IsServerTimeout(err), apierrors.IsTimeout(err), apierrors.IsServiceUnavailable(err):
+		return Unavailable
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return Unavailable
+	}
+	return Other
+}
+
+// reconciler applies a Policy to the results of the wrapped reconciler
+type reconciler struct {
+	reconcile.Reconciler
+	policy Policy
+
+	// failures counts the errors in a row of each request
+	failures map[types.NamespacedName]int
+	lock     sync.Mutex
+
+	// random returns a number in [0, 1), replaced by the tests
+	random func() float64
+}
+
+// Wrap returns a reconciler applying the policy to the requeues of r. The
+// errors of classes other than Other are logged and requeued after their
+// delay rather than returned.
+func Wrap(r reconcile.Reconciler, policy Policy) reconcile.Reconciler {
+	return &reconciler{
+		Reconciler: r,
+		policy:     policy,
+		failures:   make(map[types.NamespacedName]int),
+		random:     rand.Float64,
+	}
+}
+
+func (r *reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	res, err := r.Reconciler.Reconcile(request)
+	if err != nil {
+		class := Classify(err)
+		if class == Other {
+			r.forget(request.NamespacedName)
+			return res, err
+		}
+		delay := r.errorDelay(request.NamespacedName, class, err)
+		log.Info("Retrying the reconciliation after an error",
+			"requestNamespace", request.Namespace,
+			"requestName", request.Name,
+			"class", class.String(),
+			"delay", delay.String(),
+			"error", err.Error())
+		return reconcile.Result{Requeue: true, RequeueAfter: delay}, nil
+	}
+
+	r.forget(request.NamespacedName)
+	if !res.Requeue && res.RequeueAfter == 0 {
+		return res, nil
+	}
+	delay := res.RequeueAfter
+	if delay < r.policy.MinDelay {
+		delay = r.policy.MinDelay
+	}
+	if r.policy.MaxDelay > 0 && delay > r.policy.MaxDelay {
+		delay = r.policy.MaxDelay
+	}
+	return reconcile.Result{Requeue: true, RequeueAfter: r.jitter(delay)}, nil
+}
+
+// errorDelay counts the error as one more in a row for the request and
+// returns the delay before retrying it
+func (r *reconciler) errorDelay(request types.NamespacedName, class Class, err error) time.Duration {
+	r.lock.Lock()
+	r.failures[request]++
+	failures := r.failures[request]
+	r.lock.Unlock()
+
+	if class == Conflict {
+		return r.jitter(r.policy.ErrorBaseDelay)
+	}
+
+	backoff := float64(r.policy.ErrorBaseDelay) * math.Pow(2, float64(failures-1))
+	delay := r.policy.ErrorMaxDelay
+	if backoff < float64(delay) {
+		delay = time.Duration(backoff)
+	}
+	if class == Throttled {
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && time.Duration(seconds)*time.Second > delay {
+			delay = time.Duration(seconds) * time.Second
+		}
+	}
+	return r.jitter(delay)
+}
+
+func (r *reconciler) forget(request types.NamespacedName) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.failures, request)
+}
+
+// jitter adds up to the jitter fraction of the delay to it. A delay of zero,
+// an immediate requeue, stays so.
+func (r *reconciler) jitter(delay time.Duration) time.Duration {
+	if delay <= 0 || r.policy.Jitter == 0 {
+		return delay
+	}
+	return delay + time.Duration(r.random()*r.policy.Jitter*float64(delay))
+}
@@ -0,0 +1,153 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package requeue
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fakeReconciler struct {
+	res reconcile.Result
+	err error
+}
+
+func (f *fakeReconciler) Reconcile(reconcile.Request) (reconcile.Result, error) {
+	return f.res, f.err
+}
+
+var request = reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "dc1"}}
+
+func wrapFake(policy Policy, random float64) (*fakeReconciler, *reconciler) {
+	fake := &fakeReconciler{}
+	r := Wrap(fake, policy).(*reconciler)
+	r.random = func() float64 { return random }
+	return fake, r
+}
+
+func TestFromEnv(t *testing.T) {
+	envs := []string{MinDelayEnv, MaxDelayEnv, ErrorBaseDelayEnv, ErrorMaxDelayEnv, JitterEnv}
+	defer func() {
+		for _, env := range envs {
+			os.Unsetenv(env)
+		}
+	}()
+
+	policy, err := FromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPolicy(), policy)
+
+	os.Setenv(MinDelayEnv, "2s")
+	os.Setenv(MaxDelayEnv, "1m")
+	os.Setenv(JitterEnv, "0.5")
+	policy, err = FromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Second, policy.MinDelay)
+	assert.Equal(t, time.Minute, policy.MaxDelay)
+	assert.Equal(t, 0.5, policy.Jitter)
+	assert.Equal(t, time.Second, policy.ErrorBaseDelay)
+
+	os.Setenv(JitterEnv, "2")
+	_, err = FromEnv()
+	assert.Error(t, err)
+	os.Setenv(JitterEnv, "0.5")
+
+	os.Setenv(ErrorBaseDelayEnv, "ten seconds")
+	_, err = FromEnv()
+	assert.Error(t, err)
+	os.Setenv(ErrorBaseDelayEnv, "10m")
+	_, err = FromEnv()
+	assert.Error(t, err, "the base delay of the errors is longer than their max delay")
+}
+
+func TestClassify(t *testing.T) {
+	resource := schema.GroupResource{Resource: "pods"}
+	assert.Equal(t, Conflict, Classify(apierrors.NewConflict(resource, "pod", fmt.Errorf("changed"))))
+	assert.Equal(t, Throttled, Classify(apierrors.NewTooManyRequests("slow down", 5)))
+	assert.Equal(t, Unavailable, Classify(apierrors.NewServiceUnavailable("down")))
+	assert.Equal(t, Unavailable, Classify(apierrors.NewServerTimeout(resource, "get", 1)))
+	assert.Equal(t, Unavailable, Classify(fmt.Errorf("calling the management API: %w", &net.OpError{Op: "dial", Err: fmt.Errorf("refused")})))
+	assert.Equal(t, Other, Classify(apierrors.NewNotFound(resource, "pod")))
+	assert.Equal(t, Other, Classify(fmt.Errorf("invalid")))
+}
+
+func TestReconcileSpreadsRequeues(t *testing.T) {
+	fake, r := wrapFake(Policy{MinDelay: 5 * time.Second, MaxDelay: time.Minute, Jitter: 0.1}, 0.5)
+
+	fake.res = reconcile.Result{}
+	res, err := r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, res, "a reconciliation that is done is not requeued")
+
+	fake.res = reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}
+	res, _ = r.Reconcile(request)
+	assert.Equal(t, reconcile.Result{Requeue: true, RequeueAfter: 10500 * time.Millisecond}, res)
+
+	fake.res = reconcile.Result{Requeue: true, RequeueAfter: 2 * time.Second}
+	res, _ = r.Reconcile(request)
+	assert.Equal(t, 5250*time.Millisecond, res.RequeueAfter, "requeues are no sooner than the min delay")
+
+	fake.res = reconcile.Result{Requeue: true}
+	res, _ = r.Reconcile(request)
+	assert.Equal(t, 5250*time.Millisecond, res.RequeueAfter, "immediate requeues wait for the min delay")
+
+	fake.res = reconcile.Result{Requeue: true, RequeueAfter: time.Hour}
+	res, _ = r.Reconcile(request)
+	assert.Equal(t, 63*time.Second, res.RequeueAfter, "requeues are no later than the max delay")
+}
+
+func TestReconcileDefaultPolicy(t *testing.T) {
+	fake, r := wrapFake(DefaultPolicy(), 0)
+
+	fake.res = reconcile.Result{Requeue: true}
+	res, _ := r.Reconcile(request)
+	assert.Equal(t, reconcile.Result{Requeue: true}, res, "immediate requeues stay immediate by default")
+
+	fake.res = reconcile.Result{Requeue: true, RequeueAfter: 2 * time.Second}
+	res, _ = r.Reconcile(request)
+	assert.Equal(t, 2*time.Second, res.RequeueAfter)
+}
+
+func TestReconcileBacksOffErrors(t *testing.T) {
+	policy := Policy{ErrorBaseDelay: time.Second, ErrorMaxDelay: 5 * time.Second}
+	fake, r := wrapFake(policy, 0)
+
+	fake.err = apierrors.NewServiceUnavailable("down")
+	var delays []time.Duration
+	for i := 0; i < 5; i++ {
+		res, err := r.Reconcile(request)
+		assert.NoError(t, err, "errors with a class are requeued rather than returned")
+		assert.True(t, res.Requeue)
+		delays = append(delays, res.RequeueAfter)
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+
+	fake.err = nil
+	r.Reconcile(request)
+	fake.err = apierrors.NewServiceUnavailable("down")
+	res, _ := r.Reconcile(request)
+	assert.Equal(t, time.Second, res.RequeueAfter, "a successful reconciliation resets the backoff")
+
+	fake.err = apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, "pod", fmt.Errorf("changed"))
+	res, _ = r.Reconcile(request)
+	assert.Equal(t, time.Second, res.RequeueAfter, "conflicts are retried without backing off")
+
+	fake.err = apierrors.NewTooManyRequests("slow down", 30)
+	res, _ = r.Reconcile(request)
+	assert.Equal(t, 30*time.Second, res.RequeueAfter, "throttling waits as long as the server suggests")
+
+	fake.err = fmt.Errorf("invalid")
+	_, err := r.Reconcile(request)
+	assert.Error(t, err, "other errors are left to controller-runtime")
+	assert.Empty(t, r.failures)
+}
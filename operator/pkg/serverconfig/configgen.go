@@ -54,3 +54,17 @@ func GetModelValues(
 
 	return modelValues
 }
+
+// MergeConfig merges the config into dst: the objects of both are merged,
+// and the other values of config replace the ones of dst
+func MergeConfig(dst, config map[string]interface{}) {
+	for key, value := range config {
+		if object, ok := value.(map[string]interface{}); ok {
+			if dstObject, ok := dst[key].(map[string]interface{}); ok {
+				MergeConfig(dstObject, object)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
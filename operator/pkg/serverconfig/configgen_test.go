@@ -6,6 +6,8 @@ package serverconfig
 import (
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGetModelValues(t *testing.T) {
@@ -197,3 +199,25 @@ func TestGetModelValues(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeConfig(t *testing.T) {
+	merged := map[string]interface{}{
+		"cassandra-yaml": map[string]interface{}{"num_tokens": 16, "concurrent_reads": 32},
+		"jvm-server-options": map[string]interface{}{
+			"additional-jvm-opts": []interface{}{"-Dcassandra.ring_delay_ms=0"},
+		},
+	}
+	MergeConfig(merged, map[string]interface{}{
+		"cassandra-yaml": map[string]interface{}{"concurrent_reads": 64},
+		"jvm-server-options": map[string]interface{}{
+			"additional-jvm-opts": []interface{}{"-Dcassandra.consistent.rangemovement=false"},
+		},
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"cassandra-yaml": map[string]interface{}{"num_tokens": 16, "concurrent_reads": 64},
+		"jvm-server-options": map[string]interface{}{
+			"additional-jvm-opts": []interface{}{"-Dcassandra.consistent.rangemovement=false"},
+		},
+	}, merged)
+}
@@ -0,0 +1,83 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+)
+
+// LiveSettings maps the options of cassandra.yaml that a running node can
+// change to the settings of the management API that change them, as the
+// nodetool set commands do
+var LiveSettings = map[string]string{
+	"compaction_throughput_mb_per_sec":            "compactionthroughput",
+	"stream_throughput_outbound_megabits_per_sec": "streamthroughput",
+	"concurrent_compactors":                       "concurrentcompactors",
+}
+
+// LiveSettingValues returns the values of the live settings of the config,
+// as JSON rendered by the config builder, by option of cassandra.yaml. The
+// options whose value is not a whole number are left out.
+func LiveSettingValues(config string) (map[string]int64, error) {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(config), &parsed); err != nil {
+		return nil, err
+	}
+	yaml, _ := parsed["cassandra-yaml"].(map[string]interface{})
+
+	values := map[string]int64{}
+	for option := range LiveSettings {
+		if value, ok := wholeNumber(yaml[option]); ok {
+			values[option] = value
+		}
+	}
+	return values, nil
+}
+
+// IsLiveSettingsChange returns whether the desired config only differs from
+// the current one in the values of live settings, so that it can be applied
+// to the running nodes without a restart. A live setting that is removed
+// from the config is not, since the nodes would keep its former value.
+func IsLiveSettingsChange(currentConfig, desiredConfig string) bool {
+	var current, desired map[string]interface{}
+	if err := json.Unmarshal([]byte(currentConfig), &current); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(desiredConfig), &desired); err != nil {
+		return false
+	}
+	currentYaml, _ := current["cassandra-yaml"].(map[string]interface{})
+	desiredYaml, _ := desired["cassandra-yaml"].(map[string]interface{})
+
+	changed := false
+	for option := range LiveSettings {
+		currentValue, inCurrent := currentYaml[option]
+		desiredValue, inDesired := desiredYaml[option]
+		if !inDesired {
+			if inCurrent {
+				return false
+			}
+			continue
+		}
+		if _, ok := wholeNumber(desiredValue); !ok {
+			return false
+		}
+		if !reflect.DeepEqual(currentValue, desiredValue) {
+			changed = true
+		}
+		delete(desiredYaml, option)
+		delete(currentYaml, option)
+	}
+	return changed && reflect.DeepEqual(current, desired)
+}
+
+func wholeNumber(value interface{}) (int64, bool) {
+	number, ok := value.(float64)
+	if !ok || number != math.Trunc(number) {
+		return 0, false
+	}
+	return int64(number), true
+}
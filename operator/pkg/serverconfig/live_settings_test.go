@@ -0,0 +1,75 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveSettingValues(t *testing.T) {
+	values, err := LiveSettingValues(`{"cassandra-yaml":{"compaction_throughput_mb_per_sec":64,"concurrent_compactors":2.5,"num_tokens":16}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"compaction_throughput_mb_per_sec": 64}, values)
+
+	values, err = LiveSettingValues(`{"cluster-info":{"name":"cluster1"}}`)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+
+	_, err = LiveSettingValues(`{`)
+	assert.Error(t, err)
+}
+
+func TestIsLiveSettingsChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		desired string
+		want    bool
+	}{
+		{
+			name:    "Changed live setting",
+			current: `{"cassandra-yaml":{"compaction_throughput_mb_per_sec":16,"num_tokens":16}}`,
+			desired: `{"cassandra-yaml":{"compaction_throughput_mb_per_sec":64,"num_tokens":16}}`,
+			want:    true,
+		},
+		{
+			name:    "Added live settings",
+			current: `{"cassandra-yaml":{"num_tokens":16}}`,
+			desired: `{"cassandra-yaml":{"concurrent_compactors":4,"stream_throughput_outbound_megabits_per_sec":200,"num_tokens":16}}`,
+			want:    true,
+		},
+		{
+			name:    "Removed live setting",
+			current: `{"cassandra-yaml":{"concurrent_compactors":4,"num_tokens":16}}`,
+			desired: `{"cassandra-yaml":{"num_tokens":16}}`,
+		},
+		{
+			name:    "Changed live setting and other option",
+			current: `{"cassandra-yaml":{"concurrent_compactors":4,"num_tokens":16}}`,
+			desired: `{"cassandra-yaml":{"concurrent_compactors":2,"num_tokens":32}}`,
+		},
+		{
+			name:    "Changed JVM option",
+			current: `{"cassandra-yaml":{"concurrent_compactors":4},"jvm-server-options":{"max_heap_size":"4G"}}`,
+			desired: `{"cassandra-yaml":{"concurrent_compactors":2},"jvm-server-options":{"max_heap_size":"8G"}}`,
+		},
+		{
+			name:    "Live setting that is not a whole number",
+			current: `{"cassandra-yaml":{"concurrent_compactors":4}}`,
+			desired: `{"cassandra-yaml":{"concurrent_compactors":"2"}}`,
+		},
+		{
+			name:    "Same config",
+			current: `{"cassandra-yaml":{"concurrent_compactors":4}}`,
+			desired: `{"cassandra-yaml":{"concurrent_compactors":4}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsLiveSettingsChange(tt.current, tt.desired))
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Files of the configuration of Cassandra that RenderConfig renders
+const (
+	CassandraYamlFile = "cassandra.yaml"
+	RackDCFile        = "cassandra-rackdc.properties"
+)
+
+// addressOptions are the options of cassandra.yaml that the
+// server-config-init container sets from the addresses of the pod, which are
+// not known before it runs
+var addressOptions = []string{"listen_address", "rpc_address", "broadcast_address", "broadcast_rpc_address"}
+
+// RenderedConfig is the configuration of the nodes of a rack that the
+// operator renders in place of the config builder: the files the
+// server-config-init container copies, and the options of the JVM the server
+// container gets in JVM_EXTRA_OPTS
+type RenderedConfig struct {
+	Files      map[string]string
+	JvmOptions []string
+}
+
+// RenderConfig renders the config, as JSON rendered by GetConfigAsJSON, for
+// the nodes of the rack of the Cassandra version. The options of
+// cassandra-yaml are merged over the defaults of the config builder, and of
+// the JVM options only the heap and the additional-jvm-opts are supported.
+func RenderConfig(version, rackName, config string) (*RenderedConfig, error) {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(config), &parsed); err != nil {
+		return nil, err
+	}
+
+	jvmBuckets := JvmOptionsBuckets(version)
+	for section := range parsed {
+		switch {
+		case section == "cluster-info" || section == "datacenter-info" || section == "cassandra-yaml":
+		case contains(jvmBuckets, section):
+		default:
+			return nil, fmt.Errorf("config %s is not supported", section)
+		}
+	}
+
+	clusterInfo, _ := parsed["cluster-info"].(map[string]interface{})
+	datacenterInfo, _ := parsed["datacenter-info"].(map[string]interface{})
+	clusterName, _ := clusterInfo["name"].(string)
+	seeds, _ := clusterInfo["seeds"].(string)
+	dcName, _ := datacenterInfo["name"].(string)
+
+	cassandraYaml := defaultCassandraYaml(version, clusterName, seeds)
+	options, _ := parsed["cassandra-yaml"].(map[string]interface{})
+	for option, value := range options {
+		if renamed, ok := renamedOptions[option]; ok {
+			// Only one of the names of the option can be set
+			delete(cassandraYaml, renamed.name)
+		}
+		cassandraYaml[option] = wholeNumbers(value)
+	}
+	for _, option := range addressOptions {
+		delete(cassandraYaml, option)
+	}
+
+	yamlBytes, err := yaml.Marshal(cassandraYaml)
+	if err != nil {
+		return nil, err
+	}
+
+	jvmOptions := []string{}
+	for _, bucket := range jvmBuckets {
+		options, err := renderJvmOptions(bucket, parsed[bucket])
+		if err != nil {
+			return nil, err
+		}
+		jvmOptions = append(jvmOptions, options...)
+	}
+
+	return &RenderedConfig{
+		Files: map[string]string{
+			CassandraYamlFile: string(yamlBytes),
+			RackDCFile:        fmt.Sprintf("dc=%s\nrack=%s\n", dcName, rackName),
+		},
+		JvmOptions: jvmOptions,
+	}, nil
+}
+
+// defaultCassandraYaml returns the options of cassandra.yaml the config
+// builder sets by default, which Cassandra has no default for or does not
+// default to the same value
+func defaultCassandraYaml(version, clusterName, seeds string) map[string]interface{} {
+	numTokens := 256
+	if IsCassandraAtLeast(version, 4, 0) {
+		numTokens = 16
+	}
+
+	cassandraYaml := map[string]interface{}{
+		"cluster_name": clusterName,
+		"num_tokens":   numTokens,
+		"seed_provider": []interface{}{
+			map[string]interface{}{
+				"class_name": "org.apache.cassandra.locator.SimpleSeedProvider",
+				"parameters": []interface{}{
+					map[string]interface{}{"seeds": seeds},
+				},
+			},
+		},
+		"partitioner":            "org.apache.cassandra.dht.Murmur3Partitioner",
+		"endpoint_snitch":        "GossipingPropertyFileSnitch",
+		"authenticator":          "PasswordAuthenticator",
+		"authorizer":             "CassandraAuthorizer",
+		"role_manager":           "CassandraRoleManager",
+		"start_native_transport": true,
+		"commitlog_sync":         "periodic",
+		"data_file_directories":  []interface{}{"/var/lib/cassandra/data"},
+		"commitlog_directory":    "/var/lib/cassandra/commitlog",
+		"saved_caches_directory": "/var/lib/cassandra/saved_caches",
+		"hints_directory":        "/var/lib/cassandra/hints",
+	}
+	if IsCassandraAtLeast(version, 4, 1) {
+		cassandraYaml["commitlog_sync_period"] = "10000ms"
+	} else {
+		cassandraYaml["commitlog_sync_period_in_ms"] = 10000
+	}
+	return cassandraYaml
+}
+
+// renderJvmOptions returns the options of the JVM of the bucket of JVM
+// options of the config
+func renderJvmOptions(bucket string, value interface{}) ([]string, error) {
+	options, _ := value.(map[string]interface{})
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var jvmOptions []string
+	for _, name := range names {
+		switch name {
+		case "initial_heap_size":
+			jvmOptions = append(jvmOptions, fmt.Sprintf("-Xms%v", options[name]))
+		case "max_heap_size":
+			jvmOptions = append(jvmOptions, fmt.Sprintf("-Xmx%v", options[name]))
+		case "additional-jvm-opts":
+			additional, ok := options[name].([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s of %s is not a list", name, bucket)
+			}
+			for _, option := range additional {
+				jvmOptions = append(jvmOptions, fmt.Sprint(option))
+			}
+		default:
+			return nil, fmt.Errorf("option %s of %s is not supported", name, bucket)
+		}
+	}
+	return jvmOptions, nil
+}
+
+// wholeNumbers returns the value with the whole numbers parsed from JSON as
+// integers, which yaml would otherwise render in the exponent notation when
+// they are large
+func wholeNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, entry := range v {
+			v[key] = wholeNumbers(entry)
+		}
+	case []interface{}:
+		for i, entry := range v {
+			v[i] = wholeNumbers(entry)
+		}
+	default:
+		if number, ok := wholeNumber(value); ok {
+			return number
+		}
+	}
+	return value
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
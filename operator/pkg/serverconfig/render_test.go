@@ -0,0 +1,55 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestRenderConfig(t *testing.T) {
+	config := `{
+		"cluster-info": {"name": "cluster1", "seeds": "cluster1-seed-service"},
+		"datacenter-info": {"name": "dc1"},
+		"cassandra-yaml": {"num_tokens": 8, "listen_address": "10.0.0.1", "max_value_size_in_mb": 2000000},
+		"jvm-server-options": {"initial_heap_size": "4G", "max_heap_size": "4G", "additional-jvm-opts": ["-Dcassandra.ring_delay_ms=0"]}
+	}`
+
+	rendered, err := RenderConfig("4.0.1", "rack1", config)
+	assert.NoError(t, err)
+	assert.Equal(t, "dc=dc1\nrack=rack1\n", rendered.Files[RackDCFile])
+	assert.Equal(t, []string{"-Dcassandra.ring_delay_ms=0", "-Xms4G", "-Xmx4G"}, rendered.JvmOptions)
+
+	cassandraYaml := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal([]byte(rendered.Files[CassandraYamlFile]), &cassandraYaml))
+	assert.Equal(t, "cluster1", cassandraYaml["cluster_name"])
+	assert.Equal(t, 8, cassandraYaml["num_tokens"])
+	assert.Equal(t, 2000000, cassandraYaml["max_value_size_in_mb"])
+	assert.Equal(t, 10000, cassandraYaml["commitlog_sync_period_in_ms"])
+	assert.NotContains(t, cassandraYaml, "listen_address", "the server-config-init container sets the addresses")
+	assert.Contains(t, rendered.Files[CassandraYamlFile], "seeds: cluster1-seed-service")
+}
+
+func TestRenderConfig_RenamedOptions(t *testing.T) {
+	rendered, err := RenderConfig("4.1.0", "rack1", `{"cassandra-yaml":{"commitlog_sync_period_in_ms":5000}}`)
+	assert.NoError(t, err)
+
+	cassandraYaml := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal([]byte(rendered.Files[CassandraYamlFile]), &cassandraYaml))
+	assert.Equal(t, 5000, cassandraYaml["commitlog_sync_period_in_ms"])
+	assert.NotContains(t, cassandraYaml, "commitlog_sync_period", "only one of the names of the option can be set")
+}
+
+func TestRenderConfig_Unsupported(t *testing.T) {
+	_, err := RenderConfig("4.0.1", "rack1", `{"cassandra-env-sh":{"malloc-arena-max":4}}`)
+	assert.EqualError(t, err, "config cassandra-env-sh is not supported")
+
+	_, err = RenderConfig("4.0.1", "rack1", `{"jvm-server-options":{"garbage_collector":"G1GC"}}`)
+	assert.EqualError(t, err, "option garbage_collector of jvm-server-options is not supported")
+
+	_, err = RenderConfig("3.11.7", "rack1", `{"jvm-server-options":{"max_heap_size":"4G"}}`)
+	assert.EqualError(t, err, "config jvm-server-options is not supported")
+}
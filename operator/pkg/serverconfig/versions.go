@@ -0,0 +1,161 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IsCassandraAtLeast returns whether the Cassandra version, such as 4.1.0, is
+// major.minor or later
+func IsCassandraAtLeast(version string, major, minor int) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	versionMajor, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	versionMinor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	return versionMajor > major || (versionMajor == major && versionMinor >= minor)
+}
+
+// JvmOptionsBuckets returns the sections of the config that the config
+// builder renders into the jvm options files of the Cassandra version: the
+// jvm.options of 3.11, and the jvm-server.options of 4.0 and later with the
+// options of each JDK the version runs on
+func JvmOptionsBuckets(version string) []string {
+	switch {
+	case IsCassandraAtLeast(version, 5, 0):
+		return []string{"jvm-server-options", "jvm11-server-options", "jvm17-server-options"}
+	case IsCassandraAtLeast(version, 4, 0):
+		return []string{"jvm-server-options", "jvm8-server-options", "jvm11-server-options"}
+	default:
+		return []string{"jvm-options"}
+	}
+}
+
+// Kinds of the values of the options of the cassandra.yaml of Cassandra 4.1,
+// which carry their units
+const (
+	durationOption = "duration"
+	dataSizeOption = "data size"
+	flagOption     = "flag"
+)
+
+var optionValueRegexps = map[string]*regexp.Regexp{
+	durationOption: regexp.MustCompile(`^\d+\s*(ns|us|µs|ms|s|m|h|d)$`),
+	dataSizeOption: regexp.MustCompile(`^\d+\s*(B|KiB|MiB|GiB)$`),
+}
+
+// renamedOption is an option of cassandra.yaml that Cassandra 4.1 renamed,
+// and whose former name is still read by 4.1 and 5.0
+type renamedOption struct {
+	name string
+	kind string
+}
+
+// renamedOptions maps the former names of the options of cassandra.yaml the
+// operator's users set the most to their names since Cassandra 4.1
+var renamedOptions = map[string]renamedOption{
+	"read_request_timeout_in_ms":                      {"read_request_timeout", durationOption},
+	"range_request_timeout_in_ms":                     {"range_request_timeout", durationOption},
+	"write_request_timeout_in_ms":                     {"write_request_timeout", durationOption},
+	"counter_write_request_timeout_in_ms":             {"counter_write_request_timeout", durationOption},
+	"cas_contention_timeout_in_ms":                    {"cas_contention_timeout", durationOption},
+	"truncate_request_timeout_in_ms":                  {"truncate_request_timeout", durationOption},
+	"request_timeout_in_ms":                           {"request_timeout", durationOption},
+	"slow_query_log_timeout_in_ms":                    {"slow_query_log_timeout", durationOption},
+	"max_hint_window_in_ms":                           {"max_hint_window", durationOption},
+	"hinted_handoff_throttle_in_kb":                   {"hinted_handoff_throttle", dataSizeOption},
+	"commitlog_segment_size_in_mb":                    {"commitlog_segment_size", dataSizeOption},
+	"commitlog_sync_period_in_ms":                     {"commitlog_sync_period", durationOption},
+	"key_cache_size_in_mb":                            {"key_cache_size", dataSizeOption},
+	"counter_cache_size_in_mb":                        {"counter_cache_size", dataSizeOption},
+	"file_cache_size_in_mb":                           {"file_cache_size", dataSizeOption},
+	"memtable_heap_space_in_mb":                       {"memtable_heap_space", dataSizeOption},
+	"memtable_offheap_space_in_mb":                    {"memtable_offheap_space", dataSizeOption},
+	"column_index_size_in_kb":                         {"column_index_size", dataSizeOption},
+	"batch_size_warn_threshold_in_kb":                 {"batch_size_warn_threshold", dataSizeOption},
+	"batch_size_fail_threshold_in_kb":                 {"batch_size_fail_threshold", dataSizeOption},
+	"compaction_large_partition_warning_threshold_mb": {"compaction_large_partition_warning_threshold", dataSizeOption},
+	"native_transport_max_frame_size_in_mb":           {"native_transport_max_frame_size", dataSizeOption},
+	"gc_warn_threshold_in_ms":                         {"gc_warn_threshold", durationOption},
+	"gc_log_threshold_in_ms":                          {"gc_log_threshold", durationOption},
+	"enable_user_defined_functions":                   {"user_defined_functions_enabled", flagOption},
+	"enable_scripted_user_defined_functions":          {"scripted_user_defined_functions_enabled", flagOption},
+	"enable_materialized_views":                       {"materialized_views_enabled", flagOption},
+	"enable_sasi_indexes":                             {"sasi_indexes_enabled", flagOption},
+	"enable_transient_replication":                    {"transient_replication_enabled", flagOption},
+}
+
+// ValidateConfig checks that the config of a Cassandra datacenter only has
+// the sections and the options of cassandra.yaml the version reads, so that
+// a config is rejected before it is rolled out to nodes that would not
+// start with it. The options Cassandra 4.1 renamed take their units in their
+// values, and cannot be set along with their former names.
+func ValidateConfig(version string, config map[string]interface{}) error {
+	buckets := JvmOptionsBuckets(version)
+	var sections []string
+	for section := range config {
+		if strings.HasPrefix(section, "jvm") && strings.HasSuffix(section, "-options") {
+			sections = append(sections, section)
+		}
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		if !isStringInSlice(section, buckets) {
+			return fmt.Errorf("define config %s with cassandra-%s, which reads %s", section, version, strings.Join(buckets, ", "))
+		}
+	}
+
+	yaml, _ := config["cassandra-yaml"].(map[string]interface{})
+	var formerNames []string
+	for formerName := range renamedOptions {
+		formerNames = append(formerNames, formerName)
+	}
+	sort.Strings(formerNames)
+	for _, formerName := range formerNames {
+		option := renamedOptions[formerName]
+		value, ok := yaml[option.name]
+		if !ok {
+			continue
+		}
+		if !IsCassandraAtLeast(version, 4, 1) {
+			return fmt.Errorf("set %s in cassandra-yaml with cassandra-%s, which only reads %s", option.name, version, formerName)
+		}
+		if _, ok := yaml[formerName]; ok {
+			return fmt.Errorf("set both %s and %s in cassandra-yaml", option.name, formerName)
+		}
+		if valueRegexp, ok := optionValueRegexps[option.kind]; ok {
+			if s, isString := value.(string); !isString || !valueRegexp.MatchString(s) {
+				return fmt.Errorf("set %s in cassandra-yaml to %v, which is not a %s with its unit, such as %s", option.name, value, option.kind, exampleValues[option.kind])
+			}
+		}
+	}
+
+	return nil
+}
+
+var exampleValues = map[string]string{
+	durationOption: "5000ms",
+	dataSizeOption: "64MiB",
+}
+
+func isStringInSlice(s string, values []string) bool {
+	for _, value := range values {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
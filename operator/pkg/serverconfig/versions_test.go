@@ -0,0 +1,92 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package serverconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCassandraAtLeast(t *testing.T) {
+	assert.True(t, IsCassandraAtLeast("4.0.0", 4, 0))
+	assert.True(t, IsCassandraAtLeast("4.1.3", 4, 0))
+	assert.True(t, IsCassandraAtLeast("5.0.0", 4, 1))
+	assert.False(t, IsCassandraAtLeast("3.11.10", 4, 0))
+	assert.False(t, IsCassandraAtLeast("4.0.5", 4, 1))
+	assert.False(t, IsCassandraAtLeast("latest", 4, 0))
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		config  string
+		err     string
+	}{
+		{
+			name:    "Cassandra 4.0 options",
+			version: "4.0.0",
+			config:  `{"jvm-server-options":{"max_heap_size":"4G"},"jvm11-server-options":{"garbage_collector":"G1GC"},"cassandra-yaml":{"read_request_timeout_in_ms":10000}}`,
+		},
+		{
+			name:    "JDK 17 options with Cassandra 4.1",
+			version: "4.1.0",
+			config:  `{"jvm17-server-options":{"garbage_collector":"G1GC"}}`,
+			err:     "define config jvm17-server-options with cassandra-4.1.0, which reads jvm-server-options, jvm8-server-options, jvm11-server-options",
+		},
+		{
+			name:    "JDK 17 options with Cassandra 5.0",
+			version: "5.0.0",
+			config:  `{"jvm17-server-options":{"garbage_collector":"G1GC"}}`,
+		},
+		{
+			name:    "JDK 8 options with Cassandra 5.0",
+			version: "5.0.0",
+			config:  `{"jvm8-server-options":{"garbage_collector":"G1GC"}}`,
+			err:     "define config jvm8-server-options with cassandra-5.0.0, which reads jvm-server-options, jvm11-server-options, jvm17-server-options",
+		},
+		{
+			name:    "Durations with Cassandra 4.1",
+			version: "4.1.0",
+			config:  `{"cassandra-yaml":{"read_request_timeout":"10000ms","commitlog_segment_size":"64MiB","materialized_views_enabled":true}}`,
+		},
+		{
+			name:    "Former names with Cassandra 4.1",
+			version: "4.1.0",
+			config:  `{"cassandra-yaml":{"read_request_timeout_in_ms":10000}}`,
+		},
+		{
+			name:    "Durations with Cassandra 4.0",
+			version: "4.0.0",
+			config:  `{"cassandra-yaml":{"read_request_timeout":"10000ms"}}`,
+			err:     "set read_request_timeout in cassandra-yaml with cassandra-4.0.0, which only reads read_request_timeout_in_ms",
+		},
+		{
+			name:    "Duration without unit",
+			version: "5.0.0",
+			config:  `{"cassandra-yaml":{"read_request_timeout":10000}}`,
+			err:     "set read_request_timeout in cassandra-yaml to 10000, which is not a duration with its unit, such as 5000ms",
+		},
+		{
+			name:    "Both names",
+			version: "4.1.0",
+			config:  `{"cassandra-yaml":{"commitlog_segment_size":"64MiB","commitlog_segment_size_in_mb":64}}`,
+			err:     "set both commitlog_segment_size and commitlog_segment_size_in_mb in cassandra-yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(tt.config), &config))
+			err := ValidateConfig(tt.version, config)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
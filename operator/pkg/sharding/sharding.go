@@ -0,0 +1,81 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package sharding splits the CassandraDatacenters between several
+// deployments of the operator, for installs with more datacenters than a
+// single operator keeps up with. Each datacenter, and the tasks, backups and
+// restores of it, belongs to a single shard.
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+)
+
+// CountEnv is the environment variable holding the number of shards, and
+// IndexEnv the one holding the shard of this operator, from 0 to the count
+// minus one. Without a count, the operator manages every datacenter.
+const (
+	CountEnv = "SHARD_COUNT"
+	IndexEnv = "SHARD_INDEX"
+)
+
+// Shard is the share of the datacenters an operator manages. The nil shard
+// manages all of them.
+type Shard struct {
+	Index int
+	Count int
+}
+
+// FromEnv reads the shard of CountEnv and IndexEnv, and returns nil if the
+// datacenters are not sharded
+func FromEnv() (*Shard, error) {
+	countValue := os.Getenv(CountEnv)
+	if countValue == "" {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(countValue)
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("invalid %s '%s', it must be a positive number", CountEnv, countValue)
+	}
+	indexValue := os.Getenv(IndexEnv)
+	index, err := strconv.Atoi(indexValue)
+	if err != nil || index < 0 || index >= count {
+		return nil, fmt.Errorf("invalid %s '%s', it must be between 0 and %d", IndexEnv, indexValue, count-1)
+	}
+	if count == 1 {
+		return nil, nil
+	}
+	return &Shard{Index: index, Count: count}, nil
+}
+
+// Owns tells whether the datacenter of the namespace belongs to the shard.
+// Datacenters are spread over the shards by the hash of their namespace and
+// name, so that they keep their shard as long as the count does not change.
+func (s *Shard) Owns(namespace, datacenter string) bool {
+	if s == nil {
+		return true
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(namespace + "/" + datacenter))
+	return int(hash.Sum32()%uint32(s.Count)) == s.Index
+}
+
+// LockName returns the name of the leader election lock of the operators of
+// the shard. The shards elect their leaders separately.
+func (s *Shard) LockName(name string) string {
+	if s == nil {
+		return name
+	}
+	return fmt.Sprintf("%s-shard-%d", name, s.Index)
+}
+
+func (s *Shard) String() string {
+	if s == nil {
+		return "all"
+	}
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
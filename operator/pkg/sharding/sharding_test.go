@@ -0,0 +1,59 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package sharding
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromEnv(t *testing.T) {
+	defer os.Unsetenv(CountEnv)
+	defer os.Unsetenv(IndexEnv)
+
+	os.Unsetenv(CountEnv)
+	shard, err := FromEnv()
+	assert.NoError(t, err)
+	assert.Nil(t, shard)
+
+	os.Setenv(CountEnv, "3")
+	os.Setenv(IndexEnv, "2")
+	shard, err = FromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, &Shard{Index: 2, Count: 3}, shard)
+
+	os.Setenv(IndexEnv, "3")
+	_, err = FromEnv()
+	assert.Error(t, err)
+
+	os.Unsetenv(IndexEnv)
+	_, err = FromEnv()
+	assert.Error(t, err)
+
+	os.Setenv(CountEnv, "0")
+	_, err = FromEnv()
+	assert.Error(t, err)
+}
+
+func TestShard_Owns(t *testing.T) {
+	shards := []*Shard{{Index: 0, Count: 3}, {Index: 1, Count: 3}, {Index: 2, Count: 3}}
+	for i := 0; i < 30; i++ {
+		name := fmt.Sprintf("dc%d", i)
+		owners := 0
+		for _, shard := range shards {
+			if shard.Owns("default", name) {
+				owners++
+			}
+		}
+		assert.Equal(t, 1, owners, "datacenter %s belongs to a single shard", name)
+	}
+
+	var all *Shard
+	assert.True(t, all.Owns("default", "dc1"))
+	assert.Equal(t, "cass-operator-lock", all.LockName("cass-operator-lock"))
+	assert.Equal(t, "cass-operator-lock-shard-1", shards[1].LockName("cass-operator-lock"))
+}
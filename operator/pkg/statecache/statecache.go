@@ -0,0 +1,91 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package statecache keeps the http client of the management API that the
+// reconciliations of a CassandraDatacenter with the manual managementApiAuth
+// build from the certificates of its secret, from one reconciliation to the
+// next. The secret itself is read from the informer cache of the manager, but
+// parsing its certificates and opening new connections to the pods on every
+// reconciliation is not free: the client is reused as long as the secret
+// keeps its resourceVersion and the manual config of the datacenter does not
+// change. The entries have a kind, so that other derived state can be kept the
+// same way.
+package statecache
+
+import (
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/k8ssandra/cass-operator/operator/pkg/opmetrics"
+)
+
+// Datacenters is the cache of the CassandraDatacenters managed by the
+// operator
+var Datacenters = New()
+
+type entry struct {
+	versions string
+	value    interface{}
+}
+
+// Cache holds entries of several kinds for each datacenter
+type Cache struct {
+	lock    sync.Mutex
+	entries map[types.NamespacedName]map[string]entry
+}
+
+func New() *Cache {
+	return &Cache{entries: make(map[types.NamespacedName]map[string]entry)}
+}
+
+// Get returns the entry of the kind of the datacenter, if it was put with the
+// same resourceVersions. The lookup is counted as a hit or a miss in the
+// metrics of the operator.
+func (c *Cache) Get(dc types.NamespacedName, kind string, resourceVersions ...string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e, ok := c.entries[dc][kind]
+	hit := ok && versioned(resourceVersions) && e.versions == strings.Join(resourceVersions, ",")
+	opmetrics.ObserveStateCacheLookup(kind, hit)
+	if !hit {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Put stores the entry of the kind of the datacenter, derived from resources
+// of the given resourceVersions. It replaces the previous entry of the kind.
+// Nothing is stored for resources without a resourceVersion, which were not
+// read from the cluster.
+func (c *Cache) Put(dc types.NamespacedName, kind string, value interface{}, resourceVersions ...string) {
+	if !versioned(resourceVersions) {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.entries[dc] == nil {
+		c.entries[dc] = make(map[string]entry)
+	}
+	c.entries[dc][kind] = entry{versions: strings.Join(resourceVersions, ","), value: value}
+}
+
+// Forget removes the entries of a deleted datacenter
+func (c *Cache) Forget(dc types.NamespacedName) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, dc)
+}
+
+func versioned(resourceVersions []string) bool {
+	for _, version := range resourceVersions {
+		if version == "" {
+			return false
+		}
+	}
+	return len(resourceVersions) > 0
+}
@@ -0,0 +1,49 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package statecache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestCache(t *testing.T) {
+	cache := New()
+	dc1 := types.NamespacedName{Namespace: "ns", Name: "dc1"}
+	dc2 := types.NamespacedName{Namespace: "ns", Name: "dc2"}
+
+	_, ok := cache.Get(dc1, "kind", "1")
+	assert.False(t, ok)
+
+	cache.Put(dc1, "kind", "value", "1", "7")
+	value, ok := cache.Get(dc1, "kind", "1", "7")
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	_, ok = cache.Get(dc1, "kind", "2", "7")
+	assert.False(t, ok, "the entry was derived from an older version of a resource")
+	_, ok = cache.Get(dc1, "other", "1", "7")
+	assert.False(t, ok)
+	_, ok = cache.Get(dc2, "kind", "1", "7")
+	assert.False(t, ok, "the entries of a datacenter are its own")
+
+	cache.Put(dc1, "kind", "new value", "2", "7")
+	value, _ = cache.Get(dc1, "kind", "2", "7")
+	assert.Equal(t, "new value", value)
+
+	cache.Forget(dc1)
+	_, ok = cache.Get(dc1, "kind", "2", "7")
+	assert.False(t, ok)
+}
+
+func TestCacheWithoutResourceVersion(t *testing.T) {
+	cache := New()
+	dc := types.NamespacedName{Namespace: "ns", Name: "dc1"}
+
+	cache.Put(dc, "kind", "value", "")
+	_, ok := cache.Get(dc, "kind", "")
+	assert.False(t, ok, "resources that were not read from the cluster are not cached")
+}
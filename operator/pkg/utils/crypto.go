@@ -150,3 +150,97 @@ func rsa2pkcs8(key *rsa.PrivateKey) ([]byte, error) {
 
 	return asn1.Marshal(pkey)
 }
+
+// GenerateTruststore returns a JKS truststore that trusts the certificates
+// signed by the CA
+func GenerateTruststore(ca *corev1.Secret, password string) ([]byte, error) {
+	caCertBytes, _, _, err := prepare_ca(ca)
+	if err != nil {
+		return nil, err
+	}
+
+	store := keystore.KeyStore{
+		"ca": &keystore.TrustedCertificateEntry{
+			Entry: keystore.Entry{CreationDate: time.Now()},
+			Certificate: keystore.Certificate{
+				Type:    "X509",
+				Content: caCertBytes,
+			},
+		},
+	}
+	buffer := bytes.NewBufferString("")
+	err = keystore.Encode(buffer, store, []byte(password))
+	return buffer.Bytes(), err
+}
+
+// ConvertToJKS returns a JKS keystore with the private key and the chain of
+// certificates of a PEM encoded certificate, such as the tls.key and tls.crt
+// of the secret of a cert-manager Certificate
+func ConvertToJKS(certPEM, keyPEM []byte, alias, password string) ([]byte, error) {
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("no PEM encoded private key found")
+	}
+	keyBytes := keyBlock.Bytes
+	if _, err := x509.ParsePKCS8PrivateKey(keyBytes); err != nil {
+		var key interface{}
+		if key, err = x509.ParsePKCS1PrivateKey(keyBytes); err != nil {
+			if key, err = x509.ParseECPrivateKey(keyBytes); err != nil {
+				return nil, fmt.Errorf("failed to parse the private key: %w", err)
+			}
+		}
+		if keyBytes, err = x509.MarshalPKCS8PrivateKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	chain := pemCertificates(certPEM)
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+
+	store := keystore.KeyStore{
+		alias: &keystore.PrivateKeyEntry{
+			Entry:     keystore.Entry{CreationDate: time.Now()},
+			PrivKey:   keyBytes,
+			CertChain: chain,
+		},
+	}
+	buffer := bytes.NewBufferString("")
+	err := keystore.Encode(buffer, store, []byte(password))
+	return buffer.Bytes(), err
+}
+
+// ConvertToTruststore returns a JKS truststore that trusts the PEM encoded
+// certificates, such as the ca.crt of the secret of a cert-manager Certificate
+func ConvertToTruststore(caPEM []byte, password string) ([]byte, error) {
+	certificates := pemCertificates(caPEM)
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+
+	store := keystore.KeyStore{}
+	for i, certificate := range certificates {
+		store[fmt.Sprintf("ca-%d", i)] = &keystore.TrustedCertificateEntry{
+			Entry:       keystore.Entry{CreationDate: time.Now()},
+			Certificate: certificate,
+		}
+	}
+	buffer := bytes.NewBufferString("")
+	err := keystore.Encode(buffer, store, []byte(password))
+	return buffer.Bytes(), err
+}
+
+func pemCertificates(data []byte) []keystore.Certificate {
+	var certificates []keystore.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certificates
+		}
+		if block.Type == "CERTIFICATE" {
+			certificates = append(certificates, keystore.Certificate{Type: "X509", Content: block.Bytes})
+		}
+	}
+}
@@ -87,3 +87,48 @@ func Test_GetJKS(t *testing.T) {
 
 	ioutil.WriteFile("test-jks", jks, 0644)
 }
+
+func Test_GenerateTruststore(t *testing.T) {
+	pem_key, cert, err := GetNewCAandKey("someclusterca", "somenamespace")
+	if err != nil {
+		t.Errorf("Got an error:: %e", err)
+	}
+	truststore, err := GenerateTruststore(&corev1.Secret{
+		Data: map[string][]byte{
+			"cert": []byte(cert),
+			"key":  []byte(pem_key),
+		},
+	}, "somepassword")
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+	if len(truststore) == 0 {
+		t.Errorf("truststore blob too small")
+	}
+}
+
+func Test_ConvertToJKS(t *testing.T) {
+	pem_key, cert, err := GetNewCAandKey("someclusterca", "somenamespace")
+	if err != nil {
+		t.Errorf("Got an error:: %e", err)
+	}
+	jks, err := ConvertToJKS([]byte(cert), []byte(pem_key), "somepodname", "somepassword")
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+	if len(jks) == 0 {
+		t.Errorf("JKS blob too small")
+	}
+
+	truststore, err := ConvertToTruststore([]byte(cert), "somepassword")
+	if err != nil {
+		t.Errorf("Got an error: %e", err)
+	}
+	if len(truststore) == 0 {
+		t.Errorf("truststore blob too small")
+	}
+
+	if _, err = ConvertToJKS([]byte(cert), []byte("not a key"), "somepodname", "somepassword"); err == nil {
+		t.Errorf("Expected an error for an invalid key")
+	}
+}
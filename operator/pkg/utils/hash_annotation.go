@@ -36,6 +36,20 @@ func AddHashAnnotation(r Annotated) {
 	r.SetAnnotations(m)
 }
 
+// UpdateHashAnnotation computes the hash annotation of a resource again,
+// after it was changed
+func UpdateHashAnnotation(r Annotated) {
+	m := r.GetAnnotations()
+	delete(m, resourceHashAnnotationKey)
+	r.SetAnnotations(m)
+	AddHashAnnotation(r)
+}
+
+// DeepHash returns the hash of an object, encoded like the hash annotation
+func DeepHash(obj interface{}) string {
+	return deepHashString(obj)
+}
+
 func deepHashString(obj interface{}) string {
 	hasher := sha256.New()
 	hash.DeepHashObject(hasher, obj)
@@ -93,6 +93,17 @@ func IsPodUnschedulable(pod *corev1.Pod) bool {
 	return false
 }
 
+// IsCassandraContainerReady reports whether the cassandra container of the pod
+// is passing its readiness probe
+func IsCassandraContainerReady(pod *corev1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "cassandra" {
+			return status.Ready
+		}
+	}
+	return false
+}
+
 func GetPodNameSet(pods []*corev1.Pod) StringSet {
 	names := StringSet{}
 	for _, pod := range pods {
@@ -4,7 +4,9 @@
 package utils
 
 import (
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"reflect"
 	"math"
@@ -15,6 +17,54 @@ func IsPSPEnabled() bool {
 	return exists && "true" == strings.TrimSpace(value)
 }
 
+// ServerSideApplyEnv is the environment variable that makes the operator send
+// the resources it creates and updates with server-side apply when "true"
+const ServerSideApplyEnv = "SERVER_SIDE_APPLY"
+
+func IsServerSideApplyEnabled() bool {
+	value, exists := os.LookupEnv(ServerSideApplyEnv)
+	return exists && "true" == strings.TrimSpace(value)
+}
+
+// MaxConcurrentReconcilesEnv is the environment variable holding how many
+// datacenters the datacenter controller of the operator reconciles at once
+const MaxConcurrentReconcilesEnv = "MAX_CONCURRENT_RECONCILES"
+
+// GetMaxConcurrentReconciles returns the MaxConcurrentReconciles of the
+// datacenter controller from MaxConcurrentReconcilesEnv, 1 when it is not set
+func GetMaxConcurrentReconciles() (int, error) {
+	value := strings.TrimSpace(os.Getenv(MaxConcurrentReconcilesEnv))
+	if value == "" {
+		return 1, nil
+	}
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("invalid %s '%s', it must be a positive number", MaxConcurrentReconcilesEnv, value)
+	}
+	return count, nil
+}
+
+// ManagementApiWorkersEnv is the environment variable holding how many pods
+// of a datacenter the reconciliation calls the management API of at once when
+// it polls them all
+const ManagementApiWorkersEnv = "MANAGEMENT_API_WORKERS"
+
+// GetManagementApiWorkers returns the number of pods of ManagementApiWorkersEnv,
+// 0 when it is not set so that the client picks its default
+func GetManagementApiWorkers() (int, error) {
+	value := strings.TrimSpace(os.Getenv(ManagementApiWorkersEnv))
+	if value == "" {
+		return 0, nil
+	}
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("invalid %s '%s', it must be a positive number", ManagementApiWorkersEnv, value)
+	}
+	return count, nil
+}
+
 func RangeInt(min, max, step int) []int {
 	size := int(math.Ceil(float64((max - min)) / float64(step)))
 	l := make([]int, size)
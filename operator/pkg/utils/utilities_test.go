@@ -4,6 +4,7 @@
 package utils
 
 import (
+	"os"
 	"reflect"
 	"testing"
 
@@ -16,6 +17,42 @@ func test_RangeInt(t *testing.T) {
 	assert.Equal(t, []int{5, 8}, RangeInt(5, 10, 3))
 }
 
+func TestGetMaxConcurrentReconciles(t *testing.T) {
+	defer os.Unsetenv(MaxConcurrentReconcilesEnv)
+
+	os.Unsetenv(MaxConcurrentReconcilesEnv)
+	count, err := GetMaxConcurrentReconciles()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	os.Setenv(MaxConcurrentReconcilesEnv, "8")
+	count, err = GetMaxConcurrentReconciles()
+	assert.NoError(t, err)
+	assert.Equal(t, 8, count)
+
+	os.Setenv(MaxConcurrentReconcilesEnv, "0")
+	_, err = GetMaxConcurrentReconciles()
+	assert.Error(t, err)
+}
+
+func TestGetManagementApiWorkers(t *testing.T) {
+	defer os.Unsetenv(ManagementApiWorkersEnv)
+
+	os.Unsetenv(ManagementApiWorkersEnv)
+	count, err := GetManagementApiWorkers()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count, "the client should pick its default")
+
+	os.Setenv(ManagementApiWorkersEnv, "25")
+	count, err = GetManagementApiWorkers()
+	assert.NoError(t, err)
+	assert.Equal(t, 25, count)
+
+	os.Setenv(ManagementApiWorkersEnv, "many")
+	_, err = GetManagementApiWorkers()
+	assert.Error(t, err)
+}
+
 type foo struct {
 	a int
 	b int
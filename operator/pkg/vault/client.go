@@ -0,0 +1,261 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAuthPath is the mount path of the Kubernetes auth method of Vault
+const DefaultAuthPath = "kubernetes"
+
+// DefaultTimeout is the timeout of the requests to Vault
+const DefaultTimeout = 30 * time.Second
+
+// DefaultTokenFile is the token of the service account of the operator,
+// which it logs in to Vault with
+var DefaultTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// StatusError is returned when Vault answers with a status other than 2xx
+type StatusError struct {
+	Path       string
+	StatusCode int
+	Errors     []string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("vault returned status %d for %s: %s", e.StatusCode, e.Path, strings.Join(e.Errors, ", "))
+}
+
+// IsNotFound returns whether err is a StatusError for a path of Vault with no
+// secret
+func IsNotFound(err error) bool {
+	statusErr, ok := err.(*StatusError)
+	return ok && statusErr.StatusCode == http.StatusNotFound
+}
+
+// Client reads secrets of Vault. It logs in again when its token expires.
+type Client struct {
+	HTTPClient *http.Client
+	// Address is the URL of Vault, such as https://vault.vault:8200
+	Address string
+	// AuthPath is the mount path of the Kubernetes auth method, DefaultAuthPath
+	// when empty
+	AuthPath string
+	// Role is the role of the Kubernetes auth method to log in with
+	Role string
+	// TokenFile is the token of the service account to log in with,
+	// DefaultTokenFile when empty
+	TokenFile string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = map[string]*Client{}
+)
+
+// ClientFor returns the client of a role of Vault, shared by the reconciles
+// so that they reuse its token. The CA that signed the certificate of Vault
+// is read from the file of the VAULT_CACERT environment variable, when set.
+func ClientFor(address, authPath, role string) (*Client, error) {
+	if authPath == "" {
+		authPath = DefaultAuthPath
+	}
+	key := strings.Join([]string{address, authPath, role}, "|")
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if client, ok := clients[key]; ok {
+		return client, nil
+	}
+
+	httpClient, err := newHTTPClient(os.Getenv("VAULT_CACERT"))
+	if err != nil {
+		return nil, err
+	}
+	client := &Client{
+		HTTPClient: httpClient,
+		Address:    address,
+		AuthPath:   authPath,
+		Role:       role,
+	}
+	clients[key] = client
+	return client, nil
+}
+
+func newHTTPClient(caFile string) (*http.Client, error) {
+	if caFile == "" {
+		return &http.Client{Timeout: DefaultTimeout}, nil
+	}
+
+	ca, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA of vault: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in %s", caFile)
+	}
+	return &http.Client{
+		Timeout: DefaultTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}, nil
+}
+
+// ReadSecret returns the data of the KV secret at path, such as
+// secret/data/cassandra for version 2 of the KV secrets engine
+func (c *Client) ReadSecret(ctx context.Context, path string) (map[string]string, error) {
+	path = strings.Trim(path, "/")
+	token, err := c.getToken(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	err = c.do(ctx, http.MethodGet, path, token, nil, &response)
+	if statusErr, ok := err.(*StatusError); ok && statusErr.StatusCode == http.StatusForbidden {
+		// The token might have been revoked before it expired
+		if token, err = c.getToken(ctx, true); err != nil {
+			return nil, err
+		}
+		err = c.do(ctx, http.MethodGet, path, token, nil, &response)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := response.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			// Version 2 of the KV secrets engine
+			data = nested
+		}
+	}
+
+	values := map[string]string{}
+	for key, value := range data {
+		if s, ok := value.(string); ok {
+			values[key] = s
+			continue
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = string(b)
+	}
+	return values, nil
+}
+
+// getToken returns the token of the client, logging in when it has none or
+// when it is about to expire
+func (c *Client) getToken(ctx context.Context, renew bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !renew && c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	tokenFile := c.TokenFile
+	if tokenFile == "" {
+		tokenFile = DefaultTokenFile
+	}
+	jwt, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the token of the service account: %w", err)
+	}
+
+	authPath := c.AuthPath
+	if authPath == "" {
+		authPath = DefaultAuthPath
+	}
+	body, err := json.Marshal(map[string]string{
+		"role": c.Role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	loginPath := fmt.Sprintf("auth/%s/login", strings.Trim(authPath, "/"))
+	if err := c.do(ctx, http.MethodPost, loginPath, "", body, &response); err != nil {
+		return "", err
+	}
+	if response.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault returned no token for role %s", c.Role)
+	}
+
+	c.token = response.Auth.ClientToken
+	if lease := time.Duration(response.Auth.LeaseDuration) * time.Second; lease > 0 {
+		// Log in again before the token expires rather than renewing it,
+		// so that a maximum TTL of the role does not matter
+		c.tokenExpiry = time.Now().Add(lease - lease/10)
+	} else {
+		c.tokenExpiry = time.Now().Add(24 * time.Hour)
+	}
+	return c.token, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path, token string, body []byte, response interface{}) error {
+	url := fmt.Sprintf("%s/v1/%s", strings.TrimRight(c.Address, "/"), path)
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var errorResponse struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(b, &errorResponse)
+		return &StatusError{Path: path, StatusCode: res.StatusCode, Errors: errorResponse.Errors}
+	}
+	return json.Unmarshal(b, response)
+}
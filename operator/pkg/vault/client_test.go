@@ -0,0 +1,138 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient returns a client of the Vault served by handler, which
+// logs in with the role cass-operator and the token jwt
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, ioutil.WriteFile(tokenFile, []byte("jwt\n"), 0600))
+
+	return &Client{
+		HTTPClient: server.Client(),
+		Address:    server.URL,
+		Role:       "cass-operator",
+		TokenFile:  tokenFile,
+	}
+}
+
+func login(t *testing.T, w http.ResponseWriter, r *http.Request, token string) {
+	assert.Equal(t, http.MethodPost, r.Method)
+	var body map[string]string
+	assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	assert.Equal(t, "cass-operator", body["role"])
+	assert.Equal(t, "jwt", body["jwt"])
+	_, _ = w.Write([]byte(`{"auth": {"client_token": "` + token + `", "lease_duration": 3600}}`))
+}
+
+func TestReadSecret(t *testing.T) {
+	logins := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			logins++
+			login(t, w, r, "token")
+		case "/v1/secret/data/cassandra":
+			assert.Equal(t, http.MethodGet, r.Method)
+			assert.Equal(t, "token", r.Header.Get("X-Vault-Token"))
+			_, _ = w.Write([]byte(`{"data": {"data": {"username": "admin", "password": "secret", "config": {"cassandra-yaml": {}}}, "metadata": {"version": 2}}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	for i := 0; i < 2; i++ {
+		secret, err := client.ReadSecret(context.Background(), "/secret/data/cassandra")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"username": "admin",
+			"password": "secret",
+			"config":   `{"cassandra-yaml":{}}`,
+		}, secret)
+	}
+	assert.Equal(t, 1, logins, "the token should be reused")
+}
+
+func TestReadSecret_KVVersion1(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/approle-k8s/login":
+			login(t, w, r, "token")
+		case "/v1/kv/cassandra":
+			_, _ = w.Write([]byte(`{"data": {"username": "admin", "data": "value"}, "lease_duration": 2764800}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+	client.AuthPath = "approle-k8s"
+
+	secret, err := client.ReadSecret(context.Background(), "kv/cassandra")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"username": "admin", "data": "value"}, secret)
+}
+
+func TestReadSecret_RevokedToken(t *testing.T) {
+	logins := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			logins++
+			login(t, w, r, "new-token")
+		case "/v1/secret/data/cassandra":
+			if r.Header.Get("X-Vault-Token") != "new-token" {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data": {"data": {"username": "admin"}, "metadata": {}}}`))
+		}
+	})
+	client.token = "revoked-token"
+	client.tokenExpiry = time.Now().Add(time.Hour)
+
+	secret, err := client.ReadSecret(context.Background(), "secret/data/cassandra")
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", secret["username"])
+	assert.Equal(t, 1, logins)
+}
+
+func TestReadSecret_NotFound(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/kubernetes/login" {
+			login(t, w, r, "token")
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors": []}`))
+	})
+
+	_, err := client.ReadSecret(context.Background(), "secret/data/missing")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestReadSecret_LoginFailure(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors": ["invalid role name \"cass-operator\""]}`))
+	})
+
+	_, err := client.ReadSecret(context.Background(), "secret/data/cassandra")
+	assert.EqualError(t, err, `vault returned status 400 for auth/kubernetes/login: invalid role name "cass-operator"`)
+}
@@ -0,0 +1,17 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package vault reads secrets of HashiCorp Vault for the operator, which logs
+// in with the Kubernetes auth method of Vault and the token of its service
+// account:
+//
+//	client, err := vault.ClientFor("https://vault.vault:8200", "kubernetes", "cass-operator")
+//	secret, err := client.ReadSecret(ctx, "secret/data/cassandra/superuser")
+//
+// Both versions of the KV secrets engine are supported: the data of a
+// version 2 secret is returned without its metadata. Values that are not
+// strings are returned as JSON.
+//
+// It only relies on the HTTP API of Vault, so that the operator does not
+// depend on the Vault libraries.
+package vault
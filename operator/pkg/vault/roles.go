@@ -0,0 +1,73 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package vault
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// AddressEnv is the environment variable of the operator with the address of
+// Vault, such as https://vault.vault:8200
+const AddressEnv = "VAULT_ADDR"
+
+// RolesEnv is the environment variable of the operator with the roles of the
+// Kubernetes auth method the datacenters of each namespace may log in with,
+// as comma separated <namespace>=<role> pairs, such as
+// team-a=cassandra-team-a,team-b=cassandra-team-b. The namespace * stands for
+// every namespace.
+const RolesEnv = "VAULT_ROLES"
+
+// Address returns the address of Vault the operator is configured with, empty
+// when it is not
+func Address() string {
+	return os.Getenv(AddressEnv)
+}
+
+// AllowedRoles returns the roles the datacenters of the namespace may log in
+// with, according to RolesEnv
+func AllowedRoles(namespace string) ([]string, error) {
+	var roles []string
+	for _, pair := range strings.Split(os.Getenv(RolesEnv), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid %s entry '%s', it must be <namespace>=<role>", RolesEnv, pair)
+		}
+		if parts[0] == namespace || parts[0] == "*" {
+			roles = append(roles, parts[1])
+		}
+	}
+	return roles, nil
+}
+
+// RoleFor returns the role a datacenter of the namespace logs in with: the
+// requested one when it is allowed, or the only role allowed for the
+// namespace when none is requested.
+func RoleFor(namespace, requested string) (string, error) {
+	roles, err := AllowedRoles(namespace)
+	if err != nil {
+		return "", err
+	}
+	if len(roles) == 0 {
+		return "", fmt.Errorf("no role of vault is allowed for namespace %s by the %s environment variable of the operator", namespace, RolesEnv)
+	}
+
+	if requested == "" {
+		if len(roles) > 1 {
+			return "", fmt.Errorf("several roles of vault are allowed for namespace %s, pick one of %s", namespace, strings.Join(roles, ", "))
+		}
+		return roles[0], nil
+	}
+	for _, role := range roles {
+		if role == requested {
+			return role, nil
+		}
+	}
+	return "", fmt.Errorf("role %s of vault is not allowed for namespace %s by the %s environment variable of the operator", requested, namespace, RolesEnv)
+}
@@ -0,0 +1,42 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package vault
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleFor(t *testing.T) {
+	os.Setenv(RolesEnv, "team-a=cassandra-team-a, team-b=cassandra-team-b,*=shared")
+	defer os.Unsetenv(RolesEnv)
+
+	roles, err := AllowedRoles("team-a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cassandra-team-a", "shared"}, roles)
+
+	role, err := RoleFor("team-a", "cassandra-team-a")
+	assert.NoError(t, err)
+	assert.Equal(t, "cassandra-team-a", role)
+
+	_, err = RoleFor("team-a", "cassandra-team-b")
+	assert.Error(t, err, "a namespace cannot log in with the role of another one")
+
+	_, err = RoleFor("team-a", "")
+	assert.Error(t, err, "the role is ambiguous")
+
+	role, err = RoleFor("team-c", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "shared", role)
+
+	os.Setenv(RolesEnv, "team-a")
+	_, err = RoleFor("team-a", "")
+	assert.Error(t, err)
+
+	os.Unsetenv(RolesEnv)
+	_, err = RoleFor("team-a", "cassandra-team-a")
+	assert.Error(t, err, "no role is allowed by default")
+}
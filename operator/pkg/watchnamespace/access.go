@@ -0,0 +1,104 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package watchnamespace
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// How long the result of checking a namespace is reused
+const accessCheckTTL = 5 * time.Minute
+
+// The permissions the operator needs in every namespace it manages
+// CassandraDatacenters in
+var requiredPermissions = []authorizationv1.ResourceAttributes{
+	{Group: "cassandra.datastax.com", Resource: "cassandradatacenters", Verb: "update"},
+	{Group: "cassandra.datastax.com", Resource: "cassandradatacenters", Subresource: "status", Verb: "patch"},
+	{Group: "apps", Resource: "statefulsets", Verb: "create"},
+	{Group: "apps", Resource: "statefulsets", Verb: "update"},
+	{Group: "", Resource: "services", Verb: "create"},
+	{Group: "", Resource: "secrets", Verb: "create"},
+	{Group: "", Resource: "pods", Verb: "delete"},
+	{Group: "", Resource: "persistentvolumeclaims", Verb: "delete"},
+	{Group: "policy", Resource: "poddisruptionbudgets", Verb: "create"},
+}
+
+type accessCheck struct {
+	missing   []string
+	checkedAt time.Time
+}
+
+// AccessChecker checks that the operator has the permissions it needs in a
+// namespace, with SelfSubjectAccessReviews. The results are cached for a few
+// minutes per namespace.
+type AccessChecker struct {
+	client client.Client
+
+	lock   sync.Mutex
+	checks map[string]accessCheck
+}
+
+// NewAccessChecker creates an access checker that reviews the permissions of
+// the client
+func NewAccessChecker(client client.Client) *AccessChecker {
+	return &AccessChecker{
+		client: client,
+		checks: map[string]accessCheck{},
+	}
+}
+
+// MissingPermissions returns the permissions the operator lacks in the
+// namespace, as "verb group/resource" strings
+func (c *AccessChecker) MissingPermissions(ctx context.Context, namespace string) ([]string, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	c.lock.Lock()
+	check, found := c.checks[namespace]
+	c.lock.Unlock()
+	if found && time.Since(check.checkedAt) < accessCheckTTL {
+		return check.missing, nil
+	}
+
+	var missing []string
+	for _, permission := range requiredPermissions {
+		attributes := permission
+		attributes.Namespace = namespace
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &attributes,
+			},
+		}
+		if err := c.client.Create(ctx, review); err != nil {
+			return nil, err
+		}
+		if !review.Status.Allowed {
+			missing = append(missing, describePermission(permission))
+		}
+	}
+
+	c.lock.Lock()
+	c.checks[namespace] = accessCheck{missing: missing, checkedAt: time.Now()}
+	c.lock.Unlock()
+
+	return missing, nil
+}
+
+func describePermission(permission authorizationv1.ResourceAttributes) string {
+	resource := permission.Resource
+	if permission.Subresource != "" {
+		resource = resource + "/" + permission.Subresource
+	}
+	if permission.Group != "" {
+		resource = permission.Group + "/" + resource
+	}
+	return fmt.Sprintf("%s %s", permission.Verb, resource)
+}
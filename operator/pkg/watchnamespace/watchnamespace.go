@@ -0,0 +1,80 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+// Package watchnamespace decides which namespaces the operator manages
+// CassandraDatacenters in, when it watches more than one namespace, and checks
+// that the operator has the permissions it needs in those namespaces.
+package watchnamespace
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SelectorEnv is the environment variable holding the label selector of the
+// namespaces to manage, e.g. "cassandra=enabled". It narrows down the
+// namespaces of WATCH_NAMESPACE, which is usually set to all namespaces
+// when a selector is used.
+const SelectorEnv = "WATCH_NAMESPACE_SELECTOR"
+
+// GetSelector parses the label selector of SelectorEnv, and returns nil if it
+// is not set
+func GetSelector() (labels.Selector, error) {
+	value := os.Getenv(SelectorEnv)
+	if value == "" {
+		return nil, nil
+	}
+
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s '%s': %v", SelectorEnv, value, err)
+	}
+	return selector, nil
+}
+
+// Filter tells whether the operator manages the CassandraDatacenters of a
+// namespace
+type Filter struct {
+	client   client.Client
+	selector labels.Selector
+}
+
+// NewFilter creates a filter for the given label selector. All namespaces are
+// managed when the selector is nil.
+func NewFilter(client client.Client, selector labels.Selector) *Filter {
+	return &Filter{
+		client:   client,
+		selector: selector,
+	}
+}
+
+// HasSelector returns whether namespaces are selected by their labels
+func (f *Filter) HasSelector() bool {
+	return f != nil && f.selector != nil
+}
+
+// IsManaged returns whether the labels of the namespace match the selector
+func (f *Filter) IsManaged(ctx context.Context, namespace string) (bool, error) {
+	if !f.HasSelector() {
+		return true, nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := f.client.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return false, err
+	}
+
+	return f.selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// Matches returns whether the labels of an already fetched namespace match
+// the selector
+func (f *Filter) Matches(namespaceLabels map[string]string) bool {
+	return !f.HasSelector() || f.selector.Matches(labels.Set(namespaceLabels))
+}
@@ -0,0 +1,76 @@
+// Copyright DataStax, Inc.
+// Please see the included license file for details.
+
+package watchnamespace
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetSelector(t *testing.T) {
+	defer os.Unsetenv(SelectorEnv)
+
+	os.Unsetenv(SelectorEnv)
+	selector, err := GetSelector()
+	assert.NoError(t, err)
+	assert.Nil(t, selector)
+
+	os.Setenv(SelectorEnv, "cassandra=enabled")
+	selector, err = GetSelector()
+	assert.NoError(t, err)
+	assert.True(t, selector.Matches(labels.Set{"cassandra": "enabled"}))
+
+	os.Setenv(SelectorEnv, "cassandra==")
+	_, err = GetSelector()
+	assert.Error(t, err)
+}
+
+func TestFilter_IsManaged(t *testing.T) {
+	fakeClient := fake.NewFakeClient(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "selected", Labels: map[string]string{"cassandra": "enabled"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+	)
+	ctx := context.Background()
+
+	filter := NewFilter(fakeClient, labels.SelectorFromSet(labels.Set{"cassandra": "enabled"}))
+	managed, err := filter.IsManaged(ctx, "selected")
+	assert.NoError(t, err)
+	assert.True(t, managed)
+
+	managed, err = filter.IsManaged(ctx, "other")
+	assert.NoError(t, err)
+	assert.False(t, managed)
+
+	_, err = filter.IsManaged(ctx, "missing")
+	assert.Error(t, err)
+
+	var noFilter *Filter
+	managed, err = noFilter.IsManaged(ctx, "other")
+	assert.NoError(t, err)
+	assert.True(t, managed)
+	assert.True(t, NewFilter(fakeClient, nil).Matches(nil))
+}
+
+func TestAccessChecker_MissingPermissions(t *testing.T) {
+	checker := NewAccessChecker(fake.NewFakeClient())
+
+	// The fake client does not authorize anything
+	missing, err := checker.MissingPermissions(context.Background(), "default")
+	assert.NoError(t, err)
+	assert.Len(t, missing, len(requiredPermissions))
+	assert.Contains(t, missing, "patch cassandra.datastax.com/cassandradatacenters/status")
+	assert.Contains(t, missing, "delete pods")
+
+	var noChecker *AccessChecker
+	missing, err = noChecker.MissingPermissions(context.Background(), "default")
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+}